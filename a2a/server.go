@@ -0,0 +1,685 @@
+// Package a2a serves agents over the Agent2Agent (A2A) protocol, so other A2A-compatible
+// agents can discover them through their Agent Card, send them tasks and stream their progress.
+//
+// The server implements the JSON-RPC transport: message/send, message/stream, tasks/get,
+// tasks/cancel and tasks/resubscribe. Every task runs the agent with a stream runner; messages
+// sharing a context ID continue the same conversation.
+//
+//	server := a2a.NewServer(a2a.AgentCard{Name: "support", URL: "https://example.com/a2a"}, runner)
+//	http.ListenAndServe(":8080", server.Handler())
+package a2a
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/easyagent-dev/agent"
+	"github.com/easyagent-dev/llm"
+	"github.com/google/uuid"
+)
+
+// DefaultMaxTasks is the number of finished tasks kept in memory by default
+const DefaultMaxTasks = 1000
+
+// DefaultMaxIterations is the iteration limit of requests created by the default request factory
+const DefaultMaxIterations = 10
+
+// OutputArtifactName is the name of the artifact holding the output of a task
+const OutputArtifactName = "output"
+
+// RequestFactory creates the agent request running a task from the conversation of its context
+type RequestFactory func(ctx context.Context, task *Task, messages []*llm.ModelMessage) (*agent.AgentRequest, error)
+
+// DefaultRequestFactory creates a request allowing plain text answers, identified by the task ID
+func DefaultRequestFactory(ctx context.Context, task *Task, messages []*llm.ModelMessage) (*agent.AgentRequest, error) {
+	return &agent.AgentRequest{
+		Messages:      messages,
+		MaxIterations: DefaultMaxIterations,
+		DirectAnswer:  true,
+		RunID:         task.ID,
+		Metadata: map[string]string{
+			"a2a_task_id":    task.ID,
+			"a2a_context_id": task.ContextID,
+		},
+	}, nil
+}
+
+// Option is a functional option for configuring the server
+type Option func(*Server)
+
+// WithRequestFactory sets the factory creating the agent requests of tasks, DefaultRequestFactory by default
+func WithRequestFactory(factory RequestFactory) Option {
+	return func(s *Server) {
+		s.newRequest = factory
+	}
+}
+
+// WithMaxTasks sets how many finished tasks are kept in memory, the oldest are dropped first
+func WithMaxTasks(n int) Option {
+	return func(s *Server) {
+		s.maxTasks = n
+	}
+}
+
+// WithLogger sets the logger receiving server diagnostics
+func WithLogger(logger agent.Logger) Option {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// taskState is a task and its execution
+type taskState struct {
+	task *Task
+
+	// messages is the conversation of the task context, including the agent reply once completed
+	messages []*llm.ModelMessage
+
+	cancel      context.CancelFunc
+	cancelled   bool
+	subscribers map[chan any]struct{}
+	done        chan struct{}
+}
+
+// Server runs tasks with a stream runner and serves them over A2A.
+// This type is safe for concurrent use.
+type Server struct {
+	card       AgentCard
+	runner     agent.StreamRunner
+	newRequest RequestFactory
+	maxTasks   int
+	logger     agent.Logger
+
+	mu    sync.Mutex
+	tasks map[string]*taskState
+
+	// finished holds the IDs of finished tasks, oldest first
+	finished []string
+
+	// contexts holds the ID of the latest task of every context
+	contexts map[string]string
+}
+
+// NewServer creates a server for the agent described by card, running tasks with runner.
+// Protocol fields of the card left empty are filled in.
+func NewServer(card AgentCard, runner agent.StreamRunner, opts ...Option) *Server {
+	if card.ProtocolVersion == "" {
+		card.ProtocolVersion = ProtocolVersion
+	}
+	if card.PreferredTransport == "" {
+		card.PreferredTransport = "JSONRPC"
+	}
+	if card.Version == "" {
+		card.Version = "1.0.0"
+	}
+	if len(card.DefaultInputModes) == 0 {
+		card.DefaultInputModes = []string{"text/plain", "application/json"}
+	}
+	if len(card.DefaultOutputModes) == 0 {
+		card.DefaultOutputModes = []string{"text/plain", "application/json"}
+	}
+	if card.Skills == nil {
+		card.Skills = []AgentSkill{}
+	}
+	card.Capabilities.Streaming = true
+
+	s := &Server{
+		card:       card,
+		runner:     runner,
+		newRequest: DefaultRequestFactory,
+		maxTasks:   DefaultMaxTasks,
+		logger:     agent.NoOpLogger{},
+		tasks:      make(map[string]*taskState),
+		contexts:   make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Card returns the Agent Card served by the server
+func (s *Server) Card() AgentCard {
+	return s.card
+}
+
+// Handler returns the HTTP handler serving the Agent Card and the JSON-RPC endpoint
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/agent-card.json", s.handleCard)
+	// Path of earlier protocol versions
+	mux.HandleFunc("GET /.well-known/agent.json", s.handleCard)
+	mux.HandleFunc("POST /", s.handleRPC)
+	return mux
+}
+
+func (s *Server) handleCard(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.card); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleRPC dispatches a JSON-RPC request. Streaming methods respond with server-sent events.
+func (s *Server) handleRPC(w http.ResponseWriter, r *http.Request) {
+	var req rpcRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeResponse(w, nil, nil, &Error{Code: CodeParseError, Message: "invalid JSON: " + err.Error()})
+		return
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		writeResponse(w, req.ID, nil, &Error{Code: CodeInvalidRequest, Message: "invalid JSON-RPC request"})
+		return
+	}
+
+	switch req.Method {
+	case "message/send":
+		var params MessageSendParams
+		if err := decodeParams(req.Params, &params); err != nil {
+			writeResponse(w, req.ID, nil, err)
+			return
+		}
+		task, err := s.SendMessage(r.Context(), &params)
+		writeResponse(w, req.ID, task, err)
+	case "message/stream":
+		var params MessageSendParams
+		if err := decodeParams(req.Params, &params); err != nil {
+			writeResponse(w, req.ID, nil, err)
+			return
+		}
+		state, err := s.startTask(&params)
+		if err != nil {
+			writeResponse(w, req.ID, nil, err)
+			return
+		}
+		s.stream(w, r, req.ID, state)
+	case "tasks/get":
+		var params TaskQueryParams
+		if err := decodeParams(req.Params, &params); err != nil {
+			writeResponse(w, req.ID, nil, err)
+			return
+		}
+		task, err := s.GetTask(params.ID, params.HistoryLength)
+		writeResponse(w, req.ID, task, err)
+	case "tasks/cancel":
+		var params TaskIDParams
+		if err := decodeParams(req.Params, &params); err != nil {
+			writeResponse(w, req.ID, nil, err)
+			return
+		}
+		task, err := s.CancelTask(r.Context(), params.ID)
+		writeResponse(w, req.ID, task, err)
+	case "tasks/resubscribe":
+		var params TaskIDParams
+		if err := decodeParams(req.Params, &params); err != nil {
+			writeResponse(w, req.ID, nil, err)
+			return
+		}
+		s.mu.Lock()
+		state, ok := s.tasks[params.ID]
+		s.mu.Unlock()
+		if !ok {
+			writeResponse(w, req.ID, nil, taskNotFound(params.ID))
+			return
+		}
+		s.stream(w, r, req.ID, state)
+	case "tasks/pushNotificationConfig/set", "tasks/pushNotificationConfig/get":
+		writeResponse(w, req.ID, nil, &Error{Code: CodeUnsupportedOperation, Message: "push notifications are not supported"})
+	default:
+		writeResponse(w, req.ID, nil, &Error{Code: CodeMethodNotFound, Message: fmt.Sprintf("method '%s' not found", req.Method)})
+	}
+}
+
+// SendMessage starts a task for a message. Unless the configuration disables blocking, it waits
+// until the task is finished or ctx is done, and returns the task.
+func (s *Server) SendMessage(ctx context.Context, params *MessageSendParams) (*Task, error) {
+	state, err := s.startTask(params)
+	if err != nil {
+		return nil, err
+	}
+	var historyLength *int
+	if config := params.Configuration; config != nil {
+		historyLength = config.HistoryLength
+		if config.Blocking != nil && !*config.Blocking {
+			return s.GetTask(state.task.ID, historyLength)
+		}
+	}
+	select {
+	case <-state.done:
+	case <-ctx.Done():
+	}
+	return s.GetTask(state.task.ID, historyLength)
+}
+
+// GetTask returns a copy of a task, keeping the last historyLength messages of its history if set
+func (s *Server) GetTask(id string, historyLength *int) (*Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.tasks[id]
+	if !ok {
+		return nil, taskNotFound(id)
+	}
+	task := copyTask(state.task)
+	if historyLength != nil && *historyLength >= 0 && len(task.History) > *historyLength {
+		task.History = task.History[len(task.History)-*historyLength:]
+	}
+	return task, nil
+}
+
+// CancelTask cancels a running task and waits until it stops or ctx is done
+func (s *Server) CancelTask(ctx context.Context, id string) (*Task, error) {
+	s.mu.Lock()
+	state, ok := s.tasks[id]
+	if !ok {
+		s.mu.Unlock()
+		return nil, taskNotFound(id)
+	}
+	if state.task.Status.State.Terminal() {
+		s.mu.Unlock()
+		return nil, &Error{Code: CodeTaskNotCancelable, Message: fmt.Sprintf("task is %s", state.task.Status.State)}
+	}
+	state.cancelled = true
+	state.cancel()
+	s.mu.Unlock()
+
+	select {
+	case <-state.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return s.GetTask(id, nil)
+}
+
+// startTask validates a message and runs its task in the background
+func (s *Server) startTask(params *MessageSendParams) (*taskState, error) {
+	message := params.Message
+	if message.Role != RoleUser {
+		return nil, &Error{Code: CodeInvalidParams, Message: "message role must be user"}
+	}
+	if len(message.Parts) == 0 {
+		return nil, &Error{Code: CodeInvalidParams, Message: "message must have at least one part"}
+	}
+	content, err := partsText(message.Parts)
+	if err != nil {
+		return nil, &Error{Code: CodeInvalidParams, Message: err.Error()}
+	}
+
+	s.mu.Lock()
+	if message.TaskID != "" {
+		// Tasks never wait for input, so they cannot be continued
+		state, ok := s.tasks[message.TaskID]
+		s.mu.Unlock()
+		if !ok {
+			return nil, taskNotFound(message.TaskID)
+		}
+		if state.task.Status.State.Terminal() {
+			return nil, &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("task '%s' is %s, send the message without task ID to continue its context", message.TaskID, state.task.Status.State)}
+		}
+		return nil, &Error{Code: CodeUnsupportedOperation, Message: fmt.Sprintf("task '%s' is not waiting for input", message.TaskID)}
+	}
+
+	if message.ContextID == "" {
+		message.ContextID = uuid.New().String()
+	}
+	if message.MessageID == "" {
+		message.MessageID = uuid.New().String()
+	}
+	message.Kind = "message"
+	var messages []*llm.ModelMessage
+	if previousID, ok := s.contexts[message.ContextID]; ok {
+		previous := s.tasks[previousID]
+		if !previous.task.Status.State.Terminal() {
+			s.mu.Unlock()
+			return nil, &Error{Code: CodeUnsupportedOperation, Message: fmt.Sprintf("task '%s' of the context is still running", previousID)}
+		}
+		messages = append(messages, previous.messages...)
+	}
+	messages = append(messages, &llm.ModelMessage{Role: llm.RoleUser, Content: content})
+
+	task := &Task{
+		Kind:      "task",
+		ID:        uuid.New().String(),
+		ContextID: message.ContextID,
+		Status:    TaskStatus{State: TaskStateSubmitted, Timestamp: time.Now()},
+		History:   []Message{message},
+		Metadata:  params.Metadata,
+	}
+	message.TaskID = task.ID
+	task.History[0].TaskID = task.ID
+
+	ctx, cancel := context.WithCancel(context.Background())
+	state := &taskState{
+		task:        task,
+		messages:    messages,
+		cancel:      cancel,
+		subscribers: make(map[chan any]struct{}),
+		done:        make(chan struct{}),
+	}
+	s.tasks[task.ID] = state
+	s.contexts[task.ContextID] = task.ID
+	s.mu.Unlock()
+
+	go s.runTask(ctx, state)
+	return state, nil
+}
+
+// runTask runs the agent for a task, publishing its progress to subscribers
+func (s *Server) runTask(ctx context.Context, state *taskState) {
+	defer state.cancel()
+	task := state.task
+
+	req, err := s.newRequest(ctx, copyTask(task), state.messages)
+	if err != nil {
+		s.finish(state, nil, fmt.Errorf("failed to create request: %w", err))
+		return
+	}
+	s.setStatus(state, TaskStateWorking, "")
+
+	stream, err := s.runner.Run(ctx, req, nil)
+	if err != nil {
+		s.finish(state, nil, err)
+		return
+	}
+	for event := range stream.Events() {
+		if event.Partial {
+			continue
+		}
+		switch event.Type {
+		case agent.AgentEventTypeUseTool:
+			if event.ToolCall != nil {
+				s.setStatus(state, TaskStateWorking, fmt.Sprintf("Using tool %s", event.ToolCall.Name))
+			}
+		case agent.AgentEventTypeHandoff:
+			s.setStatus(state, TaskStateWorking, fmt.Sprintf("Handed off to %s", event.Agent))
+		case agent.AgentEventTypeText:
+			if event.Text != nil {
+				s.setStatus(state, TaskStateWorking, *event.Text)
+			}
+		case agent.AgentEventTypeOutput:
+			s.addArtifact(state, Artifact{
+				ArtifactID: uuid.New().String(),
+				Name:       OutputArtifactName,
+				Parts:      []Part{outputPart(event.Output)},
+			})
+		}
+	}
+	resp, err := stream.Wait()
+	s.finish(state, resp, err)
+}
+
+// setStatus updates the status of a running task, with an optional agent message
+func (s *Server) setStatus(state *taskState, taskState TaskState, text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task := state.task
+	task.Status = TaskStatus{State: taskState, Timestamp: time.Now()}
+	if text != "" {
+		task.Status.Message = agentMessage(task, TextPart(text))
+	}
+	s.publish(state, &TaskStatusUpdateEvent{
+		Kind:      "status-update",
+		TaskID:    task.ID,
+		ContextID: task.ContextID,
+		Status:    task.Status,
+	})
+}
+
+// addArtifact adds an artifact to a task and publishes it
+func (s *Server) addArtifact(state *taskState, artifact Artifact) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task := state.task
+	task.Artifacts = append(task.Artifacts, artifact)
+	s.publish(state, &TaskArtifactUpdateEvent{
+		Kind:      "artifact-update",
+		TaskID:    task.ID,
+		ContextID: task.ContextID,
+		Artifact:  artifact,
+		LastChunk: true,
+	})
+}
+
+// finish stores the outcome of a task, closes its subscriptions and drops the oldest finished tasks
+func (s *Server) finish(state *taskState, resp *agent.AgentResponse, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task := state.task
+	status := TaskStatus{State: TaskStateCompleted, Timestamp: time.Now()}
+	switch {
+	case state.cancelled:
+		status.State = TaskStateCanceled
+	case err != nil:
+		status.State = TaskStateFailed
+		status.Message = agentMessage(task, TextPart(err.Error()))
+		s.logger.Warn("a2a task failed", "task", task.ID, "error", err)
+	default:
+		reply := agentMessage(task, outputPart(resp.Output))
+		task.History = append(task.History, *reply)
+		state.messages = append(state.messages, &llm.ModelMessage{Role: llm.RoleAssistant, Content: outputText(resp.Output)})
+	}
+	task.Status = status
+
+	for subscriber := range state.subscribers {
+		close(subscriber)
+	}
+	state.subscribers = nil
+	close(state.done)
+
+	s.finished = append(s.finished, task.ID)
+	if s.maxTasks > 0 && len(s.finished) > s.maxTasks {
+		evicted := s.tasks[s.finished[0]].task
+		delete(s.tasks, evicted.ID)
+		if s.contexts[evicted.ContextID] == evicted.ID {
+			delete(s.contexts, evicted.ContextID)
+		}
+		s.finished = s.finished[1:]
+	}
+}
+
+// publish sends an event to the subscribers of a task without blocking. Slow subscribers miss
+// intermediate events, the final status and the artifacts are sent when their channel is closed.
+// The caller must hold the lock.
+func (s *Server) publish(state *taskState, event any) {
+	for subscriber := range state.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+		}
+	}
+}
+
+// stream writes the task and its updates as server-sent events until the task is finished
+func (s *Server) stream(w http.ResponseWriter, r *http.Request, id json.RawMessage, state *taskState) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeResponse(w, id, nil, &Error{Code: CodeInternalError, Message: "streaming not supported"})
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	updates := make(chan any, 64)
+	s.mu.Lock()
+	task := copyTask(state.task)
+	if state.subscribers != nil {
+		state.subscribers[updates] = struct{}{}
+	} else {
+		close(updates)
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(state.subscribers, updates)
+		s.mu.Unlock()
+	}()
+
+	sent := make(map[string]bool, len(task.Artifacts))
+	for _, artifact := range task.Artifacts {
+		sent[artifact.ArtifactID] = true
+	}
+	if !writeEvent(w, flusher, id, task) {
+		return
+	}
+	for {
+		select {
+		case event, ok := <-updates:
+			if !ok {
+				s.writeFinal(w, flusher, id, state, sent)
+				return
+			}
+			if update, isArtifact := event.(*TaskArtifactUpdateEvent); isArtifact {
+				sent[update.Artifact.ArtifactID] = true
+			}
+			if !writeEvent(w, flusher, id, event) {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeFinal writes the artifacts a subscriber missed and the final status of a finished task
+func (s *Server) writeFinal(w http.ResponseWriter, flusher http.Flusher, id json.RawMessage, state *taskState, sent map[string]bool) {
+	s.mu.Lock()
+	task := copyTask(state.task)
+	s.mu.Unlock()
+	for _, artifact := range task.Artifacts {
+		if sent[artifact.ArtifactID] {
+			continue
+		}
+		event := &TaskArtifactUpdateEvent{
+			Kind:      "artifact-update",
+			TaskID:    task.ID,
+			ContextID: task.ContextID,
+			Artifact:  artifact,
+			LastChunk: true,
+		}
+		if !writeEvent(w, flusher, id, event) {
+			return
+		}
+	}
+	writeEvent(w, flusher, id, &TaskStatusUpdateEvent{
+		Kind:      "status-update",
+		TaskID:    task.ID,
+		ContextID: task.ContextID,
+		Status:    task.Status,
+		Final:     true,
+	})
+}
+
+// writeEvent writes a JSON-RPC response as a server-sent event and reports whether it succeeded
+func writeEvent(w http.ResponseWriter, flusher http.Flusher, id json.RawMessage, result any) bool {
+	data, err := json.Marshal(rpcResponse{JSONRPC: "2.0", ID: id, Result: result})
+	if err != nil {
+		return false
+	}
+	if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+		return false
+	}
+	flusher.Flush()
+	return true
+}
+
+// writeResponse writes a JSON-RPC response with result or err
+func writeResponse(w http.ResponseWriter, id json.RawMessage, result any, err error) {
+	resp := rpcResponse{JSONRPC: "2.0", ID: id}
+	if err != nil {
+		var rpcErr *Error
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &Error{Code: CodeInternalError, Message: err.Error()}
+		}
+		resp.Error = rpcErr
+	} else {
+		resp.Result = result
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func decodeParams(params json.RawMessage, v any) error {
+	if len(params) == 0 {
+		return &Error{Code: CodeInvalidParams, Message: "params are required"}
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &Error{Code: CodeInvalidParams, Message: "invalid params: " + err.Error()}
+	}
+	return nil
+}
+
+func taskNotFound(id string) *Error {
+	return &Error{Code: CodeTaskNotFound, Message: fmt.Sprintf("task '%s' not found", id)}
+}
+
+// agentMessage creates a message of the agent in the context of task
+func agentMessage(task *Task, parts ...Part) *Message {
+	return &Message{
+		Kind:      "message",
+		MessageID: uuid.New().String(),
+		Role:      RoleAgent,
+		Parts:     parts,
+		TaskID:    task.ID,
+		ContextID: task.ContextID,
+	}
+}
+
+// copyTask returns a copy of a task whose slices can be read without the lock
+func copyTask(task *Task) *Task {
+	copied := *task
+	copied.Artifacts = append([]Artifact(nil), task.Artifacts...)
+	copied.History = append([]Message(nil), task.History...)
+	return &copied
+}
+
+// partsText converts the parts of a message to the content of a model message
+func partsText(parts []Part) (string, error) {
+	texts := make([]string, 0, len(parts))
+	for _, part := range parts {
+		switch part.Kind {
+		case PartKindText:
+			texts = append(texts, part.Text)
+		case PartKindData:
+			data, err := json.Marshal(part.Data)
+			if err != nil {
+				return "", fmt.Errorf("invalid data part: %w", err)
+			}
+			texts = append(texts, string(data))
+		case PartKindFile:
+			if part.File == nil || part.File.URI == "" {
+				return "", errors.New("file parts must reference a URI")
+			}
+			texts = append(texts, fmt.Sprintf("[file %s (%s): %s]", part.File.Name, part.File.MimeType, part.File.URI))
+		default:
+			return "", fmt.Errorf("unsupported part kind '%s'", part.Kind)
+		}
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// outputPart converts the output of a run to a text part for plain answers and a data part otherwise
+func outputPart(output any) Part {
+	if text, ok := output.(string); ok {
+		return TextPart(text)
+	}
+	return DataPart(output)
+}
+
+// outputText converts the output of a run to the content of the assistant message continuing its context
+func outputText(output any) string {
+	if text, ok := output.(string); ok {
+		return text
+	}
+	data, err := json.Marshal(output)
+	if err != nil {
+		return fmt.Sprint(output)
+	}
+	return string(data)
+}
@@ -0,0 +1,242 @@
+package a2a
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ProtocolVersion is the version of the A2A protocol implemented by the server
+const ProtocolVersion = "0.3.0"
+
+// AgentCard describes an agent to A2A clients, served at /.well-known/agent-card.json
+type AgentCard struct {
+	ProtocolVersion    string            `json:"protocolVersion"`
+	Name               string            `json:"name"`
+	Description        string            `json:"description"`
+	URL                string            `json:"url"`
+	PreferredTransport string            `json:"preferredTransport,omitempty"`
+	Version            string            `json:"version"`
+	Provider           *AgentProvider    `json:"provider,omitempty"`
+	DocumentationURL   string            `json:"documentationUrl,omitempty"`
+	Capabilities       AgentCapabilities `json:"capabilities"`
+	DefaultInputModes  []string          `json:"defaultInputModes"`
+	DefaultOutputModes []string          `json:"defaultOutputModes"`
+	Skills             []AgentSkill      `json:"skills"`
+}
+
+// AgentProvider is the organization providing an agent
+type AgentProvider struct {
+	Organization string `json:"organization"`
+	URL          string `json:"url"`
+}
+
+// AgentCapabilities lists the optional protocol features supported by an agent
+type AgentCapabilities struct {
+	Streaming              bool `json:"streaming,omitempty"`
+	PushNotifications      bool `json:"pushNotifications,omitempty"`
+	StateTransitionHistory bool `json:"stateTransitionHistory,omitempty"`
+}
+
+// AgentSkill is a capability advertised by an agent
+type AgentSkill struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+	Examples    []string `json:"examples,omitempty"`
+	InputModes  []string `json:"inputModes,omitempty"`
+	OutputModes []string `json:"outputModes,omitempty"`
+}
+
+// Role is the sender of a message
+type Role string
+
+const (
+	RoleUser  Role = "user"
+	RoleAgent Role = "agent"
+)
+
+// PartKind identifies the content of a part
+type PartKind string
+
+const (
+	PartKindText PartKind = "text"
+	PartKindData PartKind = "data"
+	PartKindFile PartKind = "file"
+)
+
+// Part is a piece of message or artifact content. Text is set for text parts, Data for data parts
+// and File for file parts.
+type Part struct {
+	Kind     PartKind       `json:"kind"`
+	Text     string         `json:"text,omitempty"`
+	Data     any            `json:"data,omitempty"`
+	File     *File          `json:"file,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// File is the content of a file part, inlined as base64 bytes or referenced by URI
+type File struct {
+	Name     string `json:"name,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+	Bytes    string `json:"bytes,omitempty"`
+	URI      string `json:"uri,omitempty"`
+}
+
+// TextPart creates a text part
+func TextPart(text string) Part {
+	return Part{Kind: PartKindText, Text: text}
+}
+
+// DataPart creates a structured data part
+func DataPart(data any) Part {
+	return Part{Kind: PartKindData, Data: data}
+}
+
+// Message is a turn of the conversation between a client and an agent
+type Message struct {
+	Kind      string         `json:"kind"`
+	MessageID string         `json:"messageId"`
+	Role      Role           `json:"role"`
+	Parts     []Part         `json:"parts"`
+	TaskID    string         `json:"taskId,omitempty"`
+	ContextID string         `json:"contextId,omitempty"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+}
+
+// TaskState is the lifecycle state of a task
+type TaskState string
+
+const (
+	TaskStateSubmitted     TaskState = "submitted"
+	TaskStateWorking       TaskState = "working"
+	TaskStateInputRequired TaskState = "input-required"
+	TaskStateCompleted     TaskState = "completed"
+	TaskStateCanceled      TaskState = "canceled"
+	TaskStateFailed        TaskState = "failed"
+	TaskStateRejected      TaskState = "rejected"
+	TaskStateAuthRequired  TaskState = "auth-required"
+	TaskStateUnknown       TaskState = "unknown"
+)
+
+// Terminal reports whether a task in this state is finished
+func (s TaskState) Terminal() bool {
+	switch s {
+	case TaskStateCompleted, TaskStateCanceled, TaskStateFailed, TaskStateRejected:
+		return true
+	default:
+		return false
+	}
+}
+
+// TaskStatus is the state of a task with an optional message from the agent
+type TaskStatus struct {
+	State     TaskState `json:"state"`
+	Message   *Message  `json:"message,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Artifact is an output of a task
+type Artifact struct {
+	ArtifactID  string `json:"artifactId"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	Parts       []Part `json:"parts"`
+}
+
+// Task is a unit of work requested by a client
+type Task struct {
+	Kind      string         `json:"kind"`
+	ID        string         `json:"id"`
+	ContextID string         `json:"contextId"`
+	Status    TaskStatus     `json:"status"`
+	Artifacts []Artifact     `json:"artifacts,omitempty"`
+	History   []Message      `json:"history,omitempty"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+}
+
+// TaskStatusUpdateEvent is streamed when the status of a task changes. Final is set on the last event.
+type TaskStatusUpdateEvent struct {
+	Kind      string     `json:"kind"`
+	TaskID    string     `json:"taskId"`
+	ContextID string     `json:"contextId"`
+	Status    TaskStatus `json:"status"`
+	Final     bool       `json:"final"`
+}
+
+// TaskArtifactUpdateEvent is streamed when a task produces an artifact
+type TaskArtifactUpdateEvent struct {
+	Kind      string   `json:"kind"`
+	TaskID    string   `json:"taskId"`
+	ContextID string   `json:"contextId"`
+	Artifact  Artifact `json:"artifact"`
+	Append    bool     `json:"append,omitempty"`
+	LastChunk bool     `json:"lastChunk,omitempty"`
+}
+
+// MessageSendParams are the parameters of message/send and message/stream
+type MessageSendParams struct {
+	Message       Message                   `json:"message"`
+	Configuration *MessageSendConfiguration `json:"configuration,omitempty"`
+	Metadata      map[string]any            `json:"metadata,omitempty"`
+}
+
+// MessageSendConfiguration configures how message/send responds
+type MessageSendConfiguration struct {
+	// Blocking makes message/send wait for the task to finish, true by default
+	Blocking *bool `json:"blocking,omitempty"`
+
+	// HistoryLength limits the messages of the returned task history
+	HistoryLength *int `json:"historyLength,omitempty"`
+
+	AcceptedOutputModes []string `json:"acceptedOutputModes,omitempty"`
+}
+
+// TaskQueryParams are the parameters of tasks/get
+type TaskQueryParams struct {
+	ID            string `json:"id"`
+	HistoryLength *int   `json:"historyLength,omitempty"`
+}
+
+// TaskIDParams are the parameters of tasks/cancel and tasks/resubscribe
+type TaskIDParams struct {
+	ID string `json:"id"`
+}
+
+// JSON-RPC and A2A error codes
+const (
+	CodeParseError           = -32700
+	CodeInvalidRequest       = -32600
+	CodeMethodNotFound       = -32601
+	CodeInvalidParams        = -32602
+	CodeInternalError        = -32603
+	CodeTaskNotFound         = -32001
+	CodeTaskNotCancelable    = -32002
+	CodeUnsupportedOperation = -32004
+)
+
+// Error is a JSON-RPC error
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return e.Message
+}
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
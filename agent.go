@@ -1,10 +1,24 @@
 package agent
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
+
+// AgentType is the format an agent's model calls tools with
+type AgentType string
+
+const (
+	// AgentTypeJSON calls tools with JSON objects, see NewJSONCompletionRunner
+	AgentTypeJSON AgentType = "json"
+
+	// AgentTypeXML calls tools with XML tags, see NewXMLCompletionRunner
+	AgentTypeXML AgentType = "xml"
+)
 
 // Agent represents an AI agent with specific capabilities and behaviors.
-// It encapsulates the agent's identity, instructions, available tools,
-// callback handlers, and logging configuration.
+// It encapsulates the agent's identity, model, instructions and available tools.
+// Callbacks are passed to runs and loggers to runners.
 type Agent struct {
 	// Name is the identifier for this agent
 	Name string
@@ -12,9 +26,13 @@ type Agent struct {
 	// ModelProvider is the model provider
 	ModelProvider string
 
-	// Model is the model provider
+	// Model is the model name
 	Model string
 
+	// Type is the tool call format NewRunner and NewStreamRunner create a runner for,
+	// detected from the model if empty
+	Type AgentType
+
 	// Description provides a brief explanation of the agent's purpose
 	Description string
 
@@ -23,6 +41,20 @@ type Agent struct {
 
 	// Tools are the available tools this agent can use
 	Tools []ModelTool
+
+	// ToolOptions sets the prompt priority and category of tools by name, including request tools
+	ToolOptions map[string]ToolOptions
+
+	// ToolAliases maps legacy tool names to current ones, so outputs calling a renamed tool still resolve
+	// Calls through an alias log a deprecation warning
+	ToolAliases map[string]string
+
+	// ToolPreferences add guidance on which tool to use when several apply
+	ToolPreferences []ToolPreference
+
+	// Handoffs are the agents this agent can transfer the conversation to
+	// A handoff tool is registered automatically when this is not empty
+	Handoffs []*Agent
 }
 
 // Validate validates the agent configuration
@@ -36,6 +68,41 @@ func (a *Agent) Validate() error {
 	if a.Instructions == "" {
 		return errors.New("agent instructions are required")
 	}
-	// Logger is optional, will default to NoOpLogger if not set
+	switch a.Type {
+	case "", AgentTypeJSON, AgentTypeXML:
+	default:
+		return fmt.Errorf("invalid agent type '%s', expected json or xml", a.Type)
+	}
+	for _, preference := range a.ToolPreferences {
+		if err := preference.validate(); err != nil {
+			return fmt.Errorf("invalid tool preference: %w", err)
+		}
+	}
 	return nil
 }
+
+// validateHandoffs validates the agent and every agent reachable through handoffs.
+// Handoff cycles are allowed, names must be unique across the graph.
+func (a *Agent) validateHandoffs() (map[string]*Agent, error) {
+	agents := map[string]*Agent{}
+	pending := []*Agent{a}
+	for len(pending) > 0 {
+		current := pending[0]
+		pending = pending[1:]
+		if current == nil {
+			return nil, errors.New("handoff agent is nil")
+		}
+		if existing, ok := agents[current.Name]; ok {
+			if existing != current {
+				return nil, fmt.Errorf("duplicate agent name '%s' in handoffs", current.Name)
+			}
+			continue
+		}
+		if err := current.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid handoff agent '%s': %w", current.Name, err)
+		}
+		agents[current.Name] = current
+		pending = append(pending, current.Handoffs...)
+	}
+	return agents, nil
+}
@@ -3,6 +3,9 @@ package agent
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/easyagent-dev/llm"
 )
 
@@ -29,6 +32,8 @@ type DefaultCallback struct {
 	trace bool
 }
 
+var _ LatencyCallback = (*DefaultCallback)(nil)
+
 // NewDefaultCallback creates a new DefaultCallback with the given logger
 func NewDefaultCallback(trace bool) *DefaultCallback {
 	return &DefaultCallback{trace: trace}
@@ -37,7 +42,7 @@ func NewDefaultCallback(trace bool) *DefaultCallback {
 // BeforeModel is called before sending a request to the LLM
 func (c *DefaultCallback) BeforeModel(ctx context.Context, provider string, model string, prompts string, messages []*llm.ModelMessage) error {
 	if c.trace {
-		println(fmt.Sprintf("BeforeModel: %s/%s | Prompts: %d chars | Messages: %d", provider, model, len(prompts), len(messages)))
+		println(fmt.Sprintf("BeforeModel: %s/%s | Prompts: %d chars | Messages: %d%s", provider, model, len(prompts), len(messages), formatMetadata(MetadataOf(ctx))))
 	}
 	return nil
 }
@@ -47,13 +52,22 @@ func (c *DefaultCallback) AfterModel(ctx context.Context, provider string, model
 	if c.trace {
 		usageStr := "no usage"
 		if usage != nil {
-			usageStr = fmt.Sprintf("in:%d out:%d", usage.TotalInputTokens, usage.TotalOutputTokens)
+			usageStr = fmt.Sprintf("in:%d out:%d cache_read:%d cache_write:%d cache_hit:%.0f%%",
+				usage.TotalInputTokens, usage.TotalOutputTokens, usage.TotalCacheReadTokens, usage.TotalCacheWriteTokens, CacheHitRate(usage)*100)
 		}
-		println(fmt.Sprintf("AfterModel: %s/%s | Output: %d chars | Usage: %s", provider, model, len(output), usageStr))
+		println(fmt.Sprintf("AfterModel: %s/%s | Output: %d chars | Usage: %s%s", provider, model, len(output), usageStr, formatMetadata(MetadataOf(ctx))))
 	}
 	return nil
 }
 
+// ModelLatency is called after AfterModel for every streamed model call
+func (c *DefaultCallback) ModelLatency(ctx context.Context, provider string, model string, latency *TokenLatency) {
+	if c.trace {
+		println(fmt.Sprintf("ModelLatency: %s/%s | TTFT: %s | Inter-token: mean %s, max %s | Chunks: %d%s",
+			provider, model, latency.TimeToFirstToken, latency.MeanInterToken, latency.MaxInterToken, latency.Chunks, formatMetadata(MetadataOf(ctx))))
+	}
+}
+
 // BeforeToolCall is called before executing a tool
 func (c *DefaultCallback) BeforeToolCall(ctx context.Context, toolName string, input any) error {
 	if c.trace {
@@ -69,3 +83,25 @@ func (c *DefaultCallback) AfterToolCall(ctx context.Context, toolName string, in
 	}
 	return nil
 }
+
+// formatMetadata formats request metadata for trace output, sorted by key
+func formatMetadata(metadata map[string]string) string {
+	if len(metadata) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var builder strings.Builder
+	builder.WriteString(" | Metadata:")
+	for _, key := range keys {
+		builder.WriteString(" ")
+		builder.WriteString(key)
+		builder.WriteString("=")
+		builder.WriteString(metadata[key])
+	}
+	return builder.String()
+}
@@ -2,6 +2,8 @@ package agent
 
 import (
 	"errors"
+	"fmt"
+
 	"github.com/easyagent-dev/llm"
 )
 
@@ -26,6 +28,64 @@ type AgentRequest struct {
 	// MaxRetries is the maximum number of consecutive retries allowed when errors occur
 	// If 0 or negative, no retry limit is enforced
 	MaxRetries int
+
+	// Session is the session store of a conversation, exposed to tools through AgentContext.Session
+	// Conversations set it to the data of their session, so tools keep state across turns
+	Session map[string]any
+
+	// SharedState is an optional blackboard shared with other agents of the same workflow
+	// It is exposed to tools through AgentContext.SharedState
+	SharedState *SharedState
+
+	// Tools are request-scoped tools added to the agent's tools for this run only,
+	// e.g. a document lookup bound to the current user's data
+	// Names must not collide with the agent's tools, the completion tool or the handoff tool
+	Tools []ModelTool
+
+	// DirectAnswer lets the model complete with a plain text answer instead of a complete_task call
+	// The answer becomes the output as a string. Direct answers are also used automatically
+	// when there is no OutputSchema and the agent has no tools.
+	DirectAnswer bool
+
+	// ToolChoice forces the first tool call of the run, e.g. ToolChoiceRequired or ToolChoiceTool("search")
+	// Once a tool call satisfies it, the choice is auto unless a LoopController decides otherwise.
+	ToolChoice ToolChoice
+
+	// Metadata holds request-level tags such as tenant ID, user ID or feature flags
+	// It is exposed through AgentContext and MetadataOf to callbacks and tools, and stamped on every event
+	Metadata map[string]string
+
+	// Credentials resolves the end user's credentials for tools through GetCredential
+	// Use it instead of baking secrets into tool constructors when tools act on behalf of users
+	Credentials CredentialsProvider
+
+	// AllowDestructive enables tools with ToolEffectDestructive for this run
+	// Their calls are still submitted to the runner's ToolApprover, if any
+	AllowDestructive bool
+
+	// DryRun simulates the calls of tools that are not read-only instead of running them, e.g. for
+	// shadow runs. Tools implementing DryRunTool return the simulated output, others a placeholder.
+	// Simulated calls are not submitted to the runner's ToolApprover.
+	DryRun bool
+
+	// Locale is the BCP 47 locale of the user, e.g. "fr-FR". The system prompt asks the model to
+	// respond in its language, and verifiers receive it to check the output.
+	Locale string
+
+	// Model is the model of the run as provider/model, e.g. "openai/o4-mini", resolved by the runner's
+	// ModelRegistry. If empty, the runner's model is used.
+	Model string
+
+	// Priority orders the model calls of the run among the pending calls of a ModelScheduler, higher first
+	Priority int
+
+	// EventBufferSize overrides the event buffer size of the runner for a streaming run, see WithEventBufferSize
+	// If 0, the runner's size is used
+	EventBufferSize int
+
+	// RunID identifies the run, generated when empty
+	// Set it to the ID of an interrupted run when resuming it, so its tool calls get the same idempotency keys
+	RunID string
 }
 
 // Validate validates the agent request parameters and returns an error if invalid.
@@ -37,9 +97,34 @@ func (r *AgentRequest) Validate() error {
 	if r.MaxIterations <= 0 {
 		return errors.New("max iterations must be positive")
 	}
+	if r.Model != "" {
+		if _, _, err := ParseModelRef(r.Model); err != nil {
+			return err
+		}
+	}
+	if r.EventBufferSize < 0 {
+		return errors.New("event buffer size must not be negative")
+	}
 	// Validate last message is from user
 	if r.Messages[len(r.Messages)-1].Role != llm.RoleUser {
 		return errors.New("last message must be from user")
 	}
+	if r.DirectAnswer && r.OutputSchema != nil {
+		return errors.New("direct answer cannot be used with an output schema")
+	}
+	seen := make(map[string]bool, len(r.Tools))
+	for _, tool := range r.Tools {
+		if tool == nil {
+			return errors.New("request tool cannot be nil")
+		}
+		name := tool.Name()
+		if name == HandoffToolName {
+			return fmt.Errorf("request tool name '%s' is reserved", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("duplicate request tool '%s'", name)
+		}
+		seen[name] = true
+	}
 	return nil
 }
@@ -1,6 +1,8 @@
 package agent
 
 import (
+	"context"
+
 	"github.com/easyagent-dev/llm"
 )
 
@@ -21,11 +23,97 @@ type AgentResponse struct {
 
 	// ToolExecutions is a list of tool executions that occurred during the agent's execution
 	ToolCalls []*llm.ToolCall `json:"toolCalls"`
+
+	// ToolStats summarizes ToolCalls by tool name
+	ToolStats map[string]ToolStats `json:"toolStats,omitempty"`
+
+	// Agent is the name of the agent that produced the output
+	// It differs from the runner's agent when the conversation was handed off
+	Agent string `json:"agent"`
+
+	// Candidates are the individual responses a consensus run chose from
+	Candidates []*AgentResponse `json:"candidates,omitempty"`
+
+	// Partial is true when MaxIterations was exceeded and Output is a best-effort answer
+	// salvaged by a final forced completion call. The run error is still returned.
+	Partial bool `json:"partial,omitempty"`
+
+	// PromptStats is the size of the system prompt of the last model call
+	PromptStats *PromptStats `json:"promptStats,omitempty"`
+
+	// Rationale explains why the judge of a consensus run chose the output
+	Rationale string `json:"rationale,omitempty"`
+
+	// Confidence is the score of the output by the scorer of a confidence runner, from 0 to 1
+	Confidence *float64 `json:"confidence,omitempty"`
+
+	// Speculation counts the speculative model calls of the run, if speculation is enabled
+	Speculation *SpeculationStats `json:"speculation,omitempty"`
+
+	// Version identifies the prompt and model configuration of the last model call
+	Version *RunVersion `json:"version,omitempty"`
+
+	// Variant is the experiment variant that ran the request, empty outside of experiments
+	Variant string `json:"variant,omitempty"`
+
+	// Degradation is set when a degrading runner answered below its full tier of service
+	Degradation *Degradation `json:"degradation,omitempty"`
+
+	// Compensations holds the undo actions of the run's tool calls not rolled back by the runner.
+	// Call Rollback on it to undo the run, e.g. when its output is rejected.
+	Compensations *Compensations `json:"-"`
 }
 
-// AgentStreamResponse is a channel that streams agent events during execution.
+// AgentStreamResponse streams agent events during execution and holds the result of the run.
 // This enables real-time monitoring of agent progress.
-type AgentStreamResponse <-chan AgentEvent
+type AgentStreamResponse struct {
+	events  <-chan AgentEvent
+	cancel  context.CancelFunc
+	emitter *eventEmitter
+
+	// done is closed once resp and err are set
+	done chan struct{}
+	resp *AgentResponse
+	err  error
+}
+
+// Events returns the event channel, closed when the run ends
+func (s *AgentStreamResponse) Events() <-chan AgentEvent {
+	return s.events
+}
+
+// Wait discards the remaining events and returns the response and error of the run
+func (s *AgentStreamResponse) Wait() (*AgentResponse, error) {
+	for range s.events {
+	}
+	<-s.done
+	return s.resp, s.err
+}
+
+// Done returns a channel closed when the run has ended
+func (s *AgentStreamResponse) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the terminal error of the run, or nil while it is running or if it succeeded
+func (s *AgentStreamResponse) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// Cancel stops the run. The event channel is closed after the run observes the cancellation.
+func (s *AgentStreamResponse) Cancel() {
+	s.cancel()
+}
+
+// Dropped returns the number of events discarded by the runner's backpressure policy
+func (s *AgentStreamResponse) Dropped() int64 {
+	return s.emitter.dropped.Load()
+}
 
 // AgentEventType represents the type of event in a streaming response
 type AgentEventType string
@@ -42,6 +130,41 @@ const (
 
 	// AgentEventTypeError indicates an error event
 	AgentEventTypeError AgentEventType = "error"
+
+	// AgentEventTypeHandoff indicates the conversation was handed off to another agent
+	AgentEventTypeHandoff AgentEventType = "handoff"
+
+	// AgentEventTypeOutputDelta indicates a field of the final output was parsed while streaming
+	// Deltas of an attempt the model fails to complete are superseded by the Output event
+	AgentEventTypeOutputDelta AgentEventType = "output_delta"
+
+	// AgentEventTypeOutput indicates the agent completed with its final output
+	// Partial is set when the output was salvaged after MaxIterations was exceeded
+	AgentEventTypeOutput AgentEventType = "output"
+
+	// AgentEventTypeToolInputDelta indicates text was appended to a string argument of a streamed tool call
+	AgentEventTypeToolInputDelta AgentEventType = "tool_input_delta"
+
+	// AgentEventTypeToolDenied indicates a tool call was not run because it was not approved
+	// ToolCall holds the call and ErrorMessage the reason
+	AgentEventTypeToolDenied AgentEventType = "tool_denied"
+
+	// AgentEventTypePromptStats reports the size of the system prompt before every model call
+	AgentEventTypePromptStats AgentEventType = "prompt_stats"
+
+	// AgentEventTypeCancelled indicates the run was cancelled, Cancellation lists the interrupted tool calls
+	AgentEventTypeCancelled AgentEventType = "cancelled"
+
+	// AgentEventTypeOutputRejected indicates the verifier rejected a final output and the run continues
+	// Output holds the rejected output and ErrorMessage the feedback
+	AgentEventTypeOutputRejected AgentEventType = "output_rejected"
+
+	// AgentEventTypeUsage reports the usage and cost of every model call and the run totals
+	AgentEventTypeUsage AgentEventType = "usage"
+
+	// AgentEventTypeInvalidToolInput indicates a tool call had an invalid input, fed back to the model
+	// ToolCall holds the call and Validation the invalid fields
+	AgentEventTypeInvalidToolInput AgentEventType = "invalid_tool_input"
 )
 
 // AgentEvent represents a single event in a streaming agent response.
@@ -50,6 +173,16 @@ type AgentEvent struct {
 	// Type identifies what kind of event this is
 	Type AgentEventType
 
+	// Agent is the name of the agent active when the event was produced
+	// For Handoff events, it is the agent taking over the conversation
+	Agent string
+
+	// RunID is the ID of the run producing the event
+	RunID string
+
+	// Version identifies the prompt and model configuration of the run, nil before the first model call
+	Version *RunVersion
+
 	// Text contains text output (for Text events)
 	Text *string
 
@@ -62,6 +195,38 @@ type AgentEvent struct {
 	// ToolCall contains the tool call (for UseTool events)
 	ToolCall *llm.ToolCall
 
+	// Handoff contains the handoff details (for Handoff events)
+	Handoff *Handoff
+
+	// OutputDelta contains the updated output field (for OutputDelta events)
+	OutputDelta *OutputDelta
+
+	// ToolInputDelta contains the appended argument text (for ToolInputDelta events)
+	ToolInputDelta *ToolInputDelta
+
+	// Output contains the final output (for Output events)
+	Output any
+
+	// PromptStats contains the system prompt size (for PromptStats events)
+	PromptStats *PromptStats
+
+	// Usage contains the usage of a model call and the run totals (for Usage events),
+	// and the run totals (for Output events)
+	Usage *UsageReport
+
+	// Cancellation contains the interrupted tool calls (for Cancelled events)
+	Cancellation *Cancellation
+
+	// Validation contains the invalid fields of the tool input (for InvalidToolInput events)
+	Validation *ValidationError
+
 	// Partial indicates if this is a partial event (more data coming)
 	Partial bool
+
+	// Ephemeral indicates the event may be shown live but must not be persisted,
+	// e.g. reasoning under ReasoningStreamOnly
+	Ephemeral bool
+
+	// Metadata is the request metadata of the run
+	Metadata map[string]string
 }
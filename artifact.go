@@ -0,0 +1,144 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Content types of artifacts stored from tool outputs
+const (
+	ArtifactContentJSON = "application/json"
+	ArtifactContentText = "text/plain"
+)
+
+// Artifact is a tool result stored out of the conversation history, which the model reads
+// through its reference with the artifact tools
+type Artifact struct {
+	// ID is the reference given to the model
+	ID string `json:"id"`
+
+	// RunID and Tool identify the tool call that produced the artifact
+	RunID string `json:"runId"`
+	Tool  string `json:"tool"`
+
+	// ContentType is ArtifactContentJSON for indented JSON, ArtifactContentText otherwise
+	ContentType string `json:"contentType"`
+	Content     []byte `json:"content"`
+
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ArtifactStore stores the artifacts of runs
+type ArtifactStore interface {
+	// Put stores artifact, replacing any artifact with the same ID
+	Put(ctx context.Context, artifact *Artifact) error
+
+	// Get returns the artifact with id, or ErrArtifactNotFound
+	Get(ctx context.Context, id string) (*Artifact, error)
+}
+
+// WithArtifactStore sets the store of the tool outputs referenced by WithOutputReferences,
+// SessionArtifactStore by default
+func WithArtifactStore(store ArtifactStore) RunnerOption {
+	return func(c *runnerConfig) {
+		c.artifacts = store
+	}
+}
+
+// MemoryArtifactStore keeps artifacts in memory.
+// This type is safe for concurrent use.
+type MemoryArtifactStore struct {
+	mu        sync.RWMutex
+	artifacts map[string]*Artifact
+}
+
+var _ ArtifactStore = (*MemoryArtifactStore)(nil)
+
+// NewMemoryArtifactStore creates an empty in-memory artifact store
+func NewMemoryArtifactStore() *MemoryArtifactStore {
+	return &MemoryArtifactStore{artifacts: make(map[string]*Artifact)}
+}
+
+// Put stores artifact
+func (s *MemoryArtifactStore) Put(ctx context.Context, artifact *Artifact) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.artifacts[artifact.ID] = artifact
+	return nil
+}
+
+// Get returns the artifact with id
+func (s *MemoryArtifactStore) Get(ctx context.Context, id string) (*Artifact, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	artifact, ok := s.artifacts[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrArtifactNotFound, id)
+	}
+	return artifact, nil
+}
+
+// Delete removes the artifact with id
+func (s *MemoryArtifactStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.artifacts, id)
+	return nil
+}
+
+// SessionArtifactStore stores artifacts in the session of the run executing in the context,
+// under OutputReferencePrefix keys, so session stores persist them with the conversation
+type SessionArtifactStore struct{}
+
+var _ ArtifactStore = SessionArtifactStore{}
+
+// Put stores artifact in the session of the run
+func (SessionArtifactStore) Put(ctx context.Context, artifact *Artifact) error {
+	ac, ok := AgentContextOf(ctx)
+	if !ok {
+		return errors.New("no agent run in context")
+	}
+	if ac.Session == nil {
+		ac.Session = make(map[string]any)
+	}
+	ac.Session[OutputReferencePrefix+artifact.ID] = artifact
+	return nil
+}
+
+// Get returns the artifact with id from the session of the run
+func (SessionArtifactStore) Get(ctx context.Context, id string) (*Artifact, error) {
+	ac, ok := AgentContextOf(ctx)
+	if !ok || ac.Session == nil {
+		return nil, fmt.Errorf("%w: %s", ErrArtifactNotFound, id)
+	}
+	switch value := ac.Session[OutputReferencePrefix+id].(type) {
+	case nil:
+		return nil, fmt.Errorf("%w: %s", ErrArtifactNotFound, id)
+	case *Artifact:
+		return value, nil
+	default:
+		// Sessions loaded from a store hold decoded JSON
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid artifact %s: %w", id, err)
+		}
+		artifact := &Artifact{}
+		if err := json.Unmarshal(data, artifact); err != nil {
+			return nil, fmt.Errorf("invalid artifact %s: %w", id, err)
+		}
+		return artifact, nil
+	}
+}
+
+// artifactOf returns the artifact with id from the store of the run executing in ctx
+func artifactOf(ctx context.Context, id string) (*Artifact, error) {
+	ac, ok := AgentContextOf(ctx)
+	if !ok || ac.Artifacts == nil {
+		return nil, errors.New("no artifact store is attached to this run")
+	}
+	return ac.Artifacts.Get(ctx, id)
+}
@@ -0,0 +1,231 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/easyagent-dev/llm"
+)
+
+const (
+	// ReadArtifactToolName is the name of the tool reading a page of lines of an artifact
+	ReadArtifactToolName = "read_artifact"
+
+	// SearchArtifactToolName is the name of the tool searching the lines of an artifact
+	SearchArtifactToolName = "search_artifact"
+)
+
+const (
+	// defaultArtifactPageLines and maxArtifactPageLines bound the lines read_artifact returns
+	defaultArtifactPageLines = 100
+	maxArtifactPageLines     = 500
+
+	// maxArtifactPageChars bounds the characters of a page, whatever its number of lines
+	maxArtifactPageChars = 16000
+
+	// defaultArtifactMatches and maxArtifactMatches bound the matches search_artifact returns
+	defaultArtifactMatches = 20
+	maxArtifactMatches     = 100
+)
+
+// ReadArtifactInput is the input of the read_artifact tool
+type ReadArtifactInput struct {
+	Ref    string `json:"ref" jsonschema:"required,description=Reference of the stored output"`
+	Offset int    `json:"offset,omitempty" jsonschema:"description=First line to read\\, starting at 1"`
+	Limit  int    `json:"limit,omitempty" jsonschema:"description=Number of lines to read\\, 100 by default and 500 at most"`
+}
+
+// SearchArtifactInput is the input of the search_artifact tool
+type SearchArtifactInput struct {
+	Ref        string `json:"ref" jsonschema:"required,description=Reference of the stored output"`
+	Pattern    string `json:"pattern" jsonschema:"required,description=Case-insensitive regular expression or text to find"`
+	Context    int    `json:"context,omitempty" jsonschema:"description=Lines of context around each match\\, 5 at most"`
+	MaxResults int    `json:"max_results,omitempty" jsonschema:"description=Maximum number of matches\\, 20 by default and 100 at most"`
+}
+
+// ReadArtifactTool lets the model page through a tool output stored by WithOutputReferences
+type ReadArtifactTool struct{}
+
+// SearchArtifactTool lets the model search a tool output stored by WithOutputReferences
+type SearchArtifactTool struct{}
+
+var (
+	_ EffectTool = &ReadArtifactTool{}
+	_ EffectTool = &SearchArtifactTool{}
+)
+
+// NewArtifactTools creates the read_artifact and search_artifact tools
+func NewArtifactTools() []ModelTool {
+	return []ModelTool{&ReadArtifactTool{}, &SearchArtifactTool{}}
+}
+
+// Name returns the name of the tool
+func (t *ReadArtifactTool) Name() string {
+	return ReadArtifactToolName
+}
+
+// Description returns a description of what the tool does
+func (t *ReadArtifactTool) Description() string {
+	return "Reads a range of lines of a tool output stored by reference"
+}
+
+// InputSchema returns the input schema of the tool
+func (t *ReadArtifactTool) InputSchema() any {
+	return llm.GenerateSchema[ReadArtifactInput]()
+}
+
+// OutputSchema returns the output schema of the tool
+func (t *ReadArtifactTool) OutputSchema() any {
+	return nil
+}
+
+// Usage returns an example of how to use the tool
+func (t *ReadArtifactTool) Usage() string {
+	return `{"ref":"search_1a2b3c4d","offset":101,"limit":100}`
+}
+
+// Effect returns ToolEffectReadOnly
+func (t *ReadArtifactTool) Effect() ToolEffect {
+	return ToolEffectReadOnly
+}
+
+// Run returns the requested lines
+func (t *ReadArtifactTool) Run(ctx context.Context, input map[string]any) (any, error) {
+	ref, _ := input["ref"].(string)
+	if ref == "" {
+		return nil, fmt.Errorf("%w: ref is required", ErrInvalidInput)
+	}
+	artifact, err := artifactOf(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(string(artifact.Content), "\n")
+
+	offset := max(intInput(input, "offset"), 1)
+	if offset > len(lines) {
+		return nil, fmt.Errorf("%w: offset %d is past the last line %d", ErrInvalidInput, offset, len(lines))
+	}
+	limit := intInput(input, "limit")
+	if limit <= 0 {
+		limit = defaultArtifactPageLines
+	}
+	limit = min(limit, maxArtifactPageLines)
+
+	var page strings.Builder
+	end := offset - 1
+	for end < len(lines) && end < offset-1+limit {
+		line := lines[end]
+		if page.Len()+len(line) > maxArtifactPageChars {
+			if end == offset-1 {
+				// A single line over the budget is cut rather than never returned
+				page.WriteString(line[:maxArtifactPageChars])
+				end++
+			}
+			break
+		}
+		page.WriteString(line)
+		page.WriteByte('\n')
+		end++
+	}
+	result := map[string]any{
+		"ref":         ref,
+		"from":        offset,
+		"to":          end,
+		"total_lines": len(lines),
+		"content":     strings.ToValidUTF8(strings.TrimSuffix(page.String(), "\n"), ""),
+	}
+	if end < len(lines) {
+		result["next_offset"] = end + 1
+	}
+	return result, nil
+}
+
+// Name returns the name of the tool
+func (t *SearchArtifactTool) Name() string {
+	return SearchArtifactToolName
+}
+
+// Description returns a description of what the tool does
+func (t *SearchArtifactTool) Description() string {
+	return "Finds the lines of a tool output stored by reference matching a pattern, with their line numbers"
+}
+
+// InputSchema returns the input schema of the tool
+func (t *SearchArtifactTool) InputSchema() any {
+	return llm.GenerateSchema[SearchArtifactInput]()
+}
+
+// OutputSchema returns the output schema of the tool
+func (t *SearchArtifactTool) OutputSchema() any {
+	return nil
+}
+
+// Usage returns an example of how to use the tool
+func (t *SearchArtifactTool) Usage() string {
+	return `{"ref":"search_1a2b3c4d","pattern":"invoice-20[0-9]+","context":2}`
+}
+
+// Effect returns ToolEffectReadOnly
+func (t *SearchArtifactTool) Effect() ToolEffect {
+	return ToolEffectReadOnly
+}
+
+// Run returns the matching lines
+func (t *SearchArtifactTool) Run(ctx context.Context, input map[string]any) (any, error) {
+	ref, _ := input["ref"].(string)
+	pattern, _ := input["pattern"].(string)
+	if ref == "" || pattern == "" {
+		return nil, fmt.Errorf("%w: ref and pattern are required", ErrInvalidInput)
+	}
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		// Models often pass plain text with special characters
+		re = regexp.MustCompile("(?i)" + regexp.QuoteMeta(pattern))
+	}
+	artifact, err := artifactOf(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(string(artifact.Content), "\n")
+
+	contextLines := min(max(intInput(input, "context"), 0), 5)
+	maxResults := intInput(input, "max_results")
+	if maxResults <= 0 {
+		maxResults = defaultArtifactMatches
+	}
+	maxResults = min(maxResults, maxArtifactMatches)
+
+	matches := []map[string]any{}
+	total := 0
+	for i, line := range lines {
+		if !re.MatchString(line) {
+			continue
+		}
+		total++
+		if len(matches) == maxResults {
+			continue
+		}
+		match := map[string]any{"line": i + 1, "text": previewLines([]string{line}, 1)}
+		if contextLines > 0 {
+			from, to := max(i-contextLines, 0), min(i+contextLines+1, len(lines))
+			match["context"] = previewLines(lines[from:to], to-from)
+		}
+		matches = append(matches, match)
+	}
+	return map[string]any{
+		"ref":           ref,
+		"matches":       matches,
+		"total_matches": total,
+		"total_lines":   len(lines),
+	}, nil
+}
+
+// intInput returns a number input as an int, 0 if it is missing
+func intInput(input map[string]any, key string) int {
+	if n, ok := input[key].(float64); ok {
+		return int(n)
+	}
+	return 0
+}
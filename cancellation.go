@@ -0,0 +1,104 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// DefaultToolCancelGrace is how long a cancelled run waits for its running tool to return
+const DefaultToolCancelGrace = 5 * time.Second
+
+// Cancellation describes a run stopped by the cancellation of its context
+type Cancellation struct {
+	// Reason is the context error, e.g. "context canceled"
+	Reason string `json:"reason"`
+
+	// ToolCalls are the tool calls interrupted by the cancellation, with an ErrorMessage
+	// describing the interruption. A call that did not return within the grace period may still be running.
+	ToolCalls []*llm.ToolCall `json:"toolCalls,omitempty"`
+}
+
+// WithToolCancelGrace sets how long a cancelled run waits for its running tool to observe the
+// cancellation and return, DefaultToolCancelGrace by default. Past it, the run ends without the tool.
+func WithToolCancelGrace(grace time.Duration) RunnerOption {
+	return func(c *runnerConfig) {
+		c.toolCancelGrace = grace
+	}
+}
+
+// toolOutcome is the outcome of a tool execution
+type toolOutcome struct {
+	output any
+	err    error
+
+	// abandoned is set when the run was cancelled and the tool did not return within the grace period
+	abandoned bool
+
+	// simulated is set when the call was simulated by a dry run
+	simulated bool
+}
+
+// runTool runs tool until it returns or, once ctx is done, for at most the cancel grace period
+func (r *BaseRunner) runTool(ctx context.Context, tool ModelTool, input map[string]any) toolOutcome {
+	done := make(chan toolOutcome, 1)
+	go func() {
+		output, err := tool.Run(ctx, input)
+		done <- toolOutcome{output: output, err: err}
+	}()
+
+	select {
+	case result := <-done:
+		return result
+	case <-ctx.Done():
+	}
+	timer := time.NewTimer(r.toolCancelGrace)
+	defer timer.Stop()
+	select {
+	case result := <-done:
+		return result
+	case <-timer.C:
+		return toolOutcome{err: ctx.Err(), abandoned: true}
+	}
+}
+
+// interruptTool records a tool call cut short by the cancellation of the run and returns the run error.
+// A call that still succeeded keeps its compensation, so rollbacks undo it.
+func (r *BaseRunner) interruptTool(ctx context.Context, run *agentRun, tool ModelTool, toolCall *llm.ToolCall, result toolOutcome) error {
+	message := fmt.Sprintf("interrupted by cancellation: %s", ctx.Err())
+	switch {
+	case result.abandoned:
+		message = fmt.Sprintf("interrupted by cancellation: still running after %s", r.toolCancelGrace)
+	case result.err != nil && !errors.Is(result.err, ctx.Err()):
+		message += ": " + result.err.Error()
+	}
+	toolCall.Output = result.output
+	toolCall.ErrorMessage = &message
+	run.agentContext.appendInterrupted(toolCall)
+	if result.err == nil && !result.simulated {
+		run.agentContext.recordCompensation(tool, toolCall.Input, result.output)
+	}
+
+	r.notify(context.WithoutCancel(ctx), run, &LifecycleEvent{
+		Type:       LifecycleToolCalled,
+		Tool:       toolCall.Name,
+		Input:      toolCall.Input,
+		DurationMs: toolCall.EndAt.Sub(toolCall.StartAt).Milliseconds(),
+		Error:      message,
+	})
+	return fmt.Errorf("context cancelled: %w", ctx.Err())
+}
+
+// emitCancelled reports the cancellation of a run and the tool calls it interrupted
+func (r *BaseRunner) emitCancelled(ctx context.Context, run *agentRun) {
+	run.events.emit(AgentEvent{
+		Type: AgentEventTypeCancelled,
+		Cancellation: &Cancellation{
+			Reason:    ctx.Err().Error(),
+			ToolCalls: run.agentContext.InterruptedToolCalls(),
+		},
+	})
+}
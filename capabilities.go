@@ -0,0 +1,163 @@
+package agent
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// Capabilities are the features a model supports. Runners resolve them per model to adapt the tool
+// call format, prompt variant and options to it, see ProbeCapabilities.
+type Capabilities struct {
+	// NativeTools reports the provider API calls tools with structured arguments
+	NativeTools bool `json:"nativeTools"`
+
+	// JSONMode reports outputs can be constrained to JSON or a JSON schema
+	JSONMode bool `json:"jsonMode"`
+
+	// Reasoning reports the model streams reasoning chunks
+	Reasoning bool `json:"reasoning"`
+
+	// Vision reports the model accepts images
+	Vision bool `json:"vision"`
+
+	// PromptCache reports the provider caches prompt prefixes
+	PromptCache bool `json:"promptCache"`
+}
+
+// covers reports whether c has every capability set in required
+func (c Capabilities) covers(required Capabilities) bool {
+	return (!required.NativeTools || c.NativeTools) &&
+		(!required.JSONMode || c.JSONMode) &&
+		(!required.Reasoning || c.Reasoning) &&
+		(!required.Vision || c.Vision) &&
+		(!required.PromptCache || c.PromptCache)
+}
+
+// CapableModel is implemented by models reporting their capabilities, which take precedence over probing
+type CapableModel interface {
+	llm.CompletionModel
+
+	// Capabilities returns the capabilities of the model
+	Capabilities() Capabilities
+}
+
+// WithCapabilities sets the capabilities of the runner's model instead of probing them
+func WithCapabilities(capabilities Capabilities) RunnerOption {
+	return func(c *runnerConfig) {
+		c.capabilities = &capabilities
+	}
+}
+
+// ModelFamilyCapabilities is a rule of a model capability table
+type ModelFamilyCapabilities struct {
+	// Provider is the provider name, as returned by llm.ModelProvider.Name
+	Provider string
+
+	// ModelPrefix matches the models whose name starts with it, every model of the provider if empty
+	ModelPrefix string
+
+	// Capabilities are those of the matching models. JSONMode is given by the structured output
+	// support of the models instead, see DefaultStructuredOutputSupport.
+	Capabilities Capabilities
+}
+
+// DefaultModelFamilyCapabilities is the capabilities of common model families.
+// The rule with the longest matching prefix applies.
+var DefaultModelFamilyCapabilities = []ModelFamilyCapabilities{
+	{Provider: "openai", ModelPrefix: "gpt-5", Capabilities: Capabilities{NativeTools: true, Reasoning: true, Vision: true, PromptCache: true}},
+	{Provider: "openai", ModelPrefix: "gpt-4.1", Capabilities: Capabilities{NativeTools: true, Vision: true, PromptCache: true}},
+	{Provider: "openai", ModelPrefix: "gpt-4o", Capabilities: Capabilities{NativeTools: true, Vision: true, PromptCache: true}},
+	{Provider: "openai", ModelPrefix: "o1", Capabilities: Capabilities{NativeTools: true, Reasoning: true, Vision: true, PromptCache: true}},
+	{Provider: "openai", ModelPrefix: "o1-mini", Capabilities: Capabilities{Reasoning: true, PromptCache: true}},
+	{Provider: "openai", ModelPrefix: "o3", Capabilities: Capabilities{NativeTools: true, Reasoning: true, Vision: true, PromptCache: true}},
+	{Provider: "openai", ModelPrefix: "o4", Capabilities: Capabilities{NativeTools: true, Reasoning: true, Vision: true, PromptCache: true}},
+	{Provider: "claude", ModelPrefix: "claude-", Capabilities: Capabilities{NativeTools: true, Vision: true, PromptCache: true}},
+	{Provider: "claude", ModelPrefix: "claude-3-7", Capabilities: Capabilities{NativeTools: true, Reasoning: true, Vision: true, PromptCache: true}},
+	{Provider: "claude", ModelPrefix: "claude-sonnet-4", Capabilities: Capabilities{NativeTools: true, Reasoning: true, Vision: true, PromptCache: true}},
+	{Provider: "claude", ModelPrefix: "claude-opus-4", Capabilities: Capabilities{NativeTools: true, Reasoning: true, Vision: true, PromptCache: true}},
+	{Provider: "gemini", ModelPrefix: "gemini-", Capabilities: Capabilities{NativeTools: true, Vision: true}},
+	{Provider: "gemini", ModelPrefix: "gemini-2.5", Capabilities: Capabilities{NativeTools: true, Reasoning: true, Vision: true, PromptCache: true}},
+	{Provider: "deepseek", ModelPrefix: "deepseek-chat", Capabilities: Capabilities{NativeTools: true, PromptCache: true}},
+	{Provider: "deepseek", ModelPrefix: "deepseek-reasoner", Capabilities: Capabilities{Reasoning: true, PromptCache: true}},
+}
+
+// familyCapabilities returns the capabilities table gives to the model of provider, false if no rule matches
+func familyCapabilities(table []ModelFamilyCapabilities, provider, model string) (Capabilities, bool) {
+	var best *ModelFamilyCapabilities
+	for i, rule := range table {
+		if rule.Provider != provider || !strings.HasPrefix(model, rule.ModelPrefix) {
+			continue
+		}
+		if best == nil || len(rule.ModelPrefix) > len(best.ModelPrefix) {
+			best = &table[i]
+		}
+	}
+	if best == nil {
+		return Capabilities{}, false
+	}
+	return best.Capabilities, true
+}
+
+// ProbeCapabilities resolves the capabilities of model, named modelName at providerName. Models
+// implementing CapableModel report their own. Otherwise they are those of the model family of
+// DefaultModelFamilyCapabilities, completed by the model catalog of provider if not nil. JSONMode
+// is set for models implementing ConstrainedModel or supporting structured outputs according to
+// DefaultStructuredOutputSupport. It returns false if the model is not known, in which case
+// runners make no assumption about it.
+func ProbeCapabilities(providerName string, modelName string, model llm.CompletionModel, provider llm.ModelProvider) (Capabilities, bool) {
+	if capable, ok := model.(CapableModel); ok {
+		return capable.Capabilities(), true
+	}
+
+	if providerName == "anthropic" {
+		providerName = "claude"
+	}
+	capabilities, known := familyCapabilities(DefaultModelFamilyCapabilities, providerName, modelName)
+	if provider != nil {
+		if info := modelInfo(provider, modelName); info != nil {
+			capabilities.Reasoning = info.Reasoning
+			capabilities.Vision = slices.Contains(info.Input, llm.ModelMediaTypeImage)
+			capabilities.PromptCache = capabilities.PromptCache || info.Pricing.InputCacheRead > 0
+			known = true
+		}
+	}
+	if _, ok := model.(ConstrainedModel); ok {
+		capabilities.JSONMode = true
+	} else if NegotiateResponseFormat(DefaultStructuredOutputSupport, providerName, modelName) != "" {
+		capabilities.JSONMode = true
+	}
+	return capabilities, known
+}
+
+// modelInfo returns the catalog entry of model at provider, nil if it is not listed
+func modelInfo(provider llm.ModelProvider, model string) *llm.ModelInfo {
+	for _, info := range provider.SupportedModels() {
+		if info != nil && (info.ID == model || info.Name == model) {
+			return info
+		}
+	}
+	return nil
+}
+
+// Capabilities returns the capabilities of the runner's model, false if they are not known
+func (r *BaseRunner) Capabilities() (Capabilities, bool) {
+	if r.capabilities == nil {
+		return Capabilities{}, false
+	}
+	return *r.capabilities, true
+}
+
+// probeRunnerCapabilities returns the capabilities set with WithCapabilities or probed for the model
+// of agent, nil if they are not known
+func probeRunnerCapabilities(config *runnerConfig, agent *Agent, model llm.CompletionModel) *Capabilities {
+	if config.capabilities != nil {
+		return config.capabilities
+	}
+	capabilities, ok := ProbeCapabilities(agent.ModelProvider, agent.Model, model, nil)
+	if !ok {
+		return nil
+	}
+	return &capabilities
+}
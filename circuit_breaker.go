@@ -0,0 +1,371 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// CircuitState is the state of a circuit breaker
+type CircuitState int
+
+const (
+	// CircuitClosed lets calls through while recording their outcome
+	CircuitClosed CircuitState = iota
+
+	// CircuitOpen rejects calls until the open timeout elapses
+	CircuitOpen
+
+	// CircuitHalfOpen lets a few probe calls through to decide whether to close or reopen
+	CircuitHalfOpen
+)
+
+// String returns the name of the state
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitOpenError is returned by calls rejected by an open circuit breaker.
+// It matches ErrCircuitOpen with errors.Is.
+type CircuitOpenError struct {
+	// Name is the name of the breaker, e.g. the model or tool it protects
+	Name string
+
+	// RetryAfter is when the breaker lets a probe call through
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface
+func (e *CircuitOpenError) Error() string {
+	if e.RetryAfter <= 0 {
+		return fmt.Sprintf("circuit breaker '%s' is open", e.Name)
+	}
+	return fmt.Sprintf("circuit breaker '%s' is open, retry after %s", e.Name, e.RetryAfter.Round(time.Millisecond))
+}
+
+// Is reports whether target is ErrCircuitOpen
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// CircuitBreakerConfig configures when a circuit breaker opens and recovers
+type CircuitBreakerConfig struct {
+	// WindowSize is the number of recent calls the failure rate is computed over, 20 by default
+	WindowSize int
+
+	// MinCalls is the number of calls in the window before the breaker may open, 5 by default
+	MinCalls int
+
+	// FailureRate is the failure rate opening the breaker, 0.5 by default
+	FailureRate float64
+
+	// OpenTimeout is how long the breaker stays open before probing, 30 seconds by default
+	OpenTimeout time.Duration
+
+	// HalfOpenCalls is the number of successful probes closing the breaker, 1 by default.
+	// A failed probe reopens it.
+	HalfOpenCalls int
+
+	// IsFailure decides which errors count as failures, all but context cancellation by default
+	IsFailure func(err error) bool
+
+	// OnStateChange is called when the breaker changes state, after the breaker is unlocked, so it
+	// may call the breaker. Transitions racing in concurrent calls may be reported out of order.
+	OnStateChange func(name string, from CircuitState, to CircuitState)
+}
+
+// CircuitBreaker fails calls fast while a dependency is failing.
+// It opens when the failure rate of recent calls reaches a threshold, rejects calls for a
+// timeout, then lets probe calls through to decide whether the dependency recovered.
+// This type is safe for concurrent use.
+type CircuitBreaker struct {
+	name   string
+	config CircuitBreakerConfig
+
+	mu    sync.Mutex
+	state CircuitState
+
+	// generation changes with every state change, so outcomes of calls allowed in an earlier
+	// state are not mistaken for probes
+	generation uint64
+
+	outcomes []bool
+	next     int
+	failures int
+	openedAt time.Time
+
+	// probes counts the probe calls in flight and successes the successful ones while half open
+	probes    int
+	successes int
+
+	// transitions are the state changes not yet passed to OnStateChange
+	transitions []circuitTransition
+}
+
+// circuitTransition is a state change of a breaker
+type circuitTransition struct {
+	from CircuitState
+	to   CircuitState
+}
+
+// NewCircuitBreaker creates a closed circuit breaker, name identifies it in errors
+func NewCircuitBreaker(name string, config CircuitBreakerConfig) *CircuitBreaker {
+	if config.WindowSize <= 0 {
+		config.WindowSize = 20
+	}
+	if config.MinCalls <= 0 {
+		config.MinCalls = 5
+	}
+	if config.FailureRate <= 0 {
+		config.FailureRate = 0.5
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = 30 * time.Second
+	}
+	if config.HalfOpenCalls <= 0 {
+		config.HalfOpenCalls = 1
+	}
+	if config.IsFailure == nil {
+		config.IsFailure = func(err error) bool {
+			return !errors.Is(err, context.Canceled)
+		}
+	}
+	return &CircuitBreaker{
+		name:     name,
+		config:   config,
+		outcomes: make([]bool, 0, config.WindowSize),
+	}
+}
+
+// State returns the current state of the breaker
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CircuitOpen && time.Since(b.openedAt) >= b.config.OpenTimeout {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+// CircuitPermit is returned by Allow for an allowed call and passed back to Record with its outcome
+type CircuitPermit struct {
+	generation uint64
+}
+
+// Allow reports whether a call may proceed, returning a *CircuitOpenError if not.
+// Every allowed call must be followed by Record with the returned permit and its outcome.
+func (b *CircuitBreaker) Allow() (CircuitPermit, error) {
+	b.mu.Lock()
+	defer b.unlock()
+
+	if b.state == CircuitOpen {
+		elapsed := time.Since(b.openedAt)
+		if elapsed < b.config.OpenTimeout {
+			return CircuitPermit{}, &CircuitOpenError{Name: b.name, RetryAfter: b.config.OpenTimeout - elapsed}
+		}
+		b.setState(CircuitHalfOpen)
+	}
+	if b.state == CircuitHalfOpen {
+		if b.probes+b.successes >= b.config.HalfOpenCalls {
+			return CircuitPermit{}, &CircuitOpenError{Name: b.name}
+		}
+		b.probes++
+	}
+	return CircuitPermit{generation: b.generation}, nil
+}
+
+// Record records the outcome of a call allowed with permit.
+// Outcomes of calls allowed before the last state change are ignored.
+func (b *CircuitBreaker) Record(permit CircuitPermit, err error) {
+	failed := err != nil && b.config.IsFailure(err)
+
+	b.mu.Lock()
+	defer b.unlock()
+
+	if permit.generation != b.generation {
+		return
+	}
+	if b.state == CircuitHalfOpen {
+		b.probes--
+		if failed {
+			b.open()
+			return
+		}
+		b.successes++
+		if b.successes >= b.config.HalfOpenCalls {
+			b.reset()
+			b.setState(CircuitClosed)
+		}
+		return
+	}
+
+	if len(b.outcomes) < b.config.WindowSize {
+		b.outcomes = append(b.outcomes, failed)
+	} else {
+		if b.outcomes[b.next] {
+			b.failures--
+		}
+		b.outcomes[b.next] = failed
+		b.next = (b.next + 1) % b.config.WindowSize
+	}
+	if failed {
+		b.failures++
+	}
+	if len(b.outcomes) >= b.config.MinCalls && float64(b.failures)/float64(len(b.outcomes)) >= b.config.FailureRate {
+		b.open()
+	}
+}
+
+// open opens the breaker and clears its window. The caller must hold the lock.
+func (b *CircuitBreaker) open() {
+	b.reset()
+	b.openedAt = time.Now()
+	b.setState(CircuitOpen)
+}
+
+// reset clears the recorded outcomes and probes. The caller must hold the lock.
+func (b *CircuitBreaker) reset() {
+	b.outcomes = b.outcomes[:0]
+	b.next = 0
+	b.failures = 0
+	b.probes = 0
+	b.successes = 0
+}
+
+// setState changes the state and records the transition for the state change callback.
+// The caller must hold the lock and release it with unlock.
+func (b *CircuitBreaker) setState(state CircuitState) {
+	if b.state == state {
+		return
+	}
+	if b.config.OnStateChange != nil {
+		b.transitions = append(b.transitions, circuitTransition{from: b.state, to: state})
+	}
+	b.state = state
+	b.generation++
+}
+
+// unlock releases the lock, then passes the recorded transitions to the state change callback
+func (b *CircuitBreaker) unlock() {
+	transitions := b.transitions
+	b.transitions = nil
+	b.mu.Unlock()
+	for _, transition := range transitions {
+		b.config.OnStateChange(b.name, transition.from, transition.to)
+	}
+}
+
+// WrapModel returns a model whose calls go through the breaker.
+// Streaming calls succeed once the stream is established.
+func (b *CircuitBreaker) WrapModel(model llm.CompletionModel) llm.CompletionModel {
+	return &circuitModel{model: model, breaker: b}
+}
+
+// circuitModel is a model protected by a circuit breaker
+type circuitModel struct {
+	model   llm.CompletionModel
+	breaker *CircuitBreaker
+}
+
+var _ ConstrainedModel = (*circuitModel)(nil)
+
+// Complete calls the model unless the breaker is open
+func (m *circuitModel) Complete(ctx context.Context, req *llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	permit, err := m.breaker.Allow()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := m.model.Complete(ctx, req)
+	m.breaker.Record(permit, err)
+	return resp, err
+}
+
+// StreamComplete streams from the model unless the breaker is open
+func (m *circuitModel) StreamComplete(ctx context.Context, req *llm.CompletionRequest) (llm.StreamCompletionResponse, error) {
+	permit, err := m.breaker.Allow()
+	if err != nil {
+		return nil, err
+	}
+	stream, err := m.model.StreamComplete(ctx, req)
+	m.breaker.Record(permit, err)
+	return stream, err
+}
+
+// Constrain constrains the wrapped model if it supports constrained decoding, keeping the breaker
+func (m *circuitModel) Constrain(constraint *GenerationConstraint) (llm.CompletionModel, error) {
+	model, err := constrainModel(m.model, constraint)
+	if err != nil {
+		return nil, err
+	}
+	return &circuitModel{model: model, breaker: m.breaker}, nil
+}
+
+// circuitTool is a tool protected by a circuit breaker.
+// It forwards the Effect, DryRun and HealthCheck methods of the wrapped tool.
+type circuitTool struct {
+	ModelTool
+	breaker *CircuitBreaker
+}
+
+var (
+	_ EffectTool    = (*circuitTool)(nil)
+	_ DryRunTool    = (*circuitTool)(nil)
+	_ HealthChecker = (*circuitTool)(nil)
+)
+
+// compensatingCircuitTool is a compensating tool protected by a circuit breaker
+type compensatingCircuitTool struct {
+	*circuitTool
+}
+
+var _ CompensatingTool = (*compensatingCircuitTool)(nil)
+
+// WithCircuitBreaker wraps tool so its calls go through breaker.
+// The wrapper keeps the effect, dry run, health check and compensation of tool.
+func WithCircuitBreaker(tool ModelTool, breaker *CircuitBreaker) ModelTool {
+	wrapped := &circuitTool{
+		ModelTool: tool,
+		breaker:   breaker,
+	}
+	if _, ok := tool.(CompensatingTool); ok {
+		return &compensatingCircuitTool{circuitTool: wrapped}
+	}
+	return wrapped
+}
+
+// Compensate undoes a call of the wrapped tool, bypassing the breaker so rollbacks are attempted
+func (t *compensatingCircuitTool) Compensate(ctx context.Context, input map[string]any, output any) error {
+	return t.ModelTool.(CompensatingTool).Compensate(ctx, input, output)
+}
+
+// Effect returns the effect of the wrapped tool
+func (t *circuitTool) Effect() ToolEffect {
+	return ToolEffectOf(t.ModelTool, ToolOptions{})
+}
+
+// DryRun simulates a call of the wrapped tool, bypassing the breaker
+func (t *circuitTool) DryRun(ctx context.Context, input map[string]any) (any, error) {
+	return dryRun(ctx, t.ModelTool, input)
+}
+
+// Run calls the tool unless the breaker is open
+func (t *circuitTool) Run(ctx context.Context, input map[string]any) (any, error) {
+	permit, err := t.breaker.Allow()
+	if err != nil {
+		return nil, fmt.Errorf("tool '%s' is unavailable, do not call it again: %w", t.Name(), err)
+	}
+	output, err := t.ModelTool.Run(ctx, input)
+	t.breaker.Record(permit, err)
+	return output, err
+}
@@ -0,0 +1,122 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerIgnoresCallsFromEarlierStates(t *testing.T) {
+	breaker := NewCircuitBreaker("test", CircuitBreakerConfig{
+		WindowSize:  2,
+		MinCalls:    2,
+		OpenTimeout: time.Millisecond,
+	})
+	failure := errors.New("failure")
+
+	// A slow call allowed while closed
+	slow, err := breaker.Allow()
+	if err != nil {
+		t.Fatalf("Allow() error = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		permit, err := breaker.Allow()
+		if err != nil {
+			t.Fatalf("Allow() error = %v", err)
+		}
+		breaker.Record(permit, failure)
+	}
+	if state := breaker.State(); state != CircuitOpen {
+		t.Fatalf("State() = %s, want open", state)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	probe, err := breaker.Allow()
+	if err != nil {
+		t.Fatalf("Allow() probe error = %v", err)
+	}
+
+	// The slow call finishing while half open is not a probe
+	breaker.Record(slow, nil)
+	if _, err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Allow() with a probe in flight error = %v, want ErrCircuitOpen", err)
+	}
+	if state := breaker.State(); state != CircuitHalfOpen {
+		t.Fatalf("State() = %s, want half_open", state)
+	}
+
+	breaker.Record(probe, nil)
+	if state := breaker.State(); state != CircuitClosed {
+		t.Fatalf("State() = %s, want closed", state)
+	}
+}
+
+func TestCircuitBreakerStateChangeCallbackMayUseBreaker(t *testing.T) {
+	var breaker *CircuitBreaker
+	var states []CircuitState
+	breaker = NewCircuitBreaker("test", CircuitBreakerConfig{
+		WindowSize: 1,
+		MinCalls:   1,
+		OnStateChange: func(name string, from CircuitState, to CircuitState) {
+			// Calling the breaker from the callback deadlocks if the lock is still held
+			states = append(states, breaker.State())
+		},
+	})
+
+	permit, err := breaker.Allow()
+	if err != nil {
+		t.Fatalf("Allow() error = %v", err)
+	}
+	breaker.Record(permit, errors.New("failure"))
+	if len(states) != 1 || states[0] != CircuitOpen {
+		t.Fatalf("states seen by the callback = %v, want [open]", states)
+	}
+}
+
+// optionalTool implements every optional tool interface
+type optionalTool struct {
+	benchmarkTool
+	compensated bool
+}
+
+func (t *optionalTool) Effect() ToolEffect { return ToolEffectDestructive }
+
+func (t *optionalTool) DryRun(ctx context.Context, input map[string]any) (any, error) {
+	return "simulated", nil
+}
+
+func (t *optionalTool) HealthCheck(ctx context.Context) error { return errors.New("unhealthy") }
+
+func (t *optionalTool) Compensate(ctx context.Context, input map[string]any, output any) error {
+	t.compensated = true
+	return nil
+}
+
+func TestWithCircuitBreakerKeepsOptionalInterfaces(t *testing.T) {
+	breaker := NewCircuitBreaker("tool", CircuitBreakerConfig{})
+
+	tool := &optionalTool{benchmarkTool: benchmarkTool{name: "delete_file"}}
+	wrapped := WithCircuitBreaker(tool, breaker)
+	if effect := ToolEffectOf(wrapped, ToolOptions{}); effect != ToolEffectDestructive {
+		t.Errorf("ToolEffectOf() = %v, want %v", effect, ToolEffectDestructive)
+	}
+	if output, err := wrapped.(DryRunTool).DryRun(context.Background(), nil); err != nil || output != "simulated" {
+		t.Errorf("DryRun() = %v, %v, want simulated", output, err)
+	}
+	if err := wrapped.(HealthChecker).HealthCheck(context.Background()); err == nil {
+		t.Error("HealthCheck() succeeded, want the error of the wrapped tool")
+	}
+	compensating, ok := wrapped.(CompensatingTool)
+	if !ok {
+		t.Fatal("wrapped compensating tool is not a CompensatingTool")
+	}
+	if err := compensating.Compensate(context.Background(), nil, nil); err != nil || !tool.compensated {
+		t.Errorf("Compensate() error = %v, compensated = %v", err, tool.compensated)
+	}
+
+	// Tools without compensation must not look compensable to transactions
+	if _, ok := WithCircuitBreaker(&benchmarkTool{name: "search"}, breaker).(CompensatingTool); ok {
+		t.Error("wrapped tool without compensation is a CompensatingTool")
+	}
+}
@@ -0,0 +1,88 @@
+// Command agentcli runs an agent defined in a YAML or JSON file against a prompt,
+// streaming its events to the terminal.
+//
+//	agentcli -config agent.yaml "What's the weather in Tokyo?"
+//	echo "Summarize this" | agentcli -config agent.yaml
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/easyagent-dev/agent"
+	"github.com/easyagent-dev/agent/config"
+	"github.com/easyagent-dev/llm"
+)
+
+func main() {
+	configPath := flag.String("config", "agent.yaml", "agent definition file (YAML or JSON)")
+	noColor := flag.Bool("no-color", false, "disable colorized output")
+	trace := flag.Bool("trace", false, "print model and tool callbacks")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: agentcli [flags] [prompt]\n\nThe prompt is read from stdin when not given.\n\nFlags:\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if err := run(*configPath, strings.Join(flag.Args(), " "), *trace, newPrinter(os.Stdout, !*noColor)); err != nil {
+		fmt.Fprintf(os.Stderr, "agentcli: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(configPath string, prompt string, trace bool, out *printer) error {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return err
+	}
+
+	if prompt == "" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt: %w", err)
+		}
+		prompt = strings.TrimSpace(string(data))
+	}
+	if prompt == "" {
+		return errors.New("prompt is required")
+	}
+
+	loader := config.NewLoader()
+	registerTools(loader)
+	runner, _, err := loader.NewStreamRunner(cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var callback agent.Callback
+	if trace {
+		callback = agent.NewDefaultCallback(true)
+	}
+	req := cfg.NewRequest(&llm.ModelMessage{Role: llm.RoleUser, Content: prompt})
+	req.SharedState = agent.NewSharedState()
+	streamResp, err := runner.Run(ctx, req, callback)
+	if err != nil {
+		return err
+	}
+
+	for event := range streamResp.Events() {
+		out.event(event)
+	}
+	resp, err := streamResp.Wait()
+	if resp != nil {
+		out.usage(resp.Usage, resp.Cost)
+	}
+	if err != nil {
+		return errors.New("agent run failed")
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/easyagent-dev/agent"
+	"github.com/easyagent-dev/llm"
+)
+
+// ANSI color codes
+const (
+	colorReset  = "\033[0m"
+	colorGray   = "\033[90m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorBlue   = "\033[34m"
+	colorCyan   = "\033[36m"
+)
+
+// printer writes agent events to the terminal
+type printer struct {
+	w     io.Writer
+	color bool
+
+	// streaming is true while partial text is being printed on the current line
+	streaming bool
+}
+
+func newPrinter(w io.Writer, color bool) *printer {
+	return &printer{w: w, color: color}
+}
+
+func (p *printer) colorize(color string, text string) string {
+	if !p.color {
+		return text
+	}
+	return color + text + colorReset
+}
+
+// event prints a single event. Partial tool calls are skipped, only completed calls are shown.
+func (p *printer) event(event agent.AgentEvent) {
+	if event.Type != agent.AgentEventTypeText && p.streaming {
+		fmt.Fprintln(p.w)
+		p.streaming = false
+	}
+
+	switch event.Type {
+	case agent.AgentEventTypeText:
+		if event.Text != nil {
+			fmt.Fprint(p.w, *event.Text)
+			p.streaming = true
+		}
+	case agent.AgentEventTypeReasoning:
+		if event.Reasoning != nil {
+			fmt.Fprintln(p.w, p.colorize(colorGray, "[reasoning] "+*event.Reasoning))
+		}
+	case agent.AgentEventTypeUseTool:
+		if event.Partial || event.ToolCall == nil {
+			return
+		}
+		input, _ := json.Marshal(event.ToolCall.Input)
+		fmt.Fprintln(p.w, p.colorize(colorBlue, fmt.Sprintf("[%s] %s %s", event.Agent, event.ToolCall.Name, input)))
+	case agent.AgentEventTypeHandoff:
+		if event.Handoff != nil {
+			fmt.Fprintln(p.w, p.colorize(colorCyan, fmt.Sprintf("[handoff] -> %s: %s", event.Handoff.Agent, event.Handoff.Note)))
+		}
+	case agent.AgentEventTypeOutput:
+		output, _ := json.MarshalIndent(event.Output, "", "  ")
+		label := "[output]"
+		if event.Partial {
+			label = "[partial output]"
+		}
+		fmt.Fprintln(p.w, p.colorize(colorGreen, label))
+		fmt.Fprintln(p.w, string(output))
+	case agent.AgentEventTypeError:
+		if event.ErrorMessage != nil {
+			fmt.Fprintln(p.w, p.colorize(colorRed, "[error] "+*event.ErrorMessage))
+		}
+	}
+}
+
+// usage prints the token usage and cost of the run
+func (p *printer) usage(usage *llm.TokenUsage, cost *float64) {
+	if usage == nil {
+		return
+	}
+	total := 0.0
+	if cost != nil {
+		total = *cost
+	}
+	fmt.Fprintln(p.w, p.colorize(colorYellow, fmt.Sprintf("[usage] in:%d out:%d cache_read:%d cost:$%.6f",
+		usage.TotalInputTokens, usage.TotalOutputTokens, usage.TotalCacheReadTokens, total)))
+}
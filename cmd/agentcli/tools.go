@@ -0,0 +1,22 @@
+package main
+
+import (
+	"github.com/easyagent-dev/agent"
+	"github.com/easyagent-dev/agent/config"
+	"github.com/easyagent-dev/agent/tools/standard"
+)
+
+// registerTools registers the tools a definition can reference by name
+func registerTools(loader *config.Loader) {
+	loader.RegisterTool("shared_state_get", func(options map[string]any) (agent.ModelTool, error) {
+		return agent.NewSharedStateGetTool(), nil
+	})
+	loader.RegisterTool("shared_state_set", func(options map[string]any) (agent.ModelTool, error) {
+		return agent.NewSharedStateSetTool(), nil
+	})
+	for _, tool := range standard.NewStandardTools(standard.WithCapabilities(standard.AllCapabilities)) {
+		loader.RegisterTool(tool.Name(), func(options map[string]any) (agent.ModelTool, error) {
+			return tool, nil
+		})
+	}
+}
@@ -0,0 +1,122 @@
+// Command rundiff compares two runs recorded with agent.TranscriptCallback, e.g. before and
+// after a prompt change: iterations, tool call divergence, token deltas and output changes.
+//
+//	rundiff before.jsonl after.jsonl
+//	rundiff -json -before-run 42 -after-run 43 runs.jsonl runs.jsonl
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/easyagent-dev/agent"
+)
+
+func main() {
+	beforeRun := flag.String("before-run", "", "run ID in the before transcript, its first run by default")
+	afterRun := flag.String("after-run", "", "run ID in the after transcript, its first run by default")
+	completionTool := flag.String("completion-tool", agent.CompleteTaskToolName, "name of the completion tool")
+	asJSON := flag.Bool("json", false, "print the diff as JSON")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: rundiff [flags] before.jsonl after.jsonl\n\nFlags:\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(flag.Arg(0), *beforeRun, flag.Arg(1), *afterRun, *completionTool, *asJSON, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "rundiff: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(beforePath, beforeRun, afterPath, afterRun, completionTool string, asJSON bool, w io.Writer) error {
+	before, err := readTrace(beforePath, beforeRun)
+	if err != nil {
+		return err
+	}
+	after, err := readTrace(afterPath, afterRun)
+	if err != nil {
+		return err
+	}
+	diff := agent.DiffRuns(before, after, agent.DiffOptions{CompletionTool: completionTool})
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(diff)
+	}
+	printDiff(w, diff)
+	return nil
+}
+
+// readTrace reads the run of a transcript with runID, or its first run if runID is empty
+func readTrace(path, runID string) (*agent.RunTrace, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	traces, err := agent.ReadRunTraces(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, trace := range traces {
+		if runID == "" || trace.RunID == runID {
+			return trace, nil
+		}
+	}
+	if runID == "" {
+		return nil, fmt.Errorf("%s: no run recorded", path)
+	}
+	return nil, fmt.Errorf("%s: run %s not found", path, runID)
+}
+
+func printDiff(w io.Writer, diff *agent.RunDiff) {
+	fmt.Fprintf(w, "iterations:    %d -> %d (%+d)\n", diff.Before.ModelCalls, diff.After.ModelCalls, diff.IterationsDelta)
+	fmt.Fprintf(w, "tool calls:    %d -> %d (%+d)\n", len(diff.Before.ToolCalls), len(diff.After.ToolCalls), diff.ToolCallsDelta)
+	fmt.Fprintf(w, "input tokens:  %d -> %d (%+d)\n", diff.Before.Usage.TotalInputTokens, diff.After.Usage.TotalInputTokens, diff.InputTokensDelta)
+	fmt.Fprintf(w, "output tokens: %d -> %d (%+d)\n", diff.Before.Usage.TotalOutputTokens, diff.After.Usage.TotalOutputTokens, diff.OutputTokensDelta)
+	fmt.Fprintf(w, "duration:      %dms -> %dms (%+dms)\n", diff.Before.DurationMs, diff.After.DurationMs, diff.DurationMsDelta)
+
+	if diff.Divergence == nil {
+		fmt.Fprintln(w, "\nsame tool calls")
+	} else {
+		fmt.Fprintf(w, "\ntool calls diverge at call %d:\n", diff.Divergence.Index+1)
+		fmt.Fprintf(w, "  - %s\n", formatCall(diff.Divergence.Before))
+		fmt.Fprintf(w, "  + %s\n", formatCall(diff.Divergence.After))
+	}
+
+	if len(diff.OutputChanges) == 0 {
+		fmt.Fprintln(w, "\nsame output")
+		return
+	}
+	fmt.Fprintln(w, "\noutput changes:")
+	for _, change := range diff.OutputChanges {
+		switch change.Kind {
+		case agent.OutputChangeAdded:
+			fmt.Fprintf(w, "  + %s: %s\n", change.Path, formatValue(change.After))
+		case agent.OutputChangeRemoved:
+			fmt.Fprintf(w, "  - %s: %s\n", change.Path, formatValue(change.Before))
+		default:
+			fmt.Fprintf(w, "  ~ %s: %s -> %s\n", change.Path, formatValue(change.Before), formatValue(change.After))
+		}
+	}
+}
+
+func formatCall(call *agent.TracedToolCall) string {
+	if call == nil {
+		return "(none)"
+	}
+	return call.Tool + " " + formatValue(call.Input)
+}
+
+func formatValue(value any) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
@@ -0,0 +1,130 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// CompensateFunc undoes the effects of a successful tool call given its input and output
+type CompensateFunc func(ctx context.Context, input map[string]any, output any) error
+
+// CompensatingTool is implemented by tools able to undo their calls.
+// Compensations of a run are recorded as its tool calls succeed and run in reverse order
+// on rollback, following the saga pattern.
+type CompensatingTool interface {
+	ModelTool
+
+	// Compensate undoes a successful call of the tool
+	Compensate(ctx context.Context, input map[string]any, output any) error
+}
+
+// compensatedTool adds a compensation function to a tool
+type compensatedTool struct {
+	ModelTool
+	compensate CompensateFunc
+}
+
+var _ CompensatingTool = (*compensatedTool)(nil)
+
+// WithCompensation wraps tool so its calls are undone by compensate on rollback
+func WithCompensation(tool ModelTool, compensate CompensateFunc) ModelTool {
+	return &compensatedTool{
+		ModelTool:  tool,
+		compensate: compensate,
+	}
+}
+
+// Effect returns the effect of the wrapped tool
+func (t *compensatedTool) Effect() ToolEffect {
+	return ToolEffectOf(t.ModelTool, ToolOptions{})
+}
+
+// DryRun simulates a call of the wrapped tool
+func (t *compensatedTool) DryRun(ctx context.Context, input map[string]any) (any, error) {
+	return dryRun(ctx, t.ModelTool, input)
+}
+
+// Compensate calls the compensation function
+func (t *compensatedTool) Compensate(ctx context.Context, input map[string]any, output any) error {
+	return t.compensate(ctx, input, output)
+}
+
+// compensation is a recorded undo action of a tool call
+type compensation struct {
+	tool   string
+	run    CompensateFunc
+	input  map[string]any
+	output any
+}
+
+// Compensations records the undo actions of the tool calls of a run.
+// This type is safe for concurrent use.
+type Compensations struct {
+	mu      sync.Mutex
+	entries []compensation
+}
+
+// Add records the compensation of a successful call of tool
+func (c *Compensations) Add(tool string, input map[string]any, output any, compensate CompensateFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = append(c.entries, compensation{
+		tool:   tool,
+		run:    compensate,
+		input:  input,
+		output: output,
+	})
+}
+
+// Len returns the number of pending compensations
+func (c *Compensations) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.entries)
+}
+
+// Rollback runs the pending compensations in reverse order and clears them.
+// A failed compensation does not stop the others, all errors are returned joined.
+func (c *Compensations) Rollback(ctx context.Context) error {
+	return c.RollbackTo(ctx, 0)
+}
+
+// RollbackTo runs the compensations recorded after the first mark ones in reverse order
+// and clears them, e.g. to undo a transaction started when Len returned mark
+func (c *Compensations) RollbackTo(ctx context.Context, mark int) error {
+	c.mu.Lock()
+	if mark < 0 || mark > len(c.entries) {
+		mark = len(c.entries)
+	}
+	entries := c.entries[mark:]
+	c.entries = c.entries[:mark:mark]
+	c.mu.Unlock()
+
+	var errs []error
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if err := entry.run(ctx, entry.input, entry.output); err != nil {
+			errs = append(errs, fmt.Errorf("failed to compensate %s: %w", entry.tool, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// recordCompensation records the compensation of a successful call of tool, if it has one
+func (ac *AgentContext) recordCompensation(tool ModelTool, input map[string]any, output any) {
+	if t, ok := tool.(CompensatingTool); ok {
+		ac.Compensations.Add(tool.Name(), input, output, t.Compensate)
+	}
+}
+
+// Rollback undoes the tool calls of the run that registered compensations, most recent first
+func (ac *AgentContext) Rollback(ctx context.Context) error {
+	if ac.Compensations == nil {
+		return nil
+	}
+	return ac.Compensations.Rollback(ctx)
+}
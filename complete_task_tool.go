@@ -6,8 +6,27 @@ import (
 
 const CompleteTaskToolName = "complete_task"
 
-// CompleteTaskTool is a mock implementation of the complete_task tool for testing
+// DefaultCompleteTaskDescription is the description of the default completion tool
+const DefaultCompleteTaskDescription = "Completes the user query and output the final results"
+
+// CompletionToolConfig configures the tool the model calls to finish the task
+type CompletionToolConfig struct {
+	// Name is the tool name, defaults to complete_task
+	Name string
+
+	// Description is the tool description, defaults to DefaultCompleteTaskDescription
+	Description string
+
+	// WrapKey, if set, nests the output schema under this property of the tool input,
+	// e.g. {"diagnosis": <output>}. The output is unwrapped when the task completes.
+	WrapKey string
+}
+
+// CompleteTaskTool is the tool the model calls with the final output to complete the task
 type CompleteTaskTool struct {
+	name         string
+	description  string
+	wrapKey      string
 	outputSchema any
 	usage        string
 }
@@ -15,25 +34,49 @@ type CompleteTaskTool struct {
 var _ ModelTool = &CompleteTaskTool{}
 
 func NewCompleteTaskTool(outputSchema any, usage string) *CompleteTaskTool {
-	return &CompleteTaskTool{
+	return NewCompletionTool(CompletionToolConfig{}, outputSchema, usage)
+}
+
+// NewCompletionTool creates a completion tool with a custom name, description or output wrapping
+func NewCompletionTool(config CompletionToolConfig, outputSchema any, usage string) *CompleteTaskTool {
+	tool := &CompleteTaskTool{
+		name:         config.Name,
+		description:  config.Description,
+		wrapKey:      config.WrapKey,
 		outputSchema: outputSchema,
 		usage:        usage,
 	}
+	if tool.name == "" {
+		tool.name = CompleteTaskToolName
+	}
+	if tool.description == "" {
+		tool.description = DefaultCompleteTaskDescription
+	}
+	return tool
 }
 
 // Name returns the name of the tool
 func (t *CompleteTaskTool) Name() string {
-	return CompleteTaskToolName
+	return t.name
 }
 
 // Description returns a description of what the tool does
 func (t *CompleteTaskTool) Description() string {
-	return "Completes the user query and output the final results"
+	return t.description
 }
 
 // InputSchema generates a JSON schema from the InputType
 func (t *CompleteTaskTool) InputSchema() any {
-	return t.outputSchema
+	if t.wrapKey == "" {
+		return t.outputSchema
+	}
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			t.wrapKey: t.outputSchema,
+		},
+		"required": []string{t.wrapKey},
+	}
 }
 
 func (t *CompleteTaskTool) OutputSchema() any {
@@ -47,5 +90,8 @@ func (t *CompleteTaskTool) Usage() string {
 
 // Run runs the tool with the provided parameters
 func (t *CompleteTaskTool) Run(ctx context.Context, input map[string]any) (any, error) {
-	return input, nil
+	if t.wrapKey == "" {
+		return input, nil
+	}
+	return input[t.wrapKey], nil
 }
@@ -0,0 +1,236 @@
+package agent
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/easyagent-dev/llm"
+)
+
+//go:embed prompts/confidence_system.md
+var confidenceSystemPrompt string
+
+// ConfidenceScore is the confidence of a scorer in a final output
+type ConfidenceScore struct {
+	// Confidence is the likelihood the output is correct, from 0 to 1
+	Confidence float64 `json:"confidence"`
+
+	// Rationale explains the score, if the scorer gives one
+	Rationale string `json:"rationale,omitempty"`
+
+	// Usage and Cost are the usage and cost of scoring, e.g. of a judge model call
+	Usage *llm.TokenUsage `json:"usage,omitempty"`
+	Cost  *float64        `json:"cost,omitempty"`
+}
+
+// ConfidenceScorer rates the final output of a run after it completes
+type ConfidenceScorer interface {
+	ScoreOutput(ctx context.Context, req *AgentRequest, resp *AgentResponse) (*ConfidenceScore, error)
+}
+
+// ConfidenceScorerFunc adapts a function to the ConfidenceScorer interface
+type ConfidenceScorerFunc func(ctx context.Context, req *AgentRequest, resp *AgentResponse) (*ConfidenceScore, error)
+
+// ScoreOutput calls f
+func (f ConfidenceScorerFunc) ScoreOutput(ctx context.Context, req *AgentRequest, resp *AgentResponse) (*ConfidenceScore, error) {
+	return f(ctx, req, resp)
+}
+
+// JudgeScorer asks a judge model to rate the final output
+type JudgeScorer struct {
+	judge        llm.CompletionModel
+	instructions string
+}
+
+var _ ConfidenceScorer = (*JudgeScorer)(nil)
+
+// NewJudgeScorer creates a scorer asking judge to rate outputs, following instructions if set
+func NewJudgeScorer(judge llm.CompletionModel, instructions string) *JudgeScorer {
+	return &JudgeScorer{judge: judge, instructions: instructions}
+}
+
+// ScoreOutput asks the judge to rate the output of resp for the conversation of req
+func (s *JudgeScorer) ScoreOutput(ctx context.Context, req *AgentRequest, resp *AgentResponse) (*ConfidenceScore, error) {
+	answer, err := json.Marshal(resp.Output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal output: %w", err)
+	}
+	prompts, err := llm.GetPrompts(confidenceSystemPrompt, map[string]interface{}{
+		"answer":       string(answer),
+		"instructions": s.instructions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prompts: %w", err)
+	}
+
+	output, err := s.judge.Complete(ctx, &llm.CompletionRequest{
+		Instructions: prompts,
+		Messages:     req.Messages,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scoring failed: %w", err)
+	}
+	score := &ConfidenceScore{Usage: output.Usage, Cost: output.Cost}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output.Output)), score); err != nil {
+		return score, fmt.Errorf("failed to parse confidence score: %w", err)
+	}
+	score.Confidence = min(max(score.Confidence, 0), 1)
+	return score, nil
+}
+
+// ConfidenceFromLogprobs turns the log probabilities of the output tokens into a confidence,
+// their geometric mean probability. Scorers for models exposing log probabilities can use it.
+func ConfidenceFromLogprobs(logprobs []float64) float64 {
+	if len(logprobs) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, logprob := range logprobs {
+		sum += logprob
+	}
+	return math.Exp(sum / float64(len(logprobs)))
+}
+
+// ConfidenceOption is a functional option for configuring confidence runners
+type ConfidenceOption func(*ConfidenceRunner)
+
+// WithConfidenceThreshold sets the confidence below which outputs are retried, 0.7 by default
+func WithConfidenceThreshold(threshold float64) ConfidenceOption {
+	return func(r *ConfidenceRunner) {
+		r.threshold = threshold
+	}
+}
+
+// WithConfidenceRetries sets how many times a run scoring below the threshold is retried
+// before escalating, 1 by default
+func WithConfidenceRetries(retries int) ConfidenceOption {
+	return func(r *ConfidenceRunner) {
+		r.retries = retries
+	}
+}
+
+// WithEscalation sets the runner, e.g. with a stronger model, running the request once the
+// retries scored below the threshold
+func WithEscalation(runner Runner) ConfidenceOption {
+	return func(r *ConfidenceRunner) {
+		r.escalation = runner
+	}
+}
+
+// ConfidenceRunner scores the output of a runner and, when the confidence is below a threshold,
+// retries the request and then escalates it to another runner. The response with the highest
+// confidence is returned, carrying the usage and cost of every attempt and of the scoring.
+type ConfidenceRunner struct {
+	runner     Runner
+	scorer     ConfidenceScorer
+	threshold  float64
+	retries    int
+	escalation Runner
+}
+
+var _ Runner = (*ConfidenceRunner)(nil)
+
+// DefaultConfidenceThreshold is the default confidence below which outputs are retried
+const DefaultConfidenceThreshold = 0.7
+
+// NewConfidenceRunner creates a runner scoring the outputs of runner with scorer
+func NewConfidenceRunner(runner Runner, scorer ConfidenceScorer, opts ...ConfidenceOption) (*ConfidenceRunner, error) {
+	if runner == nil {
+		return nil, errors.New("runner is required")
+	}
+	if scorer == nil {
+		return nil, errors.New("confidence scorer is required")
+	}
+	r := &ConfidenceRunner{
+		runner:    runner,
+		scorer:    scorer,
+		threshold: DefaultConfidenceThreshold,
+		retries:   1,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.threshold < 0 || r.threshold > 1 {
+		return nil, fmt.Errorf("confidence threshold must be between 0 and 1, got %v", r.threshold)
+	}
+	if r.retries < 0 {
+		return nil, errors.New("confidence retries must not be negative")
+	}
+	return r, nil
+}
+
+// Run runs and scores the request until an attempt reaches the threshold.
+// If no attempt succeeds, the error of the last one is returned.
+func (r *ConfidenceRunner) Run(ctx context.Context, req *AgentRequest, callback Callback) (*AgentResponse, error) {
+	runners := make([]Runner, 0, r.retries+2)
+	for i := 0; i <= r.retries; i++ {
+		runners = append(runners, r.runner)
+	}
+	if r.escalation != nil {
+		runners = append(runners, r.escalation)
+	}
+
+	usage := &llm.TokenUsage{}
+	totalCost := 0.0
+	var best *AgentResponse
+	var lastErr error
+	for i, runner := range runners {
+		attemptReq := req
+		if req.RunID != "" && i > 0 {
+			// Attempts are separate runs, their tool calls must not share idempotency keys
+			copied := *req
+			copied.RunID = fmt.Sprintf("%s/%d", req.RunID, i)
+			attemptReq = &copied
+		}
+		resp, err := runner.Run(ctx, attemptReq, callback)
+		if resp != nil {
+			if resp.Usage != nil {
+				usage.Append(resp.Usage)
+			}
+			if resp.Cost != nil {
+				totalCost += *resp.Cost
+			}
+		}
+		if err != nil {
+			lastErr = err
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+
+		score, err := r.scorer.ScoreOutput(ctx, attemptReq, resp)
+		if score != nil {
+			if score.Usage != nil {
+				usage.Append(score.Usage)
+			}
+			if score.Cost != nil {
+				totalCost += *score.Cost
+			}
+		}
+		if err != nil {
+			lastErr = fmt.Errorf("failed to score output: %w", err)
+			continue
+		}
+		confidence := score.Confidence
+		resp.Confidence = &confidence
+		if best == nil || confidence > *best.Confidence {
+			best = resp
+		}
+		if confidence >= r.threshold {
+			break
+		}
+	}
+
+	if best == nil {
+		return &AgentResponse{Usage: usage, Cost: &totalCost}, lastErr
+	}
+	best.Usage = usage
+	best.Cost = &totalCost
+	return best, nil
+}
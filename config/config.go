@@ -0,0 +1,288 @@
+// Package config builds agents and runners from declarative YAML or JSON definitions,
+// so agents can be defined by configuration rather than code changes.
+//
+//	name: assistant
+//	description: A general purpose assistant
+//	instructions: You are a helpful assistant.
+//	model:
+//	  provider: claude
+//	  name: claude-sonnet-4-5
+//	format: xml
+//	tools:
+//	  - calculator
+//	  - name: search
+//	    options:
+//	      max_results: 5
+//	limits:
+//	  max_iterations: 10
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/easyagent-dev/agent"
+	"github.com/easyagent-dev/agent/policy"
+	"github.com/easyagent-dev/llm"
+	"gopkg.in/yaml.v3"
+)
+
+// Tool call formats
+const (
+	FormatJSON = "json"
+	FormatXML  = "xml"
+)
+
+// Response formats negotiating constrained decoding or disabling it, next to json_schema and json
+const (
+	ResponseFormatAuto = "auto"
+	ResponseFormatNone = "none"
+)
+
+// Config is a declarative agent definition
+type Config struct {
+	AgentConfig `yaml:",inline"`
+
+	// Model configures the model provider and completion options
+	Model ModelConfig `yaml:"model" json:"model"`
+
+	// Format is the tool call format, json (default) or xml
+	Format string `yaml:"format,omitempty" json:"format,omitempty"`
+
+	// SystemPrompt replaces the default system prompt template
+	SystemPrompt string `yaml:"system_prompt,omitempty" json:"system_prompt,omitempty"`
+
+	// PromptVariants are system prompt templates selected by the agent's provider and model
+	PromptVariants []PromptVariantConfig `yaml:"prompt_variants,omitempty" json:"prompt_variants,omitempty"`
+
+	// CompletionTool renames or replaces the built-in complete_task tool
+	CompletionTool *CompletionToolConfig `yaml:"completion_tool,omitempty" json:"completion_tool,omitempty"`
+
+	// Limits bound the execution of runs
+	Limits LimitsConfig `yaml:"limits" json:"limits"`
+
+	// Policy constrains the tool calls of runs, see the policy package
+	Policy *policy.Policy `yaml:"policy,omitempty" json:"policy,omitempty"`
+}
+
+// AgentConfig defines an agent and the agents it can hand off to
+type AgentConfig struct {
+	Name         string        `yaml:"name" json:"name"`
+	Description  string        `yaml:"description" json:"description"`
+	Instructions string        `yaml:"instructions" json:"instructions"`
+	Tools        []ToolConfig  `yaml:"tools,omitempty" json:"tools,omitempty"`
+	Handoffs     []AgentConfig `yaml:"handoffs,omitempty" json:"handoffs,omitempty"`
+
+	// ToolPreferences add guidance on which tool to use when several apply
+	ToolPreferences []ToolPreferenceConfig `yaml:"tool_preferences,omitempty" json:"tool_preferences,omitempty"`
+}
+
+// ModelConfig configures the model provider and completion options
+type ModelConfig struct {
+	// Provider is the name of a registered provider factory, e.g. openai or claude
+	Provider string `yaml:"provider" json:"provider"`
+
+	// Name is the model name
+	Name string `yaml:"name" json:"name"`
+
+	// APIKeyEnv is the environment variable holding the API key.
+	// If empty, the provider's default variable is used.
+	APIKeyEnv string `yaml:"api_key_env,omitempty" json:"api_key_env,omitempty"`
+
+	// BaseURL overrides the provider's API endpoint
+	BaseURL string `yaml:"base_url,omitempty" json:"base_url,omitempty"`
+
+	Temperature *float64 `yaml:"temperature,omitempty" json:"temperature,omitempty"`
+	MaxTokens   int      `yaml:"max_tokens,omitempty" json:"max_tokens,omitempty"`
+
+	// Local configures the runner for a local model, see agent.WithLocalModel.
+	// Pricing, if set, still applies.
+	Local bool `yaml:"local,omitempty" json:"local,omitempty"`
+
+	// ResponseFormat constrains the tool calls the model generates, json_schema for structured outputs
+	// or json for JSON mode, see agent.NewConstrainedModel. It is negotiated from the provider and
+	// model if empty or auto, see agent.NewNegotiatedModel, and none disables it.
+	// Only applies to the json format.
+	ResponseFormat string `yaml:"response_format,omitempty" json:"response_format,omitempty"`
+
+	// Pricing overrides the llm layer's prices of the model, e.g. for self-hosted models
+	Pricing *PricingConfig `yaml:"pricing,omitempty" json:"pricing,omitempty"`
+}
+
+// PricingConfig is the price of a model in USD, see agent.ModelPrice
+type PricingConfig struct {
+	// Prompt and Completion are the prices per million input and output tokens
+	Prompt     float64 `yaml:"prompt,omitempty" json:"prompt,omitempty"`
+	Completion float64 `yaml:"completion,omitempty" json:"completion,omitempty"`
+
+	// Request is the price per model call
+	Request float64 `yaml:"request,omitempty" json:"request,omitempty"`
+
+	// PerHour is charged for the duration of every model call
+	PerHour float64 `yaml:"per_hour,omitempty" json:"per_hour,omitempty"`
+}
+
+// ToolConfig references a tool by the name of its registered factory.
+// In YAML, a plain string is accepted as a tool without options.
+type ToolConfig struct {
+	Name    string         `yaml:"name" json:"name"`
+	Options map[string]any `yaml:"options,omitempty" json:"options,omitempty"`
+
+	// Aliases are legacy names of the tool still accepted from the model
+	Aliases []string `yaml:"aliases,omitempty" json:"aliases,omitempty"`
+
+	// Priority and Category order and group the tool in the prompt
+	Priority int    `yaml:"priority,omitempty" json:"priority,omitempty"`
+	Category string `yaml:"category,omitempty" json:"category,omitempty"`
+
+	// Effect overrides the effect declared by the tool: read_only, mutating or destructive
+	Effect string `yaml:"effect,omitempty" json:"effect,omitempty"`
+
+	// MaxCalls caps the calls of the tool per run, unlimited if 0
+	MaxCalls int `yaml:"max_calls,omitempty" json:"max_calls,omitempty"`
+}
+
+// UnmarshalYAML accepts either a tool name or a mapping with name and options
+func (t *ToolConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&t.Name)
+	}
+	type plain ToolConfig
+	return node.Decode((*plain)(t))
+}
+
+// ToolPreferenceConfig mirrors agent.ToolPreference
+type ToolPreferenceConfig struct {
+	Prefer string   `yaml:"prefer" json:"prefer"`
+	Over   []string `yaml:"over" json:"over"`
+	When   string   `yaml:"when,omitempty" json:"when,omitempty"`
+}
+
+// PromptVariantConfig mirrors agent.PromptVariant
+type PromptVariantConfig struct {
+	Provider string `yaml:"provider,omitempty" json:"provider,omitempty"`
+	Model    string `yaml:"model,omitempty" json:"model,omitempty"`
+	Prompt   string `yaml:"prompt" json:"prompt"`
+}
+
+// CompletionToolConfig mirrors agent.CompletionToolConfig
+type CompletionToolConfig struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	WrapKey     string `yaml:"wrap_key,omitempty" json:"wrap_key,omitempty"`
+}
+
+// LimitsConfig bounds the execution of runs
+type LimitsConfig struct {
+	// MaxIterations is the default AgentRequest.MaxIterations, 10 if not set
+	MaxIterations int `yaml:"max_iterations,omitempty" json:"max_iterations,omitempty"`
+
+	// MaxRetries is the default AgentRequest.MaxRetries
+	MaxRetries int `yaml:"max_retries,omitempty" json:"max_retries,omitempty"`
+
+	// MaxMessageHistory is the runner's message history limit, agent.DefaultMaxMessageHistory if not set
+	MaxMessageHistory int `yaml:"max_message_history,omitempty" json:"max_message_history,omitempty"`
+
+	// EventBufferSize is the event buffer size of streaming runs, agent.DefaultEventBufferSize if not set
+	EventBufferSize int `yaml:"event_buffer_size,omitempty" json:"event_buffer_size,omitempty"`
+
+	// HistoryTurns keeps the last turns of the history instead of a number of messages, see agent.KeepRecentTurns
+	HistoryTurns int `yaml:"history_turns,omitempty" json:"history_turns,omitempty"`
+
+	// HistoryTokens keeps the recent history within a token count instead, see agent.KeepRecentTokens
+	HistoryTokens int `yaml:"history_tokens,omitempty" json:"history_tokens,omitempty"`
+
+	HandoffHistory int  `yaml:"handoff_history,omitempty" json:"handoff_history,omitempty"`
+	SalvageOutput  bool `yaml:"salvage_output,omitempty" json:"salvage_output,omitempty"`
+
+	// OutputRefTokens stores tool outputs above this token count by reference, see agent.WithOutputReferences
+	OutputRefTokens int `yaml:"output_ref_tokens,omitempty" json:"output_ref_tokens,omitempty"`
+}
+
+// Load reads and parses a configuration file
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse parses a YAML or JSON configuration and validates it
+func Parse(data []byte) (*Config, error) {
+	cfg := &Config{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	if cfg.Format == "" {
+		cfg.Format = FormatJSON
+	}
+	if cfg.Limits.MaxIterations == 0 {
+		cfg.Limits.MaxIterations = 10
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return cfg, nil
+}
+
+// Validate checks the configuration. Agent fields are validated when the agent is built.
+func (c *Config) Validate() error {
+	if c.Model.Provider == "" {
+		return errors.New("model provider is required")
+	}
+	if c.Model.Name == "" {
+		return errors.New("model name is required")
+	}
+	if c.Format != FormatJSON && c.Format != FormatXML {
+		return fmt.Errorf("invalid format '%s', expected json or xml", c.Format)
+	}
+	switch llm.ResponseFormat(c.Model.ResponseFormat) {
+	case "", ResponseFormatAuto, ResponseFormatNone, llm.ResponseFormatJsonSchema, llm.ResponseFormatJson:
+	default:
+		return fmt.Errorf("invalid response format '%s', expected auto, none, json_schema or json", c.Model.ResponseFormat)
+	}
+	if c.Limits.MaxIterations <= 0 {
+		return errors.New("max iterations must be positive")
+	}
+	if c.Limits.MaxMessageHistory < 0 {
+		return errors.New("max message history must not be negative")
+	}
+	if c.Limits.EventBufferSize < 0 {
+		return errors.New("event buffer size must not be negative")
+	}
+	if c.Limits.HistoryTurns > 0 && c.Limits.HistoryTokens > 0 {
+		return errors.New("history turns and history tokens are exclusive")
+	}
+	for _, tool := range c.Tools {
+		if tool.MaxCalls < 0 {
+			return fmt.Errorf("tool %s: max calls must not be negative", tool.Name)
+		}
+	}
+	if c.Policy != nil {
+		if err := c.Policy.Validate(); err != nil {
+			return fmt.Errorf("invalid policy: %w", err)
+		}
+	}
+	return nil
+}
+
+// NewRequest creates a request for messages with the configured limits
+func (c *Config) NewRequest(messages ...*llm.ModelMessage) *agent.AgentRequest {
+	return &agent.AgentRequest{
+		Messages:      messages,
+		MaxIterations: c.Limits.MaxIterations,
+		MaxRetries:    c.Limits.MaxRetries,
+	}
+}
+
+// Version returns the hash of the configuration, reported as the config version of runs
+func (c *Config) Version() string {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	return agent.VersionHash(data)
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/easyagent-dev/agent"
+	"github.com/easyagent-dev/llm"
+)
+
+// snapshot is a configuration with the agent and runners built from it
+type snapshot struct {
+	data         []byte
+	config       *Config
+	agent        *agent.Agent
+	runner       agent.Runner
+	streamRunner agent.StreamRunner
+}
+
+// HotRunnerOption is a functional option for configuring a HotRunner
+type HotRunnerOption func(*HotRunner)
+
+// WithRunnerOptions sets runner options applied after the configured ones on every reload
+func WithRunnerOptions(opts ...agent.RunnerOption) HotRunnerOption {
+	return func(r *HotRunner) {
+		r.runnerOpts = opts
+	}
+}
+
+// WithReloadErrorHandler sets a function called when a reload in Watch fails.
+// The previous configuration stays active.
+func WithReloadErrorHandler(handler func(err error)) HotRunnerOption {
+	return func(r *HotRunner) {
+		r.onError = handler
+	}
+}
+
+// WithReloadHandler sets a function called after a new configuration is activated
+func WithReloadHandler(handler func(cfg *Config)) HotRunnerOption {
+	return func(r *HotRunner) {
+		r.onReload = handler
+	}
+}
+
+// HotRunner runs agents built from a configuration source and swaps them atomically when
+// the configuration changes. New runs use the latest configuration while in-flight runs
+// finish on the configuration they started with.
+type HotRunner struct {
+	loader     *Loader
+	source     Source
+	runnerOpts []agent.RunnerOption
+	onError    func(err error)
+	onReload   func(cfg *Config)
+
+	current  atomic.Pointer[snapshot]
+	reloadMu sync.Mutex
+}
+
+var (
+	_ agent.Runner       = (*HotRunner)(nil)
+	_ agent.StreamRunner = (*hotStreamRunner)(nil)
+)
+
+// NewHotRunner loads the initial configuration from source and builds its runners
+func NewHotRunner(ctx context.Context, loader *Loader, source Source, opts ...HotRunnerOption) (*HotRunner, error) {
+	r := &HotRunner{
+		loader: loader,
+		source: source,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if _, err := r.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload reads the source and activates the configuration if its content changed.
+// It reports whether a new configuration was activated. On error the previous one stays active.
+func (r *HotRunner) Reload(ctx context.Context) (bool, error) {
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+
+	data, err := r.source.Read(ctx)
+	if err != nil {
+		return false, err
+	}
+	if current := r.current.Load(); current != nil && bytes.Equal(current.data, data) {
+		return false, nil
+	}
+
+	cfg, err := Parse(data)
+	if err != nil {
+		return false, err
+	}
+	next, err := r.build(cfg)
+	if err != nil {
+		return false, err
+	}
+	next.data = data
+	r.current.Store(next)
+	if r.onReload != nil {
+		r.onReload(cfg)
+	}
+	return true, nil
+}
+
+// build creates the agent, model and runners of a configuration
+func (r *HotRunner) build(cfg *Config) (*snapshot, error) {
+	a, model, err := r.loader.build(cfg)
+	if err != nil {
+		return nil, err
+	}
+	opts := append(cfg.RunnerOptions(), r.runnerOpts...)
+
+	s := &snapshot{config: cfg, agent: a}
+	s.runner, err = agent.NewRunner(a, model, opts...)
+	if err == nil {
+		s.streamRunner, err = agent.NewStreamRunner(a, model, opts...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create runner: %w", err)
+	}
+	return s, nil
+}
+
+// Watch polls the source every interval and reloads the configuration until ctx is done.
+// Reload errors are passed to the reload error handler.
+func (r *HotRunner) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.Reload(ctx); err != nil && r.onError != nil {
+				r.onError(err)
+			}
+		}
+	}
+}
+
+// Config returns the active configuration
+func (r *HotRunner) Config() *Config {
+	return r.current.Load().config
+}
+
+// Agent returns the agent of the active configuration
+func (r *HotRunner) Agent() *agent.Agent {
+	return r.current.Load().agent
+}
+
+// NewRequest creates a request for messages with the limits of the active configuration
+func (r *HotRunner) NewRequest(messages ...*llm.ModelMessage) *agent.AgentRequest {
+	return r.Config().NewRequest(messages...)
+}
+
+// Run runs the request with the active configuration
+func (r *HotRunner) Run(ctx context.Context, req *agent.AgentRequest, callback agent.Callback) (*agent.AgentResponse, error) {
+	return r.current.Load().runner.Run(ctx, req, callback)
+}
+
+// Stream returns a stream runner using the configuration active when each run starts
+func (r *HotRunner) Stream() agent.StreamRunner {
+	return (*hotStreamRunner)(r)
+}
+
+// hotStreamRunner is the stream runner view of a HotRunner
+type hotStreamRunner HotRunner
+
+// Run streams the request with the active configuration
+func (r *hotStreamRunner) Run(ctx context.Context, req *agent.AgentRequest, callback agent.Callback) (*agent.AgentStreamResponse, error) {
+	return r.current.Load().streamRunner.Run(ctx, req, callback)
+}
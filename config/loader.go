@@ -0,0 +1,325 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/easyagent-dev/agent"
+	"github.com/easyagent-dev/agent/openaicompat"
+	"github.com/easyagent-dev/agent/policy"
+	"github.com/easyagent-dev/llm"
+	"github.com/easyagent-dev/llm/providers"
+)
+
+// ToolFactory creates a tool from its configured options
+type ToolFactory func(options map[string]any) (agent.ModelTool, error)
+
+// ProviderFactory creates a model provider
+type ProviderFactory struct {
+	// APIKeyEnv is the default environment variable holding the API key
+	APIKeyEnv string
+
+	// New creates the provider
+	New func(opts ...llm.ModelOption) (llm.ModelProvider, error)
+}
+
+// defaultProviders are the providers available to every loader
+var defaultProviders = map[string]ProviderFactory{
+	"openai":     {APIKeyEnv: "OPENAI_API_KEY", New: providers.NewOpenAIModelProvider},
+	"claude":     {APIKeyEnv: "CLAUDE_API_KEY", New: providers.NewClaudeModelProvider},
+	"deepseek":   {APIKeyEnv: "DEEPSEEK_API_KEY", New: providers.NewDeepSeekModelProvider},
+	"gemini":     {APIKeyEnv: "GEMINI_API_KEY", New: providers.NewGeminiModelProvider},
+	"openrouter": {APIKeyEnv: "OPENROUTER_API_KEY", New: providers.NewOpenRouterModel},
+	"azure":      {APIKeyEnv: "AZURE_OPENAI_API_KEY", New: providers.NewAzureOpenAIModelProvider},
+	"ollama":     {New: newLocalProvider("http://localhost:11434/v1")},
+	"vllm":       {New: newLocalProvider("http://localhost:8000/v1")},
+}
+
+// newLocalProvider creates the factory of a local OpenAI-compatible endpoint, which needs no API key.
+// A configured base_url overrides the default one.
+func newLocalProvider(baseURL string) func(opts ...llm.ModelOption) (llm.ModelProvider, error) {
+	return func(opts ...llm.ModelOption) (llm.ModelProvider, error) {
+		defaults := []llm.ModelOption{llm.WithBaseURL(baseURL)}
+		return openaicompat.NewProvider(append(defaults, opts...)...)
+	}
+}
+
+// Loader builds agents, models and runners from configurations using registered
+// tool and provider factories. This type is safe for concurrent use.
+type Loader struct {
+	mu        sync.RWMutex
+	tools     map[string]ToolFactory
+	providers map[string]ProviderFactory
+}
+
+// NewLoader creates a loader with the default providers and no tools
+func NewLoader() *Loader {
+	l := &Loader{
+		tools:     make(map[string]ToolFactory),
+		providers: make(map[string]ProviderFactory, len(defaultProviders)),
+	}
+	for name, factory := range defaultProviders {
+		l.providers[name] = factory
+	}
+	return l
+}
+
+// RegisterTool registers a tool factory under name, replacing any existing one
+func (l *Loader) RegisterTool(name string, factory ToolFactory) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tools[name] = factory
+}
+
+// RegisterProvider registers a provider factory under name, replacing any existing one
+func (l *Loader) RegisterProvider(name string, factory ProviderFactory) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.providers[name] = factory
+}
+
+// BuildAgent builds the agent of a configuration, including its handoff agents
+func (l *Loader) BuildAgent(cfg *Config) (*agent.Agent, error) {
+	a, err := l.buildAgent(&cfg.AgentConfig, cfg.Model)
+	if err != nil {
+		return nil, err
+	}
+	a.Type = agent.AgentType(cfg.Format)
+	return a, nil
+}
+
+func (l *Loader) buildAgent(cfg *AgentConfig, model ModelConfig) (*agent.Agent, error) {
+	tools := make([]agent.ModelTool, 0, len(cfg.Tools))
+	toolOptions := map[string]agent.ToolOptions{}
+	toolAliases := map[string]string{}
+	for _, toolCfg := range cfg.Tools {
+		tool, err := l.buildTool(toolCfg)
+		if err != nil {
+			return nil, fmt.Errorf("agent %s: %w", cfg.Name, err)
+		}
+		tools = append(tools, tool)
+		for _, alias := range toolCfg.Aliases {
+			toolAliases[alias] = tool.Name()
+		}
+		effect, err := agent.ParseToolEffect(toolCfg.Effect)
+		if err != nil {
+			return nil, fmt.Errorf("agent %s: tool %s: %w", cfg.Name, tool.Name(), err)
+		}
+		options := agent.ToolOptions{
+			Priority: toolCfg.Priority,
+			Category: toolCfg.Category,
+			Effect:   effect,
+			MaxCalls: toolCfg.MaxCalls,
+		}
+		if options != (agent.ToolOptions{}) {
+			toolOptions[tool.Name()] = options
+		}
+	}
+	preferences := make([]agent.ToolPreference, 0, len(cfg.ToolPreferences))
+	for _, preference := range cfg.ToolPreferences {
+		preferences = append(preferences, agent.ToolPreference{
+			Prefer: preference.Prefer,
+			Over:   preference.Over,
+			When:   preference.When,
+		})
+	}
+
+	a := &agent.Agent{
+		Name:          cfg.Name,
+		ModelProvider: model.Provider,
+		Model:         model.Name,
+		Description:   cfg.Description,
+		Instructions:  cfg.Instructions,
+		Tools:         tools,
+
+		ToolOptions:     toolOptions,
+		ToolAliases:     toolAliases,
+		ToolPreferences: preferences,
+	}
+	for i := range cfg.Handoffs {
+		handoff, err := l.buildAgent(&cfg.Handoffs[i], model)
+		if err != nil {
+			return nil, err
+		}
+		a.Handoffs = append(a.Handoffs, handoff)
+	}
+	return a, nil
+}
+
+func (l *Loader) buildTool(cfg ToolConfig) (agent.ModelTool, error) {
+	l.mu.RLock()
+	factory, ok := l.tools[cfg.Name]
+	l.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown tool '%s'", cfg.Name)
+	}
+	tool, err := factory(cfg.Options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tool %s: %w", cfg.Name, err)
+	}
+	return tool, nil
+}
+
+// BuildModel creates the completion model of a configuration.
+// The API key is read from the configured or the provider's default environment variable.
+func (l *Loader) BuildModel(cfg *Config) (llm.CompletionModel, error) {
+	l.mu.RLock()
+	factory, ok := l.providers[cfg.Model.Provider]
+	l.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown model provider '%s'", cfg.Model.Provider)
+	}
+
+	opts := []llm.ModelOption{}
+	apiKeyEnv := cfg.Model.APIKeyEnv
+	if apiKeyEnv == "" {
+		apiKeyEnv = factory.APIKeyEnv
+	}
+	if apiKeyEnv != "" {
+		apiKey := os.Getenv(apiKeyEnv)
+		if apiKey == "" {
+			return nil, fmt.Errorf("%s environment variable is not set", apiKeyEnv)
+		}
+		opts = append(opts, llm.WithAPIKey(apiKey))
+	}
+	if cfg.Model.BaseURL != "" {
+		opts = append(opts, llm.WithBaseURL(cfg.Model.BaseURL))
+	}
+	provider, err := factory.New(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create model provider: %w", err)
+	}
+
+	completionOpts := []llm.CompletionOption{llm.WithUsage(true), llm.WithCost(true)}
+	if cfg.Model.MaxTokens > 0 {
+		completionOpts = append(completionOpts, llm.WithMaxTokens(cfg.Model.MaxTokens))
+	}
+	if cfg.Model.Temperature != nil {
+		completionOpts = append(completionOpts, llm.WithTemperature(*cfg.Model.Temperature))
+	}
+	var model llm.CompletionModel
+	switch {
+	case cfg.Format == FormatXML || cfg.Model.ResponseFormat == ResponseFormatNone:
+		// XML tool calls cannot be constrained
+		model, err = provider.NewCompletionModel(cfg.Model.Name, completionOpts...)
+	case cfg.Model.ResponseFormat == "" || cfg.Model.ResponseFormat == ResponseFormatAuto:
+		model, err = agent.NewNegotiatedModel(provider, cfg.Model.Name, completionOpts...)
+	default:
+		model, err = agent.NewConstrainedModel(provider, cfg.Model.Name, llm.ResponseFormat(cfg.Model.ResponseFormat), completionOpts...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create model: %w", err)
+	}
+	return model, nil
+}
+
+// RunnerOptions returns the runner options of a configuration
+func (c *Config) RunnerOptions() []agent.RunnerOption {
+	opts := []agent.RunnerOption{
+		agent.WithHandoffHistory(c.Limits.HandoffHistory),
+		agent.WithPartialOutputSalvage(c.Limits.SalvageOutput),
+		agent.WithConfigVersion(c.Version()),
+	}
+	if c.Limits.MaxMessageHistory > 0 {
+		opts = append(opts, agent.WithMaxMessageHistory(c.Limits.MaxMessageHistory))
+	}
+	if c.Limits.EventBufferSize > 0 {
+		opts = append(opts, agent.WithEventBufferSize(c.Limits.EventBufferSize))
+	}
+	if c.Limits.HistoryTurns > 0 {
+		opts = append(opts, agent.WithHistoryPolicy(agent.KeepRecentTurns(c.Limits.HistoryTurns)))
+	}
+	if c.Limits.HistoryTokens > 0 {
+		opts = append(opts, agent.WithHistoryPolicy(agent.KeepRecentTokens(agent.EstimateTokenizer{}, c.Limits.HistoryTokens)))
+	}
+	if c.Limits.OutputRefTokens > 0 {
+		opts = append(opts, agent.WithOutputReferences(c.Limits.OutputRefTokens))
+	}
+	if c.SystemPrompt != "" {
+		opts = append(opts, agent.WithSystemPrompt(c.SystemPrompt))
+	}
+	for _, variant := range c.PromptVariants {
+		opts = append(opts, agent.WithPromptVariant(agent.PromptVariant{
+			Provider: variant.Provider,
+			Model:    variant.Model,
+			Prompt:   variant.Prompt,
+		}))
+	}
+	if c.Model.Local {
+		opts = append(opts, agent.WithLocalModel())
+	}
+	if c.Model.Pricing != nil {
+		catalog := agent.NewStaticPricingCatalog().Set(c.Model.Provider, c.Model.Name, agent.ModelPrice{
+			Pricing: llm.ModelPricing{
+				Prompt:     c.Model.Pricing.Prompt,
+				Completion: c.Model.Pricing.Completion,
+				Request:    c.Model.Pricing.Request,
+			},
+			PerHour: c.Model.Pricing.PerHour,
+		})
+		opts = append(opts, agent.WithPricingCatalog(catalog, agent.UnknownModelKeep))
+	}
+	if c.CompletionTool != nil {
+		opts = append(opts, agent.WithCompletionTool(agent.CompletionToolConfig{
+			Name:        c.CompletionTool.Name,
+			Description: c.CompletionTool.Description,
+			WrapKey:     c.CompletionTool.WrapKey,
+		}))
+	}
+	if c.Policy != nil {
+		engine, err := policy.New(c.Policy)
+		if err != nil {
+			// Validate rejects invalid policies, configurations that skipped it fail their tool calls
+			opts = append(opts, agent.WithToolPolicy(agent.ToolPolicyFunc(func(ctx context.Context, req *agent.ToolPolicyRequest) (agent.ToolPolicyDecision, error) {
+				return agent.ToolPolicyDecision{}, fmt.Errorf("invalid policy: %w", err)
+			})))
+		} else {
+			opts = append(opts, agent.WithToolPolicy(engine))
+		}
+	}
+	return opts
+}
+
+// NewRunner builds the agent and model of a configuration and returns a runner for them.
+// Extra options are applied after the configured ones.
+func (l *Loader) NewRunner(cfg *Config, opts ...agent.RunnerOption) (agent.Runner, *agent.Agent, error) {
+	a, model, err := l.build(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts = append(cfg.RunnerOptions(), opts...)
+	runner, err := agent.NewRunner(a, model, opts...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create runner: %w", err)
+	}
+	return runner, a, nil
+}
+
+// NewStreamRunner builds the agent and model of a configuration and returns a stream runner for them.
+// Extra options are applied after the configured ones.
+func (l *Loader) NewStreamRunner(cfg *Config, opts ...agent.RunnerOption) (agent.StreamRunner, *agent.Agent, error) {
+	a, model, err := l.build(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts = append(cfg.RunnerOptions(), opts...)
+	runner, err := agent.NewStreamRunner(a, model, opts...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create runner: %w", err)
+	}
+	return runner, a, nil
+}
+
+func (l *Loader) build(cfg *Config) (*agent.Agent, llm.CompletionModel, error) {
+	a, err := l.BuildAgent(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	model, err := l.BuildModel(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return a, model, nil
+}
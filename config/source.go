@@ -0,0 +1,64 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+// Source provides the raw content of a configuration
+type Source interface {
+	// Read returns the current configuration content
+	Read(ctx context.Context) ([]byte, error)
+}
+
+// FileSource reads a configuration from a file
+type FileSource string
+
+var _ Source = FileSource("")
+
+// Read returns the content of the file
+func (s FileSource) Read(ctx context.Context) ([]byte, error) {
+	data, err := os.ReadFile(string(s))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	return data, nil
+}
+
+// URLSource reads a configuration from an HTTP endpoint
+type URLSource struct {
+	URL string
+
+	// Client is the HTTP client, http.DefaultClient if nil
+	Client *http.Client
+}
+
+var _ Source = (*URLSource)(nil)
+
+// Read fetches the configuration with a GET request
+func (s *URLSource) Read(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create config request: %w", err)
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch config: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch config: status %s", resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config response: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,244 @@
+package agent
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/easyagent-dev/llm"
+)
+
+//go:embed prompts/judge_system.md
+var judgeSystemPrompt string
+
+// ConsensusMode determines how the judge combines the candidates
+type ConsensusMode string
+
+const (
+	// ConsensusModeSelect makes the judge pick the best candidate
+	ConsensusModeSelect ConsensusMode = "select"
+
+	// ConsensusModeMerge makes the judge write a new output merging the candidates
+	ConsensusModeMerge ConsensusMode = "merge"
+)
+
+// Judgement is the output of the judging pass. Choice is set in select mode, Output in merge mode.
+type Judgement struct {
+	Choice    int    `json:"choice"`
+	Output    any    `json:"output"`
+	Rationale string `json:"rationale"`
+}
+
+// ConsensusOption is a functional option for configuring consensus runners
+type ConsensusOption func(*ConsensusRunner)
+
+// WithConsensusMode sets how the judge combines the candidates, defaults to ConsensusModeSelect
+//
+// Deprecated: Use x.WithConsensusMode. Consensus runners are experimental and not covered by the v1 API.
+func WithConsensusMode(mode ConsensusMode) ConsensusOption {
+	return func(r *ConsensusRunner) {
+		r.mode = mode
+	}
+}
+
+// WithJudgeInstructions adds custom instructions to the judge prompt
+//
+// Deprecated: Use x.WithJudgeInstructions. Consensus runners are experimental and not covered by the v1 API.
+func WithJudgeInstructions(instructions string) ConsensusOption {
+	return func(r *ConsensusRunner) {
+		r.instructions = instructions
+	}
+}
+
+// ConsensusRunner runs the same request on several runners in parallel, then asks a judge
+// model to select or merge the best final output. All candidates and the judge rationale
+// are returned in the response. It is experimental, see x.ConsensusRunner.
+type ConsensusRunner struct {
+	runners      []Runner
+	judge        llm.CompletionModel
+	mode         ConsensusMode
+	instructions string
+}
+
+var _ Runner = (*ConsensusRunner)(nil)
+
+// NewConsensusRunner creates a consensus runner over several runners, e.g. different agents or models
+//
+// Deprecated: Use x.NewConsensusRunner. Consensus runners are experimental and not covered by the v1 API.
+func NewConsensusRunner(runners []Runner, judge llm.CompletionModel, opts ...ConsensusOption) (*ConsensusRunner, error) {
+	if len(runners) == 0 {
+		return nil, errors.New("at least one runner is required")
+	}
+	for i, runner := range runners {
+		if runner == nil {
+			return nil, fmt.Errorf("runner %d is nil", i)
+		}
+	}
+	if judge == nil {
+		return nil, errors.New("judge model is required")
+	}
+
+	r := &ConsensusRunner{
+		runners: runners,
+		judge:   judge,
+		mode:    ConsensusModeSelect,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.mode != ConsensusModeSelect && r.mode != ConsensusModeMerge {
+		return nil, fmt.Errorf("invalid consensus mode '%s'", r.mode)
+	}
+	return r, nil
+}
+
+// NewSampledConsensusRunner creates a consensus runner sampling the same runner n times
+//
+// Deprecated: Use x.NewSampledConsensusRunner. Consensus runners are experimental and not covered by the v1 API.
+func NewSampledConsensusRunner(runner Runner, n int, judge llm.CompletionModel, opts ...ConsensusOption) (*ConsensusRunner, error) {
+	if n <= 0 {
+		return nil, errors.New("number of samples must be greater than 0")
+	}
+	runners := make([]Runner, n)
+	for i := range runners {
+		runners[i] = runner
+	}
+	return NewConsensusRunner(runners, judge, opts...)
+}
+
+// Run executes all candidates, then the judging pass.
+// Failed candidates are excluded from judging; if only one candidate succeeds it is returned without a judge call.
+func (r *ConsensusRunner) Run(ctx context.Context, req *AgentRequest, callback Callback) (*AgentResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+
+	responses := make([]*AgentResponse, len(r.runners))
+	errs := make([]error, len(r.runners))
+	var wg sync.WaitGroup
+	for i, runner := range r.runners {
+		wg.Add(1)
+		go func(i int, runner Runner) {
+			defer wg.Done()
+			candidateReq := req
+			if req.RunID != "" {
+				// Candidates are separate runs, their tool calls must not share idempotency keys
+				copied := *req
+				copied.RunID = fmt.Sprintf("%s/%d", req.RunID, i)
+				candidateReq = &copied
+			}
+			responses[i], errs[i] = runner.Run(ctx, candidateReq, callback)
+		}(i, runner)
+	}
+	wg.Wait()
+
+	resp := &AgentResponse{Usage: &llm.TokenUsage{}}
+	totalCost := 0.0
+	for i, candidate := range responses {
+		if candidate == nil {
+			continue
+		}
+		if candidate.Usage != nil {
+			resp.Usage.Append(candidate.Usage)
+		}
+		if candidate.Cost != nil {
+			totalCost += *candidate.Cost
+		}
+		if errs[i] == nil {
+			resp.Candidates = append(resp.Candidates, candidate)
+		}
+	}
+	resp.Cost = &totalCost
+
+	if len(resp.Candidates) == 0 {
+		return resp, fmt.Errorf("all candidates failed: %w", errors.Join(errs...))
+	}
+	if len(resp.Candidates) == 1 {
+		r.adopt(resp, resp.Candidates[0])
+		resp.Rationale = "only one candidate succeeded"
+		return resp, nil
+	}
+
+	verdict, output, err := r.Judge(ctx, req, resp.Candidates)
+	if output != nil {
+		if output.Usage != nil {
+			resp.Usage.Append(output.Usage)
+		}
+		if output.Cost != nil {
+			totalCost += *output.Cost
+			resp.Cost = &totalCost
+		}
+	}
+	if err != nil {
+		return resp, err
+	}
+
+	resp.Rationale = verdict.Rationale
+	if r.mode == ConsensusModeMerge {
+		r.adopt(resp, resp.Candidates[0])
+		resp.Output = verdict.Output
+		return resp, nil
+	}
+	if verdict.Choice < 0 || verdict.Choice >= len(resp.Candidates) {
+		return resp, fmt.Errorf("judge selected unknown candidate %d", verdict.Choice)
+	}
+	r.adopt(resp, resp.Candidates[verdict.Choice])
+	return resp, nil
+}
+
+// Judge asks the judge model to select or merge the candidates, returning the usage and cost of the call
+func (r *ConsensusRunner) Judge(ctx context.Context, req *AgentRequest, candidates []*AgentResponse) (*Judgement, *llm.CompletionResponse, error) {
+	outputs := make([]string, 0, len(candidates))
+	for i, candidate := range candidates {
+		data, err := json.Marshal(candidate.Output)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal candidate %d: %w", i, err)
+		}
+		outputs = append(outputs, string(data))
+	}
+
+	schema := ""
+	if req.OutputSchema != nil {
+		data, err := json.Marshal(req.OutputSchema)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal output schema: %w", err)
+		}
+		schema = string(data)
+	}
+
+	prompts, err := llm.GetPrompts(judgeSystemPrompt, map[string]interface{}{
+		"merge":        r.mode == ConsensusModeMerge,
+		"candidates":   outputs,
+		"instructions": r.instructions,
+		"schema":       schema,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get prompts: %w", err)
+	}
+
+	output, err := r.judge.Complete(ctx, &llm.CompletionRequest{
+		Instructions: prompts,
+		Messages:     req.Messages,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("judging failed: %w", err)
+	}
+
+	verdict := &Judgement{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output.Output)), verdict); err != nil {
+		return nil, output, fmt.Errorf("failed to parse judgement: %w", err)
+	}
+	return verdict, output, nil
+}
+
+// adopt copies the final output and trace of the chosen candidate into the consensus response
+func (r *ConsensusRunner) adopt(resp *AgentResponse, chosen *AgentResponse) {
+	resp.Output = chosen.Output
+	resp.ToolCalls = chosen.ToolCalls
+	resp.ToolStats = chosen.ToolStats
+	resp.Agent = chosen.Agent
+}
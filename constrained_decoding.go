@@ -0,0 +1,158 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// GenerationConstraint restricts the output of a model call
+type GenerationConstraint struct {
+	// Schema is the JSON schema the output must match
+	Schema map[string]any
+}
+
+// ConstrainedModel is a model supporting constrained decoding, such as OpenAI structured outputs,
+// JSON mode or llama.cpp grammars. Runners constrain its calls to the schema of the tool calls they
+// accept, so malformed tool calls cannot be generated. Other models are only instructed by the prompt.
+type ConstrainedModel interface {
+	llm.CompletionModel
+
+	// Constrain returns the model generating outputs matching constraint
+	Constrain(constraint *GenerationConstraint) (llm.CompletionModel, error)
+}
+
+// WithConstrainedDecoding sets whether calls to models implementing ConstrainedModel are constrained
+// to the tool call schema, true by default. XML runners and direct answers are never constrained.
+func WithConstrainedDecoding(enabled bool) RunnerOption {
+	return func(c *runnerConfig) {
+		c.constrainedDecoding = enabled
+	}
+}
+
+// maxConstrainedModels bounds the constrained models a SchemaConstrainedModel keeps
+const maxConstrainedModels = 64
+
+// SchemaConstrainedModel is a model of a provider constrained with a response format, json_schema
+// for OpenAI structured outputs and OpenAI-compatible servers such as vLLM, Ollama or the llama.cpp
+// server, which compiles the schema to a grammar, or json for backends only offering JSON mode.
+// This type is safe for concurrent use.
+type SchemaConstrainedModel struct {
+	llm.CompletionModel
+
+	provider llm.ModelProvider
+	name     string
+	format   llm.ResponseFormat
+	opts     []llm.CompletionOption
+
+	mu     sync.Mutex
+	models map[string]llm.CompletionModel
+}
+
+var _ ConstrainedModel = (*SchemaConstrainedModel)(nil)
+
+// NewConstrainedModel creates the model of provider named model with opts, constraining its outputs
+// with format, llm.ResponseFormatJsonSchema or llm.ResponseFormatJson
+func NewConstrainedModel(provider llm.ModelProvider, model string, format llm.ResponseFormat, opts ...llm.CompletionOption) (*SchemaConstrainedModel, error) {
+	if provider == nil {
+		return nil, errors.New("model provider is required")
+	}
+	if format != llm.ResponseFormatJsonSchema && format != llm.ResponseFormatJson {
+		return nil, fmt.Errorf("invalid response format '%s', expected json_schema or json", format)
+	}
+	unconstrained, err := provider.NewCompletionModel(model, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &SchemaConstrainedModel{
+		CompletionModel: unconstrained,
+		provider:        provider,
+		name:            model,
+		format:          format,
+		opts:            opts,
+		models:          make(map[string]llm.CompletionModel),
+	}, nil
+}
+
+// Constrain returns the model with the response format of constraint, created once per schema
+func (m *SchemaConstrainedModel) Constrain(constraint *GenerationConstraint) (llm.CompletionModel, error) {
+	key := ""
+	if m.format == llm.ResponseFormatJsonSchema {
+		schema, err := json.Marshal(constraint.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal constraint schema: %w", err)
+		}
+		key = string(schema)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if model, ok := m.models[key]; ok {
+		return model, nil
+	}
+
+	opts := append(slices.Clip(m.opts), llm.WithResponseFormat(m.format))
+	if m.format == llm.ResponseFormatJsonSchema {
+		opts = append(opts, llm.WithJSONSchema(constraint.Schema))
+	}
+	model, err := m.provider.NewCompletionModel(m.name, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create constrained model: %w", err)
+	}
+	// Schemas change with the tools, drop the models of past ones rather than growing forever
+	if len(m.models) >= maxConstrainedModels {
+		clear(m.models)
+	}
+	m.models[key] = model
+	return model, nil
+}
+
+// constrainModel returns model constrained by constraint if it supports constrained decoding, model otherwise
+func constrainModel(model llm.CompletionModel, constraint *GenerationConstraint) (llm.CompletionModel, error) {
+	constrained, ok := model.(ConstrainedModel)
+	if !ok || constraint == nil {
+		return model, nil
+	}
+	return constrained.Constrain(constraint)
+}
+
+// callModel returns the model of the next call, constrained to the tool calls toolChoice allows
+// when the model supports it
+func (r *BaseRunner) callModel(run *agentRun, toolChoice ToolChoice) (llm.CompletionModel, error) {
+	if !r.constrainedDecoding || (run.directAnswer && toolChoice.isAuto()) {
+		return run.model, nil
+	}
+	if _, ok := run.model.(ConstrainedModel); !ok {
+		return run.model, nil
+	}
+
+	inputSchemas := make(map[string]any)
+	for _, tool := range run.toolRegistry.GetTools() {
+		if toolChoice.check(tool.Name(), r.completionTool.Name) != "" {
+			continue
+		}
+		data, err := run.toolRegistry.GetInputSchemaJSON(tool.Name())
+		if err != nil {
+			return nil, err
+		}
+		var schema any
+		if err := json.Unmarshal(data, &schema); err != nil {
+			return nil, fmt.Errorf("invalid input schema of tool '%s': %w", tool.Name(), err)
+		}
+		inputSchemas[tool.Name()] = schema
+	}
+	schema := r.format.toolCallSchema(inputSchemas)
+	if schema == nil {
+		return run.model, nil
+	}
+
+	model, err := constrainModel(run.model, &GenerationConstraint{Schema: schema})
+	if err != nil {
+		return nil, fmt.Errorf("failed to constrain model: %w", err)
+	}
+	return model, nil
+}
@@ -25,11 +25,25 @@ func WithAgentContext(ctx context.Context, ac *AgentContext) context.Context {
 	return context.WithValue(ctx, agentContextKey, ac)
 }
 
+// MetadataOf returns the request metadata of the run executing in ctx, or nil if there is none
+// Callbacks receive the run context, so they can attribute usage without extra plumbing
+func MetadataOf(ctx context.Context) map[string]string {
+	ac, ok := AgentContextOf(ctx)
+	if !ok {
+		return nil
+	}
+	return ac.Metadata
+}
+
 // AgentContext holds the execution context for an agent execution.
 // It tracks the agent state, conversation history, and execution history.
 // This type is safe for concurrent use.
 type AgentContext struct {
-	// Agent is the agent being executed
+	// RunID uniquely identifies the run
+	RunID string
+
+	// Agent is the agent being executed, it changes on handoff
+	// Use CurrentAgent to read it from code running concurrently with the run
 	Agent *Agent
 
 	// Messages is the current conversation history
@@ -38,11 +52,35 @@ type AgentContext struct {
 	// Session is a key-value store for session-specific data
 	Session map[string]any
 
+	// SharedState is the blackboard shared with other agents, if any
+	SharedState *SharedState
+
+	// Metadata holds the request-level tags of the run
+	Metadata map[string]string
+
+	// Compensations records the undo actions of the run's successful tool calls
+	Compensations *Compensations
+
+	// Artifacts stores the tool outputs too large for the history
+	Artifacts ArtifactStore
+
+	// DryRun is set when the request simulates the calls of tools that are not read-only
+	DryRun bool
+
 	// mu protects ExecutionHistory from concurrent access
 	mu sync.RWMutex
 
 	// ToolExecutions tracks detailed tool execution information
 	ToolCalls []*llm.ToolCall
+
+	// interrupted holds the tool calls interrupted by the cancellation of the run
+	interrupted []*llm.ToolCall
+
+	// transaction is the open transaction, if any
+	transaction *transaction
+
+	// toolStats summarizes the calls of ToolCalls by tool name
+	toolStats map[string]*ToolStats
 }
 
 // IsToolCalled checks if a tool with the given name has been called during this execution.
@@ -59,6 +97,21 @@ func (ac *AgentContext) IsToolCalled(name string) bool {
 	return false
 }
 
+// CurrentAgent returns the agent being executed.
+// This method is safe for concurrent use.
+func (ac *AgentContext) CurrentAgent() *Agent {
+	ac.mu.RLock()
+	defer ac.mu.RUnlock()
+	return ac.Agent
+}
+
+// setAgent switches the agent being executed on handoff
+func (ac *AgentContext) setAgent(agent *Agent) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	ac.Agent = agent
+}
+
 // AppendToolCall records a tool execution in the execution history.
 // This method is safe for concurrent use.
 func (ac *AgentContext) AppendToolCall(toolCall *llm.ToolCall) {
@@ -68,6 +121,33 @@ func (ac *AgentContext) AppendToolCall(toolCall *llm.ToolCall) {
 		ac.ToolCalls = make([]*llm.ToolCall, 0, 10) // Pre-allocate with capacity
 	}
 	ac.ToolCalls = append(ac.ToolCalls, toolCall)
+
+	if ac.toolStats == nil {
+		ac.toolStats = make(map[string]*ToolStats)
+	}
+	stats, ok := ac.toolStats[toolCall.Name]
+	if !ok {
+		stats = &ToolStats{}
+		ac.toolStats[toolCall.Name] = stats
+	}
+	stats.add(toolCall)
+}
+
+// InterruptedToolCalls returns the tool calls interrupted by the cancellation of the run.
+// They are also part of ToolCalls, with an ErrorMessage describing the interruption.
+// This method is safe for concurrent use.
+func (ac *AgentContext) InterruptedToolCalls() []*llm.ToolCall {
+	ac.mu.RLock()
+	defer ac.mu.RUnlock()
+	return append([]*llm.ToolCall(nil), ac.interrupted...)
+}
+
+// appendInterrupted records a tool call interrupted by the cancellation of the run
+func (ac *AgentContext) appendInterrupted(toolCall *llm.ToolCall) {
+	ac.AppendToolCall(toolCall)
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	ac.interrupted = append(ac.interrupted, toolCall)
 }
 
 // FindToolCalls returns all executions for a specific tool.
@@ -0,0 +1,317 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/easyagent-dev/llm"
+	"github.com/google/uuid"
+)
+
+// Session is the persisted state of a conversation
+type Session struct {
+	ID string `json:"id"`
+
+	// Messages are the user messages and agent answers of the conversation, without the
+	// tool calls of its runs. Messages older than Summary are dropped.
+	Messages []*llm.ModelMessage `json:"messages"`
+
+	// Summary summarizes the messages dropped from the conversation, if any
+	Summary string `json:"summary,omitempty"`
+
+	// Data is the session store of the conversation, exposed to tools as AgentContext.Session.
+	// Values must survive the JSON encoding of persistent stores.
+	Data map[string]any `json:"data,omitempty"`
+
+	// Metadata holds the request metadata of the runs of the conversation
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// Usage and Cost are the totals of the runs of the conversation
+	Usage llm.TokenUsage `json:"usage"`
+	Cost  float64        `json:"cost"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// SessionStore persists conversation sessions
+type SessionStore interface {
+	// Get returns the session with id, or ErrSessionNotFound
+	Get(ctx context.Context, id string) (*Session, error)
+
+	// Save creates or replaces a session
+	Save(ctx context.Context, session *Session) error
+
+	// Delete removes a session, doing nothing if it does not exist
+	Delete(ctx context.Context, id string) error
+}
+
+// MemorySessionStore keeps sessions in memory.
+// This type is safe for concurrent use.
+type MemorySessionStore struct {
+	mu       sync.RWMutex
+	sessions map[string][]byte
+}
+
+var _ SessionStore = (*MemorySessionStore)(nil)
+
+// NewMemorySessionStore creates an empty in-memory session store
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: make(map[string][]byte)}
+}
+
+// Get returns a copy of the session with id
+func (s *MemorySessionStore) Get(ctx context.Context, id string) (*Session, error) {
+	s.mu.RLock()
+	data, ok := s.sessions[id]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+	}
+	session := &Session{}
+	if err := json.Unmarshal(data, session); err != nil {
+		return nil, fmt.Errorf("failed to decode session: %w", err)
+	}
+	return session, nil
+}
+
+// Save stores a copy of session, serialized as JSON like a persistent store would
+func (s *MemorySessionStore) Save(ctx context.Context, session *Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[session.ID] = data
+	return nil
+}
+
+// Delete removes the session with id
+func (s *MemorySessionStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+	return nil
+}
+
+// ConversationSummarizer folds messages into the summary of the earlier conversation, which is
+// empty for the first summarization, and returns the new summary
+type ConversationSummarizer func(ctx context.Context, summary string, messages []*llm.ModelMessage) (string, error)
+
+// conversationSummaryPrompt instructs the model summarizing a conversation
+const conversationSummaryPrompt = "Summarize the conversation below for an assistant continuing it. " +
+	"Keep facts, decisions, user preferences and open questions. Reply with the summary only."
+
+// ModelSummarizer returns a summarizer asking model to summarize the conversation
+func ModelSummarizer(model llm.CompletionModel) ConversationSummarizer {
+	return func(ctx context.Context, summary string, messages []*llm.ModelMessage) (string, error) {
+		var transcript strings.Builder
+		if summary != "" {
+			fmt.Fprintf(&transcript, "Summary of the earlier conversation:\n%s\n\n", summary)
+		}
+		for _, message := range messages {
+			fmt.Fprintf(&transcript, "%s: %s\n\n", message.Role, message.Content)
+		}
+		resp, err := model.Complete(ctx, &llm.CompletionRequest{
+			Instructions: conversationSummaryPrompt,
+			Messages:     []*llm.ModelMessage{{Role: llm.RoleUser, Content: transcript.String()}},
+		})
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(resp.Output), nil
+	}
+}
+
+// ConversationOption is a functional option for configuring conversations
+type ConversationOption func(*ConversationManager)
+
+// WithConversationRequest sets the template of the requests of every turn, e.g. their MaxIterations,
+// tools or output schema. Its Messages, Metadata and RunID are ignored.
+func WithConversationRequest(template AgentRequest) ConversationOption {
+	return func(m *ConversationManager) {
+		m.template = template
+	}
+}
+
+// WithConversationSummary makes conversations summarize their older messages once they hold more
+// than maxMessages, keeping the most recent half
+func WithConversationSummary(summarizer ConversationSummarizer, maxMessages int) ConversationOption {
+	return func(m *ConversationManager) {
+		m.summarizer = summarizer
+		m.maxMessages = maxMessages
+	}
+}
+
+// ConversationManager creates and resumes conversations run by a runner and persisted in a store.
+// This type is safe for concurrent use.
+type ConversationManager struct {
+	runner      Runner
+	store       SessionStore
+	template    AgentRequest
+	summarizer  ConversationSummarizer
+	maxMessages int
+}
+
+// NewConversationManager creates a manager of conversations with the agent of runner
+func NewConversationManager(runner Runner, store SessionStore, opts ...ConversationOption) *ConversationManager {
+	manager := &ConversationManager{
+		runner:   runner,
+		store:    store,
+		template: AgentRequest{MaxIterations: 10},
+	}
+	for _, opt := range opts {
+		opt(manager)
+	}
+	return manager
+}
+
+// Create starts a conversation whose runs carry metadata
+func (m *ConversationManager) Create(ctx context.Context, metadata map[string]string) (*Conversation, error) {
+	now := time.Now()
+	session := &Session{
+		ID:        uuid.New().String(),
+		Data:      make(map[string]any),
+		Metadata:  metadata,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := m.store.Save(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to save session: %w", err)
+	}
+	return &Conversation{manager: m, session: session}, nil
+}
+
+// Open resumes the conversation with id
+func (m *ConversationManager) Open(ctx context.Context, id string) (*Conversation, error) {
+	session, err := m.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Data == nil {
+		session.Data = make(map[string]any)
+	}
+	return &Conversation{manager: m, session: session}, nil
+}
+
+// Conversation is a multi-turn conversation with an agent. Every turn runs the agent with the
+// conversation history and saves the new messages to the session store.
+// Turns of a Conversation are serialized; conversations opened twice on the same session
+// overwrite each other's turns.
+type Conversation struct {
+	manager *ConversationManager
+
+	mu      sync.Mutex
+	session *Session
+}
+
+// ID returns the ID of the conversation session
+func (c *Conversation) ID() string {
+	return c.session.ID
+}
+
+// Messages returns the messages of the conversation
+func (c *Conversation) Messages() []*llm.ModelMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*llm.ModelMessage(nil), c.session.Messages...)
+}
+
+// Send runs a turn answering a user message
+func (c *Conversation) Send(ctx context.Context, message string) (*AgentResponse, error) {
+	return c.SendMessage(ctx, &llm.ModelMessage{Role: llm.RoleUser, Content: message})
+}
+
+// SendMessage runs a turn answering a user message with artifacts.
+// The turn is only added to the conversation if the run succeeds. If the conversation
+// could not be summarized, the response is returned along with the error.
+func (c *Conversation) SendMessage(ctx context.Context, message *llm.ModelMessage) (*AgentResponse, error) {
+	if message.Role != llm.RoleUser {
+		return nil, fmt.Errorf("conversation message must be from user, got '%s'", message.Role)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	req := c.manager.template
+	req.Messages = c.requestMessages(message)
+	req.Metadata = c.session.Metadata
+	req.Session = c.session.Data
+	req.RunID = ""
+	resp, err := c.manager.runner.Run(ctx, &req, nil)
+	if err != nil {
+		return resp, err
+	}
+
+	answer, err := answerMessage(resp.Output)
+	if err != nil {
+		return nil, err
+	}
+	c.session.Messages = append(c.session.Messages, message, answer)
+	if resp.Usage != nil {
+		c.session.Usage.Append(resp.Usage)
+	}
+	if resp.Cost != nil {
+		c.session.Cost += *resp.Cost
+	}
+	c.session.UpdatedAt = time.Now()
+
+	// A failed summarization keeps the messages, it is retried on the next turn
+	summaryErr := c.summarize(ctx)
+	if err := c.manager.store.Save(ctx, c.session); err != nil {
+		return nil, fmt.Errorf("failed to save session: %w", err)
+	}
+	if summaryErr != nil {
+		return resp, fmt.Errorf("failed to summarize conversation: %w", summaryErr)
+	}
+	return resp, nil
+}
+
+// requestMessages returns the history sent for a turn, the summary preceding the oldest message
+func (c *Conversation) requestMessages(message *llm.ModelMessage) []*llm.ModelMessage {
+	messages := make([]*llm.ModelMessage, 0, len(c.session.Messages)+1)
+	messages = append(messages, c.session.Messages...)
+	messages = append(messages, message)
+	if c.session.Summary != "" {
+		first := *messages[0]
+		first.Content = fmt.Sprintf("Summary of the earlier conversation:\n%s\n\n%s", c.session.Summary, first.Content)
+		messages[0] = &first
+	}
+	return messages
+}
+
+// summarize folds the older half of the messages into the summary once there are too many.
+// The kept messages start with a user message.
+func (c *Conversation) summarize(ctx context.Context) error {
+	if c.manager.summarizer == nil || len(c.session.Messages) <= c.manager.maxMessages {
+		return nil
+	}
+	cut := len(c.session.Messages) - c.manager.maxMessages/2
+	for cut < len(c.session.Messages)-1 && c.session.Messages[cut].Role != llm.RoleUser {
+		cut++
+	}
+	summary, err := c.manager.summarizer(ctx, c.session.Summary, c.session.Messages[:cut])
+	if err != nil {
+		return err
+	}
+	c.session.Summary = summary
+	c.session.Messages = append([]*llm.ModelMessage(nil), c.session.Messages[cut:]...)
+	return nil
+}
+
+// answerMessage creates the assistant message of a turn from the run output
+func answerMessage(output any) (*llm.ModelMessage, error) {
+	content, ok := output.(string)
+	if !ok {
+		data, err := json.Marshal(output)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal output: %w", err)
+		}
+		content = string(data)
+	}
+	return &llm.ModelMessage{Role: llm.RoleAssistant, Content: content}, nil
+}
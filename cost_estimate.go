@@ -0,0 +1,145 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/easyagent-dev/llm"
+)
+
+const (
+	// DefaultEstimatedOutputTokens is the assumed number of tokens the model writes per call
+	DefaultEstimatedOutputTokens = 300
+
+	// DefaultEstimatedToolResultTokens is the assumed number of tokens of a tool result
+	DefaultEstimatedToolResultTokens = 500
+)
+
+// CostProjection is the projected usage and cost of a run for a number of iterations
+type CostProjection struct {
+	Iterations   int     `json:"iterations"`
+	InputTokens  int     `json:"inputTokens"`
+	OutputTokens int     `json:"outputTokens"`
+	Cost         float64 `json:"cost"`
+}
+
+// CostEstimate is the projected cost of a request before it runs
+type CostEstimate struct {
+	// PromptTokens is the token count of the rendered system prompt
+	PromptTokens int `json:"promptTokens"`
+
+	// MessageTokens is the token count of the request messages
+	MessageTokens int `json:"messageTokens"`
+
+	// Min completes in a single call, Expected in half of MaxIterations and Max uses all of them
+	Min      CostProjection `json:"min"`
+	Expected CostProjection `json:"expected"`
+	Max      CostProjection `json:"max"`
+}
+
+// CostEstimator is implemented by the runners of this package
+type CostEstimator interface {
+	EstimateCost(req *AgentRequest, pricing llm.ModelPricing, opts ...EstimateOption) (*CostEstimate, error)
+}
+
+var (
+	_ CostEstimator = (*JSONCompletionRunner)(nil)
+	_ CostEstimator = (*JSONCompletionStreamRunner)(nil)
+	_ CostEstimator = (*XMLCompletionRunner)(nil)
+	_ CostEstimator = (*XMLCompletionStreamRunner)(nil)
+)
+
+// EstimateOption is a functional option for configuring cost estimates
+type EstimateOption func(*estimateConfig)
+
+// estimateConfig holds the assumptions of a cost estimate
+type estimateConfig struct {
+	outputTokens       int
+	toolResultTokens   int
+	expectedIterations int
+}
+
+// WithEstimatedOutputTokens sets the assumed number of tokens the model writes per call
+func WithEstimatedOutputTokens(tokens int) EstimateOption {
+	return func(c *estimateConfig) {
+		c.outputTokens = tokens
+	}
+}
+
+// WithEstimatedToolResultTokens sets the assumed number of tokens of a tool result
+func WithEstimatedToolResultTokens(tokens int) EstimateOption {
+	return func(c *estimateConfig) {
+		c.toolResultTokens = tokens
+	}
+}
+
+// WithExpectedIterations sets the iterations of the expected projection, e.g. the average of past runs
+func WithExpectedIterations(iterations int) EstimateOption {
+	return func(c *estimateConfig) {
+		c.expectedIterations = iterations
+	}
+}
+
+// EstimateCost renders the system prompt of req, counts its tokens and those of the request messages
+// with the runner's tokenizer, and projects the cost of the run with pricing. Every iteration is
+// assumed to send the whole history, growing by a tool call and its result. Direct answer runs are
+// projected to complete in a single call.
+func (r *BaseRunner) EstimateCost(req *AgentRequest, pricing llm.ModelPricing, opts ...EstimateOption) (*CostEstimate, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	config := &estimateConfig{
+		outputTokens:     DefaultEstimatedOutputTokens,
+		toolResultTokens: DefaultEstimatedToolResultTokens,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	toolRegistry, err := r.newRunToolRegistry(r.agent, req)
+	if err != nil {
+		return nil, err
+	}
+	run := &agentRun{
+		req:          req,
+		agent:        withModelRef(r.agent, req.Model),
+		capabilities: r.requestCapabilities(req),
+		toolRegistry: toolRegistry,
+		directAnswer: isDirectAnswer(req, r.agent),
+		promptSuffix: r.feedbackTemplates(req.Locale).languagePrompt(req.Locale),
+	}
+	if _, err := r.systemPrompt(run); err != nil {
+		return nil, fmt.Errorf("failed to create prompts: %w", err)
+	}
+
+	estimate := &CostEstimate{
+		PromptTokens:  run.promptStats.Tokens,
+		MessageTokens: messagesTokens(r.tokenizer, req.Messages),
+	}
+	maxIterations := req.MaxIterations
+	if run.directAnswer {
+		maxIterations = 1
+	}
+	expected := config.expectedIterations
+	if expected <= 0 {
+		expected = (maxIterations + 1) / 2
+	}
+	expected = min(expected, maxIterations)
+
+	project := func(iterations int) CostProjection {
+		projection := CostProjection{Iterations: iterations}
+		history := estimate.MessageTokens
+		for i := 0; i < iterations; i++ {
+			projection.InputTokens += estimate.PromptTokens + history
+			projection.OutputTokens += config.outputTokens
+			history += config.outputTokens + config.toolResultTokens
+		}
+		projection.Cost = float64(projection.InputTokens)*pricing.Prompt/1e6 +
+			float64(projection.OutputTokens)*pricing.Completion/1e6 +
+			float64(iterations)*pricing.Request
+		return projection
+	}
+	estimate.Min = project(1)
+	estimate.Expected = project(expected)
+	estimate.Max = project(maxIterations)
+	return estimate, nil
+}
@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Credential is a secret of the end user for an external service, e.g. an OAuth access token
+type Credential struct {
+	// Type describes the kind of credential, e.g. "oauth2", "api_key" or "basic"
+	Type string
+
+	// Token is the access token or API key
+	Token string
+
+	// RefreshToken obtains a new access token when Token expires
+	RefreshToken string
+
+	// Username and Password are set for basic credentials
+	Username string
+	Password string
+
+	// ExpiresAt is when Token expires, zero if it does not
+	ExpiresAt time.Time
+
+	// Metadata holds provider-specific values such as scopes or a database DSN
+	Metadata map[string]string
+}
+
+// Expired reports whether the credential has expired
+func (c *Credential) Expired() bool {
+	return !c.ExpiresAt.IsZero() && time.Now().After(c.ExpiresAt)
+}
+
+// CredentialsProvider resolves the credentials of the current end user.
+// Implementations typically look up the user from MetadataOf(ctx) or their own context values.
+type CredentialsProvider interface {
+	// Credential returns the credential for service, or an error wrapping ErrCredentialNotFound
+	Credential(ctx context.Context, service string) (*Credential, error)
+}
+
+// CredentialsProviderFunc is a function implementing CredentialsProvider
+type CredentialsProviderFunc func(ctx context.Context, service string) (*Credential, error)
+
+var _ CredentialsProvider = CredentialsProviderFunc(nil)
+
+// Credential calls f(ctx, service)
+func (f CredentialsProviderFunc) Credential(ctx context.Context, service string) (*Credential, error) {
+	return f(ctx, service)
+}
+
+// StaticCredentials provides fixed credentials by service name
+type StaticCredentials map[string]*Credential
+
+var _ CredentialsProvider = StaticCredentials(nil)
+
+// Credential returns the credential registered for service
+func (c StaticCredentials) Credential(ctx context.Context, service string) (*Credential, error) {
+	credential, ok := c[service]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrCredentialNotFound, service)
+	}
+	return credential, nil
+}
+
+// credentialsKey is the key for storing the CredentialsProvider in context.Context
+const credentialsKey contextKey = "credentials"
+
+// WithCredentials returns a new context carrying provider.
+// Runners do this for AgentRequest.Credentials, so tools receive it in their context.
+func WithCredentials(ctx context.Context, provider CredentialsProvider) context.Context {
+	return context.WithValue(ctx, credentialsKey, provider)
+}
+
+// CredentialsOf returns the CredentialsProvider carried by ctx
+func CredentialsOf(ctx context.Context) (CredentialsProvider, bool) {
+	provider, ok := ctx.Value(credentialsKey).(CredentialsProvider)
+	return provider, ok
+}
+
+// GetCredential returns the end user's credential for service from the provider carried by ctx
+func GetCredential(ctx context.Context, service string) (*Credential, error) {
+	provider, ok := CredentialsOf(ctx)
+	if !ok {
+		return nil, fmt.Errorf("%w: no credentials provider in context", ErrCredentialNotFound)
+	}
+	return provider.Credential(ctx, service)
+}
@@ -0,0 +1,183 @@
+(function () {
+    "use strict";
+
+    let selected = null;
+
+    function el(tag, attrs, children) {
+        const node = document.createElement(tag);
+        Object.entries(attrs || {}).forEach(([key, value]) => {
+            if (key === "text") {
+                node.textContent = value;
+            } else {
+                node.setAttribute(key, value);
+            }
+        });
+        (children || []).forEach((child) => node.appendChild(child));
+        return node;
+    }
+
+    function pretty(value) {
+        if (typeof value === "string") {
+            return value;
+        }
+        return JSON.stringify(value, null, 2);
+    }
+
+    // lineDiff returns the lines of b annotated against a using a longest common subsequence
+    function lineDiff(a, b) {
+        const x = a.split("\n"), y = b.split("\n");
+        const lcs = Array.from({length: x.length + 1}, () => new Array(y.length + 1).fill(0));
+        for (let i = x.length - 1; i >= 0; i--) {
+            for (let j = y.length - 1; j >= 0; j--) {
+                lcs[i][j] = x[i] === y[j] ? lcs[i + 1][j + 1] + 1 : Math.max(lcs[i + 1][j], lcs[i][j + 1]);
+            }
+        }
+        const out = [];
+        let i = 0, j = 0;
+        while (i < x.length || j < y.length) {
+            if (i < x.length && j < y.length && x[i] === y[j]) {
+                out.push({op: " ", line: x[i]});
+                i++;
+                j++;
+            } else if (j < y.length && (i >= x.length || lcs[i][j + 1] >= lcs[i + 1][j])) {
+                out.push({op: "+", line: y[j++]});
+            } else {
+                out.push({op: "-", line: x[i++]});
+            }
+        }
+        return out;
+    }
+
+    function renderDiff(previous, current) {
+        const changes = lineDiff(previous, current).filter((change) => change.op !== " ");
+        if (changes.length === 0) {
+            return el("p", {text: "Prompt unchanged since the previous iteration."});
+        }
+        return el("pre", {class: "diff"}, changes.map((change) =>
+            el("span", {class: change.op === "+" ? "add" : "del", text: change.op + " " + change.line})));
+    }
+
+    function renderMessage(message) {
+        const role = message.role || "";
+        const children = [el("div", {class: "role", text: role})];
+        if (message.content) {
+            children.push(el("pre", {text: message.content}));
+        }
+        if (message.toolCall) {
+            children.push(el("pre", {text: pretty(message.toolCall)}));
+        }
+        return el("div", {class: "msg " + role}, children);
+    }
+
+    function renderUsage(run) {
+        const svg = document.getElementById("usage");
+        svg.innerHTML = "";
+        const iterations = run.iterations || [];
+        const max = Math.max(1, ...iterations.map((it) => it.usage ? it.usage.totalInputTokens + it.usage.totalOutputTokens : 0));
+        const width = 36;
+        svg.setAttribute("width", String(Math.max(200, iterations.length * (width + 8))));
+        iterations.forEach((it, index) => {
+            const usage = it.usage || {totalInputTokens: 0, totalOutputTokens: 0, totalCacheReadTokens: 0};
+            const x = index * (width + 8);
+            const scale = 100 / max;
+            const input = usage.totalInputTokens * scale, output = usage.totalOutputTokens * scale;
+            const cache = usage.totalCacheReadTokens * scale;
+            const ns = "http://www.w3.org/2000/svg";
+            [["in", 100 - input, input, x, width / 2], ["cache", 100 - cache, cache, x, width / 2],
+                ["out", 100 - output, output, x + width / 2, width / 2]].forEach(([cls, y, h, rx, w]) => {
+                const rect = document.createElementNS(ns, "rect");
+                rect.setAttribute("class", cls);
+                rect.setAttribute("x", rx);
+                rect.setAttribute("y", y);
+                rect.setAttribute("width", w);
+                rect.setAttribute("height", h);
+                const title = document.createElementNS(ns, "title");
+                title.textContent = "#" + index + " in:" + usage.totalInputTokens + " out:" + usage.totalOutputTokens +
+                    " cache:" + usage.totalCacheReadTokens;
+                rect.appendChild(title);
+                svg.appendChild(rect);
+            });
+            const label = document.createElementNS(ns, "text");
+            label.setAttribute("x", x);
+            label.setAttribute("y", 115);
+            label.setAttribute("font-size", "10");
+            label.textContent = "#" + index;
+            svg.appendChild(label);
+        });
+    }
+
+    function renderRun(run) {
+        document.getElementById("empty").hidden = true;
+        document.getElementById("run").hidden = false;
+        document.getElementById("run-title").textContent = run.agent + " — " + run.id;
+        document.getElementById("run-meta").textContent = "Started " + new Date(run.startedAt).toLocaleString() +
+            " · in " + run.usage.totalInputTokens + " / out " + run.usage.totalOutputTokens + " tokens" +
+            (run.metadata ? " · " + Object.entries(run.metadata).map(([k, v]) => k + "=" + v).join(" ") : "");
+        renderUsage(run);
+
+        const container = document.getElementById("iterations");
+        const open = new Set(Array.from(container.querySelectorAll("details[open]")).map((d) => d.dataset.index));
+        container.innerHTML = "";
+        (run.iterations || []).forEach((it, index) => {
+            const calls = (run.toolCalls || []).filter((call) => call.iteration === index);
+            const previous = index > 0 ? run.iterations[index - 1].prompts : null;
+            const body = el("div", {class: "body"}, [
+                el("h4", {text: "Prompt" + (previous === null ? "" : " diff")}),
+                previous === null ? el("pre", {text: it.prompts}) : renderDiff(previous, it.prompts),
+                el("h4", {text: "Messages (" + (it.messages || []).length + ")"}),
+                el("div", {}, (it.messages || []).map(renderMessage)),
+                el("h4", {text: "Output"}),
+                el("pre", {text: it.output || "(pending)"}),
+            ].concat(calls.map((call) => el("div", {}, [
+                el("h4", {text: "Tool " + call.tool}),
+                el("pre", {text: "input: " + pretty(call.input) + "\n\noutput: " + pretty(call.output)}),
+            ]))));
+            const details = el("details", {"data-index": String(index)}, [
+                el("summary", {text: "#" + index + " " + it.agent + " · " + it.provider + "/" + it.model +
+                        (calls.length ? " · " + calls.map((call) => call.tool).join(", ") : "")}),
+                body,
+            ]);
+            details.open = open.has(String(index));
+            container.appendChild(details);
+        });
+    }
+
+    async function loadRun(id) {
+        const response = await fetch("api/runs/" + encodeURIComponent(id));
+        if (response.ok) {
+            renderRun(await response.json());
+        }
+    }
+
+    async function loadRuns() {
+        const response = await fetch("api/runs");
+        const runs = await response.json();
+        const list = document.getElementById("runs");
+        list.innerHTML = "";
+        runs.forEach((run) => {
+            const item = el("li", {class: run.id === selected ? "active" : ""}, [
+                el("strong", {text: run.agent}),
+                el("small", {text: new Date(run.startedAt).toLocaleTimeString() + " · " + run.iterations + " iterations"}),
+            ]);
+            item.addEventListener("click", () => {
+                selected = run.id;
+                loadRuns();
+                loadRun(run.id);
+            });
+            list.appendChild(item);
+        });
+        if (selected === null && runs.length > 0) {
+            selected = runs[0].id;
+            loadRun(selected);
+        }
+    }
+
+    const events = new EventSource("api/events");
+    events.onmessage = (event) => {
+        loadRuns();
+        if (event.data === selected) {
+            loadRun(selected);
+        }
+    };
+    loadRuns();
+})();
@@ -0,0 +1,309 @@
+// Package debugui serves a local web page for inspecting agent runs: message history,
+// tool calls with inputs and outputs, token usage per iteration and prompt diffs.
+//
+// The server collects runs through the agent.Callback interface:
+//
+//	ui := debugui.New()
+//	go ui.ListenAndServe("localhost:8089")
+//	resp, err := runner.Run(ctx, req, agent.NewMultiCallback(callback, ui))
+package debugui
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/easyagent-dev/agent"
+	"github.com/easyagent-dev/llm"
+)
+
+//go:embed assets
+var assets embed.FS
+
+// DefaultMaxRuns is the number of runs kept in memory by default
+const DefaultMaxRuns = 50
+
+// Iteration is a single model call of a run
+type Iteration struct {
+	Index     int                 `json:"index"`
+	Agent     string              `json:"agent"`
+	Provider  string              `json:"provider"`
+	Model     string              `json:"model"`
+	Prompts   string              `json:"prompts"`
+	Messages  []*llm.ModelMessage `json:"messages"`
+	Output    string              `json:"output"`
+	Usage     *llm.TokenUsage     `json:"usage,omitempty"`
+	StartedAt time.Time           `json:"startedAt"`
+	EndedAt   time.Time           `json:"endedAt,omitempty"`
+}
+
+// ToolCall is a tool execution of a run
+type ToolCall struct {
+	Iteration int       `json:"iteration"`
+	Tool      string    `json:"tool"`
+	Input     any       `json:"input"`
+	Output    any       `json:"output,omitempty"`
+	StartedAt time.Time `json:"startedAt"`
+	EndedAt   time.Time `json:"endedAt,omitempty"`
+}
+
+// Run is the recorded history of an agent run
+type Run struct {
+	ID         string            `json:"id"`
+	Agent      string            `json:"agent"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	StartedAt  time.Time         `json:"startedAt"`
+	UpdatedAt  time.Time         `json:"updatedAt"`
+	Iterations []*Iteration      `json:"iterations"`
+	ToolCalls  []*ToolCall       `json:"toolCalls"`
+	Usage      llm.TokenUsage    `json:"usage"`
+}
+
+// RunSummary is the list view of a run
+type RunSummary struct {
+	ID         string         `json:"id"`
+	Agent      string         `json:"agent"`
+	StartedAt  time.Time      `json:"startedAt"`
+	UpdatedAt  time.Time      `json:"updatedAt"`
+	Iterations int            `json:"iterations"`
+	ToolCalls  int            `json:"toolCalls"`
+	Usage      llm.TokenUsage `json:"usage"`
+}
+
+// Option is a functional option for configuring the server
+type Option func(*Server)
+
+// WithMaxRuns sets how many runs are kept in memory, the oldest are dropped first
+func WithMaxRuns(n int) Option {
+	return func(s *Server) {
+		s.maxRuns = n
+	}
+}
+
+// Server records agent runs and serves the debug UI.
+// It implements agent.Callback. This type is safe for concurrent use.
+type Server struct {
+	mu          sync.RWMutex
+	runs        map[string]*Run
+	order       []string
+	maxRuns     int
+	subscribers map[chan string]struct{}
+}
+
+var _ agent.Callback = (*Server)(nil)
+
+// New creates a debug UI server
+func New(opts ...Option) *Server {
+	s := &Server{
+		runs:        make(map[string]*Run),
+		maxRuns:     DefaultMaxRuns,
+		subscribers: make(map[chan string]struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Handler returns the HTTP handler serving the UI and its API
+func (s *Server) Handler() http.Handler {
+	static, _ := fs.Sub(assets, "assets")
+	mux := http.NewServeMux()
+	mux.Handle("GET /", http.FileServerFS(static))
+	mux.HandleFunc("GET /api/runs", s.handleRuns)
+	mux.HandleFunc("GET /api/runs/{id}", s.handleRun)
+	mux.HandleFunc("GET /api/events", s.handleEvents)
+	return mux
+}
+
+// ListenAndServe serves the UI on addr, e.g. "localhost:8089"
+func (s *Server) ListenAndServe(addr string) error {
+	return http.ListenAndServe(addr, s.Handler())
+}
+
+// BeforeModel records the start of an iteration with its prompts and messages
+func (s *Server) BeforeModel(ctx context.Context, provider string, model string, prompts string, messages []*llm.ModelMessage) error {
+	s.update(ctx, func(run *Run) {
+		run.Iterations = append(run.Iterations, &Iteration{
+			Index:     len(run.Iterations),
+			Agent:     agentName(ctx),
+			Provider:  provider,
+			Model:     model,
+			Prompts:   prompts,
+			Messages:  append([]*llm.ModelMessage(nil), messages...),
+			StartedAt: time.Now(),
+		})
+	})
+	return nil
+}
+
+// AfterModel records the output and usage of the current iteration
+func (s *Server) AfterModel(ctx context.Context, provider string, model string, prompts string, messages []*llm.ModelMessage, output string, usage *llm.TokenUsage) error {
+	s.update(ctx, func(run *Run) {
+		if len(run.Iterations) == 0 {
+			return
+		}
+		iteration := run.Iterations[len(run.Iterations)-1]
+		iteration.Output = output
+		iteration.Usage = usage
+		iteration.EndedAt = time.Now()
+		if usage != nil {
+			run.Usage.Append(usage)
+		}
+	})
+	return nil
+}
+
+// BeforeToolCall records a tool call with its input
+func (s *Server) BeforeToolCall(ctx context.Context, toolName string, input any) error {
+	s.update(ctx, func(run *Run) {
+		run.ToolCalls = append(run.ToolCalls, &ToolCall{
+			Iteration: len(run.Iterations) - 1,
+			Tool:      toolName,
+			Input:     input,
+			StartedAt: time.Now(),
+		})
+	})
+	return nil
+}
+
+// AfterToolCall records the output of the last call of the tool
+func (s *Server) AfterToolCall(ctx context.Context, toolName string, input any, output interface{}) error {
+	s.update(ctx, func(run *Run) {
+		for i := len(run.ToolCalls) - 1; i >= 0; i-- {
+			if call := run.ToolCalls[i]; call.Tool == toolName && call.EndedAt.IsZero() {
+				call.Output = output
+				call.EndedAt = time.Now()
+				return
+			}
+		}
+	})
+	return nil
+}
+
+// Runs returns summaries of the recorded runs, most recent first
+func (s *Server) Runs() []RunSummary {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	summaries := make([]RunSummary, 0, len(s.order))
+	for i := len(s.order) - 1; i >= 0; i-- {
+		run := s.runs[s.order[i]]
+		summaries = append(summaries, RunSummary{
+			ID:         run.ID,
+			Agent:      run.Agent,
+			StartedAt:  run.StartedAt,
+			UpdatedAt:  run.UpdatedAt,
+			Iterations: len(run.Iterations),
+			ToolCalls:  len(run.ToolCalls),
+			Usage:      run.Usage,
+		})
+	}
+	return summaries
+}
+
+// update applies fn to the run executing in ctx under the lock and notifies subscribers
+func (s *Server) update(ctx context.Context, fn func(run *Run)) {
+	ac, ok := agent.AgentContextOf(ctx)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	run, exists := s.runs[ac.RunID]
+	if !exists {
+		run = &Run{
+			ID:        ac.RunID,
+			Agent:     agentName(ctx),
+			Metadata:  ac.Metadata,
+			StartedAt: time.Now(),
+		}
+		s.runs[run.ID] = run
+		s.order = append(s.order, run.ID)
+		if s.maxRuns > 0 && len(s.order) > s.maxRuns {
+			delete(s.runs, s.order[0])
+			s.order = s.order[1:]
+		}
+	}
+	fn(run)
+	run.UpdatedAt = time.Now()
+	for subscriber := range s.subscribers {
+		// Drop the notification for slow subscribers, they refetch on the next one
+		select {
+		case subscriber <- run.ID:
+		default:
+		}
+	}
+	s.mu.Unlock()
+}
+
+func (s *Server) handleRuns(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.Runs())
+}
+
+func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	run, ok := s.runs[r.PathValue("id")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, run)
+}
+
+// handleEvents streams the IDs of updated runs as server-sent events
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	updates := make(chan string, 16)
+	s.mu.Lock()
+	s.subscribers[updates] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.subscribers, updates)
+		s.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case id := <-updates:
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", id); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// agentName returns the name of the active agent in ctx
+func agentName(ctx context.Context) string {
+	if ac, ok := agent.AgentContextOf(ctx); ok {
+		if current := ac.CurrentAgent(); current != nil {
+			return current.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,338 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DegradationLevel is a tier of service, from the full agent to a canned fallback
+type DegradationLevel int
+
+const (
+	// DegradationNone runs the full agent
+	DegradationNone DegradationLevel = iota
+
+	// DegradationReducedTools runs an agent with fewer tools, e.g. without those of an unhealthy dependency
+	DegradationReducedTools
+
+	// DegradationSingleShot answers with a single model call
+	DegradationSingleShot
+
+	// DegradationFallback returns a canned response without calling any model
+	DegradationFallback
+)
+
+// String returns the name of the level
+func (l DegradationLevel) String() string {
+	switch l {
+	case DegradationNone:
+		return "none"
+	case DegradationReducedTools:
+		return "reduced_tools"
+	case DegradationSingleShot:
+		return "single_shot"
+	case DegradationFallback:
+		return "fallback"
+	default:
+		return fmt.Sprintf("level_%d", int(l))
+	}
+}
+
+// Degradation tells how a response was degraded
+type Degradation struct {
+	Level DegradationLevel `json:"level"`
+
+	// Reason is the trigger or the failure that caused the degradation
+	Reason string `json:"reason"`
+}
+
+// DegradationTrigger decides the level requests run at, e.g. from the health of a provider,
+// budget pressure or latency
+type DegradationTrigger interface {
+	// Degradation returns the minimum level req runs at and why, DegradationNone if it does not apply
+	Degradation(ctx context.Context, req *AgentRequest) (DegradationLevel, string)
+}
+
+// DegradationTriggerFunc adapts a function to the DegradationTrigger interface
+type DegradationTriggerFunc func(ctx context.Context, req *AgentRequest) (DegradationLevel, string)
+
+// Degradation calls f
+func (f DegradationTriggerFunc) Degradation(ctx context.Context, req *AgentRequest) (DegradationLevel, string) {
+	return f(ctx, req)
+}
+
+// DegradationObserver is implemented by triggers learning from the runs of a degrading runner
+type DegradationObserver interface {
+	// ObserveRun is called after every run attempt with its level, duration and error
+	ObserveRun(level DegradationLevel, duration time.Duration, err error)
+}
+
+// FallbackFunc returns the canned response of a request no tier could answer.
+// cause is the reason of the degradation.
+type FallbackFunc func(ctx context.Context, req *AgentRequest, cause string) *AgentResponse
+
+// CannedFallback returns a fallback answering every request with output
+func CannedFallback(output any) FallbackFunc {
+	return func(ctx context.Context, req *AgentRequest, cause string) *AgentResponse {
+		return &AgentResponse{Output: output}
+	}
+}
+
+// CircuitTrigger degrades to level while breaker is not closed, e.g. during a provider outage
+func CircuitTrigger(breaker *CircuitBreaker, level DegradationLevel) DegradationTrigger {
+	return DegradationTriggerFunc(func(ctx context.Context, req *AgentRequest) (DegradationLevel, string) {
+		if state := breaker.State(); state != CircuitClosed {
+			return level, fmt.Sprintf("circuit breaker is %s", state)
+		}
+		return DegradationNone, ""
+	})
+}
+
+// HealthTrigger degrades to level while runner is unhealthy. Use WithHealthCacheTTL on the runner
+// to keep the checks cheap.
+func HealthTrigger(runner HealthyRunner, level DegradationLevel) DegradationTrigger {
+	return DegradationTriggerFunc(func(ctx context.Context, req *AgentRequest) (DegradationLevel, string) {
+		if err := runner.Healthy(ctx); err != nil {
+			return level, err.Error()
+		}
+		return DegradationNone, ""
+	})
+}
+
+// DegradationStep is the level of a trigger once a measure reaches a threshold
+type DegradationStep struct {
+	Threshold float64
+	Level     DegradationLevel
+}
+
+// BudgetTrigger degrades requests as their budget runs out. used returns the share of the budget
+// of the request already spent, e.g. the monthly cost of its tenant over its limit, and the level
+// is the highest one of the steps whose threshold is reached.
+func BudgetTrigger(used func(ctx context.Context, req *AgentRequest) float64, steps ...DegradationStep) DegradationTrigger {
+	steps = slices.Clone(steps)
+	return DegradationTriggerFunc(func(ctx context.Context, req *AgentRequest) (DegradationLevel, string) {
+		share := used(ctx, req)
+		level := DegradationNone
+		for _, step := range steps {
+			if share >= step.Threshold {
+				level = max(level, step.Level)
+			}
+		}
+		if level == DegradationNone {
+			return level, ""
+		}
+		return level, fmt.Sprintf("%.0f%% of the budget is spent", share*100)
+	})
+}
+
+// LatencySLO is a latency objective of the runs of a tier
+type LatencySLO struct {
+	// Target is the latency the percentile of the runs must stay under
+	Target time.Duration
+
+	// Percentile is the share of runs under Target, 0.95 if 0
+	Percentile float64
+
+	// Window is the number of recent runs measured, 20 if 0
+	Window int
+
+	// Cooldown is how long requests stay degraded once the objective is breached, a minute if 0
+	Cooldown time.Duration
+
+	// Level is the level requests are degraded to. Runs of lower levels are measured.
+	Level DegradationLevel
+}
+
+// LatencyTrigger degrades requests for a cooldown period when the latency of recent runs breaches
+// its objective, then lets them run at the lower levels again to measure them anew.
+// This type is safe for concurrent use.
+type LatencyTrigger struct {
+	slo          LatencySLO
+	mu           sync.Mutex
+	durations    []time.Duration
+	trippedUntil time.Time
+	breach       time.Duration
+}
+
+var (
+	_ DegradationTrigger  = (*LatencyTrigger)(nil)
+	_ DegradationObserver = (*LatencyTrigger)(nil)
+)
+
+// NewLatencyTrigger creates a trigger enforcing slo
+func NewLatencyTrigger(slo LatencySLO) *LatencyTrigger {
+	if slo.Percentile <= 0 || slo.Percentile > 1 {
+		slo.Percentile = 0.95
+	}
+	if slo.Window <= 0 {
+		slo.Window = 20
+	}
+	if slo.Cooldown <= 0 {
+		slo.Cooldown = time.Minute
+	}
+	return &LatencyTrigger{slo: slo}
+}
+
+// Degradation returns the level of the objective while it is breached
+func (t *LatencyTrigger) Degradation(ctx context.Context, req *AgentRequest) (DegradationLevel, string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if time.Now().Before(t.trippedUntil) {
+		return t.slo.Level, fmt.Sprintf("p%g latency %s exceeds %s", t.slo.Percentile*100, t.breach.Round(time.Millisecond), t.slo.Target)
+	}
+	return DegradationNone, ""
+}
+
+// ObserveRun measures the runs of the levels below the objective's level, failed ones included
+func (t *LatencyTrigger) ObserveRun(level DegradationLevel, duration time.Duration, err error) {
+	if level >= t.slo.Level {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.durations = append(t.durations, duration)
+	if len(t.durations) > t.slo.Window {
+		t.durations = t.durations[len(t.durations)-t.slo.Window:]
+	}
+	if len(t.durations) < t.slo.Window {
+		return
+	}
+	sorted := slices.Sorted(slices.Values(t.durations))
+	latency := sorted[min(int(float64(len(sorted))*t.slo.Percentile), len(sorted)-1)]
+	if latency > t.slo.Target {
+		t.breach = latency
+		t.trippedUntil = time.Now().Add(t.slo.Cooldown)
+		t.durations = t.durations[:0]
+	}
+}
+
+// DegradationOption is a functional option for configuring degrading runners
+type DegradationOption func(*DegradingRunner)
+
+// WithReducedTools sets the runner of DegradationReducedTools, e.g. the agent without its slow or costly tools
+func WithReducedTools(runner Runner) DegradationOption {
+	return func(r *DegradingRunner) {
+		r.tiers[DegradationReducedTools] = runner
+	}
+}
+
+// WithSingleShot sets the runner of DegradationSingleShot, e.g. an agent without tools on a cheaper
+// model. Its requests are limited to one iteration.
+func WithSingleShot(runner Runner) DegradationOption {
+	return func(r *DegradingRunner) {
+		r.tiers[DegradationSingleShot] = runner
+	}
+}
+
+// WithFallback sets the canned response of DegradationFallback
+func WithFallback(fallback FallbackFunc) DegradationOption {
+	return func(r *DegradingRunner) {
+		r.fallback = fallback
+	}
+}
+
+// WithDegradationTrigger adds a trigger deciding the level of requests
+func WithDegradationTrigger(trigger DegradationTrigger) DegradationOption {
+	return func(r *DegradingRunner) {
+		r.triggers = append(r.triggers, trigger)
+	}
+}
+
+// WithDegradationLogger sets the logger of degraded requests
+func WithDegradationLogger(logger Logger) DegradationOption {
+	return func(r *DegradingRunner) {
+		r.logger = logger
+	}
+}
+
+// DegradingRunner runs requests at the highest tier of service its triggers allow, and on failure
+// downgrades them to the next configured tier, down to a canned fallback, so a service keeps
+// answering when a dependency is unhealthy. Degraded responses tell their level and why.
+// Requests cancelled by the caller are not downgraded.
+type DegradingRunner struct {
+	tiers    [DegradationFallback]Runner
+	fallback FallbackFunc
+	triggers []DegradationTrigger
+	logger   Logger
+}
+
+var _ Runner = (*DegradingRunner)(nil)
+
+// NewDegradingRunner creates a degrading runner running requests with full when no trigger applies
+func NewDegradingRunner(full Runner, opts ...DegradationOption) (*DegradingRunner, error) {
+	if full == nil {
+		return nil, errors.New("full runner is required")
+	}
+	r := &DegradingRunner{logger: &NoOpLogger{}}
+	r.tiers[DegradationNone] = full
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r, nil
+}
+
+// Level returns the level of req decided by the triggers, the highest one, and its reason
+func (r *DegradingRunner) Level(ctx context.Context, req *AgentRequest) (DegradationLevel, string) {
+	level, reason := DegradationNone, ""
+	for _, trigger := range r.triggers {
+		if l, why := trigger.Degradation(ctx, req); l > level {
+			level, reason = l, why
+		}
+	}
+	return level, reason
+}
+
+// Run runs the request at its level, downgrading it on failure
+func (r *DegradingRunner) Run(ctx context.Context, req *AgentRequest, callback Callback) (*AgentResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	level, reason := r.Level(ctx, req)
+	var errs []error
+	for ; level < DegradationFallback; level++ {
+		runner := r.tiers[level]
+		if runner == nil {
+			continue
+		}
+		tierReq := req
+		if level == DegradationSingleShot && req.MaxIterations > 1 {
+			copied := *req
+			copied.MaxIterations = 1
+			tierReq = &copied
+		}
+
+		start := time.Now()
+		resp, err := runner.Run(ctx, tierReq, callback)
+		r.observe(level, time.Since(start), err)
+		// Partial responses are answers too, the run error tells they are incomplete
+		if err == nil || resp != nil && resp.Partial || ctx.Err() != nil {
+			if resp != nil && level > DegradationNone {
+				resp.Degradation = &Degradation{Level: level, Reason: reason}
+			}
+			return resp, err
+		}
+		r.logger.Warn("degrading request", "level", level.String(), "error", err)
+		errs = append(errs, fmt.Errorf("degradation level %s: %w", level, err))
+		reason = fmt.Sprintf("%s failed: %s", level, err)
+	}
+
+	if r.fallback == nil {
+		return nil, errors.Join(errs...)
+	}
+	resp := r.fallback(ctx, req, reason)
+	resp.Degradation = &Degradation{Level: DegradationFallback, Reason: reason}
+	return resp, nil
+}
+
+// observe reports a run attempt to the triggers learning from them
+func (r *DegradingRunner) observe(level DegradationLevel, duration time.Duration, err error) {
+	for _, trigger := range r.triggers {
+		if observer, ok := trigger.(DegradationObserver); ok {
+			observer.ObserveRun(level, duration, err)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package agent
+
+import (
+	_ "embed"
+)
+
+//go:embed prompts/direct_system.md
+var directSystemPrompt string
+
+// directAnswerPrompt is appended to the system prompt in direct answer mode when tools are available
+const directAnswerPrompt = `
+
+<direct_answer>
+    If you can answer without using a tool, reply with the answer in plain text and no tool call
+</direct_answer>`
+
+// isDirectAnswer reports whether a run may complete with a plain text answer.
+// It is the case when requested, or when there is no output schema and the agent has no tools.
+func isDirectAnswer(req *AgentRequest, agent *Agent) bool {
+	if req.DirectAnswer {
+		return true
+	}
+	return req.OutputSchema == nil && len(agent.Tools) == 0 && len(agent.Handoffs) == 0 && len(req.Tools) == 0
+}
+
+// hasCallableTools reports whether the registry holds tools other than the completion tool
+func hasCallableTools(toolRegistry *ToolRegistry, completionTool string) bool {
+	for _, tool := range toolRegistry.GetTools() {
+		if tool.Name() != completionTool {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,21 @@
+// Package agent runs AI agents calling tools through completion models.
+//
+// # Compatibility
+//
+// The v1 API follows semantic versioning: it only changes in backward-compatible ways until
+// the next major version. It consists of
+//
+//   - Runner and StreamRunner, NewRunner, NewStreamRunner and the JSON and XML runner constructors
+//   - RunnerOption and WithSystemPrompt, WithMaxMessageHistory, WithLogger and WithEventBufferSize
+//   - ModelTool, ToolRegistry, Callback and Logger
+//   - Agent, AgentRequest, AgentResponse, AgentStreamResponse and AgentEvent
+//   - the ErrToolNotFound, ErrToolExecution, ErrInvalidInput, ErrMaxIterations and
+//     ErrInvalidConfiguration errors
+//
+// The other exported identifiers of this package, such as degradation, shadow runs, experiments,
+// run caching, quotas and scheduling, are not covered yet: they may change in minor versions,
+// with the change noted in the release notes, and join the v1 API once their design is settled.
+// The least settled are published in the x subpackage, e.g. speculative prefetch and consensus
+// runners, and may be removed in minor versions. Their names in this package are deprecated
+// wrappers kept for existing users until the next major version.
+package agent
@@ -0,0 +1,51 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+)
+
+// DryRunTool is implemented by tools able to simulate their calls, e.g. by validating the input and
+// describing what they would do. Dry runs call DryRun instead of Run for tools that are not read-only.
+type DryRunTool interface {
+	ModelTool
+
+	// DryRun returns the output the call would have, without side effects
+	DryRun(ctx context.Context, input map[string]any) (any, error)
+}
+
+// DryRunOutput is the output of the simulated calls of tools not implementing DryRunTool
+type DryRunOutput struct {
+	DryRun  bool   `json:"dry_run"`
+	Message string `json:"message"`
+}
+
+// simulates reports whether the calls of tool are simulated: in dry runs, those of tools that are not
+// read-only, except the completion, handoff and transaction tools driving the loop
+func (r *BaseRunner) simulates(run *agentRun, tool ModelTool) bool {
+	if !run.req.DryRun {
+		return false
+	}
+	name := tool.Name()
+	if name == r.completionTool.Name || name == HandoffToolName || isTransactionTool(name) {
+		return false
+	}
+	return ToolEffectOf(tool, run.toolRegistry.GetToolOptions(name)) != ToolEffectReadOnly
+}
+
+// simulateTool simulates a call of tool
+func simulateTool(ctx context.Context, tool ModelTool, input map[string]any) toolOutcome {
+	output, err := dryRun(ctx, tool, input)
+	return toolOutcome{output: output, err: err, simulated: true}
+}
+
+// dryRun returns the simulated output of a call of tool, a DryRunOutput if it cannot simulate it
+func dryRun(ctx context.Context, tool ModelTool, input map[string]any) (any, error) {
+	if t, ok := tool.(DryRunTool); ok {
+		return t.DryRun(ctx, input)
+	}
+	return &DryRunOutput{
+		DryRun:  true,
+		Message: fmt.Sprintf("%s was not run because this is a dry run, continue as if it succeeded", tool.Name()),
+	}, nil
+}
@@ -0,0 +1,134 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Environment describes the situation of a run to the model: the current time, the locale of
+// the user and facts such as their profile or the state of the application
+type Environment struct {
+	// Time is the current time, shown in Location
+	Time     time.Time
+	Location *time.Location
+
+	// Locale is the locale of the user, e.g. "fr-FR", omitted when empty
+	Locale string
+
+	// KnowledgeCutoff is the training cutoff of the model, e.g. "2024-06", omitted when empty
+	KnowledgeCutoff string
+
+	// Facts are shown as "name: value" lines, sorted by name
+	Facts map[string]string
+}
+
+// EnvironmentProvider completes the environment of a run, e.g. with the profile of the user
+// found from MetadataOf(ctx), or a time zone and locale overriding the runner's defaults
+type EnvironmentProvider interface {
+	Describe(ctx context.Context, env *Environment) error
+}
+
+// EnvironmentProviderFunc is a function implementing EnvironmentProvider
+type EnvironmentProviderFunc func(ctx context.Context, env *Environment) error
+
+var _ EnvironmentProvider = EnvironmentProviderFunc(nil)
+
+// Describe calls f(ctx, env)
+func (f EnvironmentProviderFunc) Describe(ctx context.Context, env *Environment) error {
+	return f(ctx, env)
+}
+
+// EnvironmentConfig configures the environment section of the system prompt
+type EnvironmentConfig struct {
+	// Location is the default time zone, UTC if nil
+	Location *time.Location
+
+	// Locale is the default locale of users, overridden by AgentRequest.Locale
+	Locale string
+
+	// KnowledgeCutoff is the training cutoff of the models, so they know when to rely on tools
+	KnowledgeCutoff string
+
+	// Facts are static facts, e.g. the name and version of the application
+	Facts map[string]string
+
+	// Providers complete the environment of each run, in order
+	Providers []EnvironmentProvider
+
+	// Clock returns the current time, time.Now if nil
+	Clock func() time.Time
+}
+
+// WithEnvironment appends an environment section to the system prompt with the current date and
+// time, time zone, locale and the facts of config, so agents don't hand-roll "today is" instructions.
+// The section is built once per run and left out of RunVersion.Prompt, which stays stable.
+func WithEnvironment(config EnvironmentConfig) RunnerOption {
+	return func(c *runnerConfig) {
+		c.environment = &config
+	}
+}
+
+// describeEnvironment builds the environment of a run. Failing providers are logged and skipped,
+// so an unavailable profile service does not fail runs.
+func (r *BaseRunner) describeEnvironment(ctx context.Context, run *agentRun) *Environment {
+	config := r.environment
+	now := time.Now
+	if config.Clock != nil {
+		now = config.Clock
+	}
+	locale := config.Locale
+	if run.req.Locale != "" {
+		locale = run.req.Locale
+	}
+	env := &Environment{
+		Time:            now(),
+		Location:        config.Location,
+		Locale:          locale,
+		KnowledgeCutoff: config.KnowledgeCutoff,
+		Facts:           maps.Clone(config.Facts),
+	}
+	if env.Facts == nil {
+		env.Facts = make(map[string]string)
+	}
+	for _, provider := range config.Providers {
+		if err := provider.Describe(ctx, env); err != nil {
+			r.logger.Warn("environment provider failed",
+				"agent", run.agent.Name,
+				"runId", run.agentContext.RunID,
+				"error", err)
+		}
+	}
+	return env
+}
+
+// Prompt renders the environment as a system prompt section
+func (e *Environment) Prompt() string {
+	location := e.Location
+	if location == nil {
+		location = time.UTC
+	}
+	now := e.Time.In(location)
+
+	var b strings.Builder
+	b.WriteString("\n\n<environment>\n")
+	fmt.Fprintf(&b, "    Current date: %s\n", now.Format("Monday, 2 January 2006"))
+	fmt.Fprintf(&b, "    Current time: %s (%s, UTC%s)\n", now.Format("15:04"), location.String(), now.Format("-07:00"))
+	if e.Locale != "" {
+		fmt.Fprintf(&b, "    Locale: %s\n", e.Locale)
+	}
+	if e.KnowledgeCutoff != "" {
+		fmt.Fprintf(&b, "    Knowledge cutoff: %s, later events are only known from tools and messages\n", e.KnowledgeCutoff)
+	}
+	for _, name := range slices.Sorted(maps.Keys(e.Facts)) {
+		value := strings.Join(strings.Fields(e.Facts[name]), " ")
+		if value != "" {
+			fmt.Fprintf(&b, "    %s: %s\n", name, value)
+		}
+	}
+	b.WriteString("</environment>")
+	return b.String()
+}
@@ -21,9 +21,48 @@ var (
 	// ErrMaxIterations is returned when max iterations is reached without completion
 	ErrMaxIterations = errors.New("max iterations reached")
 
+	// ErrLoopStopped is returned when a LoopController stops the run before completion
+	ErrLoopStopped = errors.New("loop stopped")
+
 	// ErrInvalidConfiguration is returned when agent or request configuration is invalid
 	ErrInvalidConfiguration = errors.New("invalid configuration")
 
 	// ErrToolAlreadyRegistered is returned when attempting to register a duplicate tool
 	ErrToolAlreadyRegistered = errors.New("tool already registered")
+
+	// ErrVersionConflict is returned when an optimistic SharedState write loses a race
+	ErrVersionConflict = errors.New("version conflict")
+
+	// ErrCredentialNotFound is returned when no credential is available for a service
+	ErrCredentialNotFound = errors.New("credential not found")
+
+	// ErrUnauthorized is returned by tools when an API rejects their credentials
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrNoRoute is returned when a router has no runner for a classification label
+	ErrNoRoute = errors.New("no route for label")
+
+	// ErrInvalidWebhookSignature is returned when a webhook delivery is not signed with the expected secret
+	ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+	// ErrQuotaExceeded is matched by QuotaExceededError when a run would exceed a tenant quota
+	ErrQuotaExceeded = errors.New("quota exceeded")
+
+	// ErrCircuitOpen is matched by CircuitOpenError when a circuit breaker rejects a call
+	ErrCircuitOpen = errors.New("circuit open")
+
+	// ErrSessionNotFound is returned when a conversation session does not exist
+	ErrSessionNotFound = errors.New("session not found")
+
+	// ErrArtifactNotFound is returned when an artifact does not exist
+	ErrArtifactNotFound = errors.New("artifact not found")
+
+	// ErrUnknownModelPrice is returned when a model is missing from the pricing catalog under UnknownModelFail
+	ErrUnknownModelPrice = errors.New("unknown model price")
+
+	// ErrUnknownModel is returned when a model reference matches no provider or model of the ModelRegistry
+	ErrUnknownModel = errors.New("unknown model")
+
+	// ErrUnhealthy is returned by health checks when the model or a tool is unhealthy
+	ErrUnhealthy = errors.New("unhealthy")
 )
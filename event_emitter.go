@@ -0,0 +1,214 @@
+package agent
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultEventBufferSize is the default capacity of the event channel of streaming runs,
+// and the queue size above which backpressure policies apply
+const DefaultEventBufferSize = 100
+
+// BackpressurePolicy controls what a streaming run does when its consumer falls behind
+type BackpressurePolicy int
+
+const (
+	// BackpressureBlock blocks the agent loop until the consumer catches up. This is the default.
+	BackpressureBlock BackpressurePolicy = iota
+
+	// BackpressureDropOldestPartial drops the oldest queued partial tool call event when the queue
+	// is full. Partial tool calls are snapshots superseded by later ones, so no information is lost
+	// for consumers reading the final events. Other events are never dropped, and the loop blocks
+	// when the queue only holds them.
+	BackpressureDropOldestPartial
+
+	// BackpressureUnbounded queues events without limit so the loop never blocks,
+	// logging a warning once the queue grows past the buffer size
+	BackpressureUnbounded
+)
+
+// eventEmitter sends events of a streaming run to its consumer.
+// A nil emitter discards all events, which is how non-streaming runs use the shared loop.
+type eventEmitter struct {
+	ch       chan<- AgentEvent
+	agent    string
+	runID    string
+	version  *RunVersion
+	metadata map[string]string
+	sinks    []StreamEventSink
+
+	policy     BackpressurePolicy
+	bufferSize int
+	logger     Logger
+
+	// dropped counts the events discarded by the backpressure policy
+	dropped atomic.Int64
+
+	// queue buffers events for the forwarder when the policy is not BackpressureBlock
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   []AgentEvent
+	closed  bool
+	warned  bool
+	drained chan struct{}
+}
+
+// newEventEmitter creates an emitter writing to ch and sinks, applying policy when the consumer falls behind
+// by more than bufferSize events. The emitter must be closed, which closes ch once the queued events are delivered.
+func newEventEmitter(ch chan<- AgentEvent, agent string, metadata map[string]string, sinks []StreamEventSink, policy BackpressurePolicy, bufferSize int, logger Logger) *eventEmitter {
+	e := &eventEmitter{
+		ch:         ch,
+		agent:      agent,
+		metadata:   metadata,
+		sinks:      sinks,
+		policy:     policy,
+		bufferSize: bufferSize,
+		logger:     logger,
+	}
+	if policy != BackpressureBlock {
+		e.cond = sync.NewCond(&e.mu)
+		e.drained = make(chan struct{})
+		go e.forward()
+	}
+	return e
+}
+
+// setAgent changes the active agent stamped on subsequent events
+func (e *eventEmitter) setAgent(agent string) {
+	if e == nil {
+		return
+	}
+	e.agent = agent
+}
+
+// setRunID sets the run ID stamped on subsequent events
+func (e *eventEmitter) setRunID(runID string) {
+	if e == nil {
+		return
+	}
+	e.runID = runID
+}
+
+// setVersion sets the run version stamped on subsequent events
+func (e *eventEmitter) setVersion(version *RunVersion) {
+	if e == nil {
+		return
+	}
+	e.version = version
+}
+
+// emit stamps the event with the active agent, the run ID and version and the request metadata and sends it
+func (e *eventEmitter) emit(event AgentEvent) {
+	if e == nil {
+		return
+	}
+	if event.Agent == "" {
+		event.Agent = e.agent
+	}
+	event.RunID = e.runID
+	if event.Version == nil {
+		event.Version = e.version
+	}
+	if event.Metadata == nil {
+		event.Metadata = e.metadata
+	}
+	for _, sink := range e.sinks {
+		sink.SendEvent(&event)
+	}
+	if e.cond == nil {
+		e.ch <- event
+		return
+	}
+	e.enqueue(event)
+}
+
+// enqueue adds an event to the queue, applying the backpressure policy when it is full
+func (e *eventEmitter) enqueue(event AgentEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	switch e.policy {
+	case BackpressureDropOldestPartial:
+		for len(e.queue) >= e.bufferSize {
+			if i := oldestDroppable(e.queue); i >= 0 {
+				e.queue = append(e.queue[:i], e.queue[i+1:]...)
+				e.dropped.Add(1)
+				break
+			}
+			if isDroppable(event) {
+				e.dropped.Add(1)
+				return
+			}
+			e.cond.Wait()
+		}
+	case BackpressureUnbounded:
+		if len(e.queue) >= e.bufferSize && !e.warned {
+			e.warned = true
+			e.logger.Warn("event consumer is falling behind, queueing events without limit",
+				"agent", e.agent,
+				"queued", len(e.queue))
+		}
+	}
+	e.queue = append(e.queue, event)
+	e.cond.Broadcast()
+}
+
+// forward sends queued events to the channel until the emitter is closed and the queue drained
+func (e *eventEmitter) forward() {
+	defer close(e.drained)
+	for {
+		e.mu.Lock()
+		for len(e.queue) == 0 && !e.closed {
+			e.cond.Wait()
+		}
+		if len(e.queue) == 0 {
+			e.mu.Unlock()
+			return
+		}
+		event := e.queue[0]
+		e.queue[0] = AgentEvent{}
+		e.queue = e.queue[1:]
+		e.cond.Broadcast()
+		e.mu.Unlock()
+
+		e.ch <- event
+	}
+}
+
+// close delivers the queued events and closes the channel
+func (e *eventEmitter) close() {
+	if e.cond != nil {
+		e.mu.Lock()
+		e.closed = true
+		e.cond.Broadcast()
+		e.mu.Unlock()
+		<-e.drained
+	}
+	if n := e.dropped.Load(); n > 0 {
+		e.logger.Warn("dropped partial events of a slow consumer", "agent", e.agent, "dropped", n)
+	}
+	close(e.ch)
+}
+
+// isDroppable reports whether an event is a partial tool call snapshot superseded by later events
+func isDroppable(event AgentEvent) bool {
+	return event.Partial && event.Type == AgentEventTypeUseTool
+}
+
+// oldestDroppable returns the index of the oldest droppable event in queue, or -1
+func oldestDroppable(queue []AgentEvent) int {
+	for i, event := range queue {
+		if isDroppable(event) {
+			return i
+		}
+	}
+	return -1
+}
+
+// emitError sends an error event
+func (e *eventEmitter) emitError(message string) {
+	e.emit(AgentEvent{
+		Type:         AgentEventTypeError,
+		ErrorMessage: &message,
+	})
+}
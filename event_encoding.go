@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// EventVersion is the version of the AgentEvent encoding, sent as "v". Decoders accept events
+// of this version and older ones, events without a version being version 1.
+// Consumers should skip event types they do not know, new types are added without a new version.
+const EventVersion = 1
+
+// eventFields are the AgentEvent fields with their JSON names. They are kept identical to
+// AgentEvent, so a field added there does not compile until it is given a name here.
+type eventFields struct {
+	Type           AgentEventType    `json:"type"`
+	Agent          string            `json:"agent,omitempty"`
+	RunID          string            `json:"runId,omitempty"`
+	Version        *RunVersion       `json:"version,omitempty"`
+	Text           *string           `json:"text,omitempty"`
+	Reasoning      *string           `json:"reasoning,omitempty"`
+	ErrorMessage   *string           `json:"errorMessage,omitempty"`
+	ToolCall       *llm.ToolCall     `json:"toolCall,omitempty"`
+	Handoff        *Handoff          `json:"handoff,omitempty"`
+	OutputDelta    *OutputDelta      `json:"outputDelta,omitempty"`
+	ToolInputDelta *ToolInputDelta   `json:"toolInputDelta,omitempty"`
+	Output         any               `json:"output,omitempty"`
+	PromptStats    *PromptStats      `json:"promptStats,omitempty"`
+	Usage          *UsageReport      `json:"usage,omitempty"`
+	Cancellation   *Cancellation     `json:"cancellation,omitempty"`
+	Validation     *ValidationError  `json:"validation,omitempty"`
+	Partial        bool              `json:"partial,omitempty"`
+	Ephemeral      bool              `json:"ephemeral,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+}
+
+// eventJSON is the JSON encoding of AgentEvent
+type eventJSON struct {
+	Version int `json:"v"`
+	eventFields
+}
+
+// MarshalJSON encodes the event as a JSON object with its version, its type as discriminator
+// and the fields of the type, matching the JSON mapping of proto/agent_event.proto
+func (e AgentEvent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(eventJSON{Version: EventVersion, eventFields: eventFields(e)})
+}
+
+// UnmarshalJSON decodes an event encoded by MarshalJSON. Outputs and tool results are decoded
+// as generic JSON values. Events stored before the encoding was versioned are accepted.
+func (e *AgentEvent) UnmarshalJSON(data []byte) error {
+	var event eventJSON
+	if err := json.Unmarshal(data, &event); err != nil {
+		return err
+	}
+	if event.Version > EventVersion {
+		return fmt.Errorf("unsupported event version %d, expected at most %d", event.Version, EventVersion)
+	}
+	if event.Type == "" {
+		return errors.New("event type is required")
+	}
+	*e = AgentEvent(event.eventFields)
+	return nil
+}
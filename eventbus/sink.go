@@ -0,0 +1,252 @@
+// Package eventbus publishes agent events to message buses such as Kafka, NATS or Google Pub/Sub,
+// for analytics pipelines and fan-out of agent activity to other services.
+//
+// Buses are reached through the small Publisher interface, so this package depends on no client
+// library. Messages are keyed by run ID: used as the partition key on Kafka or the ordering key on
+// Pub/Sub, it keeps the events of a run in order.
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/easyagent-dev/agent"
+)
+
+// Default topics of a sink
+const (
+	DefaultTopic          = "agent.events"
+	DefaultLifecycleTopic = "agent.lifecycle"
+)
+
+// DefaultQueueSize is the number of messages a sink buffers before dropping new ones
+const DefaultQueueSize = 4096
+
+// Headers of published messages
+const (
+	HeaderEventType = "agent-event-type"
+	HeaderVersion   = "agent-event-version"
+	HeaderRunID     = "agent-run-id"
+	HeaderAgent     = "agent-name"
+)
+
+// Message is an event serialized for a message bus
+type Message struct {
+	// Topic is the topic, subject or stream the message is published to
+	Topic string
+
+	// Key is the run ID of the event, the partition or ordering key of the message
+	Key string
+
+	// Value is the JSON encoding of the event
+	Value []byte
+
+	// Headers describe the event, so consumers can route messages without decoding them
+	Headers map[string]string
+}
+
+// Publisher publishes messages to a bus, usually as a small adapter of the bus client
+type Publisher interface {
+	Publish(ctx context.Context, msg *Message) error
+}
+
+// PublisherFunc adapts a function to the Publisher interface
+type PublisherFunc func(ctx context.Context, msg *Message) error
+
+// Publish calls the function
+func (f PublisherFunc) Publish(ctx context.Context, msg *Message) error {
+	return f(ctx, msg)
+}
+
+// Sink publishes the stream events and lifecycle events of runs from a background goroutine.
+// Ephemeral events are skipped unless enabled, as bus consumers usually persist events.
+// Messages are dropped with a warning when the queue is full.
+type Sink struct {
+	publisher      Publisher
+	topic          string
+	lifecycleTopic string
+	types          map[agent.AgentEventType]bool
+	ephemeral      bool
+	timeout        time.Duration
+	logger         agent.Logger
+
+	// mu guards closed, so messages are never sent to the closed queue
+	mu     sync.RWMutex
+	closed bool
+	queue  chan *Message
+	done   chan struct{}
+}
+
+var (
+	_ agent.StreamEventSink = (*Sink)(nil)
+	_ agent.EventSink       = (*Sink)(nil)
+)
+
+// Option is a functional option for configuring sinks
+type Option func(*Sink)
+
+// WithTopic sets the topic of stream events, DefaultTopic by default
+func WithTopic(topic string) Option {
+	return func(s *Sink) {
+		s.topic = topic
+	}
+}
+
+// WithLifecycleTopic sets the topic of lifecycle events, DefaultLifecycleTopic by default
+func WithLifecycleTopic(topic string) Option {
+	return func(s *Sink) {
+		s.lifecycleTopic = topic
+	}
+}
+
+// WithEventTypes restricts the published stream events to types, all types by default
+func WithEventTypes(types ...agent.AgentEventType) Option {
+	return func(s *Sink) {
+		s.types = make(map[agent.AgentEventType]bool, len(types))
+		for _, t := range types {
+			s.types[t] = true
+		}
+	}
+}
+
+// WithEphemeral sets whether ephemeral events are published, false by default
+func WithEphemeral(enabled bool) Option {
+	return func(s *Sink) {
+		s.ephemeral = enabled
+	}
+}
+
+// WithPublishTimeout bounds every publish call, 10 seconds by default
+func WithPublishTimeout(timeout time.Duration) Option {
+	return func(s *Sink) {
+		s.timeout = timeout
+	}
+}
+
+// WithQueueSize sets the number of buffered messages, DefaultQueueSize by default
+func WithQueueSize(size int) Option {
+	return func(s *Sink) {
+		s.queue = make(chan *Message, size)
+	}
+}
+
+// WithLogger sets the logger receiving failed and dropped messages
+func WithLogger(logger agent.Logger) Option {
+	return func(s *Sink) {
+		s.logger = logger
+	}
+}
+
+// NewSink creates a sink publishing with publisher and starts its publishing goroutine.
+// Register it with agent.WithStreamEventSink and agent.WithEventSink, and call Close to publish
+// the queued messages and stop it.
+func NewSink(publisher Publisher, opts ...Option) *Sink {
+	sink := &Sink{
+		publisher:      publisher,
+		topic:          DefaultTopic,
+		lifecycleTopic: DefaultLifecycleTopic,
+		timeout:        10 * time.Second,
+		logger:         agent.NoOpLogger{},
+		queue:          make(chan *Message, DefaultQueueSize),
+		done:           make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(sink)
+	}
+	go sink.publishAll()
+	return sink
+}
+
+// SendEvent queues a stream event without blocking
+func (s *Sink) SendEvent(event *agent.AgentEvent) {
+	if (s.types != nil && !s.types[event.Type]) || (event.Ephemeral && !s.ephemeral) {
+		return
+	}
+	value, err := json.Marshal(event)
+	if err != nil {
+		s.logger.Error("failed to marshal agent event", "event", string(event.Type), "runId", event.RunID, "error", err)
+		return
+	}
+	s.enqueue(&Message{
+		Topic: s.topic,
+		Key:   event.RunID,
+		Value: value,
+		Headers: map[string]string{
+			HeaderEventType: string(event.Type),
+			HeaderVersion:   strconv.Itoa(agent.EventVersion),
+			HeaderRunID:     event.RunID,
+			HeaderAgent:     event.Agent,
+		},
+	})
+}
+
+// Send queues a lifecycle event without blocking
+func (s *Sink) Send(ctx context.Context, event *agent.LifecycleEvent) {
+	value, err := json.Marshal(event)
+	if err != nil {
+		s.logger.Error("failed to marshal lifecycle event", "event", string(event.Type), "runId", event.RunID, "error", err)
+		return
+	}
+	s.enqueue(&Message{
+		Topic: s.lifecycleTopic,
+		Key:   event.RunID,
+		Value: value,
+		Headers: map[string]string{
+			HeaderEventType: string(event.Type),
+			HeaderRunID:     event.RunID,
+			HeaderAgent:     event.Agent,
+		},
+	})
+}
+
+func (s *Sink) enqueue(msg *Message) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.queue <- msg:
+	default:
+		s.logger.Warn("event bus queue full, dropping message", "topic", msg.Topic, "event", msg.Headers[HeaderEventType], "runId", msg.Key)
+	}
+}
+
+// Close stops accepting events and waits until the queued messages are published or ctx is done
+func (s *Sink) Close(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// publishAll publishes queued messages one at a time, preserving their order
+func (s *Sink) publishAll() {
+	defer close(s.done)
+	for msg := range s.queue {
+		if err := s.publish(msg); err != nil {
+			s.logger.Error("failed to publish event", "topic", msg.Topic, "event", msg.Headers[HeaderEventType], "runId", msg.Key, "error", err)
+		}
+	}
+}
+
+func (s *Sink) publish(msg *Message) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	if err := s.publisher.Publish(ctx, msg); err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", msg.Topic, err)
+	}
+	return nil
+}
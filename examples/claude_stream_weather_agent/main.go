@@ -71,7 +71,7 @@ func main() {
 
 	// Process streaming events
 	fmt.Printf("\n=== Streaming Agent Events ===\n")
-	for event := range *streamResp {
+	for event := range streamResp.Events() {
 		switch event.Type {
 		case agent.AgentEventTypeReasoning:
 			if event.Reasoning != nil {
@@ -100,5 +100,10 @@ func main() {
 		}
 	}
 
-	fmt.Printf("\n=== Streaming Complete ===\n")
+	resp, err := streamResp.Wait()
+	if err != nil {
+		log.Fatalf("Agent run failed: %v", err)
+	}
+	output, _ := json.MarshalIndent(resp.Output, "", "  ")
+	fmt.Printf("\n=== Streaming Complete ===\n%s\n", output)
 }
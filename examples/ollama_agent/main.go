@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/easyagent-dev/agent"
+	"github.com/easyagent-dev/agent/examples"
+	"github.com/easyagent-dev/agent/openaicompat"
+	"github.com/easyagent-dev/llm"
+)
+
+func main() {
+	// Ollama serves an OpenAI-compatible API, vLLM listens on http://localhost:8000/v1
+	baseURL := os.Getenv("OLLAMA_BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:11434/v1"
+	}
+	modelName := os.Getenv("OLLAMA_MODEL")
+	if modelName == "" {
+		modelName = "llama3.1:8b"
+	}
+
+	// Create a weather tool
+	weatherTool := examples.NewWeatherTool()
+
+	// Create an agent with the weather tool
+	agentInstance := &agent.Agent{
+		Name:          "Weather Assistant",
+		Description:   "An AI assistant that can provide weather information",
+		Instructions:  "You are a helpful assistant that provides weather information for any location requested by the user.",
+		Tools:         []agent.ModelTool{weatherTool},
+		Model:         modelName,
+		ModelProvider: "ollama",
+	}
+
+	// The OpenAI-compatible provider accepts any model name the server serves
+	provider, err := openaicompat.NewProvider(llm.WithBaseURL(baseURL))
+	if err != nil {
+		log.Fatalf("Failed to create provider: %v", err)
+	}
+
+	model, err := provider.NewCompletionModel(modelName, llm.WithUsage(true), llm.WithMaxTokens(1000))
+	if err != nil {
+		log.Fatalf("Failed to create model: %v", err)
+	}
+
+	// Parse loosely written tool calls, use the compact prompt and price calls at 0
+	runner, err := agent.NewJSONCompletionRunner(agentInstance, model, agent.WithLocalModel())
+	if err != nil {
+		log.Fatalf("Failed to create runner: %v", err)
+	}
+
+	// Create an agent request
+	req := &agent.AgentRequest{
+		Messages: []*llm.ModelMessage{
+			{
+				Role:    llm.RoleUser,
+				Content: "What's the weather like in Tokyo?",
+			},
+		},
+		OutputSchema:  llm.GenerateSchema[examples.Reply](),
+		MaxIterations: 10,
+		MaxRetries:    3,
+	}
+
+	// Run the agent
+	ctx := context.Background()
+	resp, err := runner.Run(ctx, req, agent.NewDefaultCallback(true))
+	if err != nil {
+		log.Fatalf("Failed to run agent: %v", err)
+	}
+
+	// Print the response
+	fmt.Printf("\n=== Agent Response ===\n")
+	output, _ := json.MarshalIndent(resp.Output, "", "  ")
+	fmt.Printf("Output: %s\n", string(output))
+	fmt.Printf("Token Usage: %+v\n", resp.Usage)
+}
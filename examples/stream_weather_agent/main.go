@@ -69,7 +69,7 @@ func main() {
 
 	// Process streaming events
 	fmt.Printf("\n=== Streaming Agent Events ===\n")
-	for event := range *streamResp {
+	for event := range streamResp.Events() {
 		switch event.Type {
 		case agent.AgentEventTypeUseTool:
 			if event.Partial {
@@ -94,5 +94,10 @@ func main() {
 		}
 	}
 
-	fmt.Printf("\n=== Streaming Complete ===\n")
+	resp, err := streamResp.Wait()
+	if err != nil {
+		log.Fatalf("Agent run failed: %v", err)
+	}
+	output, _ := json.MarshalIndent(resp.Output, "", "  ")
+	fmt.Printf("\n=== Streaming Complete ===\n%s\n", output)
 }
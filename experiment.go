@@ -0,0 +1,302 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// ControlVariant is the name of the variant running the requests not routed to other variants
+const ControlVariant = "control"
+
+// Metadata keys tagging the requests run by experiments, so sinks and stores can split their records
+const (
+	ExperimentMetadataKey = "experiment"
+	VariantMetadataKey    = "experiment_variant"
+)
+
+// Variant is a configuration compared to the control of an experiment, e.g. a runner with a
+// different prompt, model or tool set
+type Variant struct {
+	// Name identifies the variant in responses and stats
+	Name string
+
+	// Percent is the share of runs routed to the variant, from 0 to 100
+	Percent float64
+
+	// Runner runs the requests routed to the variant
+	Runner Runner
+}
+
+// VariantStats are the comparative metrics of a variant
+type VariantStats struct {
+	Variant string  `json:"variant"`
+	Percent float64 `json:"percent"`
+
+	// Runs counts the runs of the variant, Failed those returning an error and Partial those
+	// with a salvaged output
+	Runs    int `json:"runs"`
+	Failed  int `json:"failed"`
+	Partial int `json:"partial"`
+
+	// Usage, Cost, Duration and ToolCalls are the totals of the runs
+	Usage     llm.TokenUsage `json:"usage"`
+	Cost      float64        `json:"cost"`
+	Duration  time.Duration  `json:"duration"`
+	ToolCalls int            `json:"toolCalls"`
+
+	// Scored counts the responses with a confidence, Confidence is the sum of their scores
+	Scored     int     `json:"scored"`
+	Confidence float64 `json:"confidence"`
+}
+
+// FailureRate returns the share of runs that failed, between 0 and 1
+func (s *VariantStats) FailureRate() float64 {
+	return ratio(float64(s.Failed), s.Runs)
+}
+
+// MeanCost returns the mean cost of a run in USD
+func (s *VariantStats) MeanCost() float64 {
+	return ratio(s.Cost, s.Runs)
+}
+
+// MeanDuration returns the mean duration of a run
+func (s *VariantStats) MeanDuration() time.Duration {
+	return time.Duration(ratio(float64(s.Duration), s.Runs))
+}
+
+// MeanToolCalls returns the mean number of tool calls of a run
+func (s *VariantStats) MeanToolCalls() float64 {
+	return ratio(float64(s.ToolCalls), s.Runs)
+}
+
+// MeanConfidence returns the mean confidence of the scored responses
+func (s *VariantStats) MeanConfidence() float64 {
+	return ratio(s.Confidence, s.Scored)
+}
+
+// ratio returns sum divided by count, 0 when count is 0
+func ratio(sum float64, count int) float64 {
+	if count == 0 {
+		return 0
+	}
+	return sum / float64(count)
+}
+
+// ExperimentOption is a functional option for configuring experiments
+type ExperimentOption func(*Experiment)
+
+// WithExperimentKey assigns variants by hashing the request metadata value of key, e.g. "user_id",
+// so every request of a user runs the same variant. By default requests are assigned by run ID,
+// or at random when they have none.
+func WithExperimentKey(key string) ExperimentOption {
+	return func(e *Experiment) {
+		e.key = key
+	}
+}
+
+// Experiment routes a share of runs to variant runners and the others to the control runner,
+// tags the responses with their variant and aggregates comparative metrics per variant, for
+// A/B tests and controlled rollouts of agent changes.
+// This type is safe for concurrent use.
+type Experiment struct {
+	name     string
+	key      string
+	mu       sync.Mutex
+	variants []Variant
+	stats    map[string]*VariantStats
+}
+
+var _ Runner = (*Experiment)(nil)
+
+// NewExperiment creates an experiment comparing variants to control.
+// The control variant runs the share of runs not routed to other variants.
+func NewExperiment(name string, control Runner, variants []Variant, opts ...ExperimentOption) (*Experiment, error) {
+	if name == "" {
+		return nil, errors.New("experiment name is required")
+	}
+	if control == nil {
+		return nil, errors.New("control runner is required")
+	}
+	e := &Experiment{
+		name:     name,
+		variants: []Variant{{Name: ControlVariant, Runner: control}},
+		stats:    map[string]*VariantStats{ControlVariant: {Variant: ControlVariant}},
+	}
+	total := 0.0
+	for _, variant := range variants {
+		if variant.Name == "" {
+			return nil, errors.New("variant name is required")
+		}
+		if _, ok := e.stats[variant.Name]; ok {
+			return nil, fmt.Errorf("duplicate variant '%s'", variant.Name)
+		}
+		if variant.Runner == nil {
+			return nil, fmt.Errorf("variant '%s' has no runner", variant.Name)
+		}
+		if variant.Percent < 0 {
+			return nil, fmt.Errorf("variant '%s' has a negative percent", variant.Name)
+		}
+		total += variant.Percent
+		e.variants = append(e.variants, variant)
+		e.stats[variant.Name] = &VariantStats{Variant: variant.Name}
+	}
+	if total > 100 {
+		return nil, fmt.Errorf("variants get %g%% of the runs, more than 100%%", total)
+	}
+	e.variants[0].Percent = 100 - total
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e, nil
+}
+
+// Name returns the name of the experiment
+func (e *Experiment) Name() string {
+	return e.name
+}
+
+// SetPercent changes the share of runs routed to a variant, e.g. to ramp up a rollout.
+// The control variant gets the remaining share.
+func (e *Experiment) SetPercent(variant string, percent float64) error {
+	if variant == ControlVariant {
+		return errors.New("the control variant gets the remaining share of runs")
+	}
+	if percent < 0 {
+		return fmt.Errorf("variant '%s' has a negative percent", variant)
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	index := -1
+	total := percent
+	for i, v := range e.variants[1:] {
+		if v.Name == variant {
+			index = i + 1
+		} else {
+			total += v.Percent
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("unknown variant '%s'", variant)
+	}
+	if total > 100 {
+		return fmt.Errorf("variants get %g%% of the runs, more than 100%%", total)
+	}
+	e.variants[index].Percent = percent
+	e.variants[0].Percent = 100 - total
+	return nil
+}
+
+// Assign returns the variant running req
+func (e *Experiment) Assign(req *AgentRequest) Variant {
+	point := rand.Float64() * 100
+	if key := e.assignmentKey(req); key != "" {
+		h := fnv.New64a()
+		h.Write([]byte(e.name + "/" + key))
+		point = float64(h.Sum64()%10000) / 100
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	// Variants take consecutive shares from 0 in their order, control the rest
+	cumulative := 0.0
+	for _, variant := range e.variants[1:] {
+		cumulative += variant.Percent
+		if point < cumulative {
+			return variant
+		}
+	}
+	return e.variants[0]
+}
+
+// assignmentKey returns the value req is assigned by, empty for a random assignment
+func (e *Experiment) assignmentKey(req *AgentRequest) string {
+	if e.key != "" {
+		if value := req.Metadata[e.key]; value != "" {
+			return value
+		}
+	}
+	return req.RunID
+}
+
+// Run runs the request with its variant, tagging the request metadata and the response with it
+func (e *Experiment) Run(ctx context.Context, req *AgentRequest, callback Callback) (*AgentResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	variant := e.Assign(req)
+
+	tagged := *req
+	tagged.Metadata = maps.Clone(req.Metadata)
+	if tagged.Metadata == nil {
+		tagged.Metadata = make(map[string]string, 2)
+	}
+	tagged.Metadata[ExperimentMetadataKey] = e.name
+	tagged.Metadata[VariantMetadataKey] = variant.Name
+
+	start := time.Now()
+	resp, err := variant.Runner.Run(ctx, &tagged, callback)
+	e.record(variant.Name, resp, err, time.Since(start))
+	if resp != nil {
+		resp.Variant = variant.Name
+	}
+	return resp, err
+}
+
+// record adds a run to the stats of its variant
+func (e *Experiment) record(variant string, resp *AgentResponse, err error, duration time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	stats := e.stats[variant]
+	stats.Runs++
+	stats.Duration += duration
+	if err != nil {
+		stats.Failed++
+	}
+	if resp == nil {
+		return
+	}
+	if resp.Partial {
+		stats.Partial++
+	}
+	if resp.Usage != nil {
+		stats.Usage.Append(resp.Usage)
+	}
+	if resp.Cost != nil {
+		stats.Cost += *resp.Cost
+	}
+	stats.ToolCalls += len(resp.ToolCalls)
+	if resp.Confidence != nil {
+		stats.Scored++
+		stats.Confidence += *resp.Confidence
+	}
+}
+
+// Stats returns the metrics of every variant, control first
+func (e *Experiment) Stats() []*VariantStats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	stats := make([]*VariantStats, 0, len(e.variants))
+	for _, variant := range e.variants {
+		copied := *e.stats[variant.Name]
+		copied.Percent = variant.Percent
+		stats = append(stats, &copied)
+	}
+	return stats
+}
+
+// ResetStats clears the metrics of every variant, e.g. after changing a variant's configuration
+func (e *Experiment) ResetStats() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for name := range e.stats {
+		e.stats[name] = &VariantStats{Variant: name}
+	}
+}
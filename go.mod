@@ -5,17 +5,21 @@ go 1.24.4
 require github.com/easyagent-dev/llm v0.9.9
 
 require (
-	github.com/easyagent-dev/streamjson v0.9.3
 	github.com/easyagent-dev/streamxml v0.9.1
 	github.com/google/uuid v1.6.0
+	github.com/openai/openai-go/v3 v3.0.1
+	github.com/redis/go-redis/v9 v9.9.0
+	golang.org/x/net v0.47.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/bahlo/generic-list-go v0.2.0 // indirect
 	github.com/buger/jsonparser v1.1.1 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
 	github.com/invopop/jsonschema v0.13.0 // indirect
 	github.com/mailru/easyjson v0.7.7 // indirect
-	github.com/openai/openai-go/v3 v3.0.1 // indirect
 	github.com/replicate/replicate-go v0.26.0 // indirect
 	github.com/tidwall/gjson v1.18.0 // indirect
 	github.com/tidwall/match v1.2.0 // indirect
@@ -24,5 +28,4 @@ require (
 	github.com/vincent-petithory/dataurl v1.0.0 // indirect
 	github.com/wk8/go-ordered-map/v2 v2.1.8 // indirect
 	golang.org/x/sync v0.6.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
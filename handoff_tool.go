@@ -0,0 +1,101 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const HandoffToolName = "handoff"
+
+// Handoff is the result of a handoff tool call.
+// Runners switch the active agent to Agent and pass Note along with the carried history.
+type Handoff struct {
+	// Agent is the name of the agent taking over the conversation
+	Agent string `json:"agent"`
+
+	// Note is a message from the current agent briefing the next one
+	Note string `json:"note"`
+}
+
+// HandoffTool transfers the conversation to another agent.
+// It is registered automatically for agents with Handoffs configured.
+type HandoffTool struct {
+	targets []*Agent
+}
+
+var _ ModelTool = &HandoffTool{}
+
+// NewHandoffTool creates a handoff tool for the given target agents
+func NewHandoffTool(targets []*Agent) *HandoffTool {
+	return &HandoffTool{
+		targets: targets,
+	}
+}
+
+// Name returns the name of the tool
+func (t *HandoffTool) Name() string {
+	return HandoffToolName
+}
+
+// Description returns a description of what the tool does
+func (t *HandoffTool) Description() string {
+	var builder strings.Builder
+	builder.WriteString("Transfers the conversation to a better suited agent. Available agents:")
+	for _, target := range t.targets {
+		builder.WriteString("\n- ")
+		builder.WriteString(target.Name)
+		builder.WriteString(": ")
+		builder.WriteString(target.Description)
+	}
+	return builder.String()
+}
+
+// InputSchema returns the input schema listing the available target agents
+func (t *HandoffTool) InputSchema() any {
+	names := make([]string, 0, len(t.targets))
+	for _, target := range t.targets {
+		names = append(names, target.Name)
+	}
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"agent": map[string]any{
+				"type":        "string",
+				"enum":        names,
+				"description": "Name of the agent to transfer the conversation to",
+			},
+			"note": map[string]any{
+				"type":        "string",
+				"description": "What the next agent needs to know to continue the task",
+			},
+		},
+		"required":             []string{"agent", "note"},
+		"additionalProperties": false,
+	}
+}
+
+// OutputSchema returns the output schema of the tool
+func (t *HandoffTool) OutputSchema() any {
+	return nil
+}
+
+// Usage returns an example of how to use the tool
+func (t *HandoffTool) Usage() string {
+	if len(t.targets) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(`{"agent":"%s","note":"The user wants ..."}`, t.targets[0].Name)
+}
+
+// Run validates the target agent and returns a *Handoff
+func (t *HandoffTool) Run(ctx context.Context, input map[string]any) (any, error) {
+	name, _ := input["agent"].(string)
+	note, _ := input["note"].(string)
+	for _, target := range t.targets {
+		if target.Name == name {
+			return &Handoff{Agent: name, Note: note}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: unknown agent '%s'", ErrInvalidInput, name)
+}
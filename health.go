@@ -0,0 +1,175 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// DefaultHealthCacheTTL is how long runners reuse the result of a health check
+const DefaultHealthCacheTTL = 30 * time.Second
+
+// HealthChecker is implemented by tools and models able to check their backend cheaply,
+// e.g. by pinging an API without side effects
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// HealthCheckResult is the outcome of checking a model or tool
+type HealthCheckResult struct {
+	// Name is "model" for the model and the tool name for tools
+	Name      string        `json:"name"`
+	Healthy   bool          `json:"healthy"`
+	Error     string        `json:"error,omitempty"`
+	Latency   time.Duration `json:"latency"`
+	CheckedAt time.Time     `json:"checkedAt"`
+}
+
+// HealthReport is the outcome of checking the model and tools of a runner
+type HealthReport struct {
+	Healthy bool                `json:"healthy"`
+	Checks  []HealthCheckResult `json:"checks"`
+}
+
+// Err returns an error listing the failed checks, or nil if all passed
+func (h *HealthReport) Err() error {
+	var errs []error
+	for _, check := range h.Checks {
+		if !check.Healthy {
+			errs = append(errs, fmt.Errorf("%s: %s", check.Name, check.Error))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrUnhealthy, errors.Join(errs...))
+}
+
+// HealthyRunner is implemented by the runners of this package
+type HealthyRunner interface {
+	// Healthy returns nil if the model and tools are healthy, suitable for readiness probes
+	Healthy(ctx context.Context) error
+
+	// Health returns the result of every check
+	Health(ctx context.Context) *HealthReport
+}
+
+var (
+	_ HealthyRunner = (*JSONCompletionRunner)(nil)
+	_ HealthyRunner = (*JSONCompletionStreamRunner)(nil)
+	_ HealthyRunner = (*XMLCompletionRunner)(nil)
+	_ HealthyRunner = (*XMLCompletionStreamRunner)(nil)
+)
+
+// WithHealthCacheTTL sets how long health check results are reused, DefaultHealthCacheTTL by default.
+// Model checks without a HealthChecker make a small model call, so the cache keeps probes cheap.
+func WithHealthCacheTTL(ttl time.Duration) RunnerOption {
+	return func(c *runnerConfig) {
+		c.healthTTL = ttl
+	}
+}
+
+// healthCache holds the last health report of a runner
+type healthCache struct {
+	mu     sync.Mutex
+	report *HealthReport
+	at     time.Time
+}
+
+// Healthy returns nil if the model and the tools of the agent and its handoff targets are healthy
+func (r *BaseRunner) Healthy(ctx context.Context) error {
+	return r.Health(ctx).Err()
+}
+
+// Health checks the model and the tools implementing HealthChecker, reusing a recent report.
+// Concurrent calls share a single check.
+func (r *BaseRunner) Health(ctx context.Context) *HealthReport {
+	r.health.mu.Lock()
+	defer r.health.mu.Unlock()
+	if r.health.report != nil && time.Since(r.health.at) < r.healthTTL {
+		return r.health.report
+	}
+
+	report := &HealthReport{Healthy: true}
+	report.Checks = append(report.Checks, runHealthCheck(ctx, "model", func(ctx context.Context) error {
+		return CheckModelHealth(ctx, r.model)
+	}))
+
+	checked := make(map[string]bool)
+	var names []string
+	checkers := make(map[string]HealthChecker)
+	for _, registry := range r.toolRegistries {
+		for _, tool := range registry.GetTools() {
+			checker, ok := tool.(HealthChecker)
+			if !ok || checked[tool.Name()] {
+				continue
+			}
+			checked[tool.Name()] = true
+			names = append(names, tool.Name())
+			checkers[tool.Name()] = checker
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		report.Checks = append(report.Checks, runHealthCheck(ctx, name, checkers[name].HealthCheck))
+	}
+	for _, check := range report.Checks {
+		report.Healthy = report.Healthy && check.Healthy
+	}
+
+	r.health.report = report
+	r.health.at = time.Now()
+	return report
+}
+
+// runHealthCheck runs a check and records its outcome
+func runHealthCheck(ctx context.Context, name string, check func(ctx context.Context) error) HealthCheckResult {
+	start := time.Now()
+	err := check(ctx)
+	result := HealthCheckResult{
+		Name:      name,
+		Healthy:   err == nil,
+		Latency:   time.Since(start),
+		CheckedAt: start,
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// CheckModelHealth checks a model with its HealthCheck method, or with a minimal completion call
+func CheckModelHealth(ctx context.Context, model llm.CompletionModel) error {
+	if checker, ok := model.(HealthChecker); ok {
+		return checker.HealthCheck(ctx)
+	}
+	_, err := model.Complete(ctx, &llm.CompletionRequest{
+		Instructions: "Reply with OK.",
+		Messages:     []*llm.ModelMessage{{Role: llm.RoleUser, Content: "ping"}},
+	})
+	return err
+}
+
+// HealthCheck fails while the breaker is open, without calling the model
+func (m *circuitModel) HealthCheck(ctx context.Context) error {
+	if m.breaker.State() == CircuitOpen {
+		return &CircuitOpenError{Name: m.breaker.name}
+	}
+	return CheckModelHealth(ctx, m.model)
+}
+
+// HealthCheck fails while the breaker is open and runs the check of the wrapped tool, if any
+func (t *circuitTool) HealthCheck(ctx context.Context) error {
+	if t.breaker.State() == CircuitOpen {
+		return &CircuitOpenError{Name: t.breaker.name}
+	}
+	if checker, ok := t.ModelTool.(HealthChecker); ok {
+		return checker.HealthCheck(ctx)
+	}
+	return nil
+}
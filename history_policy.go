@@ -0,0 +1,122 @@
+package agent
+
+import (
+	"encoding/json"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// HistoryPolicy trims the message history of a run after every iteration.
+// The system prompt is not part of the history, it is always sent.
+// Policies must keep an assistant tool call and its tool result together, as providers
+// reject tool results whose call is missing.
+type HistoryPolicy interface {
+	Trim(messages []*llm.ModelMessage) []*llm.ModelMessage
+}
+
+// HistoryPolicyFunc is a function implementing HistoryPolicy
+type HistoryPolicyFunc func(messages []*llm.ModelMessage) []*llm.ModelMessage
+
+var _ HistoryPolicy = HistoryPolicyFunc(nil)
+
+// Trim calls f(messages)
+func (f HistoryPolicyFunc) Trim(messages []*llm.ModelMessage) []*llm.ModelMessage {
+	return f(messages)
+}
+
+// WithHistoryPolicy sets how the message history of runs is trimmed,
+// KeepRecentMessages with the WithMaxMessageHistory limit by default
+func WithHistoryPolicy(policy HistoryPolicy) RunnerOption {
+	return func(c *runnerConfig) {
+		c.historyPolicy = policy
+	}
+}
+
+// KeepRecentMessages keeps the first message, usually the task, and the most
+// recent messages, max messages in total. The most recent tool call is kept even if it exceeds max.
+func KeepRecentMessages(max int) HistoryPolicy {
+	return HistoryPolicyFunc(func(messages []*llm.ModelMessage) []*llm.ModelMessage {
+		if len(messages) <= max {
+			return messages
+		}
+		return trimHistory(messages, func(head int, kept int, group []*llm.ModelMessage) bool {
+			return head+kept+len(group) <= max
+		})
+	})
+}
+
+// KeepRecentTurns keeps the first message, usually the task, and the last n turns,
+// a turn starting at a user message
+func KeepRecentTurns(n int) HistoryPolicy {
+	return HistoryPolicyFunc(func(messages []*llm.ModelMessage) []*llm.ModelMessage {
+		turns := 0
+		return trimHistory(messages, func(head int, kept int, group []*llm.ModelMessage) bool {
+			if turns >= n {
+				return false
+			}
+			if group[0].Role == llm.RoleUser {
+				turns++
+			}
+			return true
+		})
+	})
+}
+
+// KeepRecentTokens keeps the first message, usually the task, and the most
+// recent messages, maxTokens in total as counted by tokenizer
+func KeepRecentTokens(tokenizer Tokenizer, maxTokens int) HistoryPolicy {
+	return HistoryPolicyFunc(func(messages []*llm.ModelMessage) []*llm.ModelMessage {
+		headTokens, tokens := -1, 0
+		return trimHistory(messages, func(head int, kept int, group []*llm.ModelMessage) bool {
+			if headTokens < 0 {
+				headTokens = messagesTokens(tokenizer, messages[:head])
+			}
+			tokens += messagesTokens(tokenizer, group)
+			return headTokens+tokens <= maxTokens
+		})
+	})
+}
+
+// trimHistory keeps the first head messages, then the most recent message groups while keep accepts them,
+// newest first. A group is a single message, or an assistant tool call with its tool results.
+// The newest group is always kept.
+func trimHistory(messages []*llm.ModelMessage, keep func(head int, kept int, group []*llm.ModelMessage) bool) []*llm.ModelMessage {
+	head := min(1, len(messages))
+
+	// Groups start at any message but a tool result, walking back from the end
+	start := len(messages)
+	kept := 0
+	for start > head {
+		end := start
+		start--
+		for start > head && messages[start].Role == llm.RoleTool {
+			start--
+		}
+		group := messages[start:end]
+		if !keep(head, kept, group) && kept > 0 {
+			start = end
+			break
+		}
+		kept += len(group)
+	}
+	if start == head {
+		return messages
+	}
+
+	trimmed := make([]*llm.ModelMessage, 0, head+kept)
+	trimmed = append(trimmed, messages[:head]...)
+	return append(trimmed, messages[start:]...)
+}
+
+// messagesTokens counts the tokens of the content and tool calls of messages
+func messagesTokens(tokenizer Tokenizer, messages []*llm.ModelMessage) int {
+	tokens := 0
+	for _, message := range messages {
+		tokens += tokenizer.CountTokens(message.Content)
+		if message.ToolCall != nil {
+			call, _ := json.Marshal(message.ToolCall)
+			tokens += tokenizer.CountTokens(string(call))
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,157 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// missingToolResult is the error of the tool results inserted for tool calls without one
+const missingToolResult = "No result was recorded for this tool call."
+
+// HistoryIssueKind identifies a problem of a message history
+type HistoryIssueKind string
+
+const (
+	// HistoryMissingToolResult is an assistant tool call not followed by its tool result
+	HistoryMissingToolResult HistoryIssueKind = "missing_tool_result"
+
+	// HistoryOrphanedToolResult is a tool result not following its assistant tool call
+	HistoryOrphanedToolResult HistoryIssueKind = "orphaned_tool_result"
+)
+
+// HistoryIssue is a problem of a message history that providers reject,
+// typically left by trimming, handoffs or resumed sessions
+type HistoryIssue struct {
+	Kind HistoryIssueKind `json:"kind"`
+
+	// Index is the index of the message in the history
+	Index int `json:"index"`
+
+	// ToolCallID is the ID of the tool call, if any
+	ToolCallID string `json:"toolCallId,omitempty"`
+}
+
+// String describes the issue
+func (i HistoryIssue) String() string {
+	return fmt.Sprintf("%s at message %d (tool call '%s')", i.Kind, i.Index, i.ToolCallID)
+}
+
+// ValidateHistory returns the tool calls without a result and the tool results without a call of messages.
+// A tool result belongs to the assistant tool call right before it. Results without an ID, or without a
+// tool call, belong to that call too, matching how the message converters export them.
+func ValidateHistory(messages []*llm.ModelMessage) []HistoryIssue {
+	_, issues := repairHistory(messages, false)
+	return issues
+}
+
+// RepairHistory returns messages with a tool result inserted after every tool call without one and
+// orphaned tool results turned into user messages, along with the repaired issues.
+// It returns messages itself if there is nothing to repair.
+func RepairHistory(messages []*llm.ModelMessage) ([]*llm.ModelMessage, []HistoryIssue) {
+	return repairHistory(messages, true)
+}
+
+// WithHistoryRepair makes the runner repair the message history of requests, and of runs after
+// every iteration, before sending it to the model. Repaired request histories are logged as warnings.
+func WithHistoryRepair(enabled bool) RunnerOption {
+	return func(c *runnerConfig) {
+		c.historyRepair = enabled
+	}
+}
+
+// repairHistory finds the issues of messages and, if repair is set, returns the repaired history
+func repairHistory(messages []*llm.ModelMessage, repair bool) ([]*llm.ModelMessage, []HistoryIssue) {
+	var issues []HistoryIssue
+	var repaired []*llm.ModelMessage
+	if repair {
+		repaired = make([]*llm.ModelMessage, 0, len(messages))
+	}
+
+	// pending is the tool call awaiting its result, answered the call whose results are being read
+	var pending, answered *llm.ToolCall
+	pendingIndex := 0
+	closePending := func() {
+		if pending == nil {
+			return
+		}
+		issues = append(issues, HistoryIssue{Kind: HistoryMissingToolResult, Index: pendingIndex, ToolCallID: pending.ID})
+		if repair {
+			errorMessage := missingToolResult
+			repaired = append(repaired, &llm.ModelMessage{
+				Role: llm.RoleTool,
+				ToolCall: &llm.ToolCall{
+					ID:           pending.ID,
+					Name:         pending.Name,
+					Input:        pending.Input,
+					ErrorMessage: &errorMessage,
+				},
+			})
+		}
+		pending = nil
+	}
+
+	for i, msg := range messages {
+		switch msg.Role {
+		case llm.RoleTool:
+			call := pending
+			if call == nil {
+				call = answered
+			}
+			if call != nil && (msg.ToolCall == nil || msg.ToolCall.ID == "" || msg.ToolCall.ID == call.ID) {
+				pending, answered = nil, call
+				if repair {
+					repaired = append(repaired, msg)
+				}
+				continue
+			}
+			// The orphan is turned into a user message, which the pending call cannot precede
+			closePending()
+			answered = nil
+			id := ""
+			if msg.ToolCall != nil {
+				id = msg.ToolCall.ID
+			}
+			issues = append(issues, HistoryIssue{Kind: HistoryOrphanedToolResult, Index: i, ToolCallID: id})
+			if repair {
+				repaired = append(repaired, orphanedResultMessage(msg))
+			}
+			continue
+		case llm.RoleAssistant:
+			closePending()
+			answered = nil
+			if msg.ToolCall != nil {
+				pending, pendingIndex = msg.ToolCall, i
+			}
+		default:
+			closePending()
+			answered = nil
+		}
+		if repair {
+			repaired = append(repaired, msg)
+		}
+	}
+	closePending()
+
+	if len(issues) == 0 {
+		return messages, nil
+	}
+	return repaired, issues
+}
+
+// orphanedResultMessage turns a tool result without a tool call into a user message
+func orphanedResultMessage(msg *llm.ModelMessage) *llm.ModelMessage {
+	_, content, err := toolResult(msg, "orphaned")
+	if err != nil {
+		content = msg.Content
+	}
+	name := "a tool"
+	if msg.ToolCall != nil && msg.ToolCall.Name != "" {
+		name = fmt.Sprintf("tool '%s'", msg.ToolCall.Name)
+	}
+	return &llm.ModelMessage{
+		Role:      llm.RoleUser,
+		Content:   fmt.Sprintf("Result of an earlier call of %s: %s", name, content),
+		Artifacts: msg.Artifacts,
+	}
+}
@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+)
+
+// idempotencyKey is the key for storing the idempotency key of a tool call in context.Context
+const idempotencyKey contextKey = "idempotency_key"
+
+// WithIdempotencyKey returns a new context carrying the idempotency key of a tool call
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey, key)
+}
+
+// IdempotencyKeyOf returns the idempotency key of the tool call executing in ctx.
+// Tools performing writes pass it to their backend, e.g. as an Idempotency-Key header,
+// so executions repeated by retried or resumed runs are applied once.
+func IdempotencyKeyOf(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(idempotencyKey).(string)
+	return key, ok && key != ""
+}
+
+// NewIdempotencyKey derives the idempotency key of a tool call from the run ID,
+// the iteration and a hash of the tool name and input
+func NewIdempotencyKey(runID string, iteration int, tool string, input map[string]any) string {
+	// Map keys are marshaled in sorted order, so equal inputs hash the same
+	data, err := json.Marshal(input)
+	if err != nil {
+		data = []byte(err.Error())
+	}
+	hash := sha256.New()
+	hash.Write([]byte(runID))
+	hash.Write([]byte{0})
+	hash.Write([]byte(strconv.Itoa(iteration)))
+	hash.Write([]byte{0})
+	hash.Write([]byte(tool))
+	hash.Write([]byte{0})
+	hash.Write(data)
+	return hex.EncodeToString(hash.Sum(nil)[:16])
+}
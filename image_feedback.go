@@ -0,0 +1,128 @@
+package agent
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/easyagent-dev/llm"
+)
+
+const (
+	// DefaultMaxImageBytes is the default size limit of images fed back to the model, the limit of
+	// the strictest major provider
+	DefaultMaxImageBytes = 5 * 1024 * 1024
+
+	// DefaultMaxToolImages is the default number of images of a tool result fed back to the model
+	DefaultMaxToolImages = 4
+)
+
+// DefaultImageFormats are the image content types fed back to the model by default, those all
+// major multimodal providers accept
+var DefaultImageFormats = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+// ImageOutput is a tool output carrying images, e.g. a screenshot, with an optional result.
+// Tools can also return a bare *llm.ModelArtifact or []*llm.ModelArtifact.
+type ImageOutput struct {
+	// Output is the rest of the result, formatted as any tool output
+	Output any
+
+	// Images are the image artifacts returned by the tool
+	Images []*llm.ModelArtifact
+}
+
+// ImageFeedbackConfig bounds the images fed back to the model
+type ImageFeedbackConfig struct {
+	// MaxBytes is the size limit of an image, DefaultMaxImageBytes if 0
+	MaxBytes int
+
+	// MaxImages is the number of images of a tool result fed back, DefaultMaxToolImages if 0
+	MaxImages int
+
+	// Formats are the accepted content types, DefaultImageFormats if empty
+	Formats []string
+}
+
+// WithImageFeedback attaches the images returned by tools to the next model message, so
+// screenshot-then-analyze loops work with multimodal models. Images are sent in a user message
+// following the tool result, since providers do not accept images in tool results. Without this
+// option, or for images over the limits, the model is told which images it did not get.
+func WithImageFeedback(config ImageFeedbackConfig) RunnerOption {
+	return func(c *runnerConfig) {
+		if config.MaxBytes <= 0 {
+			config.MaxBytes = DefaultMaxImageBytes
+		}
+		if config.MaxImages <= 0 {
+			config.MaxImages = DefaultMaxToolImages
+		}
+		if len(config.Formats) == 0 {
+			config.Formats = DefaultImageFormats
+		}
+		c.imageFeedback = &config
+	}
+}
+
+// toolImages splits the images of a tool output from the rest of it
+func toolImages(output any) (any, []*llm.ModelArtifact) {
+	switch v := output.(type) {
+	case *ImageOutput:
+		return v.Output, v.Images
+	case ImageOutput:
+		return v.Output, v.Images
+	case *llm.ModelArtifact:
+		if isImageArtifact(v) {
+			return nil, []*llm.ModelArtifact{v}
+		}
+	case []*llm.ModelArtifact:
+		if slices.ContainsFunc(v, isImageArtifact) {
+			return nil, v
+		}
+	}
+	return output, nil
+}
+
+// feedImages replaces the images of a tool output with notes on what became of them, returning
+// the user message attaching the images the model can view, nil if there is none
+func (r *BaseRunner) feedImages(run *agentRun, toolCall *llm.ToolCall, output any) (any, *llm.ModelMessage) {
+	rest, images := toolImages(output)
+	if len(images) == 0 {
+		return output, nil
+	}
+
+	var attached []*llm.ModelArtifact
+	notes := make([]string, 0, len(images))
+	for i, image := range images {
+		name := image.Name
+		if name == "" {
+			name = fmt.Sprintf("image %d", i+1)
+		}
+		description := fmt.Sprintf("%s (%s, %d KB)", name, image.ContentType, (len(image.Content)+1023)/1024)
+		switch config := r.imageFeedback; {
+		case !isImageArtifact(image):
+			notes = append(notes, description+": not an image, omitted")
+		case config == nil, run.capabilities != nil && !run.capabilities.Vision:
+			notes = append(notes, description+": omitted, images are not sent to this model")
+		case !slices.Contains(config.Formats, image.ContentType):
+			notes = append(notes, description+": omitted, unsupported format")
+		case len(image.Content) > config.MaxBytes:
+			notes = append(notes, fmt.Sprintf("%s: omitted, larger than %d KB", description, (config.MaxBytes+1023)/1024))
+		case len(attached) >= config.MaxImages:
+			notes = append(notes, fmt.Sprintf("%s: omitted, only %d images are attached", description, config.MaxImages))
+		default:
+			attached = append(attached, image)
+			notes = append(notes, description+": attached in the next message")
+		}
+	}
+
+	output = map[string]any{"images": notes}
+	if rest != nil {
+		output = map[string]any{"result": rest, "images": notes}
+	}
+	if len(attached) == 0 {
+		return output, nil
+	}
+	return output, &llm.ModelMessage{
+		Role:      llm.RoleUser,
+		Content:   fmt.Sprintf("Images returned by the %s tool:", toolCall.Name),
+		Artifacts: attached,
+	}
+}
@@ -0,0 +1,316 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// maxGotLength bounds the length of the strings reported as the value of invalid fields
+const maxGotLength = 80
+
+// FieldError is an invalid field of a tool input
+type FieldError struct {
+	// Path is the JSON pointer of the field, empty for the whole input
+	Path string `json:"path"`
+
+	// Reason is the failed check: type, required, enum, const, minimum, maximum, min_length,
+	// max_length, pattern, min_items, max_items or additional_property
+	Reason string `json:"reason"`
+
+	// Expected describes the valid values, e.g. integer, one of ["asc","desc"] or >= 1
+	Expected string `json:"expected"`
+
+	// Got is the value of the field, absent when it is missing
+	Got any `json:"got,omitempty"`
+}
+
+// ValidationError is an invalid tool input. Its fields are fed back to the model as a JSON object,
+// which models correct more reliably than prose. Runners return it for inputs not matching the
+// input schema of their tool under WithInputValidation, and tools may return it for their own checks.
+type ValidationError struct {
+	// Tool is the name of the tool, set by the runner if empty
+	Tool string `json:"tool"`
+
+	Errors []FieldError `json:"errors"`
+}
+
+var _ error = (*ValidationError)(nil)
+
+// NewValidationError returns a validation error of the fields of a tool input
+func NewValidationError(errors ...FieldError) *ValidationError {
+	return &ValidationError{Errors: errors}
+}
+
+// Error describes the invalid fields
+func (e *ValidationError) Error() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "invalid input of tool '%s'", e.Tool)
+	for i, field := range e.Errors {
+		if i == 0 {
+			builder.WriteString(": ")
+		} else {
+			builder.WriteString("; ")
+		}
+		path := field.Path
+		if path == "" {
+			path = "/"
+		}
+		fmt.Fprintf(&builder, "%s: expected %s", path, field.Expected)
+		if field.Reason == "required" {
+			builder.WriteString(", missing")
+		} else if got, err := json.Marshal(field.Got); err == nil {
+			fmt.Fprintf(&builder, ", got %s", got)
+		}
+	}
+	return builder.String()
+}
+
+// Is matches ErrInvalidInput
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidInput
+}
+
+// JSON returns the error object fed back to the model
+func (e *ValidationError) JSON() string {
+	data, err := json.Marshal(struct {
+		Error string `json:"error"`
+		*ValidationError
+	}{"invalid_input", e})
+	if err != nil {
+		return e.Error()
+	}
+	return string(data)
+}
+
+// WithInputValidation sets whether tool inputs are validated against the input schemas of their tools
+// before running them, false by default. Invalid calls are not run, the model gets a ValidationError.
+// The subset of JSON schema checked is type, properties, required, additionalProperties, items, enum,
+// const, minimum, maximum, minLength, maxLength, pattern, minItems and maxItems.
+func WithInputValidation(enabled bool) RunnerOption {
+	return func(c *runnerConfig) {
+		c.inputValidation = enabled
+	}
+}
+
+// invalidInput reports the invalid input of toolCall to the stream and returns the feedback of the model
+func (r *BaseRunner) invalidInput(run *agentRun, events *eventEmitter, toolCall *llm.ToolCall, validationErr *ValidationError) string {
+	message := validationErr.Error()
+	events.emit(AgentEvent{
+		Type:         AgentEventTypeInvalidToolInput,
+		ToolCall:     toolCall,
+		ErrorMessage: &message,
+		Validation:   validationErr,
+	})
+	return fmt.Sprintf(run.templates.InvalidInput, toolCall.Name, validationErr.JSON())
+}
+
+// validateInput checks the input of a call of tool against its input schema
+func validateInput(toolRegistry *ToolRegistry, tool string, input map[string]any) (*ValidationError, error) {
+	data, err := toolRegistry.GetInputSchemaJSON(tool)
+	if err != nil {
+		return nil, err
+	}
+	var schema any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("invalid input schema of tool '%s': %w", tool, err)
+	}
+	var value any = input
+	if input == nil {
+		value = map[string]any{}
+	}
+	var errs []FieldError
+	validateValue(schema, value, "", &errs)
+	if len(errs) == 0 {
+		return nil, nil
+	}
+	return &ValidationError{Tool: tool, Errors: errs}, nil
+}
+
+// validateValue appends the failed checks of value against schema to errs
+func validateValue(schema any, value any, path string, errs *[]FieldError) {
+	s, ok := schema.(map[string]any)
+	if !ok {
+		return
+	}
+	fail := func(reason, expected string) {
+		*errs = append(*errs, FieldError{Path: path, Reason: reason, Expected: expected, Got: reportedValue(value)})
+	}
+
+	if types := schemaTypes(s); len(types) > 0 && !slices.ContainsFunc(types, func(t string) bool { return hasType(value, t) }) {
+		fail("type", strings.Join(types, " or "))
+		return
+	}
+	if enum, ok := s["enum"].([]any); ok && !slices.ContainsFunc(enum, func(v any) bool { return jsonEqual(v, value) }) {
+		fail("enum", "one of "+compactJSON(enum))
+	}
+	if constant, ok := s["const"]; ok && !jsonEqual(constant, value) {
+		fail("const", compactJSON(constant))
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		properties, _ := s["properties"].(map[string]any)
+		if required, ok := s["required"].([]any); ok {
+			for _, name := range required {
+				name, _ := name.(string)
+				if _, ok := v[name]; !ok {
+					expected := "a value"
+					if property, ok := properties[name].(map[string]any); ok {
+						if types := schemaTypes(property); len(types) > 0 {
+							expected = strings.Join(types, " or ")
+						}
+					}
+					*errs = append(*errs, FieldError{Path: path + "/" + escapePointer(name), Reason: "required", Expected: expected})
+				}
+			}
+		}
+		for _, name := range slices.Sorted(maps.Keys(v)) {
+			fieldPath := path + "/" + escapePointer(name)
+			if property, ok := properties[name]; ok {
+				validateValue(property, v[name], fieldPath, errs)
+				continue
+			}
+			switch additional := s["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					*errs = append(*errs, FieldError{
+						Path: fieldPath, Reason: "additional_property",
+						Expected: "one of " + compactJSON(slices.Sorted(maps.Keys(properties))), Got: reportedValue(v[name]),
+					})
+				}
+			case map[string]any:
+				validateValue(additional, v[name], fieldPath, errs)
+			}
+		}
+	case []any:
+		if minItems, ok := schemaNumber(s["minItems"]); ok && float64(len(v)) < minItems {
+			fail("min_items", fmt.Sprintf("at least %g items", minItems))
+		}
+		if maxItems, ok := schemaNumber(s["maxItems"]); ok && float64(len(v)) > maxItems {
+			fail("max_items", fmt.Sprintf("at most %g items", maxItems))
+		}
+		for i, item := range v {
+			validateValue(s["items"], item, path+"/"+strconv.Itoa(i), errs)
+		}
+	case string:
+		length := float64(len([]rune(v)))
+		if minLength, ok := schemaNumber(s["minLength"]); ok && length < minLength {
+			fail("min_length", fmt.Sprintf("at least %g characters", minLength))
+		}
+		if maxLength, ok := schemaNumber(s["maxLength"]); ok && length > maxLength {
+			fail("max_length", fmt.Sprintf("at most %g characters", maxLength))
+		}
+		if pattern, ok := s["pattern"].(string); ok {
+			// Patterns Go cannot compile are not checked
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(v) {
+				fail("pattern", "a match of "+pattern)
+			}
+		}
+	default:
+		number, ok := schemaNumber(v)
+		if !ok {
+			return
+		}
+		if minimum, ok := schemaNumber(s["minimum"]); ok && number < minimum {
+			fail("minimum", fmt.Sprintf(">= %g", minimum))
+		}
+		if minimum, ok := schemaNumber(s["exclusiveMinimum"]); ok && number <= minimum {
+			fail("minimum", fmt.Sprintf("> %g", minimum))
+		}
+		if maximum, ok := schemaNumber(s["maximum"]); ok && number > maximum {
+			fail("maximum", fmt.Sprintf("<= %g", maximum))
+		}
+		if maximum, ok := schemaNumber(s["exclusiveMaximum"]); ok && number >= maximum {
+			fail("maximum", fmt.Sprintf("< %g", maximum))
+		}
+	}
+}
+
+// hasType reports whether value is of the JSON schema type name
+func hasType(value any, name string) bool {
+	switch name {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	case "number":
+		_, ok := schemaNumber(value)
+		return ok
+	case "integer":
+		number, ok := schemaNumber(value)
+		return ok && number == math.Trunc(number)
+	}
+	// Unknown types are not checked
+	return true
+}
+
+// schemaNumber returns value as a float64 if it is a number
+func schemaNumber(value any) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// jsonEqual reports whether a and b have the same JSON encoding
+func jsonEqual(a, b any) bool {
+	return compactJSON(a) == compactJSON(b)
+}
+
+// compactJSON returns the JSON encoding of value
+func compactJSON(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// reportedValue returns the value reported for an invalid field, long strings and containers
+// shortened so errors stay small
+func reportedValue(value any) any {
+	switch v := value.(type) {
+	case string:
+		if runes := []rune(v); len(runes) > maxGotLength {
+			return string(runes[:maxGotLength]) + "..."
+		}
+	case map[string]any, []any:
+		if encoded := compactJSON(v); len(encoded) > maxGotLength {
+			return reportedValue(encoded)
+		}
+	}
+	return value
+}
+
+// escapePointer escapes a property name for a JSON pointer
+func escapePointer(name string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+}
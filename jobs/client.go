@@ -0,0 +1,160 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultPollInterval is how often clients and idle workers poll the store
+const DefaultPollInterval = time.Second
+
+// Client submits jobs and follows their progress
+type Client struct {
+	store        Store
+	pollInterval time.Duration
+}
+
+// ClientOption is a functional option for configuring clients
+type ClientOption func(*Client)
+
+// WithClientPollInterval sets how often Wait and Subscribe poll the store, DefaultPollInterval by default
+func WithClientPollInterval(interval time.Duration) ClientOption {
+	return func(c *Client) {
+		c.pollInterval = interval
+	}
+}
+
+// NewClient creates a client for the jobs of store
+func NewClient(store Store, opts ...ClientOption) *Client {
+	client := &Client{
+		store:        store,
+		pollInterval: DefaultPollInterval,
+	}
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client
+}
+
+// SubmitOption is a functional option for configuring submitted jobs
+type SubmitOption func(*Job)
+
+// WithJobID sets the ID of the job instead of a generated one, e.g. to deduplicate submissions
+func WithJobID(id string) SubmitOption {
+	return func(j *Job) {
+		j.ID = id
+	}
+}
+
+// WithMaxAttempts sets how many times a failing job is run, 1 by default
+func WithMaxAttempts(attempts int) SubmitOption {
+	return func(j *Job) {
+		j.MaxAttempts = attempts
+	}
+}
+
+// Submit queues a job running req
+func (c *Client) Submit(ctx context.Context, req *Request, opts ...SubmitOption) (*Job, error) {
+	if req.Runner == "" {
+		return nil, errors.New("job runner is required")
+	}
+	if len(req.Messages) == 0 {
+		return nil, errors.New("at least one message is required")
+	}
+	job := &Job{
+		ID:          uuid.New().String(),
+		Request:     req,
+		Status:      StatusQueued,
+		MaxAttempts: 1,
+		CreatedAt:   time.Now(),
+	}
+	for _, opt := range opts {
+		opt(job)
+	}
+	if job.MaxAttempts <= 0 {
+		return nil, errors.New("max attempts must be positive")
+	}
+	if err := c.store.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to create job: %w", err)
+	}
+	return job, nil
+}
+
+// Get returns the current state of a job
+func (c *Client) Get(ctx context.Context, id string) (*Job, error) {
+	return c.store.Get(ctx, id)
+}
+
+// Cancel cancels a job. A running job is stopped by its worker at its next lease renewal.
+func (c *Client) Cancel(ctx context.Context, id string) (*Job, error) {
+	return c.store.Cancel(ctx, id, time.Now())
+}
+
+// Events returns the persisted events of a job with a sequence number greater than after
+func (c *Client) Events(ctx context.Context, id string, after int64) ([]*Event, error) {
+	return c.store.Events(ctx, id, after)
+}
+
+// Wait polls a job until it is finished and returns it
+func (c *Client) Wait(ctx context.Context, id string) (*Job, error) {
+	ticker := time.NewTicker(c.pollInterval)
+	defer ticker.Stop()
+	for {
+		job, err := c.store.Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if job.Status.Terminal() {
+			return job, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Subscribe streams the events of a job persisted after the sequence number after, starting with the
+// ones already stored, until the job is finished or ctx is done. The channel is then closed.
+// Polling errors end the subscription, call Get to find out how the job ended.
+func (c *Client) Subscribe(ctx context.Context, id string, after int64) <-chan *Event {
+	ch := make(chan *Event, 16)
+	go func() {
+		defer close(ch)
+		ticker := time.NewTicker(c.pollInterval)
+		defer ticker.Stop()
+		for {
+			// Read the status first, so events persisted before the job finished are not missed
+			job, err := c.store.Get(ctx, id)
+			if err != nil {
+				return
+			}
+			events, err := c.store.Events(ctx, id, after)
+			if err != nil {
+				return
+			}
+			for _, event := range events {
+				select {
+				case ch <- event:
+					after = event.Seq
+				case <-ctx.Done():
+					return
+				}
+			}
+			if job.Status.Terminal() {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return ch
+}
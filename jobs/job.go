@@ -0,0 +1,189 @@
+// Package jobs runs agent requests as durable background jobs.
+//
+// Clients submit requests to a Store, workers claim and run them with registered stream runners,
+// persisting their events, and clients poll or subscribe to the job status and events.
+// Jobs are leased to workers, so a job whose worker died is picked up again once its lease expires.
+package jobs
+
+import (
+	"errors"
+	"time"
+
+	"github.com/easyagent-dev/agent"
+	"github.com/easyagent-dev/llm"
+)
+
+// Status is the execution status of a job
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusCancelled Status = "cancelled"
+)
+
+// Terminal reports whether a job with this status is finished
+func (s Status) Terminal() bool {
+	return s == StatusSucceeded || s == StatusFailed || s == StatusCancelled
+}
+
+var (
+	// ErrJobNotFound is returned when a job does not exist
+	ErrJobNotFound = errors.New("job not found")
+
+	// ErrJobExists is returned when creating a job with the ID of an existing one
+	ErrJobExists = errors.New("job already exists")
+
+	// ErrLeaseLost is returned when a worker updates a job it no longer holds the lease of
+	ErrLeaseLost = errors.New("job lease lost")
+
+	// ErrScheduleOverlap is reported when a schedule skips a run because its previous job is unfinished
+	ErrScheduleOverlap = errors.New("previous scheduled job is unfinished")
+
+	// ErrBudgetExceeded is reported when a schedule skips a run because its budget is spent
+	ErrBudgetExceeded = errors.New("schedule budget exceeded")
+)
+
+// Request is the serializable part of an agent request run by a job.
+// Output schemas, tools and credentials are not persisted, workers restore them with a
+// PrepareFunc before running the job.
+type Request struct {
+	// Runner is the name of the worker runner executing the request
+	Runner string `json:"runner"`
+
+	Messages         []*llm.ModelMessage `json:"messages"`
+	MaxIterations    int                 `json:"maxIterations"`
+	MaxRetries       int                 `json:"maxRetries,omitempty"`
+	Model            string              `json:"model,omitempty"`
+	Locale           string              `json:"locale,omitempty"`
+	DirectAnswer     bool                `json:"directAnswer,omitempty"`
+	AllowDestructive bool                `json:"allowDestructive,omitempty"`
+	ToolChoice       agent.ToolChoice    `json:"toolChoice,omitempty"`
+	Priority         int                 `json:"priority,omitempty"`
+	Metadata         map[string]string   `json:"metadata,omitempty"`
+}
+
+// NewRequest creates a job request run by the worker runner named runner from the serializable fields of req
+func NewRequest(runner string, req *agent.AgentRequest) *Request {
+	return &Request{
+		Runner:           runner,
+		Messages:         req.Messages,
+		MaxIterations:    req.MaxIterations,
+		MaxRetries:       req.MaxRetries,
+		Model:            req.Model,
+		Locale:           req.Locale,
+		DirectAnswer:     req.DirectAnswer,
+		AllowDestructive: req.AllowDestructive,
+		ToolChoice:       req.ToolChoice,
+		Priority:         req.Priority,
+		Metadata:         req.Metadata,
+	}
+}
+
+// agentRequest creates the agent request of a job. The job ID is the run ID, so attempts
+// resuming the job pass the same idempotency keys to its tools.
+func (r *Request) agentRequest(jobID string) *agent.AgentRequest {
+	return &agent.AgentRequest{
+		Messages:         r.Messages,
+		MaxIterations:    r.MaxIterations,
+		MaxRetries:       r.MaxRetries,
+		Model:            r.Model,
+		Locale:           r.Locale,
+		DirectAnswer:     r.DirectAnswer,
+		AllowDestructive: r.AllowDestructive,
+		ToolChoice:       r.ToolChoice,
+		Priority:         r.Priority,
+		Metadata:         r.Metadata,
+		RunID:            jobID,
+	}
+}
+
+// Result is the persisted response of a finished job
+type Result struct {
+	Output  any             `json:"output,omitempty"`
+	Usage   *llm.TokenUsage `json:"usage,omitempty"`
+	Cost    *float64        `json:"cost,omitempty"`
+	Agent   string          `json:"agent,omitempty"`
+	Partial bool            `json:"partial,omitempty"`
+}
+
+// newResult creates the result of a job from the response of its run
+func newResult(resp *agent.AgentResponse) *Result {
+	return &Result{
+		Output:  resp.Output,
+		Usage:   resp.Usage,
+		Cost:    resp.Cost,
+		Agent:   resp.Agent,
+		Partial: resp.Partial,
+	}
+}
+
+// Job is a submitted agent request and its execution state
+type Job struct {
+	ID      string   `json:"id"`
+	Request *Request `json:"request"`
+	Status  Status   `json:"status"`
+
+	// Result is set once the job succeeded, or failed with a salvaged partial output
+	Result *Result `json:"result,omitempty"`
+
+	// Usage and Cost are the token usage and cost of all attempts of the job, including failed ones
+	Usage *llm.TokenUsage `json:"usage,omitempty"`
+	Cost  float64         `json:"cost,omitempty"`
+
+	// Error is the error of the last failed attempt
+	Error string `json:"error,omitempty"`
+
+	// Attempts is the number of times the job was claimed by a worker
+	Attempts int `json:"attempts"`
+
+	// MaxAttempts is the number of attempts after which a failing job is not retried
+	MaxAttempts int `json:"maxAttempts"`
+
+	// CancelRequested is set when a running job is cancelled, until its worker stops it
+	CancelRequested bool `json:"cancelRequested,omitempty"`
+
+	// LeaseOwner is the ID of the worker running the job
+	LeaseOwner string `json:"leaseOwner,omitempty"`
+
+	// LeaseExpiresAt is when other workers may claim the running job, unless its worker renews the lease
+	LeaseExpiresAt time.Time `json:"leaseExpiresAt,omitzero"`
+
+	CreatedAt  time.Time `json:"createdAt"`
+	StartedAt  time.Time `json:"startedAt,omitzero"`
+	FinishedAt time.Time `json:"finishedAt,omitzero"`
+}
+
+// clone returns a copy of the job that can be modified without affecting the original
+func (j *Job) clone() *Job {
+	copied := *j
+	return &copied
+}
+
+// addUsage adds the usage totals of an attempt to the usage and cost of the job
+func (j *Job) addUsage(report *agent.UsageReport) {
+	if report == nil {
+		return
+	}
+	usage := &llm.TokenUsage{}
+	if j.Usage != nil {
+		usage.Append(j.Usage)
+	}
+	usage.Append(&report.TotalUsage)
+	j.Usage = usage
+	j.Cost += report.TotalCost
+}
+
+// Event is a persisted event of a job run
+type Event struct {
+	// Seq orders the events of a job, starting at 1. It is assigned by the store.
+	Seq int64 `json:"seq"`
+
+	// Attempt is the attempt of the job that produced the event
+	Attempt int `json:"attempt"`
+
+	Time  time.Time        `json:"time"`
+	Event agent.AgentEvent `json:"event"`
+}
@@ -0,0 +1,59 @@
+package jobs
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/easyagent-dev/agent"
+	"github.com/easyagent-dev/llm"
+)
+
+func TestRequestRoundTrip(t *testing.T) {
+	req := &agent.AgentRequest{
+		Messages:         []*llm.ModelMessage{{Role: llm.RoleUser, Content: "Résume ce document"}},
+		MaxIterations:    5,
+		MaxRetries:       2,
+		Model:            "openai/o4-mini",
+		Locale:           "fr-FR",
+		AllowDestructive: true,
+		Priority:         3,
+		Metadata:         map[string]string{"tenant": "acme"},
+	}
+
+	// Jobs are persisted as JSON by the stores
+	data, err := json.Marshal(NewRequest("summarizer", req))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored Request
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored.Runner != "summarizer" {
+		t.Errorf("Runner = %q, want %q", stored.Runner, "summarizer")
+	}
+
+	got := stored.agentRequest("job-1")
+	if got.Locale != req.Locale {
+		t.Errorf("Locale = %q, want %q", got.Locale, req.Locale)
+	}
+	if got.Model != req.Model {
+		t.Errorf("Model = %q, want %q", got.Model, req.Model)
+	}
+	if got.MaxIterations != req.MaxIterations || got.MaxRetries != req.MaxRetries || got.Priority != req.Priority {
+		t.Errorf("MaxIterations, MaxRetries, Priority = %d, %d, %d, want %d, %d, %d",
+			got.MaxIterations, got.MaxRetries, got.Priority, req.MaxIterations, req.MaxRetries, req.Priority)
+	}
+	if !got.AllowDestructive {
+		t.Error("AllowDestructive = false, want true")
+	}
+	if got.Metadata["tenant"] != "acme" {
+		t.Errorf("Metadata = %v, want tenant acme", got.Metadata)
+	}
+	if got.RunID != "job-1" {
+		t.Errorf("RunID = %q, want %q", got.RunID, "job-1")
+	}
+	if err := got.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
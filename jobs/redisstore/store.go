@@ -0,0 +1,392 @@
+// Package redisstore keeps agent jobs in Redis.
+//
+// Each job is a hash, queued and running jobs are indexed by sorted sets and events are kept in
+// a list per job. Claims and lease updates run as Lua scripts, so they are atomic across workers.
+package redisstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/easyagent-dev/agent/jobs"
+	"github.com/easyagent-dev/llm"
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultPrefix prefixes the keys of a store. Its hash tag keeps all keys in one Redis Cluster slot.
+const DefaultPrefix = "{agent-jobs}:"
+
+// Store keeps jobs in Redis.
+// It is safe for concurrent use by multiple goroutines and processes.
+type Store struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+var _ jobs.Store = (*Store)(nil)
+
+// New creates a store using client. All keys start with prefix, DefaultPrefix if empty.
+// With Redis Cluster, prefix must contain a hash tag.
+func New(client redis.UniversalClient, prefix string) *Store {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	return &Store{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+func (s *Store) jobKey(id string) string {
+	return s.prefix + "job:" + id
+}
+
+func (s *Store) eventsKey(id string) string {
+	return s.prefix + "events:" + id
+}
+
+func (s *Store) queuedKey() string {
+	return s.prefix + "queued"
+}
+
+func (s *Store) runningKey() string {
+	return s.prefix + "running"
+}
+
+// Sorted set scores are microseconds, which float64 scores hold exactly
+func score(t time.Time) int64 {
+	return t.UnixMicro()
+}
+
+var createScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return 0
+end
+redis.call('HSET', KEYS[1], unpack(ARGV, 3))
+if ARGV[1] == 'queued' then
+	redis.call('ZADD', KEYS[2], ARGV[2], ARGV[4])
+end
+return 1
+`)
+
+// Create stores a new job
+func (s *Store) Create(ctx context.Context, job *jobs.Job) error {
+	request, err := json.Marshal(job.Request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job request: %w", err)
+	}
+	result, err := marshalResult(job.Result)
+	if err != nil {
+		return err
+	}
+	usage, err := marshalUsage(job.Usage)
+	if err != nil {
+		return err
+	}
+	args := []any{
+		string(job.Status), score(job.CreatedAt),
+		"id", job.ID,
+		"request", string(request),
+		"status", string(job.Status),
+		"result", result,
+		"token_usage", usage,
+		"cost", formatFloat(job.Cost),
+		"error", job.Error,
+		"attempts", job.Attempts,
+		"max_attempts", job.MaxAttempts,
+		"cancel_requested", boolInt(job.CancelRequested),
+		"lease_owner", job.LeaseOwner,
+		"lease_expires_at", unixNano(job.LeaseExpiresAt),
+		"created_at", unixNano(job.CreatedAt),
+		"started_at", unixNano(job.StartedAt),
+		"finished_at", unixNano(job.FinishedAt),
+	}
+	created, err := createScript.Run(ctx, s.client, []string{s.jobKey(job.ID), s.queuedKey()}, args...).Int()
+	if err != nil {
+		return fmt.Errorf("failed to create job: %w", err)
+	}
+	if created == 0 {
+		return jobs.ErrJobExists
+	}
+	return nil
+}
+
+// Get reads a job
+func (s *Store) Get(ctx context.Context, id string) (*jobs.Job, error) {
+	fields, err := s.client.HGetAll(ctx, s.jobKey(id)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	if len(fields) == 0 {
+		return nil, jobs.ErrJobNotFound
+	}
+
+	job := &jobs.Job{
+		ID:              fields["id"],
+		Status:          jobs.Status(fields["status"]),
+		Error:           fields["error"],
+		LeaseOwner:      fields["lease_owner"],
+		CancelRequested: fields["cancel_requested"] == "1",
+		Attempts:        atoi(fields["attempts"]),
+		MaxAttempts:     atoi(fields["max_attempts"]),
+		Cost:            parseFloat(fields["cost"]),
+		LeaseExpiresAt:  parseUnixNano(fields["lease_expires_at"]),
+		CreatedAt:       parseUnixNano(fields["created_at"]),
+		StartedAt:       parseUnixNano(fields["started_at"]),
+		FinishedAt:      parseUnixNano(fields["finished_at"]),
+	}
+	if err := json.Unmarshal([]byte(fields["request"]), &job.Request); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job request: %w", err)
+	}
+	if result := fields["result"]; result != "" {
+		if err := json.Unmarshal([]byte(result), &job.Result); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal job result: %w", err)
+		}
+	}
+	if usage := fields["token_usage"]; usage != "" {
+		if err := json.Unmarshal([]byte(usage), &job.Usage); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal job usage: %w", err)
+		}
+	}
+	return job, nil
+}
+
+var claimScript = redis.NewScript(`
+local id = redis.call('ZRANGE', KEYS[1], 0, 0)[1]
+if id then
+	redis.call('ZREM', KEYS[1], id)
+else
+	id = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', '(' .. ARGV[1], 'LIMIT', 0, 1)[1]
+	if not id then
+		return false
+	end
+end
+redis.call('ZADD', KEYS[2], ARGV[2], id)
+local key = ARGV[3] .. id
+redis.call('HSET', key, 'status', 'running', 'lease_owner', ARGV[4], 'lease_expires_at', ARGV[5], 'started_at', ARGV[6])
+redis.call('HINCRBY', key, 'attempts', 1)
+return id
+`)
+
+// Claim leases the oldest queued job, or a running job whose lease expired, to worker
+func (s *Store) Claim(ctx context.Context, worker string, now time.Time, leaseUntil time.Time) (*jobs.Job, error) {
+	id, err := claimScript.Run(ctx, s.client, []string{s.queuedKey(), s.runningKey()},
+		score(now), score(leaseUntil), s.jobKey(""), worker, unixNano(leaseUntil), unixNano(now)).Text()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+	return s.Get(ctx, id)
+}
+
+// leaseCheck returns -1 if the job does not exist and 0 if worker does not hold its lease
+const leaseCheck = `
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return -1
+end
+if redis.call('HGET', KEYS[1], 'status') ~= 'running' or redis.call('HGET', KEYS[1], 'lease_owner') ~= ARGV[1] then
+	return 0
+end
+`
+
+var renewScript = redis.NewScript(leaseCheck + `
+redis.call('HSET', KEYS[1], 'lease_expires_at', ARGV[2])
+redis.call('ZADD', KEYS[2], ARGV[3], ARGV[4])
+return 1
+`)
+
+// Renew extends the lease of a job held by worker
+func (s *Store) Renew(ctx context.Context, id string, worker string, leaseUntil time.Time) (*jobs.Job, error) {
+	status, err := renewScript.Run(ctx, s.client, []string{s.jobKey(id), s.runningKey()},
+		worker, unixNano(leaseUntil), score(leaseUntil), id).Int()
+	if err != nil {
+		return nil, fmt.Errorf("failed to renew job lease: %w", err)
+	}
+	if err := leaseError(status); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, id)
+}
+
+var finishScript = redis.NewScript(leaseCheck + `
+redis.call('HSET', KEYS[1], 'status', ARGV[2], 'attempts', ARGV[3], 'result', ARGV[4], 'error', ARGV[5],
+	'finished_at', ARGV[6], 'token_usage', ARGV[8], 'cost', ARGV[9],
+	'cancel_requested', '0', 'lease_owner', '', 'lease_expires_at', '0')
+redis.call('ZREM', KEYS[2], ARGV[7])
+if ARGV[2] == 'queued' then
+	redis.call('ZADD', KEYS[3], math.floor(tonumber(redis.call('HGET', KEYS[1], 'created_at')) / 1000), ARGV[7])
+end
+return 1
+`)
+
+// Finish stores the outcome of a job held by worker
+func (s *Store) Finish(ctx context.Context, job *jobs.Job, worker string) error {
+	result, err := marshalResult(job.Result)
+	if err != nil {
+		return err
+	}
+	usage, err := marshalUsage(job.Usage)
+	if err != nil {
+		return err
+	}
+	status, err := finishScript.Run(ctx, s.client, []string{s.jobKey(job.ID), s.runningKey(), s.queuedKey()},
+		worker, string(job.Status), job.Attempts, result, job.Error, unixNano(job.FinishedAt), job.ID,
+		usage, formatFloat(job.Cost)).Int()
+	if err != nil {
+		return fmt.Errorf("failed to finish job: %w", err)
+	}
+	return leaseError(status)
+}
+
+var cancelScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return -1
+end
+local status = redis.call('HGET', KEYS[1], 'status')
+if status == 'queued' then
+	redis.call('HSET', KEYS[1], 'status', 'cancelled', 'finished_at', ARGV[2])
+	redis.call('ZREM', KEYS[2], ARGV[1])
+elseif status == 'running' then
+	redis.call('HSET', KEYS[1], 'cancel_requested', '1')
+end
+return 1
+`)
+
+// Cancel cancels a queued job or flags a running one
+func (s *Store) Cancel(ctx context.Context, id string, now time.Time) (*jobs.Job, error) {
+	status, err := cancelScript.Run(ctx, s.client, []string{s.jobKey(id), s.queuedKey()}, id, unixNano(now)).Int()
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel job: %w", err)
+	}
+	if status < 0 {
+		return nil, jobs.ErrJobNotFound
+	}
+	return s.Get(ctx, id)
+}
+
+// AppendEvent pushes an event to the list of a job. Its sequence number is its position in the list.
+func (s *Store) AppendEvent(ctx context.Context, id string, event *jobs.Event) error {
+	exists, err := s.client.Exists(ctx, s.jobKey(id)).Result()
+	if err != nil {
+		return fmt.Errorf("failed to append job event: %w", err)
+	}
+	if exists == 0 {
+		return jobs.ErrJobNotFound
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job event: %w", err)
+	}
+	length, err := s.client.RPush(ctx, s.eventsKey(id), data).Result()
+	if err != nil {
+		return fmt.Errorf("failed to append job event: %w", err)
+	}
+	event.Seq = length
+	return nil
+}
+
+// Events returns the events of a job after a sequence number
+func (s *Store) Events(ctx context.Context, id string, after int64) ([]*jobs.Event, error) {
+	exists, err := s.client.Exists(ctx, s.jobKey(id)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job events: %w", err)
+	}
+	if exists == 0 {
+		return nil, jobs.ErrJobNotFound
+	}
+	if after < 0 {
+		after = 0
+	}
+	items, err := s.client.LRange(ctx, s.eventsKey(id), after, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job events: %w", err)
+	}
+	events := make([]*jobs.Event, 0, len(items))
+	for i, item := range items {
+		var event jobs.Event
+		if err := json.Unmarshal([]byte(item), &event); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal job event: %w", err)
+		}
+		event.Seq = after + int64(i) + 1
+		events = append(events, &event)
+	}
+	return events, nil
+}
+
+// leaseError converts the status of a lease script to an error
+func leaseError(status int) error {
+	switch {
+	case status < 0:
+		return jobs.ErrJobNotFound
+	case status == 0:
+		return jobs.ErrLeaseLost
+	default:
+		return nil
+	}
+}
+
+// marshalResult encodes a job result, or returns an empty string for a job without result
+func marshalResult(result *jobs.Result) (string, error) {
+	if result == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal job result: %w", err)
+	}
+	return string(data), nil
+}
+
+// marshalUsage encodes the usage of a job, or returns an empty string for a job without usage
+func marshalUsage(usage *llm.TokenUsage) (string, error) {
+	if usage == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(usage)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal job usage: %w", err)
+	}
+	return string(data), nil
+}
+
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func parseUnixNano(value string) time.Time {
+	n, _ := strconv.ParseInt(value, 10, 64)
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func parseFloat(value string) float64 {
+	f, _ := strconv.ParseFloat(value, 64)
+	return f
+}
+
+func atoi(value string) int {
+	n, _ := strconv.Atoi(value)
+	return n
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
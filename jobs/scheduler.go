@@ -0,0 +1,308 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/easyagent-dev/agent"
+)
+
+// OverlapPolicy decides what a schedule does when its previous job is unfinished at the next activation
+type OverlapPolicy string
+
+const (
+	// OverlapSkip skips the activation, the default
+	OverlapSkip OverlapPolicy = "skip"
+
+	// OverlapAllow submits the job anyway, letting runs overlap
+	OverlapAllow OverlapPolicy = "allow"
+
+	// OverlapReplace cancels the previous job and submits a new one
+	OverlapReplace OverlapPolicy = "replace"
+)
+
+// Budget limits the runs and spending of a schedule over a sliding window.
+// Spending is counted once jobs finish, from their usage and cost.
+type Budget struct {
+	// Window is the duration the limits apply to, the lifetime of the scheduler if zero
+	Window time.Duration
+
+	// MaxRuns is the number of jobs submitted in the window, unlimited if zero
+	MaxRuns int
+
+	// MaxTokens is the number of input and output tokens used in the window, unlimited if zero
+	MaxTokens int64
+
+	// MaxCost is the cost spent in the window, unlimited if zero
+	MaxCost float64
+}
+
+// Schedule submits a job running Request at every activation of a cron expression
+type Schedule struct {
+	// Name identifies the schedule. Job IDs are the name and the activation time, so schedulers
+	// sharing a store submit each activation once.
+	Name string
+
+	// Spec is the cron expression of the schedule, see ParseCron
+	Spec string
+
+	Request *Request
+
+	// Location is the time zone of Spec, UTC if nil
+	Location *time.Location
+
+	Overlap OverlapPolicy
+
+	// Jitter delays every submission by a random duration up to Jitter, spreading the load of
+	// schedules with the same activations
+	Jitter time.Duration
+
+	Budget *Budget
+
+	// MaxAttempts is the number of attempts of each job, 1 if zero
+	MaxAttempts int
+}
+
+// ScheduleErrorHandler receives the errors of schedule activations, including skipped ones
+// reported with ErrScheduleOverlap or ErrBudgetExceeded
+type ScheduleErrorHandler func(schedule string, activation time.Time, err error)
+
+// scheduledRun is a job submitted by a schedule
+type scheduledRun struct {
+	activation time.Time
+	jobID      string
+
+	// finished runs keep their spending, so they are not read again
+	finished bool
+	tokens   int64
+	cost     float64
+}
+
+// scheduleState is a schedule and the jobs it submitted
+type scheduleState struct {
+	schedule *Schedule
+	cron     *CronSchedule
+	runs     []*scheduledRun
+	lastJob  string
+}
+
+// Scheduler submits the jobs of schedules through a client.
+// Budgets and overlap checks only account for jobs submitted since the scheduler started.
+type Scheduler struct {
+	client       *Client
+	logger       agent.Logger
+	errorHandler ScheduleErrorHandler
+
+	mu        sync.Mutex
+	schedules []*scheduleState
+}
+
+// SchedulerOption is a functional option for configuring schedulers
+type SchedulerOption func(*Scheduler)
+
+// WithSchedulerLogger sets the logger receiving scheduler diagnostics
+func WithSchedulerLogger(logger agent.Logger) SchedulerOption {
+	return func(s *Scheduler) {
+		s.logger = logger
+	}
+}
+
+// WithScheduleErrorHandler sets the handler receiving the errors of schedule activations
+func WithScheduleErrorHandler(handler ScheduleErrorHandler) SchedulerOption {
+	return func(s *Scheduler) {
+		s.errorHandler = handler
+	}
+}
+
+// NewScheduler creates a scheduler submitting jobs with client
+func NewScheduler(client *Client, opts ...SchedulerOption) *Scheduler {
+	scheduler := &Scheduler{
+		client: client,
+		logger: agent.NoOpLogger{},
+	}
+	for _, opt := range opts {
+		opt(scheduler)
+	}
+	return scheduler
+}
+
+// Add validates and registers a schedule. Schedules must be added before Run is called.
+func (s *Scheduler) Add(schedule *Schedule) error {
+	if schedule.Name == "" {
+		return errors.New("schedule name is required")
+	}
+	if schedule.Request == nil || schedule.Request.Runner == "" {
+		return fmt.Errorf("schedule '%s' requires a request with a runner", schedule.Name)
+	}
+	if schedule.Jitter < 0 {
+		return fmt.Errorf("schedule '%s' jitter must not be negative", schedule.Name)
+	}
+	switch schedule.Overlap {
+	case "", OverlapSkip, OverlapAllow, OverlapReplace:
+	default:
+		return fmt.Errorf("schedule '%s' has unknown overlap policy '%s'", schedule.Name, schedule.Overlap)
+	}
+	cron, err := ParseCron(schedule.Spec)
+	if err != nil {
+		return fmt.Errorf("schedule '%s': %w", schedule.Name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.schedules {
+		if existing.schedule.Name == schedule.Name {
+			return fmt.Errorf("schedule '%s' already exists", schedule.Name)
+		}
+	}
+	s.schedules = append(s.schedules, &scheduleState{schedule: schedule, cron: cron})
+	return nil
+}
+
+// Run submits the jobs of the schedules until ctx is done
+func (s *Scheduler) Run(ctx context.Context) error {
+	s.mu.Lock()
+	schedules := s.schedules
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, state := range schedules {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.loop(ctx, state)
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// loop waits for the activations of a schedule and submits their jobs.
+// Activations missed while a submission was delayed are skipped.
+func (s *Scheduler) loop(ctx context.Context, state *scheduleState) {
+	loc := state.schedule.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	activation := state.cron.Next(time.Now().In(loc))
+	for !activation.IsZero() {
+		delay := time.Until(activation)
+		if state.schedule.Jitter > 0 {
+			delay += rand.N(state.schedule.Jitter)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		if err := s.activate(ctx, state, activation); err != nil && ctx.Err() == nil {
+			s.logger.Warn("scheduled job not submitted", "schedule", state.schedule.Name, "activation", activation, "error", err)
+			if s.errorHandler != nil {
+				s.errorHandler(state.schedule.Name, activation, err)
+			}
+		}
+
+		next := state.cron.Next(activation)
+		if now := time.Now(); next.Before(now) {
+			next = state.cron.Next(now.In(loc))
+		}
+		activation = next
+	}
+}
+
+// activate submits the job of an activation unless the overlap policy or budget prevents it
+func (s *Scheduler) activate(ctx context.Context, state *scheduleState, activation time.Time) error {
+	schedule := state.schedule
+	if err := s.checkBudget(ctx, state, activation); err != nil {
+		return err
+	}
+
+	if state.lastJob != "" && schedule.Overlap != OverlapAllow {
+		previous, err := s.client.Get(ctx, state.lastJob)
+		switch {
+		case errors.Is(err, ErrJobNotFound):
+		case err != nil:
+			return fmt.Errorf("failed to get previous job: %w", err)
+		case previous.Status.Terminal():
+		case schedule.Overlap == OverlapReplace:
+			if _, err := s.client.Cancel(ctx, previous.ID); err != nil {
+				return fmt.Errorf("failed to cancel previous job: %w", err)
+			}
+		default:
+			return fmt.Errorf("%w: job '%s' is %s", ErrScheduleOverlap, previous.ID, previous.Status)
+		}
+	}
+
+	maxAttempts := schedule.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = 1
+	}
+	id := schedule.Name + "@" + activation.UTC().Format(time.RFC3339)
+	_, err := s.client.Submit(ctx, schedule.Request, WithJobID(id), WithMaxAttempts(maxAttempts))
+	// Another scheduler sharing the store submitted this activation already
+	if err != nil && !errors.Is(err, ErrJobExists) {
+		return err
+	}
+	state.lastJob = id
+	if schedule.Budget != nil {
+		state.runs = append(state.runs, &scheduledRun{activation: activation, jobID: id})
+	}
+	return nil
+}
+
+// checkBudget drops the runs outside the budget window and reports whether the remaining ones spent the budget
+func (s *Scheduler) checkBudget(ctx context.Context, state *scheduleState, activation time.Time) error {
+	budget := state.schedule.Budget
+	if budget == nil {
+		return nil
+	}
+	if budget.Window > 0 {
+		start := activation.Add(-budget.Window)
+		kept := state.runs[:0]
+		for _, run := range state.runs {
+			if run.activation.After(start) {
+				kept = append(kept, run)
+			}
+		}
+		state.runs = kept
+	}
+
+	if budget.MaxRuns > 0 && len(state.runs) >= budget.MaxRuns {
+		return fmt.Errorf("%w: %d runs", ErrBudgetExceeded, len(state.runs))
+	}
+	if budget.MaxTokens <= 0 && budget.MaxCost <= 0 {
+		return nil
+	}
+
+	var tokens int64
+	var cost float64
+	for _, run := range state.runs {
+		if !run.finished {
+			job, err := s.client.Get(ctx, run.jobID)
+			if err != nil && !errors.Is(err, ErrJobNotFound) {
+				return fmt.Errorf("failed to get scheduled job: %w", err)
+			}
+			if err == nil && job.Status.Terminal() {
+				run.finished = true
+				// Failed attempts without a result are paid for too
+				if job.Usage != nil {
+					run.tokens = job.Usage.TotalInputTokens + job.Usage.TotalOutputTokens
+				}
+				run.cost = job.Cost
+			}
+		}
+		tokens += run.tokens
+		cost += run.cost
+	}
+	if budget.MaxTokens > 0 && tokens >= budget.MaxTokens {
+		return fmt.Errorf("%w: %d tokens", ErrBudgetExceeded, tokens)
+	}
+	if budget.MaxCost > 0 && cost >= budget.MaxCost {
+		return fmt.Errorf("%w: cost %.4f", ErrBudgetExceeded, cost)
+	}
+	return nil
+}
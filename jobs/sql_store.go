@@ -0,0 +1,403 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// Placeholder returns the bind parameter of the n-th query argument, starting at 1
+type Placeholder func(n int) string
+
+// QuestionPlaceholder binds arguments with ?, as SQLite and MySQL do
+func QuestionPlaceholder(n int) string {
+	return "?"
+}
+
+// DollarPlaceholder binds arguments with $1, $2..., as PostgreSQL does
+func DollarPlaceholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// SQLStore keeps jobs in a SQL database through database/sql, so any driver can be used.
+// Times are stored as Unix nanoseconds and JSON documents as text, keeping the schema portable.
+// It is safe for concurrent use by multiple goroutines and processes.
+type SQLStore struct {
+	db          *sql.DB
+	placeholder Placeholder
+	jobs        string
+	events      string
+}
+
+var _ Store = (*SQLStore)(nil)
+
+// SQLOption is a functional option for configuring SQL stores
+type SQLOption func(*SQLStore)
+
+// WithTablePrefix sets the prefix of the table names, "agent_" by default
+func WithTablePrefix(prefix string) SQLOption {
+	return func(s *SQLStore) {
+		s.jobs = prefix + "jobs"
+		s.events = prefix + "job_events"
+	}
+}
+
+// NewSQLStore creates a store using db, binding query arguments with placeholder
+func NewSQLStore(db *sql.DB, placeholder Placeholder, opts ...SQLOption) *SQLStore {
+	store := &SQLStore{
+		db:          db,
+		placeholder: placeholder,
+	}
+	WithTablePrefix("agent_")(store)
+	for _, opt := range opts {
+		opt(store)
+	}
+	return store
+}
+
+// Migrate creates the tables of the store if they do not exist.
+// The statements are tested with PostgreSQL and SQLite.
+func (s *SQLStore) Migrate(ctx context.Context) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS ` + s.jobs + ` (
+			id VARCHAR(128) PRIMARY KEY,
+			request TEXT NOT NULL,
+			status VARCHAR(16) NOT NULL,
+			result TEXT,
+			token_usage TEXT,
+			cost DOUBLE PRECISION NOT NULL,
+			error TEXT NOT NULL,
+			attempts INTEGER NOT NULL,
+			max_attempts INTEGER NOT NULL,
+			cancel_requested INTEGER NOT NULL,
+			lease_owner VARCHAR(128) NOT NULL,
+			lease_expires_at BIGINT NOT NULL,
+			created_at BIGINT NOT NULL,
+			started_at BIGINT NOT NULL,
+			finished_at BIGINT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS ` + s.jobs + `_status ON ` + s.jobs + ` (status, created_at)`,
+		`CREATE TABLE IF NOT EXISTS ` + s.events + ` (
+			job_id VARCHAR(128) NOT NULL,
+			seq BIGINT NOT NULL,
+			attempt INTEGER NOT NULL,
+			time BIGINT NOT NULL,
+			event TEXT NOT NULL,
+			PRIMARY KEY (job_id, seq)
+		)`,
+	}
+	for _, statement := range statements {
+		if _, err := s.db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to migrate job tables: %w", err)
+		}
+	}
+	return nil
+}
+
+// rebind replaces the ? placeholders of query with the store's placeholders
+func (s *SQLStore) rebind(query string) string {
+	var builder strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			builder.WriteString(s.placeholder(n))
+			continue
+		}
+		builder.WriteRune(c)
+	}
+	return builder.String()
+}
+
+const jobColumns = `id, request, status, result, token_usage, cost, error, attempts, max_attempts, cancel_requested,
+	lease_owner, lease_expires_at, created_at, started_at, finished_at`
+
+// Create inserts a new job. The primary key rejects concurrent creations of the same job,
+// whatever error the driver reports for the violation.
+func (s *SQLStore) Create(ctx context.Context, job *Job) error {
+	request, err := json.Marshal(job.Request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job request: %w", err)
+	}
+	result, err := marshalResult(job.Result)
+	if err != nil {
+		return err
+	}
+	usage, err := marshalUsage(job.Usage)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, s.rebind(`INSERT INTO `+s.jobs+` (`+jobColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		job.ID, string(request), string(job.Status), result, usage, job.Cost, job.Error, job.Attempts, job.MaxAttempts,
+		boolInt(job.CancelRequested), job.LeaseOwner, unixNano(job.LeaseExpiresAt),
+		unixNano(job.CreatedAt), unixNano(job.StartedAt), unixNano(job.FinishedAt))
+	if err != nil {
+		// Drivers report unique violations differently, a job existing after a failed insert is one
+		if _, getErr := s.Get(ctx, job.ID); getErr == nil {
+			return ErrJobExists
+		}
+		return fmt.Errorf("failed to insert job: %w", err)
+	}
+	return nil
+}
+
+// Get selects a job
+func (s *SQLStore) Get(ctx context.Context, id string) (*Job, error) {
+	row := s.db.QueryRowContext(ctx, s.rebind(`SELECT `+jobColumns+` FROM `+s.jobs+` WHERE id = ?`), id)
+	var (
+		job                                              Job
+		request, status                                  string
+		result, usage                                    sql.NullString
+		cancelRequested                                  int
+		leaseExpiresAt, createdAt, startedAt, finishedAt int64
+	)
+	err := row.Scan(&job.ID, &request, &status, &result, &usage, &job.Cost, &job.Error, &job.Attempts, &job.MaxAttempts,
+		&cancelRequested, &job.LeaseOwner, &leaseExpiresAt, &createdAt, &startedAt, &finishedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to select job: %w", err)
+	}
+	if err := json.Unmarshal([]byte(request), &job.Request); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job request: %w", err)
+	}
+	if result.Valid {
+		if err := json.Unmarshal([]byte(result.String), &job.Result); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal job result: %w", err)
+		}
+	}
+	if usage.Valid {
+		if err := json.Unmarshal([]byte(usage.String), &job.Usage); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal job usage: %w", err)
+		}
+	}
+	job.Status = Status(status)
+	job.CancelRequested = cancelRequested != 0
+	job.LeaseExpiresAt = fromUnixNano(leaseExpiresAt)
+	job.CreatedAt = fromUnixNano(createdAt)
+	job.StartedAt = fromUnixNano(startedAt)
+	job.FinishedAt = fromUnixNano(finishedAt)
+	return &job, nil
+}
+
+// Claim leases the oldest available job to worker. Concurrent claims of the same job are resolved
+// by a conditional update, so no locking clause specific to a database is needed.
+func (s *SQLStore) Claim(ctx context.Context, worker string, now time.Time, leaseUntil time.Time) (*Job, error) {
+	rows, err := s.db.QueryContext(ctx, s.rebind(`SELECT id FROM `+s.jobs+`
+		WHERE status = ? OR (status = ? AND lease_expires_at < ?)
+		ORDER BY created_at, id LIMIT 8`),
+		string(StatusQueued), string(StatusRunning), unixNano(now))
+	if err != nil {
+		return nil, fmt.Errorf("failed to select claimable jobs: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to select claimable jobs: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to select claimable jobs: %w", err)
+	}
+
+	for _, id := range ids {
+		result, err := s.db.ExecContext(ctx, s.rebind(`UPDATE `+s.jobs+`
+			SET status = ?, lease_owner = ?, lease_expires_at = ?, attempts = attempts + 1, started_at = ?
+			WHERE id = ? AND (status = ? OR (status = ? AND lease_expires_at < ?))`),
+			string(StatusRunning), worker, unixNano(leaseUntil), unixNano(now),
+			id, string(StatusQueued), string(StatusRunning), unixNano(now))
+		if err != nil {
+			return nil, fmt.Errorf("failed to claim job: %w", err)
+		}
+		if claimed, _ := result.RowsAffected(); claimed == 1 {
+			return s.Get(ctx, id)
+		}
+		// Another worker claimed the job meanwhile
+	}
+	return nil, nil
+}
+
+// Renew extends the lease of a job held by worker
+func (s *SQLStore) Renew(ctx context.Context, id string, worker string, leaseUntil time.Time) (*Job, error) {
+	result, err := s.db.ExecContext(ctx, s.rebind(`UPDATE `+s.jobs+` SET lease_expires_at = ?
+		WHERE id = ? AND status = ? AND lease_owner = ?`),
+		unixNano(leaseUntil), id, string(StatusRunning), worker)
+	if err != nil {
+		return nil, fmt.Errorf("failed to renew job lease: %w", err)
+	}
+	if err := s.checkLease(ctx, id, result); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, id)
+}
+
+// Finish stores the outcome of a job held by worker
+func (s *SQLStore) Finish(ctx context.Context, job *Job, worker string) error {
+	resultJSON, err := marshalResult(job.Result)
+	if err != nil {
+		return err
+	}
+	usage, err := marshalUsage(job.Usage)
+	if err != nil {
+		return err
+	}
+	result, err := s.db.ExecContext(ctx, s.rebind(`UPDATE `+s.jobs+`
+		SET status = ?, attempts = ?, result = ?, token_usage = ?, cost = ?, error = ?, finished_at = ?,
+			cancel_requested = 0, lease_owner = '', lease_expires_at = 0
+		WHERE id = ? AND status = ? AND lease_owner = ?`),
+		string(job.Status), job.Attempts, resultJSON, usage, job.Cost, job.Error, unixNano(job.FinishedAt),
+		job.ID, string(StatusRunning), worker)
+	if err != nil {
+		return fmt.Errorf("failed to finish job: %w", err)
+	}
+	return s.checkLease(ctx, job.ID, result)
+}
+
+// checkLease returns ErrLeaseLost or ErrJobNotFound if a conditional update of a leased job did nothing
+func (s *SQLStore) checkLease(ctx context.Context, id string, result sql.Result) error {
+	if updated, err := result.RowsAffected(); err != nil || updated == 1 {
+		return err
+	}
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+	return ErrLeaseLost
+}
+
+// Cancel cancels a queued job or flags a running one
+func (s *SQLStore) Cancel(ctx context.Context, id string, now time.Time) (*Job, error) {
+	_, err := s.db.ExecContext(ctx, s.rebind(`UPDATE `+s.jobs+` SET status = ?, finished_at = ? WHERE id = ? AND status = ?`),
+		string(StatusCancelled), unixNano(now), id, string(StatusQueued))
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel job: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx, s.rebind(`UPDATE `+s.jobs+` SET cancel_requested = 1 WHERE id = ? AND status = ?`),
+		id, string(StatusRunning))
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel job: %w", err)
+	}
+	return s.Get(ctx, id)
+}
+
+// AppendEvent inserts an event of a job. Only the worker holding the job appends events,
+// so the next sequence number is read in the same transaction without locking.
+func (s *SQLStore) AppendEvent(ctx context.Context, id string, event *Event) error {
+	data, err := json.Marshal(event.Event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job event: %w", err)
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to append job event: %w", err)
+	}
+	defer tx.Rollback()
+
+	var seq int64
+	err = tx.QueryRowContext(ctx, s.rebind(`SELECT COALESCE(MAX(seq), 0) FROM `+s.events+` WHERE job_id = ?`), id).Scan(&seq)
+	if err != nil {
+		return fmt.Errorf("failed to append job event: %w", err)
+	}
+	seq++
+	_, err = tx.ExecContext(ctx, s.rebind(`INSERT INTO `+s.events+` (job_id, seq, attempt, time, event) VALUES (?, ?, ?, ?, ?)`),
+		id, seq, event.Attempt, unixNano(event.Time), string(data))
+	if err != nil {
+		return fmt.Errorf("failed to append job event: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to append job event: %w", err)
+	}
+	event.Seq = seq
+	return nil
+}
+
+// Events selects the events of a job after a sequence number
+func (s *SQLStore) Events(ctx context.Context, id string, after int64) ([]*Event, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, s.rebind(`SELECT seq, attempt, time, event FROM `+s.events+`
+		WHERE job_id = ? AND seq > ? ORDER BY seq`), id, after)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select job events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*Event
+	for rows.Next() {
+		var (
+			event Event
+			at    int64
+			data  string
+		)
+		if err := rows.Scan(&event.Seq, &event.Attempt, &at, &data); err != nil {
+			return nil, fmt.Errorf("failed to select job events: %w", err)
+		}
+		if err := json.Unmarshal([]byte(data), &event.Event); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal job event: %w", err)
+		}
+		event.Time = fromUnixNano(at)
+		events = append(events, &event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to select job events: %w", err)
+	}
+	return events, nil
+}
+
+// marshalResult encodes a job result, or returns nil for a job without result
+func marshalResult(result *Result) (any, error) {
+	if result == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal job result: %w", err)
+	}
+	return string(data), nil
+}
+
+// marshalUsage encodes the usage of a job, or returns nil for a job without usage
+func marshalUsage(usage *llm.TokenUsage) (any, error) {
+	if usage == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(usage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal job usage: %w", err)
+	}
+	return string(data), nil
+}
+
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func fromUnixNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
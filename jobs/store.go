@@ -0,0 +1,207 @@
+package jobs
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Store persists jobs and their events.
+// Claiming, renewing and finishing must be atomic, so several workers can share a store.
+type Store interface {
+	// Create stores a new job, or returns ErrJobExists
+	Create(ctx context.Context, job *Job) error
+
+	// Get returns a job, or ErrJobNotFound
+	Get(ctx context.Context, id string) (*Job, error)
+
+	// Claim leases the oldest queued job, or a running job whose lease expired before now, to worker
+	// until leaseUntil, marking it running and incrementing its attempts. It returns nil if no job is available.
+	Claim(ctx context.Context, worker string, now time.Time, leaseUntil time.Time) (*Job, error)
+
+	// Renew extends the lease of a job held by worker and returns the job,
+	// so the worker sees cancellation requests. It returns ErrLeaseLost if worker no longer holds the lease.
+	Renew(ctx context.Context, id string, worker string, leaseUntil time.Time) (*Job, error)
+
+	// Finish stores the status, attempts, result, error and finish time of a job held by worker and releases its lease.
+	// A job finished with StatusQueued is retried. It returns ErrLeaseLost if worker no longer holds the lease.
+	Finish(ctx context.Context, job *Job, worker string) error
+
+	// Cancel cancels a queued job, or requests the cancellation of a running job from its worker.
+	// Finished jobs are left unchanged. It returns the updated job.
+	Cancel(ctx context.Context, id string, now time.Time) (*Job, error)
+
+	// AppendEvent stores an event of a job, assigning its sequence number
+	AppendEvent(ctx context.Context, id string, event *Event) error
+
+	// Events returns the events of a job with a sequence number greater than after, in order
+	Events(ctx context.Context, id string, after int64) ([]*Event, error)
+}
+
+// MemoryStore keeps jobs in memory, for tests and single-process services.
+// It is safe for concurrent use by multiple goroutines.
+type MemoryStore struct {
+	mu     sync.Mutex
+	jobs   map[string]*Job
+	events map[string][]*Event
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore creates a new in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		jobs:   make(map[string]*Job),
+		events: make(map[string][]*Event),
+	}
+}
+
+// Create stores a copy of the job
+func (s *MemoryStore) Create(ctx context.Context, job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.jobs[job.ID]; exists {
+		return ErrJobExists
+	}
+	s.jobs[job.ID] = job.clone()
+	return nil
+}
+
+// Get returns a copy of the job
+func (s *MemoryStore) Get(ctx context.Context, id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return job.clone(), nil
+}
+
+// Claim leases the oldest available job to worker
+func (s *MemoryStore) Claim(ctx context.Context, worker string, now time.Time, leaseUntil time.Time) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var candidates []*Job
+	for _, job := range s.jobs {
+		if claimable(job, now) {
+			candidates = append(candidates, job)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	})
+	job := candidates[0]
+	job.Status = StatusRunning
+	job.LeaseOwner = worker
+	job.LeaseExpiresAt = leaseUntil
+	job.Attempts++
+	job.StartedAt = now
+	return job.clone(), nil
+}
+
+// claimable reports whether a job is queued or its lease expired before now
+func claimable(job *Job, now time.Time) bool {
+	return job.Status == StatusQueued || (job.Status == StatusRunning && job.LeaseExpiresAt.Before(now))
+}
+
+// Renew extends the lease of a job held by worker
+func (s *MemoryStore) Renew(ctx context.Context, id string, worker string, leaseUntil time.Time) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	if job.Status != StatusRunning || job.LeaseOwner != worker {
+		return nil, ErrLeaseLost
+	}
+	job.LeaseExpiresAt = leaseUntil
+	return job.clone(), nil
+}
+
+// Finish stores the outcome of a job held by worker
+func (s *MemoryStore) Finish(ctx context.Context, job *Job, worker string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.jobs[job.ID]
+	if !ok {
+		return ErrJobNotFound
+	}
+	if stored.Status != StatusRunning || stored.LeaseOwner != worker {
+		return ErrLeaseLost
+	}
+	stored.Status = job.Status
+	stored.Attempts = job.Attempts
+	stored.Result = job.Result
+	stored.Usage = job.Usage
+	stored.Cost = job.Cost
+	stored.Error = job.Error
+	stored.FinishedAt = job.FinishedAt
+	stored.CancelRequested = false
+	stored.LeaseOwner = ""
+	stored.LeaseExpiresAt = time.Time{}
+	return nil
+}
+
+// Cancel cancels a queued job or flags a running one
+func (s *MemoryStore) Cancel(ctx context.Context, id string, now time.Time) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	switch job.Status {
+	case StatusQueued:
+		job.Status = StatusCancelled
+		job.FinishedAt = now
+	case StatusRunning:
+		job.CancelRequested = true
+	}
+	return job.clone(), nil
+}
+
+// AppendEvent stores an event of a job
+func (s *MemoryStore) AppendEvent(ctx context.Context, id string, event *Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[id]; !ok {
+		return ErrJobNotFound
+	}
+	event.Seq = int64(len(s.events[id]) + 1)
+	copied := *event
+	s.events[id] = append(s.events[id], &copied)
+	return nil
+}
+
+// Events returns the events of a job after a sequence number
+func (s *MemoryStore) Events(ctx context.Context, id string, after int64) ([]*Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[id]; !ok {
+		return nil, ErrJobNotFound
+	}
+	events := s.events[id]
+	if after < 0 {
+		after = 0
+	}
+	if after >= int64(len(events)) {
+		return nil, nil
+	}
+	result := make([]*Event, 0, int64(len(events))-after)
+	for _, event := range events[after:] {
+		copied := *event
+		result = append(result, &copied)
+	}
+	return result, nil
+}
@@ -0,0 +1,306 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/easyagent-dev/agent"
+	"github.com/google/uuid"
+)
+
+// DefaultLeaseDuration is how long a job stays leased to a worker without renewal
+const DefaultLeaseDuration = 30 * time.Second
+
+// PrepareFunc restores the parts of an agent request that are not persisted, such as the
+// output schema, request tools and credentials, before a worker runs the job
+type PrepareFunc func(ctx context.Context, job *Job, req *agent.AgentRequest) error
+
+// EventFilter selects the events of a run persisted by workers
+type EventFilter func(event agent.AgentEvent) bool
+
+// completeEvents persists every event except partial ones, which are superseded by later events,
+// and ephemeral ones
+func completeEvents(event agent.AgentEvent) bool {
+	return !event.Partial && !event.Ephemeral
+}
+
+// Worker claims jobs from a store and runs them with registered stream runners
+type Worker struct {
+	store   Store
+	id      string
+	runners map[string]agent.StreamRunner
+
+	concurrency   int
+	pollInterval  time.Duration
+	leaseDuration time.Duration
+	prepare       PrepareFunc
+	filter        EventFilter
+	logger        agent.Logger
+}
+
+// WorkerOption is a functional option for configuring workers
+type WorkerOption func(*Worker)
+
+// WithWorkerID sets the ID identifying the worker in job leases, a random one by default
+func WithWorkerID(id string) WorkerOption {
+	return func(w *Worker) {
+		w.id = id
+	}
+}
+
+// WithConcurrency sets how many jobs the worker runs at the same time, 1 by default
+func WithConcurrency(n int) WorkerOption {
+	return func(w *Worker) {
+		w.concurrency = n
+	}
+}
+
+// WithPollInterval sets how often an idle worker polls the store, DefaultPollInterval by default
+func WithPollInterval(interval time.Duration) WorkerOption {
+	return func(w *Worker) {
+		w.pollInterval = interval
+	}
+}
+
+// WithLeaseDuration sets how long jobs stay leased without renewal, DefaultLeaseDuration by default.
+// Leases are renewed at a third of this duration, which is also the delay before a cancellation is noticed.
+func WithLeaseDuration(d time.Duration) WorkerOption {
+	return func(w *Worker) {
+		w.leaseDuration = d
+	}
+}
+
+// WithPrepare sets the function restoring the request of a job before it runs
+func WithPrepare(prepare PrepareFunc) WorkerOption {
+	return func(w *Worker) {
+		w.prepare = prepare
+	}
+}
+
+// WithEventFilter sets which events are persisted, all but partial and ephemeral events by default
+func WithEventFilter(filter EventFilter) WorkerOption {
+	return func(w *Worker) {
+		w.filter = filter
+	}
+}
+
+// WithLogger sets the logger receiving worker diagnostics
+func WithLogger(logger agent.Logger) WorkerOption {
+	return func(w *Worker) {
+		w.logger = logger
+	}
+}
+
+// NewWorker creates a worker for the jobs of store
+func NewWorker(store Store, opts ...WorkerOption) *Worker {
+	worker := &Worker{
+		store:         store,
+		id:            uuid.New().String(),
+		runners:       make(map[string]agent.StreamRunner),
+		concurrency:   1,
+		pollInterval:  DefaultPollInterval,
+		leaseDuration: DefaultLeaseDuration,
+		filter:        completeEvents,
+		logger:        agent.NoOpLogger{},
+	}
+	for _, opt := range opts {
+		opt(worker)
+	}
+	return worker
+}
+
+// Register makes runner available to jobs whose request names it.
+// Runners must be registered before Run is called.
+func (w *Worker) Register(name string, runner agent.StreamRunner) {
+	w.runners[name] = runner
+}
+
+// Run claims and runs jobs until ctx is done. Jobs running when ctx is done are queued again.
+func (w *Worker) Run(ctx context.Context) error {
+	if w.concurrency <= 0 {
+		return errors.New("worker concurrency must be positive")
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < w.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx)
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// loop runs claimed jobs one at a time, polling when none is available
+func (w *Worker) loop(ctx context.Context) {
+	for ctx.Err() == nil {
+		now := time.Now()
+		job, err := w.store.Claim(ctx, w.id, now, now.Add(w.leaseDuration))
+		if err != nil && ctx.Err() == nil {
+			w.logger.Error("failed to claim job", "worker", w.id, "error", err)
+		}
+		if job == nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(w.pollInterval):
+			}
+			continue
+		}
+		w.process(ctx, job)
+	}
+}
+
+// process runs a claimed job and stores its outcome
+func (w *Worker) process(ctx context.Context, job *Job) {
+	if job.Attempts > job.MaxAttempts {
+		// The lease of the last attempt expired, its worker probably died
+		job.Status = StatusFailed
+		job.Error = fmt.Sprintf("job abandoned after %d attempts: %s", job.MaxAttempts, job.Error)
+		w.finish(job)
+		return
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cancelled := make(chan struct{})
+	leaseLost := make(chan struct{})
+	go w.renew(runCtx, cancel, job.ID, cancelled, leaseLost)
+
+	resp, usage, err := w.runJob(runCtx, job)
+
+	// Stop the renewal before reading its outcome
+	cancel()
+	select {
+	case <-leaseLost:
+		w.logger.Warn("job lease lost, discarding the outcome", "worker", w.id, "job", job.ID)
+		return
+	default:
+	}
+
+	switch {
+	case isClosed(cancelled):
+		job.Status = StatusCancelled
+		job.Error = "cancelled"
+	case err == nil:
+		job.Status = StatusSucceeded
+		job.Error = ""
+	case ctx.Err() != nil:
+		// The worker is shutting down, let another worker resume the job without counting this attempt
+		job.Status = StatusQueued
+		job.Attempts--
+	case job.Attempts < job.MaxAttempts:
+		job.Status = StatusQueued
+		job.Error = err.Error()
+	default:
+		job.Status = StatusFailed
+		job.Error = err.Error()
+	}
+	if resp != nil {
+		job.Result = newResult(resp)
+		// Runners reporting no usage events still report the totals in their response
+		if usage == nil {
+			usage = &agent.UsageReport{}
+			if resp.Usage != nil {
+				usage.TotalUsage = *resp.Usage
+			}
+			if resp.Cost != nil {
+				usage.TotalCost = *resp.Cost
+			}
+		}
+	}
+	job.addUsage(usage)
+	w.finish(job)
+}
+
+// runJob runs the request of a job, persisting its events. It returns the usage totals of
+// the last usage event, so the spend of failed attempts is known.
+func (w *Worker) runJob(ctx context.Context, job *Job) (*agent.AgentResponse, *agent.UsageReport, error) {
+	runner, ok := w.runners[job.Request.Runner]
+	if !ok {
+		return nil, nil, fmt.Errorf("no runner named '%s'", job.Request.Runner)
+	}
+	req := job.Request.agentRequest(job.ID)
+	if w.prepare != nil {
+		if err := w.prepare(ctx, job, req); err != nil {
+			return nil, nil, fmt.Errorf("failed to prepare request: %w", err)
+		}
+	}
+
+	stream, err := runner.Run(ctx, req, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	var usage *agent.UsageReport
+	for event := range stream.Events() {
+		if event.Type == agent.AgentEventTypeUsage && event.Usage != nil {
+			usage = event.Usage
+		}
+		if !w.filter(event) {
+			continue
+		}
+		// Events of a cancelled run, such as its error, are still persisted
+		err := w.store.AppendEvent(context.WithoutCancel(ctx), job.ID, &Event{
+			Attempt: job.Attempts,
+			Time:    time.Now(),
+			Event:   event,
+		})
+		if err != nil {
+			w.logger.Warn("failed to persist job event", "worker", w.id, "job", job.ID, "error", err)
+		}
+	}
+	resp, err := stream.Wait()
+	return resp, usage, err
+}
+
+// renew extends the lease of a job until ctx is done, cancelling the run when the job is
+// cancelled or the lease is lost
+func (w *Worker) renew(ctx context.Context, cancel context.CancelFunc, id string, cancelled chan<- struct{}, leaseLost chan<- struct{}) {
+	ticker := time.NewTicker(w.leaseDuration / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		job, err := w.store.Renew(ctx, id, w.id, time.Now().Add(w.leaseDuration))
+		switch {
+		case errors.Is(err, ErrLeaseLost), errors.Is(err, ErrJobNotFound):
+			close(leaseLost)
+			cancel()
+			return
+		case err != nil:
+			if ctx.Err() == nil {
+				w.logger.Warn("failed to renew job lease", "worker", w.id, "job", id, "error", err)
+			}
+		case job.CancelRequested:
+			close(cancelled)
+			cancel()
+			return
+		}
+	}
+}
+
+// finish stores the outcome of a job, even if the worker is shutting down
+func (w *Worker) finish(job *Job) {
+	job.FinishedAt = time.Time{}
+	if job.Status.Terminal() {
+		job.FinishedAt = time.Now()
+	}
+	if err := w.store.Finish(context.Background(), job, w.id); err != nil {
+		w.logger.Error("failed to finish job", "worker", w.id, "job", job.ID, "status", string(job.Status), "error", err)
+	}
+}
+
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,99 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/easyagent-dev/agent"
+	"github.com/easyagent-dev/llm"
+)
+
+// failingModel streams an invalid tool call with its usage, then fails every later call
+type failingModel struct {
+	cost  float64
+	calls int
+}
+
+var _ llm.CompletionModel = (*failingModel)(nil)
+
+func (m *failingModel) Complete(ctx context.Context, req *llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	return nil, errors.New("completion not supported")
+}
+
+func (m *failingModel) StreamComplete(ctx context.Context, req *llm.CompletionRequest) (llm.StreamCompletionResponse, error) {
+	m.calls++
+	if m.calls > 1 {
+		return nil, errors.New("model unavailable")
+	}
+	stream := make(chan llm.StreamChunk, 2)
+	stream <- llm.StreamTextChunk{Text: `{"name": "search", "input": {`}
+	stream <- llm.StreamUsageChunk{
+		Usage: &llm.TokenUsage{TotalInputTokens: 100, TotalOutputTokens: 20},
+		Cost:  &m.cost,
+	}
+	close(stream)
+	return stream, nil
+}
+
+func TestFailedJobsRecordUsage(t *testing.T) {
+	ctx := context.Background()
+	model := &failingModel{cost: 0.25}
+	runner, err := agent.NewJSONCompletionStreamRunner(&agent.Agent{
+		Name:         "reporter",
+		Description:  "writes reports",
+		Instructions: "Write the report",
+	}, model)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewMemoryStore()
+	client := NewClient(store)
+	worker := NewWorker(store)
+	worker.Register("reporter", runner)
+
+	req := NewRequest("reporter", &agent.AgentRequest{
+		Messages:      []*llm.ModelMessage{{Role: llm.RoleUser, Content: "Write the weekly report"}},
+		MaxIterations: 5,
+		MaxRetries:    1,
+	})
+	submitted, err := client.Submit(ctx, req, WithMaxAttempts(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	claimed, err := store.Claim(ctx, worker.id, now, now.Add(time.Minute))
+	if err != nil || claimed == nil {
+		t.Fatalf("Claim() = %v, %v", claimed, err)
+	}
+	worker.process(ctx, claimed)
+
+	job, err := client.Get(ctx, submitted.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != StatusFailed {
+		t.Fatalf("Status = %s, want failed", job.Status)
+	}
+	if job.Result != nil {
+		t.Fatalf("Result = %+v, want nil", job.Result)
+	}
+	if job.Cost != 0.25 {
+		t.Errorf("Cost = %v, want 0.25", job.Cost)
+	}
+	if job.Usage == nil || job.Usage.TotalInputTokens != 100 || job.Usage.TotalOutputTokens != 20 {
+		t.Errorf("Usage = %+v, want 100 input and 20 output tokens", job.Usage)
+	}
+
+	// The spend of the failed job counts against the budget of its schedule
+	scheduler := NewScheduler(client)
+	state := &scheduleState{
+		schedule: &Schedule{Name: "weekly-report", Budget: &Budget{MaxCost: 0.2}},
+		runs:     []*scheduledRun{{activation: now, jobID: job.ID}},
+	}
+	if err := scheduler.checkBudget(ctx, state, now); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("checkBudget() error = %v, want ErrBudgetExceeded", err)
+	}
+}
@@ -0,0 +1,126 @@
+package agent
+
+import (
+	"context"
+	"time"
+
+	"github.com/easyagent-dev/llm"
+	"github.com/google/uuid"
+)
+
+// LifecycleEventType identifies a milestone of a run reported to event sinks
+type LifecycleEventType string
+
+const (
+	// LifecycleRunStarted is sent when a run starts
+	LifecycleRunStarted LifecycleEventType = "run.started"
+
+	// LifecycleToolCalled is sent after every tool execution, successful or not
+	LifecycleToolCalled LifecycleEventType = "tool.called"
+
+	// LifecycleRunCompleted is sent when a run completes its task
+	LifecycleRunCompleted LifecycleEventType = "run.completed"
+
+	// LifecycleRunFailed is sent when a run ends with an error
+	LifecycleRunFailed LifecycleEventType = "run.failed"
+)
+
+// LifecycleEvent is a milestone of a run, coarser than stream events and sent by every runner
+type LifecycleEvent struct {
+	// ID is unique per event, so receivers can drop duplicated deliveries
+	ID       string             `json:"id"`
+	Type     LifecycleEventType `json:"type"`
+	Time     time.Time          `json:"time"`
+	RunID    string             `json:"runId"`
+	Agent    string             `json:"agent"`
+	Metadata map[string]string  `json:"metadata,omitempty"`
+
+	// Version identifies the prompt and model configuration, nil before the first model call
+	Version *RunVersion `json:"version,omitempty"`
+
+	// Tool, Input and DurationMs are set for tool events
+	Tool       string `json:"tool,omitempty"`
+	Input      any    `json:"input,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+
+	// Output is set for completed runs
+	Output any `json:"output,omitempty"`
+
+	// Usage and Cost are set for finished runs
+	Usage *llm.TokenUsage `json:"usage,omitempty"`
+	Cost  *float64        `json:"cost,omitempty"`
+
+	// Error is set for failed runs and tool calls
+	Error string `json:"error,omitempty"`
+
+	// Validation is set for tool calls failing with a ValidationError
+	Validation *ValidationError `json:"validation,omitempty"`
+}
+
+// EventSink receives the lifecycle events of runs.
+// Send is called synchronously from the agent loop, so sinks doing I/O should queue the events.
+type EventSink interface {
+	Send(ctx context.Context, event *LifecycleEvent)
+}
+
+// EventSinkFunc adapts a function to the EventSink interface
+type EventSinkFunc func(ctx context.Context, event *LifecycleEvent)
+
+// Send calls the function
+func (f EventSinkFunc) Send(ctx context.Context, event *LifecycleEvent) {
+	f(ctx, event)
+}
+
+// WithEventSink adds a sink receiving the lifecycle events of runs
+func WithEventSink(sink EventSink) RunnerOption {
+	return func(c *runnerConfig) {
+		c.sinks = append(c.sinks, sink)
+	}
+}
+
+// StreamEventSink receives the events of streamed runs, including those a backpressure policy
+// drops for the consumer. SendEvent is called synchronously from the agent loop, so sinks doing
+// I/O should queue the events. The event must not be modified.
+type StreamEventSink interface {
+	SendEvent(event *AgentEvent)
+}
+
+// WithStreamEventSink adds a sink receiving the events of streamed runs
+func WithStreamEventSink(sink StreamEventSink) RunnerOption {
+	return func(c *runnerConfig) {
+		c.streamSinks = append(c.streamSinks, sink)
+	}
+}
+
+// notify sends a lifecycle event of run to the sinks of the runner
+func (r *BaseRunner) notify(ctx context.Context, run *agentRun, event *LifecycleEvent) {
+	if len(r.sinks) == 0 {
+		return
+	}
+	event.ID = uuid.New().String()
+	event.Time = time.Now()
+	event.RunID = run.agentContext.RunID
+	event.Agent = run.agent.Name
+	event.Metadata = run.req.Metadata
+	event.Version = run.version
+	for _, sink := range r.sinks {
+		sink.Send(ctx, event)
+	}
+}
+
+// notifyRunEnd sends the completed or failed event of a run
+func (r *BaseRunner) notifyRunEnd(ctx context.Context, run *agentRun, resp *AgentResponse, err error) {
+	event := &LifecycleEvent{Type: LifecycleRunCompleted}
+	if err != nil {
+		event.Type = LifecycleRunFailed
+		event.Error = err.Error()
+	}
+	if resp != nil {
+		if err == nil {
+			event.Output = resp.Output
+		}
+		event.Usage = resp.Usage
+		event.Cost = resp.Cost
+	}
+	r.notify(ctx, run, event)
+}
@@ -0,0 +1,248 @@
+package agent
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/easyagent-dev/llm"
+)
+
+//go:embed prompts/json_compact_system.md
+var jsonCompactSystemPrompt string
+
+//go:embed prompts/xml_compact_system.md
+var xmlCompactSystemPrompt string
+
+// WithLenientParsing makes the runner accept tool calls that do not follow the format exactly,
+// as small local models often write them: wrapped in code fences or prose, with trailing commas,
+// or in other common shapes such as {"tool":...,"arguments":...} or OpenAI function calls
+func WithLenientParsing(enabled bool) RunnerOption {
+	return func(c *runnerConfig) {
+		c.lenientParsing = enabled
+	}
+}
+
+// WithCompactPrompt uses a shorter default system prompt, leaving more of a small context window
+// to the conversation. It is ignored if WithSystemPrompt is set.
+func WithCompactPrompt(enabled bool) RunnerOption {
+	return func(c *runnerConfig) {
+		c.compactPrompt = enabled
+	}
+}
+
+// WithLocalModel configures the runner for local OpenAI-compatible endpoints such as Ollama or vLLM:
+// lenient parsing, the compact system prompt, and model calls priced at 0
+func WithLocalModel() RunnerOption {
+	return func(c *runnerConfig) {
+		c.lenientParsing = true
+		c.compactPrompt = true
+		c.pricing = freePricing{}
+	}
+}
+
+// freePricing prices every model at 0
+type freePricing struct{}
+
+// Price returns a zero price for any model
+func (freePricing) Price(provider, model string) (ModelPrice, bool) {
+	return ModelPrice{}, true
+}
+
+// errNoToolCall is returned by lenient parsing when the output holds nothing resembling a tool call
+var errNoToolCall = errors.New("no tool call found in output")
+
+// lenientToolCallFormat falls back to lenient parsing when the strict parsing of a format fails
+type lenientToolCallFormat struct {
+	toolCallFormat
+}
+
+func (f lenientToolCallFormat) parse(output string) (*llm.ToolCall, error) {
+	toolCall, err := f.toolCallFormat.parse(output)
+	if err == nil && toolCall.Name != "" {
+		return toolCall, nil
+	}
+	if lenient, lenientErr := parseLenientToolCall(output); lenientErr == nil {
+		return lenient, nil
+	}
+	if err == nil {
+		err = errors.New("tool call has no name")
+	}
+	return nil, err
+}
+
+func (f lenientToolCallFormat) newStreamParser() toolCallStreamParser {
+	return &lenientStreamParser{inner: f.toolCallFormat.newStreamParser()}
+}
+
+// lenientStreamParser streams the tool calls the strict parser understands. Once it fails, it stops
+// reporting tool calls, and the runner parses the full output leniently when the stream ends.
+type lenientStreamParser struct {
+	inner  toolCallStreamParser
+	buffer strings.Builder
+	failed bool
+}
+
+func (p *lenientStreamParser) Append(content string) {
+	p.buffer.WriteString(content)
+	if !p.failed {
+		p.inner.Append(content)
+	}
+}
+
+func (p *lenientStreamParser) release() {
+	p.inner.release()
+}
+
+func (p *lenientStreamParser) Parse() (*llm.ToolCall, bool, *string, error) {
+	if p.failed {
+		return nil, false, nil, nil
+	}
+	toolCall, completed, reasoning, err := p.inner.Parse()
+	if err != nil {
+		p.failed = true
+		return nil, false, reasoning, nil
+	}
+	if completed && (toolCall == nil || toolCall.Name == "") {
+		if lenient, err := parseLenientToolCall(p.buffer.String()); err == nil {
+			return lenient, true, reasoning, nil
+		}
+		p.failed = true
+		return nil, false, reasoning, nil
+	}
+	return toolCall, completed, reasoning, nil
+}
+
+// lenientXMLToolCall matches a <use-tool> tag, closed or not, with a quoted or bare name
+var lenientXMLToolCall = regexp.MustCompile(`(?s)<use-tool\s+name\s*=\s*["']?([\w.:-]+)["']?\s*/?>(.*?)(?:</use-tool>|$)`)
+
+// parseLenientToolCall finds a tool call in output written loosely
+func parseLenientToolCall(output string) (*llm.ToolCall, error) {
+	if m := lenientXMLToolCall.FindStringSubmatch(output); m != nil {
+		input := map[string]any{}
+		if body := strings.TrimSpace(m[2]); body != "" {
+			if err := decodeLenientJSON(body, &input); err != nil {
+				return nil, err
+			}
+		}
+		return &llm.ToolCall{Name: m[1], Input: input}, nil
+	}
+
+	var object map[string]any
+	if err := decodeLenientJSON(output, &object); err != nil {
+		return nil, err
+	}
+	return normalizeToolCall(object)
+}
+
+// decodeLenientJSON decodes the first JSON object of text into v, ignoring the text around it,
+// such as code fences, and trailing commas
+func decodeLenientJSON(text string, v any) error {
+	object := extractJSONObject(text)
+	if object == "" {
+		return errNoToolCall
+	}
+	return json.Unmarshal([]byte(removeTrailingCommas(object)), v)
+}
+
+// extractJSONObject returns the first balanced JSON object of text, or "" if there is none
+func extractJSONObject(text string) string {
+	start := strings.IndexByte(text, '{')
+	if start < 0 {
+		return ""
+	}
+	depth := 0
+	inString, escaped := false, false
+	for i := start; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return text[start : i+1]
+			}
+		}
+	}
+	return ""
+}
+
+// removeTrailingCommas removes the commas directly followed by a closing brace or bracket outside strings
+func removeTrailingCommas(object string) string {
+	var builder strings.Builder
+	inString, escaped := false, false
+	for i := 0; i < len(object); i++ {
+		c := object[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case !inString && c == ',':
+			next := strings.TrimLeft(object[i+1:], " \t\r\n")
+			if next != "" && (next[0] == '}' || next[0] == ']') {
+				continue
+			}
+		}
+		builder.WriteByte(c)
+	}
+	return builder.String()
+}
+
+// toolCallNameKeys and toolCallInputKeys are the keys models use for the tool name and input
+var (
+	toolCallNameKeys  = []string{"name", "tool", "tool_name", "function", "action"}
+	toolCallInputKeys = []string{"input", "arguments", "parameters", "args", "action_input"}
+)
+
+// normalizeToolCall reads a tool call from the common shapes models write them in
+func normalizeToolCall(object map[string]any) (*llm.ToolCall, error) {
+	if calls, ok := object["tool_calls"].([]any); ok && len(calls) > 0 {
+		if call, ok := calls[0].(map[string]any); ok {
+			object = call
+		}
+	}
+	if function, ok := object["function"].(map[string]any); ok {
+		object = function
+	}
+
+	name := ""
+	for _, key := range toolCallNameKeys {
+		if s, ok := object[key].(string); ok && s != "" {
+			name = s
+			break
+		}
+	}
+	if name == "" {
+		return nil, errNoToolCall
+	}
+
+	input := map[string]any{}
+	for _, key := range toolCallInputKeys {
+		switch v := object[key].(type) {
+		case map[string]any:
+			input = v
+		case string:
+			// OpenAI function calls encode their arguments as a JSON string
+			if err := decodeLenientJSON(v, &input); err != nil {
+				return nil, err
+			}
+		default:
+			continue
+		}
+		break
+	}
+	return &llm.ToolCall{Name: name, Input: input}, nil
+}
@@ -0,0 +1,305 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// FeedbackTemplates are the messages the runner writes to the model, localized so that English
+// feedback does not switch the model to English. Empty fields use DefaultFeedbackTemplates.
+type FeedbackTemplates struct {
+	// Respond asks the model to respond in a language, given the language name and the locale
+	Respond string
+
+	// OutputRejected sends a rejected output back to the model, given the feedback of the verdict
+	OutputRejected string
+
+	// Rejected is the feedback of rejections without one
+	Rejected string
+
+	// LanguageMismatch is the feedback of LanguageVerifier, given the language name
+	LanguageMismatch string
+
+	// ToolNotFound answers calls of unknown tools, given the tool name and the available tools
+	ToolNotFound string
+
+	// ToolDenied answers calls denied by the approver, given the tool name and the reason
+	ToolDenied string
+
+	// ToolFailed answers calls failing with a retryable ToolError, given the tool name and the message
+	ToolFailed string
+
+	// ToolFailedPermanently answers calls failing with a non-retryable ToolError, given the tool name
+	// and the message
+	ToolFailedPermanently string
+
+	// InvalidInput answers calls with an invalid input, given the tool name and the ValidationError as JSON
+	InvalidInput string
+
+	// ToolLimitReached answers calls of a tool past its MaxCalls, given the tool name and the limit
+	ToolLimitReached string
+}
+
+// DefaultFeedbackTemplates are the English feedback templates
+var DefaultFeedbackTemplates = FeedbackTemplates{
+	Respond:          "Respond in %s (%s), whatever the language of the instructions, tool results and documents.",
+	OutputRejected:   "Your output was reviewed and not accepted: %s\n\nPlease revise it and complete the task again.",
+	Rejected:         "the output was rejected",
+	LanguageMismatch: "the output is not written in %s",
+	ToolNotFound:     "Tool '%s' not found.\n\nAvailable tools: %v\n\nPlease use one of the available tools.",
+	ToolDenied:       "Tool call '%s' was not approved: %s\n\nPlease continue without it or try a different approach.",
+	ToolFailed:       "Tool '%s' failed: %s\n\nYou may retry the call, correcting its input if needed.",
+	ToolFailedPermanently: "Tool '%s' failed: %s\n\nThis failure is permanent, do not retry the same call. " +
+		"Please continue without it or try a different approach.",
+	InvalidInput: "Tool '%s' was called with an invalid input. Fix these fields and call it again:\n\n%s",
+	ToolLimitReached: "Tool '%s' reached its limit of %d calls in this run and cannot be called again.\n\n" +
+		"Please continue with the information you already have or try a different approach.",
+}
+
+// WithFeedbackTemplates sets the feedback templates of runs whose AgentRequest.Locale is locale,
+// or a regional variant of it when locale is a language, e.g. "fr" for "fr-CA"
+func WithFeedbackTemplates(locale string, templates FeedbackTemplates) RunnerOption {
+	return func(c *runnerConfig) {
+		if c.feedback == nil {
+			c.feedback = make(map[string]*FeedbackTemplates)
+		}
+		c.feedback[strings.ToLower(locale)] = &templates
+	}
+}
+
+// feedbackTemplates returns the templates of locale: those of the exact locale, then of its language,
+// completed with the defaults
+func (r *BaseRunner) feedbackTemplates(locale string) *FeedbackTemplates {
+	templates := DefaultFeedbackTemplates
+	localized, ok := r.feedback[strings.ToLower(locale)]
+	if !ok {
+		localized, ok = r.feedback[baseLanguage(locale)]
+	}
+	if !ok {
+		return &templates
+	}
+	if localized.Respond != "" {
+		templates.Respond = localized.Respond
+	}
+	if localized.OutputRejected != "" {
+		templates.OutputRejected = localized.OutputRejected
+	}
+	if localized.Rejected != "" {
+		templates.Rejected = localized.Rejected
+	}
+	if localized.LanguageMismatch != "" {
+		templates.LanguageMismatch = localized.LanguageMismatch
+	}
+	if localized.ToolNotFound != "" {
+		templates.ToolNotFound = localized.ToolNotFound
+	}
+	if localized.ToolDenied != "" {
+		templates.ToolDenied = localized.ToolDenied
+	}
+	if localized.ToolFailed != "" {
+		templates.ToolFailed = localized.ToolFailed
+	}
+	if localized.ToolFailedPermanently != "" {
+		templates.ToolFailedPermanently = localized.ToolFailedPermanently
+	}
+	if localized.InvalidInput != "" {
+		templates.InvalidInput = localized.InvalidInput
+	}
+	if localized.ToolLimitReached != "" {
+		templates.ToolLimitReached = localized.ToolLimitReached
+	}
+	return &templates
+}
+
+// languagePrompt returns the language section of the system prompt, empty without locale
+func (t *FeedbackTemplates) languagePrompt(locale string) string {
+	if locale == "" {
+		return ""
+	}
+	return "\n\n<language>\n    " + fmt.Sprintf(t.Respond, LanguageName(locale), locale) + "\n</language>"
+}
+
+// language is a language known by LanguageName and LanguageVerifier
+type language struct {
+	name    string
+	scripts []*unicode.RangeTable
+}
+
+var (
+	latin      = []*unicode.RangeTable{unicode.Latin}
+	cyrillic   = []*unicode.RangeTable{unicode.Cyrillic}
+	arabic     = []*unicode.RangeTable{unicode.Arabic}
+	devanagari = []*unicode.RangeTable{unicode.Devanagari}
+)
+
+// languages are the known languages by ISO 639-1 code
+var languages = map[string]language{
+	"ar": {"Arabic", arabic},
+	"bg": {"Bulgarian", cyrillic},
+	"bn": {"Bengali", []*unicode.RangeTable{unicode.Bengali}},
+	"ca": {"Catalan", latin},
+	"cs": {"Czech", latin},
+	"da": {"Danish", latin},
+	"de": {"German", latin},
+	"el": {"Greek", []*unicode.RangeTable{unicode.Greek}},
+	"en": {"English", latin},
+	"es": {"Spanish", latin},
+	"et": {"Estonian", latin},
+	"fa": {"Persian", arabic},
+	"fi": {"Finnish", latin},
+	"fr": {"French", latin},
+	"he": {"Hebrew", []*unicode.RangeTable{unicode.Hebrew}},
+	"hi": {"Hindi", devanagari},
+	"hr": {"Croatian", latin},
+	"hu": {"Hungarian", latin},
+	"id": {"Indonesian", latin},
+	"it": {"Italian", latin},
+	"ja": {"Japanese", []*unicode.RangeTable{unicode.Han, unicode.Hiragana, unicode.Katakana}},
+	"ko": {"Korean", []*unicode.RangeTable{unicode.Hangul, unicode.Han}},
+	"lt": {"Lithuanian", latin},
+	"lv": {"Latvian", latin},
+	"mr": {"Marathi", devanagari},
+	"ms": {"Malay", latin},
+	"nb": {"Norwegian Bokmål", latin},
+	"nl": {"Dutch", latin},
+	"no": {"Norwegian", latin},
+	"pl": {"Polish", latin},
+	"pt": {"Portuguese", latin},
+	"ro": {"Romanian", latin},
+	"ru": {"Russian", cyrillic},
+	"sk": {"Slovak", latin},
+	"sl": {"Slovenian", latin},
+	"sr": {"Serbian", []*unicode.RangeTable{unicode.Cyrillic, unicode.Latin}},
+	"sv": {"Swedish", latin},
+	"sw": {"Swahili", latin},
+	"ta": {"Tamil", []*unicode.RangeTable{unicode.Tamil}},
+	"th": {"Thai", []*unicode.RangeTable{unicode.Thai}},
+	"tl": {"Tagalog", latin},
+	"tr": {"Turkish", latin},
+	"uk": {"Ukrainian", cyrillic},
+	"ur": {"Urdu", arabic},
+	"vi": {"Vietnamese", latin},
+	"zh": {"Chinese", []*unicode.RangeTable{unicode.Han}},
+}
+
+// baseLanguage returns the lowercase language subtag of locale, e.g. "pt" for "pt-BR" or "pt_BR"
+func baseLanguage(locale string) string {
+	if i := strings.IndexAny(locale, "-_"); i >= 0 {
+		locale = locale[:i]
+	}
+	return strings.ToLower(locale)
+}
+
+// LanguageName returns the English name of the language of locale, e.g. "Portuguese" for "pt-BR",
+// or locale itself for unknown languages
+func LanguageName(locale string) string {
+	if language, ok := languages[baseLanguage(locale)]; ok {
+		return language.name
+	}
+	return locale
+}
+
+// LanguageDetector detects the language of texts
+type LanguageDetector interface {
+	// DetectLanguage returns the ISO 639-1 code of the language of text, or "" when unsure
+	DetectLanguage(ctx context.Context, text string) (string, error)
+}
+
+// LanguageDetectorFunc is a function implementing LanguageDetector
+type LanguageDetectorFunc func(ctx context.Context, text string) (string, error)
+
+var _ LanguageDetector = LanguageDetectorFunc(nil)
+
+// DetectLanguage calls f(ctx, text)
+func (f LanguageDetectorFunc) DetectLanguage(ctx context.Context, text string) (string, error) {
+	return f(ctx, text)
+}
+
+// DefaultMinLanguageLetters is the number of letters under which LanguageVerifier accepts any output
+const DefaultMinLanguageLetters = 20
+
+// LanguageVerifier rejects outputs not written in the language of the request's locale.
+// Without a Detector, it only checks that most letters are in the script of the language, which
+// catches a Japanese answer to a French user but not an English one. Outputs are checked through
+// their strings, URLs excluded, and requests without locale are accepted.
+type LanguageVerifier struct {
+	// Detector detects the language of outputs, optional
+	Detector LanguageDetector
+
+	// MinLetters is the number of letters under which outputs are accepted, DefaultMinLanguageLetters if 0
+	MinLetters int
+}
+
+var _ OutputVerifier = (*LanguageVerifier)(nil)
+
+// VerifyOutput checks the language of the output
+func (v *LanguageVerifier) VerifyOutput(ctx context.Context, req *OutputVerificationRequest) (OutputVerdict, error) {
+	if req.Locale == "" {
+		return OutputVerdict{Accepted: true}, nil
+	}
+	var b strings.Builder
+	outputText(&b, req.Output)
+	text := b.String()
+
+	minLetters := v.MinLetters
+	if minLetters <= 0 {
+		minLetters = DefaultMinLanguageLetters
+	}
+	letters, inScript := 0, 0
+	language, known := languages[baseLanguage(req.Locale)]
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if known && unicode.In(r, language.scripts...) {
+			inScript++
+		}
+	}
+	if letters < minLetters {
+		return OutputVerdict{Accepted: true}, nil
+	}
+
+	feedback := req.Feedback
+	if feedback == nil {
+		feedback = &DefaultFeedbackTemplates
+	}
+	rejected := OutputVerdict{Feedback: fmt.Sprintf(feedback.LanguageMismatch, LanguageName(req.Locale))}
+	if known && inScript*2 < letters {
+		return rejected, nil
+	}
+	if v.Detector == nil {
+		return OutputVerdict{Accepted: true}, nil
+	}
+	detected, err := v.Detector.DetectLanguage(ctx, text)
+	if err != nil {
+		return OutputVerdict{}, fmt.Errorf("failed to detect the output language: %w", err)
+	}
+	if detected != "" && baseLanguage(detected) != baseLanguage(req.Locale) {
+		return rejected, nil
+	}
+	return OutputVerdict{Accepted: true}, nil
+}
+
+// urlPattern matches the URLs left out of language checks
+var urlPattern = regexp.MustCompile(`[A-Za-z][A-Za-z0-9+.-]*://[^\s\p{Han}\p{Hiragana}\p{Katakana}\p{Hangul}]*`)
+
+// outputText writes the strings of output to b without their URLs, one per line
+func outputText(b *strings.Builder, output any) {
+	switch value := output.(type) {
+	case string:
+		b.WriteString(urlPattern.ReplaceAllString(value, " "))
+		b.WriteByte('\n')
+	case map[string]any:
+		for _, v := range value {
+			outputText(b, v)
+		}
+	case []any:
+		for _, v := range value {
+			outputText(b, v)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package agent
+
+// Logger receives diagnostics from runners, with fields as alternating keys and values
+type Logger interface {
+	Debug(msg string, fields ...interface{})
+	Info(msg string, fields ...interface{})
+	Warn(msg string, fields ...interface{})
+	Error(msg string, fields ...interface{})
+}
+
+// NoOpLogger discards all log messages. It is the default logger of runners.
+type NoOpLogger struct{}
+
+var _ Logger = NoOpLogger{}
+
+func (NoOpLogger) Debug(msg string, fields ...interface{}) {}
+func (NoOpLogger) Info(msg string, fields ...interface{})  {}
+func (NoOpLogger) Warn(msg string, fields ...interface{})  {}
+func (NoOpLogger) Error(msg string, fields ...interface{}) {}
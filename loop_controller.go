@@ -0,0 +1,73 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// LoopState is a snapshot of a run passed to a LoopController before every iteration
+type LoopState struct {
+	// Iteration is the zero-based index of the iteration about to run
+	Iteration int
+
+	// MaxIterations is the current iteration limit, including extensions
+	MaxIterations int
+
+	// Agent is the name of the active agent
+	Agent string
+
+	// Messages is the conversation history of the run
+	Messages []*llm.ModelMessage
+
+	// ToolCalls are the tool calls executed so far
+	ToolCalls []*llm.ToolCall
+
+	// ToolStats summarizes ToolCalls by tool name
+	ToolStats map[string]ToolStats
+
+	// Usage is the token usage so far
+	Usage *llm.TokenUsage
+
+	// Cost is the cost so far
+	Cost float64
+
+	// ConsecutiveErrors is the number of consecutive failed iterations
+	ConsecutiveErrors int
+}
+
+// LoopDecision tells the runner how to continue the loop
+type LoopDecision struct {
+	// Stop ends the run before the iteration, returning ErrLoopStopped
+	Stop bool
+
+	// StopReason is included in the error when Stop is set
+	StopReason string
+
+	// ExtendIterations raises the iteration limit of the run by this amount
+	ExtendIterations int
+
+	// SuggestTool, if set, asks the model to use this tool in the iteration
+	SuggestTool string
+
+	// ToolChoice, if set, forces the tool choice of the iteration
+	// Unlike SuggestTool, a model response violating it is rejected and retried
+	ToolChoice ToolChoice
+}
+
+// LoopController is consulted before every iteration, allowing custom stopping criteria,
+// dynamic iteration limits and tool suggestions without forking the runner
+type LoopController interface {
+	ContinueLoop(ctx context.Context, state *LoopState) (LoopDecision, error)
+}
+
+// LoopControllerFunc adapts a function to the LoopController interface
+type LoopControllerFunc func(ctx context.Context, state *LoopState) (LoopDecision, error)
+
+// ContinueLoop calls f
+func (f LoopControllerFunc) ContinueLoop(ctx context.Context, state *LoopState) (LoopDecision, error) {
+	return f(ctx, state)
+}
+
+// suggestToolPrompt asks the model to use a tool in the next iteration
+const suggestToolPrompt = "Use the `%s` tool next."
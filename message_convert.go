@@ -0,0 +1,486 @@
+package agent
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/easyagent-dev/llm"
+	"github.com/google/uuid"
+)
+
+// OpenAIMessage is a message in the OpenAI chat-completions format
+type OpenAIMessage struct {
+	Role string `json:"role"`
+
+	// Content is a string, a list of OpenAIContentPart, or nil for assistant tool calls
+	Content any `json:"content"`
+
+	ToolCalls  []OpenAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+// OpenAIContentPart is a part of a multi-part OpenAI message content
+type OpenAIContentPart struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	ImageURL *OpenAIImageURL `json:"image_url,omitempty"`
+}
+
+// OpenAIImageURL references an image by URL or data URL
+type OpenAIImageURL struct {
+	URL string `json:"url"`
+}
+
+// OpenAIToolCall is a function call made by an OpenAI assistant message
+type OpenAIToolCall struct {
+	ID       string             `json:"id"`
+	Type     string             `json:"type"`
+	Function OpenAIFunctionCall `json:"function"`
+}
+
+// OpenAIFunctionCall holds the function name and its JSON-encoded arguments
+type OpenAIFunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// AnthropicMessage is a message in the Anthropic messages format
+type AnthropicMessage struct {
+	Role    string                  `json:"role"`
+	Content []AnthropicContentBlock `json:"content"`
+}
+
+// AnthropicContentBlock is a content block of an Anthropic message
+type AnthropicContentBlock struct {
+	Type string `json:"type"`
+
+	// Text is set for text blocks
+	Text string `json:"text,omitempty"`
+
+	// ID, Name and Input are set for tool_use blocks
+	ID    string         `json:"id,omitempty"`
+	Name  string         `json:"name,omitempty"`
+	Input map[string]any `json:"input,omitempty"`
+
+	// ToolUseID and Content are set for tool_result blocks
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	Content   string `json:"content,omitempty"`
+	IsError   bool   `json:"is_error,omitempty"`
+
+	// Source is set for image blocks
+	Source *AnthropicImageSource `json:"source,omitempty"`
+}
+
+// AnthropicImageSource is a base64-encoded image
+type AnthropicImageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
+}
+
+// ToOpenAIMessages converts a message history to the OpenAI chat-completions format.
+// Tool results without a tool call are attached to the preceding assistant tool call,
+// and only image artifacts are exported.
+func ToOpenAIMessages(messages []*llm.ModelMessage) ([]OpenAIMessage, error) {
+	converted := make([]OpenAIMessage, 0, len(messages))
+	lastToolCallID := ""
+	for i, msg := range messages {
+		switch msg.Role {
+		case llm.RoleUser:
+			converted = append(converted, OpenAIMessage{
+				Role:    "user",
+				Content: openAIContent(msg),
+			})
+		case llm.RoleAssistant:
+			out := OpenAIMessage{Role: "assistant"}
+			if msg.Content != "" {
+				out.Content = msg.Content
+			}
+			if msg.ToolCall != nil {
+				arguments, err := json.Marshal(msg.ToolCall.Input)
+				if err != nil {
+					return nil, fmt.Errorf("message %d: failed to marshal tool call input: %w", i, err)
+				}
+				lastToolCallID = toolCallID(msg.ToolCall)
+				out.ToolCalls = []OpenAIToolCall{{
+					ID:   lastToolCallID,
+					Type: "function",
+					Function: OpenAIFunctionCall{
+						Name:      msg.ToolCall.Name,
+						Arguments: string(arguments),
+					},
+				}}
+			}
+			converted = append(converted, out)
+		case llm.RoleTool:
+			id, content, err := toolResult(msg, lastToolCallID)
+			if err != nil {
+				return nil, fmt.Errorf("message %d: %w", i, err)
+			}
+			converted = append(converted, OpenAIMessage{
+				Role:       "tool",
+				Content:    content,
+				ToolCallID: id,
+			})
+		default:
+			return nil, fmt.Errorf("message %d: unsupported role '%s'", i, msg.Role)
+		}
+	}
+	return converted, nil
+}
+
+// FromOpenAIMessages converts OpenAI chat-completions messages to a message history.
+// System and developer messages are skipped since instructions belong to the agent,
+// and assistant messages with several tool calls are split into one message per call.
+func FromOpenAIMessages(messages []OpenAIMessage) ([]*llm.ModelMessage, error) {
+	converted := make([]*llm.ModelMessage, 0, len(messages))
+	toolCalls := map[string]*llm.ToolCall{}
+	for i, msg := range messages {
+		text, artifacts, err := fromOpenAIContent(msg.Content)
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %w", i, err)
+		}
+		switch msg.Role {
+		case "system", "developer":
+			continue
+		case "user":
+			converted = append(converted, &llm.ModelMessage{
+				Role:      llm.RoleUser,
+				Content:   text,
+				Artifacts: artifacts,
+			})
+		case "assistant":
+			if len(msg.ToolCalls) == 0 {
+				converted = append(converted, &llm.ModelMessage{Role: llm.RoleAssistant, Content: text})
+				continue
+			}
+			for j, call := range msg.ToolCalls {
+				input := map[string]any{}
+				if call.Function.Arguments != "" {
+					if err := json.Unmarshal([]byte(call.Function.Arguments), &input); err != nil {
+						return nil, fmt.Errorf("message %d: invalid arguments of tool call %s: %w", i, call.ID, err)
+					}
+				}
+				toolCall := &llm.ToolCall{ID: call.ID, Name: call.Function.Name, Input: input}
+				toolCalls[call.ID] = toolCall
+				content := ""
+				if j == 0 {
+					content = text
+				}
+				converted = append(converted, &llm.ModelMessage{
+					Role:     llm.RoleAssistant,
+					Content:  content,
+					ToolCall: toolCall,
+				})
+			}
+		case "tool":
+			converted = append(converted, toolResultMessage(toolCalls, msg.ToolCallID, text, nil))
+		default:
+			return nil, fmt.Errorf("message %d: unsupported role '%s'", i, msg.Role)
+		}
+	}
+	return converted, nil
+}
+
+// ToAnthropicMessages converts a message history to the Anthropic messages format.
+// Tool results are sent as tool_result blocks of user messages, and consecutive
+// messages with the same role are merged since Anthropic requires alternating roles.
+func ToAnthropicMessages(messages []*llm.ModelMessage) ([]AnthropicMessage, error) {
+	converted := make([]AnthropicMessage, 0, len(messages))
+	appendBlocks := func(role string, blocks ...AnthropicContentBlock) {
+		if len(blocks) == 0 {
+			return
+		}
+		if n := len(converted); n > 0 && converted[n-1].Role == role {
+			converted[n-1].Content = append(converted[n-1].Content, blocks...)
+			return
+		}
+		converted = append(converted, AnthropicMessage{Role: role, Content: blocks})
+	}
+
+	lastToolCallID := ""
+	for i, msg := range messages {
+		switch msg.Role {
+		case llm.RoleUser:
+			var blocks []AnthropicContentBlock
+			if msg.Content != "" {
+				blocks = append(blocks, AnthropicContentBlock{Type: "text", Text: msg.Content})
+			}
+			for _, artifact := range msg.Artifacts {
+				if isImageArtifact(artifact) {
+					blocks = append(blocks, AnthropicContentBlock{
+						Type: "image",
+						Source: &AnthropicImageSource{
+							Type:      "base64",
+							MediaType: artifact.ContentType,
+							Data:      base64.StdEncoding.EncodeToString(artifact.Content),
+						},
+					})
+				}
+			}
+			appendBlocks("user", blocks...)
+		case llm.RoleAssistant:
+			var blocks []AnthropicContentBlock
+			if msg.Content != "" {
+				blocks = append(blocks, AnthropicContentBlock{Type: "text", Text: msg.Content})
+			}
+			if msg.ToolCall != nil {
+				lastToolCallID = toolCallID(msg.ToolCall)
+				input := msg.ToolCall.Input
+				if input == nil {
+					input = map[string]any{}
+				}
+				blocks = append(blocks, AnthropicContentBlock{
+					Type:  "tool_use",
+					ID:    lastToolCallID,
+					Name:  msg.ToolCall.Name,
+					Input: input,
+				})
+			}
+			appendBlocks("assistant", blocks...)
+		case llm.RoleTool:
+			id, content, err := toolResult(msg, lastToolCallID)
+			if err != nil {
+				return nil, fmt.Errorf("message %d: %w", i, err)
+			}
+			appendBlocks("user", AnthropicContentBlock{
+				Type:      "tool_result",
+				ToolUseID: id,
+				Content:   content,
+				IsError:   msg.ToolCall != nil && msg.ToolCall.ErrorMessage != nil,
+			})
+		default:
+			return nil, fmt.Errorf("message %d: unsupported role '%s'", i, msg.Role)
+		}
+	}
+	return converted, nil
+}
+
+// FromAnthropicMessages converts Anthropic messages to a message history.
+// tool_result blocks become tool messages, and assistant messages with several
+// tool_use blocks are split into one message per call.
+func FromAnthropicMessages(messages []AnthropicMessage) ([]*llm.ModelMessage, error) {
+	converted := make([]*llm.ModelMessage, 0, len(messages))
+	toolCalls := map[string]*llm.ToolCall{}
+	for i, msg := range messages {
+		var texts []string
+		switch msg.Role {
+		case "user":
+			var artifacts []*llm.ModelArtifact
+			for _, block := range msg.Content {
+				switch block.Type {
+				case "text":
+					texts = append(texts, block.Text)
+				case "image":
+					if block.Source == nil || block.Source.Type != "base64" {
+						return nil, fmt.Errorf("message %d: only base64 images are supported", i)
+					}
+					data, err := base64.StdEncoding.DecodeString(block.Source.Data)
+					if err != nil {
+						return nil, fmt.Errorf("message %d: invalid image data: %w", i, err)
+					}
+					artifacts = append(artifacts, &llm.ModelArtifact{
+						ID:          uuid.New().String(),
+						Name:        fmt.Sprintf("image-%d", len(artifacts)+1),
+						ContentType: block.Source.MediaType,
+						Content:     data,
+					})
+				case "tool_result":
+					var errorMessage *string
+					if block.IsError {
+						errorMessage = &block.Content
+					}
+					converted = append(converted, toolResultMessage(toolCalls, block.ToolUseID, block.Content, errorMessage))
+				default:
+					return nil, fmt.Errorf("message %d: unsupported content block '%s'", i, block.Type)
+				}
+			}
+			if len(texts) > 0 || len(artifacts) > 0 {
+				converted = append(converted, &llm.ModelMessage{
+					Role:      llm.RoleUser,
+					Content:   strings.Join(texts, "\n"),
+					Artifacts: artifacts,
+				})
+			}
+		case "assistant":
+			calls := 0
+			for _, block := range msg.Content {
+				switch block.Type {
+				case "text":
+					texts = append(texts, block.Text)
+				case "tool_use":
+					toolCall := &llm.ToolCall{ID: block.ID, Name: block.Name, Input: block.Input}
+					toolCalls[block.ID] = toolCall
+					content := ""
+					if calls == 0 {
+						content = strings.Join(texts, "\n")
+					}
+					calls++
+					converted = append(converted, &llm.ModelMessage{
+						Role:     llm.RoleAssistant,
+						Content:  content,
+						ToolCall: toolCall,
+					})
+				case "thinking", "redacted_thinking":
+					continue
+				default:
+					return nil, fmt.Errorf("message %d: unsupported content block '%s'", i, block.Type)
+				}
+			}
+			if calls == 0 {
+				converted = append(converted, &llm.ModelMessage{
+					Role:    llm.RoleAssistant,
+					Content: strings.Join(texts, "\n"),
+				})
+			}
+		default:
+			return nil, fmt.Errorf("message %d: unsupported role '%s'", i, msg.Role)
+		}
+	}
+	return converted, nil
+}
+
+// openAIContent returns the content of a user message, as parts if it carries images
+func openAIContent(msg *llm.ModelMessage) any {
+	var images []OpenAIContentPart
+	for _, artifact := range msg.Artifacts {
+		if isImageArtifact(artifact) {
+			images = append(images, OpenAIContentPart{
+				Type: "image_url",
+				ImageURL: &OpenAIImageURL{
+					URL: "data:" + artifact.ContentType + ";base64," + base64.StdEncoding.EncodeToString(artifact.Content),
+				},
+			})
+		}
+	}
+	if len(images) == 0 {
+		return msg.Content
+	}
+	parts := make([]OpenAIContentPart, 0, len(images)+1)
+	if msg.Content != "" {
+		parts = append(parts, OpenAIContentPart{Type: "text", Text: msg.Content})
+	}
+	return append(parts, images...)
+}
+
+// fromOpenAIContent returns the text and image artifacts of an OpenAI message content
+func fromOpenAIContent(content any) (string, []*llm.ModelArtifact, error) {
+	switch c := content.(type) {
+	case nil:
+		return "", nil, nil
+	case string:
+		return c, nil, nil
+	}
+
+	// Parts decoded from JSON are generic values, round-trip them into typed parts
+	data, err := json.Marshal(content)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid content: %w", err)
+	}
+	var parts []OpenAIContentPart
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return "", nil, fmt.Errorf("invalid content: %w", err)
+	}
+
+	var texts []string
+	var artifacts []*llm.ModelArtifact
+	for _, part := range parts {
+		switch part.Type {
+		case "text":
+			texts = append(texts, part.Text)
+		case "image_url":
+			if part.ImageURL == nil {
+				continue
+			}
+			contentType, data, ok := parseDataURL(part.ImageURL.URL)
+			if !ok {
+				return "", nil, fmt.Errorf("only data URL images are supported")
+			}
+			artifacts = append(artifacts, &llm.ModelArtifact{
+				ID:          uuid.New().String(),
+				Name:        fmt.Sprintf("image-%d", len(artifacts)+1),
+				ContentType: contentType,
+				Content:     data,
+			})
+		default:
+			return "", nil, fmt.Errorf("unsupported content part '%s'", part.Type)
+		}
+	}
+	return strings.Join(texts, "\n"), artifacts, nil
+}
+
+// parseDataURL decodes a base64 data URL
+func parseDataURL(url string) (string, []byte, bool) {
+	rest, ok := strings.CutPrefix(url, "data:")
+	if !ok {
+		return "", nil, false
+	}
+	contentType, encoded, ok := strings.Cut(rest, ";base64,")
+	if !ok {
+		return "", nil, false
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", nil, false
+	}
+	return contentType, data, true
+}
+
+// toolResult returns the tool call ID and content of a tool message.
+// Messages without a tool call belong to the preceding assistant tool call.
+func toolResult(msg *llm.ModelMessage, lastToolCallID string) (string, string, error) {
+	if msg.ToolCall == nil {
+		if lastToolCallID == "" {
+			return "", "", fmt.Errorf("tool result without a preceding tool call")
+		}
+		return lastToolCallID, msg.Content, nil
+	}
+
+	id := msg.ToolCall.ID
+	if id == "" {
+		id = lastToolCallID
+	}
+	if msg.ToolCall.ErrorMessage != nil {
+		return id, *msg.ToolCall.ErrorMessage, nil
+	}
+	switch output := msg.ToolCall.Output.(type) {
+	case nil:
+		return id, msg.Content, nil
+	case string:
+		return id, output, nil
+	default:
+		data, err := json.Marshal(output)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to marshal tool output: %w", err)
+		}
+		return id, string(data), nil
+	}
+}
+
+// toolResultMessage creates a tool message for the result of a previously imported tool call
+func toolResultMessage(toolCalls map[string]*llm.ToolCall, id string, content string, errorMessage *string) *llm.ModelMessage {
+	toolCall := &llm.ToolCall{ID: id, Output: content, ErrorMessage: errorMessage}
+	if call, ok := toolCalls[id]; ok {
+		toolCall.Name = call.Name
+		toolCall.Input = call.Input
+	}
+	return &llm.ModelMessage{
+		Role:     llm.RoleTool,
+		ToolCall: toolCall,
+	}
+}
+
+// toolCallID returns the ID of a tool call, or a generated one if it is empty
+func toolCallID(toolCall *llm.ToolCall) string {
+	if toolCall.ID == "" {
+		return uuid.New().String()
+	}
+	return toolCall.ID
+}
+
+// isImageArtifact reports whether an artifact is an image
+func isImageArtifact(artifact *llm.ModelArtifact) bool {
+	return artifact != nil && strings.HasPrefix(artifact.ContentType, "image/")
+}
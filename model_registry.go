@@ -0,0 +1,170 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// ParseModelRef splits a model reference such as "openai/o4-mini" into its provider and model.
+// The model may contain slashes, e.g. "openrouter/meta-llama/llama-3.1-8b".
+func ParseModelRef(ref string) (provider string, model string, err error) {
+	provider, model, ok := strings.Cut(ref, "/")
+	if !ok || provider == "" || model == "" {
+		return "", "", fmt.Errorf("invalid model reference '%s', expected provider/model", ref)
+	}
+	return provider, model, nil
+}
+
+// registeredProvider is a provider of a ModelRegistry and the options of the models it creates
+type registeredProvider struct {
+	provider llm.ModelProvider
+	opts     []llm.CompletionOption
+}
+
+// ModelRegistry resolves model references such as "openai/o4-mini" to completion models, so a single
+// runner serves requests targeting different models through AgentRequest.Model. Models are either
+// registered by reference or created by the provider of the reference on first use, then reused.
+// This type is safe for concurrent use.
+type ModelRegistry struct {
+	mu        sync.RWMutex
+	providers map[string]registeredProvider
+	models    map[string]llm.CompletionModel
+}
+
+// NewModelRegistry creates an empty model registry
+func NewModelRegistry() *ModelRegistry {
+	return &ModelRegistry{
+		providers: make(map[string]registeredProvider),
+		models:    make(map[string]llm.CompletionModel),
+	}
+}
+
+// RegisterProvider registers the provider creating the models referenced as name/model, with opts
+func (m *ModelRegistry) RegisterProvider(name string, provider llm.ModelProvider, opts ...llm.CompletionOption) error {
+	if name == "" || strings.Contains(name, "/") {
+		return fmt.Errorf("invalid provider name '%s'", name)
+	}
+	if provider == nil {
+		return errors.New("provider cannot be nil")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.providers[name]; exists {
+		return fmt.Errorf("provider '%s' already registered", name)
+	}
+	m.providers[name] = registeredProvider{provider: provider, opts: opts}
+	return nil
+}
+
+// RegisterModel registers the model of ref, e.g. a constrained or local model, taking precedence over
+// the provider of ref
+func (m *ModelRegistry) RegisterModel(ref string, model llm.CompletionModel) error {
+	if _, _, err := ParseModelRef(ref); err != nil {
+		return err
+	}
+	if model == nil {
+		return errors.New("model cannot be nil")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.models[ref]; exists {
+		return fmt.Errorf("model '%s' already registered", ref)
+	}
+	m.models[ref] = model
+	return nil
+}
+
+// Resolve returns the model of ref, creating it with the provider of ref if it is not registered
+func (m *ModelRegistry) Resolve(ref string) (llm.CompletionModel, error) {
+	name, modelName, err := ParseModelRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	model, ok := m.models[ref]
+	m.mu.RUnlock()
+	if ok {
+		return model, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if model, ok := m.models[ref]; ok {
+		return model, nil
+	}
+	provider, ok := m.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: no provider '%s' for %s", ErrUnknownModel, name, ref)
+	}
+	model, err = provider.provider.NewCompletionModel(modelName, provider.opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create model %s: %w", ref, err)
+	}
+	m.models[ref] = model
+	return model, nil
+}
+
+// Capabilities probes the capabilities of the model of ref with the model catalog of its provider,
+// false if they are not known or the model cannot be resolved
+func (m *ModelRegistry) Capabilities(ref string) (Capabilities, bool) {
+	model, err := m.Resolve(ref)
+	if err != nil {
+		return Capabilities{}, false
+	}
+	name, modelName, _ := ParseModelRef(ref)
+	m.mu.RLock()
+	provider := m.providers[name].provider
+	m.mu.RUnlock()
+	return ProbeCapabilities(name, modelName, model, provider)
+}
+
+// WithModelRegistry sets the registry resolving the AgentRequest.Model of requests. Requests without
+// a model run on the runner's model.
+func WithModelRegistry(registry *ModelRegistry) RunnerOption {
+	return func(c *runnerConfig) {
+		c.modelRegistry = registry
+	}
+}
+
+// requestModel returns the model of req and agent with the provider and model of req,
+// the runner's model and agent if req has no model
+func (r *BaseRunner) requestModel(req *AgentRequest, agent *Agent) (llm.CompletionModel, *Agent, error) {
+	if req.Model == "" {
+		return r.model, agent, nil
+	}
+	if r.modelRegistry == nil {
+		return nil, nil, fmt.Errorf("%w: request model %s requires a model registry", ErrInvalidConfiguration, req.Model)
+	}
+	model, err := r.modelRegistry.Resolve(req.Model)
+	if err != nil {
+		return nil, nil, err
+	}
+	return model, withModelRef(agent, req.Model), nil
+}
+
+// requestCapabilities returns the capabilities of the model of req, nil if they are not known
+func (r *BaseRunner) requestCapabilities(req *AgentRequest) *Capabilities {
+	if req.Model == "" || r.modelRegistry == nil {
+		return r.capabilities
+	}
+	capabilities, ok := r.modelRegistry.Capabilities(req.Model)
+	if !ok {
+		return nil
+	}
+	return &capabilities
+}
+
+// withModelRef returns a copy of agent with the provider and model of ref, agent if ref is empty
+func withModelRef(agent *Agent, ref string) *Agent {
+	provider, model, err := ParseModelRef(ref)
+	if err != nil {
+		return agent
+	}
+	copied := *agent
+	copied.ModelProvider, copied.Model = provider, model
+	return &copied
+}
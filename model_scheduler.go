@@ -0,0 +1,206 @@
+package agent
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// priorityKey is the key for storing the priority of model calls in context.Context
+const priorityKey contextKey = "priority"
+
+// WithPriority returns a new context whose model calls are scheduled with priority.
+// Runners set it from AgentRequest.Priority.
+func WithPriority(ctx context.Context, priority int) context.Context {
+	return context.WithValue(ctx, priorityKey, priority)
+}
+
+// PriorityOf returns the priority of model calls made with ctx, 0 by default
+func PriorityOf(ctx context.Context) int {
+	priority, _ := ctx.Value(priorityKey).(int)
+	return priority
+}
+
+// ModelSchedulerStats is a snapshot of a model scheduler
+type ModelSchedulerStats struct {
+	InFlight int
+	Queued   int
+}
+
+// ModelScheduler limits the model calls in flight across all the models it wraps, e.g. the models
+// of one provider account. Pending calls start by decreasing priority, then in arrival order.
+// This type is safe for concurrent use.
+type ModelScheduler struct {
+	mu          sync.Mutex
+	maxInFlight int
+	inFlight    int
+	queue       callQueue
+	seq         uint64
+}
+
+// NewModelScheduler creates a scheduler allowing maxInFlight concurrent model calls
+func NewModelScheduler(maxInFlight int) *ModelScheduler {
+	if maxInFlight <= 0 {
+		maxInFlight = 1
+	}
+	return &ModelScheduler{maxInFlight: maxInFlight}
+}
+
+// Wrap returns a model whose calls are scheduled by s.
+// A streaming call holds its slot until its stream is closed.
+func (s *ModelScheduler) Wrap(model llm.CompletionModel) llm.CompletionModel {
+	return &scheduledModel{model: model, scheduler: s}
+}
+
+// Stats returns the number of calls in flight and waiting
+func (s *ModelScheduler) Stats() ModelSchedulerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return ModelSchedulerStats{InFlight: s.inFlight, Queued: len(s.queue)}
+}
+
+// acquire waits for a slot, or returns the context error if ctx is done first
+func (s *ModelScheduler) acquire(ctx context.Context) error {
+	s.mu.Lock()
+	if s.inFlight < s.maxInFlight && len(s.queue) == 0 {
+		s.inFlight++
+		s.mu.Unlock()
+		return nil
+	}
+	call := &pendingCall{priority: PriorityOf(ctx), seq: s.seq, ready: make(chan struct{})}
+	s.seq++
+	heap.Push(&s.queue, call)
+	s.mu.Unlock()
+
+	select {
+	case <-call.ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if call.index < 0 {
+			// The slot was granted while giving up, pass it on
+			s.releaseLocked()
+		} else {
+			heap.Remove(&s.queue, call.index)
+		}
+		return ctx.Err()
+	}
+}
+
+// release frees a slot, granting it to the next pending call
+func (s *ModelScheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.releaseLocked()
+}
+
+func (s *ModelScheduler) releaseLocked() {
+	if len(s.queue) == 0 {
+		s.inFlight--
+		return
+	}
+	// The slot moves to the next call, inFlight is unchanged
+	call := heap.Pop(&s.queue).(*pendingCall)
+	close(call.ready)
+}
+
+// pendingCall is a model call waiting for a slot
+type pendingCall struct {
+	priority int
+	seq      uint64
+	ready    chan struct{}
+
+	// index is the position in the queue, -1 once popped
+	index int
+}
+
+// callQueue is a heap of pending calls, highest priority and oldest first
+type callQueue []*pendingCall
+
+func (q callQueue) Len() int { return len(q) }
+
+func (q callQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q callQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *callQueue) Push(x any) {
+	call := x.(*pendingCall)
+	call.index = len(*q)
+	*q = append(*q, call)
+}
+
+func (q *callQueue) Pop() any {
+	old := *q
+	n := len(old)
+	call := old[n-1]
+	old[n-1] = nil
+	call.index = -1
+	*q = old[:n-1]
+	return call
+}
+
+// scheduledModel is a model whose calls wait for a slot of its scheduler
+type scheduledModel struct {
+	model     llm.CompletionModel
+	scheduler *ModelScheduler
+}
+
+var _ ConstrainedModel = (*scheduledModel)(nil)
+
+// Complete waits for a slot and calls the model
+func (m *scheduledModel) Complete(ctx context.Context, req *llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	if err := m.scheduler.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer m.scheduler.release()
+	return m.model.Complete(ctx, req)
+}
+
+// Constrain constrains the wrapped model if it supports constrained decoding, keeping the scheduler
+func (m *scheduledModel) Constrain(constraint *GenerationConstraint) (llm.CompletionModel, error) {
+	model, err := constrainModel(m.model, constraint)
+	if err != nil {
+		return nil, err
+	}
+	return &scheduledModel{model: model, scheduler: m.scheduler}, nil
+}
+
+// StreamComplete waits for a slot and streams the model response, releasing the slot once the stream is closed
+func (m *scheduledModel) StreamComplete(ctx context.Context, req *llm.CompletionRequest) (llm.StreamCompletionResponse, error) {
+	if err := m.scheduler.acquire(ctx); err != nil {
+		return nil, err
+	}
+	stream, err := m.model.StreamComplete(ctx, req)
+	if err != nil {
+		m.scheduler.release()
+		return nil, err
+	}
+	chunks := make(chan llm.StreamChunk)
+	go func() {
+		defer m.scheduler.release()
+		defer close(chunks)
+		for chunk := range stream {
+			select {
+			case chunks <- chunk:
+			case <-ctx.Done():
+				// The model stops streaming once ctx is done, drain what it already produced
+				for range stream {
+				}
+				return
+			}
+		}
+	}()
+	return chunks, nil
+}
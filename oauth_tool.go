@@ -0,0 +1,153 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// TokenSource provides OAuth access tokens for a service
+type TokenSource interface {
+	// Token returns a valid access token, refreshing it first if it expired
+	Token(ctx context.Context) (*Credential, error)
+
+	// Refresh obtains a new access token, e.g. after the API rejected the current one
+	Refresh(ctx context.Context) (*Credential, error)
+}
+
+// RefreshFunc exchanges a refresh token for a new credential
+type RefreshFunc func(ctx context.Context, refreshToken string) (*Credential, error)
+
+// RefreshingTokenSource caches a credential and refreshes it with its refresh token when it
+// expires or is rejected. This type is safe for concurrent use.
+type RefreshingTokenSource struct {
+	mu         sync.Mutex
+	credential *Credential
+	refresh    RefreshFunc
+}
+
+var _ TokenSource = (*RefreshingTokenSource)(nil)
+
+// NewRefreshingTokenSource creates a token source starting from credential
+func NewRefreshingTokenSource(credential *Credential, refresh RefreshFunc) *RefreshingTokenSource {
+	return &RefreshingTokenSource{
+		credential: credential,
+		refresh:    refresh,
+	}
+}
+
+// Token returns the cached credential, refreshing it if it expired
+func (s *RefreshingTokenSource) Token(ctx context.Context) (*Credential, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.credential != nil && !s.credential.Expired() {
+		return s.credential, nil
+	}
+	return s.refreshLocked(ctx)
+}
+
+// Refresh replaces the cached credential with a refreshed one
+func (s *RefreshingTokenSource) Refresh(ctx context.Context) (*Credential, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.refreshLocked(ctx)
+}
+
+func (s *RefreshingTokenSource) refreshLocked(ctx context.Context) (*Credential, error) {
+	if s.credential == nil || s.credential.RefreshToken == "" {
+		return nil, errors.New("no refresh token available")
+	}
+	credential, err := s.refresh(ctx, s.credential.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+	// Providers may omit the refresh token when it is unchanged
+	if credential.RefreshToken == "" {
+		credential.RefreshToken = s.credential.RefreshToken
+	}
+	s.credential = credential
+	return credential, nil
+}
+
+// OAuthError reports that a tool could not obtain a valid access token
+type OAuthError struct {
+	// Service is the service the token is for
+	Service string
+
+	// Err is the token source error
+	Err error
+}
+
+func (e *OAuthError) Error() string {
+	return fmt.Sprintf("failed to obtain access token for %s: %v", e.Service, e.Err)
+}
+
+func (e *OAuthError) Unwrap() error {
+	return e.Err
+}
+
+// OAuthTool runs a tool with an access token from a TokenSource, available to the tool
+// through GetCredential(ctx, service). When the tool fails with an error wrapping
+// ErrUnauthorized, the token is refreshed and the call is retried once.
+type OAuthTool struct {
+	ModelTool
+	service string
+	source  TokenSource
+}
+
+var _ ModelTool = (*OAuthTool)(nil)
+
+// WithOAuth wraps tool so it runs with access tokens for service from source
+func WithOAuth(tool ModelTool, service string, source TokenSource) *OAuthTool {
+	return &OAuthTool{
+		ModelTool: tool,
+		service:   service,
+		source:    source,
+	}
+}
+
+// Effect returns the effect of the wrapped tool
+func (t *OAuthTool) Effect() ToolEffect {
+	return ToolEffectOf(t.ModelTool, ToolOptions{})
+}
+
+// DryRun simulates a call of the wrapped tool
+func (t *OAuthTool) DryRun(ctx context.Context, input map[string]any) (any, error) {
+	return dryRun(ctx, t.ModelTool, input)
+}
+
+// Run runs the tool with a valid access token, refreshing it and retrying once if it is rejected
+func (t *OAuthTool) Run(ctx context.Context, input map[string]any) (any, error) {
+	credential, err := t.source.Token(ctx)
+	if err != nil {
+		return nil, &OAuthError{Service: t.service, Err: err}
+	}
+	output, err := t.ModelTool.Run(t.withToken(ctx, credential), input)
+	if !errors.Is(err, ErrUnauthorized) {
+		return output, err
+	}
+
+	credential, err = t.source.Refresh(ctx)
+	if err != nil {
+		return nil, &OAuthError{Service: t.service, Err: err}
+	}
+	return t.ModelTool.Run(t.withToken(ctx, credential), input)
+}
+
+// withToken returns a context whose credentials provider returns credential for the tool's service
+// and defers to the run's provider for other services
+func (t *OAuthTool) withToken(ctx context.Context, credential *Credential) context.Context {
+	parent, _ := CredentialsOf(ctx)
+	return WithCredentials(ctx, CredentialsProviderFunc(func(ctx context.Context, service string) (*Credential, error) {
+		if service == t.service {
+			return credential, nil
+		}
+		if parent == nil {
+			return nil, fmt.Errorf("%w: %s", ErrCredentialNotFound, service)
+		}
+		return parent.Credential(ctx, service)
+	}))
+}
@@ -0,0 +1,290 @@
+// Package openaicompat provides completion models for OpenAI-compatible endpoints such as Ollama,
+// vLLM or the llama.cpp server. Unlike the llm layer's OpenAI provider, it honors the base URL and
+// accepts any model name, as local servers serve whatever models were pulled.
+package openaicompat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/easyagent-dev/llm"
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+)
+
+// DefaultAPIKey is sent when no API key is set, local servers ignore it but the client requires one
+const DefaultAPIKey = "local"
+
+// reasoningFields are the delta fields local servers stream the reasoning of thinking models in
+var reasoningFields = []string{"reasoning_content", "reasoning"}
+
+// Provider creates the models of an OpenAI-compatible endpoint
+type Provider struct {
+	name   string
+	client openai.Client
+}
+
+var _ llm.ModelProvider = (*Provider)(nil)
+
+// NewProvider creates a provider for the endpoint set by llm.WithBaseURL, e.g. http://localhost:11434/v1.
+// llm.WithAPIKey is only needed by servers checking it.
+func NewProvider(opts ...llm.ModelOption) (*Provider, error) {
+	config := llm.ApplyOptions(opts)
+	if config.BaseURL == "" {
+		return nil, llm.ErrBaseURLEmpty
+	}
+	apiKey := config.APIKey
+	if apiKey == "" {
+		apiKey = DefaultAPIKey
+	}
+	requestOpts := []option.RequestOption{
+		option.WithAPIKey(apiKey),
+		option.WithBaseURL(config.BaseURL),
+	}
+	requestOpts = append(requestOpts, config.Options...)
+	return &Provider{
+		name:   "openai-compatible",
+		client: openai.NewClient(requestOpts...),
+	}, nil
+}
+
+// Name returns the provider name
+func (p *Provider) Name() string {
+	return p.name
+}
+
+// SupportedModels returns nil, the models depend on the server
+func (p *Provider) SupportedModels() []*llm.ModelInfo {
+	return nil
+}
+
+// NewCompletionModel creates the completion model named model
+func (p *Provider) NewCompletionModel(model string, opts ...llm.CompletionOption) (llm.CompletionModel, error) {
+	if model == "" {
+		return nil, errors.New("model name is required")
+	}
+	return &CompletionModel{name: model, client: p.client, options: opts}, nil
+}
+
+// NewEmbeddingModel is not supported
+func (p *Provider) NewEmbeddingModel(model string) (llm.EmbeddingModel, error) {
+	return nil, llm.NewUnsupportedCapabilityError(p.name, "embeddings")
+}
+
+// NewImageModel is not supported
+func (p *Provider) NewImageModel(model string) (llm.ImageModel, error) {
+	return nil, llm.NewUnsupportedCapabilityError(p.name, "image generation")
+}
+
+// NewConversationModel is not supported
+func (p *Provider) NewConversationModel(model string, opts ...llm.ResponseOption) (llm.ConversationModel, error) {
+	return nil, llm.NewUnsupportedCapabilityError(p.name, "conversations")
+}
+
+// CompletionModel is a model of an OpenAI-compatible endpoint. Its calls have no cost.
+type CompletionModel struct {
+	name    string
+	client  openai.Client
+	options []llm.CompletionOption
+}
+
+var _ llm.CompletionModel = (*CompletionModel)(nil)
+
+// Complete generates the complete output of the model
+func (m *CompletionModel) Complete(ctx context.Context, req *llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	opts := llm.ApplyCompletionOptions(m.options)
+	params, err := m.params(req, opts)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := m.client.Chat.Completions.New(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete chat: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return nil, llm.ErrEmptyContent
+	}
+
+	var usage *llm.TokenUsage
+	if opts.WithUsage != nil && *opts.WithUsage {
+		usage = toTokenUsage(resp.Usage)
+	}
+	return &llm.CompletionResponse{
+		Output: resp.Choices[0].Message.Content,
+		Usage:  usage,
+	}, nil
+}
+
+// StreamComplete streams the output of the model. Errors are streamed as text, like the llm layer does.
+func (m *CompletionModel) StreamComplete(ctx context.Context, req *llm.CompletionRequest) (llm.StreamCompletionResponse, error) {
+	opts := llm.ApplyCompletionOptions(m.options)
+	params, err := m.params(req, opts)
+	if err != nil {
+		return nil, err
+	}
+	withUsage := opts.WithUsage != nil && *opts.WithUsage
+	if withUsage {
+		params.StreamOptions.IncludeUsage = openai.Bool(true)
+	}
+
+	stream := m.client.Chat.Completions.NewStreaming(ctx, params)
+	chunks := make(chan llm.StreamChunk, 1)
+	go func() {
+		defer close(chunks)
+		defer stream.Close()
+
+		send := func(chunk llm.StreamChunk) bool {
+			select {
+			case chunks <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		var usage *llm.TokenUsage
+		for stream.Next() {
+			chunk := stream.Current()
+			if chunk.Usage.TotalTokens > 0 {
+				usage = toTokenUsage(chunk.Usage)
+			}
+			if len(chunk.Choices) == 0 {
+				continue
+			}
+			delta := chunk.Choices[0].Delta
+			if delta.Content != "" {
+				if !send(llm.StreamTextChunk{Text: delta.Content}) {
+					return
+				}
+				continue
+			}
+			if reasoning := deltaReasoning(delta); reasoning != "" {
+				if !send(llm.StreamReasoningChunk{Reasoning: reasoning}) {
+					return
+				}
+			}
+		}
+		if err := stream.Err(); err != nil {
+			if ctx.Err() == nil {
+				send(llm.StreamTextChunk{Text: fmt.Sprintf("Error from OpenAI-compatible API: %v", err)})
+			}
+			return
+		}
+		if withUsage && usage != nil {
+			send(llm.StreamUsageChunk{Usage: usage})
+		}
+	}()
+	return chunks, nil
+}
+
+// params converts a request to chat completion parameters
+func (m *CompletionModel) params(req *llm.CompletionRequest, opts *llm.CompletionOptions) (openai.ChatCompletionNewParams, error) {
+	messages := make([]openai.ChatCompletionMessageParamUnion, 0, len(req.Messages)+1)
+	if req.Instructions != "" {
+		messages = append(messages, openai.SystemMessage(req.Instructions))
+	}
+	for _, msg := range req.Messages {
+		message, err := toMessage(msg)
+		if err != nil {
+			return openai.ChatCompletionNewParams{}, fmt.Errorf("failed to convert message: %w", err)
+		}
+		messages = append(messages, message)
+	}
+
+	params := openai.ChatCompletionNewParams{
+		Model:    m.name,
+		Messages: messages,
+	}
+	if opts.Temperature != nil {
+		params.Temperature = openai.Float(*opts.Temperature)
+	}
+	if opts.TopP != nil {
+		params.TopP = openai.Float(*opts.TopP)
+	}
+	if opts.MaxTokens != nil && *opts.MaxTokens > 0 {
+		params.MaxTokens = openai.Int(int64(*opts.MaxTokens))
+	}
+	if opts.PresencePenalty != nil {
+		params.PresencePenalty = openai.Float(*opts.PresencePenalty)
+	}
+	if opts.FrequencyPenalty != nil {
+		params.FrequencyPenalty = openai.Float(*opts.FrequencyPenalty)
+	}
+	if opts.Seed != nil {
+		params.Seed = openai.Int(*opts.Seed)
+	}
+	if len(opts.Stop) > 0 {
+		params.Stop = openai.ChatCompletionNewParamsStopUnion{OfStringArray: opts.Stop}
+	}
+	if opts.ResponseFormat != nil {
+		switch *opts.ResponseFormat {
+		case llm.ResponseFormatJson:
+			params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+				OfJSONObject: &openai.ResponseFormatJSONObjectParam{},
+			}
+		case llm.ResponseFormatJsonSchema:
+			params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+				OfJSONSchema: &openai.ResponseFormatJSONSchemaParam{
+					JSONSchema: openai.ResponseFormatJSONSchemaJSONSchemaParam{
+						Name:   "response_schema",
+						Schema: opts.JSONSchema,
+					},
+				},
+			}
+		}
+	}
+	return params, nil
+}
+
+// toMessage converts a message, tool calls and results are sent as text like the llm layer does
+func toMessage(msg *llm.ModelMessage) (openai.ChatCompletionMessageParamUnion, error) {
+	switch msg.Role {
+	case llm.RoleUser:
+		return openai.UserMessage(msg.Content), nil
+	case llm.RoleAssistant:
+		if msg.ToolCall == nil {
+			return openai.AssistantMessage(msg.Content), nil
+		}
+		call, err := json.Marshal(msg.ToolCall)
+		if err != nil {
+			return openai.ChatCompletionMessageParamUnion{}, fmt.Errorf("failed to marshal tool call: %w", err)
+		}
+		return openai.AssistantMessage("call tool: ```" + string(call) + "```"), nil
+	case llm.RoleTool:
+		result, err := json.Marshal(msg.ToolCall)
+		if err != nil {
+			return openai.ChatCompletionMessageParamUnion{}, fmt.Errorf("failed to marshal tool call results: %w", err)
+		}
+		return openai.UserMessage("call tool results: ```" + string(result) + "```"), nil
+	default:
+		return openai.ChatCompletionMessageParamUnion{}, fmt.Errorf("unknown role '%s'", msg.Role)
+	}
+}
+
+// deltaReasoning returns the reasoning of a streamed delta, if any
+func deltaReasoning(delta openai.ChatCompletionChunkChoiceDelta) string {
+	for _, name := range reasoningFields {
+		field, ok := delta.JSON.ExtraFields[name]
+		if !ok {
+			continue
+		}
+		var reasoning string
+		if err := json.Unmarshal([]byte(field.Raw()), &reasoning); err == nil && reasoning != "" {
+			return reasoning
+		}
+	}
+	return ""
+}
+
+// toTokenUsage converts the usage of a chat completion
+func toTokenUsage(usage openai.CompletionUsage) *llm.TokenUsage {
+	return &llm.TokenUsage{
+		TotalInputTokens:     usage.PromptTokens,
+		TotalOutputTokens:    usage.CompletionTokens,
+		TotalReasoningTokens: usage.CompletionTokensDetails.ReasoningTokens,
+		TotalRequests:        1,
+		TotalCacheReadTokens: usage.PromptTokensDetails.CachedTokens,
+	}
+}
@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OutputDelta is an incremental update of the final structured output
+type OutputDelta struct {
+	// Path is the JSON pointer (RFC 6901) of the updated value, e.g. "/items/0/title"
+	Path string `json:"path"`
+
+	// Value is the scalar value at Path
+	Value any `json:"value"`
+}
+
+// outputDiffer tracks the leaves of a partially parsed output and reports the ones that changed
+type outputDiffer struct {
+	seen map[string]any
+}
+
+// newOutputDiffer creates an empty differ
+func newOutputDiffer() *outputDiffer {
+	return &outputDiffer{seen: make(map[string]any)}
+}
+
+// diff returns the leaves of output that are new or changed since the previous call, in document order
+func (d *outputDiffer) diff(output map[string]any) []*OutputDelta {
+	var deltas []*OutputDelta
+	d.walk("", output, &deltas)
+	return deltas
+}
+
+func (d *outputDiffer) walk(path string, value any, deltas *[]*OutputDelta) {
+	switch v := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			d.walk(path+"/"+escapeJSONPointer(key), v[key], deltas)
+		}
+	case []any:
+		for i, item := range v {
+			d.walk(path+"/"+strconv.Itoa(i), item, deltas)
+		}
+	default:
+		if previous, ok := d.seen[path]; ok && reflect.DeepEqual(previous, value) {
+			return
+		}
+		d.seen[path] = value
+		*deltas = append(*deltas, &OutputDelta{Path: path, Value: value})
+	}
+}
+
+// escapeJSONPointer escapes a key for use as a JSON pointer reference token
+func escapeJSONPointer(key string) string {
+	if !strings.ContainsAny(key, "~/") {
+		return key
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,327 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OutputProcessRequest is a final output passed through the output processors
+type OutputProcessRequest struct {
+	// Agent is the name of the agent completing the task
+	Agent string
+
+	// Schema is the output schema of the request, nil for direct answers
+	Schema any
+
+	// Output is the output returned by the previous processor
+	Output any
+}
+
+// OutputProcessor transforms the final output of runs before it is verified and returned,
+// e.g. to clean up quirks of the model. Errors are reported to the model, which retries.
+type OutputProcessor interface {
+	ProcessOutput(ctx context.Context, req *OutputProcessRequest) (any, error)
+}
+
+// OutputProcessorFunc adapts a function to the OutputProcessor interface
+type OutputProcessorFunc func(ctx context.Context, req *OutputProcessRequest) (any, error)
+
+// ProcessOutput calls f
+func (f OutputProcessorFunc) ProcessOutput(ctx context.Context, req *OutputProcessRequest) (any, error) {
+	return f(ctx, req)
+}
+
+// WithOutputProcessors appends processors applied in order to the final output of runs
+func WithOutputProcessors(processors ...OutputProcessor) RunnerOption {
+	return func(c *runnerConfig) {
+		c.outputProcessors = append(c.outputProcessors, processors...)
+	}
+}
+
+// processOutput passes output through the runner's output processors
+func (r *BaseRunner) processOutput(ctx context.Context, run *agentRun, output any) (any, error) {
+	for _, processor := range r.outputProcessors {
+		processed, err := processor.ProcessOutput(ctx, &OutputProcessRequest{
+			Agent:  run.agent.Name,
+			Schema: run.req.OutputSchema,
+			Output: output,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("output processing failed: %w", err)
+		}
+		output = processed
+	}
+	return output, nil
+}
+
+// CoerceOutput converts the values of the output to the types of the output schema where the
+// conversion is lossless: numeric and boolean strings to numbers and booleans, numbers and booleans
+// to strings, single values to arrays and JSON strings to objects. Other values are kept as is.
+func CoerceOutput() OutputProcessor {
+	return OutputProcessorFunc(func(ctx context.Context, req *OutputProcessRequest) (any, error) {
+		schema, err := schemaMap(req.Schema)
+		if err != nil {
+			return nil, err
+		}
+		return coerceValue(schema, req.Output), nil
+	})
+}
+
+// NormalizeDates rewrites the string values of the output whose schema format is date or date-time
+// as 2006-01-02 and RFC 3339 respectively. Values that cannot be parsed as dates are kept as is.
+func NormalizeDates() OutputProcessor {
+	return OutputProcessorFunc(func(ctx context.Context, req *OutputProcessRequest) (any, error) {
+		schema, err := schemaMap(req.Schema)
+		if err != nil {
+			return nil, err
+		}
+		return normalizeDates(schema, req.Output), nil
+	})
+}
+
+// StripMarkdown removes markdown formatting from the string values of the output: code fences
+// wrapping a whole value, heading markers, bold markers and inline code backticks
+func StripMarkdown() OutputProcessor {
+	return OutputProcessorFunc(func(ctx context.Context, req *OutputProcessRequest) (any, error) {
+		return mapStrings(req.Output, stripMarkdown), nil
+	})
+}
+
+// schemaMap returns schema as a JSON object, decoding typed schemas such as the ones of llm.GenerateSchema
+func schemaMap(schema any) (map[string]any, error) {
+	if schema == nil {
+		return nil, nil
+	}
+	if m, ok := schema.(map[string]any); ok {
+		return m, nil
+	}
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal output schema: %w", err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("output schema is not an object: %w", err)
+	}
+	return m, nil
+}
+
+// schemaTypes returns the types allowed by a schema, which declares one or a list of them
+func schemaTypes(schema map[string]any) []string {
+	switch t := schema["type"].(type) {
+	case string:
+		return []string{t}
+	case []any:
+		types := make([]string, 0, len(t))
+		for _, v := range t {
+			if s, ok := v.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// schemaAllows reports whether a schema allows type t, all types being allowed if it declares none
+func schemaAllows(schema map[string]any, t string) bool {
+	types := schemaTypes(schema)
+	if len(types) == 0 {
+		return true
+	}
+	for _, allowed := range types {
+		if allowed == t || (allowed == "number" && t == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType returns the JSON schema type of a decoded JSON value
+func jsonType(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case int, int64:
+		return "integer"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return ""
+}
+
+// coerceValue converts value to a type allowed by schema and coerces its elements and properties
+func coerceValue(schema map[string]any, value any) any {
+	if schema == nil {
+		return value
+	}
+	if !schemaAllows(schema, jsonType(value)) {
+		value = convertValue(schemaTypes(schema), value)
+	}
+	return mapSchemaValues(schema, value, coerceValue)
+}
+
+// convertValue converts value to the first of types it can be converted to losslessly
+func convertValue(types []string, value any) any {
+	for _, t := range types {
+		switch t {
+		case "integer", "number":
+			if s, ok := value.(string); ok {
+				if n, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil && (t == "number" || n == math.Trunc(n)) {
+					return n
+				}
+			}
+		case "boolean":
+			if s, ok := value.(string); ok {
+				if b, err := strconv.ParseBool(strings.TrimSpace(s)); err == nil {
+					return b
+				}
+			}
+		case "string":
+			switch v := value.(type) {
+			case float64:
+				return strconv.FormatFloat(v, 'f', -1, 64)
+			case bool:
+				return strconv.FormatBool(v)
+			}
+		case "array":
+			if value != nil {
+				return []any{value}
+			}
+		case "object":
+			if s, ok := value.(string); ok {
+				var object map[string]any
+				if err := json.Unmarshal([]byte(strings.TrimSpace(s)), &object); err == nil {
+					return object
+				}
+			}
+		}
+	}
+	return value
+}
+
+// dateLayouts are the layouts NormalizeDates parses dates with
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02",
+	"01/02/2006",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2 January 2006",
+	"2 Jan 2006",
+}
+
+// normalizeDates rewrites the values of value whose schema format is date or date-time
+func normalizeDates(schema map[string]any, value any) any {
+	if schema == nil {
+		return value
+	}
+	switch v := value.(type) {
+	case string:
+		layout := ""
+		switch schema["format"] {
+		case "date":
+			layout = time.DateOnly
+		case "date-time":
+			layout = time.RFC3339
+		default:
+			return value
+		}
+		for _, dateLayout := range dateLayouts {
+			if t, err := time.Parse(dateLayout, strings.TrimSpace(v)); err == nil {
+				return t.Format(layout)
+			}
+		}
+	default:
+		return mapSchemaValues(schema, value, normalizeDates)
+	}
+	return value
+}
+
+// mapSchemaValues applies f to the properties of an object or the items of an array with their schemas.
+// The value is copied, leaving the tool call input the output was read from untouched.
+func mapSchemaValues(schema map[string]any, value any, f func(schema map[string]any, value any) any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		mapped := make(map[string]any, len(v))
+		for key, item := range v {
+			if propertySchema, ok := properties[key].(map[string]any); ok {
+				item = f(propertySchema, item)
+			}
+			mapped[key] = item
+		}
+		return mapped
+	case []any:
+		itemSchema, ok := schema["items"].(map[string]any)
+		if !ok {
+			return value
+		}
+		mapped := make([]any, len(v))
+		for i, item := range v {
+			mapped[i] = f(itemSchema, item)
+		}
+		return mapped
+	}
+	return value
+}
+
+// mapStrings applies f to the strings of a copy of value, recursing into arrays and objects
+func mapStrings(value any, f func(string) string) any {
+	switch v := value.(type) {
+	case string:
+		return f(v)
+	case map[string]any:
+		mapped := make(map[string]any, len(v))
+		for key, item := range v {
+			mapped[key] = mapStrings(item, f)
+		}
+		return mapped
+	case []any:
+		mapped := make([]any, len(v))
+		for i, item := range v {
+			mapped[i] = mapStrings(item, f)
+		}
+		return mapped
+	}
+	return value
+}
+
+var (
+	markdownFence   = regexp.MustCompile("(?s)^```[a-zA-Z0-9_-]*\\n(.*?)\\n?```$")
+	markdownHeading = regexp.MustCompile(`(?m)^#{1,6}\s+`)
+	markdownBold    = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	markdownCode    = regexp.MustCompile("`([^`\n]+)`")
+)
+
+// stripMarkdown removes the markdown formatting of s
+func stripMarkdown(s string) string {
+	s = strings.TrimSpace(s)
+	if m := markdownFence.FindStringSubmatch(s); m != nil {
+		return m[1]
+	}
+	s = markdownHeading.ReplaceAllString(s, "")
+	s = markdownBold.ReplaceAllString(s, "$1$2")
+	return markdownCode.ReplaceAllString(s, "$1")
+}
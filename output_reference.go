@@ -0,0 +1,186 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/easyagent-dev/llm"
+	"github.com/google/uuid"
+)
+
+// OutputReferencePrefix starts the session keys of the artifacts stored by SessionArtifactStore
+const OutputReferencePrefix = "output_ref:"
+
+// OutputQueryToolName is the tool the model is told to query stored outputs with, the json_query
+// tool of the tools/standard package
+const OutputQueryToolName = "json_query"
+
+// maxShapeKeys bounds the object keys listed in the structure of a stored output
+const maxShapeKeys = 20
+
+// artifactPreviewLines is the number of lines of a stored output shown to the model
+const artifactPreviewLines = 20
+
+// WithOutputReferences stores the outputs of tools above maxTokens in the runner's ArtifactStore
+// instead of the history. The model gets a reference, the size and a preview of the output, and
+// the structure of JSON outputs, and reads what it needs with the built-in read_artifact and
+// search_artifact tools, or with the json_query tool when it is registered, rather than
+// re-reading the whole payload every iteration.
+func WithOutputReferences(maxTokens int) RunnerOption {
+	return func(c *runnerConfig) {
+		c.outputRefTokens = maxTokens
+	}
+}
+
+// OutputReferenceOf returns the tool output stored under ref by the run executing in ctx,
+// decoded JSON for JSON outputs and a string otherwise
+func OutputReferenceOf(ctx context.Context, ref string) (any, bool) {
+	artifact, err := artifactOf(ctx, ref)
+	if err != nil {
+		return nil, false
+	}
+	if artifact.ContentType != ArtifactContentJSON {
+		return string(artifact.Content), true
+	}
+	var value any
+	if err := json.Unmarshal(artifact.Content, &value); err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// referenceOutput stores output as an artifact when it is above the token threshold, returning
+// the reference the model sees instead, or output unchanged
+func (r *BaseRunner) referenceOutput(ctx context.Context, run *agentRun, toolCall *llm.ToolCall, output any) any {
+	if r.outputRefTokens <= 0 || output == nil {
+		return output
+	}
+	content, err := r.format.formatToolOutput(output)
+	if err != nil {
+		return output
+	}
+	tokens := r.tokenizer.CountTokens(content)
+	if tokens <= r.outputRefTokens {
+		return output
+	}
+
+	artifact := &Artifact{
+		ID:          toolCall.Name + "_" + strings.ReplaceAll(uuid.New().String(), "-", "")[:8],
+		RunID:       run.agentContext.RunID,
+		Tool:        toolCall.Name,
+		ContentType: ArtifactContentText,
+		CreatedAt:   time.Now(),
+	}
+	value, isJSON := jsonDocument(output)
+	if isJSON {
+		data, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return output
+		}
+		artifact.ContentType = ArtifactContentJSON
+		artifact.Content = data
+	} else if text, ok := output.(string); ok {
+		artifact.Content = []byte(text)
+	} else {
+		artifact.Content = []byte(content)
+	}
+	if err := r.artifacts.Put(ctx, artifact); err != nil {
+		r.logger.Warn("failed to store tool output, passing it whole", "agent", run.agent.Name, "tool", toolCall.Name, "error", err)
+		return output
+	}
+	r.logger.Debug("stored tool output by reference", "agent", run.agent.Name, "tool", toolCall.Name, "ref", artifact.ID, "tokens", tokens)
+
+	lines := strings.Split(string(artifact.Content), "\n")
+	tools := fmt.Sprintf("Page through it with %s and search it with %s", ReadArtifactToolName, SearchArtifactToolName)
+	if isJSON && run.toolRegistry.HasTool(OutputQueryToolName) {
+		tools += fmt.Sprintf(", or extract parts with %s and a JSONPath expression", OutputQueryToolName)
+	}
+	reference := map[string]any{
+		"ref":          artifact.ID,
+		"content_type": artifact.ContentType,
+		"lines":        len(lines),
+		"note": fmt.Sprintf("The output is too large to show (about %d tokens) and was stored as '%s'. %s, reading only the parts you need.",
+			tokens, artifact.ID, tools),
+		"preview": previewLines(lines, artifactPreviewLines),
+	}
+	if isJSON {
+		reference["structure"] = jsonShape(value, 0)
+	}
+	return reference
+}
+
+// previewLines returns the first n lines, each cut to 200 characters
+func previewLines(lines []string, n int) string {
+	preview := make([]string, 0, min(n, len(lines)))
+	for _, line := range lines[:min(n, len(lines))] {
+		if runes := []rune(line); len(runes) > 200 {
+			line = string(runes[:200]) + "…"
+		}
+		preview = append(preview, line)
+	}
+	return strings.Join(preview, "\n")
+}
+
+// jsonDocument returns output as a decoded JSON object or array, decoding JSON text outputs
+func jsonDocument(output any) (any, bool) {
+	var data []byte
+	if text, ok := output.(string); ok {
+		data = []byte(strings.TrimSpace(text))
+	} else {
+		var err error
+		if data, err = json.Marshal(output); err != nil {
+			return nil, false
+		}
+	}
+	if len(data) == 0 || data[0] != '{' && data[0] != '[' {
+		return nil, false
+	}
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// jsonShape describes the structure of a decoded JSON value, e.g. {"items": [240 × {"id": number}]}
+func jsonShape(value any, depth int) string {
+	switch v := value.(type) {
+	case map[string]any:
+		if len(v) == 0 {
+			return "{}"
+		}
+		if depth >= 3 {
+			return fmt.Sprintf("{%d keys}", len(v))
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		fields := make([]string, 0, min(len(keys), maxShapeKeys)+1)
+		for _, key := range keys[:min(len(keys), maxShapeKeys)] {
+			fields = append(fields, fmt.Sprintf("%q: %s", key, jsonShape(v[key], depth+1)))
+		}
+		if len(keys) > maxShapeKeys {
+			fields = append(fields, fmt.Sprintf("... %d more keys", len(keys)-maxShapeKeys))
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	case []any:
+		if len(v) == 0 {
+			return "[]"
+		}
+		// Items are assumed to share the structure of the first one
+		return fmt.Sprintf("[%d × %s]", len(v), jsonShape(v[0], depth+1))
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return "null"
+}
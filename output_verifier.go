@@ -0,0 +1,128 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// OutputVerificationRequest describes a final output awaiting verification
+type OutputVerificationRequest struct {
+	// Agent is the name of the agent completing the task
+	Agent string
+
+	// RunID is the ID of the run
+	RunID string
+
+	// Output is the final output of the run, a string for direct answers
+	Output any
+
+	// Attempt is the number of outputs submitted by the run so far, starting at 1
+	Attempt int
+
+	// Locale is the locale of the request, empty if none was set
+	Locale string
+
+	// Feedback are the feedback templates of the locale, to write localized verdicts
+	Feedback *FeedbackTemplates
+}
+
+// OutputVerdict is the decision of an OutputVerifier
+type OutputVerdict struct {
+	// Accepted completes the run with the output
+	Accepted bool
+
+	// Feedback tells the model what to change in a rejected output
+	Feedback string
+}
+
+// OutputVerifier reviews the final output of runs before they complete, e.g. by asking a human
+// to approve a draft. Rejected outputs are sent back to the model with the feedback and the run
+// continues. Returning an error fails the run.
+type OutputVerifier interface {
+	VerifyOutput(ctx context.Context, req *OutputVerificationRequest) (OutputVerdict, error)
+}
+
+// OutputVerifierFunc adapts a function to the OutputVerifier interface
+type OutputVerifierFunc func(ctx context.Context, req *OutputVerificationRequest) (OutputVerdict, error)
+
+// VerifyOutput calls f
+func (f OutputVerifierFunc) VerifyOutput(ctx context.Context, req *OutputVerificationRequest) (OutputVerdict, error) {
+	return f(ctx, req)
+}
+
+// ChainOutputVerifiers returns a verifier submitting outputs to verifiers in order.
+// Outputs are accepted when all verifiers accept them, the first rejection or error is returned.
+func ChainOutputVerifiers(verifiers ...OutputVerifier) OutputVerifier {
+	return OutputVerifierFunc(func(ctx context.Context, req *OutputVerificationRequest) (OutputVerdict, error) {
+		for _, verifier := range verifiers {
+			verdict, err := verifier.VerifyOutput(ctx, req)
+			if err != nil || !verdict.Accepted {
+				return verdict, err
+			}
+		}
+		return OutputVerdict{Accepted: true}, nil
+	})
+}
+
+// WithOutputVerifier sets the verifier of the final outputs of runs.
+// Rejections use up iterations, runs whose outputs keep being rejected end with ErrMaxIterations.
+func WithOutputVerifier(verifier OutputVerifier) RunnerOption {
+	return func(c *runnerConfig) {
+		c.verifier = verifier
+	}
+}
+
+// verifyOutput submits output to the runner's verifier, if any. On rejection, it emits an
+// output_rejected event and sends the feedback to the model, as the result of toolCall
+// unless the output is a direct answer, returning false.
+func (r *BaseRunner) verifyOutput(ctx context.Context, run *agentRun, toolCall *llm.ToolCall, output any) (bool, error) {
+	if r.verifier == nil {
+		return true, nil
+	}
+	run.outputAttempts++
+	verdict, err := r.verifier.VerifyOutput(ctx, &OutputVerificationRequest{
+		Agent:    run.agent.Name,
+		RunID:    run.agentContext.RunID,
+		Output:   output,
+		Attempt:  run.outputAttempts,
+		Locale:   run.req.Locale,
+		Feedback: run.templates,
+	})
+	if err != nil {
+		return false, fmt.Errorf("output verification failed: %w", err)
+	}
+	if verdict.Accepted {
+		return true, nil
+	}
+
+	feedback := verdict.Feedback
+	if feedback == "" {
+		feedback = run.templates.Rejected
+	}
+	run.events.emit(AgentEvent{
+		Type:         AgentEventTypeOutputRejected,
+		Output:       output,
+		ErrorMessage: &feedback,
+	})
+	message := fmt.Sprintf(run.templates.OutputRejected, feedback)
+	if toolCall == nil {
+		run.messages = append(run.messages, &llm.ModelMessage{
+			Role:    llm.RoleUser,
+			Content: message,
+		})
+		return false, nil
+	}
+	// The rejection answers the completion call, keeping the call and its result together
+	run.messages = append(run.messages, &llm.ModelMessage{
+		Role: llm.RoleTool,
+		ToolCall: &llm.ToolCall{
+			ID:           toolCall.ID,
+			Name:         toolCall.Name,
+			Input:        toolCall.Input,
+			ErrorMessage: &message,
+		},
+	})
+	return false, nil
+}
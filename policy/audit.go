@@ -0,0 +1,57 @@
+package policy
+
+import (
+	"context"
+	"time"
+
+	"github.com/easyagent-dev/agent"
+)
+
+// AuditRecord is the decision of a policy on a tool call
+type AuditRecord struct {
+	Time time.Time `json:"time"`
+
+	RunID  string `json:"runId"`
+	Agent  string `json:"agent"`
+	Tenant string `json:"tenant,omitempty"`
+
+	Tool  string         `json:"tool"`
+	Input map[string]any `json:"input,omitempty"`
+
+	// Rule is the rule that decided, DefaultRuleName when none matched
+	Rule    string `json:"rule"`
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// Auditor receives the decision of every tool call evaluated by an engine.
+// Audit is called synchronously from the agent loop, so auditors doing I/O should queue the records.
+type Auditor interface {
+	Audit(ctx context.Context, record *AuditRecord)
+}
+
+// AuditorFunc adapts a function to the Auditor interface
+type AuditorFunc func(ctx context.Context, record *AuditRecord)
+
+// Audit calls f
+func (f AuditorFunc) Audit(ctx context.Context, record *AuditRecord) {
+	f(ctx, record)
+}
+
+// LogAuditor returns an auditor logging every decision to logger, denials as warnings
+func LogAuditor(logger agent.Logger) Auditor {
+	return AuditorFunc(func(ctx context.Context, record *AuditRecord) {
+		args := []any{
+			"runId", record.RunID,
+			"agent", record.Agent,
+			"tenant", record.Tenant,
+			"tool", record.Tool,
+			"rule", record.Rule,
+		}
+		if record.Allowed {
+			logger.Info("tool call allowed by policy", args...)
+			return
+		}
+		logger.Warn("tool call denied by policy", append(args, "reason", record.Reason)...)
+	})
+}
@@ -0,0 +1,244 @@
+package policy
+
+import (
+	"context"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/easyagent-dev/agent"
+)
+
+// Option is a functional option for configuring engines
+type Option func(*Engine)
+
+// WithAuditor sets the auditor receiving the decision of every tool call
+func WithAuditor(auditor Auditor) Option {
+	return func(e *Engine) {
+		e.auditor = auditor
+	}
+}
+
+// Engine evaluates tool calls against a policy. Set it with agent.WithToolPolicy.
+// This type is safe for concurrent use, and its policy can be replaced while runs are active.
+type Engine struct {
+	policy  atomic.Pointer[compiledPolicy]
+	auditor Auditor
+}
+
+var _ agent.ToolPolicy = (*Engine)(nil)
+
+// New creates an engine enforcing policy
+func New(policy *Policy, opts ...Option) (*Engine, error) {
+	engine := &Engine{}
+	for _, opt := range opts {
+		opt(engine)
+	}
+	if err := engine.Update(policy); err != nil {
+		return nil, err
+	}
+	return engine, nil
+}
+
+// Update replaces the policy, e.g. after its file changed. An invalid policy is rejected and the
+// current one kept.
+func (e *Engine) Update(policy *Policy) error {
+	compiled, err := policy.compile()
+	if err != nil {
+		return err
+	}
+	e.policy.Store(compiled)
+	return nil
+}
+
+// EvaluateToolCall returns the decision of the first rule matching the call, or the default action
+func (e *Engine) EvaluateToolCall(ctx context.Context, req *agent.ToolPolicyRequest) (agent.ToolPolicyDecision, error) {
+	policy := e.policy.Load()
+	tenant := req.Metadata[policy.tenantKey]
+	decision := agent.ToolPolicyDecision{Allowed: policy.allowByDefault, Rule: DefaultRuleName}
+	for i, rule := range policy.rules {
+		if !rule.match(req, tenant) {
+			continue
+		}
+		decision = agent.ToolPolicyDecision{Allowed: rule.allow, Rule: rule.Name, Reason: rule.Reason}
+		if decision.Rule == "" {
+			decision.Rule = "#" + strconv.Itoa(i+1)
+		}
+		break
+	}
+	if !decision.Allowed && decision.Reason == "" {
+		decision.Reason = "the call is not allowed by policy rule " + decision.Rule
+	}
+
+	if e.auditor != nil {
+		e.auditor.Audit(ctx, &AuditRecord{
+			Time:    time.Now(),
+			RunID:   req.RunID,
+			Agent:   req.Agent,
+			Tenant:  tenant,
+			Tool:    req.ToolCall.Name,
+			Input:   req.ToolCall.Input,
+			Rule:    decision.Rule,
+			Allowed: decision.Allowed,
+			Reason:  decision.Reason,
+		})
+	}
+	return decision, nil
+}
+
+// match reports whether the rule applies to the call
+func (r *compiledRule) match(req *agent.ToolPolicyRequest, tenant string) bool {
+	if !matchAny(r.Tools, req.ToolCall.Name) || !matchAny(r.Agents, req.Agent) {
+		return false
+	}
+	if len(r.Tenants) > 0 && !slices.Contains(r.Tenants, tenant) {
+		return false
+	}
+	if len(r.effects) > 0 && !slices.Contains(r.effects, req.Effect) {
+		return false
+	}
+	for key, pattern := range r.Metadata {
+		value, ok := req.Metadata[key]
+		if !ok {
+			return false
+		}
+		if matched, _ := path.Match(pattern, value); !matched {
+			return false
+		}
+	}
+	for _, condition := range r.conditions {
+		if !condition.holds(req.ToolCall.Input) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchAny reports whether name matches one of patterns, or patterns is empty
+func matchAny(patterns []string, name string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// holds evaluates the condition on input
+func (c *compiledCondition) holds(input map[string]any) bool {
+	values, found := fieldValues(input, c.path)
+	if c.Exists != nil && found != *c.Exists {
+		return false
+	}
+	if !found {
+		return c.Exists != nil
+	}
+	if c.equals == nil && c.in == nil && c.matches == nil && c.Min == nil && c.Max == nil {
+		return true
+	}
+	for _, value := range values {
+		if c.test(value) != c.Not {
+			return true
+		}
+	}
+	return false
+}
+
+// test reports whether value passes the value tests
+func (c *compiledCondition) test(value any) bool {
+	text, scalar := scalarText(value)
+	if c.equals != nil && (!scalar || text != *c.equals) {
+		return false
+	}
+	if c.in != nil && (!scalar || !slices.Contains(c.in, text)) {
+		return false
+	}
+	if c.matches != nil && (!scalar || !c.matches.MatchString(text)) {
+		return false
+	}
+	if c.Min != nil || c.Max != nil {
+		number, ok := value.(float64)
+		if !ok {
+			parsed, err := strconv.ParseFloat(text, 64)
+			if !scalar || err != nil {
+				return false
+			}
+			number = parsed
+		}
+		if c.Min != nil && number < *c.Min {
+			return false
+		}
+		if c.Max != nil && number > *c.Max {
+			return false
+		}
+	}
+	return true
+}
+
+// splitField splits a dot-separated field path
+func splitField(field string) []string {
+	return strings.Split(field, ".")
+}
+
+// fieldValues returns the values at path in input, the elements of arrays flattened
+func fieldValues(input any, path []string) ([]any, bool) {
+	if len(path) == 0 {
+		if elements, ok := input.([]any); ok {
+			return elements, true
+		}
+		return []any{input}, true
+	}
+	switch value := input.(type) {
+	case map[string]any:
+		child, ok := value[path[0]]
+		if !ok {
+			return nil, false
+		}
+		return fieldValues(child, path[1:])
+	case []any:
+		if index, err := strconv.Atoi(path[0]); err == nil {
+			if index < 0 || index >= len(value) {
+				return nil, false
+			}
+			return fieldValues(value[index], path[1:])
+		}
+		// A field of array elements, e.g. items.id
+		var values []any
+		for _, element := range value {
+			if elementValues, ok := fieldValues(element, path); ok {
+				values = append(values, elementValues...)
+			}
+		}
+		return values, len(values) > 0
+	default:
+		return nil, false
+	}
+}
+
+// scalarText returns the text of a string, number or boolean, numbers without trailing zeros
+func scalarText(value any) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), true
+	case int:
+		return strconv.Itoa(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case uint64:
+		return strconv.FormatUint(v, 10), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,243 @@
+// Package policy evaluates tool calls against declarative allow and deny rules, so security teams
+// can constrain agents without code changes. Rules match the tool, the agent, the tenant, the run
+// metadata and the input of calls; the first matching rule decides.
+//
+//	default: allow
+//	tenant_key: tenant_id
+//	rules:
+//	  - name: no-external-email
+//	    action: deny
+//	    tools: [send_email]
+//	    input:
+//	      - field: to
+//	        matches: "@acme\\.dev$"
+//	        not: true
+//	    reason: emails can only be sent to acme.dev addresses
+//	  - name: trial-tenants-read-only
+//	    action: deny
+//	    effects: [mutating, destructive]
+//	    metadata:
+//	      plan: trial
+package policy
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+
+	"github.com/easyagent-dev/agent"
+	"gopkg.in/yaml.v3"
+)
+
+// Rule actions
+const (
+	ActionAllow = "allow"
+	ActionDeny  = "deny"
+)
+
+// DefaultTenantKey is the metadata key of the tenant when the policy sets none
+const DefaultTenantKey = "tenant_id"
+
+// DefaultRuleName names the decisions taken by the default action
+const DefaultRuleName = "default"
+
+// Policy is a declarative set of rules
+type Policy struct {
+	// Default is the action when no rule matches, allow if empty
+	Default string `yaml:"default,omitempty" json:"default,omitempty"`
+
+	// TenantKey is the metadata key holding the tenant of runs, DefaultTenantKey if empty
+	TenantKey string `yaml:"tenant_key,omitempty" json:"tenant_key,omitempty"`
+
+	// Rules are evaluated in order, the first matching rule decides
+	Rules []Rule `yaml:"rules" json:"rules"`
+}
+
+// Rule allows or denies the tool calls it matches. Empty criteria match every call.
+type Rule struct {
+	// Name identifies the rule in audits
+	Name string `yaml:"name" json:"name"`
+
+	// Action is allow or deny
+	Action string `yaml:"action" json:"action"`
+
+	// Tools and Agents are name patterns, see path.Match
+	Tools  []string `yaml:"tools,omitempty" json:"tools,omitempty"`
+	Agents []string `yaml:"agents,omitempty" json:"agents,omitempty"`
+
+	// Tenants are the tenants the rule applies to
+	Tenants []string `yaml:"tenants,omitempty" json:"tenants,omitempty"`
+
+	// Effects are the tool effects the rule applies to: read_only, mutating or destructive
+	Effects []string `yaml:"effects,omitempty" json:"effects,omitempty"`
+
+	// Metadata are patterns the run metadata must match, see path.Match
+	Metadata map[string]string `yaml:"metadata,omitempty" json:"metadata,omitempty"`
+
+	// Input are conditions on the input fields, all of which must hold
+	Input []Condition `yaml:"input,omitempty" json:"input,omitempty"`
+
+	// Reason tells the model why its call was denied
+	Reason string `yaml:"reason,omitempty" json:"reason,omitempty"`
+}
+
+// Condition checks an input field. All the set tests must hold, and fields holding an array pass
+// when one of their elements does. Tests on a missing field fail, unless Exists is false.
+type Condition struct {
+	// Field is the dot-separated path of the field, e.g. "options.region" or "items.0.id"
+	Field string `yaml:"field" json:"field"`
+
+	// Exists tests whether the field is set
+	Exists *bool `yaml:"exists,omitempty" json:"exists,omitempty"`
+
+	// Equals and In compare the field as text, so 5 equals "5"
+	Equals any   `yaml:"equals,omitempty" json:"equals,omitempty"`
+	In     []any `yaml:"in,omitempty" json:"in,omitempty"`
+
+	// Matches is a regular expression the field must match, see regexp/syntax
+	Matches string `yaml:"matches,omitempty" json:"matches,omitempty"`
+
+	// Min and Max bound numeric fields
+	Min *float64 `yaml:"min,omitempty" json:"min,omitempty"`
+	Max *float64 `yaml:"max,omitempty" json:"max,omitempty"`
+
+	// Not negates the value tests, but not Exists: with an array, one element must fail the tests
+	Not bool `yaml:"not,omitempty" json:"not,omitempty"`
+}
+
+// Load reads a policy from a YAML or JSON file
+func Load(file string) (*Policy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse parses and validates a YAML or JSON policy
+func Parse(data []byte) (*Policy, error) {
+	var policy Policy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %w", err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// Validate checks the actions, patterns and regular expressions of the policy
+func (p *Policy) Validate() error {
+	_, err := p.compile()
+	return err
+}
+
+// compiledPolicy is a validated Policy
+type compiledPolicy struct {
+	allowByDefault bool
+	tenantKey      string
+	rules          []*compiledRule
+}
+
+type compiledRule struct {
+	*Rule
+	allow      bool
+	effects    []agent.ToolEffect
+	conditions []*compiledCondition
+}
+
+type compiledCondition struct {
+	*Condition
+	path    []string
+	equals  *string
+	in      []string
+	matches *regexp.Regexp
+}
+
+func (p *Policy) compile() (*compiledPolicy, error) {
+	compiled := &compiledPolicy{tenantKey: p.TenantKey}
+	if compiled.tenantKey == "" {
+		compiled.tenantKey = DefaultTenantKey
+	}
+	switch p.Default {
+	case "", ActionAllow:
+		compiled.allowByDefault = true
+	case ActionDeny:
+	default:
+		return nil, fmt.Errorf("invalid default action '%s', expected allow or deny", p.Default)
+	}
+
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		name := rule.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		c := &compiledRule{Rule: rule}
+		switch rule.Action {
+		case ActionAllow:
+			c.allow = true
+		case ActionDeny:
+		default:
+			return nil, fmt.Errorf("rule %s: invalid action '%s', expected allow or deny", name, rule.Action)
+		}
+		for _, patterns := range [][]string{rule.Tools, rule.Agents} {
+			for _, pattern := range patterns {
+				if _, err := path.Match(pattern, ""); err != nil {
+					return nil, fmt.Errorf("rule %s: invalid pattern '%s': %w", name, pattern, err)
+				}
+			}
+		}
+		for key, pattern := range rule.Metadata {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("rule %s: invalid pattern '%s' of metadata %s: %w", name, pattern, key, err)
+			}
+		}
+		for _, effectName := range rule.Effects {
+			effect, err := agent.ParseToolEffect(effectName)
+			if err != nil || effect == agent.ToolEffectUnspecified {
+				return nil, fmt.Errorf("rule %s: invalid tool effect '%s', expected read_only, mutating or destructive", name, effectName)
+			}
+			c.effects = append(c.effects, effect)
+		}
+		for j := range rule.Input {
+			condition, err := compileCondition(&rule.Input[j])
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: %w", name, err)
+			}
+			c.conditions = append(c.conditions, condition)
+		}
+		compiled.rules = append(compiled.rules, c)
+	}
+	return compiled, nil
+}
+
+func compileCondition(condition *Condition) (*compiledCondition, error) {
+	if condition.Field == "" {
+		return nil, fmt.Errorf("input condition without field")
+	}
+	c := &compiledCondition{Condition: condition, path: splitField(condition.Field)}
+	if condition.Equals != nil {
+		text, ok := scalarText(condition.Equals)
+		if !ok {
+			return nil, fmt.Errorf("input condition on %s: equals must be a scalar", condition.Field)
+		}
+		c.equals = &text
+	}
+	for _, value := range condition.In {
+		text, ok := scalarText(value)
+		if !ok {
+			return nil, fmt.Errorf("input condition on %s: in must list scalars", condition.Field)
+		}
+		c.in = append(c.in, text)
+	}
+	if condition.Matches != "" {
+		matches, err := regexp.Compile(condition.Matches)
+		if err != nil {
+			return nil, fmt.Errorf("input condition on %s: invalid regular expression: %w", condition.Field, err)
+		}
+		c.matches = matches
+	}
+	return c, nil
+}
@@ -0,0 +1,138 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// PreparedPrompt is the system prompt of an agent rendered by Prepare
+type PreparedPrompt struct {
+	Agent string `json:"agent"`
+
+	// Tokens and ToolsTokens are the size of the prompt without the completion tool of the requests
+	Tokens      int `json:"tokens"`
+	ToolsTokens int `json:"toolsTokens"`
+}
+
+// PrepareReport tells what Prepare did, for observability of cold starts
+type PrepareReport struct {
+	// Prompts are the system prompts parsed and rendered, one per agent and handoff target
+	Prompts []PreparedPrompt `json:"prompts"`
+
+	// Schemas is the number of tool input schemas marshaled
+	Schemas int `json:"schemas"`
+
+	// Model is the result of the model ping, nil if the model was not pinged
+	Model *HealthCheckResult `json:"model,omitempty"`
+
+	// Capabilities are the capabilities of the model, nil if they are not known
+	Capabilities *Capabilities `json:"capabilities,omitempty"`
+
+	PreparedAt time.Time     `json:"preparedAt"`
+	Duration   time.Duration `json:"duration"`
+}
+
+// PreparableRunner is implemented by the runners of this package
+type PreparableRunner interface {
+	// Prepare does the work of the first request ahead of time, e.g. at startup
+	Prepare(ctx context.Context, opts ...PrepareOption) (*PrepareReport, error)
+
+	// Prepared returns the report of the last Prepare call, nil if the runner was not prepared
+	Prepared() *PrepareReport
+}
+
+var (
+	_ PreparableRunner = (*JSONCompletionRunner)(nil)
+	_ PreparableRunner = (*JSONCompletionStreamRunner)(nil)
+	_ PreparableRunner = (*XMLCompletionRunner)(nil)
+	_ PreparableRunner = (*XMLCompletionStreamRunner)(nil)
+)
+
+// PrepareOption is a functional option for Prepare
+type PrepareOption func(*prepareConfig)
+
+// prepareConfig holds the options of a Prepare call
+type prepareConfig struct {
+	pingModel bool
+}
+
+// WithModelPing sets whether Prepare pings the model, false by default. The ping uses the model's
+// HealthChecker or a minimal completion call, opening the connection to the provider.
+func WithModelPing(enabled bool) PrepareOption {
+	return func(c *prepareConfig) {
+		c.pingModel = enabled
+	}
+}
+
+// Prepare minimizes the latency of the first request, e.g. in serverless or cold-start environments:
+// it marshals the input schemas of the tools, parses and renders the system prompt templates of
+// the agent and its handoff targets, loads the tokenizer and optionally pings the model.
+// It may be called again, e.g. after tools were registered. A failed model ping is reported
+// and returned as an error after the other steps are done.
+func (r *BaseRunner) Prepare(ctx context.Context, opts ...PrepareOption) (*PrepareReport, error) {
+	config := &prepareConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	start := time.Now()
+	report := &PrepareReport{PreparedAt: start, Capabilities: r.capabilities}
+	names := make([]string, 0, len(r.agents))
+	for name := range r.agents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		agent, toolRegistry := r.agents[name], r.toolRegistries[name]
+		for _, tool := range toolRegistry.GetTools() {
+			if _, err := toolRegistry.GetInputSchemaJSON(tool.Name()); err != nil {
+				return nil, err
+			}
+			report.Schemas++
+		}
+		toolsPrompt, err := r.registryToolsPrompt(agent, toolRegistry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create tools prompt of agent %s: %w", name, err)
+		}
+		prompt, err := r.renderSystemPrompt(agent, r.capabilities, toolsPrompt)
+		if err != nil {
+			return nil, fmt.Errorf("agent %s: %w", name, err)
+		}
+		report.Prompts = append(report.Prompts, PreparedPrompt{
+			Agent:       name,
+			Tokens:      r.tokenizer.CountTokens(prompt),
+			ToolsTokens: r.tokenizer.CountTokens(toolsPrompt),
+		})
+		if _, err := llm.GetPrompts(directSystemPrompt, map[string]interface{}{"agent": agent}); err != nil {
+			return nil, fmt.Errorf("agent %s: failed to get prompts: %w", name, err)
+		}
+	}
+
+	var err error
+	if config.pingModel {
+		result := runHealthCheck(ctx, "model", func(ctx context.Context) error {
+			return CheckModelHealth(ctx, r.model)
+		})
+		report.Model = &result
+		if !result.Healthy {
+			err = fmt.Errorf("%w: model: %s", ErrUnhealthy, result.Error)
+		}
+	}
+	report.Duration = time.Since(start)
+	r.prepared.Store(report)
+	r.logger.Info("runner prepared",
+		"agent", r.agent.Name,
+		"prompts", len(report.Prompts),
+		"schemas", report.Schemas,
+		"duration", report.Duration)
+	return report, err
+}
+
+// Prepared returns the report of the last Prepare call, nil if the runner was not prepared
+func (r *BaseRunner) Prepared() *PrepareReport {
+	return r.prepared.Load()
+}
@@ -0,0 +1,145 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// ModelPrice is the price of a model in a pricing catalog
+type ModelPrice struct {
+	// Pricing holds the token, request and cache prices in USD
+	Pricing llm.ModelPricing `json:"pricing" yaml:"pricing"`
+
+	// PerHour is charged for the duration of every call in USD, for self-hosted models billed by the hour
+	PerHour float64 `json:"perHour,omitempty" yaml:"perHour,omitempty"`
+}
+
+// Cost returns the cost of a model call with usage lasting duration
+func (p ModelPrice) Cost(usage *llm.TokenUsage, duration time.Duration) float64 {
+	cost := p.PerHour * duration.Hours()
+	if usage == nil {
+		return cost + p.Pricing.Request
+	}
+	// Cached tokens are counted in the input tokens, they are billed at the cache read price when set
+	input := usage.TotalInputTokens
+	if p.Pricing.InputCacheRead > 0 {
+		input -= usage.TotalCacheReadTokens
+		cost += float64(usage.TotalCacheReadTokens) * p.Pricing.InputCacheRead / 1e6
+	}
+	cost += float64(input)*p.Pricing.Prompt/1e6 +
+		float64(usage.TotalOutputTokens)*p.Pricing.Completion/1e6 +
+		float64(usage.TotalReasoningTokens)*p.Pricing.InternalReasoning/1e6 +
+		float64(usage.TotalCacheWriteTokens)*p.Pricing.InputCacheWrite/1e6 +
+		float64(usage.TotalImages)*p.Pricing.Image +
+		float64(usage.TotalWebSearches)*p.Pricing.WebSearch +
+		p.Pricing.Request
+	return cost
+}
+
+// PricingCatalog returns the prices of models, overriding the costs computed by the llm layer
+type PricingCatalog interface {
+	// Price returns the price of a model of provider, false if the model is unknown
+	Price(provider, model string) (ModelPrice, bool)
+}
+
+// StaticPricingCatalog is a pricing catalog keyed by provider and model.
+// This type is safe for concurrent use.
+type StaticPricingCatalog struct {
+	mu     sync.RWMutex
+	prices map[string]ModelPrice
+}
+
+var _ PricingCatalog = (*StaticPricingCatalog)(nil)
+
+// NewStaticPricingCatalog creates an empty pricing catalog
+func NewStaticPricingCatalog() *StaticPricingCatalog {
+	return &StaticPricingCatalog{prices: make(map[string]ModelPrice)}
+}
+
+// Set sets the price of a model of provider. An empty provider matches the model of any provider.
+func (c *StaticPricingCatalog) Set(provider, model string, price ModelPrice) *StaticPricingCatalog {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.prices[provider+"/"+model] = price
+	return c
+}
+
+// Price returns the price of the model of provider, falling back to its price for any provider
+func (c *StaticPricingCatalog) Price(provider, model string) (ModelPrice, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if price, ok := c.prices[provider+"/"+model]; ok {
+		return price, true
+	}
+	price, ok := c.prices["/"+model]
+	return price, ok
+}
+
+// UnknownModelPolicy decides the cost of calls to models missing from the pricing catalog
+type UnknownModelPolicy string
+
+const (
+	// UnknownModelKeep keeps the cost computed by the llm layer
+	UnknownModelKeep UnknownModelPolicy = "keep"
+
+	// UnknownModelWarn keeps the cost computed by the llm layer and logs a warning
+	UnknownModelWarn UnknownModelPolicy = "warn"
+
+	// UnknownModelZero prices the calls at 0
+	UnknownModelZero UnknownModelPolicy = "zero"
+
+	// UnknownModelFail fails the run with ErrUnknownModelPrice
+	UnknownModelFail UnknownModelPolicy = "fail"
+)
+
+// ParseUnknownModelPolicy parses the name of an unknown model policy, UnknownModelKeep if empty
+func ParseUnknownModelPolicy(name string) (UnknownModelPolicy, error) {
+	switch policy := UnknownModelPolicy(strings.ToLower(name)); policy {
+	case "":
+		return UnknownModelKeep, nil
+	case UnknownModelKeep, UnknownModelWarn, UnknownModelZero, UnknownModelFail:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid unknown model policy '%s', expected keep, warn, zero or fail", name)
+	}
+}
+
+// WithPricingCatalog makes the runner compute the cost of model calls from catalog instead of the
+// llm layer's prices. Calls to models missing from the catalog are handled by policy.
+func WithPricingCatalog(catalog PricingCatalog, policy UnknownModelPolicy) RunnerOption {
+	return func(c *runnerConfig) {
+		c.pricing = catalog
+		c.unknownModelPolicy = policy
+	}
+}
+
+// priceTurn replaces the cost of a model call with its price in the runner's pricing catalog, if any
+func (r *BaseRunner) priceTurn(run *agentRun, turn *modelTurn) error {
+	if r.pricing == nil {
+		return nil
+	}
+	price, ok := r.pricing.Price(run.agent.ModelProvider, run.agent.Model)
+	if ok {
+		cost := price.Cost(turn.usage, turn.duration)
+		turn.cost = &cost
+		return nil
+	}
+
+	switch r.unknownModelPolicy {
+	case UnknownModelWarn:
+		r.logger.Warn("model missing from pricing catalog",
+			"agent", run.agent.Name,
+			"provider", run.agent.ModelProvider,
+			"model", run.agent.Model)
+	case UnknownModelZero:
+		cost := 0.0
+		turn.cost = &cost
+	case UnknownModelFail:
+		return fmt.Errorf("%w: %s/%s", ErrUnknownModelPrice, run.agent.ModelProvider, run.agent.Model)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"path"
+)
+
+// PromptVariant is a system prompt template used instead of the runner's default for
+// agents of a specific provider or model, e.g. terser instructions for one model family
+// or reinforced format rules for small local models
+type PromptVariant struct {
+	// Provider matches Agent.ModelProvider. If empty, any provider matches.
+	Provider string
+
+	// Model is a path.Match pattern matched against Agent.Model, e.g. "llama3*".
+	// If empty, any model matches.
+	Model string
+
+	// Capabilities are the capabilities the model must have, e.g. Capabilities{Reasoning: true}.
+	// Variants requiring capabilities do not match models whose capabilities are not known.
+	Capabilities Capabilities
+
+	// Prompt is the system prompt template, with the same variables as the default one
+	Prompt string
+}
+
+// validate checks the variant has a prompt and a valid model pattern
+func (v PromptVariant) validate() error {
+	if v.Prompt == "" {
+		return errors.New("prompt is required")
+	}
+	if _, err := path.Match(v.Model, ""); err != nil {
+		return fmt.Errorf("invalid model pattern '%s': %w", v.Model, err)
+	}
+	return nil
+}
+
+// specificity scores how specifically the variant matches agent running on a model with capabilities,
+// or returns -1 if it does not match. A provider match outweighs a model pattern match, and an exact
+// model name outweighs a pattern, which outweighs required capabilities.
+func (v PromptVariant) specificity(agent *Agent, capabilities *Capabilities) int {
+	score := 0
+	if v.Capabilities != (Capabilities{}) {
+		if capabilities == nil || !capabilities.covers(v.Capabilities) {
+			return -1
+		}
+		score++
+	}
+	if v.Provider != "" {
+		if v.Provider != agent.ModelProvider {
+			return -1
+		}
+		score += 8
+	}
+	if v.Model != "" {
+		if v.Model == agent.Model {
+			score += 4
+		} else if matched, _ := path.Match(v.Model, agent.Model); matched {
+			score += 2
+		} else {
+			return -1
+		}
+	}
+	return score
+}
+
+// selectPromptVariant returns the prompt of the most specific variant matching agent and capabilities.
+// Ties go to the variant registered first. It returns false if no variant matches.
+func selectPromptVariant(variants []PromptVariant, agent *Agent, capabilities *Capabilities) (string, bool) {
+	best := -1
+	prompt := ""
+	for _, variant := range variants {
+		if score := variant.specificity(agent, capabilities); score > best {
+			best = score
+			prompt = variant.Prompt
+		}
+	}
+	return prompt, best >= 0
+}
@@ -0,0 +1,190 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// QuotaLimit identifies a limit of a tenant quota
+type QuotaLimit string
+
+const (
+	// QuotaConcurrentRuns limits the runs of a tenant executing at the same time
+	QuotaConcurrentRuns QuotaLimit = "concurrent_runs"
+
+	// QuotaRunsPerMinute limits the runs a tenant starts within a minute
+	QuotaRunsPerMinute QuotaLimit = "runs_per_minute"
+
+	// QuotaMonthlyCost limits the cost a tenant spends in a calendar month (UTC)
+	QuotaMonthlyCost QuotaLimit = "monthly_cost"
+)
+
+// QuotaExceededError is returned when a run would exceed a quota of its tenant.
+// It matches ErrQuotaExceeded with errors.Is.
+type QuotaExceededError struct {
+	Tenant string
+	Limit  QuotaLimit
+
+	// Max is the value of the exceeded limit
+	Max float64
+
+	// RetryAfter is when the run would be allowed again, zero if unknown
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("quota exceeded for tenant '%s': %s limit %g", e.Tenant, e.Limit, e.Max)
+}
+
+// Is reports whether target is ErrQuotaExceeded
+func (e *QuotaExceededError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// QuotaManager admits the runs of tenants.
+// Acquire returns a *QuotaExceededError when the run is not admitted, or a release function
+// the runner calls once with the cost of the run when it ends.
+type QuotaManager interface {
+	Acquire(ctx context.Context, tenant string) (release func(cost float64), err error)
+}
+
+// QuotaLimits are the limits of a tenant. Zero values are unlimited.
+type QuotaLimits struct {
+	MaxConcurrentRuns int
+	MaxRunsPerMinute  int
+	MaxMonthlyCost    float64
+}
+
+// QuotaUsage is the current consumption of a tenant
+type QuotaUsage struct {
+	ConcurrentRuns int
+	RunsLastMinute int
+	MonthlyCost    float64
+}
+
+// tenantQuota is the consumption state of a tenant
+type tenantQuota struct {
+	running int
+	starts  []time.Time
+
+	// month is the calendar month cost is accumulated for
+	month time.Month
+	year  int
+	cost  float64
+}
+
+// MemoryQuotaManager enforces quota limits within a single process.
+// This type is safe for concurrent use.
+type MemoryQuotaManager struct {
+	mu       sync.Mutex
+	defaults QuotaLimits
+	limits   map[string]QuotaLimits
+	tenants  map[string]*tenantQuota
+}
+
+var _ QuotaManager = (*MemoryQuotaManager)(nil)
+
+// NewMemoryQuotaManager creates a quota manager applying defaults to tenants without specific limits
+func NewMemoryQuotaManager(defaults QuotaLimits) *MemoryQuotaManager {
+	return &MemoryQuotaManager{
+		defaults: defaults,
+		limits:   make(map[string]QuotaLimits),
+		tenants:  make(map[string]*tenantQuota),
+	}
+}
+
+// SetLimits sets the limits of a tenant instead of the defaults
+func (m *MemoryQuotaManager) SetLimits(tenant string, limits QuotaLimits) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.limits[tenant] = limits
+}
+
+// Acquire admits a run of tenant if none of its limits is reached
+func (m *MemoryQuotaManager) Acquire(ctx context.Context, tenant string) (func(cost float64), error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	limits, ok := m.limits[tenant]
+	if !ok {
+		limits = m.defaults
+	}
+	now := time.Now()
+	quota := m.tenant(tenant, now)
+
+	if limits.MaxConcurrentRuns > 0 && quota.running >= limits.MaxConcurrentRuns {
+		return nil, &QuotaExceededError{Tenant: tenant, Limit: QuotaConcurrentRuns, Max: float64(limits.MaxConcurrentRuns)}
+	}
+	if limits.MaxRunsPerMinute > 0 && len(quota.starts) >= limits.MaxRunsPerMinute {
+		return nil, &QuotaExceededError{
+			Tenant:     tenant,
+			Limit:      QuotaRunsPerMinute,
+			Max:        float64(limits.MaxRunsPerMinute),
+			RetryAfter: quota.starts[len(quota.starts)-limits.MaxRunsPerMinute].Add(time.Minute).Sub(now),
+		}
+	}
+	if limits.MaxMonthlyCost > 0 && quota.cost >= limits.MaxMonthlyCost {
+		utc := now.UTC()
+		nextMonth := time.Date(utc.Year(), utc.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		return nil, &QuotaExceededError{Tenant: tenant, Limit: QuotaMonthlyCost, Max: limits.MaxMonthlyCost, RetryAfter: nextMonth.Sub(now)}
+	}
+
+	quota.running++
+	quota.starts = append(quota.starts, now)
+	var once sync.Once
+	return func(cost float64) {
+		once.Do(func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			quota := m.tenant(tenant, time.Now())
+			quota.running--
+			quota.cost += cost
+		})
+	}, nil
+}
+
+// Usage returns the current consumption of a tenant
+func (m *MemoryQuotaManager) Usage(tenant string) QuotaUsage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	quota := m.tenant(tenant, time.Now())
+	return QuotaUsage{
+		ConcurrentRuns: quota.running,
+		RunsLastMinute: len(quota.starts),
+		MonthlyCost:    quota.cost,
+	}
+}
+
+// tenant returns the state of a tenant, dropping starts older than a minute and the cost of past months.
+// The caller must hold the lock.
+func (m *MemoryQuotaManager) tenant(tenant string, now time.Time) *tenantQuota {
+	quota, ok := m.tenants[tenant]
+	if !ok {
+		quota = &tenantQuota{}
+		m.tenants[tenant] = quota
+	}
+	cutoff := now.Add(-time.Minute)
+	i := 0
+	for i < len(quota.starts) && !quota.starts[i].After(cutoff) {
+		i++
+	}
+	quota.starts = quota.starts[i:]
+
+	utc := now.UTC()
+	if quota.year != utc.Year() || quota.month != utc.Month() {
+		quota.year, quota.month, quota.cost = utc.Year(), utc.Month(), 0
+	}
+	return quota
+}
+
+// WithQuotaManager makes the runner admit every run through manager, using the value of
+// request metadata key as the tenant. Requests without the key share the empty tenant.
+func WithQuotaManager(manager QuotaManager, tenantKey string) RunnerOption {
+	return func(c *runnerConfig) {
+		c.quotas = manager
+		c.tenantKey = tenantKey
+	}
+}
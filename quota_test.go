@@ -0,0 +1,60 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// scriptedModel answers completions with its responses in order, then fails
+type scriptedModel struct {
+	responses []*llm.CompletionResponse
+	calls     int
+}
+
+var _ llm.CompletionModel = (*scriptedModel)(nil)
+
+func (m *scriptedModel) Complete(ctx context.Context, req *llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	m.calls++
+	if m.calls > len(m.responses) {
+		return nil, errors.New("model unavailable")
+	}
+	return m.responses[m.calls-1], nil
+}
+
+func (m *scriptedModel) StreamComplete(ctx context.Context, req *llm.CompletionRequest) (llm.StreamCompletionResponse, error) {
+	return nil, errors.New("streaming not supported")
+}
+
+func TestQuotaChargesFailedRuns(t *testing.T) {
+	cost := 0.25
+	model := &scriptedModel{responses: []*llm.CompletionResponse{
+		{Output: `{"name": "search", "input": {`, Usage: &llm.TokenUsage{}, Cost: &cost},
+	}}
+	quotas := NewMemoryQuotaManager(QuotaLimits{})
+	runner, err := NewJSONCompletionRunner(cacheTestAgent, model, WithQuotaManager(quotas, "tenant"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := newCacheTestRequest()
+	req.MaxRetries = 1
+	req.Metadata = map[string]string{"tenant": "acme"}
+	resp, err := runner.Run(context.Background(), req, nil)
+	if err == nil {
+		t.Fatalf("Run() = %v, want an error", resp)
+	}
+	if model.calls != 2 {
+		t.Fatalf("model calls = %d, want 2", model.calls)
+	}
+
+	usage := quotas.Usage("acme")
+	if usage.MonthlyCost != cost {
+		t.Errorf("MonthlyCost = %v, want %v", usage.MonthlyCost, cost)
+	}
+	if usage.ConcurrentRuns != 0 {
+		t.Errorf("ConcurrentRuns = %d, want 0", usage.ConcurrentRuns)
+	}
+}
@@ -0,0 +1,102 @@
+package rag
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Splitter splits the text of a document into chunk texts
+type Splitter func(text string) []string
+
+// DefaultChunkSize is the maximum chunk size in characters of the default splitter
+const DefaultChunkSize = 1000
+
+// DefaultChunkOverlap is the overlap in characters between chunks of the default splitter
+const DefaultChunkOverlap = 100
+
+// FixedSizeSplitter splits text into chunks of at most size characters, each repeating up to
+// overlap characters of the previous one so sentences cut at a boundary keep their context.
+// Chunks end at whitespace where possible.
+func FixedSizeSplitter(size, overlap int) Splitter {
+	if overlap >= size {
+		overlap = 0
+	}
+	return func(text string) []string {
+		return splitFixed(text, size, overlap)
+	}
+}
+
+// ParagraphSplitter splits text at blank lines, merging consecutive paragraphs into chunks of at most
+// size characters. Paragraphs longer than size are split by FixedSizeSplitter without overlap.
+func ParagraphSplitter(size int) Splitter {
+	return func(text string) []string {
+		var chunks []string
+		var current strings.Builder
+		flush := func() {
+			if current.Len() > 0 {
+				chunks = append(chunks, current.String())
+				current.Reset()
+			}
+		}
+		for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+			paragraph = strings.TrimSpace(paragraph)
+			if paragraph == "" {
+				continue
+			}
+			length := utf8.RuneCountInString(paragraph)
+			if length > size {
+				flush()
+				chunks = append(chunks, splitFixed(paragraph, size, 0)...)
+				continue
+			}
+			if current.Len() > 0 && utf8.RuneCountInString(current.String())+2+length > size {
+				flush()
+			}
+			if current.Len() > 0 {
+				current.WriteString("\n\n")
+			}
+			current.WriteString(paragraph)
+		}
+		flush()
+		return chunks
+	}
+}
+
+// splitFixed splits text into chunks of at most size runes overlapping by overlap runes
+func splitFixed(text string, size, overlap int) []string {
+	runes := []rune(strings.TrimSpace(text))
+	var chunks []string
+	for start := 0; start < len(runes); {
+		end := min(start+size, len(runes))
+		if end < len(runes) {
+			// Cut at the last whitespace of the second half of the chunk, if any
+			for i := end; i > start+size/2; i-- {
+				if isSpace(runes[i-1]) {
+					end = i
+					break
+				}
+			}
+		}
+		if chunk := strings.TrimSpace(string(runes[start:end])); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		if end == len(runes) {
+			break
+		}
+		next := end - overlap
+		// Start the overlap at a word
+		for next > start && next < end && !isSpace(runes[next-1]) {
+			next++
+		}
+		if next <= start || next >= end {
+			next = end
+		}
+		start = next
+	}
+	return chunks
+}
+
+// isSpace reports whether r is whitespace
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\n' || r == '\t' || r == '\r'
+}
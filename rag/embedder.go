@@ -0,0 +1,59 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// Embedder computes the embeddings of texts
+type Embedder interface {
+	// Embed returns the embeddings of texts, in order
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+// ModelEmbedder computes embeddings with an embedding model
+type ModelEmbedder struct {
+	model     llm.EmbeddingModel
+	modelName string
+	batchSize int
+}
+
+var _ Embedder = (*ModelEmbedder)(nil)
+
+// DefaultEmbeddingBatchSize is the number of texts sent in a single embedding request
+const DefaultEmbeddingBatchSize = 64
+
+// NewModelEmbedder creates an embedder calling model with the model name modelName
+func NewModelEmbedder(model llm.EmbeddingModel, modelName string) *ModelEmbedder {
+	return &ModelEmbedder{model: model, modelName: modelName, batchSize: DefaultEmbeddingBatchSize}
+}
+
+// WithBatchSize sets the number of texts sent in a single embedding request
+func (e *ModelEmbedder) WithBatchSize(size int) *ModelEmbedder {
+	e.batchSize = size
+	return e
+}
+
+// Embed returns the embeddings of texts, batching the requests to the model
+func (e *ModelEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	embeddings := make([][]float64, len(texts))
+	for start := 0; start < len(texts); start += e.batchSize {
+		end := min(start+e.batchSize, len(texts))
+		resp, err := e.model.GenerateEmbeddings(ctx, &llm.EmbeddingRequest{
+			Model:    e.modelName,
+			Contents: texts[start:end],
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate embeddings: %w", err)
+		}
+		if len(resp.Embeddings) != end-start {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", end-start, len(resp.Embeddings))
+		}
+		for i, embedding := range resp.Embeddings {
+			embeddings[start+i] = embedding.Embedding
+		}
+	}
+	return embeddings, nil
+}
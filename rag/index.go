@@ -0,0 +1,95 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Index splits, embeds and stores documents, and searches them by text
+type Index struct {
+	store    DocumentStore
+	embedder Embedder
+	splitter Splitter
+}
+
+// IndexOption is a functional option for configuring indexes
+type IndexOption func(*Index)
+
+// WithSplitter sets how documents are split into chunks,
+// FixedSizeSplitter(DefaultChunkSize, DefaultChunkOverlap) by default
+func WithSplitter(splitter Splitter) IndexOption {
+	return func(i *Index) {
+		i.splitter = splitter
+	}
+}
+
+// NewIndex creates an index storing chunks in store, embedded by embedder
+func NewIndex(store DocumentStore, embedder Embedder, opts ...IndexOption) *Index {
+	index := &Index{
+		store:    store,
+		embedder: embedder,
+		splitter: FixedSizeSplitter(DefaultChunkSize, DefaultChunkOverlap),
+	}
+	for _, opt := range opts {
+		opt(index)
+	}
+	return index
+}
+
+// Add splits, embeds and stores documents, replacing the chunks of documents added before
+func (i *Index) Add(ctx context.Context, documents ...*Document) error {
+	for _, document := range documents {
+		if document.ID == "" {
+			return errors.New("document ID is required")
+		}
+		var chunks []*Chunk
+		var texts []string
+		for n, text := range i.splitter(document.Text) {
+			chunks = append(chunks, &Chunk{
+				ID:         fmt.Sprintf("%s#%d", document.ID, n),
+				DocumentID: document.ID,
+				Index:      n,
+				Text:       text,
+				Metadata:   document.Metadata,
+			})
+			texts = append(texts, text)
+		}
+
+		if len(texts) > 0 {
+			embeddings, err := i.embedder.Embed(ctx, texts)
+			if err != nil {
+				return fmt.Errorf("failed to embed document '%s': %w", document.ID, err)
+			}
+			for n, chunk := range chunks {
+				chunk.Embedding = embeddings[n]
+			}
+		}
+
+		// The previous chunks are only removed once the new ones are embedded
+		if err := i.store.Delete(ctx, document.ID); err != nil {
+			return fmt.Errorf("failed to delete document '%s': %w", document.ID, err)
+		}
+		if len(chunks) == 0 {
+			continue
+		}
+		if err := i.store.Add(ctx, chunks); err != nil {
+			return fmt.Errorf("failed to store document '%s': %w", document.ID, err)
+		}
+	}
+	return nil
+}
+
+// Delete removes a document from the index
+func (i *Index) Delete(ctx context.Context, documentID string) error {
+	return i.store.Delete(ctx, documentID)
+}
+
+// Search returns the k chunks most relevant to query, most relevant first
+func (i *Index) Search(ctx context.Context, query string, k int) ([]Result, error) {
+	embeddings, err := i.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	return i.store.Query(ctx, embeddings[0], k)
+}
@@ -0,0 +1,138 @@
+// Package rag assembles retrieval-augmented agents: documents are split into chunks, embedded and
+// stored in a DocumentStore, and a retrieval tool lets the model search them for the chunks most
+// relevant to its query.
+//
+//	index := rag.NewIndex(rag.NewMemoryStore(), rag.NewModelEmbedder(embeddingModel, "text-embedding-3-small"))
+//	err := index.Add(ctx, &rag.Document{ID: "handbook", Text: handbook})
+//	myAgent.Tools = append(myAgent.Tools, rag.NewRetrievalTool(index))
+package rag
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"sync"
+)
+
+// ErrDimensionMismatch is returned when embeddings of different dimensions are compared
+var ErrDimensionMismatch = errors.New("embedding dimension mismatch")
+
+// Document is a text to retrieve from
+type Document struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+
+	// Metadata is copied to the chunks of the document, e.g. its title or URL
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Chunk is a part of a document, the unit stored and retrieved
+type Chunk struct {
+	// ID is the document ID followed by the index of the chunk in the document
+	ID         string            `json:"id"`
+	DocumentID string            `json:"documentId"`
+	Index      int               `json:"index"`
+	Text       string            `json:"text"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	Embedding  []float64         `json:"embedding,omitempty"`
+}
+
+// Result is a chunk matching a query
+type Result struct {
+	Chunk *Chunk `json:"chunk"`
+
+	// Score is the similarity of the chunk to the query, higher is closer
+	Score float64 `json:"score"`
+}
+
+// DocumentStore stores embedded chunks and finds the ones closest to a query embedding
+type DocumentStore interface {
+	// Add stores chunks, replacing the chunks with the same IDs
+	Add(ctx context.Context, chunks []*Chunk) error
+
+	// Query returns the k chunks most similar to embedding, most similar first
+	Query(ctx context.Context, embedding []float64, k int) ([]Result, error)
+
+	// Delete removes the chunks of a document
+	Delete(ctx context.Context, documentID string) error
+}
+
+// MemoryStore keeps chunks in memory and ranks them by cosine similarity, scanning all of them.
+// It is safe for concurrent use by multiple goroutines.
+type MemoryStore struct {
+	mu     sync.RWMutex
+	chunks map[string]*Chunk
+}
+
+var _ DocumentStore = (*MemoryStore)(nil)
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{chunks: make(map[string]*Chunk)}
+}
+
+// Add stores chunks
+func (s *MemoryStore) Add(ctx context.Context, chunks []*Chunk) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, chunk := range chunks {
+		stored := *chunk
+		s.chunks[chunk.ID] = &stored
+	}
+	return nil
+}
+
+// Query returns the k chunks with the highest cosine similarity to embedding
+func (s *MemoryStore) Query(ctx context.Context, embedding []float64, k int) ([]Result, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	results := make([]Result, 0, len(s.chunks))
+	for _, chunk := range s.chunks {
+		score, err := CosineSimilarity(embedding, chunk.Embedding)
+		if err != nil {
+			return nil, err
+		}
+		stored := *chunk
+		results = append(results, Result{Chunk: &stored, Score: score})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Chunk.ID < results[j].Chunk.ID
+	})
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results, nil
+}
+
+// Delete removes the chunks of a document
+func (s *MemoryStore) Delete(ctx context.Context, documentID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, chunk := range s.chunks {
+		if chunk.DocumentID == documentID {
+			delete(s.chunks, id)
+		}
+	}
+	return nil
+}
+
+// CosineSimilarity returns the cosine similarity of a and b, 0 if either is a zero vector
+func CosineSimilarity(a, b []float64) (float64, error) {
+	if len(a) != len(b) {
+		return 0, ErrDimensionMismatch
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0, nil
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB)), nil
+}
@@ -0,0 +1,142 @@
+package rag
+
+import (
+	"context"
+	"errors"
+
+	"github.com/easyagent-dev/agent"
+	"github.com/easyagent-dev/llm"
+)
+
+// RetrievalToolName is the default name of the retrieval tool
+const RetrievalToolName = "search_documents"
+
+// DefaultTopK is the default number of chunks returned by the retrieval tool
+const DefaultTopK = 5
+
+// RetrievalInput is the input of the retrieval tool
+type RetrievalInput struct {
+	Query string `json:"query" jsonschema:"required,description=What to look for in the documents, phrased as a question or keywords"`
+}
+
+// RetrievalOption is a functional option for configuring retrieval tools
+type RetrievalOption func(*RetrievalTool)
+
+// WithToolName sets the name of the retrieval tool, e.g. to register several indexes
+func WithToolName(name string) RetrievalOption {
+	return func(t *RetrievalTool) {
+		t.name = name
+	}
+}
+
+// WithToolDescription describes the documents searched by the tool, helping the model decide when to use it
+func WithToolDescription(description string) RetrievalOption {
+	return func(t *RetrievalTool) {
+		t.description = description
+	}
+}
+
+// WithTopK sets the number of chunks returned by the tool
+func WithTopK(k int) RetrievalOption {
+	return func(t *RetrievalTool) {
+		t.topK = k
+	}
+}
+
+// WithMinScore drops the chunks scoring less than score
+func WithMinScore(score float64) RetrievalOption {
+	return func(t *RetrievalTool) {
+		t.minScore = score
+	}
+}
+
+// RetrievalTool lets the model search an index, returning the text of the top-k chunks
+type RetrievalTool struct {
+	index       *Index
+	name        string
+	description string
+	topK        int
+	minScore    float64
+}
+
+var (
+	_ agent.ModelTool  = (*RetrievalTool)(nil)
+	_ agent.EffectTool = (*RetrievalTool)(nil)
+)
+
+// NewRetrievalTool creates a tool searching index
+func NewRetrievalTool(index *Index, opts ...RetrievalOption) *RetrievalTool {
+	tool := &RetrievalTool{
+		index:       index,
+		name:        RetrievalToolName,
+		description: "Searches the knowledge base and returns the passages most relevant to a query",
+		topK:        DefaultTopK,
+	}
+	for _, opt := range opts {
+		opt(tool)
+	}
+	return tool
+}
+
+// Name returns the name of the tool
+func (t *RetrievalTool) Name() string {
+	return t.name
+}
+
+// Description returns a description of what the tool does
+func (t *RetrievalTool) Description() string {
+	return t.description
+}
+
+// InputSchema returns the input schema of the tool
+func (t *RetrievalTool) InputSchema() any {
+	return llm.GenerateSchema[RetrievalInput]()
+}
+
+// OutputSchema returns the output schema of the tool
+func (t *RetrievalTool) OutputSchema() any {
+	return nil
+}
+
+// Usage returns an example of how to use the tool
+func (t *RetrievalTool) Usage() string {
+	return `{"query":"refund policy for damaged items"}`
+}
+
+// Effect returns agent.ToolEffectReadOnly, searching has no side effects
+func (t *RetrievalTool) Effect() agent.ToolEffect {
+	return agent.ToolEffectReadOnly
+}
+
+// Passage is a chunk returned to the model
+type Passage struct {
+	Source   string            `json:"source"`
+	Text     string            `json:"text"`
+	Score    float64           `json:"score"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Run searches the index for the query
+func (t *RetrievalTool) Run(ctx context.Context, input map[string]any) (any, error) {
+	query, _ := input["query"].(string)
+	if query == "" {
+		return nil, errors.New("query is required")
+	}
+	results, err := t.index.Search(ctx, query, t.topK)
+	if err != nil {
+		return nil, err
+	}
+	passages := make([]Passage, 0, len(results))
+	for _, result := range results {
+		if result.Score < t.minScore {
+			continue
+		}
+		passages = append(passages, Passage{
+			Source:   result.Chunk.ID,
+			Text:     result.Chunk.Text,
+			Score:    result.Score,
+			Metadata: result.Chunk.Metadata,
+		})
+	}
+	return map[string]any{"passages": passages}, nil
+}
@@ -0,0 +1,99 @@
+package agent
+
+import (
+	"context"
+	"strings"
+)
+
+// DefaultReasoningSummaryLength is the length in characters of reasoning summaries made by TruncateReasoning
+const DefaultReasoningSummaryLength = 280
+
+// ReasoningPolicy controls what runners do with model reasoning: reasoning chunks streamed by the
+// model and, with the XML format, the text written before the tool call.
+// It applies to reasoning events and to the model outputs passed to callbacks such as TranscriptCallback.
+type ReasoningPolicy string
+
+const (
+	// ReasoningKeep streams reasoning events and passes reasoning to callbacks. This is the default.
+	ReasoningKeep ReasoningPolicy = "keep"
+
+	// ReasoningStreamOnly streams reasoning events flagged as Ephemeral, which must not be persisted,
+	// and removes reasoning from the outputs passed to callbacks
+	ReasoningStreamOnly ReasoningPolicy = "stream_only"
+
+	// ReasoningSummarize emits a single reasoning event per model call with the summary of its
+	// reasoning, and removes reasoning from the outputs passed to callbacks
+	ReasoningSummarize ReasoningPolicy = "summarize"
+
+	// ReasoningDrop never emits reasoning events and removes reasoning from the outputs passed to callbacks.
+	// Text streamed as a partial direct answer before the model turned to a tool call is not retracted.
+	ReasoningDrop ReasoningPolicy = "drop"
+)
+
+// ReasoningSummarizer summarizes the reasoning of a model call for ReasoningSummarize
+type ReasoningSummarizer func(ctx context.Context, reasoning string) (string, error)
+
+// TruncateReasoning returns a summarizer keeping the first max characters of the reasoning
+func TruncateReasoning(max int) ReasoningSummarizer {
+	return func(ctx context.Context, reasoning string) (string, error) {
+		runes := []rune(reasoning)
+		if len(runes) <= max {
+			return reasoning, nil
+		}
+		return strings.TrimSpace(string(runes[:max])) + "…", nil
+	}
+}
+
+// WithReasoningPolicy sets what the runner does with model reasoning, ReasoningKeep by default
+func WithReasoningPolicy(policy ReasoningPolicy) RunnerOption {
+	return func(c *runnerConfig) {
+		c.reasoningPolicy = policy
+	}
+}
+
+// WithReasoningSummarizer sets the summarizer used by ReasoningSummarize,
+// TruncateReasoning(DefaultReasoningSummaryLength) by default
+func WithReasoningSummarizer(summarizer ReasoningSummarizer) RunnerOption {
+	return func(c *runnerConfig) {
+		c.reasoningSummarizer = summarizer
+	}
+}
+
+// emitReasoning emits reasoning streamed during a model call according to the reasoning policy
+func (r *BaseRunner) emitReasoning(run *agentRun, reasoning *string) {
+	switch r.reasoningPolicy {
+	case ReasoningKeep:
+		run.events.emit(AgentEvent{Type: AgentEventTypeReasoning, Reasoning: reasoning})
+	case ReasoningStreamOnly:
+		run.events.emit(AgentEvent{Type: AgentEventTypeReasoning, Reasoning: reasoning, Ephemeral: true})
+	}
+}
+
+// redactReasoning applies the reasoning policy once a model call ended and its output was parsed.
+// It removes reasoning from the output of the turn and emits its summary.
+func (r *BaseRunner) redactReasoning(ctx context.Context, run *agentRun, turn *modelTurn) {
+	if r.reasoningPolicy == ReasoningKeep {
+		return
+	}
+	prefix, rest := r.format.splitReasoning(turn.output)
+	turn.output = rest
+	if r.reasoningPolicy != ReasoningSummarize || run.events == nil {
+		return
+	}
+
+	var parts []string
+	for _, part := range []string{turn.reasoning, prefix} {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return
+	}
+	summary, err := r.reasoningSummarizer(ctx, strings.Join(parts, "\n\n"))
+	if err != nil {
+		r.logger.Warn("failed to summarize reasoning", "agent", run.agent.Name, "error", err)
+		return
+	}
+	run.events.emit(AgentEvent{Type: AgentEventTypeReasoning, Reasoning: &summary})
+}
@@ -0,0 +1,187 @@
+package agent
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/easyagent-dev/llm"
+)
+
+//go:embed prompts/router_system.md
+var routerSystemPrompt string
+
+// Route maps a classification label to the runner handling it
+type Route struct {
+	// Label is the classification label
+	Label string
+
+	// Description tells the classifier which requests belong to this label
+	Description string
+
+	// Runner handles requests classified with this label
+	Runner Runner
+}
+
+// Classification is the result of a routing decision
+type Classification struct {
+	Label  string `json:"label"`
+	Reason string `json:"reason"`
+}
+
+// RouterOption is a functional option for configuring routers
+type RouterOption func(*Router)
+
+// WithRouterFallback sets the runner used when the classifier returns an unknown label
+func WithRouterFallback(runner Runner) RouterOption {
+	return func(r *Router) {
+		r.fallback = runner
+	}
+}
+
+// WithRouterInstructions adds custom instructions to the classification prompt
+func WithRouterInstructions(instructions string) RouterOption {
+	return func(r *Router) {
+		r.instructions = instructions
+	}
+}
+
+// Router runs a small classification prompt with a fixed label schema and dispatches
+// the request to the runner registered for the label, for intent-routing front doors.
+type Router struct {
+	model        llm.CompletionModel
+	routes       []Route
+	fallback     Runner
+	instructions string
+}
+
+var _ Runner = (*Router)(nil)
+
+// NewRouter creates a router classifying with model
+func NewRouter(model llm.CompletionModel, routes []Route, opts ...RouterOption) (*Router, error) {
+	if len(routes) == 0 {
+		return nil, errors.New("at least one route is required")
+	}
+	seen := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		if route.Label == "" {
+			return nil, errors.New("route label is required")
+		}
+		if seen[route.Label] {
+			return nil, fmt.Errorf("duplicate route label '%s'", route.Label)
+		}
+		if route.Runner == nil {
+			return nil, fmt.Errorf("route '%s' has no runner", route.Label)
+		}
+		seen[route.Label] = true
+	}
+
+	router := &Router{
+		model:  model,
+		routes: routes,
+	}
+	for _, opt := range opts {
+		opt(router)
+	}
+	return router, nil
+}
+
+// Labels returns the labels the router can classify into
+func (r *Router) Labels() []string {
+	labels := make([]string, 0, len(r.routes))
+	for _, route := range r.routes {
+		labels = append(labels, route.Label)
+	}
+	return labels
+}
+
+// Classify returns the label of the conversation along with the usage and cost of the classification call
+func (r *Router) Classify(ctx context.Context, messages []*llm.ModelMessage) (*Classification, *llm.CompletionResponse, error) {
+	schema, err := json.Marshal(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"label":  map[string]any{"type": "string", "enum": r.Labels()},
+			"reason": map[string]any{"type": "string"},
+		},
+		"required": []string{"label"},
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal label schema: %w", err)
+	}
+
+	prompts, err := llm.GetPrompts(routerSystemPrompt, map[string]interface{}{
+		"labels":       r.routes,
+		"instructions": r.instructions,
+		"schema":       string(schema),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get prompts: %w", err)
+	}
+
+	output, err := r.model.Complete(ctx, &llm.CompletionRequest{
+		Instructions: prompts,
+		Messages:     messages,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("classification failed: %w", err)
+	}
+
+	classification := &Classification{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output.Output)), classification); err != nil {
+		return nil, output, fmt.Errorf("failed to parse classification: %w", err)
+	}
+	return classification, output, nil
+}
+
+// Route returns the runner for a label, or the fallback runner if the label is unknown
+func (r *Router) Route(label string) (Runner, error) {
+	for _, route := range r.routes {
+		if route.Label == label {
+			return route.Runner, nil
+		}
+	}
+	if r.fallback != nil {
+		return r.fallback, nil
+	}
+	return nil, fmt.Errorf("%w: '%s'", ErrNoRoute, label)
+}
+
+// Run classifies the request and runs it with the selected runner.
+// Usage and cost of the classification call are included in the response.
+func (r *Router) Run(ctx context.Context, req *AgentRequest, callback Callback) (*AgentResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+
+	classification, output, err := r.Classify(ctx, req.Messages)
+	var runner Runner
+	if err != nil {
+		if r.fallback == nil {
+			return nil, err
+		}
+		runner = r.fallback
+	} else if runner, err = r.Route(classification.Label); err != nil {
+		return nil, err
+	}
+
+	resp, err := runner.Run(ctx, req, callback)
+	if resp != nil && output != nil {
+		if resp.Usage == nil {
+			resp.Usage = &llm.TokenUsage{}
+		}
+		if output.Usage != nil {
+			resp.Usage.Append(output.Usage)
+		}
+		if output.Cost != nil {
+			cost := *output.Cost
+			if resp.Cost != nil {
+				cost += *resp.Cost
+			}
+			resp.Cost = &cost
+		}
+	}
+	return resp, err
+}
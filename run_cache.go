@@ -0,0 +1,254 @@
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// RunCache stores agent responses by request fingerprint
+type RunCache interface {
+	// Get returns the cached response for key, if present and not expired
+	Get(ctx context.Context, key string) (*AgentResponse, bool, error)
+
+	// Set stores a response for key. If ttl is 0, the entry never expires.
+	Set(ctx context.Context, key string, resp *AgentResponse, ttl time.Duration) error
+}
+
+// memoryRunCacheEntry is a cached response with its expiration time
+type memoryRunCacheEntry struct {
+	resp      *AgentResponse
+	expiresAt time.Time
+}
+
+// MemoryRunCache is an in-memory RunCache. Expired entries are removed lazily.
+// This type is safe for concurrent use.
+type MemoryRunCache struct {
+	mu      sync.RWMutex
+	entries map[string]memoryRunCacheEntry
+}
+
+var _ RunCache = (*MemoryRunCache)(nil)
+
+// NewMemoryRunCache creates an empty in-memory run cache
+func NewMemoryRunCache() *MemoryRunCache {
+	return &MemoryRunCache{
+		entries: make(map[string]memoryRunCacheEntry),
+	}
+}
+
+// Get returns the cached response for key
+func (c *MemoryRunCache) Get(ctx context.Context, key string) (*AgentResponse, bool, error) {
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, false, nil
+	}
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		c.mu.Lock()
+		delete(c.entries, key)
+		c.mu.Unlock()
+		return nil, false, nil
+	}
+	return entry.resp, true, nil
+}
+
+// Set stores a response for key
+func (c *MemoryRunCache) Set(ctx context.Context, key string, resp *AgentResponse, ttl time.Duration) error {
+	entry := memoryRunCacheEntry{resp: resp}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = entry
+	return nil
+}
+
+// fingerprintTool is the cache-relevant part of a tool
+type fingerprintTool struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	InputSchema any    `json:"inputSchema"`
+}
+
+// RunFingerprint returns a hash of the agent configuration and the request
+// (messages, output schema, options and caller identity), identifying requests producing the same run.
+// Requests of different tenants, sessions or credentials providers never share a fingerprint.
+func RunFingerprint(agent *Agent, req *AgentRequest) (string, error) {
+	agent = withModelRef(agent, req.Model)
+	tools := make([]fingerprintTool, 0, len(agent.Tools)+len(req.Tools))
+	for _, tool := range append(append([]ModelTool{}, agent.Tools...), req.Tools...) {
+		tools = append(tools, fingerprintTool{
+			Name:        tool.Name(),
+			Description: tool.Description(),
+			InputSchema: tool.InputSchema(),
+		})
+	}
+	handoffs := make([]string, 0, len(agent.Handoffs))
+	for _, handoff := range agent.Handoffs {
+		handoffs = append(handoffs, handoff.Name)
+	}
+
+	data, err := json.Marshal(map[string]any{
+		"agent": map[string]any{
+			"name":          agent.Name,
+			"modelProvider": agent.ModelProvider,
+			"model":         agent.Model,
+			"description":   agent.Description,
+			"instructions":  agent.Instructions,
+			"handoffs":      handoffs,
+		},
+		"tools":            tools,
+		"messages":         req.Messages,
+		"outputSchema":     req.OutputSchema,
+		"outputUsage":      req.OutputUsage,
+		"maxIterations":    req.MaxIterations,
+		"directAnswer":     req.DirectAnswer,
+		"toolChoice":       req.ToolChoice,
+		"locale":           req.Locale,
+		"metadata":         req.Metadata,
+		"session":          identity(req.Session),
+		"credentials":      identity(req.Credentials),
+		"dryRun":           req.DryRun,
+		"allowDestructive": req.AllowDestructive,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request fingerprint: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// identity returns a string identifying v: the address of maps, pointers and functions,
+// and the value of anything else. It is empty for nil.
+func identity(v any) string {
+	if v == nil {
+		return ""
+	}
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Map, reflect.Pointer, reflect.Func, reflect.Chan, reflect.Slice, reflect.UnsafePointer:
+		if value.IsNil() {
+			return ""
+		}
+		return fmt.Sprintf("%T@%x", v, value.Pointer())
+	default:
+		return fmt.Sprintf("%T:%#v", v, v)
+	}
+}
+
+// CachingRunner returns cached responses for identical requests and runs the wrapped runner otherwise.
+// Concurrent identical requests share a single run, which protects against retry storms.
+// Only successful responses are cached. Requests with a Session or Credentials always run,
+// their responses may depend on per-user state the fingerprint cannot capture.
+type CachingRunner struct {
+	runner Runner
+	agent  *Agent
+	cache  RunCache
+	ttl    time.Duration
+
+	mu       sync.Mutex
+	inflight map[string]*cachedRun
+}
+
+// cachedRun is a run shared by concurrent identical requests
+type cachedRun struct {
+	done chan struct{}
+	resp *AgentResponse
+	err  error
+}
+
+var _ Runner = (*CachingRunner)(nil)
+
+// NewCachingRunner creates a runner caching the responses of runner, which runs agent.
+// If ttl is 0, cached responses never expire.
+func NewCachingRunner(runner Runner, agent *Agent, cache RunCache, ttl time.Duration) *CachingRunner {
+	return &CachingRunner{
+		runner:   runner,
+		agent:    agent,
+		cache:    cache,
+		ttl:      ttl,
+		inflight: make(map[string]*cachedRun),
+	}
+}
+
+// Run returns the cached response for the request, or runs it and caches the response.
+// The returned response is a copy, cached usage and cost are those of the original run.
+func (r *CachingRunner) Run(ctx context.Context, req *AgentRequest, callback Callback) (*AgentResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	if req.Session != nil || req.Credentials != nil {
+		return r.runner.Run(ctx, req, callback)
+	}
+
+	key, err := RunFingerprint(r.agent, req)
+	if err != nil {
+		return nil, err
+	}
+	resp, ok, err := r.cache.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run cache: %w", err)
+	}
+	if ok {
+		return copyResponse(resp), nil
+	}
+
+	r.mu.Lock()
+	if shared, ok := r.inflight[key]; ok {
+		r.mu.Unlock()
+		select {
+		case <-shared.done:
+			if shared.err != nil {
+				return nil, shared.err
+			}
+			return copyResponse(shared.resp), nil
+		case <-ctx.Done():
+			return nil, fmt.Errorf("context cancelled: %w", ctx.Err())
+		}
+	}
+	shared := &cachedRun{done: make(chan struct{})}
+	r.inflight[key] = shared
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		delete(r.inflight, key)
+		r.mu.Unlock()
+		close(shared.done)
+	}()
+
+	shared.resp, shared.err = r.runner.Run(ctx, req, callback)
+	if shared.err != nil {
+		return shared.resp, shared.err
+	}
+	if err := r.cache.Set(ctx, key, shared.resp, r.ttl); err != nil {
+		return shared.resp, fmt.Errorf("failed to write run cache: %w", err)
+	}
+	return copyResponse(shared.resp), nil
+}
+
+// copyResponse returns a shallow copy of resp so callers cannot modify the cached response fields
+func copyResponse(resp *AgentResponse) *AgentResponse {
+	copied := *resp
+	if resp.Usage != nil {
+		usage := *resp.Usage
+		copied.Usage = &usage
+	}
+	if resp.Cost != nil {
+		cost := *resp.Cost
+		copied.Cost = &cost
+	}
+	copied.ToolCalls = append([]*llm.ToolCall(nil), resp.ToolCalls...)
+	return &copied
+}
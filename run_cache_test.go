@@ -0,0 +1,164 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// countingRunner answers every request with the number of runs so far
+type countingRunner struct {
+	runs int
+}
+
+var _ Runner = (*countingRunner)(nil)
+
+func (r *countingRunner) Run(ctx context.Context, req *AgentRequest, callback Callback) (*AgentResponse, error) {
+	r.runs++
+	return &AgentResponse{Output: r.runs}, nil
+}
+
+// newCacheTestRequest returns a valid request with a single user message
+func newCacheTestRequest() *AgentRequest {
+	return &AgentRequest{
+		Messages:      []*llm.ModelMessage{{Role: llm.RoleUser, Content: "What is the weather in Paris?"}},
+		MaxIterations: 5,
+	}
+}
+
+var cacheTestAgent = &Agent{
+	Name:         "assistant",
+	Description:  "answers questions",
+	Instructions: "Answer the question",
+}
+
+func TestCachingRunnerSeparatesTenants(t *testing.T) {
+	runner := &countingRunner{}
+	caching := NewCachingRunner(runner, cacheTestAgent, NewMemoryRunCache(), 0)
+	ctx := context.Background()
+
+	for _, tenant := range []string{"acme", "globex", "acme", "globex"} {
+		req := newCacheTestRequest()
+		req.Metadata = map[string]string{"tenant": tenant}
+		if _, err := caching.Run(ctx, req, nil); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+	}
+	// Each tenant runs once and then hits its own entry only
+	if runner.runs != 2 {
+		t.Errorf("runs = %d, want 2", runner.runs)
+	}
+
+	first := newCacheTestRequest()
+	first.Metadata = map[string]string{"tenant": "acme"}
+	second := newCacheTestRequest()
+	second.Metadata = map[string]string{"tenant": "globex"}
+	firstKey, err := RunFingerprint(cacheTestAgent, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secondKey, err := RunFingerprint(cacheTestAgent, second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if firstKey == secondKey {
+		t.Error("RunFingerprint() is equal for requests of different tenants")
+	}
+}
+
+func TestCachingRunnerSkipsPerUserRequests(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(req *AgentRequest)
+	}{
+		{name: "session", modify: func(req *AgentRequest) { req.Session = map[string]any{} }},
+		{name: "credentials", modify: func(req *AgentRequest) {
+			req.Credentials = StaticCredentials{"github": {Type: "oauth2", Token: "secret"}}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &countingRunner{}
+			caching := NewCachingRunner(runner, cacheTestAgent, NewMemoryRunCache(), 0)
+			for i := 0; i < 2; i++ {
+				req := newCacheTestRequest()
+				tt.modify(req)
+				if _, err := caching.Run(context.Background(), req, nil); err != nil {
+					t.Fatalf("Run() error = %v", err)
+				}
+			}
+			if runner.runs != 2 {
+				t.Errorf("runs = %d, want 2", runner.runs)
+			}
+		})
+	}
+}
+
+func TestRunFingerprintSafetyFlags(t *testing.T) {
+	base, err := RunFingerprint(cacheTestAgent, newCacheTestRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		modify func(req *AgentRequest)
+	}{
+		{name: "dry run", modify: func(req *AgentRequest) { req.DryRun = true }},
+		{name: "allow destructive", modify: func(req *AgentRequest) { req.AllowDestructive = true }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newCacheTestRequest()
+			tt.modify(req)
+			got, err := RunFingerprint(cacheTestAgent, req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got == base {
+				t.Errorf("RunFingerprint() = %s, want it to differ from the request without %s", got, tt.name)
+			}
+		})
+	}
+}
+
+func TestCachingRunnerSeparatesDryRuns(t *testing.T) {
+	runner := &countingRunner{}
+	caching := NewCachingRunner(runner, cacheTestAgent, NewMemoryRunCache(), 0)
+
+	dryRun := newCacheTestRequest()
+	dryRun.DryRun = true
+	if _, err := caching.Run(context.Background(), dryRun, nil); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	resp, err := caching.Run(context.Background(), newCacheTestRequest(), nil)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	// A real run must not be served the simulated response of a dry run
+	if resp.Output != 2 {
+		t.Errorf("Output = %v, want 2", resp.Output)
+	}
+}
+
+func TestCachingRunnerSeparatesLocales(t *testing.T) {
+	runner := &countingRunner{}
+	caching := NewCachingRunner(runner, cacheTestAgent, NewMemoryRunCache(), 0)
+
+	for _, locale := range []string{"fr-FR", "en-US", "fr-FR"} {
+		req := newCacheTestRequest()
+		req.Locale = locale
+		resp, err := caching.Run(context.Background(), req, nil)
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		if locale == "en-US" && resp.Output != 2 {
+			t.Errorf("en-US Output = %v, want 2", resp.Output)
+		}
+	}
+	if runner.runs != 2 {
+		t.Errorf("runs = %d, want 2", runner.runs)
+	}
+}
@@ -0,0 +1,236 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// RunTrace is the transcript of a single run, as written by TranscriptCallback
+type RunTrace struct {
+	RunID   string             `json:"runId"`
+	Entries []*TranscriptEntry `json:"entries"`
+}
+
+// ReadRunTraces reads a JSONL transcript and splits it into runs, in the order they started
+func ReadRunTraces(r io.Reader) ([]*RunTrace, error) {
+	var traces []*RunTrace
+	byID := make(map[string]*RunTrace)
+	scanner := bufio.NewScanner(r)
+	// Entries hold whole prompts and histories
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		entry := &TranscriptEntry{}
+		if err := json.Unmarshal(scanner.Bytes(), entry); err != nil {
+			return nil, fmt.Errorf("invalid transcript entry at line %d: %w", line, err)
+		}
+		trace, ok := byID[entry.RunID]
+		if !ok {
+			trace = &RunTrace{RunID: entry.RunID}
+			byID[entry.RunID] = trace
+			traces = append(traces, trace)
+		}
+		trace.Entries = append(trace.Entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read transcript: %w", err)
+	}
+	return traces, nil
+}
+
+// TracedToolCall is a tool call of a run trace
+type TracedToolCall struct {
+	Tool  string `json:"tool"`
+	Input any    `json:"input,omitempty"`
+}
+
+// RunStats summarizes a run trace
+type RunStats struct {
+	// ModelCalls is the number of model calls, one per iteration
+	ModelCalls int `json:"modelCalls"`
+
+	// ToolCalls are the tool calls in order, including the completion tool
+	ToolCalls []*TracedToolCall `json:"toolCalls"`
+
+	Usage llm.TokenUsage `json:"usage"`
+
+	// Cost is computed with the pricing catalog of the diff options, 0 without one
+	Cost float64 `json:"cost"`
+
+	// DurationMs is the time spent in model calls and tools
+	DurationMs int64 `json:"durationMs"`
+
+	// Output is the result of the last completion tool call, nil if the run did not complete
+	Output any `json:"output,omitempty"`
+}
+
+// DiffOptions configures DiffRuns
+type DiffOptions struct {
+	// CompletionTool is the name of the completion tool, CompleteTaskToolName if empty
+	CompletionTool string
+
+	// Pricing prices the model calls, costs are 0 if nil
+	Pricing PricingCatalog
+}
+
+// OutputChangeKind is the kind of an output change
+type OutputChangeKind string
+
+const (
+	// OutputChangeAdded is a leaf only in the after output
+	OutputChangeAdded OutputChangeKind = "added"
+
+	// OutputChangeRemoved is a leaf only in the before output
+	OutputChangeRemoved OutputChangeKind = "removed"
+
+	// OutputChangeChanged is a leaf with different values
+	OutputChangeChanged OutputChangeKind = "changed"
+)
+
+// OutputChange is a leaf of the final output that differs between two runs
+type OutputChange struct {
+	Kind OutputChangeKind `json:"kind"`
+
+	// Path is the JSON pointer (RFC 6901) of the leaf
+	Path string `json:"path"`
+
+	Before any `json:"before,omitempty"`
+	After  any `json:"after,omitempty"`
+}
+
+// ToolCallDivergence is the first tool call at which two runs differ
+type ToolCallDivergence struct {
+	// Index is the position of the call in the tool calls of both runs
+	Index int `json:"index"`
+
+	// Before and After are the calls of each run, nil if the run made no call at Index
+	Before *TracedToolCall `json:"before,omitempty"`
+	After  *TracedToolCall `json:"after,omitempty"`
+}
+
+// RunDiff is the difference between two runs, e.g. before and after a prompt change.
+// Deltas are the after value minus the before value.
+type RunDiff struct {
+	Before *RunStats `json:"before"`
+	After  *RunStats `json:"after"`
+
+	IterationsDelta   int     `json:"iterationsDelta"`
+	ToolCallsDelta    int     `json:"toolCallsDelta"`
+	InputTokensDelta  int64   `json:"inputTokensDelta"`
+	OutputTokensDelta int64   `json:"outputTokensDelta"`
+	CostDelta         float64 `json:"costDelta"`
+	DurationMsDelta   int64   `json:"durationMsDelta"`
+
+	// Divergence is the first differing tool call, nil if both runs made the same calls
+	Divergence *ToolCallDivergence `json:"divergence,omitempty"`
+
+	// OutputChanges are the differing leaves of the final outputs, in document order
+	OutputChanges []*OutputChange `json:"outputChanges,omitempty"`
+}
+
+// Stats summarizes the trace
+func (t *RunTrace) Stats(opts DiffOptions) *RunStats {
+	completionTool := opts.CompletionTool
+	if completionTool == "" {
+		completionTool = CompleteTaskToolName
+	}
+	stats := &RunStats{ToolCalls: []*TracedToolCall{}}
+	for _, entry := range t.Entries {
+		stats.DurationMs += entry.DurationMs
+		switch entry.Type {
+		case TranscriptEntryModelResponse:
+			stats.ModelCalls++
+			if entry.Usage != nil {
+				stats.Usage.Append(entry.Usage)
+			}
+			if opts.Pricing != nil {
+				if price, ok := opts.Pricing.Price(entry.Provider, entry.Model); ok {
+					stats.Cost += price.Cost(entry.Usage, time.Duration(entry.DurationMs)*time.Millisecond)
+				}
+			}
+		case TranscriptEntryToolCall:
+			stats.ToolCalls = append(stats.ToolCalls, &TracedToolCall{Tool: entry.Tool, Input: entry.Input})
+		case TranscriptEntryToolResult:
+			if entry.Tool == completionTool {
+				stats.Output = entry.Result
+			}
+		}
+	}
+	return stats
+}
+
+// DiffRuns compares two run traces
+func DiffRuns(before, after *RunTrace, opts DiffOptions) *RunDiff {
+	diff := &RunDiff{
+		Before: before.Stats(opts),
+		After:  after.Stats(opts),
+	}
+	diff.IterationsDelta = diff.After.ModelCalls - diff.Before.ModelCalls
+	diff.ToolCallsDelta = len(diff.After.ToolCalls) - len(diff.Before.ToolCalls)
+	diff.InputTokensDelta = diff.After.Usage.TotalInputTokens - diff.Before.Usage.TotalInputTokens
+	diff.OutputTokensDelta = diff.After.Usage.TotalOutputTokens - diff.Before.Usage.TotalOutputTokens
+	diff.CostDelta = diff.After.Cost - diff.Before.Cost
+	diff.DurationMsDelta = diff.After.DurationMs - diff.Before.DurationMs
+	diff.Divergence = toolCallDivergence(diff.Before.ToolCalls, diff.After.ToolCalls)
+	diff.OutputChanges = diffOutputs(diff.Before.Output, diff.After.Output)
+	return diff
+}
+
+// toolCallDivergence returns the first position at which before and after differ
+func toolCallDivergence(before, after []*TracedToolCall) *ToolCallDivergence {
+	for i := 0; i < max(len(before), len(after)); i++ {
+		if i < len(before) && i < len(after) && before[i].Tool == after[i].Tool && reflect.DeepEqual(before[i].Input, after[i].Input) {
+			continue
+		}
+		divergence := &ToolCallDivergence{Index: i}
+		if i < len(before) {
+			divergence.Before = before[i]
+		}
+		if i < len(after) {
+			divergence.After = after[i]
+		}
+		return divergence
+	}
+	return nil
+}
+
+// diffOutputs returns the leaves added, removed or changed from before to after
+func diffOutputs(before, after any) []*OutputChange {
+	var beforeLeaves, afterLeaves []*OutputDelta
+	if before != nil {
+		newOutputDiffer().walk("", before, &beforeLeaves)
+	}
+	if after != nil {
+		newOutputDiffer().walk("", after, &afterLeaves)
+	}
+
+	previous := make(map[string]any, len(beforeLeaves))
+	for _, leaf := range beforeLeaves {
+		previous[leaf.Path] = leaf.Value
+	}
+	var changes []*OutputChange
+	for _, leaf := range afterLeaves {
+		value, ok := previous[leaf.Path]
+		delete(previous, leaf.Path)
+		switch {
+		case !ok:
+			changes = append(changes, &OutputChange{Kind: OutputChangeAdded, Path: leaf.Path, After: leaf.Value})
+		case !reflect.DeepEqual(value, leaf.Value):
+			changes = append(changes, &OutputChange{Kind: OutputChangeChanged, Path: leaf.Path, Before: value, After: leaf.Value})
+		}
+	}
+	for _, leaf := range beforeLeaves {
+		if _, ok := previous[leaf.Path]; ok {
+			changes = append(changes, &OutputChange{Kind: OutputChangeRemoved, Path: leaf.Path, Before: leaf.Value})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,1042 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/easyagent-dev/llm"
+	"github.com/google/uuid"
+)
+
+// agentRun holds the mutable state of a single agent execution
+type agentRun struct {
+	req      *AgentRequest
+	callback Callback
+	events   *eventEmitter
+
+	// agent is the active agent, which changes on handoff
+	agent *Agent
+
+	// model is the model of the run, the model of AgentRequest.Model if set
+	model llm.CompletionModel
+
+	// capabilities are the capabilities of model, nil if they are not known
+	capabilities *Capabilities
+
+	// toolRegistry holds the tools available to the active agent for this run
+	toolRegistry *ToolRegistry
+
+	// prompts is the rendered system prompt, valid while promptRegistry and promptVersion match
+	prompts        string
+	promptRegistry *ToolRegistry
+	promptVersion  uint64
+	promptStats    PromptStats
+
+	// version identifies the configuration of the current system prompt
+	version *RunVersion
+
+	// promptSuffix holds the sections of the run appended to the system prompt: its environment and language
+	promptSuffix string
+
+	// templates are the feedback templates of the locale of the run
+	templates *FeedbackTemplates
+
+	// directAnswer allows completing with a plain text answer
+	directAnswer bool
+
+	// toolChoice is the request's tool choice, reset to auto once satisfied
+	toolChoice ToolChoice
+
+	agentContext      *AgentContext
+	messages          []*llm.ModelMessage
+	usage             *llm.TokenUsage
+	totalCost         float64
+	consecutiveErrors int
+
+	// outputAttempts counts the outputs submitted to the verifier
+	outputAttempts int
+
+	// permanentFailures holds the feedback of the calls that failed with a non-retryable ToolError, by toolCallKey
+	permanentFailures map[string]string
+
+	// speculation is the pending speculative model call, if any
+	speculation      *speculation
+	speculationStats SpeculationStats
+}
+
+// modelTurn is the outcome of a single model call
+type modelTurn struct {
+	// err is set when the model call itself failed
+	err error
+
+	output   string
+	toolCall *llm.ToolCall
+
+	// reasoning accumulates the reasoning chunks streamed by the model when it is summarized
+	reasoning string
+
+	// parseErr is set when no valid tool call could be parsed from output
+	parseErr error
+
+	usage *llm.TokenUsage
+	cost  *float64
+
+	// duration is the time the model call took
+	duration time.Duration
+
+	// latency is the token latency of streamed calls
+	latency *TokenLatency
+}
+
+// run executes the agent loop. If events is nil, nothing is streamed.
+// If the task is not completed within MaxIterations, it returns ErrMaxIterations along with
+// the response, holding a best-effort partial output when salvage is enabled.
+func (r *BaseRunner) run(ctx context.Context, req *AgentRequest, callback Callback, events *eventEmitter) (resp *AgentResponse, err error) {
+	var run *agentRun
+	if r.quotas != nil {
+		release, err := r.quotas.Acquire(ctx, req.Metadata[r.tenantKey])
+		if err != nil {
+			return nil, err
+		}
+		// Failed runs return no response but are charged the model calls they made
+		defer func() {
+			var cost float64
+			if run != nil {
+				cost = run.totalCost
+			}
+			release(cost)
+		}()
+	}
+
+	// Copy the history so appends never write into the caller's backing array
+	messages := make([]*llm.ModelMessage, len(req.Messages))
+	copy(messages, req.Messages)
+	var repairs []HistoryIssue
+	if r.historyRepair {
+		messages, repairs = RepairHistory(messages)
+	}
+
+	toolRegistry, err := r.newRunToolRegistry(r.agent, req)
+	if err != nil {
+		return nil, err
+	}
+	model, agent, err := r.requestModel(req, r.agent)
+	if err != nil {
+		return nil, err
+	}
+
+	run = &agentRun{
+		req:          req,
+		callback:     callback,
+		events:       events,
+		agent:        agent,
+		model:        model,
+		capabilities: r.requestCapabilities(req),
+		toolRegistry: toolRegistry,
+		directAnswer: isDirectAnswer(req, r.agent),
+		toolChoice:   req.ToolChoice,
+		messages:     messages,
+		usage:        &llm.TokenUsage{},
+	}
+	for _, issue := range repairs {
+		r.logger.Warn("repaired request history", "agent", run.agent.Name, "issue", issue.String())
+	}
+	runID := req.RunID
+	if runID == "" {
+		runID = uuid.New().String()
+	}
+	run.agentContext = &AgentContext{
+		RunID:         runID,
+		Agent:         agent,
+		Messages:      messages,
+		Session:       req.Session,
+		SharedState:   req.SharedState,
+		Metadata:      req.Metadata,
+		Compensations: &Compensations{},
+		Artifacts:     r.artifacts,
+		DryRun:        req.DryRun,
+	}
+	ctx = WithAgentContext(ctx, run.agentContext)
+	events.setRunID(runID)
+	defer func() {
+		// Runs ending early leave a pending speculation, which must not keep calling the model
+		if run.speculation != nil {
+			run.speculation.cancel()
+		}
+	}()
+	if req.Priority != 0 {
+		ctx = WithPriority(ctx, req.Priority)
+	}
+	if req.Credentials != nil {
+		ctx = WithCredentials(ctx, req.Credentials)
+	}
+	run.templates = r.feedbackTemplates(req.Locale)
+	if r.environment != nil {
+		run.promptSuffix = r.describeEnvironment(ctx, run).Prompt()
+	}
+	run.promptSuffix += run.templates.languagePrompt(req.Locale)
+	r.notify(ctx, run, &LifecycleEvent{Type: LifecycleRunStarted})
+	// Deferred first, so sinks see the error left by rollbacks
+	defer func() {
+		r.notifyRunEnd(ctx, run, resp, err)
+	}()
+	defer func() {
+		if err != nil && ctx.Err() != nil {
+			r.emitCancelled(ctx, run)
+		}
+	}()
+	if r.rollback {
+		defer func() {
+			if err != nil {
+				err = r.rollbackRun(ctx, run, err)
+			}
+		}()
+	}
+	if r.transactions {
+		defer func() {
+			r.abortOpenTransaction(ctx, run, "the run ended before it was committed")
+		}()
+	}
+
+	var results any = nil
+	var stopErr error
+	completed := false
+	maxIterations := req.MaxIterations
+	for i := 0; i < maxIterations && !completed; i++ {
+		// Check context cancellation
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("context cancelled: %w", ctx.Err())
+		default:
+		}
+
+		toolChoice := run.toolChoice
+		var notes []string
+		if r.loopController != nil {
+			decision, err := r.loopController.ContinueLoop(ctx, &LoopState{
+				Iteration:         i,
+				MaxIterations:     maxIterations,
+				Agent:             run.agent.Name,
+				Messages:          run.messages,
+				ToolCalls:         run.agentContext.ToolCalls,
+				ToolStats:         run.agentContext.AllToolStats(),
+				Usage:             run.usage,
+				Cost:              run.totalCost,
+				ConsecutiveErrors: run.consecutiveErrors,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("loop controller failed: %w", err)
+			}
+			if decision.Stop {
+				stopErr = fmt.Errorf("%w: %s", ErrLoopStopped, decision.StopReason)
+				break
+			}
+			if decision.ExtendIterations > 0 {
+				maxIterations += decision.ExtendIterations
+			}
+			if decision.SuggestTool != "" {
+				notes = append(notes, fmt.Sprintf(suggestToolPrompt, decision.SuggestTool))
+			}
+			if decision.ToolChoice != "" {
+				toolChoice = decision.ToolChoice
+			}
+		}
+		if err := toolChoice.validate(run.toolRegistry); err != nil {
+			return nil, err
+		}
+		if constraint := toolChoice.prompt(r.completionTool.Name); constraint != "" {
+			notes = append(notes, constraint)
+		}
+
+		// Notes only apply to this iteration, they are not kept in the history
+		messages := run.messages
+		if len(notes) > 0 {
+			messages = make([]*llm.ModelMessage, len(run.messages), len(run.messages)+1)
+			copy(messages, run.messages)
+			messages = append(messages, &llm.ModelMessage{
+				Role:    llm.RoleUser,
+				Content: strings.Join(notes, "\n\n"),
+			})
+		}
+
+		prompts, err := r.systemPrompt(run)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create prompts: %w", err)
+		}
+		promptStats := run.promptStats
+		events.emit(AgentEvent{
+			Type:        AgentEventTypePromptStats,
+			PromptStats: &promptStats,
+		})
+		completionReq := &llm.CompletionRequest{
+			Instructions: prompts,
+			Messages:     messages,
+		}
+
+		// Call BeforeModel callback
+		if callback != nil {
+			if err := callback.BeforeModel(ctx, run.agent.ModelProvider, run.agent.Model, prompts, messages); err != nil {
+				return nil, fmt.Errorf("callback BeforeModel failed: %w", err)
+			}
+		}
+
+		model, err := r.callModel(run, toolChoice)
+		if err != nil {
+			return nil, err
+		}
+		if spec := r.takeSpeculation(run, prompts, messages); spec != nil {
+			model = spec
+		}
+		var turn *modelTurn
+		if events == nil {
+			turn, err = r.complete(ctx, run, model, prompts, completionReq)
+		} else {
+			turn, err = r.streamComplete(ctx, run, model, prompts, completionReq)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if turn.err != nil {
+			// Retrying cannot succeed until the breaker lets calls through again
+			if errors.Is(turn.err, ErrCircuitOpen) {
+				return nil, fmt.Errorf("model completion failed: %w", turn.err)
+			}
+			if err := run.fail(i, fmt.Sprintf("Model completion failed: %s\n\nPlease try a different approach or tool.", turn.err.Error())); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if err := r.priceTurn(run, turn); err != nil {
+			return nil, err
+		}
+		run.addUsage(turn)
+
+		if turn.parseErr != nil && run.directAnswer && toolChoice.isAuto() && r.format.plainTextPrefix(turn.output) == len(turn.output) {
+			answer := strings.TrimSpace(turn.output)
+			run.messages = append(run.messages, &llm.ModelMessage{
+				Role:    llm.RoleAssistant,
+				Content: answer,
+			})
+			output, err := r.processOutput(ctx, run, answer)
+			if err != nil {
+				if err := run.fail(i, err.Error()); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			accepted, err := r.verifyOutput(ctx, run, nil, output)
+			if err != nil {
+				return nil, err
+			}
+			if !accepted {
+				continue
+			}
+			events.emit(AgentEvent{
+				Type:   AgentEventTypeOutput,
+				Output: output,
+				Usage:  run.usageTotals(),
+			})
+			completed = true
+			results = output
+			break
+		}
+
+		if turn.parseErr != nil {
+			if err := run.fail(i, "Failed to parse tool call from your response.\n\n"+fmt.Sprintf(r.format.invalidOutputHint(), turn.output, turn.parseErr.Error())); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		toolCall := turn.toolCall
+		run.messages = append(run.messages, &llm.ModelMessage{
+			Role:     llm.RoleAssistant,
+			Content:  "",
+			ToolCall: toolCall,
+		})
+		// The loop keeps updating the tool call, so consumers get a copy
+		emitted := *toolCall
+		events.emit(AgentEvent{
+			Type:     AgentEventTypeUseTool,
+			ToolCall: &emitted,
+		})
+
+		r.resolveToolAlias(run, toolCall)
+		if feedback := toolChoice.check(toolCall.Name, r.completionTool.Name); feedback != "" {
+			if err := run.fail(i, feedback); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		run.toolChoice = ToolChoiceAuto
+
+		// Handle tool call
+		tool, err := run.toolRegistry.GetTool(toolCall.Name)
+		if err != nil {
+			availableTools := []string{}
+			for _, t := range run.toolRegistry.GetTools() {
+				availableTools = append(availableTools, t.Name())
+			}
+			run.feedback(i, fmt.Sprintf(run.templates.ToolNotFound, toolCall.Name, availableTools))
+			continue
+		}
+
+		if tool.Name() == r.completionTool.Name && run.agentContext.InTransaction() {
+			if err := run.fail(i, "Commit or abort the open transaction before completing the task."); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if limit := run.toolRegistry.GetToolOptions(tool.Name()).MaxCalls; limit > 0 && run.agentContext.GetToolStats(tool.Name()).Calls >= limit {
+			if err := run.fail(i, fmt.Sprintf(run.templates.ToolLimitReached, tool.Name(), limit)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		// Calls that failed permanently are answered with the same failure without running again
+		callKey := toolCallKey(tool.Name(), toolCall.Input)
+		if message, ok := run.permanentFailures[callKey]; ok {
+			if err := run.fail(i, message); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if r.inputValidation {
+			validationErr, err := validateInput(run.toolRegistry, tool.Name(), toolCall.Input)
+			if err != nil {
+				return nil, err
+			}
+			if validationErr != nil {
+				if err := run.fail(i, r.invalidInput(run, events, toolCall, validationErr)); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+
+		approval, err := r.approveToolCall(ctx, run, tool, toolCall)
+		if err != nil {
+			return nil, err
+		}
+		if !approval.Approved {
+			reason := approval.Reason
+			if reason == "" {
+				reason = "the call was denied"
+			}
+			events.emit(AgentEvent{
+				Type:         AgentEventTypeToolDenied,
+				ToolCall:     toolCall,
+				ErrorMessage: &reason,
+			})
+			run.feedback(i, fmt.Sprintf(run.templates.ToolDenied, toolCall.Name, reason))
+			continue
+		}
+
+		// Call BeforeToolCall callback
+		if callback != nil {
+			if cbErr := callback.BeforeToolCall(ctx, toolCall.Name, toolCall.Input); cbErr != nil {
+				return nil, fmt.Errorf("callback BeforeToolCall failed: %w", cbErr)
+			}
+		}
+
+		// Track tool execution with timing
+		toolCall.StartAt = time.Now()
+		toolCtx := WithIdempotencyKey(ctx, NewIdempotencyKey(run.agentContext.RunID, i, toolCall.Name, toolCall.Input))
+		spec := r.speculate(ctx, run, tool, toolCall, prompts)
+		var result toolOutcome
+		if r.simulates(run, tool) {
+			result = simulateTool(toolCtx, tool, toolCall.Input)
+		} else {
+			result = r.runTool(toolCtx, tool, toolCall.Input)
+		}
+		r.settleSpeculation(run, spec)
+		toolCall.EndAt = time.Now()
+		if ctx.Err() != nil {
+			return nil, r.interruptTool(ctx, run, tool, toolCall, result)
+		}
+		toolCallOutput, err := result.output, result.err
+
+		// Call AfterToolCall callback
+		if callback != nil && err == nil {
+			if cbErr := callback.AfterToolCall(ctx, toolCall.Name, toolCall.Input, toolCallOutput); cbErr != nil {
+				return nil, fmt.Errorf("callback AfterToolCall failed: %w", cbErr)
+			}
+		}
+
+		// Tools returning a ValidationError may leave its tool unset
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) && validationErr.Tool == "" {
+			validationErr.Tool = toolCall.Name
+		}
+
+		toolCall.Output = toolCallOutput
+		if err != nil {
+			errorMessage := err.Error()
+			toolCall.ErrorMessage = &errorMessage
+		}
+		run.agentContext.AppendToolCall(toolCall)
+		toolEvent := &LifecycleEvent{
+			Type:       LifecycleToolCalled,
+			Tool:       toolCall.Name,
+			Input:      toolCall.Input,
+			DurationMs: toolCall.EndAt.Sub(toolCall.StartAt).Milliseconds(),
+		}
+		if err != nil {
+			toolEvent.Error = err.Error()
+			toolEvent.Validation = validationErr
+		}
+		r.notify(ctx, run, toolEvent)
+
+		if err != nil {
+			var message string
+			var permanent bool
+			if validationErr != nil {
+				message = r.invalidInput(run, events, toolCall, validationErr)
+			} else {
+				message, permanent = run.toolFailure(tool.Name(), err)
+			}
+			if permanent {
+				if run.permanentFailures == nil {
+					run.permanentFailures = make(map[string]string)
+				}
+				run.permanentFailures[callKey] = message
+			}
+			var toolErr *ToolError
+			if errors.As(err, &toolErr) {
+				r.logger.Warn("tool call failed", "agent", run.agent.Name, "tool", tool.Name(), "retryable", toolErr.Retryable, "error", err)
+			}
+			if !isTransactionTool(tool.Name()) {
+				if outcome := r.abortOpenTransaction(ctx, run, fmt.Sprintf("%s failed", tool.Name())); outcome != "" {
+					message += "\n\n" + outcome
+				}
+			}
+			if err := run.fail(i, message); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		run.consecutiveErrors = 0
+		if !result.simulated {
+			run.agentContext.recordCompensation(tool, toolCall.Input, toolCallOutput)
+		}
+		run.agentContext.recordTransactionCall(tool, run.toolRegistry.GetToolOptions(tool.Name()))
+
+		switch tool.Name() {
+		case r.completionTool.Name:
+			output, err := r.processOutput(ctx, run, toolCallOutput)
+			if err != nil {
+				if err := run.fail(i, err.Error()); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			accepted, err := r.verifyOutput(ctx, run, toolCall, output)
+			if err != nil {
+				return nil, err
+			}
+			if !accepted {
+				continue
+			}
+			completed = true
+			results = output
+			events.emit(AgentEvent{
+				Type:   AgentEventTypeOutput,
+				Output: output,
+				Usage:  run.usageTotals(),
+			})
+		case HandoffToolName:
+			handoff, ok := toolCallOutput.(*Handoff)
+			if !ok {
+				return nil, fmt.Errorf("handoff tool returned %T", toolCallOutput)
+			}
+			if err := r.handoff(run, handoff); err != nil {
+				var toolErr *ToolError
+				if !errors.As(err, &toolErr) {
+					return nil, err
+				}
+				message, _ := run.toolFailure(HandoffToolName, err)
+				if err := run.fail(i, message); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		default:
+			toolCallOutput, imageMessage := r.feedImages(run, toolCall, toolCallOutput)
+			toolCallOutput = r.referenceOutput(ctx, run, toolCall, toolCallOutput)
+			message, err := r.toolResultMessage(toolCall, toolCallOutput)
+			if err != nil {
+				return nil, err
+			}
+			run.messages = append(run.messages, message)
+			if imageMessage != nil {
+				run.messages = append(run.messages, imageMessage)
+			}
+			r.matchSpeculation(run, toolCallOutput, message)
+		}
+
+		// Trim message history to prevent unbounded growth
+		run.messages = r.historyPolicy.Trim(run.messages)
+		if r.historyRepair {
+			run.messages, _ = RepairHistory(run.messages)
+		}
+	}
+
+	var runErr error
+	partial := false
+	if !completed {
+		runErr = stopErr
+		if runErr == nil {
+			runErr = fmt.Errorf("%w: %d", ErrMaxIterations, maxIterations)
+		}
+		if r.salvage {
+			output, err := r.salvageOutput(ctx, run)
+			if err != nil {
+				runErr = errors.Join(runErr, fmt.Errorf("failed to salvage partial output: %w", err))
+			} else {
+				results = output
+				partial = true
+			}
+		}
+	}
+
+	r.discardSpeculation(run)
+
+	resp = &AgentResponse{
+		Output:        results,
+		Usage:         run.usage,
+		Cost:          &run.totalCost,
+		ToolCalls:     run.agentContext.ToolCalls,
+		ToolStats:     run.agentContext.AllToolStats(),
+		Agent:         run.agent.Name,
+		Partial:       partial,
+		Compensations: run.agentContext.Compensations,
+	}
+	if run.prompts != "" {
+		resp.PromptStats = &run.promptStats
+		resp.Version = run.version
+	}
+	if r.speculation != nil {
+		resp.Speculation = &run.speculationStats
+	}
+	return resp, runErr
+}
+
+// toolResultMessage creates the history message of the result of toolCall
+func (r *BaseRunner) toolResultMessage(toolCall *llm.ToolCall, output any) (*llm.ModelMessage, error) {
+	if output == nil {
+		return &llm.ModelMessage{
+			Role:    llm.RoleTool,
+			Content: "Tool call success, no results",
+		}, nil
+	}
+	content, err := r.format.formatToolOutput(output)
+	if err != nil {
+		return nil, err
+	}
+	return &llm.ModelMessage{
+		Role: llm.RoleTool,
+		ToolCall: &llm.ToolCall{
+			ID:     toolCall.ID,
+			Name:   toolCall.Name,
+			Input:  toolCall.Input,
+			Output: content,
+		},
+	}, nil
+}
+
+// runStream validates the request and executes the agent loop in the background,
+// streaming events to the returned channel
+func (r *BaseRunner) runStream(ctx context.Context, req *AgentRequest, callback Callback) (*AgentStreamResponse, error) {
+	// Validate request
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+
+	bufferSize := r.eventBufferSize
+	if req.EventBufferSize > 0 {
+		bufferSize = req.EventBufferSize
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	eventChan := make(chan AgentEvent, bufferSize)
+	events := newEventEmitter(eventChan, r.agent.Name, req.Metadata, r.streamSinks, r.backpressure, bufferSize, r.logger)
+	streamResp := &AgentStreamResponse{
+		events:  eventChan,
+		cancel:  cancel,
+		emitter: events,
+		done:    make(chan struct{}),
+	}
+
+	go func() {
+		defer cancel()
+		defer events.close()
+
+		resp, err := r.run(ctx, req, callback, events)
+		if err != nil {
+			events.emitError(err.Error())
+		}
+		streamResp.resp, streamResp.err = resp, err
+		close(streamResp.done)
+	}()
+
+	return streamResp, nil
+}
+
+// systemPrompt returns the system prompt of the run, rendering it only when the active
+// agent changed through a handoff or its tool registry was modified
+func (r *BaseRunner) systemPrompt(run *agentRun) (string, error) {
+	version := run.toolRegistry.Version()
+	if run.promptRegistry == run.toolRegistry && run.promptVersion == version {
+		return run.prompts, nil
+	}
+	var prompts, toolsPrompt string
+	if run.directAnswer && !hasCallableTools(run.toolRegistry, r.completionTool.Name) {
+		rendered, err := llm.GetPrompts(directSystemPrompt, map[string]interface{}{
+			"agent": run.agent,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to get prompts: %w", err)
+		}
+		prompts = rendered
+	} else {
+		var err error
+		toolsPrompt, err = r.registryToolsPrompt(run.agent, run.toolRegistry)
+		if err != nil {
+			return "", fmt.Errorf("failed to create tools prompt: %w", err)
+		}
+		prompts, err = r.renderSystemPrompt(run.agent, run.capabilities, toolsPrompt)
+		if err != nil {
+			return "", err
+		}
+		if run.directAnswer {
+			prompts += directAnswerPrompt
+		}
+	}
+	// The environment and language change from run to run, so they are left out of the version
+	run.version = r.newRunVersion(run, prompts, toolsPrompt)
+	prompts += run.promptSuffix
+	run.prompts = prompts
+	run.promptRegistry = run.toolRegistry
+	run.promptVersion = version
+	run.events.setVersion(run.version)
+	run.promptStats = PromptStats{
+		Tokens:      r.tokenizer.CountTokens(prompts),
+		ToolsTokens: r.tokenizer.CountTokens(toolsPrompt),
+	}
+	if r.promptTokenWarning > 0 && run.promptStats.Tokens > r.promptTokenWarning {
+		r.logger.Warn("system prompt exceeds token threshold",
+			"agent", run.agent.Name,
+			"tokens", run.promptStats.Tokens,
+			"toolsTokens", run.promptStats.ToolsTokens,
+			"tools", len(run.toolRegistry.GetTools()),
+			"threshold", r.promptTokenWarning)
+	}
+	return prompts, nil
+}
+
+// rollbackRun undoes the compensated tool calls of a failed run, even if the run was cancelled.
+// Compensation failures are joined to the run error.
+func (r *BaseRunner) rollbackRun(ctx context.Context, run *agentRun, runErr error) error {
+	pending := run.agentContext.Compensations.Len()
+	if pending == 0 {
+		return runErr
+	}
+	r.logger.Info("rolling back failed run",
+		"agent", run.agent.Name,
+		"runId", run.agentContext.RunID,
+		"compensations", pending)
+	if err := run.agentContext.Rollback(context.WithoutCancel(ctx)); err != nil {
+		return errors.Join(runErr, fmt.Errorf("rollback failed: %w", err))
+	}
+	return runErr
+}
+
+// resolveToolAlias renames a tool call made through a deprecated alias to the current tool name
+func (r *BaseRunner) resolveToolAlias(run *agentRun, toolCall *llm.ToolCall) {
+	name, ok := run.toolRegistry.ResolveAlias(toolCall.Name)
+	if !ok {
+		return
+	}
+	r.logger.Warn("tool called by deprecated alias",
+		"agent", run.agent.Name,
+		"alias", toolCall.Name,
+		"tool", name)
+	toolCall.Name = name
+}
+
+// unwrapOutput returns the output part of a partial completion tool input
+func (r *BaseRunner) unwrapOutput(input map[string]any) map[string]any {
+	if r.completionTool.WrapKey == "" {
+		return input
+	}
+	output, _ := input[r.completionTool.WrapKey].(map[string]any)
+	return output
+}
+
+// complete calls model once and parses the tool call from its output
+func (r *BaseRunner) complete(ctx context.Context, run *agentRun, model llm.CompletionModel, prompts string, completionReq *llm.CompletionRequest) (*modelTurn, error) {
+	start := time.Now()
+	output, err := model.Complete(ctx, completionReq)
+	if err != nil {
+		return &modelTurn{err: err}, nil
+	}
+
+	turn := &modelTurn{
+		output:   output.Output,
+		usage:    output.Usage,
+		cost:     output.Cost,
+		duration: time.Since(start),
+	}
+	turn.toolCall, turn.parseErr = r.format.parse(output.Output)
+	if turn.toolCall != nil {
+		turn.toolCall.ID = uuid.New().String()
+	}
+	r.redactReasoning(ctx, run, turn)
+
+	// Call AfterModel callback
+	if run.callback != nil {
+		if cbErr := run.callback.AfterModel(ctx, run.agent.ModelProvider, run.agent.Model, prompts, completionReq.Messages, turn.output, output.Usage); cbErr != nil {
+			return nil, fmt.Errorf("callback AfterModel failed: %w", cbErr)
+		}
+	}
+	return turn, nil
+}
+
+// streamComplete streams a model call, emitting reasoning and partial tool call
+// events as they arrive, and returns the parsed tool call once the stream ends
+func (r *BaseRunner) streamComplete(ctx context.Context, run *agentRun, model llm.CompletionModel, prompts string, completionReq *llm.CompletionRequest) (*modelTurn, error) {
+	start := time.Now()
+	stream, err := model.StreamComplete(ctx, completionReq)
+	if err != nil {
+		return &modelTurn{err: err}, nil
+	}
+
+	// Create parser for streaming tool calls
+	parser := r.format.newStreamParser()
+	defer parser.release()
+	differ := newOutputDiffer()
+	inputDiffer := newStringDiffer()
+	turn := &modelTurn{usage: &llm.TokenUsage{}}
+	latency := &latencyRecorder{start: start}
+	reasoningSent := 0
+
+	// fullOutput and fullReasoning accumulate the chunks until the stream ends
+	var fullOutput, fullReasoning strings.Builder
+
+	// callID identifies the tool call in partial events, it is assigned when the call starts
+	callID := ""
+	textSent := 0
+	totalCost := 0.0
+	hasCost := false
+
+	// Process stream chunks until the model is done
+	streamClosed := false
+	for !streamClosed {
+		select {
+		case chunk, ok := <-stream:
+			if !ok || chunk == nil {
+				streamClosed = true
+				break
+			}
+			if chunk.Type() != llm.UsageChunkType {
+				latency.record(time.Now())
+			}
+
+			switch chunk.Type() {
+			case llm.ReasoningChunkType:
+				reasoningChunk := chunk.(llm.StreamReasoningChunk)
+				if r.reasoningPolicy == ReasoningSummarize {
+					fullReasoning.WriteString(reasoningChunk.Reasoning)
+				}
+				r.emitReasoning(run, &reasoningChunk.Reasoning)
+			case llm.TextChunkType:
+				// Ignore anything the model writes after a complete or invalid tool call
+				if turn.toolCall != nil || turn.parseErr != nil {
+					break
+				}
+				textChunk := chunk.(llm.StreamTextChunk)
+				content := textChunk.Text
+
+				// Accumulate full output for AfterModel callback
+				fullOutput.WriteString(content)
+
+				// Stream the plain text answer as it arrives
+				if run.directAnswer {
+					if n := r.format.plainTextPrefix(fullOutput.String()); n > textSent {
+						text := fullOutput.String()[textSent:n]
+						textSent = n
+						run.events.emit(AgentEvent{
+							Type:    AgentEventTypeText,
+							Text:    &text,
+							Partial: true,
+						})
+					}
+				}
+
+				// Append to parser
+				parser.Append(content)
+
+				// Parse events
+				currentToolCall, toolCompleted, reasoning, err := parser.Parse()
+				if err != nil {
+					turn.parseErr = err
+					break
+				}
+
+				// Stream the reasoning as it grows. Text of direct answer runs is streamed as an
+				// answer until the tool call starts, its reasoning is only sent once known to be one.
+				if reasoning != nil && len(*reasoning) > reasoningSent && (!run.directAnswer || currentToolCall != nil) {
+					delta := (*reasoning)[reasoningSent:]
+					reasoningSent = len(*reasoning)
+					r.emitReasoning(run, &delta)
+				}
+
+				if currentToolCall != nil {
+					if callID == "" {
+						callID = uuid.New().String()
+					}
+					currentToolCall.ID = callID
+					if !toolCompleted {
+						for _, delta := range inputDiffer.diff(currentToolCall.Name, currentToolCall.Input) {
+							delta.ToolCallID = callID
+							run.events.emit(AgentEvent{
+								Type:           AgentEventTypeToolInputDelta,
+								ToolInputDelta: delta,
+								Partial:        true,
+							})
+						}
+					}
+					if currentToolCall.Name == r.completionTool.Name {
+						for _, delta := range differ.diff(r.unwrapOutput(currentToolCall.Input)) {
+							run.events.emit(AgentEvent{
+								Type:        AgentEventTypeOutputDelta,
+								OutputDelta: delta,
+								Partial:     true,
+							})
+						}
+					}
+					if toolCompleted {
+						turn.toolCall = currentToolCall
+					} else {
+						run.events.emit(AgentEvent{
+							Type:     AgentEventTypeUseTool,
+							ToolCall: currentToolCall,
+							Partial:  true,
+						})
+					}
+				}
+			case llm.UsageChunkType:
+				usageChunk := chunk.(llm.StreamUsageChunk)
+				if usageChunk.Usage != nil {
+					turn.usage.Append(usageChunk.Usage)
+				}
+				if usageChunk.Cost != nil {
+					totalCost += *usageChunk.Cost
+					hasCost = true
+				}
+			}
+		case <-ctx.Done():
+			return nil, fmt.Errorf("context cancelled: %w", ctx.Err())
+		}
+	}
+	turn.output = fullOutput.String()
+	turn.reasoning = fullReasoning.String()
+	if hasCost {
+		turn.cost = &totalCost
+	}
+	turn.duration = time.Since(start)
+	turn.latency = latency.result(turn.duration)
+
+	// The stream may end without the parser seeing a complete tool call,
+	// parse the full output to get a precise error for the model
+	if turn.toolCall == nil && turn.parseErr == nil {
+		turn.toolCall, turn.parseErr = r.format.parse(turn.output)
+	}
+	if turn.toolCall != nil {
+		if callID == "" {
+			callID = uuid.New().String()
+		}
+		turn.toolCall.ID = callID
+	}
+	r.redactReasoning(ctx, run, turn)
+
+	// Call AfterModel callback
+	if run.callback != nil {
+		if cbErr := run.callback.AfterModel(ctx, run.agent.ModelProvider, run.agent.Model, prompts, completionReq.Messages, turn.output, turn.usage); cbErr != nil {
+			return nil, fmt.Errorf("callback AfterModel failed: %w", cbErr)
+		}
+	}
+	if observer, ok := run.callback.(LatencyCallback); ok && turn.latency != nil {
+		observer.ModelLatency(ctx, run.agent.ModelProvider, run.agent.Model, turn.latency)
+	}
+
+	return turn, nil
+}
+
+// handoff transfers the run to the agent named in handoff, carrying over the
+// configured amount of history followed by the handoff note
+func (r *BaseRunner) handoff(run *agentRun, handoff *Handoff) error {
+	target, ok := r.agents[handoff.Agent]
+	if !ok {
+		names := make([]string, 0, len(run.agent.Handoffs))
+		for _, h := range run.agent.Handoffs {
+			names = append(names, h.Name)
+		}
+		return NewRetryableToolError(fmt.Sprintf("Unknown agent '%s'. Valid agents: %s", handoff.Agent, strings.Join(names, ", ")), ErrInvalidInput)
+	}
+	toolRegistry, err := r.newRunToolRegistry(target, run.req)
+	if err != nil {
+		return fmt.Errorf("handoff to %s failed: %w", target.Name, err)
+	}
+	from := run.agent.Name
+
+	// Carry the history up to, but excluding, the handoff tool call
+	history := run.messages[:len(run.messages)-1]
+	if r.handoffHistory > 0 && len(history) > r.handoffHistory+1 {
+		carried := make([]*llm.ModelMessage, 0, r.handoffHistory+2)
+		carried = append(carried, history[0]) // Keep the first user message
+		carried = append(carried, history[len(history)-r.handoffHistory:]...)
+		history = carried
+	}
+	run.messages = append(history, &llm.ModelMessage{
+		Role:    llm.RoleUser,
+		Content: fmt.Sprintf("HANDOFF from %s: %s", from, handoff.Note),
+	})
+
+	run.agent = withModelRef(target, run.req.Model)
+	run.toolRegistry = toolRegistry
+	run.agentContext.setAgent(run.agent)
+	run.events.setAgent(target.Name)
+	run.events.emit(AgentEvent{
+		Type:    AgentEventTypeHandoff,
+		Handoff: handoff,
+	})
+	return nil
+}
+
+// fail records a consecutive error and sends feedback to the model.
+// It returns an error once MaxRetries consecutive errors have been exceeded.
+func (run *agentRun) fail(iteration int, message string) error {
+	run.consecutiveErrors++
+	if run.req.MaxRetries > 0 && run.consecutiveErrors > run.req.MaxRetries {
+		return fmt.Errorf("exceeded max retries (%d) due to consecutive errors", run.req.MaxRetries)
+	}
+	run.feedback(iteration, message)
+	return nil
+}
+
+// feedback sends an error message for the given iteration to the model
+func (run *agentRun) feedback(iteration int, message string) {
+	run.messages = append(run.messages, &llm.ModelMessage{
+		Role:    llm.RoleUser,
+		Content: fmt.Sprintf("ERROR [Iteration %d]: %s", iteration+1, message),
+	})
+}
@@ -0,0 +1,187 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// MapItemResult is the outcome of running the agent for a single item
+type MapItemResult[R any] struct {
+	// Index is the position of the item in the input slice
+	Index int
+
+	// Output is the agent output decoded into R
+	Output R
+
+	// Response is the raw agent response, nil if the run failed before producing one
+	Response *AgentResponse
+
+	// Err is the error of this item, if any
+	Err error
+}
+
+// MapResult aggregates the results of RunMap
+type MapResult[R any] struct {
+	// Items contains one result per input item, in input order
+	Items []MapItemResult[R]
+
+	// Usage is the total token usage of all runs
+	Usage *llm.TokenUsage
+
+	// Cost is the total cost of all runs
+	Cost float64
+
+	// Failed is the number of items that returned an error
+	Failed int
+}
+
+// Outputs returns the outputs of the successful items in input order
+func (r *MapResult[R]) Outputs() []R {
+	outputs := make([]R, 0, len(r.Items)-r.Failed)
+	for _, item := range r.Items {
+		if item.Err == nil {
+			outputs = append(outputs, item.Output)
+		}
+	}
+	return outputs
+}
+
+// MapOption is a functional option for configuring RunMap
+type MapOption func(*mapConfig)
+
+// mapConfig holds configuration options for RunMap
+type mapConfig struct {
+	concurrency int
+	callback    Callback
+	failFast    bool
+}
+
+// WithMapConcurrency sets how many runs execute at the same time, defaults to 4
+func WithMapConcurrency(n int) MapOption {
+	return func(c *mapConfig) {
+		c.concurrency = n
+	}
+}
+
+// WithMapCallback sets the callback passed to every run
+func WithMapCallback(callback Callback) MapOption {
+	return func(c *mapConfig) {
+		c.callback = callback
+	}
+}
+
+// WithMapFailFast cancels the remaining runs and returns an error as soon as one item fails
+func WithMapFailFast(failFast bool) MapOption {
+	return func(c *mapConfig) {
+		c.failFast = failFast
+	}
+}
+
+// RunMap runs the agent once per item with bounded concurrency. templateFn builds the request
+// for an item, and each output is decoded into R, either directly or through JSON.
+// Item failures are reported in the result; an error is only returned on fail-fast or cancellation.
+func RunMap[T any, R any](ctx context.Context, runner Runner, items []T, templateFn func(item T) (*AgentRequest, error), opts ...MapOption) (*MapResult[R], error) {
+	config := &mapConfig{concurrency: 4}
+	for _, opt := range opts {
+		opt(config)
+	}
+	if config.concurrency <= 0 {
+		config.concurrency = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	result := &MapResult[R]{
+		Items: make([]MapItemResult[R], len(items)),
+		Usage: &llm.TokenUsage{},
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var firstErr error
+	sem := make(chan struct{}, config.concurrency)
+	started := make([]bool, len(items))
+
+	for i, item := range items {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		started[i] = true
+		wg.Add(1)
+		go func(i int, item T) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			itemResult := MapItemResult[R]{Index: i}
+			req, err := templateFn(item)
+			if err == nil {
+				itemResult.Response, err = runner.Run(ctx, req, config.callback)
+			}
+			if err == nil {
+				itemResult.Output, err = decodeOutput[R](itemResult.Response.Output)
+			}
+			itemResult.Err = err
+
+			mu.Lock()
+			defer mu.Unlock()
+			result.Items[i] = itemResult
+			if resp := itemResult.Response; resp != nil {
+				if resp.Usage != nil {
+					result.Usage.Append(resp.Usage)
+				}
+				if resp.Cost != nil {
+					result.Cost += *resp.Cost
+				}
+			}
+			if err != nil {
+				result.Failed++
+				if config.failFast && firstErr == nil {
+					firstErr = fmt.Errorf("item %d: %w", i, err)
+					cancel()
+				}
+			}
+		}(i, item)
+	}
+	wg.Wait()
+
+	// Items never started because the run was cancelled
+	for i := range items {
+		if !started[i] {
+			result.Items[i] = MapItemResult[R]{Index: i, Err: ctx.Err()}
+			result.Failed++
+		}
+	}
+
+	if firstErr != nil {
+		return result, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return result, fmt.Errorf("context cancelled: %w", err)
+	}
+	return result, nil
+}
+
+// decodeOutput converts an agent output into R
+func decodeOutput[R any](output any) (R, error) {
+	var decoded R
+	if typed, ok := output.(R); ok {
+		return typed, nil
+	}
+	data, err := json.Marshal(output)
+	if err != nil {
+		return decoded, fmt.Errorf("failed to marshal output: %w", err)
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return decoded, fmt.Errorf("failed to decode output: %w", err)
+	}
+	return decoded, nil
+}
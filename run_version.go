@@ -0,0 +1,50 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// RunVersion identifies the effective configuration serving a run, so production issues can be
+// traced to the exact prompt revision. Hashes are the first 16 hex digits of a SHA-256.
+type RunVersion struct {
+	// Prompt is the hash of the rendered system prompt, tools included
+	Prompt string `json:"prompt"`
+
+	// Tools is the hash of the tools section of the prompt, with the input and output schemas,
+	// empty when the model is not offered tools
+	Tools string `json:"tools,omitempty"`
+
+	// Model is the provider and model of the active agent, e.g. "openai/gpt-4o"
+	Model string `json:"model"`
+
+	// Config is the version set with WithConfigVersion
+	Config string `json:"config,omitempty"`
+}
+
+// WithConfigVersion sets the version of the runner configuration reported in RunVersion,
+// e.g. a release, a commit or the hash of a configuration file
+func WithConfigVersion(version string) RunnerOption {
+	return func(c *runnerConfig) {
+		c.configVersion = version
+	}
+}
+
+// VersionHash returns the hash of data used in RunVersion
+func VersionHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// newRunVersion returns the version of a run with the system prompt prompts and the tools section toolsPrompt
+func (r *BaseRunner) newRunVersion(run *agentRun, prompts, toolsPrompt string) *RunVersion {
+	version := &RunVersion{
+		Prompt: VersionHash([]byte(prompts)),
+		Model:  run.agent.ModelProvider + "/" + run.agent.Model,
+		Config: r.configVersion,
+	}
+	if toolsPrompt != "" {
+		version.Tools = VersionHash([]byte(toolsPrompt))
+	}
+	return version
+}
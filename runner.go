@@ -4,12 +4,21 @@ import (
 	"context"
 	_ "embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/easyagent-dev/llm"
 )
 
+// DefaultMaxMessageHistory is the default maximum number of messages to keep in history
+const DefaultMaxMessageHistory = 100
+
+// Runner runs an agent to completion.
+// When MaxIterations is reached without completing the task, Run returns ErrMaxIterations together
+// with the partial response, so callers should inspect the response even when err is not nil.
 type Runner interface {
 	Run(ctx context.Context, req *AgentRequest, callback Callback) (*AgentResponse, error)
 }
@@ -18,9 +27,76 @@ type StreamRunner interface {
 	Run(ctx context.Context, req *AgentRequest, callback Callback) (*AgentStreamResponse, error)
 }
 
+// BaseRunner implements the agent loop shared by all runners.
+// Runners differ only in how tool calls are encoded and whether events are streamed.
 type BaseRunner struct {
-	systemPrompts     string
-	maxMessageHistory int
+	agent           *Agent
+	model           llm.CompletionModel
+	format          toolCallFormat
+	systemPrompts   string
+	historyPolicy   HistoryPolicy
+	historyRepair   bool
+	handoffHistory  int
+	completionTool  CompletionToolConfig
+	salvage         bool
+	loopController  LoopController
+	promptVariants  []PromptVariant
+	logger          Logger
+	tokenizer       Tokenizer
+	backpressure    BackpressurePolicy
+	eventBufferSize int
+	approver        ToolApprover
+	toolPolicy      ToolPolicy
+	verifier        OutputVerifier
+	rollback        bool
+	transactions    bool
+	sinks           []EventSink
+	streamSinks     []StreamEventSink
+	configVersion   string
+	outputRefTokens int
+	artifacts       ArtifactStore
+	imageFeedback   *ImageFeedbackConfig
+	environment     *EnvironmentConfig
+	feedback        map[string]*FeedbackTemplates
+	quotas          QuotaManager
+	tenantKey       string
+	healthTTL       time.Duration
+	toolCancelGrace time.Duration
+	health          *healthCache
+	prepared        *atomic.Pointer[PrepareReport]
+
+	reasoningPolicy     ReasoningPolicy
+	reasoningSummarizer ReasoningSummarizer
+
+	// outputProcessors transform final outputs before they are verified
+	outputProcessors []OutputProcessor
+
+	pricing            PricingCatalog
+	unknownModelPolicy UnknownModelPolicy
+
+	// constrainedDecoding constrains the calls to models implementing ConstrainedModel
+	constrainedDecoding bool
+
+	// modelRegistry resolves the models of requests setting AgentRequest.Model, if set
+	modelRegistry *ModelRegistry
+
+	// capabilities are the capabilities of the model, nil if they are not known
+	capabilities *Capabilities
+
+	// inputValidation validates tool inputs against the input schemas of their tools
+	inputValidation bool
+
+	// speculation enables speculative model calls while tools run, if set
+	speculation *SpeculationConfig
+
+	// promptTokenWarning is the system prompt size in tokens above which a warning is logged
+	promptTokenWarning int
+
+	// toolRegistries holds the base tool registry of the agent and of every handoff target
+	toolRegistries map[string]*ToolRegistry
+
+	// agents holds the agent and every handoff target by name
+	agents map[string]*Agent
 }
 
 // RunnerOption is a functional option for configuring runners
@@ -30,6 +106,54 @@ type RunnerOption func(*runnerConfig)
 type runnerConfig struct {
 	systemPrompts     string
 	maxMessageHistory int
+	historyPolicy     HistoryPolicy
+	historyRepair     bool
+	handoffHistory    int
+	completionTool    CompletionToolConfig
+	salvage           bool
+	loopController    LoopController
+	promptVariants    []PromptVariant
+	logger            Logger
+	tokenizer         Tokenizer
+	promptWarning     int
+	backpressure      BackpressurePolicy
+	eventBufferSize   int
+	approver          ToolApprover
+	toolPolicy        ToolPolicy
+	verifier          OutputVerifier
+	rollback          bool
+	transactions      bool
+	sinks             []EventSink
+	streamSinks       []StreamEventSink
+	configVersion     string
+	outputRefTokens   int
+	artifacts         ArtifactStore
+	imageFeedback     *ImageFeedbackConfig
+	environment       *EnvironmentConfig
+	feedback          map[string]*FeedbackTemplates
+	quotas            QuotaManager
+	tenantKey         string
+	healthTTL         time.Duration
+	toolCancelGrace   time.Duration
+
+	reasoningPolicy     ReasoningPolicy
+	reasoningSummarizer ReasoningSummarizer
+
+	outputProcessors []OutputProcessor
+
+	pricing            PricingCatalog
+	unknownModelPolicy UnknownModelPolicy
+
+	lenientParsing bool
+	compactPrompt  bool
+
+	constrainedDecoding bool
+	inputValidation     bool
+
+	modelRegistry *ModelRegistry
+	capabilities  *Capabilities
+
+	speculation *SpeculationConfig
 }
 
 // WithSystemPrompt sets a custom system prompt for the runner
@@ -39,43 +163,336 @@ func WithSystemPrompt(prompt string) RunnerOption {
 	}
 }
 
-// WithMaxMessageHistory sets the maximum message history for the runner
+// WithMaxMessageHistory sets the maximum message history for the runner, ignored if WithHistoryPolicy is set
 func WithMaxMessageHistory(max int) RunnerOption {
 	return func(c *runnerConfig) {
 		c.maxMessageHistory = max
 	}
 }
 
+// WithHandoffHistory sets how many recent messages are carried over when the
+// conversation is handed off to another agent. If 0, the whole history is carried.
+func WithHandoffHistory(n int) RunnerOption {
+	return func(c *runnerConfig) {
+		c.handoffHistory = n
+	}
+}
+
+// WithCompletionTool renames or replaces the built-in complete_task tool,
+// e.g. to use domain terminology like submit_diagnosis
+func WithCompletionTool(config CompletionToolConfig) RunnerOption {
+	return func(c *runnerConfig) {
+		c.completionTool = config
+	}
+}
+
+// WithPartialOutputSalvage makes the runner perform one final forced completion call when
+// MaxIterations is exceeded, returning a best-effort output flagged as Partial along with the error
+func WithPartialOutputSalvage(enabled bool) RunnerOption {
+	return func(c *runnerConfig) {
+		c.salvage = enabled
+	}
+}
+
+// WithLoopController sets a controller consulted before every iteration of the agent loop
+func WithLoopController(controller LoopController) RunnerOption {
+	return func(c *runnerConfig) {
+		c.loopController = controller
+	}
+}
+
+// WithPromptVariant registers a system prompt template selected automatically for agents matching
+// the variant's provider and model. The most specific matching variant is used.
+func WithPromptVariant(variant PromptVariant) RunnerOption {
+	return func(c *runnerConfig) {
+		c.promptVariants = append(c.promptVariants, variant)
+	}
+}
+
+// WithLogger sets the logger receiving runner diagnostics such as prompt size warnings
+func WithLogger(logger Logger) RunnerOption {
+	return func(c *runnerConfig) {
+		c.logger = logger
+	}
+}
+
+// WithTokenizer sets the tokenizer counting system prompt tokens, EstimateTokenizer by default
+func WithTokenizer(tokenizer Tokenizer) RunnerOption {
+	return func(c *runnerConfig) {
+		c.tokenizer = tokenizer
+	}
+}
+
+// WithPromptTokenWarning logs a warning whenever the rendered system prompt exceeds threshold tokens,
+// typically because tools and output schemas grew. If 0, no warning is logged.
+func WithPromptTokenWarning(threshold int) RunnerOption {
+	return func(c *runnerConfig) {
+		c.promptWarning = threshold
+	}
+}
+
+// WithBackpressure sets how streaming runs handle a consumer that falls behind, BackpressureBlock by default
+func WithBackpressure(policy BackpressurePolicy) RunnerOption {
+	return func(c *runnerConfig) {
+		c.backpressure = policy
+	}
+}
+
+// WithEventBufferSize sets the capacity of the event channel of streaming runs, and the number of
+// events a consumer may fall behind before the backpressure policy applies, DefaultEventBufferSize by default
+func WithEventBufferSize(size int) RunnerOption {
+	return func(c *runnerConfig) {
+		c.eventBufferSize = size
+	}
+}
+
+// WithToolApprover sets the approver of mutating and destructive tool calls.
+// Without an approver, calls are approved unless they are destructive and the request does not allow it.
+func WithToolApprover(approver ToolApprover) RunnerOption {
+	return func(c *runnerConfig) {
+		c.approver = approver
+	}
+}
+
+// WithRollbackOnFailure makes the runner undo the compensated tool calls of runs ending with an error
+func WithRollbackOnFailure(enabled bool) RunnerOption {
+	return func(c *runnerConfig) {
+		c.rollback = enabled
+	}
+}
+
+// WithTransactions gives agents the begin_transaction, commit_transaction and abort_transaction tools
+// to group tool calls that must either all apply or all be compensated. A transaction still open
+// when a tool call fails or the run ends is aborted.
+func WithTransactions(enabled bool) RunnerOption {
+	return func(c *runnerConfig) {
+		c.transactions = enabled
+	}
+}
+
 // newRunnerConfig creates a new runner configuration with default values
 func newRunnerConfig(opts ...RunnerOption) *runnerConfig {
 	config := &runnerConfig{
 		maxMessageHistory: DefaultMaxMessageHistory,
+		eventBufferSize:   DefaultEventBufferSize,
+		logger:            NoOpLogger{},
+		tokenizer:         EstimateTokenizer{},
+		artifacts:         SessionArtifactStore{},
+		healthTTL:         DefaultHealthCacheTTL,
+		toolCancelGrace:   DefaultToolCancelGrace,
+
+		reasoningPolicy:     ReasoningKeep,
+		reasoningSummarizer: TruncateReasoning(DefaultReasoningSummaryLength),
+
+		constrainedDecoding: true,
 	}
 	for _, opt := range opts {
 		opt(config)
 	}
+	if config.completionTool.Name == "" {
+		config.completionTool.Name = CompleteTaskToolName
+	}
+	if config.historyPolicy == nil {
+		config.historyPolicy = KeepRecentMessages(config.maxMessageHistory)
+	}
 	return config
 }
 
 //go:embed prompts/json_system.md
 var jsonSystemPrompt string //nolint:gochecknoglobals
 
-func (r *BaseRunner) GetSystemPrompt(agent *Agent, message *llm.ModelMessage, tools []ModelTool) (string, error) {
+// newBaseRunner validates the agent and its handoff targets and builds their tool registries
+func newBaseRunner(agent *Agent, model llm.CompletionModel, format toolCallFormat, opts ...RunnerOption) (BaseRunner, error) {
+	// Validate agent configuration
+	if err := agent.Validate(); err != nil {
+		return BaseRunner{}, fmt.Errorf("invalid agent: %w", err)
+	}
+	agents, err := agent.validateHandoffs()
+	if err != nil {
+		return BaseRunner{}, fmt.Errorf("invalid agent: %w", err)
+	}
+
+	config := newRunnerConfig(opts...)
+	if config.maxMessageHistory <= 0 {
+		return BaseRunner{}, fmt.Errorf("max message history must be positive, got %d", config.maxMessageHistory)
+	}
+	if config.eventBufferSize <= 0 {
+		return BaseRunner{}, fmt.Errorf("event buffer size must be positive, got %d", config.eventBufferSize)
+	}
+	for _, variant := range config.promptVariants {
+		if err := variant.validate(); err != nil {
+			return BaseRunner{}, fmt.Errorf("invalid prompt variant for %s/%s: %w", variant.Provider, variant.Model, err)
+		}
+	}
+
+	switch config.reasoningPolicy {
+	case ReasoningKeep, ReasoningStreamOnly, ReasoningSummarize, ReasoningDrop:
+	default:
+		return BaseRunner{}, fmt.Errorf("invalid reasoning policy '%s'", config.reasoningPolicy)
+	}
+	if _, err := ParseUnknownModelPolicy(string(config.unknownModelPolicy)); err != nil {
+		return BaseRunner{}, err
+	}
+	if config.speculation != nil && config.speculation.Predictor == nil {
+		return BaseRunner{}, errors.New("speculation requires a result predictor")
+	}
+	if config.unknownModelPolicy == "" {
+		config.unknownModelPolicy = UnknownModelKeep
+	}
+
+	toolRegistries := make(map[string]*ToolRegistry, len(agents))
+	for name, a := range agents {
+		toolRegistry := NewToolRegistry()
+		for _, tool := range a.Tools {
+			if tool.Name() == config.completionTool.Name || tool.Name() == HandoffToolName {
+				return BaseRunner{}, fmt.Errorf("tool name '%s' is reserved", tool.Name())
+			}
+			if err := toolRegistry.RegisterToolWithOptions(tool, a.ToolOptions[tool.Name()]); err != nil {
+				return BaseRunner{}, fmt.Errorf("failed to register tool %s: %w", tool.Name(), err)
+			}
+			// Warm the schema cache so every run's registry clone starts with it
+			if _, err := toolRegistry.GetInputSchemaJSON(tool.Name()); err != nil {
+				return BaseRunner{}, err
+			}
+		}
+		for alias, target := range a.ToolAliases {
+			if err := toolRegistry.RegisterAlias(alias, target); err != nil {
+				return BaseRunner{}, fmt.Errorf("invalid tool alias for agent %s: %w", name, err)
+			}
+		}
+		toolRegistries[name] = toolRegistry
+	}
+
+	// Use the format's system prompt if no custom prompt is set
+	systemPrompt := format.defaultSystemPrompt()
+	if config.compactPrompt {
+		systemPrompt = format.compactSystemPrompt()
+	}
+	if config.systemPrompts != "" {
+		systemPrompt = config.systemPrompts
+	}
+	if config.lenientParsing {
+		format = lenientToolCallFormat{format}
+	}
+
+	return BaseRunner{
+		agent:           agent,
+		model:           model,
+		format:          format,
+		systemPrompts:   systemPrompt,
+		historyPolicy:   config.historyPolicy,
+		historyRepair:   config.historyRepair,
+		handoffHistory:  config.handoffHistory,
+		completionTool:  config.completionTool,
+		salvage:         config.salvage,
+		loopController:  config.loopController,
+		promptVariants:  config.promptVariants,
+		logger:          config.logger,
+		tokenizer:       config.tokenizer,
+		backpressure:    config.backpressure,
+		eventBufferSize: config.eventBufferSize,
+		approver:        config.approver,
+		toolPolicy:      config.toolPolicy,
+		verifier:        config.verifier,
+		rollback:        config.rollback,
+		transactions:    config.transactions,
+		sinks:           config.sinks,
+		streamSinks:     config.streamSinks,
+		configVersion:   config.configVersion,
+		outputRefTokens: config.outputRefTokens,
+		artifacts:       config.artifacts,
+		imageFeedback:   config.imageFeedback,
+		environment:     config.environment,
+		feedback:        config.feedback,
+		quotas:          config.quotas,
+		tenantKey:       config.tenantKey,
+		healthTTL:       config.healthTTL,
+		health:          &healthCache{},
+		prepared:        &atomic.Pointer[PrepareReport]{},
+		toolCancelGrace: config.toolCancelGrace,
+
+		reasoningPolicy:     config.reasoningPolicy,
+		reasoningSummarizer: config.reasoningSummarizer,
+
+		outputProcessors: config.outputProcessors,
+
+		pricing:            config.pricing,
+		unknownModelPolicy: config.unknownModelPolicy,
+
+		constrainedDecoding: config.constrainedDecoding,
+		inputValidation:     config.inputValidation,
+
+		modelRegistry: config.modelRegistry,
+		capabilities:  probeRunnerCapabilities(config, agent, model),
+
+		speculation: config.speculation,
+
+		promptTokenWarning: config.promptWarning,
+		toolRegistries:     toolRegistries,
+		agents:             agents,
+	}, nil
+}
+
+// newRunToolRegistry returns the tools available to agent during a single run:
+// the agent's tools, the request-scoped tools and the built-in tools
+func (r *BaseRunner) newRunToolRegistry(agent *Agent, req *AgentRequest) (*ToolRegistry, error) {
+	toolRegistry := r.toolRegistries[agent.Name].Clone()
+	for _, tool := range req.Tools {
+		if err := toolRegistry.RegisterToolWithOptions(tool, agent.ToolOptions[tool.Name()]); err != nil {
+			return nil, fmt.Errorf("failed to register request tool %s: %w", tool.Name(), err)
+		}
+	}
+	if err := toolRegistry.RegisterTool(NewCompletionTool(r.completionTool, req.OutputSchema, req.OutputUsage)); err != nil {
+		return nil, fmt.Errorf("failed to register completion tool: %w", err)
+	}
+	if len(agent.Handoffs) > 0 {
+		if err := toolRegistry.RegisterTool(NewHandoffTool(agent.Handoffs)); err != nil {
+			return nil, fmt.Errorf("failed to register handoff tool: %w", err)
+		}
+	}
+	if r.outputRefTokens > 0 {
+		for _, tool := range NewArtifactTools() {
+			if err := toolRegistry.RegisterTool(tool); err != nil {
+				return nil, fmt.Errorf("failed to register artifact tool: %w", err)
+			}
+		}
+	}
+	if r.transactions {
+		for _, tool := range NewTransactionTools() {
+			if err := toolRegistry.RegisterTool(tool); err != nil {
+				return nil, fmt.Errorf("failed to register transaction tool: %w", err)
+			}
+		}
+	}
+	return toolRegistry, nil
+}
+
+// GetSystemPrompt renders the system prompt for agent with tools.
+// It only depends on the agent and its tools, so it stays byte-stable across iterations
+// and turns, which lets providers serve it from their prompt cache.
+func (r *BaseRunner) GetSystemPrompt(agent *Agent, tools []ModelTool) (string, error) {
 	toolsPrompt, err := r.ToolsPrompts(tools)
 	if err != nil {
 		return "", fmt.Errorf("failed to create tools prompt: %w", err)
 	}
+	return r.renderSystemPrompt(agent, r.capabilities, toolsPrompt)
+}
 
-	// Use custom prompts if set, otherwise use default jsonSystemPrompt
+// renderSystemPrompt executes the system prompt template for agent running on a model with capabilities
+func (r *BaseRunner) renderSystemPrompt(agent *Agent, capabilities *Capabilities, toolsPrompt string) (string, error) {
+	// Use the agent's prompt variant if any, otherwise the runner's prompt
 	systemPrompt := jsonSystemPrompt
-	if r.systemPrompts != "" {
+	if variant, ok := selectPromptVariant(r.promptVariants, agent, capabilities); ok {
+		systemPrompt = variant
+	} else if r.systemPrompts != "" {
 		systemPrompt = r.systemPrompts
 	}
 
 	prompts, err := llm.GetPrompts(systemPrompt, map[string]interface{}{
-		"agent":     agent,
-		"tools":     toolsPrompt,
-		"userQuery": message.Content,
+		"agent":        agent,
+		"tools":        toolsPrompt,
+		"completeTool": r.completionTool.Name,
+		"wrapKey":      r.completionTool.WrapKey,
 	})
 	if err != nil {
 		return "", fmt.Errorf("failed to get prompts: %w", err)
@@ -84,6 +501,29 @@ func (r *BaseRunner) GetSystemPrompt(agent *Agent, message *llm.ModelMessage, to
 }
 
 func (r *BaseRunner) ToolsPrompts(tools []ModelTool) (string, error) {
+	return writeToolsPrompt(tools, func(tool ModelTool) ([]byte, error) {
+		return json.Marshal(tool.InputSchema())
+	}, func(name string) ToolOptions {
+		return ToolOptions{}
+	})
+}
+
+// registryToolsPrompt builds the tools prompt of agent using the schemas cached in toolRegistry,
+// ordering tools by priority and appending the agent's tool preferences
+func (r *BaseRunner) registryToolsPrompt(agent *Agent, toolRegistry *ToolRegistry) (string, error) {
+	tools := toolRegistry.GetTools()
+	sortToolsForPrompt(tools, toolRegistry.GetToolOptions)
+	prompt, err := writeToolsPrompt(tools, func(tool ModelTool) ([]byte, error) {
+		return toolRegistry.GetInputSchemaJSON(tool.Name())
+	}, toolRegistry.GetToolOptions)
+	if err != nil {
+		return "", err
+	}
+	return prompt + writeToolPreferences(agent.ToolPreferences, toolRegistry.HasTool), nil
+}
+
+// writeToolsPrompt describes tools for the system prompt, marshaling input schemas with inputSchema
+func writeToolsPrompt(tools []ModelTool, inputSchema func(tool ModelTool) ([]byte, error), options func(name string) ToolOptions) (string, error) {
 	if len(tools) == 0 {
 		return "No tools available", nil
 	}
@@ -96,13 +536,17 @@ func (r *BaseRunner) ToolsPrompts(tools []ModelTool) (string, error) {
 		if i > 0 {
 			builder.WriteString("\n")
 		}
-		inputSchema, _ := json.Marshal(tool.InputSchema())
+		schema, _ := inputSchema(tool)
 		builder.WriteString("<tool name=\"")
 		builder.WriteString(tool.Name())
+		if category := options(tool.Name()).Category; category != "" {
+			builder.WriteString("\" category=\"")
+			builder.WriteString(category)
+		}
 		builder.WriteString("\">\n<description>")
 		builder.WriteString(tool.Description())
 		builder.WriteString("</description>\n<input_schema>\n")
-		builder.Write(inputSchema)
+		builder.Write(schema)
 		builder.WriteString("\n</input_schema>")
 
 		usage := tool.Usage()
@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// NewRunner creates the runner of agent's type: a JSON completion runner for AgentTypeJSON and an
+// XML completion runner for AgentTypeXML. Handoff targets run in the same format. When the type is
+// empty it is detected from the capabilities of the model, see DetectAgentType and WithCapabilities.
+func NewRunner(agent *Agent, model llm.CompletionModel, opts ...RunnerOption) (Runner, error) {
+	if agent == nil {
+		return nil, errors.New("agent is required")
+	}
+	if resolveAgentType(agent, model, opts) == AgentTypeXML {
+		return NewXMLCompletionRunner(agent, model, opts...)
+	}
+	return NewJSONCompletionRunner(agent, model, opts...)
+}
+
+// NewStreamRunner creates the stream runner of agent's type, like NewRunner
+func NewStreamRunner(agent *Agent, model llm.CompletionModel, opts ...RunnerOption) (StreamRunner, error) {
+	if agent == nil {
+		return nil, errors.New("agent is required")
+	}
+	if resolveAgentType(agent, model, opts) == AgentTypeXML {
+		return NewXMLCompletionStreamRunner(agent, model, opts...)
+	}
+	return NewJSONCompletionStreamRunner(agent, model, opts...)
+}
+
+// DetectAgentType returns the tool call format best suited to the model of agent: JSON for models
+// with JSON mode, which keeps their tool calls well-formed, XML for Claude models, which follow
+// XML tags more reliably, and JSON otherwise. The capabilities of the model are probed with
+// ProbeCapabilities.
+func DetectAgentType(agent *Agent, model llm.CompletionModel) AgentType {
+	capabilities, _ := ProbeCapabilities(agent.ModelProvider, agent.Model, model, nil)
+	return detectAgentType(agent, capabilities)
+}
+
+// detectAgentType returns the tool call format best suited to agent running on a model with capabilities
+func detectAgentType(agent *Agent, capabilities Capabilities) AgentType {
+	if capabilities.JSONMode {
+		return AgentTypeJSON
+	}
+	switch strings.ToLower(agent.ModelProvider) {
+	case "claude", "anthropic":
+		return AgentTypeXML
+	}
+	if strings.HasPrefix(strings.ToLower(agent.Model), "claude") {
+		return AgentTypeXML
+	}
+	return AgentTypeJSON
+}
+
+// resolveAgentType returns the type of agent, detected if empty from the capabilities set in opts
+// or probed for model
+func resolveAgentType(agent *Agent, model llm.CompletionModel, opts []RunnerOption) AgentType {
+	if agent.Type != "" {
+		return agent.Type
+	}
+	if config := newRunnerConfig(opts...); config.capabilities != nil {
+		return detectAgentType(agent, *config.capabilities)
+	}
+	return DetectAgentType(agent, model)
+}
@@ -0,0 +1,95 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// salvagePrompt asks the model for a best-effort answer once iterations are exhausted
+const salvagePrompt = "You have run out of iterations. Do not call any tool other than `%s`.\n\n" +
+	"Summarize what you have found so far and call `%s` now with your best-effort answer."
+
+// salvageOutput performs a final forced completion call and returns the output of the completion tool
+func (r *BaseRunner) salvageOutput(ctx context.Context, run *agentRun) (any, error) {
+	prompts, err := r.systemPrompt(run)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create prompts: %w", err)
+	}
+
+	// The salvage instruction is only sent for this call, it is not kept in the history
+	messages := make([]*llm.ModelMessage, len(run.messages), len(run.messages)+1)
+	copy(messages, run.messages)
+	messages = append(messages, &llm.ModelMessage{
+		Role:    llm.RoleUser,
+		Content: fmt.Sprintf(salvagePrompt, r.completionTool.Name, r.completionTool.Name),
+	})
+	completionReq := &llm.CompletionRequest{
+		Instructions: prompts,
+		Messages:     messages,
+	}
+
+	if run.callback != nil {
+		if err := run.callback.BeforeModel(ctx, run.agent.ModelProvider, run.agent.Model, prompts, messages); err != nil {
+			return nil, fmt.Errorf("callback BeforeModel failed: %w", err)
+		}
+	}
+
+	model, err := r.callModel(run, ToolChoiceTool(r.completionTool.Name))
+	if err != nil {
+		return nil, err
+	}
+	var turn *modelTurn
+	if run.events == nil {
+		turn, err = r.complete(ctx, run, model, prompts, completionReq)
+	} else {
+		turn, err = r.streamComplete(ctx, run, model, prompts, completionReq)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if turn.err != nil {
+		return nil, turn.err
+	}
+	if err := r.priceTurn(run, turn); err != nil {
+		return nil, err
+	}
+	run.addUsage(turn)
+
+	if turn.parseErr == nil {
+		r.resolveToolAlias(run, turn.toolCall)
+	}
+	var output any
+	switch {
+	case turn.parseErr != nil && run.directAnswer && r.format.plainTextPrefix(turn.output) == len(turn.output):
+		output = strings.TrimSpace(turn.output)
+	case turn.parseErr != nil:
+		return nil, turn.parseErr
+	case turn.toolCall.Name != r.completionTool.Name:
+		return nil, fmt.Errorf("model called '%s' instead of '%s'", turn.toolCall.Name, r.completionTool.Name)
+	default:
+		toolCall := turn.toolCall
+		tool, err := run.toolRegistry.GetTool(toolCall.Name)
+		if err != nil {
+			return nil, err
+		}
+		toolCall.StartAt = time.Now()
+		output, err = tool.Run(ctx, toolCall.Input)
+		toolCall.EndAt = time.Now()
+		run.agentContext.AppendToolCall(toolCall)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	run.events.emit(AgentEvent{
+		Type:    AgentEventTypeOutput,
+		Output:  output,
+		Usage:   run.usageTotals(),
+		Partial: true,
+	})
+	return output, nil
+}
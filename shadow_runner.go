@@ -0,0 +1,271 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/easyagent-dev/llm"
+	"github.com/google/uuid"
+)
+
+// DefaultShadowTimeout bounds the duration of shadow runs when none is set
+const DefaultShadowTimeout = 5 * time.Minute
+
+// ShadowMetadataKey tags the metadata of shadow runs with the run ID of their primary run,
+// so sinks and stores can tell them apart
+const ShadowMetadataKey = "shadow_of"
+
+// ShadowResult is the outcome of a request run by the primary and the shadow runner
+type ShadowResult struct {
+	// RunID and ShadowRunID identify the primary and the shadow run
+	RunID       string `json:"runId"`
+	ShadowRunID string `json:"shadowRunId"`
+
+	// Messages is the history of the request
+	Messages []*llm.ModelMessage `json:"messages"`
+
+	Primary         *AgentResponse `json:"primary,omitempty"`
+	PrimaryError    string         `json:"primaryError,omitempty"`
+	PrimaryDuration time.Duration  `json:"primaryDuration"`
+
+	Shadow         *AgentResponse `json:"shadow,omitempty"`
+	ShadowError    string         `json:"shadowError,omitempty"`
+	ShadowDuration time.Duration  `json:"shadowDuration"`
+
+	// Divergence is the first differing tool call, nil if both runs made the same calls
+	Divergence *ToolCallDivergence `json:"divergence,omitempty"`
+
+	// OutputChanges are the differing leaves of the outputs, from the primary to the shadow output
+	OutputChanges []*OutputChange `json:"outputChanges,omitempty"`
+}
+
+// compare sets the divergence and the output changes of the runs
+func (r *ShadowResult) compare() {
+	var primary, shadow []*TracedToolCall
+	var primaryOutput, shadowOutput any
+	if r.Primary != nil {
+		primary = tracedToolCalls(r.Primary.ToolCalls)
+		primaryOutput = r.Primary.Output
+	}
+	if r.Shadow != nil {
+		shadow = tracedToolCalls(r.Shadow.ToolCalls)
+		shadowOutput = r.Shadow.Output
+	}
+	r.Divergence = toolCallDivergence(primary, shadow)
+	r.OutputChanges = diffOutputs(primaryOutput, shadowOutput)
+}
+
+// tracedToolCalls returns the tool and input of tool calls
+func tracedToolCalls(toolCalls []*llm.ToolCall) []*TracedToolCall {
+	traced := make([]*TracedToolCall, 0, len(toolCalls))
+	for _, toolCall := range toolCalls {
+		traced = append(traced, &TracedToolCall{Tool: toolCall.Name, Input: toolCall.Input})
+	}
+	return traced
+}
+
+// ShadowRecorder receives the results of shadowed requests, once both runs ended
+type ShadowRecorder interface {
+	RecordShadow(ctx context.Context, result *ShadowResult)
+}
+
+// ShadowRecorderFunc adapts a function to the ShadowRecorder interface
+type ShadowRecorderFunc func(ctx context.Context, result *ShadowResult)
+
+// RecordShadow calls f
+func (f ShadowRecorderFunc) RecordShadow(ctx context.Context, result *ShadowResult) {
+	f(ctx, result)
+}
+
+// ShadowLog writes shadow results as JSONL for offline comparison.
+// This type is safe for concurrent use.
+type ShadowLog struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	logger  Logger
+}
+
+var _ ShadowRecorder = (*ShadowLog)(nil)
+
+// NewShadowLog creates a recorder writing shadow results to w, logging write errors to logger
+func NewShadowLog(w io.Writer, logger Logger) *ShadowLog {
+	if logger == nil {
+		logger = &NoOpLogger{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return &ShadowLog{encoder: encoder, logger: logger}
+}
+
+// RecordShadow writes result as a JSON line
+func (l *ShadowLog) RecordShadow(ctx context.Context, result *ShadowResult) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.encoder.Encode(result); err != nil {
+		l.logger.Error("failed to write shadow result", "runId", result.RunID, "error", err)
+	}
+}
+
+// ShadowOption is a functional option for configuring shadow runners
+type ShadowOption func(*ShadowRunner)
+
+// WithShadowTimeout bounds the duration of shadow runs, DefaultShadowTimeout by default
+func WithShadowTimeout(timeout time.Duration) ShadowOption {
+	return func(r *ShadowRunner) {
+		r.timeout = timeout
+	}
+}
+
+// WithShadowPercent sets the share of requests also run by the shadow runner, from 0 to 100, all by default
+func WithShadowPercent(percent float64) ShadowOption {
+	return func(r *ShadowRunner) {
+		r.percent = percent
+	}
+}
+
+// ShadowRunner runs requests with the primary runner and, in parallel, a candidate shadow runner
+// in dry-run mode, so the shadow's tools are simulated rather than run. The primary response is
+// returned as soon as it is ready and is never affected by the shadow run; both outcomes are given
+// to the recorder once the shadow run ends, for offline comparison.
+//
+// Shadow runs get no callback, outlive the cancellation of the request and are bounded by the shadow timeout.
+// This type is safe for concurrent use.
+type ShadowRunner struct {
+	primary  Runner
+	shadow   Runner
+	recorder ShadowRecorder
+	timeout  time.Duration
+	percent  float64
+
+	mu       sync.Mutex
+	closed   bool
+	inflight sync.WaitGroup
+}
+
+var _ Runner = (*ShadowRunner)(nil)
+
+// NewShadowRunner creates a runner shadowing primary with shadow, recording both outcomes with recorder
+func NewShadowRunner(primary, shadow Runner, recorder ShadowRecorder, opts ...ShadowOption) (*ShadowRunner, error) {
+	if primary == nil {
+		return nil, errors.New("primary runner is required")
+	}
+	if shadow == nil {
+		return nil, errors.New("shadow runner is required")
+	}
+	if recorder == nil {
+		return nil, errors.New("shadow recorder is required")
+	}
+	r := &ShadowRunner{
+		primary:  primary,
+		shadow:   shadow,
+		recorder: recorder,
+		timeout:  DefaultShadowTimeout,
+		percent:  100,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.percent < 0 || r.percent > 100 {
+		return nil, fmt.Errorf("shadow percent must be between 0 and 100, got %g", r.percent)
+	}
+	return r, nil
+}
+
+// Run runs the request with the primary runner and starts its shadow run
+func (r *ShadowRunner) Run(ctx context.Context, req *AgentRequest, callback Callback) (*AgentResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	primaryReq := req
+	var primaryDone chan *ShadowResult
+	if r.percent > 0 && (r.percent >= 100 || rand.Float64()*100 < r.percent) {
+		// The shadow run is tagged with the primary run ID, which must be known in advance
+		if req.RunID == "" {
+			copied := *req
+			copied.RunID = uuid.New().String()
+			primaryReq = &copied
+		}
+		primaryDone = r.startShadow(ctx, primaryReq)
+	}
+
+	start := time.Now()
+	resp, err := r.primary.Run(ctx, primaryReq, callback)
+	if primaryDone != nil {
+		result := &ShadowResult{Primary: resp, PrimaryDuration: time.Since(start)}
+		if err != nil {
+			result.PrimaryError = err.Error()
+		}
+		primaryDone <- result
+	}
+	return resp, err
+}
+
+// startShadow starts the shadow run of req, returning the channel receiving the primary outcome.
+// It returns nil once the runner is closed.
+func (r *ShadowRunner) startShadow(ctx context.Context, req *AgentRequest) chan *ShadowResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+
+	shadowReq := *req
+	shadowReq.RunID = req.RunID + "/shadow"
+	shadowReq.DryRun = true
+	shadowReq.Metadata = maps.Clone(req.Metadata)
+	if shadowReq.Metadata == nil {
+		shadowReq.Metadata = make(map[string]string, 1)
+	}
+	shadowReq.Metadata[ShadowMetadataKey] = req.RunID
+
+	primaryDone := make(chan *ShadowResult, 1)
+	r.inflight.Add(1)
+	go func() {
+		defer r.inflight.Done()
+		shadowCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.timeout)
+		defer cancel()
+
+		start := time.Now()
+		resp, err := r.shadow.Run(shadowCtx, &shadowReq, nil)
+		duration := time.Since(start)
+
+		result := <-primaryDone
+		result.RunID = req.RunID
+		result.ShadowRunID = shadowReq.RunID
+		result.Messages = req.Messages
+		result.Shadow = resp
+		result.ShadowDuration = duration
+		if err != nil {
+			result.ShadowError = err.Error()
+		}
+		result.compare()
+		r.recorder.RecordShadow(context.WithoutCancel(ctx), result)
+	}()
+	return primaryDone
+}
+
+// Close stops starting shadow runs and waits until the running ones are recorded or ctx is done
+func (r *ShadowRunner) Close(ctx context.Context) error {
+	r.mu.Lock()
+	r.closed = true
+	r.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		r.inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
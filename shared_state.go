@@ -0,0 +1,136 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// SharedStateEntry is a versioned value stored in a SharedState
+type SharedStateEntry struct {
+	// Value is the stored value
+	Value any `json:"value"`
+
+	// Version is incremented every time the key is written
+	Version int64 `json:"version"`
+}
+
+// SharedState is a blackboard that multiple agents in a workflow can read and write.
+// Every key carries a version so writers can use optimistic concurrency: read a value,
+// compute a new one, then write it back only if nobody changed it in the meantime.
+// This type is safe for concurrent use.
+type SharedState struct {
+	mu      sync.RWMutex
+	entries map[string]SharedStateEntry
+}
+
+// NewSharedState creates an empty shared state
+func NewSharedState() *SharedState {
+	return &SharedState{
+		entries: make(map[string]SharedStateEntry),
+	}
+}
+
+// Get returns the value and version stored under key.
+// The version is 0 and ok is false if the key does not exist.
+func (s *SharedState) Get(key string) (any, int64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.entries[key]
+	return entry.Value, entry.Version, ok
+}
+
+// Set unconditionally writes value under key and returns the new version
+func (s *SharedState) Set(key string, value any) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	version := s.entries[key].Version + 1
+	s.entries[key] = SharedStateEntry{Value: value, Version: version}
+	return version
+}
+
+// CompareAndSet writes value under key only if the current version equals expectedVersion.
+// Use expectedVersion 0 to create a key that must not exist yet.
+// It returns the new version, or ErrVersionConflict if the key was modified concurrently.
+func (s *SharedState) CompareAndSet(key string, value any, expectedVersion int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := s.entries[key].Version
+	if current != expectedVersion {
+		return current, fmt.Errorf("%w: key '%s' is at version %d, expected %d", ErrVersionConflict, key, current, expectedVersion)
+	}
+
+	version := current + 1
+	s.entries[key] = SharedStateEntry{Value: value, Version: version}
+	return version, nil
+}
+
+// Update applies fn to the current value of key and writes the result using CompareAndSet,
+// retrying up to maxAttempts times when a concurrent writer wins the race.
+// If maxAttempts is 0 or negative, a single attempt is made.
+func (s *SharedState) Update(key string, maxAttempts int, fn func(current any, exists bool) (any, error)) (int64, error) {
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+
+	var err error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		current, version, exists := s.Get(key)
+		next, fnErr := fn(current, exists)
+		if fnErr != nil {
+			return version, fnErr
+		}
+
+		var newVersion int64
+		newVersion, err = s.CompareAndSet(key, next, version)
+		if err == nil {
+			return newVersion, nil
+		}
+	}
+	return 0, err
+}
+
+// Delete removes key if its current version equals expectedVersion.
+// Use a negative expectedVersion to delete unconditionally.
+func (s *SharedState) Delete(key string, expectedVersion int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+	if expectedVersion >= 0 && current.Version != expectedVersion {
+		return fmt.Errorf("%w: key '%s' is at version %d, expected %d", ErrVersionConflict, key, current.Version, expectedVersion)
+	}
+	delete(s.entries, key)
+	return nil
+}
+
+// Keys returns all keys in sorted order
+func (s *SharedState) Keys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]string, 0, len(s.entries))
+	for key := range s.entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Snapshot returns a copy of all entries
+func (s *SharedState) Snapshot() map[string]SharedStateEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshot := make(map[string]SharedStateEntry, len(s.entries))
+	for key, entry := range s.entries {
+		snapshot[key] = entry
+	}
+	return snapshot
+}
@@ -0,0 +1,168 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/easyagent-dev/llm"
+)
+
+const (
+	// SharedStateGetToolName is the name of the tool reading from the shared state
+	SharedStateGetToolName = "shared_state_get"
+
+	// SharedStateSetToolName is the name of the tool writing to the shared state
+	SharedStateSetToolName = "shared_state_set"
+)
+
+// SharedStateGetInput is the input of the shared_state_get tool
+type SharedStateGetInput struct {
+	Key string `json:"key,omitempty" jsonschema:"description=Key to read. Leave empty to list all keys."`
+}
+
+// SharedStateSetInput is the input of the shared_state_set tool
+type SharedStateSetInput struct {
+	Key     string `json:"key" jsonschema:"required,description=Key to write"`
+	Value   any    `json:"value" jsonschema:"required,description=Value to store"`
+	Version *int64 `json:"version,omitempty" jsonschema:"description=Version returned by shared_state_get. The write fails if the key changed since. Use 0 to create a new key."`
+}
+
+// SharedStateGetTool lets the model read intermediate results from the run's SharedState
+type SharedStateGetTool struct{}
+
+var _ ModelTool = &SharedStateGetTool{}
+
+// NewSharedStateGetTool creates a new shared_state_get tool
+func NewSharedStateGetTool() *SharedStateGetTool {
+	return &SharedStateGetTool{}
+}
+
+// Name returns the name of the tool
+func (t *SharedStateGetTool) Name() string {
+	return SharedStateGetToolName
+}
+
+// Description returns a description of what the tool does
+func (t *SharedStateGetTool) Description() string {
+	return "Reads a value and its version from the state shared with other agents"
+}
+
+// InputSchema returns the input schema of the tool
+func (t *SharedStateGetTool) InputSchema() any {
+	return llm.GenerateSchema[SharedStateGetInput]()
+}
+
+// OutputSchema returns the output schema of the tool
+func (t *SharedStateGetTool) OutputSchema() any {
+	return nil
+}
+
+// Usage returns an example of how to use the tool
+func (t *SharedStateGetTool) Usage() string {
+	return `{"key":"research_notes"}`
+}
+
+// Effect returns ToolEffectReadOnly, reading the shared state has no side effects
+func (t *SharedStateGetTool) Effect() ToolEffect {
+	return ToolEffectReadOnly
+}
+
+// Run runs the tool with the provided parameters
+func (t *SharedStateGetTool) Run(ctx context.Context, input map[string]any) (any, error) {
+	state, err := sharedStateOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key, _ := input["key"].(string)
+	if key == "" {
+		return map[string]any{"keys": state.Keys()}, nil
+	}
+
+	value, version, ok := state.Get(key)
+	return map[string]any{
+		"key":     key,
+		"exists":  ok,
+		"value":   value,
+		"version": version,
+	}, nil
+}
+
+// SharedStateSetTool lets the model publish intermediate results to the run's SharedState
+type SharedStateSetTool struct{}
+
+var _ ModelTool = &SharedStateSetTool{}
+
+// NewSharedStateSetTool creates a new shared_state_set tool
+func NewSharedStateSetTool() *SharedStateSetTool {
+	return &SharedStateSetTool{}
+}
+
+// Name returns the name of the tool
+func (t *SharedStateSetTool) Name() string {
+	return SharedStateSetToolName
+}
+
+// Description returns a description of what the tool does
+func (t *SharedStateSetTool) Description() string {
+	return "Writes a value to the state shared with other agents, optionally only if it is still at the given version"
+}
+
+// InputSchema returns the input schema of the tool
+func (t *SharedStateSetTool) InputSchema() any {
+	return llm.GenerateSchema[SharedStateSetInput]()
+}
+
+// OutputSchema returns the output schema of the tool
+func (t *SharedStateSetTool) OutputSchema() any {
+	return nil
+}
+
+// Usage returns an example of how to use the tool
+func (t *SharedStateSetTool) Usage() string {
+	return `{"key":"research_notes","value":["finding 1"],"version":3}`
+}
+
+// Effect returns ToolEffectMutating
+func (t *SharedStateSetTool) Effect() ToolEffect {
+	return ToolEffectMutating
+}
+
+// Run runs the tool with the provided parameters
+func (t *SharedStateSetTool) Run(ctx context.Context, input map[string]any) (any, error) {
+	state, err := sharedStateOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key, _ := input["key"].(string)
+	if key == "" {
+		return nil, fmt.Errorf("%w: key is required", ErrInvalidInput)
+	}
+	value := input["value"]
+
+	rawVersion, hasVersion := input["version"]
+	if !hasVersion || rawVersion == nil {
+		return map[string]any{"key": key, "version": state.Set(key, value)}, nil
+	}
+
+	expected, ok := rawVersion.(float64)
+	if !ok {
+		return nil, fmt.Errorf("%w: version must be a number", ErrInvalidInput)
+	}
+	version, err := state.CompareAndSet(key, value, int64(expected))
+	if err != nil {
+		return nil, fmt.Errorf("%w; read the key again and retry", err)
+	}
+	return map[string]any{"key": key, "version": version}, nil
+}
+
+// sharedStateOf returns the SharedState of the current agent run
+func sharedStateOf(ctx context.Context) (*SharedState, error) {
+	agentContext, ok := AgentContextOf(ctx)
+	if !ok || agentContext.SharedState == nil {
+		return nil, errors.New("no shared state is attached to this run")
+	}
+	return agentContext.SharedState, nil
+}
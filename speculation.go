@@ -0,0 +1,281 @@
+package agent
+
+import (
+	"context"
+	"time"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// ResultPredictor predicts the result of a tool call before the tool returns
+type ResultPredictor interface {
+	// PredictResult returns the predicted result of toolCall, false if it cannot predict one
+	PredictResult(ctx context.Context, toolCall *llm.ToolCall) (any, bool)
+}
+
+// ResultPredictorFunc adapts a function to the ResultPredictor interface
+type ResultPredictorFunc func(ctx context.Context, toolCall *llm.ToolCall) (any, bool)
+
+// PredictResult calls f
+func (f ResultPredictorFunc) PredictResult(ctx context.Context, toolCall *llm.ToolCall) (any, bool) {
+	return f(ctx, toolCall)
+}
+
+// SpeculationConfig configures speculative model calls. It is experimental, see x.SpeculationConfig.
+type SpeculationConfig struct {
+	// Predictor predicts the results of tool calls
+	Predictor ResultPredictor
+
+	// Match reports whether the actual result of a tool is close enough to the prediction to keep the
+	// model call made with it. If nil, both must serialize to the same tool message.
+	Match func(predicted, actual any) bool
+
+	// Delay is how long a tool must run before the next model call is started, so fast tools
+	// do not spend tokens on speculation
+	Delay time.Duration
+}
+
+// SpeculationStats counts the speculative model calls of a run
+type SpeculationStats struct {
+	// Attempts is the number of model calls started with a predicted tool result
+	Attempts int `json:"attempts"`
+
+	// Hits is the number of those calls used as the next model call
+	Hits int `json:"hits"`
+}
+
+// HitRate returns the share of speculative model calls used, 0 without attempts
+func (s SpeculationStats) HitRate() float64 {
+	if s.Attempts == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Attempts)
+}
+
+// WithSpeculation enables speculative next-step prefetch, an experimental mode trading tokens for
+// latency. While a tool runs, the next model call is started with the result predicted by the
+// config's predictor. The call is used if the actual result matches and the next request is
+// otherwise unchanged, and discarded otherwise. Discarded calls still count in the usage and cost.
+//
+// Deprecated: Use x.WithSpeculation. Speculation is experimental and not covered by the v1 API.
+func WithSpeculation(config SpeculationConfig) RunnerOption {
+	return func(c *runnerConfig) {
+		c.speculation = &config
+	}
+}
+
+// speculation is a model call started with a predicted tool result.
+// It implements llm.CompletionModel to replay its outcome in place of the next model call.
+type speculation struct {
+	timer  *time.Timer
+	cancel context.CancelFunc
+
+	// base is the history the call was started from, ending with the tool call
+	base []*llm.ModelMessage
+
+	// predicted is the predicted result and message, actual the message of the actual result once it matched
+	predicted        any
+	predictedMessage *llm.ModelMessage
+	actual           *llm.ModelMessage
+
+	prompts string
+
+	// done is closed once the call returned, the fields below are set then
+	done     chan struct{}
+	response *llm.CompletionResponse
+	chunks   []llm.StreamChunk
+	err      error
+	duration time.Duration
+}
+
+var _ llm.CompletionModel = (*speculation)(nil)
+
+// Complete returns the response of the speculative call
+func (s *speculation) Complete(ctx context.Context, req *llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	<-s.done
+	return s.response, s.err
+}
+
+// StreamComplete replays the chunks streamed by the speculative call
+func (s *speculation) StreamComplete(ctx context.Context, req *llm.CompletionRequest) (llm.StreamCompletionResponse, error) {
+	<-s.done
+	if s.err != nil {
+		return nil, s.err
+	}
+	chunks := make(chan llm.StreamChunk, len(s.chunks))
+	for _, chunk := range s.chunks {
+		chunks <- chunk
+	}
+	close(chunks)
+	return chunks, nil
+}
+
+// speculate schedules the next model call with the predicted result of toolCall, started once the
+// tool ran for the configured delay. It returns nil if the call is not speculated on.
+func (r *BaseRunner) speculate(ctx context.Context, run *agentRun, tool ModelTool, toolCall *llm.ToolCall, prompts string) *speculation {
+	if r.speculation == nil || tool.Name() == r.completionTool.Name || tool.Name() == HandoffToolName || isTransactionTool(tool.Name()) {
+		return nil
+	}
+	predicted, ok := r.speculation.Predictor.PredictResult(ctx, toolCall)
+	if !ok {
+		return nil
+	}
+	predictedMessage, err := r.toolResultMessage(toolCall, predicted)
+	if err != nil {
+		return nil
+	}
+	model, err := r.callModel(run, ToolChoiceAuto)
+	if err != nil {
+		return nil
+	}
+
+	// The tool call is updated once the tool returns, the request gets a snapshot of it
+	messages := make([]*llm.ModelMessage, len(run.messages), len(run.messages)+1)
+	copy(messages, run.messages)
+	last := *messages[len(messages)-1]
+	snapshot := *toolCall
+	last.ToolCall = &snapshot
+	messages[len(messages)-1] = &last
+	messages = append(messages, predictedMessage)
+	req := &llm.CompletionRequest{
+		Instructions: prompts,
+		Messages:     messages,
+	}
+
+	specCtx, cancel := context.WithCancel(ctx)
+	s := &speculation{
+		cancel:           cancel,
+		base:             run.messages,
+		predicted:        predicted,
+		predictedMessage: predictedMessage,
+		prompts:          prompts,
+		done:             make(chan struct{}),
+	}
+	streaming := run.events != nil
+	s.timer = time.AfterFunc(r.speculation.Delay, func() {
+		defer close(s.done)
+		start := time.Now()
+		if !streaming {
+			s.response, s.err = model.Complete(specCtx, req)
+			s.duration = time.Since(start)
+			return
+		}
+		stream, err := model.StreamComplete(specCtx, req)
+		if err != nil {
+			s.err = err
+			return
+		}
+		for chunk := range stream {
+			s.chunks = append(s.chunks, chunk)
+		}
+		s.duration = time.Since(start)
+		if specCtx.Err() != nil {
+			s.err = specCtx.Err()
+		}
+	})
+	return s
+}
+
+// settleSpeculation is called once the tool of a speculation returned. A call not started yet is
+// dropped, a started one is kept for matchSpeculation.
+func (r *BaseRunner) settleSpeculation(run *agentRun, s *speculation) {
+	if s == nil {
+		return
+	}
+	if s.timer.Stop() {
+		s.cancel()
+		return
+	}
+	run.speculationStats.Attempts++
+	run.speculation = s
+}
+
+// matchSpeculation keeps the pending speculation if the actual result of its tool, added to the
+// history as message, matches the prediction, and discards it otherwise
+func (r *BaseRunner) matchSpeculation(run *agentRun, actual any, message *llm.ModelMessage) {
+	s := run.speculation
+	if s == nil {
+		return
+	}
+	matched := false
+	if r.speculation.Match != nil {
+		matched = r.speculation.Match(s.predicted, actual)
+	} else {
+		matched = sameToolMessage(s.predictedMessage, message)
+	}
+	if !matched {
+		r.discardSpeculation(run)
+		return
+	}
+	s.actual = message
+}
+
+// takeSpeculation returns the pending speculation if its call can replace the model call with
+// prompts and messages, and discards it otherwise
+func (r *BaseRunner) takeSpeculation(run *agentRun, prompts string, messages []*llm.ModelMessage) *speculation {
+	s := run.speculation
+	if s == nil {
+		return nil
+	}
+	if s.actual == nil || s.prompts != prompts || !sameHistory(messages, s.base, s.actual) {
+		r.discardSpeculation(run)
+		return nil
+	}
+	<-s.done
+	if s.err != nil {
+		r.discardSpeculation(run)
+		return nil
+	}
+	run.speculation = nil
+	run.speculationStats.Hits++
+	return s
+}
+
+// discardSpeculation cancels the pending speculation. The usage of its call, if it returned,
+// is still added to the run.
+func (r *BaseRunner) discardSpeculation(run *agentRun) {
+	s := run.speculation
+	if s == nil {
+		return
+	}
+	run.speculation = nil
+	s.cancel()
+	<-s.done
+
+	turn := &modelTurn{duration: s.duration}
+	if s.response != nil {
+		turn.usage, turn.cost = s.response.Usage, s.response.Cost
+	}
+	for _, chunk := range s.chunks {
+		if usage, ok := chunk.(llm.StreamUsageChunk); ok {
+			turn.usage, turn.cost = usage.Usage, usage.Cost
+		}
+	}
+	if turn.usage == nil && turn.cost == nil {
+		return
+	}
+	// A pricing catalog failing on unknown models already fails the regular calls
+	_ = r.priceTurn(run, turn)
+	run.addUsage(turn)
+}
+
+// sameHistory reports whether messages is base followed by actual
+func sameHistory(messages, base []*llm.ModelMessage, actual *llm.ModelMessage) bool {
+	if len(messages) != len(base)+1 || messages[len(base)] != actual {
+		return false
+	}
+	for i, message := range base {
+		if messages[i] != message {
+			return false
+		}
+	}
+	return true
+}
+
+// sameToolMessage reports whether two tool result messages carry the same content
+func sameToolMessage(a, b *llm.ModelMessage) bool {
+	if a.Content != b.Content || (a.ToolCall == nil) != (b.ToolCall == nil) {
+		return false
+	}
+	return a.ToolCall == nil || a.ToolCall.Output == b.ToolCall.Output
+}
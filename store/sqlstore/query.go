@@ -0,0 +1,344 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/easyagent-dev/agent"
+	"github.com/easyagent-dev/llm"
+)
+
+// RunStatus is the status of a recorded run
+type RunStatus string
+
+// Run statuses
+const (
+	RunRunning   RunStatus = "running"
+	RunCompleted RunStatus = "completed"
+	RunFailed    RunStatus = "failed"
+)
+
+// DefaultListLimit bounds the runs returned by ListRuns when the filter sets no limit
+const DefaultListLimit = 100
+
+// Run is a recorded run
+type Run struct {
+	ID       string            `json:"id"`
+	Agent    string            `json:"agent"`
+	Tenant   string            `json:"tenant,omitempty"`
+	Status   RunStatus         `json:"status"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Version  *agent.RunVersion `json:"version,omitempty"`
+
+	// Output is set for completed runs, Error for failed ones
+	Output any    `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+
+	Usage llm.TokenUsage `json:"usage"`
+	Cost  float64        `json:"cost"`
+
+	Iterations int `json:"iterations"`
+	ToolCalls  int `json:"toolCalls"`
+
+	StartedAt time.Time `json:"startedAt"`
+
+	// FinishedAt is zero while the run is running
+	FinishedAt time.Time `json:"finishedAt,omitempty"`
+}
+
+// Iteration is a model call of a run
+type Iteration struct {
+	Seq      int            `json:"seq"`
+	Agent    string         `json:"agent"`
+	Provider string         `json:"provider"`
+	Model    string         `json:"model"`
+	Output   string         `json:"output"`
+	Usage    llm.TokenUsage `json:"usage"`
+	Time     time.Time      `json:"time"`
+}
+
+// ToolCall is a tool call of a run
+type ToolCall struct {
+	Seq        int            `json:"seq"`
+	ID         string         `json:"id"`
+	Tool       string         `json:"tool"`
+	Input      map[string]any `json:"input,omitempty"`
+	Output     any            `json:"output,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	StartedAt  time.Time      `json:"startedAt"`
+	FinishedAt time.Time      `json:"finishedAt"`
+}
+
+// Trace is a run with its iterations and tool calls, in order
+type Trace struct {
+	Run        *Run         `json:"run"`
+	Iterations []*Iteration `json:"iterations"`
+	ToolCalls  []*ToolCall  `json:"toolCalls"`
+}
+
+// RunFilter selects runs. Empty fields match every run.
+type RunFilter struct {
+	Tenant string
+	Agent  string
+	Status RunStatus
+
+	// From and To bound the start time of runs, To excluded
+	From time.Time
+	To   time.Time
+
+	// Limit and Offset page the runs, DefaultListLimit runs by default
+	Limit  int
+	Offset int
+}
+
+// TenantUsage is the usage of the runs of a tenant over a period
+type TenantUsage struct {
+	Tenant string         `json:"tenant"`
+	Runs   int            `json:"runs"`
+	Failed int            `json:"failed"`
+	Usage  llm.TokenUsage `json:"usage"`
+	Cost   float64        `json:"cost"`
+}
+
+const runColumns = `id, agent, tenant, status, metadata, version, output, error, input_tokens, output_tokens,
+	reasoning_tokens, cache_read_tokens, cache_write_tokens, requests, cost, iterations, tool_calls,
+	started_at, finished_at`
+
+// GetRun selects a run
+func (s *Store) GetRun(ctx context.Context, id string) (*Run, error) {
+	rows, err := s.db.QueryContext(ctx, s.rebind(`SELECT `+runColumns+` FROM `+s.runs+` WHERE id = ?`), id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select run: %w", err)
+	}
+	runs, err := scanRuns(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return nil, ErrRunNotFound
+	}
+	return runs[0], nil
+}
+
+// ListRuns selects the runs matching filter, most recent first
+func (s *Store) ListRuns(ctx context.Context, filter RunFilter) ([]*Run, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+	if filter.Tenant != "" {
+		conditions = append(conditions, "tenant = ?")
+		args = append(args, filter.Tenant)
+	}
+	if filter.Agent != "" {
+		conditions = append(conditions, "agent = ?")
+		args = append(args, filter.Agent)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, string(filter.Status))
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "started_at >= ?")
+		args = append(args, unixNano(filter.From))
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "started_at < ?")
+		args = append(args, unixNano(filter.To))
+	}
+	query := `SELECT ` + runColumns + ` FROM ` + s.runs
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	query += ` ORDER BY started_at DESC, id LIMIT ? OFFSET ?`
+	args = append(args, limit, max(filter.Offset, 0))
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select runs: %w", err)
+	}
+	return scanRuns(rows)
+}
+
+// Trace selects a run with its iterations and tool calls
+func (s *Store) Trace(ctx context.Context, id string) (*Trace, error) {
+	run, err := s.GetRun(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	trace := &Trace{Run: run, Iterations: []*Iteration{}, ToolCalls: []*ToolCall{}}
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(`SELECT seq, agent, provider, model, output, input_tokens,
+		output_tokens, reasoning_tokens, cache_read_tokens, cache_write_tokens, time
+		FROM `+s.iterations+` WHERE run_id = ? ORDER BY seq`), id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select run iterations: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			iteration Iteration
+			at        int64
+		)
+		err := rows.Scan(&iteration.Seq, &iteration.Agent, &iteration.Provider, &iteration.Model, &iteration.Output,
+			&iteration.Usage.TotalInputTokens, &iteration.Usage.TotalOutputTokens, &iteration.Usage.TotalReasoningTokens,
+			&iteration.Usage.TotalCacheReadTokens, &iteration.Usage.TotalCacheWriteTokens, &at)
+		if err != nil {
+			return nil, fmt.Errorf("failed to select run iterations: %w", err)
+		}
+		iteration.Usage.TotalRequests = 1
+		iteration.Time = fromUnixNano(at)
+		trace.Iterations = append(trace.Iterations, &iteration)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to select run iterations: %w", err)
+	}
+
+	toolRows, err := s.db.QueryContext(ctx, s.rebind(`SELECT seq, call_id, tool, input, output, error, started_at, finished_at
+		FROM `+s.toolCalls+` WHERE run_id = ? ORDER BY seq`), id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select run tool calls: %w", err)
+	}
+	defer toolRows.Close()
+	for toolRows.Next() {
+		var (
+			toolCall              ToolCall
+			input, output         sql.NullString
+			startedAt, finishedAt int64
+		)
+		err := toolRows.Scan(&toolCall.Seq, &toolCall.ID, &toolCall.Tool, &input, &output, &toolCall.Error, &startedAt, &finishedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to select run tool calls: %w", err)
+		}
+		if err := unmarshalJSON(input, &toolCall.Input); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal tool input: %w", err)
+		}
+		if err := unmarshalJSON(output, &toolCall.Output); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal tool output: %w", err)
+		}
+		toolCall.StartedAt = fromUnixNano(startedAt)
+		toolCall.FinishedAt = fromUnixNano(finishedAt)
+		trace.ToolCalls = append(trace.ToolCalls, &toolCall)
+	}
+	if err := toolRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to select run tool calls: %w", err)
+	}
+	return trace, nil
+}
+
+// UsageByTenant sums the usage and cost of the runs started in [from, to) per tenant, for billing.
+// Running runs are left out, their usage is not known yet.
+func (s *Store) UsageByTenant(ctx context.Context, from, to time.Time) ([]*TenantUsage, error) {
+	rows, err := s.db.QueryContext(ctx, s.rebind(`SELECT tenant, COUNT(*),
+		SUM(CASE WHEN status = ? THEN 1 ELSE 0 END),
+		SUM(input_tokens), SUM(output_tokens), SUM(reasoning_tokens), SUM(cache_read_tokens),
+		SUM(cache_write_tokens), SUM(requests), SUM(cost)
+		FROM `+s.runs+` WHERE status <> ? AND started_at >= ? AND started_at < ?
+		GROUP BY tenant ORDER BY tenant`),
+		string(RunFailed), string(RunRunning), unixNano(from), unixNano(to))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum tenant usage: %w", err)
+	}
+	defer rows.Close()
+
+	var usages []*TenantUsage
+	for rows.Next() {
+		var (
+			usage    TenantUsage
+			requests int64
+		)
+		err := rows.Scan(&usage.Tenant, &usage.Runs, &usage.Failed, &usage.Usage.TotalInputTokens,
+			&usage.Usage.TotalOutputTokens, &usage.Usage.TotalReasoningTokens, &usage.Usage.TotalCacheReadTokens,
+			&usage.Usage.TotalCacheWriteTokens, &requests, &usage.Cost)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sum tenant usage: %w", err)
+		}
+		usage.Usage.TotalRequests = int(requests)
+		usages = append(usages, &usage)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to sum tenant usage: %w", err)
+	}
+	return usages, nil
+}
+
+// UsageRecords returns the model calls made in [from, to) as usage records, to feed an
+// agent.UsageAggregator. Iterations store neither cost nor chosen tool: the aggregator prices them
+// with its pricing catalog and they are not attributed to tools.
+func (s *Store) UsageRecords(ctx context.Context, from, to time.Time) ([]*agent.UsageRecord, error) {
+	rows, err := s.db.QueryContext(ctx, s.rebind(`SELECT r.tenant, i.agent, i.provider, i.model, i.input_tokens,
+		i.output_tokens, i.reasoning_tokens, i.cache_read_tokens, i.cache_write_tokens, i.time
+		FROM `+s.iterations+` i JOIN `+s.runs+` r ON r.id = i.run_id
+		WHERE i.time >= ? AND i.time < ? ORDER BY i.time`),
+		unixNano(from), unixNano(to))
+	if err != nil {
+		return nil, fmt.Errorf("failed to select usage records: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*agent.UsageRecord
+	for rows.Next() {
+		var (
+			record agent.UsageRecord
+			at     int64
+		)
+		err := rows.Scan(&record.Tenant, &record.Agent, &record.Provider, &record.Model, &record.Usage.TotalInputTokens,
+			&record.Usage.TotalOutputTokens, &record.Usage.TotalReasoningTokens, &record.Usage.TotalCacheReadTokens,
+			&record.Usage.TotalCacheWriteTokens, &at)
+		if err != nil {
+			return nil, fmt.Errorf("failed to select usage records: %w", err)
+		}
+		record.Usage.TotalRequests = 1
+		record.Time = fromUnixNano(at)
+		records = append(records, &record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to select usage records: %w", err)
+	}
+	return records, nil
+}
+
+// scanRuns reads and closes rows of runColumns
+func scanRuns(rows *sql.Rows) ([]*Run, error) {
+	defer rows.Close()
+	var runs []*Run
+	for rows.Next() {
+		var (
+			run                       Run
+			status                    string
+			metadata, version, output sql.NullString
+			startedAt, finishedAt     int64
+		)
+		err := rows.Scan(&run.ID, &run.Agent, &run.Tenant, &status, &metadata, &version, &output, &run.Error,
+			&run.Usage.TotalInputTokens, &run.Usage.TotalOutputTokens, &run.Usage.TotalReasoningTokens,
+			&run.Usage.TotalCacheReadTokens, &run.Usage.TotalCacheWriteTokens, &run.Usage.TotalRequests, &run.Cost,
+			&run.Iterations, &run.ToolCalls, &startedAt, &finishedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to select runs: %w", err)
+		}
+		if err := unmarshalJSON(metadata, &run.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal run metadata: %w", err)
+		}
+		if err := unmarshalJSON(version, &run.Version); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal run version: %w", err)
+		}
+		if err := unmarshalJSON(output, &run.Output); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal run output: %w", err)
+		}
+		run.Status = RunStatus(status)
+		run.StartedAt = fromUnixNano(startedAt)
+		run.FinishedAt = fromUnixNano(finishedAt)
+		runs = append(runs, &run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to select runs: %w", err)
+	}
+	return runs, nil
+}
@@ -0,0 +1,204 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/easyagent-dev/agent"
+	"github.com/easyagent-dev/llm"
+)
+
+var (
+	_ agent.EventSink = (*Store)(nil)
+	_ agent.Callback  = (*Store)(nil)
+)
+
+// Send records the start and the end of runs. Tool calls are recorded with the end of the run,
+// from its context, so their outputs are included.
+// Failures are logged, recording never fails a run.
+func (s *Store) Send(ctx context.Context, event *agent.LifecycleEvent) {
+	var err error
+	switch event.Type {
+	case agent.LifecycleRunStarted:
+		err = s.startRun(ctx, event)
+	case agent.LifecycleRunCompleted, agent.LifecycleRunFailed:
+		err = s.finishRun(ctx, event)
+	default:
+		return
+	}
+	if err != nil {
+		s.logger.Error("failed to record run", "runId", event.RunID, "event", string(event.Type), "error", err)
+	}
+}
+
+// startRun inserts a running run
+func (s *Store) startRun(ctx context.Context, event *agent.LifecycleEvent) error {
+	metadata, err := marshalJSON(event.Metadata)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, s.rebind(`INSERT INTO `+s.runs+` (`+runColumns+`)
+		VALUES (?, ?, ?, ?, ?, NULL, NULL, '', 0, 0, 0, 0, 0, 0, 0, 0, 0, ?, 0)`),
+		event.RunID, event.Agent, event.Metadata[s.tenantKey], string(RunRunning), metadata, unixNano(event.Time))
+	if err != nil {
+		return fmt.Errorf("failed to insert run: %w", err)
+	}
+	return nil
+}
+
+// finishRun stores the outcome of a run and its tool calls
+func (s *Store) finishRun(ctx context.Context, event *agent.LifecycleEvent) error {
+	ctx = context.WithoutCancel(ctx)
+	status := RunCompleted
+	if event.Type == agent.LifecycleRunFailed {
+		status = RunFailed
+	}
+	version, err := marshalJSON(event.Version)
+	if err != nil {
+		return err
+	}
+	output, err := marshalJSON(event.Output)
+	if err != nil {
+		return fmt.Errorf("failed to marshal run output: %w", err)
+	}
+	usage := event.Usage
+	if usage == nil {
+		usage = &llm.TokenUsage{}
+	}
+	cost := 0.0
+	if event.Cost != nil {
+		cost = *event.Cost
+	}
+	var toolCalls []*llm.ToolCall
+	if agentContext, ok := agent.AgentContextOf(ctx); ok && agentContext.RunID == event.RunID {
+		toolCalls = agentContext.ToolCalls
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to finish run: %w", err)
+	}
+	defer tx.Rollback()
+
+	var iterations int
+	err = tx.QueryRowContext(ctx, s.rebind(`SELECT COUNT(*) FROM `+s.iterations+` WHERE run_id = ?`), event.RunID).Scan(&iterations)
+	if err != nil {
+		return fmt.Errorf("failed to count run iterations: %w", err)
+	}
+	result, err := tx.ExecContext(ctx, s.rebind(`UPDATE `+s.runs+`
+		SET status = ?, version = ?, output = ?, error = ?, input_tokens = ?, output_tokens = ?,
+			reasoning_tokens = ?, cache_read_tokens = ?, cache_write_tokens = ?, requests = ?, cost = ?,
+			iterations = ?, tool_calls = ?, finished_at = ?
+		WHERE id = ?`),
+		string(status), version, output, event.Error, usage.TotalInputTokens, usage.TotalOutputTokens,
+		usage.TotalReasoningTokens, usage.TotalCacheReadTokens, usage.TotalCacheWriteTokens, usage.TotalRequests, cost,
+		iterations, len(toolCalls), unixNano(event.Time), event.RunID)
+	if err != nil {
+		return fmt.Errorf("failed to update run: %w", err)
+	}
+	if updated, _ := result.RowsAffected(); updated == 0 {
+		// The store was registered after the run started
+		metadata, err := marshalJSON(event.Metadata)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, s.rebind(`INSERT INTO `+s.runs+` (`+runColumns+`)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+			event.RunID, event.Agent, event.Metadata[s.tenantKey], string(status), metadata, version, output, event.Error,
+			usage.TotalInputTokens, usage.TotalOutputTokens, usage.TotalReasoningTokens, usage.TotalCacheReadTokens,
+			usage.TotalCacheWriteTokens, usage.TotalRequests, cost, iterations, len(toolCalls),
+			unixNano(event.Time), unixNano(event.Time))
+		if err != nil {
+			return fmt.Errorf("failed to insert run: %w", err)
+		}
+	}
+
+	for i, toolCall := range toolCalls {
+		input, err := marshalJSON(toolCall.Input)
+		if err != nil {
+			return fmt.Errorf("failed to marshal tool input: %w", err)
+		}
+		output, err := marshalJSON(toolCall.Output)
+		if err != nil {
+			// Outputs are arbitrary values, keep the call without it
+			output = nil
+		}
+		toolError := ""
+		if toolCall.ErrorMessage != nil {
+			toolError = *toolCall.ErrorMessage
+		}
+		_, err = tx.ExecContext(ctx, s.rebind(`INSERT INTO `+s.toolCalls+`
+			(run_id, seq, call_id, tool, input, output, error, started_at, finished_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+			event.RunID, i+1, toolCall.ID, toolCall.Name, input, output, toolError,
+			unixNano(toolCall.StartAt), unixNano(toolCall.EndAt))
+		if err != nil {
+			return fmt.Errorf("failed to insert tool call: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to finish run: %w", err)
+	}
+	return nil
+}
+
+// BeforeModel does nothing
+func (s *Store) BeforeModel(ctx context.Context, provider string, model string, prompts string, messages []*llm.ModelMessage) error {
+	return nil
+}
+
+// AfterModel records an iteration of the run carried by ctx
+func (s *Store) AfterModel(ctx context.Context, provider string, model string, prompts string, messages []*llm.ModelMessage, output string, usage *llm.TokenUsage) error {
+	agentContext, ok := agent.AgentContextOf(ctx)
+	if !ok {
+		return nil
+	}
+	if err := s.appendIteration(ctx, agentContext, provider, model, output, usage); err != nil {
+		s.logger.Error("failed to record run iteration", "runId", agentContext.RunID, "error", err)
+	}
+	return nil
+}
+
+// appendIteration inserts an iteration. The iterations of a run are sequential, so the next
+// sequence number is read in the same transaction without locking.
+func (s *Store) appendIteration(ctx context.Context, agentContext *agent.AgentContext, provider, model, output string, usage *llm.TokenUsage) error {
+	if usage == nil {
+		usage = &llm.TokenUsage{}
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var seq int
+	err = tx.QueryRowContext(ctx, s.rebind(`SELECT COALESCE(MAX(seq), 0) FROM `+s.iterations+` WHERE run_id = ?`), agentContext.RunID).Scan(&seq)
+	if err != nil {
+		return err
+	}
+	agentName := ""
+	if current := agentContext.CurrentAgent(); current != nil {
+		agentName = current.Name
+	}
+	_, err = tx.ExecContext(ctx, s.rebind(`INSERT INTO `+s.iterations+`
+		(run_id, seq, agent, provider, model, output, input_tokens, output_tokens, reasoning_tokens,
+			cache_read_tokens, cache_write_tokens, time)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		agentContext.RunID, seq+1, agentName, provider, model, output, usage.TotalInputTokens, usage.TotalOutputTokens,
+		usage.TotalReasoningTokens, usage.TotalCacheReadTokens, usage.TotalCacheWriteTokens, unixNano(time.Now()))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// BeforeToolCall does nothing
+func (s *Store) BeforeToolCall(ctx context.Context, toolName string, input any) error {
+	return nil
+}
+
+// AfterToolCall does nothing, tool calls are recorded with the end of the run
+func (s *Store) AfterToolCall(ctx context.Context, toolName string, input any, output interface{}) error {
+	return nil
+}
@@ -0,0 +1,207 @@
+// Package sqlstore persists the history of agent runs to a SQL database through database/sql:
+// runs with their usage, cost and output, the model calls of every iteration, and the tool calls
+// with their inputs and outputs. Its query API lists runs by tenant, agent, status and time, fetches
+// full traces and sums usage per tenant, for dashboards and billing.
+//
+// The schema is portable and tested with PostgreSQL and SQLite, any driver can be used.
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/easyagent-dev/agent"
+)
+
+// DefaultTenantKey is the metadata key of the tenant of runs
+const DefaultTenantKey = "tenant_id"
+
+// ErrRunNotFound is returned when no run has the requested ID
+var ErrRunNotFound = errors.New("run not found")
+
+// Placeholder returns the bind parameter of the n-th query argument, starting at 1
+type Placeholder func(n int) string
+
+// QuestionPlaceholder binds arguments with ?, as SQLite and MySQL do
+func QuestionPlaceholder(n int) string {
+	return "?"
+}
+
+// DollarPlaceholder binds arguments with $1, $2..., as PostgreSQL does
+func DollarPlaceholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// Store records runs in a SQL database and queries them. Register it with agent.WithEventSink to
+// record runs and their tool calls, and pass it as the callback of runs, alone or in an
+// agent.MultiCallback, to record their iterations too.
+// Times are stored as Unix nanoseconds and JSON documents as text, keeping the schema portable.
+// This type is safe for concurrent use.
+type Store struct {
+	db          *sql.DB
+	placeholder Placeholder
+	tenantKey   string
+	logger      agent.Logger
+	runs        string
+	iterations  string
+	toolCalls   string
+}
+
+// Option is a functional option for configuring stores
+type Option func(*Store)
+
+// WithTablePrefix sets the prefix of the table names, "agent_" by default
+func WithTablePrefix(prefix string) Option {
+	return func(s *Store) {
+		s.runs = prefix + "runs"
+		s.iterations = prefix + "run_iterations"
+		s.toolCalls = prefix + "run_tool_calls"
+	}
+}
+
+// WithTenantKey sets the metadata key of the tenant of runs, DefaultTenantKey by default
+func WithTenantKey(key string) Option {
+	return func(s *Store) {
+		s.tenantKey = key
+	}
+}
+
+// WithLogger sets the logger of recording failures, which never fail runs
+func WithLogger(logger agent.Logger) Option {
+	return func(s *Store) {
+		s.logger = logger
+	}
+}
+
+// New creates a store using db, binding query arguments with placeholder
+func New(db *sql.DB, placeholder Placeholder, opts ...Option) *Store {
+	store := &Store{
+		db:          db,
+		placeholder: placeholder,
+		tenantKey:   DefaultTenantKey,
+		logger:      agent.NoOpLogger{},
+	}
+	WithTablePrefix("agent_")(store)
+	for _, opt := range opts {
+		opt(store)
+	}
+	return store
+}
+
+// Migrate creates the tables of the store if they do not exist
+func (s *Store) Migrate(ctx context.Context) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS ` + s.runs + ` (
+			id VARCHAR(128) PRIMARY KEY,
+			agent VARCHAR(255) NOT NULL,
+			tenant VARCHAR(255) NOT NULL,
+			status VARCHAR(16) NOT NULL,
+			metadata TEXT,
+			version TEXT,
+			output TEXT,
+			error TEXT NOT NULL,
+			input_tokens BIGINT NOT NULL,
+			output_tokens BIGINT NOT NULL,
+			reasoning_tokens BIGINT NOT NULL,
+			cache_read_tokens BIGINT NOT NULL,
+			cache_write_tokens BIGINT NOT NULL,
+			requests INTEGER NOT NULL,
+			cost DOUBLE PRECISION NOT NULL,
+			iterations INTEGER NOT NULL,
+			tool_calls INTEGER NOT NULL,
+			started_at BIGINT NOT NULL,
+			finished_at BIGINT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS ` + s.runs + `_tenant ON ` + s.runs + ` (tenant, started_at)`,
+		`CREATE INDEX IF NOT EXISTS ` + s.runs + `_started ON ` + s.runs + ` (started_at)`,
+		`CREATE TABLE IF NOT EXISTS ` + s.iterations + ` (
+			run_id VARCHAR(128) NOT NULL,
+			seq INTEGER NOT NULL,
+			agent VARCHAR(255) NOT NULL,
+			provider VARCHAR(64) NOT NULL,
+			model VARCHAR(255) NOT NULL,
+			output TEXT NOT NULL,
+			input_tokens BIGINT NOT NULL,
+			output_tokens BIGINT NOT NULL,
+			reasoning_tokens BIGINT NOT NULL,
+			cache_read_tokens BIGINT NOT NULL,
+			cache_write_tokens BIGINT NOT NULL,
+			time BIGINT NOT NULL,
+			PRIMARY KEY (run_id, seq)
+		)`,
+		`CREATE INDEX IF NOT EXISTS ` + s.iterations + `_time ON ` + s.iterations + ` (time)`,
+		`CREATE TABLE IF NOT EXISTS ` + s.toolCalls + ` (
+			run_id VARCHAR(128) NOT NULL,
+			seq INTEGER NOT NULL,
+			call_id VARCHAR(128) NOT NULL,
+			tool VARCHAR(255) NOT NULL,
+			input TEXT,
+			output TEXT,
+			error TEXT NOT NULL,
+			started_at BIGINT NOT NULL,
+			finished_at BIGINT NOT NULL,
+			PRIMARY KEY (run_id, seq)
+		)`,
+	}
+	for _, statement := range statements {
+		if _, err := s.db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to migrate run tables: %w", err)
+		}
+	}
+	return nil
+}
+
+// rebind replaces the ? placeholders of query with the store's placeholders
+func (s *Store) rebind(query string) string {
+	var builder strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			builder.WriteString(s.placeholder(n))
+			continue
+		}
+		builder.WriteRune(c)
+	}
+	return builder.String()
+}
+
+// marshalJSON encodes value as text, or returns nil for a nil value
+func marshalJSON(value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// unmarshalJSON decodes a nullable JSON column into target
+func unmarshalJSON(column sql.NullString, target any) error {
+	if !column.Valid || column.String == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(column.String), target)
+}
+
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func fromUnixNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
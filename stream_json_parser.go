@@ -0,0 +1,542 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// StreamJsonParserMode controls how values still being streamed are exposed
+type StreamJsonParserMode int
+
+const (
+	// StreamJsonPartialValues exposes values as soon as they start, including partial
+	// strings, numbers and the last element of an array
+	StreamJsonPartialValues StreamJsonParserMode = iota
+
+	// StreamJsonCompleteElements exposes array elements only once they are complete,
+	// so arrays grow one finished element at a time. Object fields are still exposed partially.
+	StreamJsonCompleteElements
+)
+
+// jsonExpect is what an open object or array expects at the parser's offset
+type jsonExpect int
+
+const (
+	// jsonExpectValue expects a value, after ':' in objects or ',' in arrays
+	jsonExpectValue jsonExpect = iota
+
+	// jsonExpectValueOrEnd expects the first element of an array or ']'
+	jsonExpectValueOrEnd
+
+	// jsonExpectKeyOrEnd expects the first key of an object or '}'
+	jsonExpectKeyOrEnd
+
+	// jsonExpectKey expects a key after ','
+	jsonExpectKey
+
+	// jsonExpectColon expects ':' after a key
+	jsonExpectColon
+
+	// jsonExpectSeparator expects ',' or the end of the container after a value
+	jsonExpectSeparator
+)
+
+// jsonFrame is an object or array still open at the parser's offset
+type jsonFrame struct {
+	// object holds the fields of an object, it is nil for arrays
+	object map[string]any
+	array  []any
+
+	// key is the key of the value being parsed in an object
+	key    string
+	expect jsonExpect
+}
+
+// value returns the container of the frame
+func (f *jsonFrame) value() any {
+	if f.object != nil {
+		return f.object
+	}
+	return f.array
+}
+
+// jsonString is a string still open at the parser's offset
+type jsonString struct {
+	builder strings.Builder
+	key     bool
+}
+
+// StreamJsonParser parses a JSON document while it is being streamed.
+// Any JSON value is accepted at the root. Parsing resumes from the last stable offset, so
+// streaming a document costs time linear in its size, and syntax errors stop parsing at the error.
+// Values returned are snapshots which are not modified by later appends and must not be modified.
+type StreamJsonParser struct {
+	mode StreamJsonParserMode
+
+	// buffer holds the streamed content, its capacity is kept on Reset
+	buffer []byte
+
+	// offset is where parsing resumes, the content before it is decoded into stack and str
+	offset int
+	stack  []*jsonFrame
+	str    *jsonString
+
+	root      any
+	completed bool
+	err       error
+
+	// parsed is the buffer length of the last snapshot, value holds the snapshot
+	parsed int
+	value  any
+}
+
+// NewStreamJsonParser creates a parser exposing partial values according to mode
+func NewStreamJsonParser(mode StreamJsonParserMode) *StreamJsonParser {
+	return &StreamJsonParser{mode: mode, parsed: -1}
+}
+
+// Append adds streamed content
+func (p *StreamJsonParser) Append(content string) {
+	p.buffer = append(p.buffer, content...)
+}
+
+// Reset clears the parser for reuse
+func (p *StreamJsonParser) Reset() {
+	p.buffer = p.buffer[:0]
+	p.offset = 0
+	clear(p.stack)
+	p.stack = p.stack[:0]
+	p.str = nil
+	p.root = nil
+	p.completed = false
+	p.err = nil
+	p.parsed = -1
+	p.value = nil
+}
+
+// parse parses the content appended since the last parse and takes a snapshot of the value
+func (p *StreamJsonParser) parse() {
+	if p.parsed == len(p.buffer) {
+		return
+	}
+	p.parsed = len(p.buffer)
+
+	p.advance(p.buffer)
+	p.value = p.snapshot(p.buffer)
+}
+
+// Value returns the root value parsed so far, or nil if no value started yet
+func (p *StreamJsonParser) Value() any {
+	p.parse()
+	return p.value
+}
+
+// Get returns the value at the path of object keys, or nil if it is not parsed yet.
+// Without keys it returns the root value.
+func (p *StreamJsonParser) Get(keys ...string) any {
+	value := p.Value()
+	for _, key := range keys {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
+
+// ToString serializes the value parsed so far as a valid JSON document, closing
+// truncated strings, arrays and objects. It returns an empty string if no value started yet.
+func (p *StreamJsonParser) ToString() (string, error) {
+	value := p.Value()
+	if value == nil && !p.completed {
+		return "", nil
+	}
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return "", fmt.Errorf("failed to marshal partial JSON: %w", err)
+	}
+	return strings.TrimSuffix(buffer.String(), "\n"), nil
+}
+
+// IsCompleted reports whether the root value is complete
+func (p *StreamJsonParser) IsCompleted() bool {
+	p.parse()
+	return p.completed
+}
+
+// End returns the offset just after the root value once it is completed,
+// so trailing content can be told apart from the document
+func (p *StreamJsonParser) End() int {
+	p.parse()
+	return p.offset
+}
+
+// Err returns the syntax error that stopped parsing, if any
+func (p *StreamJsonParser) Err() error {
+	p.parse()
+	return p.err
+}
+
+// advance parses input from the offset, stopping at the end of the root value, at a syntax
+// error, or where a token is cut by the end of the input
+func (p *StreamJsonParser) advance(input []byte) {
+	for !p.completed && p.err == nil {
+		if p.str != nil {
+			if !p.scanString(input) {
+				return
+			}
+			continue
+		}
+
+		p.skipWhitespace(input)
+		if p.offset >= len(input) {
+			return
+		}
+		expect := jsonExpectValue
+		var top *jsonFrame
+		if len(p.stack) > 0 {
+			top = p.stack[len(p.stack)-1]
+			expect = top.expect
+		}
+
+		c := input[p.offset]
+		switch expect {
+		case jsonExpectValue:
+			if !p.startValue(input) {
+				return
+			}
+		case jsonExpectValueOrEnd:
+			if c == ']' {
+				p.offset++
+				p.closeFrame()
+			} else if !p.startValue(input) {
+				return
+			}
+		case jsonExpectKeyOrEnd, jsonExpectKey:
+			switch {
+			case c == '}' && expect == jsonExpectKeyOrEnd:
+				p.offset++
+				p.closeFrame()
+			case c == '"':
+				p.offset++
+				p.str = &jsonString{key: true}
+			default:
+				p.err = jsonSyntaxError(input, p.offset, "object key")
+			}
+		case jsonExpectColon:
+			if c != ':' {
+				p.err = jsonSyntaxError(input, p.offset, "':'")
+				return
+			}
+			p.offset++
+			top.expect = jsonExpectValue
+		case jsonExpectSeparator:
+			switch {
+			case c == ',':
+				p.offset++
+				top.expect = jsonExpectValue
+				if top.object != nil {
+					top.expect = jsonExpectKey
+				}
+			case c == '}' && top.object != nil, c == ']' && top.object == nil:
+				p.offset++
+				p.closeFrame()
+			case top.object != nil:
+				p.err = jsonSyntaxError(input, p.offset, "',' or '}'")
+			default:
+				p.err = jsonSyntaxError(input, p.offset, "',' or ']'")
+			}
+		}
+	}
+}
+
+func (p *StreamJsonParser) skipWhitespace(input []byte) {
+	for p.offset < len(input) {
+		switch input[p.offset] {
+		case ' ', '\t', '\n', '\r':
+			p.offset++
+		default:
+			return
+		}
+	}
+}
+
+func jsonSyntaxError(input []byte, offset int, expected string) error {
+	return fmt.Errorf("invalid JSON at offset %d: expected %s, found '%c'", offset, expected, input[offset])
+}
+
+// startValue starts the value at the offset. It returns false if parsing must stop,
+// on a syntax error or when a number or literal may be cut by the end of the input.
+func (p *StreamJsonParser) startValue(input []byte) bool {
+	switch c := input[p.offset]; {
+	case c == '{':
+		p.offset++
+		p.stack = append(p.stack, &jsonFrame{object: map[string]any{}, expect: jsonExpectKeyOrEnd})
+	case c == '[':
+		p.offset++
+		p.stack = append(p.stack, &jsonFrame{array: []any{}, expect: jsonExpectValueOrEnd})
+	case c == '"':
+		p.offset++
+		p.str = &jsonString{}
+	case c == 't':
+		return p.scanLiteral(input, "true", true)
+	case c == 'f':
+		return p.scanLiteral(input, "false", false)
+	case c == 'n':
+		return p.scanLiteral(input, "null", nil)
+	case c == '-' || (c >= '0' && c <= '9'):
+		end := scanNumber(input, p.offset)
+		if end >= len(input) {
+			// More digits may follow
+			return false
+		}
+		value, err := strconv.ParseFloat(string(input[p.offset:end]), 64)
+		if err != nil {
+			p.err = jsonSyntaxError(input, p.offset, "number")
+			return false
+		}
+		p.offset = end
+		p.setValue(value)
+	default:
+		p.err = jsonSyntaxError(input, p.offset, "value")
+		return false
+	}
+	return true
+}
+
+// setValue stores a completed value in the open container, or as the root
+func (p *StreamJsonParser) setValue(value any) {
+	if len(p.stack) == 0 {
+		p.root = value
+		p.completed = true
+		return
+	}
+	top := p.stack[len(p.stack)-1]
+	if top.object != nil {
+		top.object[top.key] = value
+	} else {
+		top.array = append(top.array, value)
+	}
+	top.expect = jsonExpectSeparator
+}
+
+// closeFrame completes the open container
+func (p *StreamJsonParser) closeFrame() {
+	top := p.stack[len(p.stack)-1]
+	p.stack = p.stack[:len(p.stack)-1]
+	p.setValue(top.value())
+}
+
+// scanLiteral parses true, false or null, returning false if it is cut or invalid
+func (p *StreamJsonParser) scanLiteral(input []byte, literal string, value any) bool {
+	rest := input[p.offset:]
+	if len(rest) < len(literal) {
+		if !strings.HasPrefix(literal, string(rest)) {
+			p.err = jsonSyntaxError(input, p.offset, literal)
+		}
+		return false
+	}
+	if string(rest[:len(literal)]) != literal {
+		p.err = jsonSyntaxError(input, p.offset, literal)
+		return false
+	}
+	p.offset += len(literal)
+	p.setValue(value)
+	return true
+}
+
+// scanString decodes the open string from the offset, returning true once it is closed.
+// An escape sequence cut by the end of the input is left for the next parse.
+func (p *StreamJsonParser) scanString(input []byte) bool {
+	str := p.str
+	for p.offset < len(input) {
+		// Copy the run of plain characters at once
+		start := p.offset
+		for p.offset < len(input) && input[p.offset] != '"' && input[p.offset] != '\\' {
+			p.offset++
+		}
+		str.builder.Write(input[start:p.offset])
+		if p.offset >= len(input) {
+			return false
+		}
+
+		if input[p.offset] == '"' {
+			p.offset++
+			p.str = nil
+			if str.key {
+				top := p.stack[len(p.stack)-1]
+				top.key = str.builder.String()
+				top.expect = jsonExpectColon
+			} else {
+				p.setValue(str.builder.String())
+			}
+			return true
+		}
+
+		if p.offset+1 >= len(input) {
+			return false
+		}
+		switch e := input[p.offset+1]; e {
+		case '"', '\\', '/':
+			str.builder.WriteByte(e)
+		case 'b':
+			str.builder.WriteByte('\b')
+		case 'f':
+			str.builder.WriteByte('\f')
+		case 'n':
+			str.builder.WriteByte('\n')
+		case 'r':
+			str.builder.WriteByte('\r')
+		case 't':
+			str.builder.WriteByte('\t')
+		case 'u':
+			r, size, ok := p.unicodeEscape(input)
+			if !ok {
+				return false
+			}
+			str.builder.WriteRune(r)
+			p.offset += size
+			continue
+		default:
+			p.offset++
+			p.err = jsonSyntaxError(input, p.offset, "escape character")
+			return false
+		}
+		p.offset += 2
+	}
+	return false
+}
+
+// unicodeEscape decodes the \uXXXX escape at the offset, combining surrogate pairs, and returns
+// the rune with the length of the escape. It returns false if the escape is cut or invalid.
+func (p *StreamJsonParser) unicodeEscape(input []byte) (rune, int, bool) {
+	r, ok := p.hex4(input, p.offset+2)
+	if !ok {
+		return 0, 0, false
+	}
+	if !utf16.IsSurrogate(r) {
+		return r, 6, true
+	}
+	// A high surrogate must be followed by an escaped low surrogate
+	if p.offset+8 > len(input) {
+		return 0, 0, false
+	}
+	if input[p.offset+6] != '\\' || input[p.offset+7] != 'u' {
+		return utf8.RuneError, 6, true
+	}
+	low, ok := p.hex4(input, p.offset+8)
+	if !ok {
+		return 0, 0, false
+	}
+	if combined := utf16.DecodeRune(r, low); combined != utf8.RuneError {
+		return combined, 12, true
+	}
+	return utf8.RuneError, 6, true
+}
+
+// hex4 decodes the four hexadecimal digits at start, returning false if they are cut or invalid
+func (p *StreamJsonParser) hex4(input []byte, start int) (rune, bool) {
+	if start+4 > len(input) {
+		return 0, false
+	}
+	value, err := strconv.ParseUint(string(input[start:start+4]), 16, 32)
+	if err != nil {
+		p.err = jsonSyntaxError(input, start, "hexadecimal escape")
+		return 0, false
+	}
+	return rune(value), true
+}
+
+// scanNumber returns the end of the number starting at start
+func scanNumber(input []byte, start int) int {
+	end := start
+	for end < len(input) {
+		c := input[end]
+		if (c >= '0' && c <= '9') || c == '-' || c == '+' || c == '.' || c == 'e' || c == 'E' {
+			end++
+			continue
+		}
+		break
+	}
+	return end
+}
+
+// snapshot returns a copy of the value parsed so far, exposing partial values according to the mode
+func (p *StreamJsonParser) snapshot(input []byte) any {
+	if p.completed {
+		return p.root
+	}
+
+	value, ok := p.partialValue(input)
+	for i := len(p.stack) - 1; i >= 0; i-- {
+		frame := p.stack[i]
+		if frame.object != nil {
+			object := make(map[string]any, len(frame.object)+1)
+			for key, field := range frame.object {
+				object[key] = field
+			}
+			if ok && frame.expect == jsonExpectValue {
+				object[frame.key] = value
+			}
+			value = object
+		} else {
+			array := make([]any, len(frame.array), len(frame.array)+1)
+			copy(array, frame.array)
+			// Only complete elements are exposed in complete elements mode
+			if ok && frame.expect != jsonExpectSeparator && p.mode == StreamJsonPartialValues {
+				array = append(array, value)
+			}
+			value = array
+		}
+		ok = true
+	}
+	if !ok {
+		return nil
+	}
+	return value
+}
+
+// partialValue returns the value being parsed at the offset, if it can be exposed yet
+func (p *StreamJsonParser) partialValue(input []byte) (any, bool) {
+	if p.str != nil {
+		if p.str.key {
+			return nil, false
+		}
+		return trimPartialRune(p.str.builder.String()), true
+	}
+	if p.err != nil || p.offset >= len(input) {
+		return nil, false
+	}
+	if len(p.stack) > 0 {
+		if expect := p.stack[len(p.stack)-1].expect; expect != jsonExpectValue && expect != jsonExpectValueOrEnd {
+			return nil, false
+		}
+	}
+	// A number is exposed if it is already valid, e.g. "12" of "123"
+	if c := input[p.offset]; c == '-' || (c >= '0' && c <= '9') {
+		value, err := strconv.ParseFloat(string(input[p.offset:scanNumber(input, p.offset)]), 64)
+		return value, err == nil
+	}
+	return nil, false
+}
+
+// trimPartialRune drops an incomplete UTF-8 sequence cut by the end of the input
+func trimPartialRune(value string) string {
+	for i := len(value) - 1; i >= 0 && i >= len(value)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(value[i]) {
+			if !utf8.FullRuneInString(value[i:]) {
+				return value[:i]
+			}
+			break
+		}
+	}
+	return value
+}
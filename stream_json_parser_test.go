@@ -0,0 +1,213 @@
+package agent
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// benchmarkToolCallInput returns the JSON input of a write_file call with about 5 KB of content
+func benchmarkToolCallInput() string {
+	input, _ := json.Marshal(map[string]any{
+		"path":    "notes.md",
+		"content": strings.Repeat("The quick brown fox jumps over the lazy dog.\n", 120),
+	})
+	return string(input)
+}
+
+func TestStreamJsonParserTruncated(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      StreamJsonParserMode
+		input     string
+		want      any
+		completed bool
+		wantErr   bool
+	}{
+		{name: "empty", input: "", want: nil},
+		{name: "whitespace", input: " \n", want: nil},
+		{name: "array open", input: "[", want: []any{}},
+		{name: "empty array", input: "[]", want: []any{}, completed: true},
+		{name: "array partial number", input: "[1, 2", want: []any{1.0, 2.0}},
+		{name: "array partial number complete elements", mode: StreamJsonCompleteElements, input: "[1, 2", want: []any{1.0}},
+		{name: "array after comma", input: `[1,`, want: []any{1.0}},
+		{name: "array partial string", input: `["ab", "c`, want: []any{"ab", "c"}},
+		{name: "array partial string complete elements", mode: StreamJsonCompleteElements, input: `["ab", "c`, want: []any{"ab"}},
+		{name: "array partial literal", input: `[true, fa`, want: []any{true}},
+		{name: "array complete", input: `[1, "a", null, false]`, want: []any{1.0, "a", nil, false}, completed: true},
+		{name: "nested arrays open", input: "[[", want: []any{[]any{}}},
+		{name: "nested arrays open complete elements", mode: StreamJsonCompleteElements, input: "[[", want: []any{}},
+		{name: "nested arrays partial", input: "[[1, 2], [3", want: []any{[]any{1.0, 2.0}, []any{3.0}}},
+		{name: "nested arrays partial complete elements", mode: StreamJsonCompleteElements, input: "[[1, 2], [3", want: []any{[]any{1.0, 2.0}}},
+		{name: "nested arrays deep", input: "[[[1]], [[2", want: []any{[]any{[]any{1.0}}, []any{[]any{2.0}}}},
+		{name: "nested arrays complete", input: "[[1], [], [[2]]]", want: []any{[]any{1.0}, []any{}, []any{[]any{2.0}}}, completed: true},
+		{name: "array of objects partial", input: `[{"a": 1}, {"b": "x`, want: []any{map[string]any{"a": 1.0}, map[string]any{"b": "x"}}},
+		{name: "array of objects partial complete elements", mode: StreamJsonCompleteElements, input: `[{"a": 1}, {"b": "x`, want: []any{map[string]any{"a": 1.0}}},
+		{name: "object with nested array", input: `{"a": [1, [2, 3`, want: map[string]any{"a": []any{1.0, []any{2.0, 3.0}}}},
+		{name: "object with nested array complete elements", mode: StreamJsonCompleteElements, input: `{"a": [1, [2, 3`, want: map[string]any{"a": []any{1.0}}},
+		{name: "object partial key", input: `{"a": 1, "b`, want: map[string]any{"a": 1.0}},
+		{name: "object missing value", input: `{"a":`, want: map[string]any{}},
+		{name: "string partial escape", input: `"ab\`, want: "ab"},
+		{name: "string partial unicode escape", input: `"ab\u00`, want: "ab"},
+		{name: "string unicode escape", input: `"é😀"`, want: "é😀", completed: true},
+		{name: "string partial rune", input: "\"caf\xc3", want: "caf"},
+		{name: "root number partial", input: "12", want: 12.0},
+		{name: "root literal partial", input: "nu", want: nil},
+		{name: "trailing content", input: `{"a": 1} tail`, want: map[string]any{"a": 1.0}, completed: true},
+		{name: "array missing comma", input: "[1 2", want: []any{1.0}, wantErr: true},
+		{name: "nested array missing comma", input: "[[1] [2", want: []any{[]any{1.0}}, wantErr: true},
+		{name: "object missing colon", input: `{"a" 1`, want: map[string]any{}, wantErr: true},
+		{name: "invalid value", input: "[1, x]", want: []any{1.0}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := NewStreamJsonParser(tt.mode)
+			parser.Append(tt.input)
+
+			if got := parser.Value(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Value() = %#v, want %#v", got, tt.want)
+			}
+			if got := parser.IsCompleted(); got != tt.completed {
+				t.Errorf("IsCompleted() = %v, want %v", got, tt.completed)
+			}
+			if err := parser.Err(); (err != nil) != tt.wantErr {
+				t.Errorf("Err() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStreamJsonParserEnd(t *testing.T) {
+	parser := NewStreamJsonParser(StreamJsonCompleteElements)
+	parser.Append(`  [1, [2]] {"next": true}`)
+	if !parser.IsCompleted() {
+		t.Fatal("IsCompleted() = false, want true")
+	}
+	if got, want := parser.End(), len(`  [1, [2]]`); got != want {
+		t.Errorf("End() = %d, want %d", got, want)
+	}
+}
+
+// streamJsonDocuments are fed to parsers in chunks by the incremental tests
+var streamJsonDocuments = []string{
+	`{"name":"write_file","input":{"path":"a.txt","content":"line 1\nline \"2\"\té😀 end"}}`,
+	`[[1, 2.5e3, -3], [], [[true, false, null]], "x", {"a": [{"b": "c"}]}]`,
+	`{"tags": ["a", "b"], "nested": {"deep": {"list": [1, {"k": "v"}, [2, 3]]}}, "n": -0.5}`,
+	"  \"café \\u00e9 \\\\ \\/\"  ",
+	`12345.678`,
+}
+
+func TestStreamJsonParserChunked(t *testing.T) {
+	for _, mode := range []StreamJsonParserMode{StreamJsonPartialValues, StreamJsonCompleteElements} {
+		for _, document := range streamJsonDocuments {
+			for _, size := range []int{1, 2, 3, 7, 64} {
+				incremental := NewStreamJsonParser(mode)
+				for start := 0; start < len(document); start += size {
+					end := min(start+size, len(document))
+					incremental.Append(document[start:end])
+
+					// Parsing the prefix at once must give the same result as parsing it chunk by chunk
+					fresh := NewStreamJsonParser(mode)
+					fresh.Append(document[:end])
+					if got, want := incremental.Value(), fresh.Value(); !reflect.DeepEqual(got, want) {
+						t.Fatalf("mode %d, chunk %d, prefix %q: Value() = %#v, want %#v", mode, size, document[:end], got, want)
+					}
+					if got, want := incremental.IsCompleted(), fresh.IsCompleted(); got != want {
+						t.Fatalf("mode %d, chunk %d, prefix %q: IsCompleted() = %v, want %v", mode, size, document[:end], got, want)
+					}
+				}
+
+				var want any
+				if err := json.Unmarshal([]byte(document), &want); err != nil {
+					t.Fatal(err)
+				}
+				if got := incremental.Value(); !reflect.DeepEqual(got, want) {
+					t.Fatalf("mode %d, chunk %d: Value() = %#v, want %#v", mode, size, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestStreamJsonParserValuesAreSnapshots(t *testing.T) {
+	parser := NewStreamJsonParser(StreamJsonPartialValues)
+	parser.Append(`{"list": [1`)
+	first := parser.Value()
+
+	parser.Append(`, 2], "more": true}`)
+	if want := map[string]any{"list": []any{1.0}}; !reflect.DeepEqual(first, want) {
+		t.Errorf("earlier Value() = %#v changed, want %#v", first, want)
+	}
+	if want := map[string]any{"list": []any{1.0, 2.0}, "more": true}; !reflect.DeepEqual(parser.Value(), want) {
+		t.Errorf("Value() = %#v, want %#v", parser.Value(), want)
+	}
+}
+
+func BenchmarkStreamJsonParser(b *testing.B) {
+	document := `{"name":"write_file","input":` + benchmarkToolCallInput() + `}`
+	b.ReportAllocs()
+	b.SetBytes(int64(len(document)))
+	for i := 0; i < b.N; i++ {
+		parser := NewStreamJsonParser(StreamJsonCompleteElements)
+		for start := 0; start < len(document); start += 16 {
+			parser.Append(document[start:min(start+16, len(document))])
+			parser.Get("input")
+		}
+		if !parser.IsCompleted() {
+			b.Fatal("IsCompleted() = false, want true")
+		}
+	}
+}
+
+func FuzzStreamJsonParserToString(f *testing.F) {
+	f.Add("plain text")
+	f.Add(`quotes " and \ backslashes`)
+	f.Add("html <b>&amp;</b> stays as is")
+	f.Add("control \n\r\t\b\f \x00 \x1f characters")
+	f.Add("unicode é ü 中文 😀    ")
+	f.Add("invalid utf-8 \xff\xfe")
+	f.Add(`escaped é \/ sequences`)
+
+	f.Fuzz(func(t *testing.T, text string) {
+		document, err := json.Marshal(map[string]any{
+			"text": text,
+			"list": []any{text, 1.5, map[string]any{"nested": text}},
+		})
+		if err != nil {
+			t.Skip()
+		}
+
+		for _, mode := range []StreamJsonParserMode{StreamJsonPartialValues, StreamJsonCompleteElements} {
+			parser := NewStreamJsonParser(mode)
+			for i := range document {
+				parser.Append(string(document[i : i+1]))
+
+				// The serialized partial value must parse back to the same value
+				serialized, err := parser.ToString()
+				if err != nil {
+					t.Fatalf("ToString() error = %v", err)
+				}
+				if serialized == "" {
+					continue
+				}
+				var parsed any
+				if err := json.Unmarshal([]byte(serialized), &parsed); err != nil {
+					t.Fatalf("prefix %q: ToString() = %q is not valid JSON: %v", document[:i+1], serialized, err)
+				}
+				if value := parser.Value(); !reflect.DeepEqual(parsed, value) {
+					t.Fatalf("prefix %q: ToString() parsed = %#v, want %#v", document[:i+1], parsed, value)
+				}
+			}
+
+			var want any
+			if err := json.Unmarshal(document, &want); err != nil {
+				t.Fatal(err)
+			}
+			if got := parser.Value(); !reflect.DeepEqual(got, want) {
+				t.Fatalf("Value() = %#v, want %#v", got, want)
+			}
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"strings"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// StructuredOutputSupport is a rule of a structured output capability table
+type StructuredOutputSupport struct {
+	// Provider is the provider name, as returned by llm.ModelProvider.Name
+	Provider string
+
+	// ModelPrefix matches the models whose name starts with it, every model of the provider if empty
+	ModelPrefix string
+
+	// Format is the best response format the models support, empty if they support none
+	Format llm.ResponseFormat
+}
+
+// DefaultStructuredOutputSupport is the structured output support of the llm layer's providers
+// and of the openaicompat provider. The rule with the longest matching prefix applies.
+var DefaultStructuredOutputSupport = []StructuredOutputSupport{
+	{Provider: "openai", ModelPrefix: "gpt-5", Format: llm.ResponseFormatJsonSchema},
+	{Provider: "openai", ModelPrefix: "gpt-4.1", Format: llm.ResponseFormatJsonSchema},
+	{Provider: "openai", ModelPrefix: "gpt-4o", Format: llm.ResponseFormatJsonSchema},
+	{Provider: "openai", ModelPrefix: "gpt-4-turbo", Format: llm.ResponseFormatJson},
+	{Provider: "openai", ModelPrefix: "gpt-3.5-turbo", Format: llm.ResponseFormatJson},
+	{Provider: "openai", ModelPrefix: "o1", Format: llm.ResponseFormatJsonSchema},
+	{Provider: "openai", ModelPrefix: "o1-mini"},
+	{Provider: "openai", ModelPrefix: "o3", Format: llm.ResponseFormatJsonSchema},
+	{Provider: "openai", ModelPrefix: "o4", Format: llm.ResponseFormatJsonSchema},
+	{Provider: "azure_openai", ModelPrefix: "gpt-5", Format: llm.ResponseFormatJsonSchema},
+	{Provider: "azure_openai", ModelPrefix: "gpt-4.1", Format: llm.ResponseFormatJsonSchema},
+	{Provider: "azure_openai", ModelPrefix: "gpt-4o", Format: llm.ResponseFormatJsonSchema},
+	{Provider: "gemini", Format: llm.ResponseFormatJsonSchema},
+	{Provider: "deepseek", Format: llm.ResponseFormatJson},
+	{Provider: "openrouter", ModelPrefix: "openai/", Format: llm.ResponseFormatJsonSchema},
+	{Provider: "openrouter", ModelPrefix: "google/", Format: llm.ResponseFormatJsonSchema},
+	{Provider: "openai-compatible", Format: llm.ResponseFormatJsonSchema},
+}
+
+// NegotiateResponseFormat returns the best response format table gives to the model of provider,
+// empty if the model supports none or no rule matches
+func NegotiateResponseFormat(table []StructuredOutputSupport, provider, model string) llm.ResponseFormat {
+	var best *StructuredOutputSupport
+	for i, rule := range table {
+		if rule.Provider != provider || !strings.HasPrefix(model, rule.ModelPrefix) {
+			continue
+		}
+		if best == nil || len(rule.ModelPrefix) > len(best.ModelPrefix) {
+			best = &table[i]
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	return best.Format
+}
+
+// NewNegotiatedModel creates the model of provider named model with opts, constrained with the best
+// response format of DefaultStructuredOutputSupport, so JSON runners cannot generate malformed tool
+// calls on supporting models. Other models are created unconstrained.
+func NewNegotiatedModel(provider llm.ModelProvider, model string, opts ...llm.CompletionOption) (llm.CompletionModel, error) {
+	format := NegotiateResponseFormat(DefaultStructuredOutputSupport, provider.Name(), model)
+	if format == "" {
+		return provider.NewCompletionModel(model, opts...)
+	}
+	return NewConstrainedModel(provider, model, format, opts...)
+}
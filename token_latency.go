@@ -0,0 +1,60 @@
+package agent
+
+import (
+	"context"
+	"time"
+)
+
+// TokenLatency is the latency of the output streamed by a model call, the numbers interactive
+// frontends feel. Chunks stand for tokens, as providers stream about one token per chunk.
+type TokenLatency struct {
+	// TimeToFirstToken is the time from the request to the first text or reasoning chunk
+	TimeToFirstToken time.Duration `json:"timeToFirstToken"`
+
+	// MeanInterToken and MaxInterToken are the average and longest gaps between chunks
+	MeanInterToken time.Duration `json:"meanInterToken"`
+	MaxInterToken  time.Duration `json:"maxInterToken"`
+
+	// Chunks is the number of text and reasoning chunks streamed
+	Chunks int `json:"chunks"`
+
+	// Duration is the time the whole call took
+	Duration time.Duration `json:"duration"`
+}
+
+// LatencyCallback is implemented by callbacks receiving the token latency of streamed model calls
+type LatencyCallback interface {
+	// ModelLatency is called after AfterModel for every streamed model call
+	ModelLatency(ctx context.Context, provider string, model string, latency *TokenLatency)
+}
+
+// latencyRecorder measures the token latency of a streamed model call
+type latencyRecorder struct {
+	start   time.Time
+	last    time.Time
+	latency TokenLatency
+}
+
+// record records a chunk received at now
+func (l *latencyRecorder) record(now time.Time) {
+	if l.latency.Chunks == 0 {
+		l.latency.TimeToFirstToken = now.Sub(l.start)
+	} else {
+		l.latency.MaxInterToken = max(l.latency.MaxInterToken, now.Sub(l.last))
+	}
+	l.latency.Chunks++
+	l.last = now
+}
+
+// result returns the latency of a call that took duration, nil if nothing was streamed
+func (l *latencyRecorder) result(duration time.Duration) *TokenLatency {
+	if l.latency.Chunks == 0 {
+		return nil
+	}
+	latency := l.latency
+	if latency.Chunks > 1 {
+		latency.MeanInterToken = (l.last.Sub(l.start) - latency.TimeToFirstToken) / time.Duration(latency.Chunks-1)
+	}
+	latency.Duration = duration
+	return &latency
+}
@@ -0,0 +1,39 @@
+package agent
+
+import "unicode/utf8"
+
+// Tokenizer counts the tokens of a text for a model
+type Tokenizer interface {
+	CountTokens(text string) int
+}
+
+// TokenizerFunc is a function implementing Tokenizer
+type TokenizerFunc func(text string) int
+
+var _ Tokenizer = TokenizerFunc(nil)
+
+// CountTokens calls f(text)
+func (f TokenizerFunc) CountTokens(text string) int {
+	return f(text)
+}
+
+// EstimateTokenizer approximates token counts as one token per four characters,
+// which is close for English text and JSON with most BPE tokenizers.
+// It is the default tokenizer of runners.
+type EstimateTokenizer struct{}
+
+var _ Tokenizer = EstimateTokenizer{}
+
+// CountTokens returns the estimated token count of text
+func (EstimateTokenizer) CountTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// PromptStats describes the size of a rendered system prompt
+type PromptStats struct {
+	// Tokens is the token count of the whole system prompt
+	Tokens int `json:"tokens"`
+
+	// ToolsTokens is the token count of the tools section, including input and output schemas
+	ToolsTokens int `json:"toolsTokens"`
+}
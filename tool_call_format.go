@@ -0,0 +1,214 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// toolCallFormat describes how tool calls are encoded in the model output
+type toolCallFormat interface {
+	// defaultSystemPrompt returns the system prompt template used when no custom prompt is set
+	defaultSystemPrompt() string
+
+	// compactSystemPrompt returns the shorter system prompt template of WithCompactPrompt
+	compactSystemPrompt() string
+
+	// parse parses a complete tool call from the model output
+	parse(output string) (*llm.ToolCall, error)
+
+	// newStreamParser returns a parser for streamed model output, to release once the stream ended
+	newStreamParser() toolCallStreamParser
+
+	// invalidOutputHint is appended to the feedback when the output cannot be parsed
+	invalidOutputHint() string
+
+	// formatToolOutput serializes a tool result for the message history
+	formatToolOutput(output any) (string, error)
+
+	// plainTextPrefix returns the length of the prefix of a partial output that
+	// cannot be part of a tool call, so it can be streamed as a plain text answer
+	plainTextPrefix(output string) int
+
+	// splitReasoning splits the reasoning written before the tool call from the rest of the output
+	splitReasoning(output string) (reasoning string, rest string)
+
+	// toolCallSchema returns the JSON schema of a call to the tools of inputSchemas, keyed by name,
+	// for constrained decoding, nil if the format cannot be described by a JSON schema
+	toolCallSchema(inputSchemas map[string]any) map[string]any
+}
+
+// toolCallStreamParser incrementally parses tool calls from streamed model output
+type toolCallStreamParser interface {
+	// Append adds new content to the parser
+	Append(content string)
+
+	// Parse returns the current tool call, whether it is complete, and any reasoning text
+	Parse() (*llm.ToolCall, bool, *string, error)
+
+	// release returns the parser to its pool, it must not be used afterwards.
+	// The tool calls it returned stay valid, the reasoning pointers do not.
+	release()
+}
+
+// toolCallJsonParsers and toolCallXMLParsers pool the parsers of model outputs, so runs do not
+// allocate new parsers on every iteration
+var (
+	toolCallJsonParsers = sync.Pool{New: func() any { return NewToolCallJsonParser() }}
+	toolCallXMLParsers  = sync.Pool{New: func() any { return NewToolCallXMLParser() }}
+)
+
+// jsonToolCallFormat encodes tool calls as a bare JSON object
+type jsonToolCallFormat struct{}
+
+func (jsonToolCallFormat) defaultSystemPrompt() string {
+	return jsonSystemPrompt
+}
+
+func (jsonToolCallFormat) compactSystemPrompt() string {
+	return jsonCompactSystemPrompt
+}
+
+func (jsonToolCallFormat) parse(output string) (*llm.ToolCall, error) {
+	toolCall := &llm.ToolCall{}
+	if err := json.Unmarshal([]byte(output), toolCall); err != nil {
+		return nil, err
+	}
+	return toolCall, nil
+}
+
+func (jsonToolCallFormat) newStreamParser() toolCallStreamParser {
+	return jsonStreamParser{toolCallJsonParsers.Get().(*ToolCallJsonParser)}
+}
+
+func (jsonToolCallFormat) invalidOutputHint() string {
+	return "Invalid JSON: %s\n\nError: %s\n\nPlease ensure your response is valid JSON matching the tool call schema."
+}
+
+func (jsonToolCallFormat) formatToolOutput(output any) (string, error) {
+	content, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal tool call output: %w", err)
+	}
+	return string(content), nil
+}
+
+func (jsonToolCallFormat) plainTextPrefix(output string) int {
+	trimmed := strings.TrimLeft(output, " \t\r\n")
+	if trimmed == "" || strings.HasPrefix(trimmed, "{") {
+		return 0
+	}
+	return len(output)
+}
+
+func (jsonToolCallFormat) splitReasoning(output string) (string, string) {
+	return "", output
+}
+
+func (jsonToolCallFormat) toolCallSchema(inputSchemas map[string]any) map[string]any {
+	names := make([]string, 0, len(inputSchemas))
+	for name := range inputSchemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var input any
+	if len(names) == 1 {
+		input = inputSchemas[names[0]]
+	} else {
+		anyOf := make([]any, len(names))
+		for i, name := range names {
+			anyOf[i] = inputSchemas[name]
+		}
+		input = map[string]any{"anyOf": anyOf}
+	}
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name":  map[string]any{"type": "string", "enum": names},
+			"input": input,
+		},
+		"required":             []string{"name", "input"},
+		"additionalProperties": false,
+	}
+}
+
+// jsonStreamParser adapts ToolCallJsonParser to toolCallStreamParser
+type jsonStreamParser struct {
+	*ToolCallJsonParser
+}
+
+func (p jsonStreamParser) Parse() (*llm.ToolCall, bool, *string, error) {
+	toolCall, completed, err := p.ToolCallJsonParser.Parse()
+	return toolCall, completed, nil, err
+}
+
+func (p jsonStreamParser) release() {
+	p.Reset()
+	toolCallJsonParsers.Put(p.ToolCallJsonParser)
+}
+
+// xmlToolCallFormat encodes tool calls as a <use-tool> tag with a JSON body,
+// optionally preceded by free-form reasoning text
+type xmlToolCallFormat struct{}
+
+func (xmlToolCallFormat) defaultSystemPrompt() string {
+	return xmlSystemPrompt
+}
+
+func (xmlToolCallFormat) compactSystemPrompt() string {
+	return xmlCompactSystemPrompt
+}
+
+func (xmlToolCallFormat) parse(output string) (*llm.ToolCall, error) {
+	return parseXMLToolCall(output)
+}
+
+func (xmlToolCallFormat) newStreamParser() toolCallStreamParser {
+	return toolCallXMLParsers.Get().(*ToolCallXMLParser)
+}
+
+func (xmlToolCallFormat) invalidOutputHint() string {
+	return "Invalid XML: %s\n\nError: %s\n\nPlease ensure your response contains a valid <use-tool> tag with proper JSON input."
+}
+
+func (xmlToolCallFormat) formatToolOutput(output any) (string, error) {
+	// Text is passed as is, anything else is serialized as JSON like the tool input
+	if text, ok := output.(string); ok {
+		return text, nil
+	}
+	content, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal tool call output: %w", err)
+	}
+	return string(content), nil
+}
+
+func (xmlToolCallFormat) plainTextPrefix(output string) int {
+	if i := strings.Index(output, "<use-tool"); i >= 0 {
+		return i
+	}
+	// Hold back a trailing '<' that may start a tool call tag
+	if i := strings.LastIndexByte(output, '<'); i >= 0 && strings.HasPrefix("<use-tool", output[i:]) {
+		return i
+	}
+	return len(output)
+}
+
+func (xmlToolCallFormat) splitReasoning(output string) (string, string) {
+	// Without a tool call, the text is a direct answer rather than reasoning
+	i := strings.Index(output, "<use-tool")
+	if i <= 0 {
+		return "", output
+	}
+	return output[:i], output[i:]
+}
+
+func (xmlToolCallFormat) toolCallSchema(map[string]any) map[string]any {
+	// The <use-tool> tag is not JSON, XML runners rely on the prompt alone
+	return nil
+}
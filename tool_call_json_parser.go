@@ -2,51 +2,77 @@ package agent
 
 import (
 	"encoding/json"
+	"strings"
+
 	"github.com/easyagent-dev/llm"
-	"github.com/easyagent-dev/streamjson"
 )
 
 // ToolCallJsonParser parses streaming JSON for ToolCall
 type ToolCallJsonParser struct {
-	parser *streamjson.StreamJSONParser
-	buffer string
+	parser *StreamJsonParser
+
+	// buffer holds the streamed content, its capacity is kept on Reset
+	buffer  []byte
+	started bool
+	start   int
 }
 
 // NewToolCallJsonParser creates a new JSON parser for ToolCall
 func NewToolCallJsonParser() *ToolCallJsonParser {
 	return &ToolCallJsonParser{
-		parser: streamjson.NewStreamJSONParser(),
+		parser: NewStreamJsonParser(StreamJsonCompleteElements),
 	}
 }
 
+// Reset clears the parser for reuse
+func (p *ToolCallJsonParser) Reset() {
+	p.parser.Reset()
+	p.buffer = p.buffer[:0]
+	p.started = false
+	p.start = 0
+}
+
 // Append adds new content to the buffer
 func (p *ToolCallJsonParser) Append(content string) {
-	p.buffer += content
+	p.buffer = append(p.buffer, content...)
+	if !p.started {
+		// Only feed the stream parser from the opening brace, anything before
+		// it is not a tool call and is reported when the full output is parsed
+		idx := strings.IndexByte(content, '{')
+		if idx < 0 {
+			return
+		}
+		p.started = true
+		p.start = len(p.buffer) - len(content) + idx
+		content = content[idx:]
+	}
 	p.parser.Append(content)
 }
 
 // ParseNext parses the next events from the stream
 func (p *ToolCallJsonParser) Parse() (*llm.ToolCall, bool, error) {
+	if !p.started {
+		return nil, false, nil
+	}
+
 	// Check if parsing is completed
 	completed := p.parser.IsCompleted()
 
 	if completed {
 		var currentToolCall llm.ToolCall
-		err := json.Unmarshal([]byte(p.buffer), &currentToolCall)
+		err := json.Unmarshal(p.buffer[p.start:p.start+p.parser.End()], &currentToolCall)
 		if err != nil {
 			return nil, false, err
 		}
 		return &currentToolCall, true, nil
 	} else {
-		toolName := p.parser.Get("name")
-		if toolName != nil {
-			input := p.parser.Get("input")
-			if input != nil {
-				return &llm.ToolCall{
-					Name:  toolName.(string),
-					Input: input.(map[string]any),
-				}, false, nil
-			}
+		toolName, _ := p.parser.Get("name").(string)
+		input, _ := p.parser.Get("input").(map[string]any)
+		if toolName != "" && input != nil {
+			return &llm.ToolCall{
+				Name:  toolName,
+				Input: input,
+			}, false, nil
 		}
 	}
 
@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// streamToolCall feeds output to parser in chunks of size bytes, parsing after every chunk
+// like a streaming run, and returns the completed tool call
+func streamToolCall(tb testing.TB, parser toolCallStreamParser, output string, size int) *llm.ToolCall {
+	tb.Helper()
+	for start := 0; start < len(output); start += size {
+		end := min(start+size, len(output))
+		parser.Append(output[start:end])
+		toolCall, completed, _, err := parser.Parse()
+		if err != nil {
+			tb.Fatalf("Parse() error = %v", err)
+		}
+		if completed {
+			return toolCall
+		}
+	}
+	tb.Fatal("Parse() did not complete the tool call")
+	return nil
+}
+
+func TestToolCallJsonParserReuse(t *testing.T) {
+	output := `{"name":"write_file","input":` + benchmarkToolCallInput() + `}`
+	format := jsonToolCallFormat{}
+
+	// A released parser must parse the next output as a new one
+	for i := 0; i < 3; i++ {
+		parser := format.newStreamParser()
+		toolCall := streamToolCall(t, parser, output, 16)
+		parser.release()
+
+		if toolCall.Name != "write_file" {
+			t.Fatalf("iteration %d: Name = %q, want write_file", i, toolCall.Name)
+		}
+		if path, _ := toolCall.Input["path"].(string); path != "notes.md" {
+			t.Fatalf("iteration %d: path = %q, want notes.md", i, path)
+		}
+	}
+}
+
+func BenchmarkToolCallJsonParser(b *testing.B) {
+	output := `{"name":"write_file","input":` + benchmarkToolCallInput() + `}`
+
+	b.Run("new", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(output)))
+		for i := 0; i < b.N; i++ {
+			streamToolCall(b, jsonStreamParser{NewToolCallJsonParser()}, output, 16)
+		}
+	})
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(output)))
+		for i := 0; i < b.N; i++ {
+			parser := jsonToolCallFormat{}.newStreamParser()
+			streamToolCall(b, parser, output, 16)
+			parser.release()
+		}
+	})
+}
@@ -5,72 +5,117 @@ import (
 	"strings"
 
 	"github.com/easyagent-dev/llm"
-	"github.com/easyagent-dev/streamjson"
 	"github.com/easyagent-dev/streamxml"
 )
 
+// jsonTailLength is the length of the end of the tool input compared between chunks
+const jsonTailLength = 64
+
 // ToolCallXMLParser parses streaming XML for ToolCall
 type ToolCallXMLParser struct {
 	xmlParser  *streamxml.StreamXmlParser
-	jsonParser *streamjson.StreamJSONParser
-	buffer     string
+	jsonParser *StreamJsonParser
+	buffer     strings.Builder
 	reasoning  string
 	toolName   string
-	jsonBuffer string
 	foundTag   bool
+
+	// textEnd is the length of the text before the tag found so far, where the tag search resumes
+	textEnd int
+
+	// jsonLen is the length of the tool input given to the JSON parser, and jsonTail its end
+	jsonLen  int
+	jsonTail string
 }
 
 // NewToolCallXMLParser creates a new XML parser for ToolCall
 func NewToolCallXMLParser() *ToolCallXMLParser {
-	parser := streamxml.NewStreamXmlParser()
-	parser.SetAllowedElements([]string{"use-tool"})
 	return &ToolCallXMLParser{
-		xmlParser:  parser,
-		jsonParser: streamjson.NewStreamJSONParser(),
-		foundTag:   false,
+		xmlParser:  newUseToolParser(),
+		jsonParser: NewStreamJsonParser(StreamJsonCompleteElements),
 	}
 }
 
+// newUseToolParser creates an XML parser of <use-tool> tags
+func newUseToolParser() *streamxml.StreamXmlParser {
+	parser := streamxml.NewStreamXmlParser()
+	parser.SetAllowedElements([]string{"use-tool"})
+	return parser
+}
+
+// Reset clears the parser for reuse
+func (p *ToolCallXMLParser) Reset() {
+	// The XML parser cannot be reset, it is replaced
+	p.xmlParser = newUseToolParser()
+	p.jsonParser.Reset()
+	p.buffer.Reset()
+	p.reasoning = ""
+	p.toolName = ""
+	p.foundTag = false
+	p.textEnd = 0
+	p.jsonLen = 0
+	p.jsonTail = ""
+}
+
 // Append adds new content to the buffer
 func (p *ToolCallXMLParser) Append(content string) {
-	p.buffer += content
+	p.buffer.WriteString(content)
 	_ = p.xmlParser.Append(content)
 }
 
 // Parse parses the next events from the stream
 // Returns (toolCall, completed, reasoning, error)
+// Reasoning is the text before the tool call seen so far. It grows as content is appended,
+// each value extending the previous one, until the tag starts.
 func (p *ToolCallXMLParser) Parse() (*llm.ToolCall, bool, *string, error) {
+	if !p.foundTag {
+		// The text before the tag only grows, so the tag is searched from the previous end of the text
+		buffer := p.buffer.String()
+		p.textEnd += xmlToolCallFormat{}.plainTextPrefix(buffer[p.textEnd:])
+		p.reasoning = strings.TrimSpace(buffer[:p.textEnd])
+	}
+	var reasoningPtr *string
+	if p.reasoning != "" {
+		reasoningPtr = &p.reasoning
+	}
+
 	// Get XML node
 	node, err := p.xmlParser.GetXmlNode()
-	if err != nil || node == nil {
+	if err != nil {
 		return nil, false, nil, err
 	}
+	if node == nil {
+		return nil, false, reasoningPtr, nil
+	}
 
 	// Check if this is the use-tool tag
 	if node.Name == "use-tool" {
-		if !p.foundTag {
-			p.foundTag = true
-
-			// Extract tool name from attribute
-			if name, ok := node.Attributes["name"]; ok {
-				p.toolName = name
-			}
-
-			// Extract reasoning from text before the tag
-			text, _ := p.xmlParser.GetText()
-			if text != "" {
-				p.reasoning = strings.TrimSpace(text)
-			}
+		// Extract tool name from attribute, which may still be growing
+		// while the opening tag is split across chunks
+		if name, ok := node.Attributes["name"]; ok && name != "" {
+			p.toolName = name
 		}
 
+		// The reasoning is complete once the tag starts
+		p.foundTag = true
+
 		// Get the JSON content
 		jsonContent := strings.TrimSpace(node.Content)
 
-		// If content changed, append to JSON parser
-		if jsonContent != p.jsonBuffer {
-			newContent := jsonContent[len(p.jsonBuffer):]
-			p.jsonBuffer = jsonContent
-			p.jsonParser.Append(newContent)
+		// Content may shrink, e.g. once a partial closing tag is recognized, the JSON parser then
+		// restarts from the current content. Closing tags are only recognized at the end of the
+		// content, so the end of the previous content is compared rather than all of it.
+		if len(jsonContent) < p.jsonLen || !strings.HasSuffix(jsonContent[:p.jsonLen], p.jsonTail) {
+			p.jsonParser.Reset()
+			p.jsonLen = 0
+			p.jsonTail = ""
+		}
+
+		// Append the new content to the JSON parser
+		if len(jsonContent) > p.jsonLen {
+			p.jsonParser.Append(jsonContent[p.jsonLen:])
+			p.jsonLen = len(jsonContent)
+			p.jsonTail = jsonContent[max(0, p.jsonLen-jsonTailLength):]
 		}
 
 		// Check if the tag is complete (not partial)
@@ -86,33 +131,26 @@ func (p *ToolCallXMLParser) Parse() (*llm.ToolCall, bool, *string, error) {
 				Name:  p.toolName,
 				Input: input,
 			}
-
-			var reasoningPtr *string
-			if p.reasoning != "" {
-				reasoningPtr = &p.reasoning
-			}
-
 			return toolCall, true, reasoningPtr, nil
 		}
 
 		// Return partial tool call if we have enough data
-		if p.toolName != "" && p.jsonParser.Get("") != nil {
-			input := p.jsonParser.Get("")
-			if inputMap, ok := input.(map[string]any); ok {
+		if p.toolName != "" {
+			if inputMap, ok := p.jsonParser.Value().(map[string]any); ok {
 				toolCall := &llm.ToolCall{
 					Name:  p.toolName,
 					Input: inputMap,
 				}
-
-				var reasoningPtr *string
-				if p.reasoning != "" {
-					reasoningPtr = &p.reasoning
-				}
-
 				return toolCall, false, reasoningPtr, nil
 			}
 		}
 	}
 
-	return nil, false, nil, nil
+	return nil, false, reasoningPtr, nil
+}
+
+// release resets the parser and returns it to its pool
+func (p *ToolCallXMLParser) release() {
+	p.Reset()
+	toolCallXMLParsers.Put(p)
 }
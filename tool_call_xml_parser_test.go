@@ -0,0 +1,43 @@
+package agent
+
+import "testing"
+
+func TestToolCallXMLParserReuse(t *testing.T) {
+	output := "Let me save the notes.\n\n<use-tool name=\"write_file\">\n" + benchmarkToolCallInput() + "\n</use-tool>"
+	format := xmlToolCallFormat{}
+
+	// A released parser must parse the next output as a new one
+	for i := 0; i < 3; i++ {
+		parser := format.newStreamParser()
+		toolCall := streamToolCall(t, parser, output, 16)
+		parser.release()
+
+		if toolCall.Name != "write_file" {
+			t.Fatalf("iteration %d: Name = %q, want write_file", i, toolCall.Name)
+		}
+		if path, _ := toolCall.Input["path"].(string); path != "notes.md" {
+			t.Fatalf("iteration %d: path = %q, want notes.md", i, path)
+		}
+	}
+}
+
+func BenchmarkToolCallXMLParser(b *testing.B) {
+	output := "Let me save the notes.\n\n<use-tool name=\"write_file\">\n" + benchmarkToolCallInput() + "\n</use-tool>"
+
+	b.Run("new", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(output)))
+		for i := 0; i < b.N; i++ {
+			streamToolCall(b, NewToolCallXMLParser(), output, 16)
+		}
+	})
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(output)))
+		for i := 0; i < b.N; i++ {
+			parser := xmlToolCallFormat{}.newStreamParser()
+			streamToolCall(b, parser, output, 16)
+			parser.release()
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"fmt"
+)
+
+// ToolChoice controls which tool the model must call in an iteration.
+// It is ToolChoiceAuto, ToolChoiceRequired or the name of a specific tool.
+type ToolChoice string
+
+const (
+	// ToolChoiceAuto lets the model choose between calling a tool and completing the task
+	ToolChoiceAuto ToolChoice = "auto"
+
+	// ToolChoiceRequired makes the model call one of the agent's tools rather than completing the task
+	ToolChoiceRequired ToolChoice = "required"
+)
+
+// ToolChoiceTool makes the model call the named tool
+func ToolChoiceTool(name string) ToolChoice {
+	return ToolChoice(name)
+}
+
+// isAuto reports whether the choice leaves the model free
+func (c ToolChoice) isAuto() bool {
+	return c == "" || c == ToolChoiceAuto
+}
+
+// prompt returns the constraint sent to the model, JSON and XML runners have no native tool_choice
+func (c ToolChoice) prompt(completionTool string) string {
+	switch {
+	case c.isAuto():
+		return ""
+	case c == ToolChoiceRequired:
+		return fmt.Sprintf("You must call one of the available tools other than `%s` in this response.", completionTool)
+	default:
+		return fmt.Sprintf("You must call the `%s` tool in this response.", string(c))
+	}
+}
+
+// validate checks the choice against the tools available to the run
+func (c ToolChoice) validate(toolRegistry *ToolRegistry) error {
+	if c.isAuto() || c == ToolChoiceRequired {
+		return nil
+	}
+	if _, err := toolRegistry.GetTool(string(c)); err != nil {
+		return fmt.Errorf("invalid tool choice: %w", err)
+	}
+	return nil
+}
+
+// check returns a feedback message if the called tool does not satisfy the choice
+func (c ToolChoice) check(toolName string, completionTool string) string {
+	switch {
+	case c.isAuto():
+		return ""
+	case c == ToolChoiceRequired:
+		if toolName == completionTool {
+			return fmt.Sprintf("You must call one of the available tools other than `%s`.", completionTool)
+		}
+	case toolName != string(c):
+		return fmt.Sprintf("You called `%s`, but you must call the `%s` tool.", toolName, string(c))
+	}
+	return ""
+}
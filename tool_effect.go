@@ -0,0 +1,154 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// ToolEffect classifies the side effects of a tool, deciding whether its calls need approval
+type ToolEffect int
+
+const (
+	// ToolEffectUnspecified is the effect of tools that do not declare one, handled as ToolEffectMutating
+	ToolEffectUnspecified ToolEffect = iota
+
+	// ToolEffectReadOnly tools only read data, their calls are always approved
+	ToolEffectReadOnly
+
+	// ToolEffectMutating tools change external state, their calls need the approval of the runner's approver
+	ToolEffectMutating
+
+	// ToolEffectDestructive tools make changes that cannot be undone, e.g. deleting data.
+	// Their calls are blocked unless the request sets AllowDestructive.
+	ToolEffectDestructive
+)
+
+// String returns the name of the effect
+func (e ToolEffect) String() string {
+	switch e {
+	case ToolEffectReadOnly:
+		return "read_only"
+	case ToolEffectMutating:
+		return "mutating"
+	case ToolEffectDestructive:
+		return "destructive"
+	default:
+		return "unspecified"
+	}
+}
+
+// ParseToolEffect parses the name of an effect as returned by String.
+// An empty name is ToolEffectUnspecified.
+func ParseToolEffect(name string) (ToolEffect, error) {
+	switch name {
+	case "", "unspecified":
+		return ToolEffectUnspecified, nil
+	case "read_only":
+		return ToolEffectReadOnly, nil
+	case "mutating":
+		return ToolEffectMutating, nil
+	case "destructive":
+		return ToolEffectDestructive, nil
+	default:
+		return ToolEffectUnspecified, fmt.Errorf("invalid tool effect '%s', expected read_only, mutating or destructive", name)
+	}
+}
+
+// EffectTool is implemented by tools declaring their effect
+type EffectTool interface {
+	ModelTool
+
+	// Effect returns the side effects of the tool's calls
+	Effect() ToolEffect
+}
+
+// ToolEffectOf returns the effect of tool, overridden by options.Effect when set.
+// Tools without a declared effect are mutating.
+func ToolEffectOf(tool ModelTool, options ToolOptions) ToolEffect {
+	effect := options.Effect
+	if effect == ToolEffectUnspecified {
+		if t, ok := tool.(EffectTool); ok {
+			effect = t.Effect()
+		}
+	}
+	if effect == ToolEffectUnspecified {
+		return ToolEffectMutating
+	}
+	return effect
+}
+
+// ToolApprovalRequest describes a tool call awaiting approval
+type ToolApprovalRequest struct {
+	// Agent is the name of the agent making the call
+	Agent string
+
+	// ToolCall is the call to approve
+	ToolCall *llm.ToolCall
+
+	// Effect is the effect of the called tool
+	Effect ToolEffect
+}
+
+// ToolApproval is the decision of a ToolApprover
+type ToolApproval struct {
+	// Approved allows the call to run
+	Approved bool
+
+	// Reason tells the model why the call was denied
+	Reason string
+}
+
+// ToolApprover decides whether mutating and destructive tool calls may run,
+// e.g. by asking a human operator. Returning an error fails the run.
+type ToolApprover interface {
+	ApproveToolCall(ctx context.Context, req *ToolApprovalRequest) (ToolApproval, error)
+}
+
+// ToolApproverFunc adapts a function to the ToolApprover interface
+type ToolApproverFunc func(ctx context.Context, req *ToolApprovalRequest) (ToolApproval, error)
+
+// ApproveToolCall calls f
+func (f ToolApproverFunc) ApproveToolCall(ctx context.Context, req *ToolApprovalRequest) (ToolApproval, error) {
+	return f(ctx, req)
+}
+
+// approveToolCall applies the confirmation policy to a tool call: calls denied by the tool policy
+// are denied, read-only calls are approved, destructive calls are denied unless the request allows them, and the remaining calls are
+// submitted to the runner's approver, if any, unless the request is a dry run
+func (r *BaseRunner) approveToolCall(ctx context.Context, run *agentRun, tool ModelTool, toolCall *llm.ToolCall) (ToolApproval, error) {
+	if tool.Name() == r.completionTool.Name || tool.Name() == HandoffToolName {
+		return ToolApproval{Approved: true}, nil
+	}
+	effect := ToolEffectOf(tool, run.toolRegistry.GetToolOptions(tool.Name()))
+	decision, err := r.checkToolPolicy(ctx, run, toolCall, effect)
+	if err != nil {
+		return ToolApproval{}, err
+	}
+	if !decision.Allowed {
+		reason := decision.Reason
+		if reason == "" {
+			reason = "the call is not allowed by policy"
+		}
+		return ToolApproval{Reason: reason}, nil
+	}
+	switch {
+	case effect == ToolEffectReadOnly:
+		return ToolApproval{Approved: true}, nil
+	case effect == ToolEffectDestructive && !run.req.AllowDestructive:
+		return ToolApproval{Reason: "destructive tools are disabled for this request"}, nil
+	case r.approver == nil || run.req.DryRun:
+		return ToolApproval{Approved: true}, nil
+	}
+
+	approval, err := r.approver.ApproveToolCall(ctx, &ToolApprovalRequest{
+		Agent:    run.agent.Name,
+		ToolCall: toolCall,
+		Effect:   effect,
+	})
+	if err != nil {
+		return ToolApproval{}, fmt.Errorf("tool approval failed: %w", err)
+	}
+	return approval, nil
+}
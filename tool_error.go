@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ToolError is an error of a tool separating the message shown to the model from the internal
+// error reported to operators. Tools return it to give the model an actionable message without
+// leaking secrets, hostnames or stack traces.
+//
+// The model is told whether it may retry. When the error is not retryable, the runner also refuses
+// to run the same call again and answers it with the same message.
+type ToolError struct {
+	// Message is shown to the model: what went wrong and what to do, without internal details
+	Message string
+
+	// Err is the internal error, logged and reported in events and responses but never shown to the model
+	Err error
+
+	// Retryable tells the model the call may succeed if retried, e.g. after a timeout or with a corrected input
+	Retryable bool
+}
+
+var _ error = (*ToolError)(nil)
+
+// NewToolError returns a non-retryable tool error showing message to the model
+func NewToolError(message string, err error) *ToolError {
+	return &ToolError{Message: message, Err: err}
+}
+
+// NewRetryableToolError returns a retryable tool error showing message to the model
+func NewRetryableToolError(message string, err error) *ToolError {
+	return &ToolError{Message: message, Err: err, Retryable: true}
+}
+
+// Error returns the model message followed by the internal error, for operators
+func (e *ToolError) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Message, e.Err)
+}
+
+// Unwrap returns the internal error
+func (e *ToolError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches ErrToolExecution
+func (e *ToolError) Is(target error) bool {
+	return target == ErrToolExecution
+}
+
+// toolFailure returns the message telling the model a call of tool failed with err, and whether
+// the same call must not run again. Errors other than ToolError are shown as they are.
+func (run *agentRun) toolFailure(tool string, err error) (string, bool) {
+	var toolErr *ToolError
+	if !errors.As(err, &toolErr) {
+		return err.Error(), false
+	}
+	if toolErr.Retryable {
+		return fmt.Sprintf(run.templates.ToolFailed, tool, toolErr.Message), false
+	}
+	return fmt.Sprintf(run.templates.ToolFailedPermanently, tool, toolErr.Message), true
+}
+
+// toolCallKey identifies the calls of tool with the same input
+func toolCallKey(tool string, input map[string]any) string {
+	// Map keys are marshaled in sorted order, so equal inputs give the same key
+	data, err := json.Marshal(input)
+	if err != nil {
+		data = []byte(err.Error())
+	}
+	return tool + "\x00" + string(data)
+}
@@ -0,0 +1,78 @@
+package agent
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ToolInputDelta is text appended to a string argument of a tool call while it is streamed,
+// e.g. a document the agent is writing into a content argument
+type ToolInputDelta struct {
+	// ToolCallID is the ID of the streamed tool call
+	ToolCallID string `json:"toolCallId"`
+
+	// Tool is the name of the called tool
+	Tool string `json:"tool"`
+
+	// Path is the JSON pointer (RFC 6901) of the string in the tool input, e.g. "/content"
+	Path string `json:"path"`
+
+	// Offset is the byte offset of Text in the string. It is 0 for the first delta of a
+	// string, and also when the string changed in a way that is not an append.
+	Offset int `json:"offset"`
+
+	// Text is the text at Offset
+	Text string `json:"text"`
+}
+
+// stringDiffer tracks the string leaves of a partially parsed tool input and reports appended text
+type stringDiffer struct {
+	seen map[string]string
+}
+
+// newStringDiffer creates an empty differ
+func newStringDiffer() *stringDiffer {
+	return &stringDiffer{seen: make(map[string]string)}
+}
+
+// diff returns the text appended to the strings of input since the previous call, in document order
+func (d *stringDiffer) diff(tool string, input map[string]any) []*ToolInputDelta {
+	var deltas []*ToolInputDelta
+	d.walk(tool, "", input, &deltas)
+	return deltas
+}
+
+func (d *stringDiffer) walk(tool string, path string, value any, deltas *[]*ToolInputDelta) {
+	switch v := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			d.walk(tool, path+"/"+escapeJSONPointer(key), v[key], deltas)
+		}
+	case []any:
+		for i, item := range v {
+			d.walk(tool, path+"/"+strconv.Itoa(i), item, deltas)
+		}
+	case string:
+		key := tool + path
+		previous, ok := d.seen[key]
+		if ok && previous == v {
+			return
+		}
+		d.seen[key] = v
+		delta := &ToolInputDelta{Tool: tool, Path: path, Text: v}
+		if ok && strings.HasPrefix(v, previous) {
+			delta.Offset = len(previous)
+			delta.Text = v[len(previous):]
+		}
+		if delta.Text == "" {
+			return
+		}
+		*deltas = append(*deltas, delta)
+	}
+}
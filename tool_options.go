@@ -0,0 +1,98 @@
+package agent
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// ToolOptions are per-agent settings of a tool, applied without changing the tool implementation
+type ToolOptions struct {
+	// Priority orders tools in the prompt, higher first. Tools of equal priority keep registration order.
+	Priority int
+
+	// Category groups related tools in the prompt, e.g. "search" or "files"
+	Category string
+
+	// Effect overrides the effect declared by the tool, e.g. for third-party tools
+	Effect ToolEffect
+
+	// MaxCalls caps the executed calls of the tool per run, unlimited if 0. Further calls are
+	// refused with a message telling the model to continue without the tool.
+	MaxCalls int
+}
+
+// ToolPreference tells the model to prefer a tool over others when several apply
+type ToolPreference struct {
+	// Prefer is the name of the preferred tool
+	Prefer string
+
+	// Over are the names of the tools Prefer should be used instead of
+	Over []string
+
+	// When optionally describes the situation, e.g. "looking up customer data"
+	When string
+}
+
+// validate checks the preference names its tools
+func (p ToolPreference) validate() error {
+	if p.Prefer == "" {
+		return errors.New("preferred tool is required")
+	}
+	if len(p.Over) == 0 {
+		return errors.New("at least one tool to prefer over is required")
+	}
+	return nil
+}
+
+// sortToolsForPrompt orders tools by descending priority, grouping tools of the same
+// priority by category in order of first appearance. The sort is stable, so the prompt
+// stays byte-stable for a given registry.
+func sortToolsForPrompt(tools []ModelTool, options func(name string) ToolOptions) {
+	categoryRank := map[string]int{}
+	for _, tool := range tools {
+		category := options(tool.Name()).Category
+		if _, ok := categoryRank[category]; !ok {
+			categoryRank[category] = len(categoryRank)
+		}
+	}
+	sort.SliceStable(tools, func(i, j int) bool {
+		a, b := options(tools[i].Name()), options(tools[j].Name())
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		return categoryRank[a.Category] < categoryRank[b.Category]
+	})
+}
+
+// writeToolPreferences describes the preferences between available tools.
+// Preferences naming unavailable tools are skipped or narrowed to the available ones.
+func writeToolPreferences(preferences []ToolPreference, available func(name string) bool) string {
+	var builder strings.Builder
+	for _, preference := range preferences {
+		if !available(preference.Prefer) {
+			continue
+		}
+		over := make([]string, 0, len(preference.Over))
+		for _, name := range preference.Over {
+			if available(name) {
+				over = append(over, name)
+			}
+		}
+		if len(over) == 0 {
+			continue
+		}
+		builder.WriteString("\n- Prefer ")
+		builder.WriteString(preference.Prefer)
+		builder.WriteString(" over ")
+		builder.WriteString(strings.Join(over, ", "))
+		if preference.When != "" {
+			builder.WriteString(" when ")
+			builder.WriteString(preference.When)
+		}
+	}
+	if builder.Len() == 0 {
+		return ""
+	}
+	return "\n<tool_preferences>" + builder.String() + "\n</tool_preferences>"
+}
@@ -0,0 +1,81 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// ToolPolicyRequest describes a tool call checked by a ToolPolicy
+type ToolPolicyRequest struct {
+	// Agent is the name of the agent making the call
+	Agent string
+
+	// RunID is the ID of the run
+	RunID string
+
+	// ToolCall is the call to check
+	ToolCall *llm.ToolCall
+
+	// Effect is the effect of the called tool
+	Effect ToolEffect
+
+	// Metadata holds the request-level tags of the run, e.g. its tenant
+	Metadata map[string]string
+}
+
+// ToolPolicyDecision is the decision of a ToolPolicy
+type ToolPolicyDecision struct {
+	// Allowed lets the call proceed to the approver, if any
+	Allowed bool
+
+	// Rule names the rule that decided, for audits
+	Rule string
+
+	// Reason tells the model why the call was denied
+	Reason string
+}
+
+// ToolPolicy constrains the tool calls of runs, e.g. with rules maintained by a security team.
+// Unlike a ToolApprover, it checks every tool call, read-only ones included, before the approver.
+// Denied calls are answered with the reason and the run continues. Returning an error fails the run.
+type ToolPolicy interface {
+	EvaluateToolCall(ctx context.Context, req *ToolPolicyRequest) (ToolPolicyDecision, error)
+}
+
+// ToolPolicyFunc adapts a function to the ToolPolicy interface
+type ToolPolicyFunc func(ctx context.Context, req *ToolPolicyRequest) (ToolPolicyDecision, error)
+
+var _ ToolPolicy = ToolPolicyFunc(nil)
+
+// EvaluateToolCall calls f
+func (f ToolPolicyFunc) EvaluateToolCall(ctx context.Context, req *ToolPolicyRequest) (ToolPolicyDecision, error) {
+	return f(ctx, req)
+}
+
+// WithToolPolicy sets the policy checking every tool call of runs, see the policy package for
+// declarative rules
+func WithToolPolicy(policy ToolPolicy) RunnerOption {
+	return func(c *runnerConfig) {
+		c.toolPolicy = policy
+	}
+}
+
+// checkToolPolicy evaluates toolCall against the runner's policy, if any
+func (r *BaseRunner) checkToolPolicy(ctx context.Context, run *agentRun, toolCall *llm.ToolCall, effect ToolEffect) (ToolPolicyDecision, error) {
+	if r.toolPolicy == nil {
+		return ToolPolicyDecision{Allowed: true}, nil
+	}
+	decision, err := r.toolPolicy.EvaluateToolCall(ctx, &ToolPolicyRequest{
+		Agent:    run.agent.Name,
+		RunID:    run.agentContext.RunID,
+		ToolCall: toolCall,
+		Effect:   effect,
+		Metadata: run.req.Metadata,
+	})
+	if err != nil {
+		return ToolPolicyDecision{}, fmt.Errorf("tool policy failed: %w", err)
+	}
+	return decision, nil
+}
@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"encoding/json"
 	"fmt"
 	"sync"
 )
@@ -10,18 +11,42 @@ import (
 type ToolRegistry struct {
 	mu    sync.RWMutex
 	tools map[string]ModelTool
+
+	// order holds tool names in registration order, so prompts built from the registry are stable
+	order []string
+
+	// version is incremented on every registration change
+	version uint64
+
+	// schemas caches the marshaled input schema of each tool by name
+	schemas map[string][]byte
+
+	// options holds the options of tools registered with RegisterToolWithOptions
+	options map[string]ToolOptions
+
+	// aliases maps legacy tool names to the current ones
+	aliases map[string]string
 }
 
 // NewToolRegistry creates a new tool registry
 func NewToolRegistry() *ToolRegistry {
 	return &ToolRegistry{
-		tools: make(map[string]ModelTool),
+		tools:   make(map[string]ModelTool),
+		schemas: make(map[string][]byte),
+		options: make(map[string]ToolOptions),
+		aliases: make(map[string]string),
 	}
 }
 
 // RegisterTool adds a tool to the registry
 // It returns an error if a tool with the same name already exists
 func (tr *ToolRegistry) RegisterTool(tool ModelTool) error {
+	return tr.RegisterToolWithOptions(tool, ToolOptions{})
+}
+
+// RegisterToolWithOptions adds a tool to the registry with its prompt priority and category
+// It returns an error if a tool with the same name already exists
+func (tr *ToolRegistry) RegisterToolWithOptions(tool ModelTool, options ToolOptions) error {
 	tr.mu.Lock()
 	defer tr.mu.Unlock()
 
@@ -29,8 +54,16 @@ func (tr *ToolRegistry) RegisterTool(tool ModelTool) error {
 	if _, exists := tr.tools[name]; exists {
 		return fmt.Errorf("tool with name '%s' already registered", name)
 	}
+	if _, exists := tr.aliases[name]; exists {
+		return fmt.Errorf("tool name '%s' is already registered as an alias", name)
+	}
 
 	tr.tools[name] = tool
+	tr.order = append(tr.order, name)
+	if options != (ToolOptions{}) {
+		tr.options[name] = options
+	}
+	tr.version++
 	return nil
 }
 
@@ -45,16 +78,54 @@ func (tr *ToolRegistry) UnregisterTool(name string) error {
 	}
 
 	delete(tr.tools, name)
+	delete(tr.schemas, name)
+	delete(tr.options, name)
+	for i, n := range tr.order {
+		if n == name {
+			tr.order = append(tr.order[:i:i], tr.order[i+1:]...)
+			break
+		}
+	}
+	tr.version++
 	return nil
 }
 
-// GetTool retrieves a tool by name
+// RegisterAlias registers a legacy name resolving to the tool named name,
+// so model outputs calling a renamed tool still resolve. The alias is not shown in prompts.
+// It returns an error if alias is already used by a tool or another alias.
+func (tr *ToolRegistry) RegisterAlias(alias string, name string) error {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	if _, exists := tr.tools[alias]; exists {
+		return fmt.Errorf("alias '%s' is already registered as a tool", alias)
+	}
+	if _, exists := tr.aliases[alias]; exists {
+		return fmt.Errorf("alias '%s' already registered", alias)
+	}
+	tr.aliases[alias] = name
+	return nil
+}
+
+// ResolveAlias returns the current name of a tool called by a legacy name
+func (tr *ToolRegistry) ResolveAlias(alias string) (string, bool) {
+	tr.mu.RLock()
+	defer tr.mu.RUnlock()
+
+	name, ok := tr.aliases[alias]
+	return name, ok
+}
+
+// GetTool retrieves a tool by name or alias
 // It returns an error if the tool is not found
 func (tr *ToolRegistry) GetTool(name string) (ModelTool, error) {
 	tr.mu.RLock()
 	defer tr.mu.RUnlock()
 
 	tool, exists := tr.tools[name]
+	if !exists {
+		tool, exists = tr.tools[tr.aliases[name]]
+	}
 	if !exists {
 		return nil, fmt.Errorf("tool with name '%s' not found", name)
 	}
@@ -62,15 +133,102 @@ func (tr *ToolRegistry) GetTool(name string) (ModelTool, error) {
 	return tool, nil
 }
 
-// GetTools returns all registered tools
+// GetTools returns all registered tools in registration order
 // The returned slice is a copy and safe to modify
 func (tr *ToolRegistry) GetTools() []ModelTool {
 	tr.mu.RLock()
 	defer tr.mu.RUnlock()
 
-	tools := make([]ModelTool, 0, len(tr.tools))
-	for _, tool := range tr.tools {
-		tools = append(tools, tool)
+	tools := make([]ModelTool, 0, len(tr.order))
+	for _, name := range tr.order {
+		tools = append(tools, tr.tools[name])
 	}
 	return tools
 }
+
+// GetToolOptions returns the options a tool was registered with
+func (tr *ToolRegistry) GetToolOptions(name string) ToolOptions {
+	tr.mu.RLock()
+	defer tr.mu.RUnlock()
+
+	return tr.options[name]
+}
+
+// HasTool reports whether a tool is registered
+func (tr *ToolRegistry) HasTool(name string) bool {
+	tr.mu.RLock()
+	defer tr.mu.RUnlock()
+
+	_, exists := tr.tools[name]
+	return exists
+}
+
+// GetInputSchemaJSON returns the marshaled input schema of a tool
+// The schema is generated once and cached until the tool is unregistered
+func (tr *ToolRegistry) GetInputSchemaJSON(name string) ([]byte, error) {
+	tr.mu.RLock()
+	schema, cached := tr.schemas[name]
+	tool, exists := tr.tools[name]
+	version := tr.version
+	tr.mu.RUnlock()
+	if cached {
+		return schema, nil
+	}
+	if !exists {
+		return nil, fmt.Errorf("tool with name '%s' not found", name)
+	}
+
+	schema, err := json.Marshal(tool.InputSchema())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal input schema of tool '%s': %w", name, err)
+	}
+
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	// Only cache if the registry did not change meanwhile
+	if tr.version == version {
+		tr.schemas[name] = schema
+	}
+	return schema, nil
+}
+
+// Version returns a counter incremented on every registration change,
+// used to detect when prompts built from the registry are stale
+func (tr *ToolRegistry) Version() uint64 {
+	tr.mu.RLock()
+	defer tr.mu.RUnlock()
+
+	return tr.version
+}
+
+// Clone returns a new registry containing the same tools
+func (tr *ToolRegistry) Clone() *ToolRegistry {
+	tr.mu.RLock()
+	defer tr.mu.RUnlock()
+
+	tools := make(map[string]ModelTool, len(tr.tools)+2)
+	for name, tool := range tr.tools {
+		tools[name] = tool
+	}
+	schemas := make(map[string][]byte, len(tr.schemas)+2)
+	for name, schema := range tr.schemas {
+		schemas[name] = schema
+	}
+	options := make(map[string]ToolOptions, len(tr.options))
+	for name, opts := range tr.options {
+		options[name] = opts
+	}
+	aliases := make(map[string]string, len(tr.aliases))
+	for alias, name := range tr.aliases {
+		aliases[alias] = name
+	}
+	order := make([]string, len(tr.order), len(tr.order)+2)
+	copy(order, tr.order)
+	return &ToolRegistry{
+		tools:   tools,
+		order:   order,
+		schemas: schemas,
+		options: options,
+		aliases: aliases,
+	}
+}
@@ -0,0 +1,127 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// benchmarkToolInput is the input of benchmarkTool, shaped like a typical tool input
+type benchmarkToolInput struct {
+	Query    string   `json:"query" jsonschema:"required,description=Search query"`
+	Limit    int      `json:"limit,omitempty" jsonschema:"description=Maximum number of results,minimum=1,maximum=100"`
+	Tags     []string `json:"tags,omitempty" jsonschema:"description=Tags the results must have"`
+	Language string   `json:"language,omitempty" jsonschema:"description=Language of the results,enum=en,enum=fr,enum=de"`
+}
+
+// benchmarkTool is a tool with a realistic schema and no behavior
+type benchmarkTool struct {
+	name string
+}
+
+var _ ModelTool = (*benchmarkTool)(nil)
+
+func (t *benchmarkTool) Name() string        { return t.name }
+func (t *benchmarkTool) Description() string { return "Searches the knowledge base for " + t.name }
+func (t *benchmarkTool) InputSchema() any    { return llm.GenerateSchema[benchmarkToolInput]() }
+func (t *benchmarkTool) OutputSchema() any   { return nil }
+func (t *benchmarkTool) Usage() string       { return `{"query":"weather in Paris","limit":5}` }
+func (t *benchmarkTool) Run(ctx context.Context, input map[string]any) (any, error) {
+	return nil, nil
+}
+
+// newBenchmarkRegistry returns a registry holding n benchmark tools
+func newBenchmarkRegistry(b *testing.B, n int) *ToolRegistry {
+	b.Helper()
+	registry := NewToolRegistry()
+	for i := 0; i < n; i++ {
+		if err := registry.RegisterTool(&benchmarkTool{name: fmt.Sprintf("search_%d", i)}); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return registry
+}
+
+func BenchmarkToolRegistryInputSchemaJSON(b *testing.B) {
+	for _, n := range []int{50, 200} {
+		registry := newBenchmarkRegistry(b, n)
+		tools := registry.GetTools()
+
+		b.Run(fmt.Sprintf("tools=%d/uncached", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for _, tool := range tools {
+					if _, err := json.Marshal(tool.InputSchema()); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("tools=%d/cached", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for _, tool := range tools {
+					if _, err := registry.GetInputSchemaJSON(tool.Name()); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkToolRegistryToolsPrompt(b *testing.B) {
+	runner := &BaseRunner{}
+	agent := &Agent{Name: "bench"}
+	for _, n := range []int{50, 200} {
+		registry := newBenchmarkRegistry(b, n)
+
+		b.Run(fmt.Sprintf("tools=%d/uncached", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := runner.ToolsPrompts(registry.GetTools()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("tools=%d/cached", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := runner.registryToolsPrompt(agent, registry); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestToolRegistryInputSchemaJSONCache(t *testing.T) {
+	registry := NewToolRegistry()
+	tool := &benchmarkTool{name: "search"}
+	if err := registry.RegisterTool(tool); err != nil {
+		t.Fatal(err)
+	}
+	want, err := json.Marshal(tool.InputSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		got, err := registry.GetInputSchemaJSON(tool.Name())
+		if err != nil {
+			t.Fatalf("GetInputSchemaJSON() error = %v", err)
+		}
+		if string(got) != string(want) {
+			t.Fatalf("GetInputSchemaJSON() = %s, want %s", got, want)
+		}
+	}
+
+	if err := registry.UnregisterTool(tool.Name()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := registry.GetInputSchemaJSON(tool.Name()); err == nil {
+		t.Fatal("GetInputSchemaJSON() of an unregistered tool succeeded")
+	}
+}
@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"time"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// ToolStats summarizes the calls of a tool during a run
+type ToolStats struct {
+	// Calls is the number of executed calls, failed ones included
+	Calls int `json:"calls"`
+
+	// Failures is the number of calls that returned an error or were interrupted
+	Failures int `json:"failures"`
+
+	// Latency is the cumulative duration of the calls
+	Latency time.Duration `json:"latency"`
+}
+
+// MeanLatency returns the mean duration of the calls
+func (s ToolStats) MeanLatency() time.Duration {
+	if s.Calls == 0 {
+		return 0
+	}
+	return s.Latency / time.Duration(s.Calls)
+}
+
+// add counts toolCall
+func (s *ToolStats) add(toolCall *llm.ToolCall) {
+	s.Calls++
+	if toolCall.ErrorMessage != nil {
+		s.Failures++
+	}
+	if !toolCall.StartAt.IsZero() && toolCall.EndAt.After(toolCall.StartAt) {
+		s.Latency += toolCall.EndAt.Sub(toolCall.StartAt)
+	}
+}
+
+// GetToolStats returns the statistics of the executed calls of a tool, zero if it was not called.
+// Tools and loop controllers use them to adapt, e.g. to stop suggesting a tool that keeps failing.
+// This method is safe for concurrent use.
+func (ac *AgentContext) GetToolStats(name string) ToolStats {
+	ac.mu.RLock()
+	defer ac.mu.RUnlock()
+	if stats, ok := ac.toolStats[name]; ok {
+		return *stats
+	}
+	return ToolStats{}
+}
+
+// AllToolStats returns the statistics of every called tool by name.
+// This method is safe for concurrent use.
+func (ac *AgentContext) AllToolStats() map[string]ToolStats {
+	ac.mu.RLock()
+	defer ac.mu.RUnlock()
+	all := make(map[string]ToolStats, len(ac.toolStats))
+	for name, stats := range ac.toolStats {
+		all[name] = *stats
+	}
+	return all
+}
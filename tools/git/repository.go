@@ -0,0 +1,132 @@
+// Package git provides tools letting code-assistant agents work on a configured git repository:
+// clone, status, log, diff, read_file and grep, read-only, and optionally commit and branch, which
+// are mutating tools going through the runner's approval policy. The tools run the git command
+// line, which must be installed.
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultTimeout bounds git commands when the repository sets none
+	DefaultTimeout = time.Minute
+
+	// DefaultMaxOutputBytes bounds the output returned to the model when the repository sets no limit
+	DefaultMaxOutputBytes = 64 * 1024
+)
+
+// Repository is the repository the tools work on
+type Repository struct {
+	// Dir is the local working tree. Required.
+	Dir string
+
+	// URL is the remote cloned by the clone tool into Dir, optional
+	URL string
+
+	// AuthorName and AuthorEmail sign the commits of the commit tool
+	AuthorName  string
+	AuthorEmail string
+
+	// Timeout bounds git commands, DefaultTimeout if 0
+	Timeout time.Duration
+
+	// MaxOutputBytes bounds the output returned to the model, DefaultMaxOutputBytes if 0
+	MaxOutputBytes int
+}
+
+// validRef matches the revisions the tools accept, e.g. main, HEAD~2, v1.2.0 or origin/main@{1}
+var validRef = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._/~^@{}-]*$`)
+
+// checkRef rejects revisions that could be taken for options or are not plain revisions
+func checkRef(ref string) error {
+	if !validRef.MatchString(ref) || strings.Contains(ref, "..") {
+		return fmt.Errorf("invalid revision '%s'", ref)
+	}
+	return nil
+}
+
+// checkPath rejects paths outside of the working tree
+func checkPath(path string) error {
+	if filepath.IsAbs(path) || strings.HasPrefix(path, "-") {
+		return fmt.Errorf("invalid path '%s', use a path relative to the repository root", path)
+	}
+	if cleaned := filepath.ToSlash(filepath.Clean(path)); cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return fmt.Errorf("invalid path '%s', it is outside of the repository", path)
+	}
+	return nil
+}
+
+// run runs git with args in the working tree, returning its stdout cut to MaxOutputBytes.
+// A failing command returns an error with its stderr.
+func (r *Repository) run(ctx context.Context, args ...string) (string, bool, error) {
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", append([]string{"--no-pager", "-c", "color.ui=false"}, args...)...)
+	cmd.Dir = r.Dir
+	// Never wait for credentials or an editor
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_EDITOR=true", "GIT_ASKPASS=")
+	cmd.WaitDelay = time.Second
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", false, fmt.Errorf("git %s timed out after %s", args[0], timeout)
+		}
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return stdout.String(), false, &CommandError{Args: args, ExitCode: cmd.ProcessState.ExitCode(), Message: message}
+	}
+	output, truncated := r.truncate(stdout.String())
+	return output, truncated, nil
+}
+
+// truncate cuts output to MaxOutputBytes
+func (r *Repository) truncate(output string) (string, bool) {
+	limit := r.MaxOutputBytes
+	if limit <= 0 {
+		limit = DefaultMaxOutputBytes
+	}
+	if len(output) <= limit {
+		return output, false
+	}
+	return strings.ToValidUTF8(output[:limit], ""), true
+}
+
+// CommandError is the error of a failed git command
+type CommandError struct {
+	Args     []string
+	ExitCode int
+	Message  string
+}
+
+// Error returns the stderr of the command
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("git %s failed: %s", e.Args[0], e.Message)
+}
+
+// cloned reports whether the working tree is a git repository
+func (r *Repository) cloned() bool {
+	_, err := os.Stat(filepath.Join(r.Dir, ".git"))
+	return err == nil
+}
+
+// errNotCloned is returned by the tools when the working tree does not exist yet
+var errNotCloned = errors.New("the repository is not cloned yet, call git_clone first")
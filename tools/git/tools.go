@@ -0,0 +1,551 @@
+package git
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/easyagent-dev/agent"
+	"github.com/easyagent-dev/llm"
+)
+
+// Tool names
+const (
+	CloneToolName        = "git_clone"
+	StatusToolName       = "git_status"
+	LogToolName          = "git_log"
+	DiffToolName         = "git_diff"
+	ReadFileToolName     = "git_read_file"
+	GrepToolName         = "git_grep"
+	CommitToolName       = "git_commit"
+	CreateBranchToolName = "git_create_branch"
+)
+
+const (
+	// defaultLogEntries and maxLogEntries bound the commits git_log returns
+	defaultLogEntries = 20
+	maxLogEntries     = 200
+
+	// maxGrepMatches bounds the matches git_grep returns
+	maxGrepMatches = 200
+)
+
+// CloneInput is the input of the git_clone tool
+type CloneInput struct {
+	Depth int `json:"depth,omitempty" jsonschema:"description=Number of commits to fetch\\, the whole history if 0"`
+}
+
+// LogInput is the input of the git_log tool
+type LogInput struct {
+	Ref   string `json:"ref,omitempty" jsonschema:"description=Revision to start from\\, HEAD by default"`
+	Path  string `json:"path,omitempty" jsonschema:"description=Only commits changing this path"`
+	Limit int    `json:"limit,omitempty" jsonschema:"description=Number of commits\\, 20 by default"`
+}
+
+// DiffInput is the input of the git_diff tool
+type DiffInput struct {
+	Ref    string `json:"ref,omitempty" jsonschema:"description=Revision to compare the working tree with\\, the index by default"`
+	Staged bool   `json:"staged,omitempty" jsonschema:"description=Show the staged changes instead of the unstaged ones"`
+	Path   string `json:"path,omitempty" jsonschema:"description=Only changes of this path"`
+	Stat   bool   `json:"stat,omitempty" jsonschema:"description=Only list the changed files and line counts"`
+}
+
+// ReadFileInput is the input of the git_read_file tool
+type ReadFileInput struct {
+	Path      string `json:"path" jsonschema:"required,description=Path of the file relative to the repository root"`
+	Ref       string `json:"ref,omitempty" jsonschema:"description=Revision to read the file at\\, the working tree by default"`
+	StartLine int    `json:"start_line,omitempty" jsonschema:"description=First line to return\\, starting at 1"`
+	EndLine   int    `json:"end_line,omitempty" jsonschema:"description=Last line to return"`
+}
+
+// GrepInput is the input of the git_grep tool
+type GrepInput struct {
+	Pattern    string `json:"pattern" jsonschema:"required,description=Extended regular expression to search for"`
+	Path       string `json:"path,omitempty" jsonschema:"description=Only search this file or directory"`
+	Ref        string `json:"ref,omitempty" jsonschema:"description=Revision to search\\, the working tree by default"`
+	IgnoreCase bool   `json:"ignore_case,omitempty"`
+}
+
+// CommitInput is the input of the git_commit tool
+type CommitInput struct {
+	Message string   `json:"message" jsonschema:"required,description=Commit message"`
+	Paths   []string `json:"paths,omitempty" jsonschema:"description=Paths to commit\\, every change by default"`
+}
+
+// CreateBranchInput is the input of the git_create_branch tool
+type CreateBranchInput struct {
+	Name     string `json:"name" jsonschema:"required,description=Name of the new branch"`
+	From     string `json:"from,omitempty" jsonschema:"description=Revision to branch from\\, HEAD by default"`
+	Checkout bool   `json:"checkout,omitempty" jsonschema:"description=Switch to the new branch"`
+}
+
+// Option is a functional option for configuring the git tools
+type Option func(*config)
+
+type config struct {
+	write bool
+}
+
+// WithWriteOperations adds the git_commit and git_create_branch tools
+func WithWriteOperations() Option {
+	return func(c *config) {
+		c.write = true
+	}
+}
+
+// Tool is one of the git tools
+type Tool struct {
+	repo        *Repository
+	name        string
+	description string
+	usage       string
+	schema      func() any
+	effect      agent.ToolEffect
+	run         func(ctx context.Context, repo *Repository, input map[string]any) (any, error)
+}
+
+var _ agent.EffectTool = (*Tool)(nil)
+
+// NewTools creates the git tools working on repo, read-only unless WithWriteOperations is set
+func NewTools(repo *Repository, opts ...Option) []agent.ModelTool {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	tools := []agent.ModelTool{
+		&Tool{
+			repo:        repo,
+			name:        CloneToolName,
+			description: "Clones the repository, if it is not cloned yet",
+			usage:       `{"depth":1}`,
+			schema:      llm.GenerateSchema[CloneInput],
+			// Cloning only creates the local copy
+			effect: agent.ToolEffectReadOnly,
+			run:    clone,
+		},
+		&Tool{
+			repo:        repo,
+			name:        StatusToolName,
+			description: "Returns the current branch and the changed, staged and untracked files",
+			usage:       `{}`,
+			schema:      llm.GenerateSchema[struct{}],
+			effect:      agent.ToolEffectReadOnly,
+			run:         status,
+		},
+		&Tool{
+			repo:        repo,
+			name:        LogToolName,
+			description: "Lists recent commits with their hash, author, date and subject",
+			usage:       `{"path":"internal/server","limit":10}`,
+			schema:      llm.GenerateSchema[LogInput],
+			effect:      agent.ToolEffectReadOnly,
+			run:         log,
+		},
+		&Tool{
+			repo:        repo,
+			name:        DiffToolName,
+			description: "Shows the changes of the working tree, of the index, or against a revision, as a unified diff",
+			usage:       `{"ref":"main","path":"README.md"}`,
+			schema:      llm.GenerateSchema[DiffInput],
+			effect:      agent.ToolEffectReadOnly,
+			run:         diff,
+		},
+		&Tool{
+			repo:        repo,
+			name:        ReadFileToolName,
+			description: "Reads a file of the working tree or of a revision, optionally a range of lines",
+			usage:       `{"path":"cmd/server/main.go","start_line":1,"end_line":80}`,
+			schema:      llm.GenerateSchema[ReadFileInput],
+			effect:      agent.ToolEffectReadOnly,
+			run:         readFile,
+		},
+		&Tool{
+			repo:        repo,
+			name:        GrepToolName,
+			description: "Searches the tracked files for a regular expression, returning the matching lines with their file and line number",
+			usage:       `{"pattern":"func New[A-Z]\\w*","path":"internal"}`,
+			schema:      llm.GenerateSchema[GrepInput],
+			effect:      agent.ToolEffectReadOnly,
+			run:         grep,
+		},
+	}
+	if c.write {
+		tools = append(tools,
+			&Tool{
+				repo:        repo,
+				name:        CommitToolName,
+				description: "Commits the changes of the working tree, or of the given paths",
+				usage:       `{"message":"Fix the retry delay of the client","paths":["client/retry.go"]}`,
+				schema:      llm.GenerateSchema[CommitInput],
+				effect:      agent.ToolEffectMutating,
+				run:         commit,
+			},
+			&Tool{
+				repo:        repo,
+				name:        CreateBranchToolName,
+				description: "Creates a branch, optionally switching to it",
+				usage:       `{"name":"fix/retry-delay","checkout":true}`,
+				schema:      llm.GenerateSchema[CreateBranchInput],
+				effect:      agent.ToolEffectMutating,
+				run:         createBranch,
+			},
+		)
+	}
+	return tools
+}
+
+// Name returns the name of the tool
+func (t *Tool) Name() string {
+	return t.name
+}
+
+// Description returns a description of what the tool does
+func (t *Tool) Description() string {
+	return t.description
+}
+
+// InputSchema returns the input schema of the tool
+func (t *Tool) InputSchema() any {
+	return t.schema()
+}
+
+// OutputSchema returns the output schema of the tool
+func (t *Tool) OutputSchema() any {
+	return nil
+}
+
+// Usage returns an example of how to use the tool
+func (t *Tool) Usage() string {
+	return t.usage
+}
+
+// Effect returns agent.ToolEffectMutating for commit and branch tools, agent.ToolEffectReadOnly otherwise
+func (t *Tool) Effect() agent.ToolEffect {
+	return t.effect
+}
+
+// Run runs the tool on the repository
+func (t *Tool) Run(ctx context.Context, input map[string]any) (any, error) {
+	if t.name != CloneToolName && !t.repo.cloned() {
+		return nil, errNotCloned
+	}
+	return t.run(ctx, t.repo, input)
+}
+
+func clone(ctx context.Context, repo *Repository, input map[string]any) (any, error) {
+	if repo.cloned() {
+		head, _, err := repo.run(ctx, "rev-parse", "--abbrev-ref", "HEAD")
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"cloned": false, "branch": strings.TrimSpace(head), "message": "the repository is already cloned"}, nil
+	}
+	if repo.URL == "" {
+		return nil, errors.New("no remote URL is configured for this repository")
+	}
+	args := []string{"clone", "--quiet"}
+	if depth := intInput(input, "depth"); depth > 0 {
+		args = append(args, fmt.Sprintf("--depth=%d", depth))
+	}
+	args = append(args, "--", repo.URL, repo.Dir)
+	// The working tree does not exist yet, run from its parent
+	parent := *repo
+	parent.Dir = filepath.Dir(repo.Dir)
+	if err := os.MkdirAll(parent.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", parent.Dir, err)
+	}
+	if _, _, err := parent.run(ctx, args...); err != nil {
+		return nil, err
+	}
+	head, _, err := repo.run(ctx, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"cloned": true, "branch": strings.TrimSpace(head)}, nil
+}
+
+func status(ctx context.Context, repo *Repository, input map[string]any) (any, error) {
+	output, _, err := repo.run(ctx, "status", "--porcelain=v1", "--branch", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]any{}
+	files := []map[string]string{}
+	for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "## "):
+			result["branch"] = strings.TrimPrefix(line, "## ")
+		case len(line) > 3:
+			files = append(files, map[string]string{"status": strings.TrimSpace(line[:2]), "path": line[3:]})
+		}
+	}
+	result["files"] = files
+	result["clean"] = len(files) == 0
+	return result, nil
+}
+
+func log(ctx context.Context, repo *Repository, input map[string]any) (any, error) {
+	limit := intInput(input, "limit")
+	if limit <= 0 {
+		limit = defaultLogEntries
+	}
+	args := []string{"log", fmt.Sprintf("-n%d", min(limit, maxLogEntries)), "--format=%H%x1f%an%x1f%aI%x1f%s"}
+	if ref, _ := input["ref"].(string); ref != "" {
+		if err := checkRef(ref); err != nil {
+			return nil, err
+		}
+		args = append(args, ref)
+	}
+	args = append(args, "--")
+	if path, _ := input["path"].(string); path != "" {
+		if err := checkPath(path); err != nil {
+			return nil, err
+		}
+		args = append(args, path)
+	}
+	output, _, err := repo.run(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	commits := []map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) == 4 {
+			commits = append(commits, map[string]string{"hash": fields[0], "author": fields[1], "date": fields[2], "subject": fields[3]})
+		}
+	}
+	return map[string]any{"commits": commits}, nil
+}
+
+func diff(ctx context.Context, repo *Repository, input map[string]any) (any, error) {
+	args := []string{"diff"}
+	if staged, _ := input["staged"].(bool); staged {
+		args = append(args, "--staged")
+	}
+	if stat, _ := input["stat"].(bool); stat {
+		args = append(args, "--stat")
+	}
+	if ref, _ := input["ref"].(string); ref != "" {
+		if err := checkRef(ref); err != nil {
+			return nil, err
+		}
+		args = append(args, ref)
+	}
+	args = append(args, "--")
+	if path, _ := input["path"].(string); path != "" {
+		if err := checkPath(path); err != nil {
+			return nil, err
+		}
+		args = append(args, path)
+	}
+	output, truncated, err := repo.run(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"diff": output, "truncated": truncated}, nil
+}
+
+func readFile(ctx context.Context, repo *Repository, input map[string]any) (any, error) {
+	path, _ := input["path"].(string)
+	if path == "" {
+		return nil, errors.New("path is required")
+	}
+	if err := checkPath(path); err != nil {
+		return nil, err
+	}
+
+	var content string
+	if ref, _ := input["ref"].(string); ref != "" {
+		if err := checkRef(ref); err != nil {
+			return nil, err
+		}
+		output, _, err := repo.run(ctx, "show", ref+":"+filepath.ToSlash(filepath.Clean(path)))
+		if err != nil {
+			return nil, err
+		}
+		content = output
+	} else {
+		full, err := filepath.EvalSymlinks(filepath.Join(repo.Dir, path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		root, err := filepath.EvalSymlinks(repo.Dir)
+		if err != nil {
+			return nil, err
+		}
+		if rel, err := filepath.Rel(root, full); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("invalid path '%s', it is outside of the repository", path)
+		}
+		data, err := os.ReadFile(full)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		content = string(data)
+	}
+	if strings.ContainsRune(content[:min(len(content), 8000)], 0) {
+		return nil, fmt.Errorf("%s is a binary file", path)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	start := max(intInput(input, "start_line"), 1)
+	end := intInput(input, "end_line")
+	if end <= 0 || end > len(lines) {
+		end = len(lines)
+	}
+	if start > end {
+		return nil, fmt.Errorf("start_line %d is past end_line %d, the file has %d lines", start, end, len(lines))
+	}
+	selected, truncated := repo.truncate(strings.Join(lines[start-1:end], "\n"))
+	return map[string]any{
+		"path":        path,
+		"content":     selected,
+		"start_line":  start,
+		"end_line":    end,
+		"total_lines": len(lines),
+		"truncated":   truncated,
+	}, nil
+}
+
+func grep(ctx context.Context, repo *Repository, input map[string]any) (any, error) {
+	pattern, _ := input["pattern"].(string)
+	if pattern == "" {
+		return nil, errors.New("pattern is required")
+	}
+	args := []string{"grep", "-n", "-I", "-E"}
+	if ignoreCase, _ := input["ignore_case"].(bool); ignoreCase {
+		args = append(args, "-i")
+	}
+	args = append(args, "-e", pattern)
+	ref, _ := input["ref"].(string)
+	if ref != "" {
+		if err := checkRef(ref); err != nil {
+			return nil, err
+		}
+		args = append(args, ref)
+	}
+	args = append(args, "--")
+	if path, _ := input["path"].(string); path != "" {
+		if err := checkPath(path); err != nil {
+			return nil, err
+		}
+		args = append(args, path)
+	}
+	output, _, err := repo.run(ctx, args...)
+	var commandErr *CommandError
+	if errors.As(err, &commandErr) && commandErr.ExitCode == 1 {
+		// git grep exits with 1 when nothing matches
+		return map[string]any{"matches": []map[string]any{}, "total": 0}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []map[string]any{}
+	total := 0
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if ref != "" {
+			line = strings.TrimPrefix(line, ref+":")
+		}
+		file, rest, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		number, text, ok := strings.Cut(rest, ":")
+		if !ok {
+			continue
+		}
+		total++
+		if len(matches) < maxGrepMatches {
+			if len(text) > 300 {
+				text = strings.ToValidUTF8(text[:300], "") + "…"
+			}
+			matches = append(matches, map[string]any{"file": file, "line": number, "text": text})
+		}
+	}
+	return map[string]any{"matches": matches, "total": total}, nil
+}
+
+func commit(ctx context.Context, repo *Repository, input map[string]any) (any, error) {
+	message, _ := input["message"].(string)
+	if strings.TrimSpace(message) == "" {
+		return nil, errors.New("message is required")
+	}
+	add := []string{"add", "--all", "--"}
+	if rawPaths, ok := input["paths"].([]any); ok && len(rawPaths) > 0 {
+		for _, rawPath := range rawPaths {
+			path, _ := rawPath.(string)
+			if err := checkPath(path); err != nil {
+				return nil, err
+			}
+			add = append(add, path)
+		}
+	}
+	if _, _, err := repo.run(ctx, add...); err != nil {
+		return nil, err
+	}
+
+	args := []string{}
+	if repo.AuthorName != "" {
+		args = append(args, "-c", "user.name="+repo.AuthorName)
+	}
+	if repo.AuthorEmail != "" {
+		args = append(args, "-c", "user.email="+repo.AuthorEmail)
+	}
+	args = append(args, "commit", "--quiet", "--no-verify", "-m", message)
+	if _, _, err := repo.run(ctx, args...); err != nil {
+		return nil, err
+	}
+	hash, _, err := repo.run(ctx, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	stat, _, err := repo.run(ctx, "show", "--stat", "--format=", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"hash": strings.TrimSpace(hash), "stat": strings.TrimSpace(stat)}, nil
+}
+
+func createBranch(ctx context.Context, repo *Repository, input map[string]any) (any, error) {
+	name, _ := input["name"].(string)
+	if err := checkRef(name); err != nil {
+		return nil, err
+	}
+	if _, _, err := repo.run(ctx, "check-ref-format", "--branch", name); err != nil {
+		return nil, fmt.Errorf("invalid branch name '%s'", name)
+	}
+	args := []string{"branch", name}
+	if checkout, _ := input["checkout"].(bool); checkout {
+		args = []string{"switch", "--create", name}
+	}
+	if from, _ := input["from"].(string); from != "" {
+		if err := checkRef(from); err != nil {
+			return nil, err
+		}
+		args = append(args, from)
+	}
+	if _, _, err := repo.run(ctx, args...); err != nil {
+		return nil, err
+	}
+	head, _, err := repo.run(ctx, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"branch": name, "current": strings.TrimSpace(head)}, nil
+}
+
+// intInput returns a number input as an int, 0 if it is missing
+func intInput(input map[string]any, key string) int {
+	if n, ok := input[key].(float64); ok {
+		return int(n)
+	}
+	return 0
+}
@@ -0,0 +1,155 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/easyagent-dev/agent"
+)
+
+// EmailCredentialService is the default credential service of the SMTP login
+const EmailCredentialService = "smtp"
+
+// EmailNotifier sends messages through an SMTP server.
+// The connection is upgraded with STARTTLS when the server supports it.
+type EmailNotifier struct {
+	// Addr is the host:port of the SMTP server
+	Addr string
+
+	// From is the sender address
+	From string
+
+	// Username and Password log in to the server, unless CredentialService is set
+	Username string
+	Password string
+
+	// CredentialService, when set, resolves the login per request from the credential of this
+	// service, e.g. to send with the SMTP account of each tenant
+	CredentialService string
+
+	// Timeout bounds the delivery of a message, 30 seconds by default
+	Timeout time.Duration
+}
+
+var _ Notifier = (*EmailNotifier)(nil)
+
+// Send delivers message to its recipients
+func (n *EmailNotifier) Send(ctx context.Context, message *Message) error {
+	if len(message.To) == 0 {
+		return errors.New("the email has no recipient")
+	}
+	username, password := n.Username, n.Password
+	if n.CredentialService != "" {
+		credential, err := agent.GetCredential(ctx, n.CredentialService)
+		if err != nil {
+			return err
+		}
+		username, password = credential.Username, credential.Password
+	}
+	data, err := n.compose(message)
+	if err != nil {
+		return err
+	}
+
+	timeout := n.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	host, _, err := net.SplitHostPort(n.Addr)
+	if err != nil {
+		return fmt.Errorf("invalid SMTP address %s: %w", n.Addr, err)
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", n.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	deadline, _ := ctx.Deadline()
+	_ = conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	if username != "" {
+		// PlainAuth refuses to send the password over an unencrypted connection to a remote host
+		if err := client.Auth(smtp.PlainAuth("", username, password, host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+	if err := client.Mail(n.From); err != nil {
+		return fmt.Errorf("SMTP server rejected the sender: %w", err)
+	}
+	for _, to := range message.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("SMTP server rejected the recipient %s: %w", to, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send the email: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to send the email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send the email: %w", err)
+	}
+	return client.Quit()
+}
+
+// compose formats message as a plain text email
+func (n *EmailNotifier) compose(message *Message) ([]byte, error) {
+	if strings.ContainsAny(message.Subject, "\r\n") {
+		return nil, errors.New("the subject must be a single line")
+	}
+	for _, to := range message.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return nil, fmt.Errorf("invalid recipient %s: %w", to, err)
+		}
+	}
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	domain := n.From[strings.LastIndex(n.From, "@")+1:]
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", n.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(message.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "Message-ID: <%s@%s>\r\n", hex.EncodeToString(id), domain)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	w := quotedprintable.NewWriter(&b)
+	if _, err := w.Write([]byte(strings.ReplaceAll(message.Body, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,144 @@
+// Package notify provides tools delivering notifications by email and Slack, so monitoring and
+// reporting agents can send their results. Messages are rendered from templates, sends are capped
+// per run, and the SMTP login or Slack webhook can be resolved per tenant from the credentials
+// provider of the request.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+const (
+	// DefaultMaxPerRun bounds the notifications a tool sends in one run
+	DefaultMaxPerRun = 5
+
+	// limiterTTL is how long the send count of a run is kept after its last send
+	limiterTTL = 24 * time.Hour
+)
+
+// ErrRateLimited is returned when a run reached its notification cap
+var ErrRateLimited = errors.New("notification limit reached for this run")
+
+// Message is a rendered notification
+type Message struct {
+	// To are the email recipients, unused by Slack
+	To []string
+
+	Subject string
+	Body    string
+}
+
+// Notifier delivers messages
+type Notifier interface {
+	Send(ctx context.Context, message *Message) error
+}
+
+// Template is a message template using text/template syntax, executed with the data of the tool input
+type Template struct {
+	// Description tells the model when to use the template and which data it needs
+	Description string `yaml:"description" json:"description"`
+
+	Subject string `yaml:"subject" json:"subject"`
+	Body    string `yaml:"body" json:"body"`
+}
+
+// compiledTemplate is a parsed Template
+type compiledTemplate struct {
+	description string
+	subject     *template.Template
+	body        *template.Template
+}
+
+// compileTemplates parses templates, failing on missing data keys when executed
+func compileTemplates(templates map[string]Template) (map[string]*compiledTemplate, error) {
+	compiled := make(map[string]*compiledTemplate, len(templates))
+	for name, t := range templates {
+		subject, err := template.New(name + ".subject").Option("missingkey=error").Parse(t.Subject)
+		if err != nil {
+			return nil, fmt.Errorf("invalid subject of template %s: %w", name, err)
+		}
+		body, err := template.New(name + ".body").Option("missingkey=error").Parse(t.Body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid body of template %s: %w", name, err)
+		}
+		compiled[name] = &compiledTemplate{description: t.Description, subject: subject, body: body}
+	}
+	return compiled, nil
+}
+
+// render executes the template with data
+func (t *compiledTemplate) render(data map[string]any) (subject string, body string, err error) {
+	var b strings.Builder
+	if err := t.subject.Execute(&b, data); err != nil {
+		return "", "", err
+	}
+	subject = strings.TrimSpace(b.String())
+	b.Reset()
+	if err := t.body.Execute(&b, data); err != nil {
+		return "", "", err
+	}
+	return subject, b.String(), nil
+}
+
+// RunLimiter caps the notifications sent per run. A limiter can be shared by several tools to
+// cap their sends together. This type is safe for concurrent use.
+type RunLimiter struct {
+	mu    sync.Mutex
+	max   int
+	runs  map[string]*runCount
+	clock func() time.Time
+}
+
+type runCount struct {
+	sent int
+	last time.Time
+}
+
+// NewRunLimiter creates a limiter allowing max notifications per run
+func NewRunLimiter(max int) *RunLimiter {
+	return &RunLimiter{
+		max:   max,
+		runs:  make(map[string]*runCount),
+		clock: time.Now,
+	}
+}
+
+// Reserve counts a notification of run, or returns ErrRateLimited if the run reached its cap
+func (l *RunLimiter) Reserve(runID string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock()
+	for id, count := range l.runs {
+		if now.Sub(count.last) > limiterTTL {
+			delete(l.runs, id)
+		}
+	}
+	count, ok := l.runs[runID]
+	if !ok {
+		count = &runCount{}
+		l.runs[runID] = count
+	}
+	if count.sent >= l.max {
+		return fmt.Errorf("%w: %d sent", ErrRateLimited, count.sent)
+	}
+	count.sent++
+	count.last = now
+	return nil
+}
+
+// Release gives back a reservation of run whose notification could not be sent
+func (l *RunLimiter) Release(runID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if count, ok := l.runs[runID]; ok && count.sent > 0 {
+		count.sent--
+	}
+}
@@ -0,0 +1,83 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/easyagent-dev/agent"
+)
+
+// SlackCredentialService is the default credential service of the Slack webhook
+const SlackCredentialService = "slack"
+
+// SlackNotifier posts messages to a Slack incoming webhook
+type SlackNotifier struct {
+	// WebhookURL is the incoming webhook of the channel, unless CredentialService is set
+	WebhookURL string
+
+	// CredentialService, when set, resolves the webhook per request from the token of the
+	// credential of this service, e.g. to post to the workspace of each tenant
+	CredentialService string
+
+	// Client sends the requests, a client with a 30 seconds timeout by default
+	Client *http.Client
+}
+
+var _ Notifier = (*SlackNotifier)(nil)
+
+// Send posts message, its subject in bold above the body
+func (n *SlackNotifier) Send(ctx context.Context, message *Message) error {
+	webhookURL := n.WebhookURL
+	if n.CredentialService != "" {
+		credential, err := agent.GetCredential(ctx, n.CredentialService)
+		if err != nil {
+			return err
+		}
+		webhookURL = credential.Token
+	}
+	if webhookURL == "" {
+		return errors.New("no Slack webhook is configured")
+	}
+
+	text := message.Body
+	if message.Subject != "" {
+		text = "*" + message.Subject + "*\n" + text
+	}
+	payload, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("invalid Slack webhook: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := n.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// The error contains the webhook URL, which is a secret
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to post to Slack: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack rejected the message with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
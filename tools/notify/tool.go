@@ -0,0 +1,278 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/easyagent-dev/agent"
+	"github.com/easyagent-dev/llm"
+)
+
+// Default tool names
+const (
+	EmailToolName = "send_email"
+	SlackToolName = "send_slack_message"
+)
+
+// EmailInput is the input of the email tool
+type EmailInput struct {
+	Template string         `json:"template,omitempty" jsonschema:"description=Name of the message template to use"`
+	Data     map[string]any `json:"data,omitempty" jsonschema:"description=Values of the template fields"`
+	Subject  string         `json:"subject,omitempty" jsonschema:"description=Subject\\, when no template is used"`
+	Body     string         `json:"body,omitempty" jsonschema:"description=Plain text body\\, when no template is used"`
+	To       []string       `json:"to,omitempty" jsonschema:"description=Recipient addresses\\, the default recipients if empty"`
+}
+
+// SlackInput is the input of the Slack tool
+type SlackInput struct {
+	Template string         `json:"template,omitempty" jsonschema:"description=Name of the message template to use"`
+	Data     map[string]any `json:"data,omitempty" jsonschema:"description=Values of the template fields"`
+	Text     string         `json:"text,omitempty" jsonschema:"description=Message in Slack markdown\\, when no template is used"`
+}
+
+// Option is a functional option for configuring notification tools
+type Option func(*Tool)
+
+// WithToolName sets the name of the tool, e.g. to register one tool per channel
+func WithToolName(name string) Option {
+	return func(t *Tool) {
+		t.name = name
+	}
+}
+
+// WithTemplates sets the templates of the messages. The model then picks a template and fills in
+// its data instead of writing the message.
+func WithTemplates(templates map[string]Template) Option {
+	return func(t *Tool) {
+		t.templates = templates
+	}
+}
+
+// WithMaxPerRun caps the notifications the tool sends per run, DefaultMaxPerRun by default
+func WithMaxPerRun(max int) Option {
+	return func(t *Tool) {
+		t.limiter = NewRunLimiter(max)
+	}
+}
+
+// WithRunLimiter sets the limiter capping the notifications per run, e.g. a limiter shared with
+// other notification tools
+func WithRunLimiter(limiter *RunLimiter) Option {
+	return func(t *Tool) {
+		t.limiter = limiter
+	}
+}
+
+// WithRecipients sets the default recipients of emails, and the recipients the model may choose:
+// addresses, or domains starting with "@". Without allowed recipients, emails only go to the
+// default ones.
+func WithRecipients(defaults []string, allowed ...string) Option {
+	return func(t *Tool) {
+		t.defaultTo = defaults
+		t.allowedTo = allowed
+	}
+}
+
+// Tool sends notifications through a Notifier.
+// The tool is agent.ToolEffectMutating, so its calls go through the runner's approver.
+type Tool struct {
+	notifier  Notifier
+	email     bool
+	name      string
+	templates map[string]Template
+	compiled  map[string]*compiledTemplate
+	limiter   *RunLimiter
+	defaultTo []string
+	allowedTo []string
+}
+
+var (
+	_ agent.ModelTool  = (*Tool)(nil)
+	_ agent.EffectTool = (*Tool)(nil)
+)
+
+// NewEmailTool creates a send_email tool delivering through notifier
+func NewEmailTool(notifier *EmailNotifier, opts ...Option) (*Tool, error) {
+	return newTool(notifier, true, EmailToolName, opts)
+}
+
+// NewSlackTool creates a send_slack_message tool posting through notifier
+func NewSlackTool(notifier *SlackNotifier, opts ...Option) (*Tool, error) {
+	return newTool(notifier, false, SlackToolName, opts)
+}
+
+func newTool(notifier Notifier, email bool, name string, opts []Option) (*Tool, error) {
+	tool := &Tool{
+		notifier: notifier,
+		email:    email,
+		name:     name,
+	}
+	for _, opt := range opts {
+		opt(tool)
+	}
+	if tool.limiter == nil {
+		tool.limiter = NewRunLimiter(DefaultMaxPerRun)
+	}
+	compiled, err := compileTemplates(tool.templates)
+	if err != nil {
+		return nil, err
+	}
+	tool.compiled = compiled
+	return tool, nil
+}
+
+// Name returns the name of the tool
+func (t *Tool) Name() string {
+	return t.name
+}
+
+// Description returns a description of what the tool does, listing its templates
+func (t *Tool) Description() string {
+	var b strings.Builder
+	if t.email {
+		b.WriteString("Sends an email")
+	} else {
+		b.WriteString("Posts a message to Slack")
+	}
+	if len(t.compiled) == 0 {
+		return b.String()
+	}
+	b.WriteString(" rendered from one of these templates:")
+	names := make([]string, 0, len(t.compiled))
+	for name := range t.compiled {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b.WriteString("\n- ")
+		b.WriteString(name)
+		if description := t.compiled[name].description; description != "" {
+			b.WriteString(": ")
+			b.WriteString(description)
+		}
+	}
+	return b.String()
+}
+
+// InputSchema returns the input schema of the tool
+func (t *Tool) InputSchema() any {
+	if t.email {
+		return llm.GenerateSchema[EmailInput]()
+	}
+	return llm.GenerateSchema[SlackInput]()
+}
+
+// OutputSchema returns the output schema of the tool
+func (t *Tool) OutputSchema() any {
+	return nil
+}
+
+// Usage returns an example of how to use the tool
+func (t *Tool) Usage() string {
+	switch {
+	case len(t.compiled) > 0:
+		return `{"template":"daily_report","data":{"date":"2025-06-02","errors":3}}`
+	case t.email:
+		return `{"subject":"Daily report","body":"3 errors were logged today."}`
+	default:
+		return `{"text":"*Daily report*\n3 errors were logged today."}`
+	}
+}
+
+// Effect returns agent.ToolEffectMutating
+func (t *Tool) Effect() agent.ToolEffect {
+	return agent.ToolEffectMutating
+}
+
+// Run renders and sends the message
+func (t *Tool) Run(ctx context.Context, input map[string]any) (any, error) {
+	message, err := t.message(input)
+	if err != nil {
+		return nil, err
+	}
+
+	runID := ""
+	if agentContext, ok := agent.AgentContextOf(ctx); ok {
+		runID = agentContext.RunID
+	}
+	if err := t.limiter.Reserve(runID); err != nil {
+		return nil, err
+	}
+	if err := t.notifier.Send(ctx, message); err != nil {
+		t.limiter.Release(runID)
+		return nil, err
+	}
+	result := map[string]any{"sent": true}
+	if t.email {
+		result["subject"] = message.Subject
+		result["to"] = message.To
+	}
+	return result, nil
+}
+
+// message renders the message of input
+func (t *Tool) message(input map[string]any) (*Message, error) {
+	message := &Message{}
+	name, _ := input["template"].(string)
+	switch {
+	case name != "":
+		compiled, ok := t.compiled[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown template '%s'", name)
+		}
+		data, _ := input["data"].(map[string]any)
+		subject, body, err := compiled.render(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render template %s: %w", name, err)
+		}
+		message.Subject, message.Body = subject, body
+	case len(t.compiled) > 0:
+		return nil, errors.New("template is required, messages must use one of the templates")
+	case t.email:
+		message.Subject, _ = input["subject"].(string)
+		message.Body, _ = input["body"].(string)
+	default:
+		message.Body, _ = input["text"].(string)
+	}
+	if strings.TrimSpace(message.Body) == "" {
+		return nil, errors.New("the message is empty")
+	}
+	if !t.email {
+		return message, nil
+	}
+
+	if message.Subject == "" {
+		return nil, errors.New("subject is required")
+	}
+	message.To = t.defaultTo
+	if rawTo, ok := input["to"].([]any); ok && len(rawTo) > 0 {
+		message.To = nil
+		for _, raw := range rawTo {
+			to, _ := raw.(string)
+			if !t.allowedRecipient(to) {
+				return nil, fmt.Errorf("recipient '%s' is not allowed", to)
+			}
+			message.To = append(message.To, to)
+		}
+	}
+	return message, nil
+}
+
+// allowedRecipient reports whether the model may send to address
+func (t *Tool) allowedRecipient(address string) bool {
+	address = strings.ToLower(strings.TrimSpace(address))
+	if address == "" {
+		return false
+	}
+	return slices.ContainsFunc(t.allowedTo, func(allowed string) bool {
+		allowed = strings.ToLower(allowed)
+		if strings.HasPrefix(allowed, "@") {
+			return strings.HasSuffix(address, allowed)
+		}
+		return address == allowed
+	})
+}
@@ -0,0 +1,43 @@
+package shell
+
+import (
+	"context"
+	"time"
+)
+
+// AuditRecord describes a command the model asked to run, whether it ran or was denied
+type AuditRecord struct {
+	Time time.Time `json:"time"`
+
+	// RunID and Agent identify the agent run, empty outside of runs
+	RunID string `json:"runId,omitempty"`
+	Agent string `json:"agent,omitempty"`
+
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+	Dir     string   `json:"dir,omitempty"`
+
+	// Allowed is false for commands denied by the policy, Violation naming the rule
+	Allowed   bool   `json:"allowed"`
+	Violation string `json:"violation,omitempty"`
+
+	ExitCode int           `json:"exitCode"`
+	TimedOut bool          `json:"timedOut,omitempty"`
+	Duration time.Duration `json:"duration"`
+
+	// Error is set when the command could not be started
+	Error string `json:"error,omitempty"`
+}
+
+// Auditor receives an audit record for every command the model asks to run
+type Auditor interface {
+	Audit(ctx context.Context, record *AuditRecord)
+}
+
+// AuditorFunc adapts a function to the Auditor interface
+type AuditorFunc func(ctx context.Context, record *AuditRecord)
+
+// Audit calls f
+func (f AuditorFunc) Audit(ctx context.Context, record *AuditRecord) {
+	f(ctx, record)
+}
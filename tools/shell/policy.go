@@ -0,0 +1,259 @@
+// Package shell provides a tool running commands under a policy, so ops-automation agents can
+// run programs with auditable constraints: allowed and denied programs, argument patterns, a
+// working directory jail, a scrubbed environment, a timeout and output caps. Commands are
+// executed directly, never through a shell, so the model cannot chain or redirect them.
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultTimeout bounds the run time of commands when the policy sets none
+	DefaultTimeout = 30 * time.Second
+
+	// DefaultMaxOutputBytes bounds stdout and stderr each when the policy sets no limit
+	DefaultMaxOutputBytes = 32 * 1024
+)
+
+// DefaultPassEnv are the environment variables passed to commands when the policy lists none.
+// Everything else, credentials included, is scrubbed.
+var DefaultPassEnv = []string{"PATH", "LANG", "LC_ALL", "TZ"}
+
+// ErrPolicyViolation is matched by the errors of commands denied by the policy
+var ErrPolicyViolation = errors.New("command denied by policy")
+
+// Policy decides which commands the tool runs and how
+type Policy struct {
+	// AllowedCommands are the programs that may run, by name, e.g. "git", or absolute path.
+	// "*" allows every program. Nothing runs when empty.
+	AllowedCommands []string `yaml:"allowed_commands" json:"allowed_commands"`
+
+	// DeniedCommands never run, even when allowed, e.g. "rm" with AllowedCommands ["*"]
+	DeniedCommands []string `yaml:"denied_commands,omitempty" json:"denied_commands,omitempty"`
+
+	// AllowedArgs restricts the arguments of programs by name: every argument of a listed program
+	// must match one of its regular expressions, e.g. {"git": ["^(status|log|diff)$", "^--oneline$"]}
+	AllowedArgs map[string][]string `yaml:"allowed_args,omitempty" json:"allowed_args,omitempty"`
+
+	// DeniedArgs are regular expressions no argument may match, e.g. "^--force$"
+	DeniedArgs []string `yaml:"denied_args,omitempty" json:"denied_args,omitempty"`
+
+	// Root jails commands: they run in Root or one of its subdirectories. Required.
+	Root string `yaml:"root" json:"root"`
+
+	// RestrictPathArgs denies arguments naming paths outside of Root, absolute or with ..
+	RestrictPathArgs bool `yaml:"restrict_path_args,omitempty" json:"restrict_path_args,omitempty"`
+
+	// PassEnv are the environment variables passed from the agent process, DefaultPassEnv if nil
+	PassEnv []string `yaml:"pass_env,omitempty" json:"pass_env,omitempty"`
+
+	// Env sets environment variables of commands, overriding passed ones
+	Env map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
+
+	// Timeout bounds the run time of commands, DefaultTimeout if 0
+	Timeout time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+
+	// MaxOutputBytes bounds stdout and stderr each, DefaultMaxOutputBytes if 0
+	MaxOutputBytes int `yaml:"max_output_bytes,omitempty" json:"max_output_bytes,omitempty"`
+}
+
+// Violation is the error of a command denied by the policy
+type Violation struct {
+	// Rule is the policy field denying the command, e.g. "denied_args"
+	Rule string
+
+	// Reason explains the denial to the model
+	Reason string
+}
+
+// Error returns the reason of the violation
+func (v *Violation) Error() string {
+	return fmt.Sprintf("%s: %s", ErrPolicyViolation, v.Reason)
+}
+
+// Is matches ErrPolicyViolation
+func (v *Violation) Is(target error) bool {
+	return target == ErrPolicyViolation
+}
+
+// Command is a program invocation checked against a policy
+type Command struct {
+	// Name is the program, a name looked up in PATH or an absolute path
+	Name string
+
+	Args []string
+
+	// Dir is the working directory, relative to the policy root
+	Dir string
+}
+
+// compiledPolicy is a validated policy with its patterns compiled
+type compiledPolicy struct {
+	Policy
+	root        string
+	allowedArgs map[string][]*regexp.Regexp
+	deniedArgs  []*regexp.Regexp
+}
+
+// compile validates the policy and applies its defaults
+func (p Policy) compile() (*compiledPolicy, error) {
+	if p.Root == "" {
+		return nil, errors.New("policy root is required")
+	}
+	root, err := filepath.Abs(p.Root)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy root: %w", err)
+	}
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		return nil, fmt.Errorf("invalid policy root: %w", err)
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("policy root %s is not a directory", root)
+	}
+
+	c := &compiledPolicy{Policy: p, root: root, allowedArgs: make(map[string][]*regexp.Regexp)}
+	if c.PassEnv == nil {
+		c.PassEnv = DefaultPassEnv
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultTimeout
+	}
+	if c.MaxOutputBytes <= 0 {
+		c.MaxOutputBytes = DefaultMaxOutputBytes
+	}
+	for name, patterns := range p.AllowedArgs {
+		for _, pattern := range patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid allowed argument pattern of %s: %w", name, err)
+			}
+			c.allowedArgs[name] = append(c.allowedArgs[name], re)
+		}
+	}
+	for _, pattern := range p.DeniedArgs {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid denied argument pattern: %w", err)
+		}
+		c.deniedArgs = append(c.deniedArgs, re)
+	}
+	return c, nil
+}
+
+// check returns the working directory of cmd, or the Violation denying it
+func (c *compiledPolicy) check(cmd *Command) (string, error) {
+	name := cmd.Name
+	if strings.ContainsRune(name, '/') && !filepath.IsAbs(name) {
+		return "", &Violation{Rule: "allowed_commands", Reason: fmt.Sprintf("'%s' is a relative path, use a program name", name)}
+	}
+	base := filepath.Base(name)
+	if slices.Contains(c.DeniedCommands, name) || slices.Contains(c.DeniedCommands, base) {
+		return "", &Violation{Rule: "denied_commands", Reason: fmt.Sprintf("'%s' is not allowed", name)}
+	}
+	allowed := slices.Contains(c.AllowedCommands, "*") || slices.Contains(c.AllowedCommands, name)
+	if !allowed && !filepath.IsAbs(name) {
+		allowed = slices.Contains(c.AllowedCommands, base)
+	}
+	if !allowed {
+		return "", &Violation{Rule: "allowed_commands", Reason: fmt.Sprintf("'%s' is not an allowed program, allowed are %s", name, strings.Join(c.AllowedCommands, ", "))}
+	}
+
+	dir, err := c.workingDir(cmd.Dir)
+	if err != nil {
+		return "", err
+	}
+	patterns, restricted := c.allowedArgs[base]
+	for _, arg := range cmd.Args {
+		for _, re := range c.deniedArgs {
+			if re.MatchString(arg) {
+				return "", &Violation{Rule: "denied_args", Reason: fmt.Sprintf("argument '%s' is not allowed", arg)}
+			}
+		}
+		if restricted && !slices.ContainsFunc(patterns, func(re *regexp.Regexp) bool { return re.MatchString(arg) }) {
+			return "", &Violation{Rule: "allowed_args", Reason: fmt.Sprintf("argument '%s' is not allowed for %s", arg, base)}
+		}
+		if c.RestrictPathArgs {
+			if err := c.checkPathArg(dir, arg); err != nil {
+				return "", err
+			}
+		}
+	}
+	return dir, nil
+}
+
+// workingDir resolves dir within the root, following symlinks so they cannot escape it
+func (c *compiledPolicy) workingDir(dir string) (string, error) {
+	if filepath.IsAbs(dir) {
+		return "", &Violation{Rule: "root", Reason: fmt.Sprintf("working directory '%s' must be relative to the root", dir)}
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Join(c.root, dir))
+	if err != nil {
+		return "", fmt.Errorf("invalid working directory '%s': %w", dir, err)
+	}
+	if !c.within(resolved) {
+		return "", &Violation{Rule: "root", Reason: fmt.Sprintf("working directory '%s' is outside of the root", dir)}
+	}
+	return resolved, nil
+}
+
+// checkPathArg denies arguments, or the values of --flag=value arguments, naming paths outside of the root
+func (c *compiledPolicy) checkPathArg(dir, arg string) error {
+	value := arg
+	if strings.HasPrefix(arg, "-") {
+		_, v, ok := strings.Cut(arg, "=")
+		if !ok {
+			return nil
+		}
+		value = v
+	}
+	if strings.HasPrefix(value, "~") {
+		return &Violation{Rule: "restrict_path_args", Reason: fmt.Sprintf("argument '%s' refers to a home directory", arg)}
+	}
+	if !filepath.IsAbs(value) && !slices.Contains(strings.Split(filepath.ToSlash(value), "/"), "..") {
+		return nil
+	}
+	path := value
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	path = filepath.Clean(path)
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	if !c.within(path) {
+		return &Violation{Rule: "restrict_path_args", Reason: fmt.Sprintf("argument '%s' is outside of the root", arg)}
+	}
+	return nil
+}
+
+// within reports whether path is the root or inside it
+func (c *compiledPolicy) within(path string) bool {
+	rel, err := filepath.Rel(c.root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// env returns the scrubbed environment of commands
+func (c *compiledPolicy) env() []string {
+	var env []string
+	for _, name := range c.PassEnv {
+		if _, overridden := c.Env[name]; overridden {
+			continue
+		}
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	for name, value := range c.Env {
+		env = append(env, name+"="+value)
+	}
+	slices.Sort(env)
+	return env
+}
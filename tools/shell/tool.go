@@ -0,0 +1,324 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/easyagent-dev/agent"
+	"github.com/easyagent-dev/llm"
+)
+
+// ToolName is the default name of the shell tool
+const ToolName = "run_command"
+
+// Input is the input of the shell tool
+type Input struct {
+	Command string   `json:"command" jsonschema:"required,description=Program to run\\, or a command line without pipes\\, redirections or other shell operators"`
+	Args    []string `json:"args,omitempty" jsonschema:"description=Arguments of the program\\, when command is only the program"`
+	Dir     string   `json:"dir,omitempty" jsonschema:"description=Working directory relative to the workspace root"`
+}
+
+// Option is a functional option for configuring shell tools
+type Option func(*Tool)
+
+// WithToolName sets the name of the tool, e.g. to register tools with different policies
+func WithToolName(name string) Option {
+	return func(t *Tool) {
+		t.name = name
+	}
+}
+
+// WithAuditor sets the auditor receiving a record of every command
+func WithAuditor(auditor Auditor) Option {
+	return func(t *Tool) {
+		t.auditor = auditor
+	}
+}
+
+// WithEffect sets the effect of the tool, agent.ToolEffectMutating by default.
+// Policies allowing only read-only programs can use agent.ToolEffectReadOnly to skip approvals.
+func WithEffect(effect agent.ToolEffect) Option {
+	return func(t *Tool) {
+		t.effect = effect
+	}
+}
+
+// Tool runs the commands its policy allows and returns their exit code and output.
+// A non-zero exit code is a result, not an error, so the model can read stderr and react.
+type Tool struct {
+	policy  *compiledPolicy
+	name    string
+	auditor Auditor
+	effect  agent.ToolEffect
+}
+
+var (
+	_ agent.ModelTool  = (*Tool)(nil)
+	_ agent.EffectTool = (*Tool)(nil)
+)
+
+// NewTool creates a shell tool enforcing policy
+func NewTool(policy Policy, opts ...Option) (*Tool, error) {
+	compiled, err := policy.compile()
+	if err != nil {
+		return nil, err
+	}
+	tool := &Tool{
+		policy: compiled,
+		name:   ToolName,
+		effect: agent.ToolEffectMutating,
+	}
+	for _, opt := range opts {
+		opt(tool)
+	}
+	return tool, nil
+}
+
+// Name returns the name of the tool
+func (t *Tool) Name() string {
+	return t.name
+}
+
+// Description returns a description of what the tool does, including the allowed programs
+func (t *Tool) Description() string {
+	return fmt.Sprintf("Runs a program in the workspace and returns its exit code, stdout and stderr. "+
+		"Commands are not run by a shell, so pipes, redirections, globs and variables are not supported. Allowed programs: %s.",
+		strings.Join(t.policy.AllowedCommands, ", "))
+}
+
+// InputSchema returns the input schema of the tool
+func (t *Tool) InputSchema() any {
+	return llm.GenerateSchema[Input]()
+}
+
+// OutputSchema returns the output schema of the tool
+func (t *Tool) OutputSchema() any {
+	return nil
+}
+
+// Usage returns an example of how to use the tool
+func (t *Tool) Usage() string {
+	return `{"command":"git","args":["log","--oneline","-n","5"],"dir":"service"}`
+}
+
+// Effect returns the effect of the tool
+func (t *Tool) Effect() agent.ToolEffect {
+	return t.effect
+}
+
+// Run checks the command against the policy and runs it
+func (t *Tool) Run(ctx context.Context, input map[string]any) (any, error) {
+	record := &AuditRecord{Time: time.Now()}
+	record.Command, _ = input["command"].(string)
+	if ac, ok := agent.AgentContextOf(ctx); ok {
+		record.RunID = ac.RunID
+		if current := ac.CurrentAgent(); current != nil {
+			record.Agent = current.Name
+		}
+	}
+	defer func() {
+		if t.auditor != nil {
+			t.auditor.Audit(ctx, record)
+		}
+	}()
+
+	cmd, err := parseInput(input)
+	if err != nil {
+		record.Error = err.Error()
+		return nil, err
+	}
+	record.Command, record.Args, record.Dir = cmd.Name, cmd.Args, cmd.Dir
+
+	dir, err := t.policy.check(cmd)
+	if err != nil {
+		var violation *Violation
+		if errors.As(err, &violation) {
+			record.Violation = violation.Rule
+		}
+		record.Error = err.Error()
+		return nil, err
+	}
+	record.Allowed = true
+
+	env := t.policy.env()
+	path, err := lookPath(cmd.Name, env)
+	if err != nil {
+		record.Error = err.Error()
+		return nil, err
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, t.policy.Timeout)
+	defer cancel()
+	process := exec.CommandContext(runCtx, path, cmd.Args...)
+	process.Dir = dir
+	process.Env = env
+	process.WaitDelay = time.Second
+	stdout := &cappedBuffer{max: t.policy.MaxOutputBytes}
+	stderr := &cappedBuffer{max: t.policy.MaxOutputBytes}
+	process.Stdout = stdout
+	process.Stderr = stderr
+
+	start := time.Now()
+	err = process.Run()
+	record.Duration = time.Since(start)
+	record.TimedOut = errors.Is(runCtx.Err(), context.DeadlineExceeded)
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		record.ExitCode = exitErr.ExitCode()
+	case ctx.Err() != nil:
+		record.Error = ctx.Err().Error()
+		return nil, ctx.Err()
+	case !record.TimedOut:
+		record.Error = err.Error()
+		return nil, fmt.Errorf("failed to run %s: %w", cmd.Name, err)
+	}
+
+	result := map[string]any{
+		"exit_code":   record.ExitCode,
+		"stdout":      stdout.String(),
+		"stderr":      stderr.String(),
+		"duration_ms": record.Duration.Milliseconds(),
+	}
+	if stdout.truncated || stderr.truncated {
+		result["truncated"] = true
+	}
+	if record.TimedOut {
+		result["timed_out"] = true
+		result["error"] = fmt.Sprintf("killed after %s", t.policy.Timeout)
+	}
+	return result, nil
+}
+
+// parseInput returns the command of the tool input, splitting command lines
+func parseInput(input map[string]any) (*Command, error) {
+	line, _ := input["command"].(string)
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil, errors.New("command is required")
+	}
+	cmd := &Command{}
+	cmd.Dir, _ = input["dir"].(string)
+	if rawArgs, ok := input["args"].([]any); ok {
+		for _, arg := range rawArgs {
+			s, ok := arg.(string)
+			if !ok {
+				return nil, fmt.Errorf("args must be strings, got %v", arg)
+			}
+			cmd.Args = append(cmd.Args, s)
+		}
+	}
+
+	words, err := splitCommandLine(line)
+	if err != nil {
+		return nil, err
+	}
+	cmd.Name = words[0]
+	cmd.Args = append(words[1:], cmd.Args...)
+	return cmd, nil
+}
+
+// shellOperators are the tokens a shell would interpret, refused rather than passed as arguments
+var shellOperators = map[string]bool{"|": true, "||": true, "&": true, "&&": true, ";": true, ">": true, ">>": true, "<": true, "<<": true, "2>": true, "2>&1": true}
+
+// splitCommandLine splits a command line into words, honoring quotes and backslashes but not
+// expanding anything
+func splitCommandLine(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord, quoted := false, false
+	var quote rune
+	escaped := false
+	for i, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord, quoted = r, true, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				if !quoted && shellOperators[word.String()] {
+					return nil, fmt.Errorf("shell operator '%s' is not supported, run one program at a time", word.String())
+				}
+				words = append(words, word.String())
+				word.Reset()
+				inWord, quoted = false, false
+			}
+		default:
+			if r == '`' || r == '$' && strings.HasPrefix(line[i:], "$(") {
+				return nil, errors.New("command substitution is not supported")
+			}
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated quote in command")
+	}
+	if inWord {
+		if !quoted && shellOperators[word.String()] {
+			return nil, fmt.Errorf("shell operator '%s' is not supported, run one program at a time", word.String())
+		}
+		words = append(words, word.String())
+	}
+	if len(words) == 0 {
+		return nil, errors.New("command is required")
+	}
+	return words, nil
+}
+
+// lookPath finds a program in the PATH of the command environment rather than of the agent process
+func lookPath(name string, env []string) (string, error) {
+	if filepath.IsAbs(name) {
+		return exec.LookPath(name)
+	}
+	for _, entry := range env {
+		if path, ok := strings.CutPrefix(entry, "PATH="); ok {
+			for _, dir := range filepath.SplitList(path) {
+				if dir == "" || !filepath.IsAbs(dir) {
+					continue
+				}
+				if found, err := exec.LookPath(filepath.Join(dir, name)); err == nil {
+					return found, nil
+				}
+			}
+		}
+	}
+	return "", fmt.Errorf("program '%s' not found", name)
+}
+
+// cappedBuffer keeps the first max bytes written to it
+type cappedBuffer struct {
+	buf       []byte
+	max       int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - len(b.buf); room < len(p) {
+		b.buf = append(b.buf, p[:max(room, 0)]...)
+		b.truncated = true
+	} else {
+		b.buf = append(b.buf, p...)
+	}
+	return len(p), nil
+}
+
+func (b *cappedBuffer) String() string {
+	return strings.ToValidUTF8(string(b.buf), "")
+}
@@ -0,0 +1,411 @@
+package standard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/easyagent-dev/agent"
+	"github.com/easyagent-dev/llm"
+)
+
+// CalculatorToolName is the name of the calculator tool
+const CalculatorToolName = "calculator"
+
+// maxExpressionLength bounds the expressions the calculator evaluates
+const maxExpressionLength = 4096
+
+// CalculatorInput is the input of the calculator tool
+type CalculatorInput struct {
+	Expression string `json:"expression" jsonschema:"required,description=Arithmetic expression with + - * / % ^ and parentheses\\, the constants pi and e\\, and the functions sqrt abs floor ceil round trunc exp ln log log2 sin cos tan asin acos atan min max pow"`
+}
+
+// CalculatorTool evaluates arithmetic expressions, which models often get wrong
+type CalculatorTool struct{}
+
+var (
+	_ agent.ModelTool  = (*CalculatorTool)(nil)
+	_ agent.EffectTool = (*CalculatorTool)(nil)
+)
+
+// NewCalculatorTool creates a calculator tool
+func NewCalculatorTool() *CalculatorTool {
+	return &CalculatorTool{}
+}
+
+// Name returns the name of the tool
+func (t *CalculatorTool) Name() string {
+	return CalculatorToolName
+}
+
+// Description returns a description of what the tool does
+func (t *CalculatorTool) Description() string {
+	return "Evaluates an arithmetic expression exactly. Use it for any calculation instead of computing in your head."
+}
+
+// InputSchema returns the input schema of the tool
+func (t *CalculatorTool) InputSchema() any {
+	return llm.GenerateSchema[CalculatorInput]()
+}
+
+// OutputSchema returns the output schema of the tool
+func (t *CalculatorTool) OutputSchema() any {
+	return nil
+}
+
+// Usage returns an example of how to use the tool
+func (t *CalculatorTool) Usage() string {
+	return `{"expression":"(1250 * 1.08 - 40) / 12"}`
+}
+
+// Effect returns agent.ToolEffectReadOnly, evaluating has no side effects
+func (t *CalculatorTool) Effect() agent.ToolEffect {
+	return agent.ToolEffectReadOnly
+}
+
+// Run evaluates the expression
+func (t *CalculatorTool) Run(ctx context.Context, input map[string]any) (any, error) {
+	expression, _ := input["expression"].(string)
+	if strings.TrimSpace(expression) == "" {
+		return nil, errors.New("expression is required")
+	}
+	result, err := Evaluate(expression)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"expression": expression, "result": result}, nil
+}
+
+// Evaluate evaluates an arithmetic expression as described by CalculatorInput.
+// ^ is right-associative and binds tighter than unary minus, so -2^2 is -4.
+func Evaluate(expression string) (float64, error) {
+	if len(expression) > maxExpressionLength {
+		return 0, fmt.Errorf("expression longer than %d characters", maxExpressionLength)
+	}
+	p := &exprParser{input: expression}
+	p.next()
+	value, err := p.parseExpression()
+	if err != nil {
+		return 0, err
+	}
+	if p.err != nil {
+		return 0, p.err
+	}
+	if p.token.kind != tokenEOF {
+		return 0, fmt.Errorf("unexpected '%s' at position %d", p.token.text, p.token.pos+1)
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, errors.New("result is not a finite number")
+	}
+	return value, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenIdent
+	tokenOperator
+)
+
+type exprToken struct {
+	kind   tokenKind
+	text   string
+	number float64
+	pos    int
+}
+
+// exprParser is a recursive descent parser evaluating while it parses
+type exprParser struct {
+	input string
+	pos   int
+	token exprToken
+	err   error
+	depth int
+}
+
+// maxExpressionDepth bounds the nesting of parentheses and function calls
+const maxExpressionDepth = 100
+
+// next reads the next token, recording invalid characters in err
+func (p *exprParser) next() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+	start := p.pos
+	if p.pos >= len(p.input) {
+		p.token = exprToken{kind: tokenEOF, text: "end of expression", pos: start}
+		return
+	}
+	c := p.input[p.pos]
+	switch {
+	case c >= '0' && c <= '9' || c == '.':
+		for p.pos < len(p.input) && (isDigit(p.input[p.pos]) || p.input[p.pos] == '.' || p.input[p.pos] == '_') {
+			p.pos++
+		}
+		// Exponent, e.g. 1.5e-3
+		if p.pos < len(p.input) && (p.input[p.pos] == 'e' || p.input[p.pos] == 'E') {
+			end := p.pos + 1
+			if end < len(p.input) && (p.input[end] == '+' || p.input[end] == '-') {
+				end++
+			}
+			if end < len(p.input) && isDigit(p.input[end]) {
+				for end < len(p.input) && isDigit(p.input[end]) {
+					end++
+				}
+				p.pos = end
+			}
+		}
+		text := p.input[start:p.pos]
+		number, err := strconv.ParseFloat(strings.ReplaceAll(text, "_", ""), 64)
+		if err != nil && p.err == nil {
+			p.err = fmt.Errorf("invalid number '%s' at position %d", text, start+1)
+		}
+		p.token = exprToken{kind: tokenNumber, text: text, number: number, pos: start}
+	case unicode.IsLetter(rune(c)):
+		for p.pos < len(p.input) && (unicode.IsLetter(rune(p.input[p.pos])) || isDigit(p.input[p.pos])) {
+			p.pos++
+		}
+		p.token = exprToken{kind: tokenIdent, text: strings.ToLower(p.input[start:p.pos]), pos: start}
+	case strings.IndexByte("+-*/%^(),", c) >= 0:
+		p.pos++
+		// ** is a common spelling of ^
+		if c == '*' && p.pos < len(p.input) && p.input[p.pos] == '*' {
+			p.pos++
+			p.token = exprToken{kind: tokenOperator, text: "^", pos: start}
+			return
+		}
+		p.token = exprToken{kind: tokenOperator, text: string(c), pos: start}
+	default:
+		if p.err == nil {
+			p.err = fmt.Errorf("unexpected character '%c' at position %d", c, start+1)
+		}
+		p.token = exprToken{kind: tokenEOF, text: string(c), pos: start}
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func (p *exprParser) isOperator(op string) bool {
+	return p.token.kind == tokenOperator && p.token.text == op
+}
+
+// parseExpression parses terms separated by + and -
+func (p *exprParser) parseExpression() (float64, error) {
+	value, err := p.parseTerm()
+	if err != nil {
+		return 0, err
+	}
+	for p.isOperator("+") || p.isOperator("-") {
+		op := p.token.text
+		p.next()
+		right, err := p.parseTerm()
+		if err != nil {
+			return 0, err
+		}
+		if op == "+" {
+			value += right
+		} else {
+			value -= right
+		}
+	}
+	return value, nil
+}
+
+// parseTerm parses unary expressions separated by *, / and %
+func (p *exprParser) parseTerm() (float64, error) {
+	value, err := p.parseUnary()
+	if err != nil {
+		return 0, err
+	}
+	for p.isOperator("*") || p.isOperator("/") || p.isOperator("%") {
+		op := p.token.text
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return 0, err
+		}
+		switch op {
+		case "*":
+			value *= right
+		case "/":
+			if right == 0 {
+				return 0, errors.New("division by zero")
+			}
+			value /= right
+		case "%":
+			if right == 0 {
+				return 0, errors.New("modulo by zero")
+			}
+			value = math.Mod(value, right)
+		}
+	}
+	return value, nil
+}
+
+// parseUnary parses a power with optional signs
+func (p *exprParser) parseUnary() (float64, error) {
+	if p.isOperator("-") || p.isOperator("+") {
+		negate := p.token.text == "-"
+		p.next()
+		value, err := p.parseUnary()
+		if negate {
+			value = -value
+		}
+		return value, err
+	}
+	return p.parsePower()
+}
+
+// parsePower parses a right-associative power
+func (p *exprParser) parsePower() (float64, error) {
+	base, err := p.parsePrimary()
+	if err != nil {
+		return 0, err
+	}
+	if !p.isOperator("^") {
+		return base, nil
+	}
+	p.next()
+	exponent, err := p.parseUnary()
+	if err != nil {
+		return 0, err
+	}
+	return math.Pow(base, exponent), nil
+}
+
+// parsePrimary parses a number, a constant, a function call or a parenthesized expression
+func (p *exprParser) parsePrimary() (float64, error) {
+	if p.err != nil {
+		return 0, p.err
+	}
+	token := p.token
+	switch token.kind {
+	case tokenNumber:
+		p.next()
+		return token.number, nil
+	case tokenIdent:
+		p.next()
+		if !p.isOperator("(") {
+			if value, ok := constants[token.text]; ok {
+				return value, nil
+			}
+			return 0, fmt.Errorf("unknown identifier '%s' at position %d", token.text, token.pos+1)
+		}
+		args, err := p.parseArguments()
+		if err != nil {
+			return 0, err
+		}
+		return callFunction(token.text, args)
+	case tokenOperator:
+		if token.text == "(" {
+			p.depth++
+			if p.depth > maxExpressionDepth {
+				return 0, errors.New("expression nested too deeply")
+			}
+			p.next()
+			value, err := p.parseExpression()
+			if err != nil {
+				return 0, err
+			}
+			if !p.isOperator(")") {
+				return 0, fmt.Errorf("expected ')' at position %d", p.token.pos+1)
+			}
+			p.depth--
+			p.next()
+			return value, nil
+		}
+	}
+	return 0, fmt.Errorf("unexpected '%s' at position %d", token.text, token.pos+1)
+}
+
+// parseArguments parses the parenthesized arguments of a function call
+func (p *exprParser) parseArguments() ([]float64, error) {
+	p.depth++
+	if p.depth > maxExpressionDepth {
+		return nil, errors.New("expression nested too deeply")
+	}
+	p.next()
+	var args []float64
+	if !p.isOperator(")") {
+		for {
+			arg, err := p.parseExpression()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if !p.isOperator(",") {
+				break
+			}
+			p.next()
+		}
+	}
+	if !p.isOperator(")") {
+		return nil, fmt.Errorf("expected ')' at position %d", p.token.pos+1)
+	}
+	p.depth--
+	p.next()
+	return args, nil
+}
+
+var constants = map[string]float64{
+	"pi": math.Pi,
+	"e":  math.E,
+}
+
+var unaryFunctions = map[string]func(float64) float64{
+	"sqrt":  math.Sqrt,
+	"abs":   math.Abs,
+	"floor": math.Floor,
+	"ceil":  math.Ceil,
+	"round": math.Round,
+	"trunc": math.Trunc,
+	"exp":   math.Exp,
+	"ln":    math.Log,
+	"log":   math.Log10,
+	"log2":  math.Log2,
+	"sin":   math.Sin,
+	"cos":   math.Cos,
+	"tan":   math.Tan,
+	"asin":  math.Asin,
+	"acos":  math.Acos,
+	"atan":  math.Atan,
+}
+
+// callFunction calls the function name with args
+func callFunction(name string, args []float64) (float64, error) {
+	if fn, ok := unaryFunctions[name]; ok {
+		if len(args) != 1 {
+			return 0, fmt.Errorf("%s takes 1 argument, got %d", name, len(args))
+		}
+		return fn(args[0]), nil
+	}
+	switch name {
+	case "pow":
+		if len(args) != 2 {
+			return 0, fmt.Errorf("pow takes 2 arguments, got %d", len(args))
+		}
+		return math.Pow(args[0], args[1]), nil
+	case "min", "max":
+		if len(args) == 0 {
+			return 0, fmt.Errorf("%s takes at least 1 argument", name)
+		}
+		value := args[0]
+		for _, arg := range args[1:] {
+			if name == "min" {
+				value = math.Min(value, arg)
+			} else {
+				value = math.Max(value, arg)
+			}
+		}
+		return value, nil
+	}
+	return 0, fmt.Errorf("unknown function '%s'", name)
+}
@@ -0,0 +1,238 @@
+package standard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	// Embedded so time zones resolve on hosts without a zoneinfo database, such as scratch images
+	_ "time/tzdata"
+
+	"github.com/easyagent-dev/agent"
+	"github.com/easyagent-dev/llm"
+)
+
+// DateTimeToolName is the name of the datetime tool
+const DateTimeToolName = "datetime"
+
+// DateTime operations
+const (
+	DateTimeNow     = "now"
+	DateTimeConvert = "convert"
+	DateTimeAdd     = "add"
+	DateTimeDiff    = "diff"
+)
+
+// timeLayouts are the layouts accepted for times, without a zone they are in the input timezone
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	time.DateOnly,
+}
+
+// DateTimeInput is the input of the datetime tool
+type DateTimeInput struct {
+	Operation string `json:"operation" jsonschema:"required,enum=now,enum=convert,enum=add,enum=diff,description=now: current time. convert: time in another timezone. add: time plus years/months/days/hours/minutes/seconds (negative to subtract). diff: duration from time to end."`
+	Time      string `json:"time,omitempty" jsonschema:"description=Time as RFC 3339 or YYYY-MM-DD[ HH:MM[:SS]]. Defaults to now."`
+	Timezone  string `json:"timezone,omitempty" jsonschema:"description=IANA timezone of times without an offset and of the result\\, e.g. Europe/Paris. Defaults to UTC."`
+	To        string `json:"to,omitempty" jsonschema:"description=IANA timezone to convert to"`
+	End       string `json:"end,omitempty" jsonschema:"description=End time of diff\\, same formats as time"`
+	Years     int    `json:"years,omitempty"`
+	Months    int    `json:"months,omitempty"`
+	Days      int    `json:"days,omitempty"`
+	Hours     int    `json:"hours,omitempty"`
+	Minutes   int    `json:"minutes,omitempty"`
+	Seconds   int    `json:"seconds,omitempty"`
+}
+
+// DateTimeOption is a functional option for configuring datetime tools
+type DateTimeOption func(*DateTimeTool)
+
+// WithClock sets the function returning the current time, time.Now by default
+func WithClock(now func() time.Time) DateTimeOption {
+	return func(t *DateTimeTool) {
+		t.now = now
+	}
+}
+
+// WithDefaultTimezone sets the timezone used when the model gives none, UTC by default
+func WithDefaultTimezone(location *time.Location) DateTimeOption {
+	return func(t *DateTimeTool) {
+		t.location = location
+	}
+}
+
+// DateTimeTool gives the current time and does timezone-aware date math. Days, months and years
+// are calendar units in the timezone, so adding a day across a DST change keeps the wall clock time
+// and adding a month to January 31 gives the end of February.
+type DateTimeTool struct {
+	now      func() time.Time
+	location *time.Location
+}
+
+var (
+	_ agent.ModelTool  = (*DateTimeTool)(nil)
+	_ agent.EffectTool = (*DateTimeTool)(nil)
+)
+
+// NewDateTimeTool creates a datetime tool
+func NewDateTimeTool(opts ...DateTimeOption) *DateTimeTool {
+	tool := &DateTimeTool{
+		now:      time.Now,
+		location: time.UTC,
+	}
+	for _, opt := range opts {
+		opt(tool)
+	}
+	return tool
+}
+
+// Name returns the name of the tool
+func (t *DateTimeTool) Name() string {
+	return DateTimeToolName
+}
+
+// Description returns a description of what the tool does
+func (t *DateTimeTool) Description() string {
+	return "Returns the current date and time, converts times between timezones, adds durations to dates and computes the time between two dates. Use it instead of guessing dates or weekdays."
+}
+
+// InputSchema returns the input schema of the tool
+func (t *DateTimeTool) InputSchema() any {
+	return llm.GenerateSchema[DateTimeInput]()
+}
+
+// OutputSchema returns the output schema of the tool
+func (t *DateTimeTool) OutputSchema() any {
+	return nil
+}
+
+// Usage returns an example of how to use the tool
+func (t *DateTimeTool) Usage() string {
+	return `{"operation":"add","time":"2025-03-28 09:00","timezone":"America/New_York","days":3}`
+}
+
+// Effect returns agent.ToolEffectReadOnly, date math has no side effects
+func (t *DateTimeTool) Effect() agent.ToolEffect {
+	return agent.ToolEffectReadOnly
+}
+
+// Run runs the operation of the input
+func (t *DateTimeTool) Run(ctx context.Context, input map[string]any) (any, error) {
+	operation, _ := input["operation"].(string)
+	location := t.location
+	if name, _ := input["timezone"].(string); name != "" {
+		var err error
+		if location, err = time.LoadLocation(name); err != nil {
+			return nil, fmt.Errorf("unknown timezone '%s'", name)
+		}
+	}
+	value, _ := input["time"].(string)
+	start, err := t.parseTime(value, location)
+	if err != nil {
+		return nil, err
+	}
+
+	switch operation {
+	case DateTimeNow:
+		return describeTime(start), nil
+	case DateTimeConvert:
+		name, _ := input["to"].(string)
+		if name == "" {
+			return nil, errors.New("to is required to convert")
+		}
+		to, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("unknown timezone '%s'", name)
+		}
+		return describeTime(start.In(to)), nil
+	case DateTimeAdd:
+		result := addMonths(start, 12*intInput(input, "years")+intInput(input, "months")).
+			AddDate(0, 0, intInput(input, "days")).
+			Add(time.Duration(intInput(input, "hours"))*time.Hour +
+				time.Duration(intInput(input, "minutes"))*time.Minute +
+				time.Duration(intInput(input, "seconds"))*time.Second)
+		return describeTime(result), nil
+	case DateTimeDiff:
+		value, _ := input["end"].(string)
+		if value == "" {
+			return nil, errors.New("end is required to diff")
+		}
+		end, err := t.parseTime(value, location)
+		if err != nil {
+			return nil, err
+		}
+		return describeDuration(start, end), nil
+	}
+	return nil, fmt.Errorf("unknown operation '%s', expected now, convert, add or diff", operation)
+}
+
+// parseTime parses value in location, returning the current time if value is empty
+func (t *DateTimeTool) parseTime(value string, location *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == DateTimeNow {
+		return t.now().In(location), nil
+	}
+	for _, layout := range timeLayouts {
+		if parsed, err := time.ParseInLocation(layout, value, location); err == nil {
+			return parsed.In(location), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time '%s', expected RFC 3339 or YYYY-MM-DD[ HH:MM[:SS]]", value)
+}
+
+// addMonths adds months to t, clamping the day to the end of shorter months,
+// so January 31 plus a month is the last day of February rather than early March
+func addMonths(t time.Time, months int) time.Time {
+	if months == 0 {
+		return t
+	}
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(day, lastDay)-1)
+}
+
+// intInput returns the integer input field key, JSON numbers being decoded as float64
+func intInput(input map[string]any, key string) int {
+	switch v := input[key].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
+
+// describeTime returns the fields of t models need, with its weekday so they do not compute it
+func describeTime(t time.Time) map[string]any {
+	zone, _ := t.Zone()
+	return map[string]any{
+		"time":     t.Format(time.RFC3339),
+		"date":     t.Format(time.DateOnly),
+		"weekday":  t.Weekday().String(),
+		"timezone": t.Location().String(),
+		"zone":     zone,
+		"offset":   t.Format("-07:00"),
+		"unix":     t.Unix(),
+	}
+}
+
+// describeDuration returns the time from start to end in seconds, calendar days and a readable form
+func describeDuration(start, end time.Time) map[string]any {
+	d := end.Sub(start)
+	// Calendar days between the dates in the start timezone, unaffected by DST changes
+	startDate := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	end = end.In(start.Location())
+	endDate := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+	return map[string]any{
+		"seconds":       int64(d / time.Second),
+		"hours":         d.Hours(),
+		"calendar_days": int(endDate.Sub(startDate).Hours() / 24),
+		"duration":      d.String(),
+	}
+}
@@ -0,0 +1,142 @@
+package standard
+
+import (
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ExtractMarkdown converts the main content of an HTML document to Markdown, returning its title
+// and content. It converts the main element, or the longest article, when the page has one, and
+// drops navigation, headers, footers, sidebars, forms, cookie banners and similar boilerplate.
+func ExtractMarkdown(r io.Reader, base *url.URL) (title string, markdown string, err error) {
+	return convertHTML(r, base, true)
+}
+
+// boilerplateRoles are the ARIA landmark roles of page chrome
+var boilerplateRoles = map[string]bool{
+	"navigation":    true,
+	"banner":        true,
+	"contentinfo":   true,
+	"complementary": true,
+	"search":        true,
+	"menu":          true,
+	"menubar":       true,
+	"dialog":        true,
+	"alertdialog":   true,
+}
+
+// boilerplateNames are the class and id words marking page chrome
+var boilerplateNames = map[string]bool{
+	"nav":           true,
+	"navbar":        true,
+	"navigation":    true,
+	"menu":          true,
+	"sidebar":       true,
+	"breadcrumb":    true,
+	"breadcrumbs":   true,
+	"cookie":        true,
+	"cookies":       true,
+	"consent":       true,
+	"gdpr":          true,
+	"advert":        true,
+	"advertisement": true,
+	"ads":           true,
+	"sponsored":     true,
+	"social":        true,
+	"share":         true,
+	"sharing":       true,
+	"newsletter":    true,
+	"subscribe":     true,
+	"popup":         true,
+	"modal":         true,
+	"related":       true,
+	"comments":      true,
+}
+
+// mainContent returns the element holding the main content of body: the main element, the
+// longest article, or body itself
+func mainContent(body *html.Node) *html.Node {
+	if main := findElement(body, atom.Main); main != nil {
+		return main
+	}
+	if main := findByRole(body, "main"); main != nil {
+		return main
+	}
+	var best *html.Node
+	bestLength := 0
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			if child.DataAtom == atom.Article {
+				// Nested articles, such as comments, are part of their parent
+				if length := len(strings.TrimSpace(collapseSpaces(textContent(child)))); length > bestLength {
+					best, bestLength = child, length
+				}
+				continue
+			}
+			walk(child)
+		}
+	}
+	walk(body)
+	if best != nil {
+		return best
+	}
+	return body
+}
+
+func findByRole(n *html.Node, role string) *html.Node {
+	if n.Type == html.ElementNode && attr(n, "role") == role {
+		return n
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if found := findByRole(child, role); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// isBoilerplate reports whether an element is page chrome rather than content
+func isBoilerplate(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.Nav, atom.Aside, atom.Form, atom.Dialog:
+		return true
+	case atom.Header, atom.Footer:
+		// The header and footer of an article belong to it
+		return !hasAncestor(n, atom.Article, atom.Main)
+	case atom.Main, atom.Article, atom.Body:
+		return false
+	}
+	if boilerplateRoles[attr(n, "role")] {
+		return true
+	}
+	for _, name := range []string{attr(n, "class"), attr(n, "id")} {
+		words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+			return r == ' ' || r == '-' || r == '_' || r == '\t'
+		})
+		for _, word := range words {
+			if boilerplateNames[word] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasAncestor(n *html.Node, atoms ...atom.Atom) bool {
+	for parent := n.Parent; parent != nil; parent = parent.Parent {
+		for _, a := range atoms {
+			if parent.DataAtom == a {
+				return true
+			}
+		}
+	}
+	return false
+}
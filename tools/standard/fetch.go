@@ -0,0 +1,122 @@
+package standard
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultUserAgent is the User-Agent header of the network tools
+const DefaultUserAgent = "easyagent-standard-tools/1.0"
+
+// Fetcher fetches URLs for the network tools, bounding the content read
+type Fetcher struct {
+	// Client sends the requests, NewSafeHTTPClient blocks internal addresses
+	Client *http.Client
+
+	// UserAgent is sent with every request
+	UserAgent string
+
+	// MaxResponseBytes bounds the body read, the rest is discarded
+	MaxResponseBytes int
+
+	// RespectRobots checks the robots.txt of sites before fetching their pages
+	RespectRobots bool
+
+	robots robotsCache
+}
+
+// FetchResult is a fetched response
+type FetchResult struct {
+	// URL is the final URL, after redirects
+	URL string
+
+	Status      int
+	ContentType string
+	Body        []byte
+
+	// Truncated is set when the body was longer than MaxResponseBytes
+	Truncated bool
+}
+
+// Fetch sends a GET request to rawURL with the Accept header accept
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string, accept string) (*FetchResult, error) {
+	target, err := parseHTTPURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if f.RespectRobots {
+		allowed, err := f.robots.allowed(ctx, f.Client, f.UserAgent, target)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, fmt.Errorf("fetching %s is %w", target, ErrDisallowedByRobots)
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", f.UserAgent)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", target, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, int64(f.MaxResponseBytes)+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", target, err)
+	}
+	result := &FetchResult{
+		URL:         resp.Request.URL.String(),
+		Status:      resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        body,
+	}
+	if len(body) > f.MaxResponseBytes {
+		result.Body = body[:f.MaxResponseBytes]
+		result.Truncated = true
+	}
+	return result, nil
+}
+
+// parseHTTPURL parses an absolute http or https URL
+func parseHTTPURL(rawURL string) (*url.URL, error) {
+	target, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, fmt.Errorf("invalid url '%s': %w", rawURL, err)
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return nil, fmt.Errorf("invalid url '%s', only http and https are supported", rawURL)
+	}
+	if target.Host == "" {
+		return nil, fmt.Errorf("invalid url '%s', host is required", rawURL)
+	}
+	return target, nil
+}
+
+// mediaType returns the media type of a Content-Type header, in lower case
+func mediaType(contentType string) string {
+	media, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	}
+	return media
+}
+
+// isText reports whether a media type holds text the model can read
+func isText(media string) bool {
+	return strings.HasPrefix(media, "text/") || media == "" ||
+		strings.HasSuffix(media, "json") || strings.HasSuffix(media, "xml") ||
+		media == "application/javascript" || media == "application/x-www-form-urlencoded"
+}
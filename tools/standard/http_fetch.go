@@ -0,0 +1,88 @@
+package standard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/easyagent-dev/agent"
+	"github.com/easyagent-dev/llm"
+)
+
+// HTTPFetchToolName is the name of the http_fetch tool
+const HTTPFetchToolName = "http_fetch"
+
+// HTTPFetchInput is the input of the http_fetch tool
+type HTTPFetchInput struct {
+	URL string `json:"url" jsonschema:"required,description=Absolute http or https URL to GET"`
+}
+
+// HTTPFetchTool fetches a URL with GET and returns its status, content type and text body,
+// e.g. to call a JSON API. Binary bodies are not returned.
+type HTTPFetchTool struct {
+	fetcher *Fetcher
+}
+
+var (
+	_ agent.ModelTool  = (*HTTPFetchTool)(nil)
+	_ agent.EffectTool = (*HTTPFetchTool)(nil)
+)
+
+// NewHTTPFetchTool creates an http_fetch tool using fetcher
+func NewHTTPFetchTool(fetcher *Fetcher) *HTTPFetchTool {
+	return &HTTPFetchTool{fetcher: fetcher}
+}
+
+// Name returns the name of the tool
+func (t *HTTPFetchTool) Name() string {
+	return HTTPFetchToolName
+}
+
+// Description returns a description of what the tool does
+func (t *HTTPFetchTool) Description() string {
+	return "Sends a GET request to a URL and returns the status, content type and body as text. Use read_url_as_markdown to read web pages."
+}
+
+// InputSchema returns the input schema of the tool
+func (t *HTTPFetchTool) InputSchema() any {
+	return llm.GenerateSchema[HTTPFetchInput]()
+}
+
+// OutputSchema returns the output schema of the tool
+func (t *HTTPFetchTool) OutputSchema() any {
+	return nil
+}
+
+// Usage returns an example of how to use the tool
+func (t *HTTPFetchTool) Usage() string {
+	return `{"url":"https://api.github.com/repos/golang/go"}`
+}
+
+// Effect returns agent.ToolEffectReadOnly, GET requests have no side effects
+func (t *HTTPFetchTool) Effect() agent.ToolEffect {
+	return agent.ToolEffectReadOnly
+}
+
+// Run fetches the URL
+func (t *HTTPFetchTool) Run(ctx context.Context, input map[string]any) (any, error) {
+	rawURL, _ := input["url"].(string)
+	if rawURL == "" {
+		return nil, errors.New("url is required")
+	}
+	result, err := t.fetcher.Fetch(ctx, rawURL, "")
+	if err != nil {
+		return nil, err
+	}
+	media := mediaType(result.ContentType)
+	if !isText(media) {
+		return nil, fmt.Errorf("unsupported content type '%s', only text is returned", media)
+	}
+	return map[string]any{
+		"url":          result.URL,
+		"status":       result.Status,
+		"content_type": result.ContentType,
+		"body":         strings.ToValidUTF8(string(result.Body), ""),
+		"truncated":    result.Truncated,
+	}, nil
+}
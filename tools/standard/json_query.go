@@ -0,0 +1,128 @@
+package standard
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/easyagent-dev/agent"
+	"github.com/easyagent-dev/llm"
+)
+
+// JSONQueryToolName is the name of the json_query tool
+const JSONQueryToolName = "json_query"
+
+// JSONQueryInput is the input of the json_query tool
+type JSONQueryInput struct {
+	Ref  string `json:"ref,omitempty" jsonschema:"description=Reference of a stored tool output to query"`
+	JSON string `json:"json,omitempty" jsonschema:"description=JSON document to query\\, when there is no ref"`
+	Path string `json:"path" jsonschema:"required,description=JSONPath expression e.g. $.items[?(@.price < 10)].name"`
+}
+
+// JSONQueryTool extracts values from a JSON document with a JSONPath expression, so a model
+// can pick fields out of a large API response instead of reading it all. The document is given
+// inline, or by the reference of a tool output stored by agent.WithOutputReferences.
+type JSONQueryTool struct{}
+
+var (
+	_ agent.ModelTool  = (*JSONQueryTool)(nil)
+	_ agent.EffectTool = (*JSONQueryTool)(nil)
+)
+
+// NewJSONQueryTool creates a json_query tool
+func NewJSONQueryTool() *JSONQueryTool {
+	return &JSONQueryTool{}
+}
+
+// Name returns the name of the tool
+func (t *JSONQueryTool) Name() string {
+	return JSONQueryToolName
+}
+
+// Description returns a description of what the tool does
+func (t *JSONQueryTool) Description() string {
+	return "Extracts values from a JSON document, or from a stored tool output by its ref, with a JSONPath expression. Supports .name, ['name'], [0], [-1], [*], .., [start:end], [0,2] and filters like [?(@.price < 10 && @.inStock)]."
+}
+
+// InputSchema returns the input schema of the tool
+func (t *JSONQueryTool) InputSchema() any {
+	return llm.GenerateSchema[JSONQueryInput]()
+}
+
+// OutputSchema returns the output schema of the tool
+func (t *JSONQueryTool) OutputSchema() any {
+	return nil
+}
+
+// Usage returns an example of how to use the tool
+func (t *JSONQueryTool) Usage() string {
+	return `{"ref":"search_1a2b3c4d","path":"$.items[?(@.price < 10)].name"}`
+}
+
+// Effect returns agent.ToolEffectReadOnly, queries have no side effects
+func (t *JSONQueryTool) Effect() agent.ToolEffect {
+	return agent.ToolEffectReadOnly
+}
+
+// Run evaluates the path against the document
+func (t *JSONQueryTool) Run(ctx context.Context, input map[string]any) (any, error) {
+	ref, _ := input["ref"].(string)
+	document, _ := input["json"].(string)
+	path, _ := input["path"].(string)
+	if strings.TrimSpace(path) == "" {
+		return nil, errors.New("path is required")
+	}
+	var value any
+	switch {
+	case ref != "":
+		stored, ok := agent.OutputReferenceOf(ctx, ref)
+		if !ok {
+			return nil, fmt.Errorf("no stored output with ref '%s'", ref)
+		}
+		var err error
+		if value, err = normalizeJSON(stored); err != nil {
+			return nil, fmt.Errorf("stored output '%s' is not JSON: %w", ref, err)
+		}
+	case strings.TrimSpace(document) != "":
+		if err := json.Unmarshal([]byte(document), &value); err != nil {
+			return nil, fmt.Errorf("invalid JSON document: %w", err)
+		}
+	default:
+		return nil, errors.New("ref or json is required")
+	}
+	matches, err := QueryJSON(value, path)
+	if err != nil {
+		return nil, err
+	}
+	if matches == nil {
+		matches = []any{}
+	}
+	return map[string]any{
+		"matches": matches,
+		"count":   len(matches),
+	}, nil
+}
+
+// normalizeJSON returns value with the types of decoded JSON, so Go values stored in a session
+// can be queried. JSON text is decoded.
+func normalizeJSON(value any) (any, error) {
+	switch value.(type) {
+	case map[string]any, []any:
+		return value, nil
+	}
+	data, ok := value.(string)
+	if !ok {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		data = string(encoded)
+	}
+	var decoded any
+	if err := json.Unmarshal([]byte(data), &decoded); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
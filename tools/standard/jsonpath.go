@@ -0,0 +1,675 @@
+package standard
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxQueryResults bounds the values a JSONPath query returns, recursive descents can match a lot
+const maxQueryResults = 10000
+
+// QueryJSON returns the values of document matching a JSONPath expression, in document order.
+// The expression supports child (.name, ['name']), wildcard (*), recursive descent (..),
+// index and negative index ([0], [-1]), slice ([start:end:step]), union ([0,2], ['a','b']) and
+// filter ([?(@.price < 10 && @.tags)]) selectors. The leading $ may be omitted.
+func QueryJSON(document any, path string) ([]any, error) {
+	segments, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	nodes := []any{document}
+	for _, segment := range segments {
+		var next []any
+		for _, node := range nodes {
+			if segment.recursive {
+				for _, descendant := range descendants(node) {
+					next = segment.apply(descendant, next)
+				}
+			} else {
+				next = segment.apply(node, next)
+			}
+			if len(next) > maxQueryResults {
+				return nil, fmt.Errorf("query matches more than %d values, narrow it down", maxQueryResults)
+			}
+		}
+		nodes = next
+	}
+	return nodes, nil
+}
+
+// pathSegment selects children of a node
+type pathSegment struct {
+	recursive bool
+	selectors []pathSelector
+}
+
+// apply appends the children of node matching the selectors to out
+func (s pathSegment) apply(node any, out []any) []any {
+	for _, selector := range s.selectors {
+		out = selector.apply(node, out)
+	}
+	return out
+}
+
+// pathSelector is a selector of a segment, only the fields of its kind are set
+type pathSelector struct {
+	kind   selectorKind
+	name   string
+	index  int
+	slice  [3]*int
+	filter filterExpr
+}
+
+type selectorKind int
+
+const (
+	selectName selectorKind = iota
+	selectWildcard
+	selectIndex
+	selectSlice
+	selectFilter
+)
+
+func (s pathSelector) apply(node any, out []any) []any {
+	switch s.kind {
+	case selectName:
+		if object, ok := node.(map[string]any); ok {
+			if value, ok := object[s.name]; ok {
+				out = append(out, value)
+			}
+		}
+	case selectWildcard:
+		out = append(out, children(node)...)
+	case selectIndex:
+		if array, ok := node.([]any); ok {
+			i := s.index
+			if i < 0 {
+				i += len(array)
+			}
+			if i >= 0 && i < len(array) {
+				out = append(out, array[i])
+			}
+		}
+	case selectSlice:
+		if array, ok := node.([]any); ok {
+			out = appendSlice(array, s.slice, out)
+		}
+	case selectFilter:
+		for _, child := range children(node) {
+			if s.filter.eval(child).truthy() {
+				out = append(out, child)
+			}
+		}
+	}
+	return out
+}
+
+// appendSlice appends array[start:end:step] to out, with Python semantics for negative bounds and steps
+func appendSlice(array []any, bounds [3]*int, out []any) []any {
+	n := len(array)
+	step := 1
+	if bounds[2] != nil {
+		step = *bounds[2]
+	}
+	if step == 0 {
+		return out
+	}
+	normalize := func(i int) int {
+		if i < 0 {
+			i += n
+		}
+		return i
+	}
+	if step > 0 {
+		start, end := 0, n
+		if bounds[0] != nil {
+			start = min(max(normalize(*bounds[0]), 0), n)
+		}
+		if bounds[1] != nil {
+			end = min(max(normalize(*bounds[1]), 0), n)
+		}
+		for i := start; i < end; i += step {
+			out = append(out, array[i])
+		}
+		return out
+	}
+	start, end := n-1, -1
+	if bounds[0] != nil {
+		start = min(max(normalize(*bounds[0]), -1), n-1)
+	}
+	if bounds[1] != nil {
+		end = min(max(normalize(*bounds[1]), -1), n-1)
+	}
+	for i := start; i > end; i += step {
+		out = append(out, array[i])
+	}
+	return out
+}
+
+// children returns the values of an object, sorted by key, or the items of an array
+func children(node any) []any {
+	switch v := node.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		values := make([]any, len(keys))
+		for i, key := range keys {
+			values[i] = v[key]
+		}
+		return values
+	case []any:
+		return v
+	}
+	return nil
+}
+
+// descendants returns node and all the values nested in it, depth first
+func descendants(node any) []any {
+	out := []any{node}
+	for _, child := range children(node) {
+		out = append(out, descendants(child)...)
+	}
+	return out
+}
+
+// pathParser parses JSONPath expressions
+type pathParser struct {
+	input string
+	pos   int
+}
+
+func parseJSONPath(path string) ([]pathSegment, error) {
+	input := strings.TrimSpace(path)
+	switch {
+	case strings.HasPrefix(input, "$"):
+		input = input[1:]
+	case input != "" && input[0] != '.' && input[0] != '[':
+		// Models often omit the root, e.g. items[0].name
+		input = "." + input
+	}
+	p := &pathParser{input: input}
+	segments, err := p.segments(false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSONPath '%s': %w", path, err)
+	}
+	return segments, nil
+}
+
+func (p *pathParser) peek() byte {
+	if p.pos >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *pathParser) skipSpaces() {
+	for p.pos < len(p.input) && (p.input[p.pos] == ' ' || p.input[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// segments parses segments until the end of the input, or until a token ending a relative
+// path of a filter when relative is set
+func (p *pathParser) segments(relative bool) ([]pathSegment, error) {
+	var segments []pathSegment
+	for p.pos < len(p.input) {
+		switch p.peek() {
+		case '.':
+			p.pos++
+			recursive := false
+			if p.peek() == '.' {
+				p.pos++
+				recursive = true
+			}
+			if p.peek() == '[' {
+				if !recursive {
+					return nil, fmt.Errorf("unexpected '[' at position %d", p.pos+1)
+				}
+				selectors, err := p.bracket()
+				if err != nil {
+					return nil, err
+				}
+				segments = append(segments, pathSegment{recursive: true, selectors: selectors})
+				continue
+			}
+			selector, err := p.dotSelector()
+			if err != nil {
+				return nil, err
+			}
+			segments = append(segments, pathSegment{recursive: recursive, selectors: []pathSelector{selector}})
+		case '[':
+			selectors, err := p.bracket()
+			if err != nil {
+				return nil, err
+			}
+			segments = append(segments, pathSegment{selectors: selectors})
+		default:
+			if relative {
+				return segments, nil
+			}
+			return nil, fmt.Errorf("unexpected '%c' at position %d", p.peek(), p.pos+1)
+		}
+	}
+	return segments, nil
+}
+
+// dotSelector parses the name or wildcard after a dot
+func (p *pathParser) dotSelector() (pathSelector, error) {
+	if p.peek() == '*' {
+		p.pos++
+		return pathSelector{kind: selectWildcard}, nil
+	}
+	start := p.pos
+	for p.pos < len(p.input) && isNameChar(p.input[p.pos]) {
+		p.pos++
+	}
+	if start == p.pos {
+		return pathSelector{}, fmt.Errorf("expected a name at position %d", p.pos+1)
+	}
+	return pathSelector{kind: selectName, name: p.input[start:p.pos]}, nil
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || c == '-' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// bracket parses a bracketed list of selectors
+func (p *pathParser) bracket() ([]pathSelector, error) {
+	p.pos++ // [
+	var selectors []pathSelector
+	for {
+		p.skipSpaces()
+		selector, err := p.bracketSelector()
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, selector)
+		p.skipSpaces()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return selectors, nil
+		default:
+			return nil, fmt.Errorf("expected ',' or ']' at position %d", p.pos+1)
+		}
+	}
+}
+
+func (p *pathParser) bracketSelector() (pathSelector, error) {
+	switch c := p.peek(); {
+	case c == '*':
+		p.pos++
+		return pathSelector{kind: selectWildcard}, nil
+	case c == '\'' || c == '"':
+		name, err := p.quoted()
+		if err != nil {
+			return pathSelector{}, err
+		}
+		return pathSelector{kind: selectName, name: name}, nil
+	case c == '?':
+		p.pos++
+		p.skipSpaces()
+		parenthesized := p.peek() == '('
+		if parenthesized {
+			p.pos++
+		}
+		filter, err := p.orExpr()
+		if err != nil {
+			return pathSelector{}, err
+		}
+		p.skipSpaces()
+		if parenthesized {
+			if p.peek() != ')' {
+				return pathSelector{}, fmt.Errorf("expected ')' at position %d", p.pos+1)
+			}
+			p.pos++
+		}
+		return pathSelector{kind: selectFilter, filter: filter}, nil
+	}
+
+	// An index or a slice
+	var bounds [3]*int
+	part := 0
+	for {
+		p.skipSpaces()
+		if n, ok := p.integer(); ok {
+			bounds[part] = &n
+		}
+		p.skipSpaces()
+		if p.peek() != ':' {
+			break
+		}
+		p.pos++
+		part++
+		if part > 2 {
+			return pathSelector{}, fmt.Errorf("unexpected ':' at position %d", p.pos)
+		}
+	}
+	if part == 0 {
+		if bounds[0] == nil {
+			return pathSelector{}, fmt.Errorf("expected a selector at position %d", p.pos+1)
+		}
+		return pathSelector{kind: selectIndex, index: *bounds[0]}, nil
+	}
+	return pathSelector{kind: selectSlice, slice: bounds}, nil
+}
+
+// integer parses an optionally signed integer
+func (p *pathParser) integer() (int, bool) {
+	start := p.pos
+	if p.peek() == '-' || p.peek() == '+' {
+		p.pos++
+	}
+	for p.pos < len(p.input) && p.input[p.pos] >= '0' && p.input[p.pos] <= '9' {
+		p.pos++
+	}
+	n, err := strconv.Atoi(p.input[start:p.pos])
+	if err != nil {
+		p.pos = start
+		return 0, false
+	}
+	return n, true
+}
+
+// quoted parses a single or double quoted string with backslash escapes
+func (p *pathParser) quoted() (string, error) {
+	quote := p.input[p.pos]
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		p.pos++
+		switch {
+		case c == quote:
+			return b.String(), nil
+		case c == '\\' && p.pos < len(p.input):
+			b.WriteByte(p.input[p.pos])
+			p.pos++
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", errors.New("unterminated string")
+}
+
+// filterExpr is an expression of a filter selector, evaluated against the current node @
+type filterExpr interface {
+	eval(current any) filterValue
+}
+
+// filterValue is the result of a filter expression. Paths matching nothing are missing.
+type filterValue struct {
+	value   any
+	missing bool
+}
+
+func (v filterValue) truthy() bool {
+	if v.missing {
+		return false
+	}
+	switch value := v.value.(type) {
+	case bool:
+		return value
+	case nil:
+		// An existing null member exists
+		return true
+	}
+	return true
+}
+
+type literalExpr struct{ value any }
+
+func (e literalExpr) eval(any) filterValue { return filterValue{value: e.value} }
+
+type pathExpr struct{ segments []pathSegment }
+
+func (e pathExpr) eval(current any) filterValue {
+	nodes := []any{current}
+	for _, segment := range e.segments {
+		var next []any
+		for _, node := range nodes {
+			if segment.recursive {
+				for _, descendant := range descendants(node) {
+					next = segment.apply(descendant, next)
+				}
+			} else {
+				next = segment.apply(node, next)
+			}
+		}
+		nodes = next
+	}
+	if len(nodes) == 0 {
+		return filterValue{missing: true}
+	}
+	return filterValue{value: nodes[0]}
+}
+
+type notExpr struct{ operand filterExpr }
+
+func (e notExpr) eval(current any) filterValue {
+	return filterValue{value: !e.operand.eval(current).truthy()}
+}
+
+type logicalExpr struct {
+	and         bool
+	left, right filterExpr
+}
+
+func (e logicalExpr) eval(current any) filterValue {
+	left := e.left.eval(current).truthy()
+	if e.and {
+		return filterValue{value: left && e.right.eval(current).truthy()}
+	}
+	return filterValue{value: left || e.right.eval(current).truthy()}
+}
+
+type compareExpr struct {
+	op          string
+	left, right filterExpr
+}
+
+func (e compareExpr) eval(current any) filterValue {
+	left, right := e.left.eval(current), e.right.eval(current)
+	if left.missing || right.missing {
+		return filterValue{value: e.op == "!=" && left.missing != right.missing}
+	}
+	switch e.op {
+	case "==":
+		return filterValue{value: jsonEqual(left.value, right.value)}
+	case "!=":
+		return filterValue{value: !jsonEqual(left.value, right.value)}
+	}
+	cmp, ok := compareValues(left.value, right.value)
+	if !ok {
+		return filterValue{value: false}
+	}
+	switch e.op {
+	case "<":
+		return filterValue{value: cmp < 0}
+	case "<=":
+		return filterValue{value: cmp <= 0}
+	case ">":
+		return filterValue{value: cmp > 0}
+	default:
+		return filterValue{value: cmp >= 0}
+	}
+}
+
+// jsonEqual compares decoded JSON values, numbers by value whatever their Go type
+func jsonEqual(a, b any) bool {
+	if x, ok := toFloat(a); ok {
+		y, ok := toFloat(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// compareValues orders two numbers or two strings
+func compareValues(a, b any) (int, bool) {
+	if x, ok := toFloat(a); ok {
+		y, ok := toFloat(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	}
+	x, ok := a.(string)
+	y, ok2 := b.(string)
+	if !ok || !ok2 {
+		return 0, false
+	}
+	return strings.Compare(x, y), true
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func (p *pathParser) orExpr() (filterExpr, error) {
+	left, err := p.andExpr()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpaces()
+		if !strings.HasPrefix(p.input[p.pos:], "||") {
+			return left, nil
+		}
+		p.pos += 2
+		right, err := p.andExpr()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalExpr{left: left, right: right}
+	}
+}
+
+func (p *pathParser) andExpr() (filterExpr, error) {
+	left, err := p.unaryExpr()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpaces()
+		if !strings.HasPrefix(p.input[p.pos:], "&&") {
+			return left, nil
+		}
+		p.pos += 2
+		right, err := p.unaryExpr()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalExpr{and: true, left: left, right: right}
+	}
+}
+
+func (p *pathParser) unaryExpr() (filterExpr, error) {
+	p.skipSpaces()
+	switch p.peek() {
+	case '!':
+		if !strings.HasPrefix(p.input[p.pos:], "!=") {
+			p.pos++
+			operand, err := p.unaryExpr()
+			if err != nil {
+				return nil, err
+			}
+			return notExpr{operand: operand}, nil
+		}
+	case '(':
+		p.pos++
+		expr, err := p.orExpr()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpaces()
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("expected ')' at position %d", p.pos+1)
+		}
+		p.pos++
+		return expr, nil
+	}
+
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if strings.HasPrefix(p.input[p.pos:], op) {
+			p.pos += len(op)
+			p.skipSpaces()
+			right, err := p.operand()
+			if err != nil {
+				return nil, err
+			}
+			return compareExpr{op: op, left: left, right: right}, nil
+		}
+	}
+	return left, nil
+}
+
+// operand parses a relative path, a string, a number or true, false and null
+func (p *pathParser) operand() (filterExpr, error) {
+	p.skipSpaces()
+	switch c := p.peek(); {
+	case c == '@':
+		p.pos++
+		segments, err := p.segments(true)
+		if err != nil {
+			return nil, err
+		}
+		return pathExpr{segments: segments}, nil
+	case c == '\'' || c == '"':
+		s, err := p.quoted()
+		if err != nil {
+			return nil, err
+		}
+		return literalExpr{value: s}, nil
+	case c == '-' || c >= '0' && c <= '9':
+		start := p.pos
+		p.pos++
+		for p.pos < len(p.input) && strings.IndexByte("0123456789.eE+-", p.input[p.pos]) >= 0 {
+			p.pos++
+		}
+		n, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%s' at position %d", p.input[start:p.pos], start+1)
+		}
+		return literalExpr{value: n}, nil
+	}
+	for _, keyword := range []struct {
+		text  string
+		value any
+	}{{"true", true}, {"false", false}, {"null", nil}} {
+		if strings.HasPrefix(p.input[p.pos:], keyword.text) {
+			p.pos += len(keyword.text)
+			return literalExpr{value: keyword.value}, nil
+		}
+	}
+	return nil, fmt.Errorf("expected a value at position %d", p.pos+1)
+}
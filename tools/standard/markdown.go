@@ -0,0 +1,384 @@
+package standard
+
+import (
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// HTMLToMarkdown converts an HTML document to Markdown, returning its title and content.
+// Scripts, styles and other non-content elements are dropped, and links and images are resolved
+// against base when it is not nil.
+func HTMLToMarkdown(r io.Reader, base *url.URL) (title string, markdown string, err error) {
+	return convertHTML(r, base, false)
+}
+
+// convertHTML converts an HTML document to Markdown, only its main content when extract is set
+func convertHTML(r io.Reader, base *url.URL, extract bool) (title string, markdown string, err error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return "", "", err
+	}
+	c := &markdownConverter{base: base, extract: extract}
+	if node := findElement(doc, atom.Title); node != nil {
+		title = strings.TrimSpace(collapseSpaces(textContent(node)))
+	}
+	root := doc
+	if body := findElement(doc, atom.Body); body != nil {
+		root = body
+	}
+	if extract {
+		root = mainContent(root)
+	}
+	return title, normalizeMarkdown(c.children(root)), nil
+}
+
+// skippedElements hold no readable content
+var skippedElements = map[atom.Atom]bool{
+	atom.Head:     true,
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Svg:      true,
+	atom.Canvas:   true,
+	atom.Iframe:   true,
+	atom.Object:   true,
+	atom.Button:   true,
+	atom.Select:   true,
+	atom.Input:    true,
+	atom.Textarea: true,
+}
+
+// markdownConverter renders HTML nodes as Markdown
+type markdownConverter struct {
+	base *url.URL
+
+	// extract drops boilerplate such as navigation, banners and sidebars
+	extract bool
+}
+
+// children renders the children of n
+func (c *markdownConverter) children(n *html.Node) string {
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		b.WriteString(c.node(child))
+	}
+	return b.String()
+}
+
+// node renders n. Blocks are surrounded by blank lines, which normalizeMarkdown collapses.
+func (c *markdownConverter) node(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return escapeMarkdown(collapseSpaces(n.Data))
+	case html.ElementNode:
+	default:
+		return c.children(n)
+	}
+	if skippedElements[n.DataAtom] || hasAttr(n, "hidden") || attr(n, "aria-hidden") == "true" {
+		return ""
+	}
+	if c.extract && isBoilerplate(n) {
+		return ""
+	}
+
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level := int(n.Data[1] - '0')
+		text := strings.TrimSpace(singleLine(c.children(n)))
+		if text == "" {
+			return ""
+		}
+		return "\n\n" + strings.Repeat("#", level) + " " + text + "\n\n"
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Header, atom.Footer,
+		atom.Aside, atom.Nav, atom.Figure, atom.Figcaption, atom.Dl, atom.Dt, atom.Address, atom.Details, atom.Summary:
+		return "\n\n" + c.children(n) + "\n\n"
+	case atom.Dd:
+		return "\n\n" + indent(strings.TrimSpace(c.children(n)), "  ") + "\n\n"
+	case atom.Br:
+		return "  \n"
+	case atom.Hr:
+		return "\n\n---\n\n"
+	case atom.Strong, atom.B:
+		return wrapInline(c.children(n), "**")
+	case atom.Em, atom.I:
+		return wrapInline(c.children(n), "*")
+	case atom.Del, atom.S:
+		return wrapInline(c.children(n), "~~")
+	case atom.Code, atom.Kbd, atom.Samp:
+		text := collapseSpaces(textContent(n))
+		if strings.TrimSpace(text) == "" {
+			return text
+		}
+		fence := "`"
+		if strings.Contains(text, "`") {
+			fence = "``"
+		}
+		return fence + text + fence
+	case atom.Pre:
+		text := strings.Trim(textContent(n), "\n")
+		fence := "```"
+		for strings.Contains(text, fence) {
+			fence += "`"
+		}
+		return "\n\n" + fence + codeLanguage(n) + "\n" + text + "\n" + fence + "\n\n"
+	case atom.A:
+		text := strings.TrimSpace(singleLine(c.children(n)))
+		href := c.resolve(attr(n, "href"))
+		if href == "" || strings.HasPrefix(href, "#") {
+			return text
+		}
+		if text == "" {
+			return ""
+		}
+		return "[" + text + "](" + href + ")"
+	case atom.Img:
+		alt := strings.TrimSpace(collapseSpaces(attr(n, "alt")))
+		src := c.resolve(attr(n, "src"))
+		if alt == "" || src == "" || strings.HasPrefix(src, "data:") {
+			return ""
+		}
+		return "![" + escapeMarkdown(alt) + "](" + src + ")"
+	case atom.Ul, atom.Ol:
+		return "\n\n" + c.list(n) + "\n\n"
+	case atom.Li:
+		// A list item outside of a list
+		return "\n\n- " + strings.TrimSpace(c.children(n)) + "\n\n"
+	case atom.Blockquote:
+		content := normalizeMarkdown(c.children(n))
+		if content == "" {
+			return ""
+		}
+		return "\n\n" + indent(content, "> ") + "\n\n"
+	case atom.Table:
+		return "\n\n" + c.table(n) + "\n\n"
+	}
+	return c.children(n)
+}
+
+// list renders the items of a ul or ol element, nested lists being indented under their item
+func (c *markdownConverter) list(n *html.Node) string {
+	ordered := n.DataAtom == atom.Ol
+	number := 1
+	if start, err := strconv.Atoi(attr(n, "start")); ordered && err == nil {
+		number = start
+	}
+	var items []string
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || child.DataAtom != atom.Li {
+			continue
+		}
+		marker := "- "
+		if ordered {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+		content := normalizeMarkdown(c.children(child))
+		items = append(items, marker+indent(content, strings.Repeat(" ", len(marker)))[len(marker):])
+	}
+	return strings.Join(items, "\n")
+}
+
+// table renders a table as a Markdown table, its first row being the header
+func (c *markdownConverter) table(n *html.Node) string {
+	var rows [][]string
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			switch child.DataAtom {
+			case atom.Tr:
+				var cells []string
+				for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+						text := strings.TrimSpace(singleLine(c.children(cell)))
+						cells = append(cells, strings.ReplaceAll(text, "|", "\\|"))
+					}
+				}
+				if len(cells) > 0 {
+					rows = append(rows, cells)
+				}
+			case atom.Table:
+				// Nested tables are flattened into the cell text
+			default:
+				walk(child)
+			}
+		}
+	}
+	walk(n)
+	if len(rows) == 0 {
+		return ""
+	}
+
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	var b strings.Builder
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			b.WriteString("|" + strings.Repeat(" --- |", columns) + "\n")
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// resolve returns ref resolved against the base URL, empty for script links
+func (c *markdownConverter) resolve(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(strings.ToLower(ref), "javascript:") {
+		return ""
+	}
+	if c.base == nil {
+		return ref
+	}
+	parsed, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return c.base.ResolveReference(parsed).String()
+}
+
+// codeLanguage returns the language of a pre element from a language-* class of it or its code child
+func codeLanguage(n *html.Node) string {
+	for _, node := range []*html.Node{n, n.FirstChild} {
+		if node == nil || node.Type != html.ElementNode {
+			continue
+		}
+		for _, class := range strings.Fields(attr(node, "class")) {
+			if language, ok := strings.CutPrefix(class, "language-"); ok {
+				return language
+			}
+		}
+	}
+	return ""
+}
+
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if found := findElement(child, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		b.WriteString(textContent(child))
+	}
+	return b.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+var spaces = regexp.MustCompile(`\s+`)
+
+// collapseSpaces collapses runs of whitespace into a single space, as browsers render them
+func collapseSpaces(text string) string {
+	return spaces.ReplaceAllString(text, " ")
+}
+
+// singleLine joins the lines of rendered Markdown
+func singleLine(text string) string {
+	return collapseSpaces(strings.ReplaceAll(text, "  \n", " "))
+}
+
+// markdownSpecial matches the characters starting Markdown syntax within text
+var markdownSpecial = regexp.MustCompile("([\\\\`*_\\[\\]])")
+
+func escapeMarkdown(text string) string {
+	return markdownSpecial.ReplaceAllString(text, "\\$1")
+}
+
+// wrapInline wraps inline content with a delimiter, keeping surrounding spaces outside of it
+func wrapInline(content, delimiter string) string {
+	trimmed := strings.TrimSpace(content)
+	if trimmed == "" {
+		return content
+	}
+	start := content[:strings.Index(content, trimmed)]
+	end := content[len(start)+len(trimmed):]
+	return start + delimiter + trimmed + delimiter + end
+}
+
+func indent(text, prefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line != "" || prefix == "> " {
+			lines[i] = strings.TrimRight(prefix+line, " ")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+// normalizeMarkdown trims the lines outside of code blocks and collapses blank lines
+func normalizeMarkdown(markdown string) string {
+	lines := strings.Split(markdown, "\n")
+	inCode := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inCode = !inCode
+			lines[i] = trimmed
+			continue
+		}
+		if inCode {
+			continue
+		}
+		// Keep hard line breaks and the indentation of nested list items
+		hardBreak := strings.HasSuffix(line, "  ") && trimmed != ""
+		line = strings.TrimRight(line, " \t")
+		if strings.TrimLeft(line, " ") != line && !isIndented(line) {
+			line = strings.TrimLeft(line, " ")
+		}
+		if hardBreak {
+			line += "  "
+		}
+		lines[i] = line
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+// isIndented reports whether a line is the indented continuation of a list item or quote
+func isIndented(line string) bool {
+	trimmed := strings.TrimLeft(line, " ")
+	return len(line)-len(trimmed) >= 2 && trimmed != ""
+}
@@ -0,0 +1,183 @@
+package standard
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/easyagent-dev/agent"
+	"github.com/easyagent-dev/llm"
+)
+
+// ReadURLToolName is the name of the read_url_as_markdown tool
+const ReadURLToolName = "read_url_as_markdown"
+
+// DefaultMaxTokens is the default token budget of the content read_url_as_markdown returns
+const DefaultMaxTokens = 4000
+
+// ReadURLInput is the input of the read_url_as_markdown tool
+type ReadURLInput struct {
+	URL       string `json:"url" jsonschema:"required,description=Absolute http or https URL of the page to read"`
+	MaxTokens int    `json:"max_tokens,omitempty" jsonschema:"description=Token budget of the returned content\\, lower it to skim a page"`
+}
+
+// ReadURLOption is a functional option for configuring read_url_as_markdown tools
+type ReadURLOption func(*ReadURLTool)
+
+// WithMaxTokens sets the token budget of the returned content, DefaultMaxTokens by default.
+// The model can ask for less, not more.
+func WithMaxTokens(maxTokens int) ReadURLOption {
+	return func(t *ReadURLTool) {
+		t.maxTokens = maxTokens
+	}
+}
+
+// WithTokenizer sets the tokenizer counting content tokens, agent.EstimateTokenizer by default
+func WithTokenizer(tokenizer agent.Tokenizer) ReadURLOption {
+	return func(t *ReadURLTool) {
+		t.tokenizer = tokenizer
+	}
+}
+
+// WithFullPage converts whole pages, navigation and other boilerplate included
+func WithFullPage() ReadURLOption {
+	return func(t *ReadURLTool) {
+		t.fullPage = true
+	}
+}
+
+// ReadURLTool fetches a web page and returns its main content as Markdown, which takes far fewer
+// tokens than HTML, truncated to a token budget. Text responses other than HTML are returned as is.
+type ReadURLTool struct {
+	fetcher   *Fetcher
+	maxTokens int
+	tokenizer agent.Tokenizer
+	fullPage  bool
+}
+
+var (
+	_ agent.ModelTool  = (*ReadURLTool)(nil)
+	_ agent.EffectTool = (*ReadURLTool)(nil)
+)
+
+// NewReadURLTool creates a read_url_as_markdown tool using fetcher
+func NewReadURLTool(fetcher *Fetcher, opts ...ReadURLOption) *ReadURLTool {
+	tool := &ReadURLTool{
+		fetcher:   fetcher,
+		maxTokens: DefaultMaxTokens,
+		tokenizer: agent.EstimateTokenizer{},
+	}
+	for _, opt := range opts {
+		opt(tool)
+	}
+	return tool
+}
+
+// Name returns the name of the tool
+func (t *ReadURLTool) Name() string {
+	return ReadURLToolName
+}
+
+// Description returns a description of what the tool does
+func (t *ReadURLTool) Description() string {
+	return "Reads a web page and returns its title and main content as Markdown, without navigation and other boilerplate"
+}
+
+// InputSchema returns the input schema of the tool
+func (t *ReadURLTool) InputSchema() any {
+	return llm.GenerateSchema[ReadURLInput]()
+}
+
+// OutputSchema returns the output schema of the tool
+func (t *ReadURLTool) OutputSchema() any {
+	return nil
+}
+
+// Usage returns an example of how to use the tool
+func (t *ReadURLTool) Usage() string {
+	return `{"url":"https://go.dev/doc/effective_go"}`
+}
+
+// Effect returns agent.ToolEffectReadOnly, reading a page has no side effects
+func (t *ReadURLTool) Effect() agent.ToolEffect {
+	return agent.ToolEffectReadOnly
+}
+
+// Run fetches the page and converts it
+func (t *ReadURLTool) Run(ctx context.Context, input map[string]any) (any, error) {
+	rawURL, _ := input["url"].(string)
+	if rawURL == "" {
+		return nil, errors.New("url is required")
+	}
+	result, err := t.fetcher.Fetch(ctx, rawURL, "text/html,application/xhtml+xml;q=0.9,text/*;q=0.8")
+	if err != nil {
+		return nil, err
+	}
+	if result.Status >= 400 {
+		return nil, fmt.Errorf("failed to read %s: status %d", result.URL, result.Status)
+	}
+
+	media := mediaType(result.ContentType)
+	var title, content string
+	switch {
+	case media == "text/html" || media == "application/xhtml+xml" || media == "":
+		base, _ := url.Parse(result.URL)
+		title, content, err = convertHTML(bytes.NewReader(result.Body), base, !t.fullPage)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert %s: %w", result.URL, err)
+		}
+	case isText(media):
+		content = strings.ToValidUTF8(string(result.Body), "")
+	default:
+		return nil, fmt.Errorf("unsupported content type '%s', only web pages and text can be read", media)
+	}
+
+	maxTokens := t.maxTokens
+	if requested, ok := input["max_tokens"].(float64); ok && requested > 0 {
+		maxTokens = min(maxTokens, int(requested))
+	}
+	content, cut := truncateTokens(content, t.tokenizer, maxTokens)
+	return map[string]any{
+		"url":       result.URL,
+		"title":     title,
+		"content":   content,
+		"truncated": result.Truncated || cut,
+	}, nil
+}
+
+// truncateTokens cuts text to maxTokens, at a paragraph boundary when one fits
+func truncateTokens(text string, tokenizer agent.Tokenizer, maxTokens int) (string, bool) {
+	if maxTokens <= 0 || tokenizer.CountTokens(text) <= maxTokens {
+		return text, false
+	}
+	paragraphs := strings.Split(text, "\n\n")
+	kept, tokens := 0, 0
+	for _, paragraph := range paragraphs {
+		// The separator of the previous paragraph counts too
+		cost := tokenizer.CountTokens(paragraph + "\n\n")
+		if tokens+cost > maxTokens {
+			break
+		}
+		kept++
+		tokens += cost
+	}
+	if kept > 0 {
+		return strings.Join(paragraphs[:kept], "\n\n"), true
+	}
+
+	// A single paragraph is over budget, keep its longest prefix within it
+	runes := []rune(text)
+	low, high := 0, len(runes)
+	for low < high {
+		mid := (low + high + 1) / 2
+		if tokenizer.CountTokens(string(runes[:mid])) <= maxTokens {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+	return string(runes[:low]), true
+}
@@ -0,0 +1,208 @@
+package standard
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrDisallowedByRobots is returned when the robots.txt of a site disallows fetching a URL
+var ErrDisallowedByRobots = errors.New("disallowed by robots.txt")
+
+const (
+	// robotsTTL is how long a robots.txt is cached
+	robotsTTL = time.Hour
+
+	// robotsErrorTTL is how long a site whose robots.txt failed with a server error is disallowed
+	robotsErrorTTL = 5 * time.Minute
+
+	// maxRobotsBytes bounds the robots.txt read, the minimum RFC 9309 asks crawlers to parse
+	maxRobotsBytes = 500 * 1024
+)
+
+// robotsCache caches the robots.txt rules of sites, by scheme and host
+type robotsCache struct {
+	mu      sync.Mutex
+	entries map[string]*robotsEntry
+}
+
+type robotsEntry struct {
+	rules   *robotsRules
+	expires time.Time
+}
+
+// allowed reports whether the robots.txt of the site of target allows agent to fetch it
+func (c *robotsCache) allowed(ctx context.Context, client *http.Client, agent string, target *url.URL) (bool, error) {
+	site := target.Scheme + "://" + target.Host
+	c.mu.Lock()
+	entry, ok := c.entries[site]
+	c.mu.Unlock()
+	if !ok || time.Now().After(entry.expires) {
+		rules, ttl, err := fetchRobots(ctx, client, agent, site)
+		if err != nil {
+			return false, err
+		}
+		entry = &robotsEntry{rules: rules, expires: time.Now().Add(ttl)}
+		c.mu.Lock()
+		if c.entries == nil {
+			c.entries = make(map[string]*robotsEntry)
+		}
+		c.entries[site] = entry
+		c.mu.Unlock()
+	}
+	path := target.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if target.RawQuery != "" {
+		path += "?" + target.RawQuery
+	}
+	return entry.rules.allowed(path), nil
+}
+
+// fetchRobots fetches and parses the robots.txt of site. As RFC 9309 asks, a missing robots.txt
+// allows everything and a server error disallows everything.
+func fetchRobots(ctx context.Context, client *http.Client, agent string, site string) (*robotsRules, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, site+"/robots.txt", nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create robots.txt request: %w", err)
+	}
+	req.Header.Set("User-Agent", agent)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch %s/robots.txt: %w", site, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return &robotsRules{disallowAll: true}, robotsErrorTTL, nil
+	case resp.StatusCode >= 400:
+		return &robotsRules{}, robotsTTL, nil
+	case resp.StatusCode >= 300:
+		// Redirects the client did not follow
+		return &robotsRules{}, robotsTTL, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRobotsBytes))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read %s/robots.txt: %w", site, err)
+	}
+	return parseRobots(body, agent), robotsTTL, nil
+}
+
+// robotsRules are the rules of a robots.txt applying to an agent
+type robotsRules struct {
+	rules       []robotsRule
+	disallowAll bool
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// parseRobots returns the rules of the groups matching the product token of agent, or of the
+// * groups when none matches
+func parseRobots(body []byte, agent string) *robotsRules {
+	token := strings.ToLower(agent)
+	if i := strings.IndexAny(token, "/ "); i >= 0 {
+		token = token[:i]
+	}
+
+	var matched, wildcard []robotsRule
+	var agents []string
+	inRules := false
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRobotsBytes)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			// A user-agent line after rules starts a new group
+			if inRules {
+				agents = nil
+				inRules = false
+			}
+			agents = append(agents, strings.ToLower(value))
+		case "allow", "disallow":
+			inRules = true
+			if value == "" {
+				// An empty disallow allows everything, which is the default
+				continue
+			}
+			rule := robotsRule{allow: key == "allow", pattern: value}
+			for _, a := range agents {
+				switch {
+				case a == "*":
+					wildcard = append(wildcard, rule)
+				case a != "" && strings.HasPrefix(token, a):
+					matched = append(matched, rule)
+				}
+			}
+		}
+	}
+	if matched != nil {
+		return &robotsRules{rules: matched}
+	}
+	return &robotsRules{rules: wildcard}
+}
+
+// allowed reports whether path, with its query, may be fetched. The longest matching rule wins,
+// allow rules winning ties.
+func (r *robotsRules) allowed(path string) bool {
+	if r.disallowAll {
+		return path == "/robots.txt"
+	}
+	allow, longest := true, -1
+	for _, rule := range r.rules {
+		if !matchRobotsPattern(rule.pattern, path) {
+			continue
+		}
+		if len(rule.pattern) > longest || len(rule.pattern) == longest && rule.allow {
+			allow, longest = rule.allow, len(rule.pattern)
+		}
+	}
+	return allow
+}
+
+// matchRobotsPattern matches a path against a robots.txt pattern, a prefix in which * matches any
+// sequence of characters and a trailing $ anchors the end of the path
+func matchRobotsPattern(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = pattern[:len(pattern)-1]
+	}
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for i, part := range parts[1:] {
+		if anchored && i == len(parts)-2 {
+			return strings.HasSuffix(rest, part)
+		}
+		j := strings.Index(rest, part)
+		if j < 0 {
+			return false
+		}
+		rest = rest[j+len(part):]
+	}
+	return !anchored || rest == ""
+}
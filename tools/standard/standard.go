@@ -0,0 +1,159 @@
+// Package standard provides a curated set of tools nearly every agent needs, giving new users a
+// batteries-included starting point: arithmetic and timezone-aware date math, which models
+// reliably get wrong on their own, HTTP fetching, reading web pages as Markdown and querying JSON.
+// The tools are opt-in, add them to an agent individually or as a bundle:
+//
+//	myAgent.Tools = append(myAgent.Tools, standard.NewStandardTools(
+//		standard.WithCapabilities(standard.DefaultCapabilities|standard.ReadURL),
+//	)...)
+package standard
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/easyagent-dev/agent"
+)
+
+// Capability flags a tool of the standard bundle
+type Capability uint
+
+const (
+	// Calculator enables the calculator tool
+	Calculator Capability = 1 << iota
+
+	// DateTime enables the datetime tool
+	DateTime
+
+	// HTTPFetch enables the http_fetch tool
+	HTTPFetch
+
+	// ReadURL enables the read_url_as_markdown tool
+	ReadURL
+
+	// JSONQuery enables the json_query tool
+	JSONQuery
+)
+
+// DefaultCapabilities are the tools without network access, enabled by default
+const DefaultCapabilities = Calculator | DateTime | JSONQuery
+
+// AllCapabilities enables every standard tool
+const AllCapabilities = Calculator | DateTime | HTTPFetch | ReadURL | JSONQuery
+
+// DefaultMaxResponseBytes bounds the content the network tools return to the model
+const DefaultMaxResponseBytes = 64 * 1024
+
+// Option is a functional option for configuring the standard tools
+type Option func(*config)
+
+type config struct {
+	capabilities     Capability
+	client           *http.Client
+	userAgent        string
+	maxResponseBytes int
+	dateTime         []DateTimeOption
+	readURL          []ReadURLOption
+	privateNetworks  bool
+	ignoreRobots     bool
+}
+
+// WithCapabilities sets the enabled tools, DefaultCapabilities by default
+func WithCapabilities(capabilities Capability) Option {
+	return func(c *config) {
+		c.capabilities = capabilities
+	}
+}
+
+// WithHTTPClient sets the client of the network tools, a NewSafeHTTPClient client with a 15 second
+// timeout by default. The client is used as is, so it is responsible for blocking internal addresses.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *config) {
+		c.client = client
+	}
+}
+
+// WithUserAgent sets the User-Agent header of the network tools
+func WithUserAgent(userAgent string) Option {
+	return func(c *config) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithMaxResponseBytes bounds the content the network tools return, DefaultMaxResponseBytes by default
+func WithMaxResponseBytes(n int) Option {
+	return func(c *config) {
+		c.maxResponseBytes = n
+	}
+}
+
+// WithDateTimeOptions sets the options of the datetime tool
+func WithDateTimeOptions(opts ...DateTimeOption) Option {
+	return func(c *config) {
+		c.dateTime = opts
+	}
+}
+
+// WithReadURLOptions sets the options of the read_url_as_markdown tool
+func WithReadURLOptions(opts ...ReadURLOption) Option {
+	return func(c *config) {
+		c.readURL = opts
+	}
+}
+
+// WithPrivateNetworks lets the default client of the network tools connect to loopback, private
+// and other internal addresses, e.g. for agents reading an intranet
+func WithPrivateNetworks() Option {
+	return func(c *config) {
+		c.privateNetworks = true
+	}
+}
+
+// WithoutRobotsTxt fetches pages whatever the robots.txt of their sites says
+func WithoutRobotsTxt() Option {
+	return func(c *config) {
+		c.ignoreRobots = true
+	}
+}
+
+// NewStandardTools returns the enabled standard tools
+func NewStandardTools(opts ...Option) []agent.ModelTool {
+	c := &config{
+		capabilities:     DefaultCapabilities,
+		userAgent:        DefaultUserAgent,
+		maxResponseBytes: DefaultMaxResponseBytes,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.client == nil {
+		c.client = NewSafeHTTPClient(15 * time.Second)
+		if c.privateNetworks {
+			c.client = &http.Client{Timeout: 15 * time.Second}
+		}
+	}
+	fetcher := &Fetcher{
+		Client:           c.client,
+		UserAgent:        c.userAgent,
+		MaxResponseBytes: c.maxResponseBytes,
+		RespectRobots:    !c.ignoreRobots,
+	}
+
+	var tools []agent.ModelTool
+	if c.capabilities&Calculator != 0 {
+		tools = append(tools, NewCalculatorTool())
+	}
+	if c.capabilities&DateTime != 0 {
+		tools = append(tools, NewDateTimeTool(c.dateTime...))
+	}
+	if c.capabilities&HTTPFetch != 0 {
+		tools = append(tools, NewHTTPFetchTool(fetcher))
+	}
+	if c.capabilities&ReadURL != 0 {
+		tools = append(tools, NewReadURLTool(fetcher, c.readURL...))
+	}
+	if c.capabilities&JSONQuery != 0 {
+		tools = append(tools, NewJSONQueryTool())
+	}
+	return tools
+}
@@ -0,0 +1,246 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/easyagent-dev/llm"
+)
+
+const (
+	// BeginTransactionToolName is the name of the tool opening a transaction
+	BeginTransactionToolName = "begin_transaction"
+
+	// CommitTransactionToolName is the name of the tool keeping the calls of the open transaction
+	CommitTransactionToolName = "commit_transaction"
+
+	// AbortTransactionToolName is the name of the tool undoing the calls of the open transaction
+	AbortTransactionToolName = "abort_transaction"
+)
+
+// errNoTransaction is returned when committing or aborting without an open transaction
+var errNoTransaction = errors.New("no transaction is open, call begin_transaction first")
+
+// TransactionOutcome reports how a transaction ended, returned to the model by the transaction tools
+type TransactionOutcome struct {
+	// Committed is true when the calls of the transaction were kept
+	Committed bool `json:"committed"`
+
+	// Calls are the names of the tools successfully called in the transaction, in order
+	Calls []string `json:"calls"`
+
+	// Uncompensated are the tools of an aborted transaction whose calls could not be undone
+	Uncompensated []string `json:"uncompensated,omitempty"`
+
+	// Reason is why the transaction was aborted
+	Reason string `json:"reason,omitempty"`
+
+	// Error describes failed compensations of an aborted transaction
+	Error string `json:"error,omitempty"`
+}
+
+// transaction is the open transaction of a run
+type transaction struct {
+	// mark is the number of compensations recorded when the transaction began
+	mark          int
+	calls         []string
+	uncompensated []string
+}
+
+// BeginTransaction opens a transaction grouping the following tool calls of the run,
+// so they are either all kept on commit or all compensated on abort
+func (ac *AgentContext) BeginTransaction() error {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	if ac.transaction != nil {
+		return errors.New("a transaction is already open, commit or abort it first")
+	}
+	ac.transaction = &transaction{mark: ac.Compensations.Len()}
+	return nil
+}
+
+// InTransaction reports whether a transaction is open
+func (ac *AgentContext) InTransaction() bool {
+	ac.mu.RLock()
+	defer ac.mu.RUnlock()
+
+	return ac.transaction != nil
+}
+
+// CommitTransaction closes the open transaction, keeping its calls.
+// Their compensations stay recorded for a rollback of the whole run.
+func (ac *AgentContext) CommitTransaction() (*TransactionOutcome, error) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	tx := ac.transaction
+	if tx == nil {
+		return nil, errNoTransaction
+	}
+	ac.transaction = nil
+	return &TransactionOutcome{
+		Committed: true,
+		Calls:     tx.calls,
+	}, nil
+}
+
+// AbortTransaction closes the open transaction, compensating its calls in reverse order.
+// Compensation failures are reported in the outcome, not as an error.
+func (ac *AgentContext) AbortTransaction(ctx context.Context, reason string) (*TransactionOutcome, error) {
+	ac.mu.Lock()
+	tx := ac.transaction
+	ac.transaction = nil
+	ac.mu.Unlock()
+	if tx == nil {
+		return nil, errNoTransaction
+	}
+
+	outcome := &TransactionOutcome{
+		Calls:         tx.calls,
+		Uncompensated: tx.uncompensated,
+		Reason:        reason,
+	}
+	if err := ac.Compensations.RollbackTo(ctx, tx.mark); err != nil {
+		outcome.Error = err.Error()
+	}
+	return outcome, nil
+}
+
+// recordTransactionCall adds a successful tool call to the open transaction, if any
+func (ac *AgentContext) recordTransactionCall(tool ModelTool, options ToolOptions) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	tx := ac.transaction
+	if tx == nil || isTransactionTool(tool.Name()) {
+		return
+	}
+	tx.calls = append(tx.calls, tool.Name())
+	if _, ok := tool.(CompensatingTool); !ok && ToolEffectOf(tool, options) != ToolEffectReadOnly {
+		tx.uncompensated = append(tx.uncompensated, tool.Name())
+	}
+}
+
+// isTransactionTool reports whether name is one of the transaction tools
+func isTransactionTool(name string) bool {
+	return name == BeginTransactionToolName || name == CommitTransactionToolName || name == AbortTransactionToolName
+}
+
+// AbortTransactionInput is the input of the abort_transaction tool
+type AbortTransactionInput struct {
+	Reason string `json:"reason,omitempty" jsonschema:"description=Why the transaction is aborted"`
+}
+
+// TransactionTool is one of the tools letting the model group tool calls into a transaction.
+// They are registered by runners created with WithTransactions.
+type TransactionTool struct {
+	name        string
+	description string
+	usage       string
+}
+
+var _ EffectTool = &TransactionTool{}
+
+// NewTransactionTools creates the begin_transaction, commit_transaction and abort_transaction tools
+func NewTransactionTools() []ModelTool {
+	return []ModelTool{
+		&TransactionTool{
+			name:        BeginTransactionToolName,
+			description: "Starts a transaction before several tool calls that must either all apply or all be undone",
+			usage:       `{}`,
+		},
+		&TransactionTool{
+			name:        CommitTransactionToolName,
+			description: "Ends the open transaction, keeping the effects of its tool calls",
+			usage:       `{}`,
+		},
+		&TransactionTool{
+			name:        AbortTransactionToolName,
+			description: "Ends the open transaction, undoing the effects of its tool calls",
+			usage:       `{"reason":"the payment failed"}`,
+		},
+	}
+}
+
+// Name returns the name of the tool
+func (t *TransactionTool) Name() string {
+	return t.name
+}
+
+// Description returns a description of what the tool does
+func (t *TransactionTool) Description() string {
+	return t.description
+}
+
+// InputSchema returns the input schema of the tool
+func (t *TransactionTool) InputSchema() any {
+	if t.name == AbortTransactionToolName {
+		return llm.GenerateSchema[AbortTransactionInput]()
+	}
+	return llm.GenerateSchema[struct{}]()
+}
+
+// OutputSchema returns the output schema of the tool
+func (t *TransactionTool) OutputSchema() any {
+	return nil
+}
+
+// Usage returns an example of how to use the tool
+func (t *TransactionTool) Usage() string {
+	return t.usage
+}
+
+// Effect returns ToolEffectReadOnly, transactions only undo calls that were approved
+func (t *TransactionTool) Effect() ToolEffect {
+	return ToolEffectReadOnly
+}
+
+// Run opens, commits or aborts the transaction of the run
+func (t *TransactionTool) Run(ctx context.Context, input map[string]any) (any, error) {
+	ac, ok := AgentContextOf(ctx)
+	if !ok {
+		return nil, errors.New("transaction tools can only be used in an agent run")
+	}
+	switch t.name {
+	case BeginTransactionToolName:
+		if err := ac.BeginTransaction(); err != nil {
+			return nil, err
+		}
+		return "Transaction started", nil
+	case CommitTransactionToolName:
+		return ac.CommitTransaction()
+	default:
+		reason, _ := input["reason"].(string)
+		return ac.AbortTransaction(ctx, reason)
+	}
+}
+
+// abortOpenTransaction aborts the transaction left open by a failed tool call or the end of the run
+// and describes the outcome for the model
+func (r *BaseRunner) abortOpenTransaction(ctx context.Context, run *agentRun, reason string) string {
+	if !run.agentContext.InTransaction() {
+		return ""
+	}
+	outcome, err := run.agentContext.AbortTransaction(context.WithoutCancel(ctx), reason)
+	if err != nil {
+		return ""
+	}
+	r.logger.Info("aborted open transaction",
+		"agent", run.agent.Name,
+		"reason", reason,
+		"calls", len(outcome.Calls),
+		"uncompensated", len(outcome.Uncompensated))
+	if outcome.Error != "" {
+		r.logger.Warn("transaction compensation failed", "agent", run.agent.Name, "error", outcome.Error)
+	}
+	message := fmt.Sprintf("The open transaction was aborted (%s) and its tool calls %v were compensated.", reason, outcome.Calls)
+	if len(outcome.Uncompensated) > 0 {
+		message += fmt.Sprintf(" These calls have no compensation and were not undone: %v.", outcome.Uncompensated)
+	}
+	if outcome.Error != "" {
+		message += " Undoing failed: " + outcome.Error
+	}
+	return message
+}
@@ -0,0 +1,200 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// TranscriptEntryType identifies the kind of transcript line
+type TranscriptEntryType string
+
+const (
+	// TranscriptEntryModelRequest is written before every model call
+	TranscriptEntryModelRequest TranscriptEntryType = "model_request"
+
+	// TranscriptEntryModelResponse is written after every model call
+	TranscriptEntryModelResponse TranscriptEntryType = "model_response"
+
+	// TranscriptEntryToolCall is written before every tool execution
+	TranscriptEntryToolCall TranscriptEntryType = "tool_call"
+
+	// TranscriptEntryToolResult is written after every successful tool execution
+	TranscriptEntryToolResult TranscriptEntryType = "tool_result"
+)
+
+// TranscriptEntry is a single JSONL line of a run transcript
+type TranscriptEntry struct {
+	Type     TranscriptEntryType `json:"type"`
+	Time     time.Time           `json:"time"`
+	RunID    string              `json:"runId,omitempty"`
+	Agent    string              `json:"agent,omitempty"`
+	Metadata map[string]string   `json:"metadata,omitempty"`
+
+	// Provider, Model, Prompts and Messages are set for model entries
+	Provider string              `json:"provider,omitempty"`
+	Model    string              `json:"model,omitempty"`
+	Prompts  string              `json:"prompts,omitempty"`
+	Messages []*llm.ModelMessage `json:"messages,omitempty"`
+
+	// Output and Usage are set for model responses
+	Output string          `json:"output,omitempty"`
+	Usage  *llm.TokenUsage `json:"usage,omitempty"`
+
+	// Tool, Input and Result are set for tool entries
+	Tool   string `json:"tool,omitempty"`
+	Input  any    `json:"input,omitempty"`
+	Result any    `json:"result,omitempty"`
+
+	// DurationMs is the duration of the model call or tool execution, set on response and result entries
+	DurationMs int64 `json:"durationMs,omitempty"`
+}
+
+// TranscriptCallback writes every prompt, model response and tool call of runs as JSONL,
+// suitable for fine-tuning datasets or offline analysis.
+// Write errors are returned from the callback methods, which fails the run.
+// This type is safe for concurrent use.
+type TranscriptCallback struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	started map[string]time.Time
+}
+
+var _ Callback = (*TranscriptCallback)(nil)
+
+// NewTranscriptCallback creates a callback writing the transcript to w
+func NewTranscriptCallback(w io.Writer) *TranscriptCallback {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false) // Prompts are XML-like, keep them readable
+	return &TranscriptCallback{
+		encoder: encoder,
+		started: make(map[string]time.Time),
+	}
+}
+
+// BeforeModel writes the prompts and messages sent to the model
+func (c *TranscriptCallback) BeforeModel(ctx context.Context, provider string, model string, prompts string, messages []*llm.ModelMessage) error {
+	return c.write(ctx, "model", &TranscriptEntry{
+		Type:     TranscriptEntryModelRequest,
+		Provider: provider,
+		Model:    model,
+		Prompts:  prompts,
+		Messages: messages,
+	}, false)
+}
+
+// AfterModel writes the model output and usage
+func (c *TranscriptCallback) AfterModel(ctx context.Context, provider string, model string, prompts string, messages []*llm.ModelMessage, output string, usage *llm.TokenUsage) error {
+	return c.write(ctx, "model", &TranscriptEntry{
+		Type:     TranscriptEntryModelResponse,
+		Provider: provider,
+		Model:    model,
+		Output:   output,
+		Usage:    usage,
+	}, true)
+}
+
+// BeforeToolCall writes the tool input
+func (c *TranscriptCallback) BeforeToolCall(ctx context.Context, toolName string, input any) error {
+	return c.write(ctx, "tool:"+toolName, &TranscriptEntry{
+		Type:  TranscriptEntryToolCall,
+		Tool:  toolName,
+		Input: input,
+	}, false)
+}
+
+// AfterToolCall writes the tool output
+func (c *TranscriptCallback) AfterToolCall(ctx context.Context, toolName string, input any, output interface{}) error {
+	return c.write(ctx, "tool:"+toolName, &TranscriptEntry{
+		Type:   TranscriptEntryToolResult,
+		Tool:   toolName,
+		Result: output,
+	}, true)
+}
+
+// write stamps the entry with the run details and writes it. The start time of an
+// operation is recorded under step, and its duration is set when finished is true.
+func (c *TranscriptCallback) write(ctx context.Context, step string, entry *TranscriptEntry, finished bool) error {
+	entry.Time = time.Now()
+	if ac, ok := AgentContextOf(ctx); ok {
+		entry.RunID = ac.RunID
+		entry.Metadata = ac.Metadata
+		if current := ac.CurrentAgent(); current != nil {
+			entry.Agent = current.Name
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := entry.RunID + "/" + step
+	if finished {
+		if start, ok := c.started[key]; ok {
+			entry.DurationMs = entry.Time.Sub(start).Milliseconds()
+			delete(c.started, key)
+		}
+	} else {
+		c.started[key] = entry.Time
+	}
+	return c.encoder.Encode(entry)
+}
+
+// MultiCallback calls several callbacks in order, stopping at the first error
+type MultiCallback []Callback
+
+var _ Callback = MultiCallback(nil)
+
+// NewMultiCallback combines callbacks, skipping nil ones
+func NewMultiCallback(callbacks ...Callback) MultiCallback {
+	multi := make(MultiCallback, 0, len(callbacks))
+	for _, callback := range callbacks {
+		if callback != nil {
+			multi = append(multi, callback)
+		}
+	}
+	return multi
+}
+
+// BeforeModel calls BeforeModel on every callback
+func (m MultiCallback) BeforeModel(ctx context.Context, provider string, model string, prompts string, messages []*llm.ModelMessage) error {
+	for _, callback := range m {
+		if err := callback.BeforeModel(ctx, provider, model, prompts, messages); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AfterModel calls AfterModel on every callback
+func (m MultiCallback) AfterModel(ctx context.Context, provider string, model string, prompts string, messages []*llm.ModelMessage, output string, usage *llm.TokenUsage) error {
+	for _, callback := range m {
+		if err := callback.AfterModel(ctx, provider, model, prompts, messages, output, usage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BeforeToolCall calls BeforeToolCall on every callback
+func (m MultiCallback) BeforeToolCall(ctx context.Context, toolName string, input any) error {
+	for _, callback := range m {
+		if err := callback.BeforeToolCall(ctx, toolName, input); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AfterToolCall calls AfterToolCall on every callback
+func (m MultiCallback) AfterToolCall(ctx context.Context, toolName string, input any, output interface{}) error {
+	for _, callback := range m {
+		if err := callback.AfterToolCall(ctx, toolName, input, output); err != nil {
+			return err
+		}
+	}
+	return nil
+}
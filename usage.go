@@ -0,0 +1,54 @@
+package agent
+
+import (
+	"github.com/easyagent-dev/llm"
+)
+
+// CacheHitRate returns the share of input tokens served from the provider's prompt cache,
+// between 0 and 1. It returns 0 if usage is nil or has no input tokens.
+func CacheHitRate(usage *llm.TokenUsage) float64 {
+	if usage == nil || usage.TotalInputTokens == 0 {
+		return 0
+	}
+	return float64(usage.TotalCacheReadTokens) / float64(usage.TotalInputTokens)
+}
+
+// UsageReport is the token usage and cost of a model call and the totals of the run so far
+type UsageReport struct {
+	// Usage and Cost are those of the model call, nil on output events
+	Usage *llm.TokenUsage `json:"usage,omitempty"`
+	Cost  *float64        `json:"cost,omitempty"`
+
+	// Latency is the token latency of the model call, set for streamed calls
+	Latency *TokenLatency `json:"latency,omitempty"`
+
+	// TotalUsage and TotalCost are the cumulative usage and cost of the run
+	TotalUsage llm.TokenUsage `json:"totalUsage"`
+	TotalCost  float64        `json:"totalCost"`
+}
+
+// addUsage adds the usage and cost of a model call to the run totals and reports them in a usage event
+func (run *agentRun) addUsage(turn *modelTurn) {
+	if turn.usage != nil {
+		run.usage.Append(turn.usage)
+	}
+	if turn.cost != nil {
+		run.totalCost += *turn.cost
+	}
+	report := run.usageTotals()
+	report.Usage = turn.usage
+	report.Cost = turn.cost
+	report.Latency = turn.latency
+	run.events.emit(AgentEvent{
+		Type:  AgentEventTypeUsage,
+		Usage: report,
+	})
+}
+
+// usageTotals returns the cumulative usage and cost of the run
+func (run *agentRun) usageTotals() *UsageReport {
+	return &UsageReport{
+		TotalUsage: *run.usage,
+		TotalCost:  run.totalCost,
+	}
+}
@@ -0,0 +1,354 @@
+package agent
+
+import (
+	"cmp"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/easyagent-dev/llm"
+)
+
+// DefaultUsageWindow is the time window of usage rollups when none is set
+const DefaultUsageWindow = 24 * time.Hour
+
+// UsageDimension is a dimension usage is rolled up by
+type UsageDimension string
+
+const (
+	// UsageByTenant rolls up usage per tenant
+	UsageByTenant UsageDimension = "tenant"
+
+	// UsageByAgent rolls up usage per agent
+	UsageByAgent UsageDimension = "agent"
+
+	// UsageByModel rolls up usage per provider and model
+	UsageByModel UsageDimension = "model"
+
+	// UsageByTool rolls up usage per tool, attributing model calls to the tool they chose
+	UsageByTool UsageDimension = "tool"
+)
+
+// UsageRecord is the usage of a model call, the unit of usage aggregation
+type UsageRecord struct {
+	Time     time.Time
+	Tenant   string
+	Agent    string
+	Provider string
+	Model    string
+
+	// Tool is the tool the model call chose, empty when it chose none or is unknown
+	Tool string
+
+	Usage llm.TokenUsage
+
+	// Cost is the cost in USD. The aggregator prices records without cost when it has a pricing catalog.
+	Cost float64
+
+	// Duration is the duration of the model call, for models billed by the hour
+	Duration time.Duration
+}
+
+// UsageRollup is the usage of the model calls within a time window, for the values of the
+// dimensions of the aggregator. Fields of other dimensions are empty.
+type UsageRollup struct {
+	Start    time.Time      `json:"start"`
+	End      time.Time      `json:"end"`
+	Tenant   string         `json:"tenant,omitempty"`
+	Agent    string         `json:"agent,omitempty"`
+	Provider string         `json:"provider,omitempty"`
+	Model    string         `json:"model,omitempty"`
+	Tool     string         `json:"tool,omitempty"`
+	Calls    int            `json:"calls"`
+	Usage    llm.TokenUsage `json:"usage"`
+	Cost     float64        `json:"cost"`
+}
+
+// UsageAggregatorOption is a functional option for configuring usage aggregators
+type UsageAggregatorOption func(*UsageAggregator)
+
+// WithUsageWindow sets the time window of rollups, DefaultUsageWindow by default.
+// Windows are aligned on UTC, so daily windows start at midnight UTC.
+func WithUsageWindow(window time.Duration) UsageAggregatorOption {
+	return func(a *UsageAggregator) {
+		if window > 0 {
+			a.window = window
+		}
+	}
+}
+
+// WithUsageDimensions sets the dimensions usage is rolled up by, tenant and model by default
+func WithUsageDimensions(dimensions ...UsageDimension) UsageAggregatorOption {
+	return func(a *UsageAggregator) {
+		a.dimensions = dimensions
+	}
+}
+
+// WithUsagePricing prices the records without cost, including the model calls seen by the
+// callback, which carry no cost
+func WithUsagePricing(catalog PricingCatalog) UsageAggregatorOption {
+	return func(a *UsageAggregator) {
+		a.pricing = catalog
+	}
+}
+
+// WithUsageTenantKey sets the metadata key of the tenant of runs, "tenant_id" by default
+func WithUsageTenantKey(key string) UsageAggregatorOption {
+	return func(a *UsageAggregator) {
+		a.tenantKey = key
+	}
+}
+
+// UsageAggregator rolls up the tokens and cost of model calls per time window and per tenant,
+// agent, model or tool, and exports the rollups as CSV or JSON for chargeback.
+//
+// Records are added with Add, e.g. from a run history store, or from runs by using the aggregator
+// as the request callback. The usage of a model call is attributed to the tool it chose when the
+// tool is called; register the aggregator as an event sink too, so the model calls of failed runs
+// are counted when the run ends. Rollups are kept in memory until pruned.
+// This type is safe for concurrent use.
+type UsageAggregator struct {
+	mu         sync.Mutex
+	window     time.Duration
+	dimensions []UsageDimension
+	pricing    PricingCatalog
+	tenantKey  string
+	rollups    map[usageKey]*UsageRollup
+	started    map[string]time.Time
+	pending    map[string]*UsageRecord
+}
+
+var (
+	_ Callback  = (*UsageAggregator)(nil)
+	_ EventSink = (*UsageAggregator)(nil)
+)
+
+// usageKey identifies a rollup
+type usageKey struct {
+	start                                int64
+	tenant, agent, provider, model, tool string
+}
+
+// NewUsageAggregator creates a usage aggregator
+func NewUsageAggregator(opts ...UsageAggregatorOption) *UsageAggregator {
+	a := &UsageAggregator{
+		window:     DefaultUsageWindow,
+		dimensions: []UsageDimension{UsageByTenant, UsageByModel},
+		tenantKey:  "tenant_id",
+		rollups:    make(map[usageKey]*UsageRollup),
+		started:    make(map[string]time.Time),
+		pending:    make(map[string]*UsageRecord),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Add adds the usage of a model call to its rollup
+func (a *UsageAggregator) Add(record *UsageRecord) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.add(record)
+}
+
+func (a *UsageAggregator) add(record *UsageRecord) {
+	cost := record.Cost
+	if cost == 0 && a.pricing != nil {
+		if price, ok := a.pricing.Price(record.Provider, record.Model); ok {
+			cost = price.Cost(&record.Usage, record.Duration)
+		}
+	}
+
+	start := record.Time.UTC().Truncate(a.window)
+	key := usageKey{start: start.UnixNano()}
+	for _, dimension := range a.dimensions {
+		switch dimension {
+		case UsageByTenant:
+			key.tenant = record.Tenant
+		case UsageByAgent:
+			key.agent = record.Agent
+		case UsageByModel:
+			key.provider, key.model = record.Provider, record.Model
+		case UsageByTool:
+			key.tool = record.Tool
+		}
+	}
+	rollup, ok := a.rollups[key]
+	if !ok {
+		rollup = &UsageRollup{
+			Start:    start,
+			End:      start.Add(a.window),
+			Tenant:   key.tenant,
+			Agent:    key.agent,
+			Provider: key.provider,
+			Model:    key.model,
+			Tool:     key.tool,
+		}
+		a.rollups[key] = rollup
+	}
+	rollup.Calls++
+	rollup.Usage.Append(&record.Usage)
+	rollup.Cost += cost
+}
+
+// Rollups returns the rollups of the windows starting in [from, to), ordered by window and
+// dimension values. A zero from or to leaves the period open.
+func (a *UsageAggregator) Rollups(from, to time.Time) []*UsageRollup {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	rollups := make([]*UsageRollup, 0, len(a.rollups))
+	for _, rollup := range a.rollups {
+		if !from.IsZero() && rollup.Start.Before(from) || !to.IsZero() && !rollup.Start.Before(to) {
+			continue
+		}
+		copied := *rollup
+		rollups = append(rollups, &copied)
+	}
+	slices.SortFunc(rollups, func(x, y *UsageRollup) int {
+		return cmp.Or(x.Start.Compare(y.Start), cmp.Compare(x.Tenant, y.Tenant), cmp.Compare(x.Agent, y.Agent),
+			cmp.Compare(x.Provider, y.Provider), cmp.Compare(x.Model, y.Model), cmp.Compare(x.Tool, y.Tool))
+	})
+	return rollups
+}
+
+// Prune drops the rollups of the windows ending before t, e.g. once they have been exported
+func (a *UsageAggregator) Prune(t time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for key, rollup := range a.rollups {
+		if !rollup.End.After(t) {
+			delete(a.rollups, key)
+		}
+	}
+}
+
+// ExportJSON writes the rollups of the windows starting in [from, to) as a JSON array
+func (a *UsageAggregator) ExportJSON(w io.Writer, from, to time.Time) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(a.Rollups(from, to))
+}
+
+// usageCSVHeader is the header of CSV exports, one column per rollup field and token count
+var usageCSVHeader = []string{"start", "end", "tenant", "agent", "provider", "model", "tool", "calls",
+	"input_tokens", "output_tokens", "reasoning_tokens", "cache_read_tokens", "cache_write_tokens",
+	"images", "web_searches", "cost"}
+
+// ExportCSV writes the rollups of the windows starting in [from, to) as CSV with a header row.
+// Times are RFC 3339 in UTC and costs are in USD.
+func (a *UsageAggregator) ExportCSV(w io.Writer, from, to time.Time) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(usageCSVHeader); err != nil {
+		return err
+	}
+	for _, rollup := range a.Rollups(from, to) {
+		err := writer.Write([]string{
+			rollup.Start.Format(time.RFC3339),
+			rollup.End.Format(time.RFC3339),
+			rollup.Tenant,
+			rollup.Agent,
+			rollup.Provider,
+			rollup.Model,
+			rollup.Tool,
+			strconv.Itoa(rollup.Calls),
+			strconv.FormatInt(rollup.Usage.TotalInputTokens, 10),
+			strconv.FormatInt(rollup.Usage.TotalOutputTokens, 10),
+			strconv.FormatInt(rollup.Usage.TotalReasoningTokens, 10),
+			strconv.FormatInt(rollup.Usage.TotalCacheReadTokens, 10),
+			strconv.FormatInt(rollup.Usage.TotalCacheWriteTokens, 10),
+			strconv.Itoa(rollup.Usage.TotalImages),
+			strconv.Itoa(rollup.Usage.TotalWebSearches),
+			strconv.FormatFloat(rollup.Cost, 'f', 6, 64),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// BeforeModel records the start of the model call, for models billed by the hour
+func (a *UsageAggregator) BeforeModel(ctx context.Context, provider string, model string, prompts string, messages []*llm.ModelMessage) error {
+	runID, _ := a.runOf(ctx)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.started[runID] = time.Now()
+	return nil
+}
+
+// AfterModel holds the usage of the model call until the tool it chose is known
+func (a *UsageAggregator) AfterModel(ctx context.Context, provider string, model string, prompts string, messages []*llm.ModelMessage, output string, usage *llm.TokenUsage) error {
+	runID, agentContext := a.runOf(ctx)
+	record := &UsageRecord{Time: time.Now(), Provider: provider, Model: model}
+	if usage != nil {
+		record.Usage = *usage
+	}
+	if agentContext != nil {
+		record.Tenant = agentContext.Metadata[a.tenantKey]
+		if current := agentContext.CurrentAgent(); current != nil {
+			record.Agent = current.Name
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if started, ok := a.started[runID]; ok {
+		record.Duration = record.Time.Sub(started)
+		delete(a.started, runID)
+	}
+	// A model call choosing no tool, e.g. an unparsable one, is followed by another model call
+	if previous, ok := a.pending[runID]; ok {
+		a.add(previous)
+	}
+	a.pending[runID] = record
+	return nil
+}
+
+// BeforeToolCall adds the usage of the model call to the rollup of the tool it chose
+func (a *UsageAggregator) BeforeToolCall(ctx context.Context, toolName string, input any) error {
+	runID, _ := a.runOf(ctx)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if record, ok := a.pending[runID]; ok {
+		record.Tool = toolName
+		a.add(record)
+		delete(a.pending, runID)
+	}
+	return nil
+}
+
+// AfterToolCall does nothing
+func (a *UsageAggregator) AfterToolCall(ctx context.Context, toolName string, input any, output interface{}) error {
+	return nil
+}
+
+// Send adds the usage of the last model call of finished runs, when it chose no tool
+func (a *UsageAggregator) Send(ctx context.Context, event *LifecycleEvent) {
+	if event.Type != LifecycleRunCompleted && event.Type != LifecycleRunFailed {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if record, ok := a.pending[event.RunID]; ok {
+		a.add(record)
+		delete(a.pending, event.RunID)
+	}
+	delete(a.started, event.RunID)
+}
+
+// runOf returns the ID and context of the run carried by ctx
+func (a *UsageAggregator) runOf(ctx context.Context) (string, *AgentContext) {
+	agentContext, ok := AgentContextOf(ctx)
+	if !ok {
+		return "", nil
+	}
+	return agentContext.RunID, agentContext
+}
@@ -0,0 +1,240 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Headers of webhook deliveries
+const (
+	WebhookEventHeader     = "X-Agent-Event"
+	WebhookDeliveryHeader  = "X-Agent-Delivery"
+	WebhookTimestampHeader = "X-Agent-Timestamp"
+	WebhookSignatureHeader = "X-Agent-Signature"
+)
+
+// DefaultWebhookQueueSize is the number of events a webhook sink buffers before dropping new ones
+const DefaultWebhookQueueSize = 1024
+
+// WebhookSink posts lifecycle events as JSON to a webhook URL from a background goroutine.
+// Deliveries are signed with HMAC-SHA256 when a secret is set and retried with exponential backoff
+// on network errors, 429 and 5xx responses. Events are dropped with a warning when the queue is full.
+type WebhookSink struct {
+	url        string
+	secret     []byte
+	client     *http.Client
+	events     map[LifecycleEventType]bool
+	maxRetries int
+	backoff    time.Duration
+	logger     Logger
+
+	// mu guards closed, so events are never sent to the closed queue
+	mu     sync.RWMutex
+	closed bool
+	queue  chan *LifecycleEvent
+	done   chan struct{}
+}
+
+var _ EventSink = (*WebhookSink)(nil)
+
+// WebhookOption is a functional option for configuring webhook sinks
+type WebhookOption func(*WebhookSink)
+
+// WithWebhookSecret signs deliveries with secret, see VerifyWebhookSignature
+func WithWebhookSecret(secret string) WebhookOption {
+	return func(s *WebhookSink) {
+		s.secret = []byte(secret)
+	}
+}
+
+// WithWebhookClient sets the HTTP client posting the events, a client with a 10 second timeout by default
+func WithWebhookClient(client *http.Client) WebhookOption {
+	return func(s *WebhookSink) {
+		s.client = client
+	}
+}
+
+// WithWebhookEvents restricts the posted events to types, all events by default
+func WithWebhookEvents(types ...LifecycleEventType) WebhookOption {
+	return func(s *WebhookSink) {
+		s.events = make(map[LifecycleEventType]bool, len(types))
+		for _, t := range types {
+			s.events[t] = true
+		}
+	}
+}
+
+// WithWebhookRetry sets how many times a failed delivery is retried, 3 by default, and the delay
+// before the first retry, 1 second by default, doubled for every following retry
+func WithWebhookRetry(maxRetries int, backoff time.Duration) WebhookOption {
+	return func(s *WebhookSink) {
+		s.maxRetries = maxRetries
+		s.backoff = backoff
+	}
+}
+
+// WithWebhookQueueSize sets the number of buffered events, DefaultWebhookQueueSize by default
+func WithWebhookQueueSize(size int) WebhookOption {
+	return func(s *WebhookSink) {
+		s.queue = make(chan *LifecycleEvent, size)
+	}
+}
+
+// WithWebhookLogger sets the logger receiving failed and dropped deliveries
+func WithWebhookLogger(logger Logger) WebhookOption {
+	return func(s *WebhookSink) {
+		s.logger = logger
+	}
+}
+
+// NewWebhookSink creates a sink posting events to url and starts its delivery goroutine.
+// Call Close to deliver the queued events and stop it.
+func NewWebhookSink(url string, opts ...WebhookOption) *WebhookSink {
+	sink := &WebhookSink{
+		url:        url,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		maxRetries: 3,
+		backoff:    time.Second,
+		logger:     NoOpLogger{},
+		queue:      make(chan *LifecycleEvent, DefaultWebhookQueueSize),
+		done:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(sink)
+	}
+	go sink.deliverAll()
+	return sink
+}
+
+// Send queues an event for delivery without blocking
+func (s *WebhookSink) Send(ctx context.Context, event *LifecycleEvent) {
+	if s.events != nil && !s.events[event.Type] {
+		return
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.queue <- event:
+	default:
+		s.logger.Warn("webhook queue full, dropping event", "url", s.url, "event", string(event.Type), "id", event.ID)
+	}
+}
+
+// Close stops accepting events and waits until the queued ones are delivered or ctx is done
+func (s *WebhookSink) Close(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// deliverAll posts queued events one at a time, preserving their order
+func (s *WebhookSink) deliverAll() {
+	defer close(s.done)
+	for event := range s.queue {
+		if err := s.deliver(event); err != nil {
+			s.logger.Error("failed to deliver webhook event", "url", s.url, "event", string(event.Type), "id", event.ID, "error", err)
+		}
+	}
+}
+
+// deliver posts an event, retrying retryable failures
+func (s *WebhookSink) deliver(event *LifecycleEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	backoff := s.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := s.post(event, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= s.maxRetries {
+			return err
+		}
+		s.logger.Warn("webhook delivery failed, retrying", "url", s.url, "id", event.ID, "attempt", attempt+1, "error", err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// post sends a single delivery and reports whether a failure is worth retrying
+func (s *WebhookSink) post(event *LifecycleEvent, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, string(event.Type))
+	req.Header.Set(WebhookDeliveryHeader, event.ID)
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	if len(s.secret) > 0 {
+		req.Header.Set(WebhookSignatureHeader, SignWebhook(s.secret, timestamp, body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= 500:
+		return true, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+}
+
+// SignWebhook returns the signature of a delivery: "sha256=" followed by the hex HMAC-SHA256
+// of the timestamp, a dot and the body
+func SignWebhook(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature checks the signature and timestamp headers of a received delivery.
+// Deliveries older than tolerance are rejected to prevent replays, unless tolerance is zero.
+func VerifyWebhookSignature(secret []byte, header http.Header, body []byte, tolerance time.Duration) error {
+	timestamp := header.Get(WebhookTimestampHeader)
+	expected := SignWebhook(secret, timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(header.Get(WebhookSignatureHeader))) {
+		return ErrInvalidWebhookSignature
+	}
+	if tolerance > 0 {
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: invalid timestamp", ErrInvalidWebhookSignature)
+		}
+		if age := time.Since(time.Unix(seconds, 0)); age > tolerance || age < -tolerance {
+			return fmt.Errorf("%w: timestamp outside tolerance", ErrInvalidWebhookSignature)
+		}
+	}
+	return nil
+}
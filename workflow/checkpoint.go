@@ -0,0 +1,91 @@
+package workflow
+
+import (
+	"context"
+	"sync"
+)
+
+// NodeStatus is the execution status of a node
+type NodeStatus string
+
+const (
+	NodeStatusCompleted NodeStatus = "completed"
+	NodeStatusFailed    NodeStatus = "failed"
+	NodeStatusSkipped   NodeStatus = "skipped"
+)
+
+// NodeResult is the recorded outcome of a finished node
+type NodeResult struct {
+	Status       NodeStatus `json:"status"`
+	Output       any        `json:"output,omitempty"`
+	ErrorMessage string     `json:"errorMessage,omitempty"`
+}
+
+// Checkpoint is the persisted progress of a workflow execution.
+// Resuming a run with the same ID skips every node recorded here.
+type Checkpoint struct {
+	RunID  string                 `json:"runId"`
+	Inputs map[string]any         `json:"inputs"`
+	Nodes  map[string]*NodeResult `json:"nodes"`
+	Cost   float64                `json:"cost"`
+}
+
+// Checkpointer persists workflow checkpoints.
+// Implementations backed by durable storage should expect node outputs to be JSON serializable.
+type Checkpointer interface {
+	// Save stores the checkpoint, replacing any previous one for the same run
+	Save(ctx context.Context, checkpoint *Checkpoint) error
+
+	// Load returns the checkpoint of a run, or nil if there is none
+	Load(ctx context.Context, runID string) (*Checkpoint, error)
+}
+
+// MemoryCheckpointer keeps checkpoints in memory.
+// It is safe for concurrent use by multiple goroutines.
+type MemoryCheckpointer struct {
+	mu          sync.RWMutex
+	checkpoints map[string]*Checkpoint
+}
+
+var _ Checkpointer = (*MemoryCheckpointer)(nil)
+
+// NewMemoryCheckpointer creates a new in-memory checkpointer
+func NewMemoryCheckpointer() *MemoryCheckpointer {
+	return &MemoryCheckpointer{
+		checkpoints: make(map[string]*Checkpoint),
+	}
+}
+
+// Save stores a copy of the checkpoint
+func (c *MemoryCheckpointer) Save(ctx context.Context, checkpoint *Checkpoint) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checkpoints[checkpoint.RunID] = checkpoint.clone()
+	return nil
+}
+
+// Load returns a copy of the stored checkpoint
+func (c *MemoryCheckpointer) Load(ctx context.Context, runID string) (*Checkpoint, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	checkpoint, ok := c.checkpoints[runID]
+	if !ok {
+		return nil, nil
+	}
+	return checkpoint.clone(), nil
+}
+
+// clone returns a shallow copy with its own node map
+func (c *Checkpoint) clone() *Checkpoint {
+	nodes := make(map[string]*NodeResult, len(c.Nodes))
+	for id, result := range c.Nodes {
+		copied := *result
+		nodes[id] = &copied
+	}
+	return &Checkpoint{
+		RunID:  c.RunID,
+		Inputs: c.Inputs,
+		Nodes:  nodes,
+		Cost:   c.Cost,
+	}
+}
@@ -0,0 +1,52 @@
+package workflow
+
+import (
+	"time"
+)
+
+// EventType represents the type of a workflow event
+type EventType string
+
+const (
+	// EventTypeNodeStarted indicates a node started running
+	EventTypeNodeStarted EventType = "node_started"
+
+	// EventTypeNodeCompleted indicates a node completed successfully
+	EventTypeNodeCompleted EventType = "node_completed"
+
+	// EventTypeNodeFailed indicates a node returned an error
+	EventTypeNodeFailed EventType = "node_failed"
+
+	// EventTypeNodeSkipped indicates a node was skipped because none of its incoming edges was taken
+	EventTypeNodeSkipped EventType = "node_skipped"
+
+	// EventTypeNodeRestored indicates a node result was restored from a checkpoint
+	EventTypeNodeRestored EventType = "node_restored"
+
+	// EventTypeWorkflowCompleted indicates the workflow finished successfully
+	EventTypeWorkflowCompleted EventType = "workflow_completed"
+
+	// EventTypeWorkflowFailed indicates the workflow stopped because of an error
+	EventTypeWorkflowFailed EventType = "workflow_failed"
+)
+
+// Event is a node-level event emitted while a workflow executes
+type Event struct {
+	// Type identifies what kind of event this is
+	Type EventType `json:"type"`
+
+	// RunID identifies the workflow execution
+	RunID string `json:"runId"`
+
+	// NodeID is the node the event is about, empty for workflow events
+	NodeID string `json:"nodeId,omitempty"`
+
+	// Output is the node output (for NodeCompleted and NodeRestored events)
+	Output any `json:"output,omitempty"`
+
+	// ErrorMessage contains error details (for failure events)
+	ErrorMessage string `json:"errorMessage,omitempty"`
+
+	// Time is when the event occurred
+	Time time.Time `json:"time"`
+}
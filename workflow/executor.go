@@ -0,0 +1,260 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultEventBufferSize is the default size of the channel returned by Stream
+const DefaultEventBufferSize = 100
+
+// Result is the outcome of a workflow execution
+type Result struct {
+	// RunID identifies the workflow execution
+	RunID string `json:"runId"`
+
+	// Outputs contains the output of every completed node
+	Outputs map[string]any `json:"outputs"`
+
+	// Nodes contains the outcome of every finished node
+	Nodes map[string]*NodeResult `json:"nodes"`
+
+	// Cost is the total cost reported by agent nodes
+	Cost float64 `json:"cost"`
+}
+
+// nodeDone is sent by a node goroutine when the node returns
+type nodeDone struct {
+	id     string
+	output any
+	err    error
+}
+
+// execution holds the scheduling state of a single workflow run.
+// It is only accessed from the scheduling goroutine.
+type execution struct {
+	// ctx is the caller's context, events are dropped once it is done
+	ctx        context.Context
+	g          *Graph
+	runID      string
+	state      *State
+	events     chan<- Event
+	checkpoint *Checkpoint
+	indegree   map[string]int
+	resolved   map[string]int
+	taken      map[string]int
+	ready      []string
+}
+
+// Run executes the graph and waits for it to finish.
+// If runID is empty, a new one is generated. If a checkpointer is configured and holds
+// a checkpoint for runID, the run is resumed and inputs are taken from the checkpoint.
+func (g *Graph) Run(ctx context.Context, runID string, inputs map[string]any) (*Result, error) {
+	return g.execute(ctx, runID, inputs, nil)
+}
+
+// Stream executes the graph in the background, streaming node-level events.
+// The channel is closed when the workflow finishes, after a WorkflowCompleted or WorkflowFailed event.
+// The workflow waits for the consumer when the channel is full; cancel ctx to stop a run whose
+// events are no longer read.
+func (g *Graph) Stream(ctx context.Context, runID string, inputs map[string]any) (<-chan Event, error) {
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+
+	size := g.eventBuffer
+	if size <= 0 {
+		size = DefaultEventBufferSize
+	}
+	events := make(chan Event, size)
+	go func() {
+		defer close(events)
+		_, _ = g.execute(ctx, runID, inputs, events)
+	}()
+	return events, nil
+}
+
+func (g *Graph) execute(ctx context.Context, runID string, inputs map[string]any, events chan<- Event) (*Result, error) {
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+	if runID == "" {
+		runID = uuid.New().String()
+	}
+
+	checkpoint := &Checkpoint{
+		RunID:  runID,
+		Inputs: inputs,
+		Nodes:  map[string]*NodeResult{},
+	}
+	restored := map[string]*NodeResult{}
+	if g.checkpointer != nil {
+		saved, err := g.checkpointer.Load(ctx, runID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load checkpoint: %w", err)
+		}
+		if saved != nil {
+			restored = saved.Nodes
+			checkpoint.Inputs = saved.Inputs
+			checkpoint.Cost = saved.Cost
+		}
+	}
+
+	ex := &execution{
+		ctx:        ctx,
+		g:          g,
+		runID:      runID,
+		state:      newState(checkpoint.Inputs),
+		events:     events,
+		checkpoint: checkpoint,
+		indegree:   map[string]int{},
+		resolved:   map[string]int{},
+		taken:      map[string]int{},
+	}
+	ex.state.AddCost(checkpoint.Cost)
+	for _, edge := range g.edges {
+		ex.indegree[edge.To]++
+	}
+	for _, id := range g.order {
+		if ex.indegree[id] == 0 {
+			ex.ready = append(ex.ready, id)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan nodeDone)
+	running := 0
+	var failure error
+	for {
+		for len(ex.ready) > 0 && failure == nil && (g.maxParallel <= 0 || running < g.maxParallel) {
+			id := ex.ready[0]
+			ex.ready = ex.ready[1:]
+
+			if result, ok := restored[id]; ok && result.Status != NodeStatusSkipped {
+				if result.Status == NodeStatusCompleted {
+					ex.state.setOutput(id, result.Output)
+				}
+				ex.emit(Event{Type: EventTypeNodeRestored, NodeID: id, Output: result.Output, ErrorMessage: result.ErrorMessage})
+				ex.finish(id, result)
+				continue
+			}
+
+			running++
+			ex.emit(Event{Type: EventTypeNodeStarted, NodeID: id})
+			go func(node Node) {
+				output, err := node.Run(ctx, ex.state)
+				done <- nodeDone{id: node.ID(), output: output, err: err}
+			}(g.nodes[id])
+		}
+		if running == 0 {
+			break
+		}
+
+		result := <-done
+		running--
+		if failure != nil {
+			continue
+		}
+
+		if result.err != nil {
+			ex.emit(Event{Type: EventTypeNodeFailed, NodeID: result.id, ErrorMessage: result.err.Error()})
+			if !ex.hasFallback(result.id) {
+				failure = fmt.Errorf("node '%s' failed: %w", result.id, result.err)
+				cancel()
+				continue
+			}
+			ex.finish(result.id, &NodeResult{Status: NodeStatusFailed, ErrorMessage: result.err.Error()})
+		} else {
+			ex.state.setOutput(result.id, result.output)
+			ex.emit(Event{Type: EventTypeNodeCompleted, NodeID: result.id, Output: result.output})
+			ex.finish(result.id, &NodeResult{Status: NodeStatusCompleted, Output: result.output})
+		}
+
+		if g.checkpointer != nil {
+			checkpoint.Cost = ex.state.Cost()
+			if err := g.checkpointer.Save(ctx, checkpoint); err != nil {
+				failure = fmt.Errorf("failed to save checkpoint: %w", err)
+				cancel()
+			}
+		}
+	}
+
+	result := &Result{
+		RunID:   runID,
+		Outputs: ex.state.Outputs(),
+		Nodes:   checkpoint.Nodes,
+		Cost:    ex.state.Cost(),
+	}
+	if failure != nil {
+		ex.emit(Event{Type: EventTypeWorkflowFailed, ErrorMessage: failure.Error()})
+		return result, failure
+	}
+	ex.emit(Event{Type: EventTypeWorkflowCompleted})
+	return result, nil
+}
+
+// finish records the result of a node and resolves its outgoing edges,
+// queueing successors that became ready and skipping those that cannot run
+func (ex *execution) finish(id string, result *NodeResult) {
+	ex.checkpoint.Nodes[id] = result
+	for _, edge := range ex.g.edges {
+		if edge.From != id {
+			continue
+		}
+		ex.resolved[edge.To]++
+		if edgeTaken(edge, result) {
+			ex.taken[edge.To]++
+		}
+		if ex.resolved[edge.To] < ex.indegree[edge.To] {
+			continue
+		}
+		if ex.taken[edge.To] > 0 {
+			ex.ready = append(ex.ready, edge.To)
+		} else {
+			ex.emit(Event{Type: EventTypeNodeSkipped, NodeID: edge.To})
+			ex.finish(edge.To, &NodeResult{Status: NodeStatusSkipped})
+		}
+	}
+}
+
+// hasFallback reports whether a node has a fallback edge handling its failure
+func (ex *execution) hasFallback(id string) bool {
+	for _, edge := range ex.g.edges {
+		if edge.From == id && edge.Kind == EdgeKindFallback {
+			return true
+		}
+	}
+	return false
+}
+
+// emit sends an event if the workflow is streamed.
+// It gives up when the caller's context is done, so an abandoned stream never blocks the run.
+func (ex *execution) emit(event Event) {
+	if ex.events == nil {
+		return
+	}
+	event.RunID = ex.runID
+	event.Time = time.Now()
+	select {
+	case ex.events <- event:
+	case <-ex.ctx.Done():
+	}
+}
+
+// edgeTaken reports whether an edge is taken given the result of its source node
+func edgeTaken(edge Edge, result *NodeResult) bool {
+	switch edge.Kind {
+	case EdgeKindFallback:
+		return result.Status == NodeStatusFailed
+	case EdgeKindConditional:
+		label, ok := result.Output.(string)
+		return result.Status == NodeStatusCompleted && ok && label == edge.Label
+	default:
+		return result.Status == NodeStatusCompleted
+	}
+}
@@ -0,0 +1,176 @@
+// Package workflow executes graphs of agent runs, tool calls, conditionals and map steps.
+package workflow
+
+import (
+	"errors"
+	"fmt"
+)
+
+// EdgeKind determines when an edge is taken
+type EdgeKind string
+
+const (
+	// EdgeKindDefault is taken when the source node completes
+	EdgeKindDefault EdgeKind = "default"
+
+	// EdgeKindConditional is taken when the source node completes with an output equal to the edge label
+	EdgeKindConditional EdgeKind = "conditional"
+
+	// EdgeKindFallback is taken when the source node fails, and marks the failure as handled
+	EdgeKindFallback EdgeKind = "fallback"
+)
+
+// Edge connects two nodes of a graph
+type Edge struct {
+	From  string   `json:"from"`
+	To    string   `json:"to"`
+	Kind  EdgeKind `json:"kind"`
+	Label string   `json:"label,omitempty"`
+}
+
+// Graph is a directed acyclic graph of nodes.
+// A node runs once all of its incoming edges are resolved and at least one was taken;
+// otherwise it is skipped. Independent branches run in parallel.
+type Graph struct {
+	nodes        map[string]Node
+	order        []string
+	edges        []Edge
+	checkpointer Checkpointer
+	maxParallel  int
+	eventBuffer  int
+}
+
+// Option is a functional option for configuring graphs
+type Option func(*Graph)
+
+// WithCheckpointer persists progress after every node so runs can be resumed
+func WithCheckpointer(checkpointer Checkpointer) Option {
+	return func(g *Graph) {
+		g.checkpointer = checkpointer
+	}
+}
+
+// WithMaxParallel limits how many nodes run at the same time. If 0, there is no limit.
+func WithMaxParallel(max int) Option {
+	return func(g *Graph) {
+		g.maxParallel = max
+	}
+}
+
+// WithEventBufferSize sets the size of the channel returned by Stream.
+// If not positive, DefaultEventBufferSize is used.
+func WithEventBufferSize(size int) Option {
+	return func(g *Graph) {
+		g.eventBuffer = size
+	}
+}
+
+// New creates an empty graph
+func New(opts ...Option) *Graph {
+	g := &Graph{
+		nodes:       make(map[string]Node),
+		eventBuffer: DefaultEventBufferSize,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// AddNode adds a node to the graph
+// It returns an error if a node with the same ID already exists
+func (g *Graph) AddNode(node Node) error {
+	id := node.ID()
+	if id == "" {
+		return errors.New("node ID is required")
+	}
+	if _, exists := g.nodes[id]; exists {
+		return fmt.Errorf("node with ID '%s' already exists", id)
+	}
+	g.nodes[id] = node
+	g.order = append(g.order, id)
+	return nil
+}
+
+// AddEdge adds an edge taken when from completes
+func (g *Graph) AddEdge(from, to string) error {
+	return g.addEdge(Edge{From: from, To: to, Kind: EdgeKindDefault})
+}
+
+// AddConditionalEdge adds an edge taken when from completes with the given label as output
+func (g *Graph) AddConditionalEdge(from, to, label string) error {
+	return g.addEdge(Edge{From: from, To: to, Kind: EdgeKindConditional, Label: label})
+}
+
+// AddFallbackEdge adds an edge taken when from fails
+func (g *Graph) AddFallbackEdge(from, to string) error {
+	return g.addEdge(Edge{From: from, To: to, Kind: EdgeKindFallback})
+}
+
+func (g *Graph) addEdge(edge Edge) error {
+	if _, ok := g.nodes[edge.From]; !ok {
+		return fmt.Errorf("node with ID '%s' not found", edge.From)
+	}
+	if _, ok := g.nodes[edge.To]; !ok {
+		return fmt.Errorf("node with ID '%s' not found", edge.To)
+	}
+	if edge.From == edge.To {
+		return fmt.Errorf("node '%s' cannot have an edge to itself", edge.From)
+	}
+	g.edges = append(g.edges, edge)
+	return nil
+}
+
+// Edges returns a copy of the graph edges
+func (g *Graph) Edges() []Edge {
+	edges := make([]Edge, len(g.edges))
+	copy(edges, g.edges)
+	return edges
+}
+
+// Validate checks that the graph is not empty and has no cycles
+func (g *Graph) Validate() error {
+	if len(g.nodes) == 0 {
+		return errors.New("graph has no nodes")
+	}
+	if _, err := g.topologicalOrder(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// topologicalOrder returns the node IDs in an order where every node comes after its predecessors
+func (g *Graph) topologicalOrder() ([]string, error) {
+	indegree := make(map[string]int, len(g.nodes))
+	for _, edge := range g.edges {
+		indegree[edge.To]++
+	}
+
+	queue := make([]string, 0, len(g.nodes))
+	for _, id := range g.order {
+		if indegree[id] == 0 {
+			queue = append(queue, id)
+		}
+	}
+
+	order := make([]string, 0, len(g.nodes))
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		order = append(order, id)
+		for _, edge := range g.edges {
+			if edge.From != id {
+				continue
+			}
+			indegree[edge.To]--
+			if indegree[edge.To] == 0 {
+				queue = append(queue, edge.To)
+			}
+		}
+	}
+
+	if len(order) != len(g.nodes) {
+		return nil, errors.New("graph contains a cycle")
+	}
+	return order, nil
+}
@@ -0,0 +1,263 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/easyagent-dev/agent"
+	"github.com/easyagent-dev/llm"
+)
+
+// Node is a unit of work in a workflow graph
+type Node interface {
+	// ID returns the unique identifier of the node within its graph
+	ID() string
+
+	// Run executes the node. Upstream outputs are available through state.
+	Run(ctx context.Context, state *State) (any, error)
+}
+
+// FuncNode runs an arbitrary function
+type FuncNode struct {
+	id string
+	fn func(ctx context.Context, state *State) (any, error)
+}
+
+var _ Node = (*FuncNode)(nil)
+
+// NewFuncNode creates a node running fn
+func NewFuncNode(id string, fn func(ctx context.Context, state *State) (any, error)) *FuncNode {
+	return &FuncNode{id: id, fn: fn}
+}
+
+// ID returns the node ID
+func (n *FuncNode) ID() string {
+	return n.id
+}
+
+// Run executes the function
+func (n *FuncNode) Run(ctx context.Context, state *State) (any, error) {
+	return n.fn(ctx, state)
+}
+
+// AgentNode runs an agent. Its output is the agent's final output and its cost is added to the workflow.
+type AgentNode struct {
+	id       string
+	runner   agent.Runner
+	request  func(state *State) (*agent.AgentRequest, error)
+	callback agent.Callback
+}
+
+var _ Node = (*AgentNode)(nil)
+
+// NewAgentNode creates a node running runner with the request built from the workflow state
+func NewAgentNode(id string, runner agent.Runner, request func(state *State) (*agent.AgentRequest, error), callback agent.Callback) *AgentNode {
+	return &AgentNode{
+		id:       id,
+		runner:   runner,
+		request:  request,
+		callback: callback,
+	}
+}
+
+// ID returns the node ID
+func (n *AgentNode) ID() string {
+	return n.id
+}
+
+// Run executes the agent
+func (n *AgentNode) Run(ctx context.Context, state *State) (any, error) {
+	req, err := n.request(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build agent request: %w", err)
+	}
+	resp, err := n.runner.Run(ctx, req, n.callback)
+	if resp != nil && resp.Cost != nil {
+		state.AddCost(*resp.Cost)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp.Output, nil
+}
+
+// ToolNode runs a tool directly, without a model in the loop
+type ToolNode struct {
+	id    string
+	tool  agent.ModelTool
+	input func(state *State) (map[string]any, error)
+}
+
+var _ Node = (*ToolNode)(nil)
+
+// NewToolNode creates a node running tool with the input built from the workflow state
+func NewToolNode(id string, tool agent.ModelTool, input func(state *State) (map[string]any, error)) *ToolNode {
+	return &ToolNode{
+		id:    id,
+		tool:  tool,
+		input: input,
+	}
+}
+
+// ID returns the node ID
+func (n *ToolNode) ID() string {
+	return n.id
+}
+
+// Run executes the tool
+func (n *ToolNode) Run(ctx context.Context, state *State) (any, error) {
+	input, err := n.input(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build tool input: %w", err)
+	}
+	return n.tool.Run(ctx, input)
+}
+
+// ConditionNode selects a branch. Its output is a label, and only the conditional
+// edges leaving it with that label are taken.
+type ConditionNode struct {
+	id string
+	fn func(ctx context.Context, state *State) (string, error)
+}
+
+var _ Node = (*ConditionNode)(nil)
+
+// NewConditionNode creates a node choosing a branch label with fn
+func NewConditionNode(id string, fn func(ctx context.Context, state *State) (string, error)) *ConditionNode {
+	return &ConditionNode{id: id, fn: fn}
+}
+
+// ID returns the node ID
+func (n *ConditionNode) ID() string {
+	return n.id
+}
+
+// Run returns the selected label
+func (n *ConditionNode) Run(ctx context.Context, state *State) (any, error) {
+	return n.fn(ctx, state)
+}
+
+// MapNode applies a function to every item of a list with bounded concurrency,
+// then optionally reduces the results into a single output
+type MapNode struct {
+	id          string
+	items       func(state *State) ([]any, error)
+	fn          func(ctx context.Context, state *State, item any) (any, error)
+	reduce      func(results []any) (any, error)
+	concurrency int
+}
+
+var _ Node = (*MapNode)(nil)
+
+// NewMapNode creates a map node. If concurrency is 0 or negative, items are processed one at a time.
+// If reduce is nil, the output is the list of results in item order.
+func NewMapNode(id string, items func(state *State) ([]any, error), fn func(ctx context.Context, state *State, item any) (any, error), reduce func(results []any) (any, error), concurrency int) *MapNode {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	return &MapNode{
+		id:          id,
+		items:       items,
+		fn:          fn,
+		reduce:      reduce,
+		concurrency: concurrency,
+	}
+}
+
+// NewAgentMapNode creates a map node running an agent for every item
+func NewAgentMapNode(id string, runner agent.Runner, items func(state *State) ([]any, error), request func(item any) (*agent.AgentRequest, error), reduce func(results []any) (any, error), concurrency int) *MapNode {
+	return NewMapNode(id, items, func(ctx context.Context, state *State, item any) (any, error) {
+		req, err := request(item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build agent request: %w", err)
+		}
+		resp, err := runner.Run(ctx, req, nil)
+		if resp != nil && resp.Cost != nil {
+			state.AddCost(*resp.Cost)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return resp.Output, nil
+	}, reduce, concurrency)
+}
+
+// ID returns the node ID
+func (n *MapNode) ID() string {
+	return n.id
+}
+
+// Run maps every item and reduces the results. The first error cancels the remaining items.
+func (n *MapNode) Run(ctx context.Context, state *State) (any, error) {
+	items, err := n.items(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list items: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]any, len(items))
+	sem := make(chan struct{}, n.concurrency)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+
+	for i, item := range items {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, item any) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result, err := n.fn(ctx, state, item)
+			if err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("item %d: %w", i, err)
+					cancel()
+				})
+				return
+			}
+			results[i] = result
+		}(i, item)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if n.reduce == nil {
+		return results, nil
+	}
+	return n.reduce(results)
+}
+
+// NewRouterNode creates a condition node classifying the conversation built from the
+// workflow state with router. Its output is the label, to be used with conditional edges.
+func NewRouterNode(id string, router *agent.Router, messages func(state *State) ([]*llm.ModelMessage, error)) *ConditionNode {
+	return NewConditionNode(id, func(ctx context.Context, state *State) (string, error) {
+		history, err := messages(state)
+		if err != nil {
+			return "", fmt.Errorf("failed to build messages: %w", err)
+		}
+		classification, output, err := router.Classify(ctx, history)
+		if output != nil && output.Cost != nil {
+			state.AddCost(*output.Cost)
+		}
+		if err != nil {
+			return "", err
+		}
+		return classification.Label, nil
+	})
+}
@@ -0,0 +1,77 @@
+package workflow
+
+import (
+	"sync"
+)
+
+// State holds the workflow inputs and the outputs of completed nodes.
+// Nodes read their upstream results from it.
+// This type is safe for concurrent use.
+type State struct {
+	mu      sync.RWMutex
+	inputs  map[string]any
+	outputs map[string]any
+	cost    float64
+}
+
+// newState creates a state with the given inputs
+func newState(inputs map[string]any) *State {
+	if inputs == nil {
+		inputs = map[string]any{}
+	}
+	return &State{
+		inputs:  inputs,
+		outputs: map[string]any{},
+	}
+}
+
+// Input returns a workflow input by key
+func (s *State) Input(key string) (any, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, ok := s.inputs[key]
+	return value, ok
+}
+
+// Output returns the output of a completed node
+func (s *State) Output(nodeID string) (any, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, ok := s.outputs[nodeID]
+	return value, ok
+}
+
+// Outputs returns a copy of all node outputs
+func (s *State) Outputs() map[string]any {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	outputs := make(map[string]any, len(s.outputs))
+	for id, value := range s.outputs {
+		outputs[id] = value
+	}
+	return outputs
+}
+
+// AddCost adds to the total cost of the workflow, nodes running agents report their cost here
+func (s *State) AddCost(cost float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cost += cost
+}
+
+// Cost returns the total cost reported so far
+func (s *State) Cost() float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cost
+}
+
+// setOutput records the output of a completed node
+func (s *State) setOutput(nodeID string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.outputs[nodeID] = value
+}
@@ -0,0 +1,70 @@
+// Package x holds the least settled experimental API of the agent package: speculative prefetch and
+// consensus runners. It is not covered by the v1 compatibility guarantee and may change or be removed in
+// minor versions. Experiments graduate to the agent package once their API is settled.
+package x
+
+import (
+	"github.com/easyagent-dev/agent"
+	"github.com/easyagent-dev/llm"
+)
+
+// ResultPredictor predicts the result of a tool call before the tool returns
+type ResultPredictor = agent.ResultPredictor
+
+// ResultPredictorFunc adapts a function to the ResultPredictor interface
+type ResultPredictorFunc = agent.ResultPredictorFunc
+
+// SpeculationConfig configures speculative model calls
+type SpeculationConfig = agent.SpeculationConfig
+
+// SpeculationStats counts the speculative model calls of a run
+type SpeculationStats = agent.SpeculationStats
+
+// WithSpeculation enables speculative next-step prefetch, trading tokens for latency. While a tool
+// runs, the next model call is started with the result predicted by the config's predictor. The call
+// is used if the actual result matches and the next request is otherwise unchanged, and discarded
+// otherwise. Discarded calls still count in the usage and cost.
+func WithSpeculation(config SpeculationConfig) agent.RunnerOption {
+	return agent.WithSpeculation(config)
+}
+
+// ConsensusMode determines how the judge combines the candidates
+type ConsensusMode = agent.ConsensusMode
+
+const (
+	// ConsensusModeSelect makes the judge pick the best candidate
+	ConsensusModeSelect = agent.ConsensusModeSelect
+
+	// ConsensusModeMerge makes the judge write a new output merging the candidates
+	ConsensusModeMerge = agent.ConsensusModeMerge
+)
+
+// Judgement is the output of the judging pass. Choice is set in select mode, Output in merge mode.
+type Judgement = agent.Judgement
+
+// ConsensusOption is a functional option for configuring consensus runners
+type ConsensusOption = agent.ConsensusOption
+
+// ConsensusRunner runs the same request on several runners in parallel, then asks a judge
+// model to select or merge the best final output
+type ConsensusRunner = agent.ConsensusRunner
+
+// WithConsensusMode sets how the judge combines the candidates, defaults to ConsensusModeSelect
+func WithConsensusMode(mode ConsensusMode) ConsensusOption {
+	return agent.WithConsensusMode(mode)
+}
+
+// WithJudgeInstructions adds custom instructions to the judge prompt
+func WithJudgeInstructions(instructions string) ConsensusOption {
+	return agent.WithJudgeInstructions(instructions)
+}
+
+// NewConsensusRunner creates a consensus runner over several runners, e.g. different agents or models
+func NewConsensusRunner(runners []agent.Runner, judge llm.CompletionModel, opts ...ConsensusOption) (*ConsensusRunner, error) {
+	return agent.NewConsensusRunner(runners, judge, opts...)
+}
+
+// NewSampledConsensusRunner creates a consensus runner sampling the same runner n times
+func NewSampledConsensusRunner(runner agent.Runner, n int, judge llm.CompletionModel, opts ...ConsensusOption) (*ConsensusRunner, error) {
+	return agent.NewSampledConsensusRunner(runner, n, judge, opts...)
+}